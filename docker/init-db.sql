@@ -23,9 +23,26 @@ CREATE TABLE IF NOT EXISTS trading.users (
     is_active BOOLEAN DEFAULT true
 );
 
+-- Create API keys table
+CREATE TABLE IF NOT EXISTS trading.api_keys (
+    id VARCHAR(40) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES trading.users(id),
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    secret_hash CHAR(64) NOT NULL,
+    signing_key_encrypted TEXT,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Keys created before signing keys were stored can't sign requests
+ALTER TABLE trading.api_keys ADD COLUMN IF NOT EXISTS signing_key_encrypted TEXT;
+
 -- Create orders table
 CREATE TABLE IF NOT EXISTS trading.orders (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    client_order_id VARCHAR(64),
     user_id UUID NOT NULL REFERENCES trading.users(id),
     symbol VARCHAR(10) NOT NULL,
     side VARCHAR(4) NOT NULL CHECK (side IN ('BUY', 'SELL')),
@@ -33,10 +50,86 @@ CREATE TABLE IF NOT EXISTS trading.orders (
     quantity DECIMAL(20, 8) NOT NULL CHECK (quantity >= 0),
     price DECIMAL(20, 8) NOT NULL CHECK (price >= 0),
     status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    reject_reason VARCHAR(64),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Create order audit trail (append-only; one row per order state transition)
+CREATE TABLE IF NOT EXISTS trading.order_events (
+    id BIGSERIAL PRIMARY KEY,
+    order_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    side VARCHAR(4) NOT NULL,
+    order_type VARCHAR(10) NOT NULL,
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('ACCEPTED', 'PARTIALLY_FILLED', 'FILLED', 'AMENDED', 'CANCELLED', 'REJECTED')),
+    status VARCHAR(20) NOT NULL,
+    price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    quantity DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    trade_id UUID,
+    fill_quantity DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    fill_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    reason TEXT NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION trading.reject_order_event_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'order events are append-only: % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS order_events_immutable ON trading.order_events;
+CREATE TRIGGER order_events_immutable BEFORE UPDATE OR DELETE ON trading.order_events
+    FOR EACH ROW EXECUTE FUNCTION trading.reject_order_event_mutation();
+
+-- Create accounts table (cash per user)
+CREATE TABLE IF NOT EXISTS trading.accounts (
+    user_id UUID PRIMARY KEY REFERENCES trading.users(id),
+    cash_balance DECIMAL(20, 8) NOT NULL DEFAULT 0 CHECK (cash_balance >= 0),
+    cash_reserved DECIMAL(20, 8) NOT NULL DEFAULT 0 CHECK (cash_reserved >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create positions table (shares per user and symbol)
+CREATE TABLE IF NOT EXISTS trading.positions (
+    user_id UUID NOT NULL REFERENCES trading.users(id),
+    symbol VARCHAR(10) NOT NULL,
+    quantity DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    reserved_quantity DECIMAL(20, 8) NOT NULL DEFAULT 0 CHECK (reserved_quantity >= 0),
+    average_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, symbol)
+);
+
+-- Databases created before buying power checks need the reservation columns
+ALTER TABLE trading.accounts ADD COLUMN IF NOT EXISTS cash_reserved DECIMAL(20, 8) NOT NULL DEFAULT 0 CHECK (cash_reserved >= 0);
+ALTER TABLE trading.positions ADD COLUMN IF NOT EXISTS reserved_quantity DECIMAL(20, 8) NOT NULL DEFAULT 0 CHECK (reserved_quantity >= 0);
+ALTER TABLE trading.positions ADD COLUMN IF NOT EXISTS average_price DECIMAL(20, 8) NOT NULL DEFAULT 0;
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'accounts_cash_balance_check' AND conrelid = 'trading.accounts'::regclass
+    ) THEN
+        ALTER TABLE trading.accounts ADD CONSTRAINT accounts_cash_balance_check CHECK (cash_balance >= 0);
+    END IF;
+END $$;
+
+-- Create reservations table (cash or shares held for open orders)
+CREATE TABLE IF NOT EXISTS trading.reservations (
+    order_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES trading.users(id),
+    symbol VARCHAR(10) NOT NULL,
+    side VARCHAR(4) NOT NULL CHECK (side IN ('BUY', 'SELL')),
+    price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    remaining DECIMAL(20, 8) NOT NULL CHECK (remaining >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Create trades table
 CREATE TABLE IF NOT EXISTS trading.trades (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -45,9 +138,147 @@ CREATE TABLE IF NOT EXISTS trading.trades (
     symbol VARCHAR(10) NOT NULL,
     quantity DECIMAL(20, 8) NOT NULL,
     price DECIMAL(20, 8) NOT NULL,
+    taker_side VARCHAR(4) CHECK (taker_side IN ('BUY', 'SELL')),
+    buy_fee DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    sell_fee DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    maker_rebate DECIMAL(20, 8) NOT NULL DEFAULT 0,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Create candles table (continuously aggregated from trades; candles of
+-- trades stored before it existed are backfilled by the trading API at startup)
+CREATE TABLE IF NOT EXISTS trading.candles (
+    symbol VARCHAR(10) NOT NULL,
+    bucket_interval VARCHAR(4) NOT NULL CHECK (bucket_interval IN ('1m', '5m', '1h')),
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    open DECIMAL(20, 8) NOT NULL,
+    high DECIMAL(20, 8) NOT NULL,
+    low DECIMAL(20, 8) NOT NULL,
+    close DECIMAL(20, 8) NOT NULL,
+    volume DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    trade_count BIGINT NOT NULL DEFAULT 0,
+    first_trade_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_trade_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (symbol, bucket_interval, bucket_start)
+);
+
+-- Create settlement obligations table (trades netted per user, symbol and trade date)
+CREATE TABLE IF NOT EXISTS trading.settlement_obligations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trade_date DATE NOT NULL,
+    settlement_date DATE NOT NULL,
+    user_id UUID NOT NULL REFERENCES trading.users(id),
+    symbol VARCHAR(10) NOT NULL,
+    net_quantity DECIMAL(20, 8) NOT NULL,
+    net_cash DECIMAL(20, 8) NOT NULL,
+    gross_buy_quantity DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    gross_sell_quantity DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    gross_notional DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    trade_count INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SETTLED', 'FAILED')),
+    fail_count INTEGER NOT NULL DEFAULT 0,
+    fail_reason VARCHAR(32),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    settled_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (trade_date, user_id, symbol)
+);
+
+-- Create clearing runs table (trade dates that have been netted)
+CREATE TABLE IF NOT EXISTS trading.clearing_runs (
+    trade_date DATE PRIMARY KEY,
+    settlement_date DATE NOT NULL,
+    obligations INTEGER NOT NULL DEFAULT 0,
+    cleared_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create designated market maker tables
+CREATE TABLE IF NOT EXISTS trading.market_makers (
+    user_id UUID NOT NULL REFERENCES trading.users(id),
+    symbol VARCHAR(10) NOT NULL,
+    max_spread_bps DECIMAL(10, 4) NOT NULL,
+    min_quote_size DECIMAL(20, 8) NOT NULL,
+    min_uptime DECIMAL(5, 4) NOT NULL,
+    rebate_bps DECIMAL(10, 4) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    registered_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, symbol)
+);
+
+-- Daily quote samples per market maker (spread and size are summed over two-sided samples)
+CREATE TABLE IF NOT EXISTS trading.market_maker_stats (
+    user_id UUID NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    trade_date DATE NOT NULL,
+    samples INTEGER NOT NULL DEFAULT 0,
+    quoted_samples INTEGER NOT NULL DEFAULT 0,
+    compliant_samples INTEGER NOT NULL DEFAULT 0,
+    spread_bps_sum DECIMAL(20, 4) NOT NULL DEFAULT 0,
+    quote_size_sum DECIMAL(28, 8) NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, symbol, trade_date),
+    FOREIGN KEY (user_id, symbol) REFERENCES trading.market_makers(user_id, symbol)
+);
+
+-- Create market surveillance tables
+CREATE TABLE IF NOT EXISTS trading.surveillance_alerts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    alert_type VARCHAR(20) NOT NULL CHECK (alert_type IN ('WASH_TRADE', 'SPOOFING', 'LAYERING', 'MOMENTUM_IGNITION', 'MARKING_THE_CLOSE')),
+    symbol VARCHAR(10) NOT NULL,
+    user_id UUID NOT NULL,
+    counterparty_id UUID,
+    trade_id UUID,
+    order_ids TEXT[] NOT NULL DEFAULT '{}',
+    quantity DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    description TEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'DISMISSED', 'ESCALATED')),
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    reviewed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Databases created before marking the close was detected need the wider alert type check
+ALTER TABLE trading.surveillance_alerts DROP CONSTRAINT IF EXISTS surveillance_alerts_alert_type_check;
+ALTER TABLE trading.surveillance_alerts ADD CONSTRAINT surveillance_alerts_alert_type_check
+    CHECK (alert_type IN ('WASH_TRADE', 'SPOOFING', 'LAYERING', 'MOMENTUM_IGNITION', 'MARKING_THE_CLOSE'));
+
+-- Accounts that share a beneficial owner; accounts not listed are their own owner
+CREATE TABLE IF NOT EXISTS trading.beneficial_owners (
+    user_id UUID PRIMARY KEY REFERENCES trading.users(id),
+    owner_id UUID NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create double-entry ledger tables (append-only; entries of a journal sum to zero per asset)
+CREATE TABLE IF NOT EXISTS trading.ledger_journals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    journal_type VARCHAR(16) NOT NULL CHECK (journal_type IN ('TRADE', 'FEE', 'DEPOSIT', 'WITHDRAWAL')),
+    reference VARCHAR(64) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS trading.ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    journal_id UUID NOT NULL REFERENCES trading.ledger_journals(id),
+    account VARCHAR(64) NOT NULL,
+    asset VARCHAR(10) NOT NULL,
+    amount DECIMAL(28, 8) NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION trading.reject_ledger_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only: % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_journals_immutable ON trading.ledger_journals;
+CREATE TRIGGER ledger_journals_immutable BEFORE UPDATE OR DELETE ON trading.ledger_journals
+    FOR EACH ROW EXECUTE FUNCTION trading.reject_ledger_mutation();
+
+DROP TRIGGER IF EXISTS ledger_entries_immutable ON trading.ledger_entries;
+CREATE TRIGGER ledger_entries_immutable BEFORE UPDATE OR DELETE ON trading.ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION trading.reject_ledger_mutation();
+
 -- Create performance metrics table
 CREATE TABLE IF NOT EXISTS analytics.performance_metrics (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -81,9 +312,24 @@ CREATE INDEX IF NOT EXISTS idx_orders_user_id ON trading.orders(user_id);
 CREATE INDEX IF NOT EXISTS idx_orders_symbol ON trading.orders(symbol);
 CREATE INDEX IF NOT EXISTS idx_orders_status ON trading.orders(status);
 CREATE INDEX IF NOT EXISTS idx_orders_created_at ON trading.orders(created_at);
+CREATE INDEX IF NOT EXISTS idx_order_events_order_id ON trading.order_events(order_id, id);
+CREATE INDEX IF NOT EXISTS idx_order_events_created_at ON trading.order_events(created_at);
+-- Databases created before client order IDs need the column for the index
+ALTER TABLE trading.orders ADD COLUMN IF NOT EXISTS client_order_id VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_user_client_order_id ON trading.orders(user_id, client_order_id) WHERE client_order_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON trading.api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_user_id ON trading.reservations(user_id);
 
 CREATE INDEX IF NOT EXISTS idx_trades_symbol ON trading.trades(symbol);
 CREATE INDEX IF NOT EXISTS idx_trades_created_at ON trading.trades(created_at);
+CREATE INDEX IF NOT EXISTS idx_trades_symbol_created_at_id ON trading.trades(symbol, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_journal_id ON trading.ledger_entries(journal_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_asset ON trading.ledger_entries(account, asset);
+CREATE INDEX IF NOT EXISTS idx_ledger_journals_reference ON trading.ledger_journals(journal_type, reference);
+CREATE INDEX IF NOT EXISTS idx_surveillance_alerts_detected_at ON trading.surveillance_alerts(detected_at DESC);
+CREATE INDEX IF NOT EXISTS idx_surveillance_alerts_user_id ON trading.surveillance_alerts(user_id, detected_at DESC);
+CREATE INDEX IF NOT EXISTS idx_settlement_obligations_due ON trading.settlement_obligations(settlement_date) WHERE status <> 'SETTLED';
 
 CREATE INDEX IF NOT EXISTS idx_performance_metrics_window_start ON analytics.performance_metrics(window_start);
 CREATE INDEX IF NOT EXISTS idx_audit_user_id ON audit.activity_log(user_id);
@@ -94,4 +340,16 @@ INSERT INTO trading.users (username, email, password_hash) VALUES
 ('admin', 'admin@trading.local', '$2a$10$example_hash_here'),
 ('trader1', 'trader1@trading.local', '$2a$10$example_hash_here'),
 ('trader2', 'trader2@trading.local', '$2a$10$example_hash_here')
-ON CONFLICT (username) DO NOTHING;
\ No newline at end of file
+ON CONFLICT (username) DO NOTHING;
+
+-- Two accounts of one beneficial owner, used by the order flow simulator to
+-- inject manipulative behaviour for surveillance testing
+INSERT INTO trading.users (id, username, email, password_hash) VALUES
+('5b0c7e1a-6d2f-4c8e-9a3b-1f4e2d6c8a01', 'manipulator1', 'manipulator1@trading.local', '$2a$10$example_hash_here'),
+('5b0c7e1a-6d2f-4c8e-9a3b-1f4e2d6c8a02', 'manipulator2', 'manipulator2@trading.local', '$2a$10$example_hash_here')
+ON CONFLICT (username) DO NOTHING;
+
+INSERT INTO trading.beneficial_owners (user_id, owner_id) VALUES
+('5b0c7e1a-6d2f-4c8e-9a3b-1f4e2d6c8a01', '5b0c7e1a-6d2f-4c8e-9a3b-1f4e2d6c8a01'),
+('5b0c7e1a-6d2f-4c8e-9a3b-1f4e2d6c8a02', '5b0c7e1a-6d2f-4c8e-9a3b-1f4e2d6c8a01')
+ON CONFLICT (user_id) DO NOTHING;
\ No newline at end of file
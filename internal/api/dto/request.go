@@ -4,6 +4,7 @@ import "fmt"
 
 // PlaceOrderRequest represents the request body for placing an order
 type PlaceOrderRequest struct {
+	UserID   string  `json:"user_id,omitempty" binding:"max=64"`
 	Symbol   string  `json:"symbol" binding:"required,min=1,max=10"`
 	Side     string  `json:"side" binding:"required,oneof=buy sell"`
 	Type     string  `json:"type" binding:"required,oneof=limit market"`
@@ -25,7 +25,7 @@ type HandlerDependencies interface {
 
 // OrderService interface for business logic
 type OrderService interface {
-	PlaceOrder(orderID, symbol string, side, orderType string, quantity, price float64) error
+	PlaceOrder(orderID, userID, symbol string, side, orderType string, quantity, price float64) error
 	GetOrder(orderID string) (Order, error)
 	CancelOrder(orderID string) error
 	GetOrderBook(symbol string) (OrderBook, error)
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/pkg/risk"
 )
 
 // OrderHandlerImpl implements the OrderHandler interface
@@ -56,6 +58,7 @@ func (h *OrderHandlerImpl) PlaceOrder(c *gin.Context) {
 	// Call service layer
 	err := h.orderService.PlaceOrder(
 		orderID,
+		req.UserID,
 		req.Symbol,
 		req.Side,
 		req.Type,
@@ -63,6 +66,19 @@ func (h *OrderHandlerImpl) PlaceOrder(c *gin.Context) {
 		req.Price,
 	)
 
+	var rejection *risk.Rejection
+	if errors.As(err, &rejection) {
+		c.JSON(http.StatusUnprocessableEntity, dto.APIResponse{
+			Success: false,
+			Error: &dto.APIError{
+				Code:    rejection.Code,
+				Message: "Order rejected by risk checks",
+				Details: rejection.Reason,
+			},
+		})
+		return
+	}
+
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
 			Success: false,
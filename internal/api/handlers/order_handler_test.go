@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/pkg/risk"
 )
 
 // MockOrderService implements OrderService for testing
@@ -19,8 +21,8 @@ type MockOrderService struct {
 	mock.Mock
 }
 
-func (m *MockOrderService) PlaceOrder(orderID, symbol string, side, orderType string, quantity, price float64) error {
-	args := m.Called(orderID, symbol, side, orderType, quantity, price)
+func (m *MockOrderService) PlaceOrder(orderID, userID, symbol string, side, orderType string, quantity, price float64) error {
+	args := m.Called(orderID, userID, symbol, side, orderType, quantity, price)
 	return args.Error(0)
 }
 
@@ -64,7 +66,7 @@ func TestOrderHandlerImpl_PlaceOrder(t *testing.T) {
 				Price:    150.0,
 			},
 			mockSetup: func(m *MockOrderService) {
-				m.On("PlaceOrder", mock.AnythingOfType("string"), "AAPL", "buy", "limit", 100.0, 150.0).Return(nil)
+				m.On("PlaceOrder", mock.AnythingOfType("string"), "", "AAPL", "buy", "limit", 100.0, 150.0).Return(nil)
 			},
 			expectedStatus: http.StatusCreated,
 			expectedData:   true,
@@ -123,11 +125,28 @@ func TestOrderHandlerImpl_PlaceOrder(t *testing.T) {
 				Price:    150.0,
 			},
 			mockSetup: func(m *MockOrderService) {
-				m.On("PlaceOrder", mock.AnythingOfType("string"), "AAPL", "buy", "limit", 100.0, 150.0).Return(errors.New("service error"))
+				m.On("PlaceOrder", mock.AnythingOfType("string"), "", "AAPL", "buy", "limit", 100.0, 150.0).Return(errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedError:  stringPtr("ORDER_PLACEMENT_FAILED"),
 		},
+		{
+			name: "risk rejection",
+			requestBody: dto.PlaceOrderRequest{
+				UserID:   "alice",
+				Symbol:   "AAPL",
+				Side:     "buy",
+				Type:     "limit",
+				Quantity: 100,
+				Price:    150.0,
+			},
+			mockSetup: func(m *MockOrderService) {
+				m.On("PlaceOrder", mock.AnythingOfType("string"), "alice", "AAPL", "buy", "limit", 100.0, 150.0).
+					Return(fmt.Errorf("order rejected: %w", &risk.Rejection{Rule: risk.RuleOpenOrders, Code: "OPEN_ORDER_LIMIT", Reason: "too many open orders"}))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  stringPtr("OPEN_ORDER_LIMIT"),
+		},
 		{
 			name:        "invalid JSON",
 			requestBody: "invalid json",
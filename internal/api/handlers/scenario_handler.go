@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/internal/simulation"
+)
+
+// maxScenarioSize limits uploaded scenario files
+const maxScenarioSize = 1 << 20
+
+// ScenarioHandler defines HTTP endpoints for simulation scenarios
+type ScenarioHandler interface {
+	ListScenarios(c *gin.Context)
+	GetScenario(c *gin.Context)
+	GetScenarioSchema(c *gin.Context)
+	UploadScenario(c *gin.Context)
+}
+
+// ScenarioService interface for simulation patterns and scenario files
+type ScenarioService interface {
+	ListPatterns() []string
+	GetPattern(name string) *simulation.SimulationPattern
+	AddScenario(data []byte) (string, *simulation.SimulationPattern, error)
+}
+
+// ScenarioHandlerImpl implements the ScenarioHandler interface
+type ScenarioHandlerImpl struct {
+	scenarioService ScenarioService
+}
+
+// NewScenarioHandler creates a new scenario handler
+func NewScenarioHandler(scenarioService ScenarioService) ScenarioHandler {
+	return &ScenarioHandlerImpl{
+		scenarioService: scenarioService,
+	}
+}
+
+// ScenarioResponse is a pattern together with the id it is activated by
+type ScenarioResponse struct {
+	ID      string                        `json:"id"`
+	Pattern *simulation.SimulationPattern `json:"pattern"`
+}
+
+// ListScenarios handles GET /api/scenarios
+func (h *ScenarioHandlerImpl) ListScenarios(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    h.scenarioService.ListPatterns(),
+	})
+}
+
+// GetScenario handles GET /api/scenarios/:id
+func (h *ScenarioHandlerImpl) GetScenario(c *gin.Context) {
+	id := c.Param("id")
+
+	pattern := h.scenarioService.GetPattern(id)
+	if pattern == nil {
+		c.JSON(http.StatusNotFound, dto.APIResponse{
+			Success: false,
+			Error: &dto.APIError{
+				Code:    "SCENARIO_NOT_FOUND",
+				Message: "Scenario not found",
+				Details: id,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    ScenarioResponse{ID: id, Pattern: pattern},
+	})
+}
+
+// GetScenarioSchema handles GET /api/scenarios/schema
+func (h *ScenarioHandlerImpl) GetScenarioSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "application/schema+json", simulation.ScenarioSchema)
+}
+
+// UploadScenario handles POST /api/scenarios with a YAML or JSON scenario
+// as the body
+func (h *ScenarioHandlerImpl) UploadScenario(c *gin.Context) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxScenarioSize+1))
+	if err != nil || len(data) > maxScenarioSize {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Scenario must be at most 1 MiB",
+			},
+		})
+		return
+	}
+
+	id, pattern, err := h.scenarioService.AddScenario(data)
+	if err != nil {
+		apiErr := &dto.APIError{
+			Code:    "INVALID_SCENARIO",
+			Message: "Invalid scenario",
+			Details: err.Error(),
+		}
+		var simErr simulation.SimulationError
+		if errors.As(err, &simErr) {
+			apiErr.Details = simErr.Message
+		}
+
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   apiErr,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.APIResponse{
+		Success: true,
+		Data:    ScenarioResponse{ID: id, Pattern: pattern},
+	})
+}
@@ -21,8 +21,8 @@ type MockOrderService struct {
 	mock.Mock
 }
 
-func (m *MockOrderService) PlaceOrder(orderID, symbol string, side, orderType string, quantity, price float64) error {
-	args := m.Called(orderID, symbol, side, orderType, quantity, price)
+func (m *MockOrderService) PlaceOrder(orderID, userID, symbol string, side, orderType string, quantity, price float64) error {
+	args := m.Called(orderID, userID, symbol, side, orderType, quantity, price)
 	return args.Error(0)
 }
 
@@ -122,7 +122,7 @@ func TestAPI_IntegrationTests(t *testing.T) {
 	mockDemoController := new(MockDemoController)
 
 	// Create dependency container
-	deps := NewDependencyContainer(mockOrderService, mockMetricsService, mockDemoController)
+	deps := NewDependencyContainer(mockOrderService, mockMetricsService, mockDemoController, nil)
 
 	// Create server with test configuration
 	config := &Config{
@@ -137,7 +137,7 @@ func TestAPI_IntegrationTests(t *testing.T) {
 
 	t.Run("POST /api/orders - Place Order Integration", func(t *testing.T) {
 		// Setup mock expectation
-		mockOrderService.On("PlaceOrder", mock.AnythingOfType("string"), "AAPL", "buy", "limit", 100.0, 150.0).Return(nil)
+		mockOrderService.On("PlaceOrder", mock.AnythingOfType("string"), "", "AAPL", "buy", "limit", 100.0, 150.0).Return(nil)
 
 		// Prepare request
 		orderRequest := dto.PlaceOrderRequest{
@@ -363,7 +363,7 @@ func TestAPI_ErrorHandling_Integration(t *testing.T) {
 	mockDemoController := new(MockDemoController)
 
 	// Create dependency container
-	deps := NewDependencyContainer(mockOrderService, mockMetricsService, mockDemoController)
+	deps := NewDependencyContainer(mockOrderService, mockMetricsService, mockDemoController, nil)
 
 	// Create server
 	config := DefaultConfig()
@@ -448,7 +448,7 @@ func TestAPI_CORS_Integration(t *testing.T) {
 	mockDemoController := new(MockDemoController)
 
 	// Create dependency container
-	deps := NewDependencyContainer(mockOrderService, mockMetricsService, mockDemoController)
+	deps := NewDependencyContainer(mockOrderService, mockMetricsService, mockDemoController, nil)
 
 	// Create server
 	config := DefaultConfig()
@@ -497,7 +497,7 @@ func TestAPI_SecurityHeaders_Integration(t *testing.T) {
 	mockDemoController := new(MockDemoController)
 
 	// Create dependency container
-	deps := NewDependencyContainer(mockOrderService, mockMetricsService, mockDemoController)
+	deps := NewDependencyContainer(mockOrderService, mockMetricsService, mockDemoController, nil)
 
 	// Create server
 	config := DefaultConfig()
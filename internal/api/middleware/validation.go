@@ -75,23 +75,42 @@ func formatValidationError(err validator.FieldError) string {
 	}
 }
 
-// ContentTypeMiddleware ensures proper content type for API requests
-func ContentTypeMiddleware() gin.HandlerFunc {
+// ContentTypeMiddleware ensures proper content type for API requests. YAML
+// is also accepted by the routes in yamlRoutes, given as registered, e.g.
+// "/api/scenarios".
+func ContentTypeMiddleware(yamlRoutes ...string) gin.HandlerFunc {
+	acceptsYAML := make(map[string]bool, len(yamlRoutes))
+	for _, route := range yamlRoutes {
+		acceptsYAML[route] = true
+	}
+
 	return gin.HandlerFunc(func(c *gin.Context) {
 		// Only check content type for POST, PUT, PATCH requests
 		if c.Request.Method == "POST" || c.Request.Method == "PUT" || c.Request.Method == "PATCH" {
 			contentType := c.GetHeader("Content-Type")
-			if !strings.Contains(contentType, "application/json") {
-				c.JSON(http.StatusUnsupportedMediaType, dto.APIResponse{
-					Success: false,
-					Error: &dto.APIError{
-						Code:    "UNSUPPORTED_MEDIA_TYPE",
-						Message: "Content-Type must be application/json",
-					},
-				})
-				c.Abort()
+			if strings.Contains(contentType, "application/json") {
+				c.Next()
 				return
 			}
+
+			message := "Content-Type must be application/json"
+			if acceptsYAML[c.FullPath()] {
+				if strings.Contains(contentType, "yaml") {
+					c.Next()
+					return
+				}
+				message = "Content-Type must be application/json or application/yaml"
+			}
+
+			c.JSON(http.StatusUnsupportedMediaType, dto.APIResponse{
+				Success: false,
+				Error: &dto.APIError{
+					Code:    "UNSUPPORTED_MEDIA_TYPE",
+					Message: message,
+				},
+			})
+			c.Abort()
+			return
 		}
 		c.Next()
 	})
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentTypeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ContentTypeMiddleware("/api/scenarios"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/api/scenarios", ok)
+	router.POST("/api/orders", ok)
+	router.PATCH("/api/orders/:id", ok)
+	router.GET("/api/orders/:id", ok)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		status      int
+	}{
+		{"JSON order", http.MethodPost, "/api/orders", "application/json; charset=utf-8", http.StatusOK},
+		{"YAML order", http.MethodPost, "/api/orders", "application/yaml", http.StatusUnsupportedMediaType},
+		{"YAML amendment", http.MethodPatch, "/api/orders/o1", "application/x-yaml", http.StatusUnsupportedMediaType},
+		{"form order", http.MethodPost, "/api/orders", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"order without a content type", http.MethodPost, "/api/orders", "", http.StatusUnsupportedMediaType},
+		{"JSON scenario", http.MethodPost, "/api/scenarios", "application/json", http.StatusOK},
+		{"YAML scenario", http.MethodPost, "/api/scenarios", "application/yaml", http.StatusOK},
+		{"legacy YAML scenario", http.MethodPost, "/api/scenarios", "text/x-yaml", http.StatusOK},
+		{"plain text scenario", http.MethodPost, "/api/scenarios", "text/plain", http.StatusUnsupportedMediaType},
+		{"GET without a content type", http.MethodGet, "/api/orders/o1", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}"))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnsupportedMediaType {
+				assert.Contains(t, w.Body.String(), "UNSUPPORTED_MEDIA_TYPE")
+			}
+		})
+	}
+}
@@ -30,9 +30,10 @@ type Config struct {
 
 // Handlers holds all HTTP handlers
 type Handlers struct {
-	OrderHandler   handlers.OrderHandler
-	MetricsHandler handlers.MetricsHandler
-	DemoHandler    handlers.DemoHandler
+	OrderHandler    handlers.OrderHandler
+	MetricsHandler  handlers.MetricsHandler
+	DemoHandler     handlers.DemoHandler
+	ScenarioHandler handlers.ScenarioHandler // nil without a simulation
 }
 
 // Dependencies interface for dependency injection into server
@@ -40,6 +41,7 @@ type Dependencies interface {
 	GetOrderService() handlers.OrderService
 	GetMetricsService() handlers.MetricsService
 	GetDemoController() handlers.DemoController
+	GetScenarioService() handlers.ScenarioService
 }
 
 // NewServer creates a new server with dependency injection
@@ -57,10 +59,16 @@ func NewServer(deps Dependencies, config *Config) *Server {
 	metricsHandler := handlers.NewMetricsHandler(deps.GetMetricsService())
 	demoHandler := handlers.NewDemoHandler(deps.GetDemoController())
 
+	var scenarioHandler handlers.ScenarioHandler
+	if scenarioService := deps.GetScenarioService(); scenarioService != nil {
+		scenarioHandler = handlers.NewScenarioHandler(scenarioService)
+	}
+
 	handlers := &Handlers{
-		OrderHandler:   orderHandler,
-		MetricsHandler: metricsHandler,
-		DemoHandler:    demoHandler,
+		OrderHandler:    orderHandler,
+		MetricsHandler:  metricsHandler,
+		DemoHandler:     demoHandler,
+		ScenarioHandler: scenarioHandler,
 	}
 
 	server := &Server{
@@ -90,8 +98,8 @@ func (s *Server) setupMiddleware() {
 	// CORS middleware
 	s.router.Use(middleware.CORSMiddleware())
 
-	// Content type validation
-	s.router.Use(middleware.ContentTypeMiddleware())
+	// Content type validation; scenarios are also uploaded as YAML
+	s.router.Use(middleware.ContentTypeMiddleware("/api/scenarios"))
 
 	// Rate limiting
 	s.router.Use(middleware.RateLimitMiddleware())
@@ -148,6 +156,17 @@ func (s *Server) setupRoutes() {
 	api.GET("/metrics", s.handlers.MetricsHandler.GetMetrics)
 	api.GET("/health", s.handlers.MetricsHandler.GetHealth)
 
+	// Scenario endpoints, when the simulation runs
+	if s.handlers.ScenarioHandler != nil {
+		scenarios := api.Group("/scenarios")
+		{
+			scenarios.GET("", s.handlers.ScenarioHandler.ListScenarios)
+			scenarios.GET("/schema", s.handlers.ScenarioHandler.GetScenarioSchema)
+			scenarios.GET("/:id", s.handlers.ScenarioHandler.GetScenario)
+			scenarios.POST("", s.handlers.ScenarioHandler.UploadScenario)
+		}
+	}
+
 	// Demo endpoints
 	demo := s.router.Group("/demo")
 	{
@@ -237,17 +256,20 @@ func DefaultConfig() *Config {
 
 // DependencyContainer implements the Dependencies interface
 type DependencyContainer struct {
-	orderService   handlers.OrderService
-	metricsService handlers.MetricsService
-	demoController handlers.DemoController
+	orderService    handlers.OrderService
+	metricsService  handlers.MetricsService
+	demoController  handlers.DemoController
+	scenarioService handlers.ScenarioService
 }
 
-// NewDependencyContainer creates a new dependency container
-func NewDependencyContainer(orderService handlers.OrderService, metricsService handlers.MetricsService, demoController handlers.DemoController) Dependencies {
+// NewDependencyContainer creates a new dependency container. The scenario
+// service may be nil when the simulation is disabled.
+func NewDependencyContainer(orderService handlers.OrderService, metricsService handlers.MetricsService, demoController handlers.DemoController, scenarioService handlers.ScenarioService) Dependencies {
 	return &DependencyContainer{
-		orderService:   orderService,
-		metricsService: metricsService,
-		demoController: demoController,
+		orderService:    orderService,
+		metricsService:  metricsService,
+		demoController:  demoController,
+		scenarioService: scenarioService,
 	}
 }
 
@@ -264,4 +286,9 @@ func (dc *DependencyContainer) GetMetricsService() handlers.MetricsService {
 // GetDemoController returns the demo controller
 func (dc *DependencyContainer) GetDemoController() handlers.DemoController {
 	return dc.demoController
-}
\ No newline at end of file
+}
+
+// GetScenarioService returns the scenario service
+func (dc *DependencyContainer) GetScenarioService() handlers.ScenarioService {
+	return dc.scenarioService
+}
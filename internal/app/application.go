@@ -132,7 +132,7 @@ func (a *Application) startSimulation() error {
 	// Create simulation configuration
 	simConfig := simulation.SimulationConfig{
 		Symbols:              a.config.Simulation.Symbols,
-		SimulationDuration:   24 * time.Hour, // Run continuously
+		SimulationDuration:   a.config.Simulation.Duration,
 		TickInterval:         100 * time.Millisecond,
 		OrderGenerationRate:  a.config.Simulation.OrdersPerSecond,
 		PriceUpdateFrequency: 1 * time.Second,
@@ -149,7 +149,11 @@ func (a *Application) startSimulation() error {
 		},
 		MarketCondition:      simulation.MarketCondition("STEADY"),
 		EnablePatterns:       a.config.Simulation.EnableVolatility,
+		PatternProbabilities: a.config.Simulation.Patterns,
 		NewsEventFrequency:   a.config.Simulation.PatternInterval,
+		Deterministic:        a.config.Simulation.Deterministic,
+		Seed:                 a.config.Simulation.Seed,
+		EventCalendar:        a.container.GetEventCalendar(),
 	}
 
 	// Start simulation in background
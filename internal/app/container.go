@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"sync"
 	"time"
 
 	"simulated_exchange/internal/api"
@@ -15,12 +14,15 @@ import (
 	"simulated_exchange/internal/metrics"
 	"simulated_exchange/internal/simulation"
 	"simulated_exchange/internal/types"
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/seasonality"
 )
 
 // Container manages all application dependencies following dependency injection principles
 type Container struct {
 	config *config.Config
 	logger *slog.Logger
+	clock  clock.Clock
 
 	// Core services
 	orderService   OrderService
@@ -34,6 +36,8 @@ type Container struct {
 	marketSimulator MarketSimulator
 	priceGenerator  PriceGenerator
 	orderGenerator  OrderGenerator
+	patternManager  *simulation.PatternManager
+	eventCalendar   []simulation.ScheduledEvent
 
 	// API components
 	server *api.Server
@@ -42,7 +46,7 @@ type Container struct {
 
 // Service interfaces for dependency inversion
 type OrderService interface {
-	PlaceOrder(orderID, symbol string, side, orderType string, quantity, price float64) error
+	PlaceOrder(orderID, userID, symbol string, side, orderType string, quantity, price float64) error
 	GetOrder(orderID string) (handlers.Order, error)
 	CancelOrder(orderID string) error
 	GetOrderBook(symbol string) (handlers.OrderBook, error)
@@ -83,6 +87,7 @@ func NewContainer(cfg *config.Config, logger *slog.Logger) (*Container, error) {
 	container := &Container{
 		config: cfg,
 		logger: logger,
+		clock:  clock.New(cfg.Simulation.Clock),
 	}
 
 	if err := container.initializeServices(); err != nil {
@@ -123,14 +128,18 @@ func (c *Container) initializeServices() error {
 func (c *Container) initializeCoreServices() error {
 	c.logger.Info("Initializing core services")
 
-	// Initialize order service
-	c.orderService = NewMockOrderService(c.logger)
+	// Orders match on the in-memory engine behind the pre-trade risk checks
+	c.orderService = NewEngineOrderService(c.config.Risk, c.clock, c.logger)
 
 	// Initialize metrics service
-	c.metricsService = NewMockMetricsService(c.config.Metrics, c.logger)
+	metricsService := NewMockMetricsService(c.config.Metrics, c.logger)
+	metricsService.SetClock(c.clock)
+	c.metricsService = metricsService
 
 	// Initialize demo controller
-	c.demoController = demomock.NewController(c.logger)
+	demoController := demomock.NewController(c.logger)
+	demoController.SetClock(c.clock)
+	c.demoController = demoController
 
 	c.logger.Info("Core services initialized successfully")
 	return nil
@@ -140,6 +149,12 @@ func (c *Container) initializeCoreServices() error {
 func (c *Container) initializeSimulationComponents() error {
 	c.logger.Info("Initializing simulation components")
 
+	// Order arrivals and volatility follow the same seasonality
+	profile, err := seasonality.New(c.config.Simulation.Seasonality)
+	if err != nil {
+		return err
+	}
+
 	// Create price generator with config
 	priceConfig := simulation.PriceGeneratorConfig{
 		BaseVolatility:    0.02,
@@ -150,20 +165,25 @@ func (c *Container) initializeSimulationComponents() error {
 		MeanReversion:     0.3,
 		HistorySize:       1000,
 		RandomSeed:        time.Now().UnixNano(),
+		Models:            c.config.Simulation.PriceModels,
+		Correlation:       c.config.Simulation.Correlation,
 	}
-	c.priceGenerator = simulation.NewRealisticPriceGenerator(priceConfig)
+	priceGenerator := simulation.NewRealisticPriceGenerator(priceConfig)
+	priceGenerator.SetSeasonality(profile)
+	c.priceGenerator = priceGenerator
 
 	// Create order generator with config
 	orderConfig := simulation.OrderGeneratorConfig{
 		BaseOrderRate:     10.0,
-		MarketHoursBoost:  1.5,
 		VolatilityBoost:   2.0,
 		NewsEventBoost:    3.0,
 		UserTypeMix:       map[string]float64{"conservative": 0.4, "aggressive": 0.3, "momentum": 0.3},
 		RandomSeed:        time.Now().UnixNano(),
 		RealtimeMode:      true,
 	}
-	c.orderGenerator = simulation.NewRealisticOrderGenerator(orderConfig)
+	orderGenerator := simulation.NewRealisticOrderGenerator(orderConfig)
+	orderGenerator.SetSeasonality(profile)
+	c.orderGenerator = orderGenerator
 
 	// Create event generator with config
 	eventConfig := simulation.EventGeneratorConfig{
@@ -176,13 +196,39 @@ func (c *Container) initializeSimulationComponents() error {
 	// Create trading engine interface for simulation
 	tradingEngine := NewSimulationTradingEngine(c.orderService, c.logger)
 
-	// Create market simulator
-	c.marketSimulator = simulation.NewRealisticSimulator(
+	// Create market simulator; deterministic runs replace its clock with a
+	// virtual one
+	marketSimulator := simulation.NewRealisticSimulator(
 		c.priceGenerator,
 		c.orderGenerator,
 		eventGenerator,
 		tradingEngine,
 	)
+	marketSimulator.SetClock(c.clock)
+	c.marketSimulator = marketSimulator
+
+	// Add the scenario files to the standard patterns
+	c.patternManager = marketSimulator.Patterns()
+	if dir := c.config.Simulation.ScenarioDir; dir != "" {
+		scenarios, err := c.patternManager.LoadScenarios(dir)
+		if err != nil {
+			return err
+		}
+		c.logger.Info("Scenarios loaded", "dir", dir, "scenarios", scenarios)
+	}
+	if path := c.config.Simulation.EventCalendar; path != "" {
+		events, err := simulation.LoadEventCalendar(path)
+		if err != nil {
+			return err
+		}
+		c.eventCalendar = events
+		c.logger.Info("Event calendar loaded", "path", path, "events", len(events))
+	}
+	for name := range c.config.Simulation.Patterns {
+		if c.patternManager.GetPattern(name) == nil {
+			return fmt.Errorf("unknown simulation pattern: %s", name)
+		}
+	}
 
 	c.logger.Info("Simulation components initialized successfully")
 	return nil
@@ -221,7 +267,13 @@ func (c *Container) initializeAPIComponents() error {
 	c.logger.Info("Initializing API components")
 
 	// Create dependency container for API
-	deps := api.NewDependencyContainer(c.orderService, c.metricsService, c.demoController)
+	// Scenario endpoints need the simulation's patterns
+	var scenarioService handlers.ScenarioService
+	if c.patternManager != nil {
+		scenarioService = c.patternManager
+	}
+
+	deps := api.NewDependencyContainer(c.orderService, c.metricsService, c.demoController, scenarioService)
 
 	// Create server configuration
 	serverConfig := &api.Config{
@@ -270,6 +322,11 @@ func (c *Container) GetMarketSimulator() MarketSimulator {
 	return c.marketSimulator
 }
 
+// GetEventCalendar returns the scheduled market events of the simulation
+func (c *Container) GetEventCalendar() []simulation.ScheduledEvent {
+	return c.eventCalendar
+}
+
 // GetServer returns the API server
 func (c *Container) GetServer() *api.Server {
 	return c.server
@@ -319,71 +376,6 @@ func (c *Container) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// MockOrderService implements OrderService interface
-type MockOrderService struct {
-	orders map[string]handlers.Order
-	mutex  sync.RWMutex
-	logger *slog.Logger
-}
-
-func NewMockOrderService(logger *slog.Logger) *MockOrderService {
-	return &MockOrderService{
-		orders: make(map[string]handlers.Order),
-		logger: logger,
-	}
-}
-
-func (m *MockOrderService) PlaceOrder(orderID, symbol string, side, orderType string, quantity, price float64) error {
-	order := handlers.Order{
-		ID:       orderID,
-		Symbol:   symbol,
-		Side:     side,
-		Type:     orderType,
-		Quantity: quantity,
-		Price:    price,
-		Status:   "active",
-	}
-
-	m.mutex.Lock()
-	m.orders[orderID] = order
-	m.mutex.Unlock()
-
-	m.logger.Info("Order placed", "order_id", orderID, "symbol", symbol, "side", side, "quantity", quantity)
-	return nil
-}
-
-func (m *MockOrderService) GetOrder(orderID string) (handlers.Order, error) {
-	m.mutex.RLock()
-	order, exists := m.orders[orderID]
-	m.mutex.RUnlock()
-
-	if exists {
-		return order, nil
-	}
-	return handlers.Order{}, fmt.Errorf("order not found: %s", orderID)
-}
-
-func (m *MockOrderService) CancelOrder(orderID string) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	if order, exists := m.orders[orderID]; exists {
-		order.Status = "cancelled"
-		m.orders[orderID] = order
-		m.logger.Info("Order cancelled", "order_id", orderID)
-		return nil
-	}
-	return fmt.Errorf("order not found: %s", orderID)
-}
-
-func (m *MockOrderService) GetOrderBook(symbol string) (handlers.OrderBook, error) {
-	return handlers.OrderBook{
-		Symbol: symbol,
-		Bids:   []handlers.OrderBookEntry{},
-		Asks:   []handlers.OrderBookEntry{},
-	}, nil
-}
-
 // MockMetricsService implements MetricsService interface
 type MockMetricsService struct {
 	collector *metrics.RealTimeMetrics
@@ -411,6 +403,11 @@ func NewMockMetricsService(cfg config.MetricsConfig, logger *slog.Logger) *MockM
 	return service
 }
 
+// SetClock sets the clock metrics windows are measured on
+func (m *MockMetricsService) SetClock(clk clock.Clock) {
+	m.collector.SetClock(clk)
+}
+
 func (m *MockMetricsService) generateMockData() {
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
@@ -427,7 +424,6 @@ func (m *MockMetricsService) generateMockData() {
 			Type:      "limit",
 			Quantity:  m.getRandomQuantity(),
 			Price:     m.getRandomPrice(),
-			Timestamp: time.Now(),
 			Latency:   m.getRandomLatency(),
 		})
 
@@ -438,7 +434,6 @@ func (m *MockMetricsService) generateMockData() {
 				Symbol:      m.getRandomSymbol(),
 				Quantity:    m.getRandomQuantity(),
 				Price:       m.getRandomPrice(),
-				Timestamp:   time.Now(),
 				Latency:     m.getRandomLatency(),
 				BuyOrderID:  fmt.Sprintf("buy_order_%d", orderCount),
 				SellOrderID: fmt.Sprintf("sell_order_%d", orderCount+1),
@@ -548,6 +543,7 @@ func (s *SimulationTradingEngine) PlaceOrder(order dto.PlaceOrderRequest) (inter
 
 	err := s.orderService.PlaceOrder(
 		orderID,
+		order.UserID,
 		order.Symbol,
 		order.Side,
 		order.Type,
@@ -563,3 +559,38 @@ func (s *SimulationTradingEngine) PlaceOrder(order dto.PlaceOrderRequest) (inter
 	}, nil
 }
 
+// tradeReportingOrderService is an OrderService that reports the trades
+// each order executes
+type tradeReportingOrderService interface {
+	PlaceOrderWithTrades(orderID, userID, symbol string, side, orderType string, quantity, price float64) ([]types.Trade, error)
+}
+
+// PlaceOrderWithTrades places an order and reports the trades it executed.
+// With an order service that doesn't report trades it returns no order ID,
+// so deterministic runs don't hash trades.
+func (s *SimulationTradingEngine) PlaceOrderWithTrades(order dto.PlaceOrderRequest) (string, []simulation.ExecutedTrade, error) {
+	reporting, ok := s.orderService.(tradeReportingOrderService)
+	if !ok {
+		_, err := s.PlaceOrder(order)
+		return "", nil, err
+	}
+
+	orderID := fmt.Sprintf("sim_%d_%s", time.Now().UnixNano(), order.Symbol)
+	trades, err := reporting.PlaceOrderWithTrades(orderID, order.UserID, order.Symbol, order.Side, order.Type, order.Quantity, order.Price)
+	if err != nil {
+		return "", nil, err
+	}
+
+	executed := make([]simulation.ExecutedTrade, len(trades))
+	for i, trade := range trades {
+		executed[i] = simulation.ExecutedTrade{
+			Symbol:      trade.Symbol,
+			Price:       trade.Price,
+			Quantity:    trade.Quantity,
+			BuyOrderID:  trade.BuyOrderID,
+			SellOrderID: trade.SellOrderID,
+		}
+	}
+	return orderID, executed, nil
+}
+
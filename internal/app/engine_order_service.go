@@ -0,0 +1,149 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"simulated_exchange/internal/api/handlers"
+	"simulated_exchange/internal/engine"
+	"simulated_exchange/internal/repository"
+	"simulated_exchange/internal/types"
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
+)
+
+// EngineOrderService implements OrderService on the in-memory matching
+// engine. Orders run through the engine's pre-trade risk checks when they
+// are enabled.
+type EngineOrderService struct {
+	engine    *engine.TradingEngine
+	orderRepo *repository.MemoryOrderRepository
+
+	// orders remembers every accepted order so filled and cancelled orders
+	// can still be looked up after they leave the book
+	orders map[string]handlers.Order
+	mutex  sync.RWMutex
+	logger *slog.Logger
+}
+
+// NewEngineOrderService creates an order service on a new matching engine
+// timestamping orders with clk
+func NewEngineOrderService(riskConfig config.RiskConfig, clk clock.Clock, logger *slog.Logger) *EngineOrderService {
+	orderRepo := repository.NewMemoryOrderRepository()
+	tradeRepo := repository.NewMemoryTradeRepository()
+	tradingEngine := engine.NewTradingEngine(orderRepo, tradeRepo, engine.NewPriceTimeOrderMatcher(), engine.NewSimpleTradeExecutor(tradeRepo))
+	tradingEngine.SetClock(clk)
+	if riskConfig.Enabled {
+		tradingEngine.EnableRiskChecks(riskConfig, nil)
+	}
+
+	return &EngineOrderService{
+		engine:    tradingEngine,
+		orderRepo: orderRepo,
+		orders:    make(map[string]handlers.Order),
+		logger:    logger,
+	}
+}
+
+func (s *EngineOrderService) PlaceOrder(orderID, userID, symbol string, side, orderType string, quantity, price float64) error {
+	_, err := s.PlaceOrderWithTrades(orderID, userID, symbol, side, orderType, quantity, price)
+	return err
+}
+
+// PlaceOrderWithTrades places an order and returns the trades it executed,
+// in execution order
+func (s *EngineOrderService) PlaceOrderWithTrades(orderID, userID, symbol string, side, orderType string, quantity, price float64) ([]types.Trade, error) {
+	trades, err := s.engine.PlaceOrderWithTrades(types.Order{
+		ID:       orderID,
+		UserID:   userID,
+		Symbol:   symbol,
+		Side:     types.OrderSide(strings.ToUpper(side)),
+		Type:     types.OrderType(strings.ToUpper(orderType)),
+		Quantity: quantity,
+		Price:    price,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	order := handlers.Order{
+		ID:       orderID,
+		Symbol:   symbol,
+		Side:     side,
+		Type:     orderType,
+		Quantity: quantity,
+		Price:    price,
+		Status:   "filled",
+	}
+	if resting, err := s.orderRepo.GetByID(orderID); err == nil {
+		order.Quantity = resting.Quantity
+		order.Status = "active"
+	}
+
+	s.mutex.Lock()
+	s.orders[orderID] = order
+	s.mutex.Unlock()
+
+	s.logger.Info("Order placed", "order_id", orderID, "user_id", userID, "symbol", symbol, "side", side, "quantity", quantity)
+	return trades, nil
+}
+
+func (s *EngineOrderService) GetOrder(orderID string) (handlers.Order, error) {
+	s.mutex.RLock()
+	order, exists := s.orders[orderID]
+	s.mutex.RUnlock()
+
+	if !exists {
+		return handlers.Order{}, fmt.Errorf("order not found: %s", orderID)
+	}
+
+	// Resting orders shrink as they fill against later orders
+	if order.Status == "active" {
+		if resting, err := s.orderRepo.GetByID(orderID); err == nil {
+			order.Quantity = resting.Quantity
+		} else {
+			order.Status = "filled"
+		}
+	}
+	return order, nil
+}
+
+func (s *EngineOrderService) CancelOrder(orderID string) error {
+	if err := s.engine.CancelOrder(orderID); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	if order, exists := s.orders[orderID]; exists {
+		order.Status = "cancelled"
+		s.orders[orderID] = order
+	}
+	s.mutex.Unlock()
+
+	s.logger.Info("Order cancelled", "order_id", orderID)
+	return nil
+}
+
+func (s *EngineOrderService) GetOrderBook(symbol string) (handlers.OrderBook, error) {
+	book, err := s.engine.GetOrderBook(symbol)
+	if err != nil {
+		return handlers.OrderBook{}, err
+	}
+
+	return handlers.OrderBook{
+		Symbol: symbol,
+		Bids:   bookEntries(book.Bids),
+		Asks:   bookEntries(book.Asks),
+	}, nil
+}
+
+// bookEntries converts resting orders to order book entries
+func bookEntries(orders []types.Order) []handlers.OrderBookEntry {
+	entries := make([]handlers.OrderBookEntry, 0, len(orders))
+	for _, order := range orders {
+		entries = append(entries, handlers.OrderBookEntry{Price: order.Price, Quantity: order.Quantity})
+	}
+	return entries
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/internal/simulation"
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/risk"
+	"simulated_exchange/pkg/shared"
+)
+
+func TestEngineOrderService_MatchesOrders(t *testing.T) {
+	service := NewEngineOrderService(config.RiskConfig{}, clock.Real(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if err := service.PlaceOrder("sell", "bob", "AAPL", "sell", "limit", 10, 100); err != nil {
+		t.Fatalf("Failed to place sell order: %v", err)
+	}
+	if err := service.PlaceOrder("buy", "alice", "AAPL", "buy", "limit", 4, 100); err != nil {
+		t.Fatalf("Failed to place buy order: %v", err)
+	}
+
+	buy, err := service.GetOrder("buy")
+	if err != nil || buy.Status != "filled" {
+		t.Fatalf("Expected buy order to be filled, got %+v, %v", buy, err)
+	}
+	sell, err := service.GetOrder("sell")
+	if err != nil || sell.Status != "active" || sell.Quantity != 6 {
+		t.Fatalf("Expected 6 of the sell order to rest, got %+v, %v", sell, err)
+	}
+
+	book, err := service.GetOrderBook("AAPL")
+	if err != nil || len(book.Asks) != 1 || len(book.Bids) != 0 {
+		t.Fatalf("Expected one resting ask, got %+v, %v", book, err)
+	}
+
+	if err := service.CancelOrder("sell"); err != nil {
+		t.Fatalf("Failed to cancel order: %v", err)
+	}
+	if sell, _ := service.GetOrder("sell"); sell.Status != "cancelled" {
+		t.Fatalf("Expected sell order to be cancelled, got %s", sell.Status)
+	}
+}
+
+func TestSimulationTradingEngine_ReportsTrades(t *testing.T) {
+	service := NewEngineOrderService(config.RiskConfig{}, clock.Real(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	engine := NewSimulationTradingEngine(service, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	sellID, trades, err := engine.PlaceOrderWithTrades(dto.PlaceOrderRequest{UserID: "bob", Symbol: "AAPL", Side: "sell", Type: "limit", Quantity: 10, Price: 100})
+	if err != nil || len(trades) != 0 {
+		t.Fatalf("Expected the sell order to rest, got %+v, %v", trades, err)
+	}
+
+	buyID, trades, err := engine.PlaceOrderWithTrades(dto.PlaceOrderRequest{UserID: "alice", Symbol: "AAPL", Side: "buy", Type: "limit", Quantity: 4, Price: 100})
+	if err != nil {
+		t.Fatalf("Failed to place buy order: %v", err)
+	}
+	want := []simulation.ExecutedTrade{{Symbol: "AAPL", Price: 100, Quantity: 4, BuyOrderID: buyID, SellOrderID: sellID}}
+	if !reflect.DeepEqual(trades, want) {
+		t.Fatalf("Expected %+v, got %+v", want, trades)
+	}
+}
+
+func TestEngineOrderService_RiskChecks(t *testing.T) {
+	service := NewEngineOrderService(config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{MaxOpenOrders: 1},
+	}, clock.Real(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if err := service.PlaceOrder("1", "alice", "AAPL", "buy", "limit", 1, 90); err != nil {
+		t.Fatalf("Failed to place order: %v", err)
+	}
+
+	err := service.PlaceOrder("2", "alice", "AAPL", "buy", "limit", 1, 90)
+	var rejection *risk.Rejection
+	if !errors.As(err, &rejection) || rejection.Code != shared.ErrCodeOpenOrderLimit {
+		t.Fatalf("Expected open order limit rejection, got %v", err)
+	}
+	if _, err := service.GetOrder("2"); err == nil {
+		t.Fatal("Rejected orders must not be recorded")
+	}
+
+	// The limit is per user
+	if err := service.PlaceOrder("3", "bob", "AAPL", "buy", "limit", 1, 90); err != nil {
+		t.Fatalf("Failed to place order for another user: %v", err)
+	}
+}
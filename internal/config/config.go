@@ -6,6 +6,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	pkgconfig "simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/priceprocess"
 )
 
 // Config holds all application configuration
@@ -25,6 +28,9 @@ type Config struct {
 	// Simulation configuration
 	Simulation SimulationConfig `json:"simulation"`
 
+	// Risk sets the pre-trade limits the matching engine enforces
+	Risk pkgconfig.RiskConfig `json:"risk"`
+
 	// Health check configuration
 	Health HealthConfig `json:"health"`
 }
@@ -75,6 +81,37 @@ type SimulationConfig struct {
 	WorkerCount       int           `json:"worker_count"`
 	PatternInterval   time.Duration `json:"pattern_interval"`
 	EnableVolatility  bool          `json:"enable_volatility"`
+	Duration          time.Duration `json:"duration"`
+
+	// PriceModels selects the stochastic price process of each symbol
+	PriceModels pkgconfig.PriceModelsConfig `json:"price_models"`
+
+	// Correlation sets how the symbols' prices move together
+	Correlation pkgconfig.CorrelationConfig `json:"correlation"`
+
+	// Deterministic runs the simulation on a virtual clock, seeded with
+	// Seed, so that runs can be reproduced exactly
+	Deterministic bool  `json:"deterministic"`
+	Seed          int64 `json:"seed"`
+
+	// Clock sets how fast simulated time runs outside deterministic runs
+	Clock pkgconfig.ClockConfig `json:"clock"`
+
+	// Seasonality shapes order arrivals and volatility over the day, the
+	// week and holidays
+	Seasonality pkgconfig.SeasonalityConfig `json:"seasonality"`
+
+	// ScenarioDir holds YAML and JSON scenario files loaded as patterns at
+	// startup; empty loads none
+	ScenarioDir string `json:"scenario_dir"`
+
+	// EventCalendar is a YAML or JSON file of market events scheduled at
+	// exact times; empty schedules none
+	EventCalendar string `json:"event_calendar"`
+
+	// Patterns maps pattern names to the probability they activate at each
+	// pattern check
+	Patterns map[string]float64 `json:"patterns"`
 }
 
 // HealthConfig contains health check settings
@@ -125,7 +162,18 @@ func LoadConfig() (*Config, error) {
 			WorkerCount:      getIntOrDefault("SIMULATION_WORKERS", 4),
 			PatternInterval:  getDurationOrDefault("SIMULATION_PATTERN_INTERVAL", 5*time.Minute),
 			EnableVolatility: getBoolOrDefault("SIMULATION_ENABLE_VOLATILITY", true),
+			Duration:         getDurationOrDefault("SIMULATION_DURATION", 24*time.Hour),
+			PriceModels:      pkgconfig.PriceModelsFromEnv(),
+			Correlation:      pkgconfig.CorrelationFromEnv(),
+			Deterministic:    getBoolOrDefault("SIMULATION_DETERMINISTIC", false),
+			Seed:             int64(getIntOrDefault("SIMULATION_SEED", 1)),
+			Clock:            pkgconfig.ClockFromEnv(),
+			Seasonality:      pkgconfig.SeasonalityFromEnv(),
+			ScenarioDir:      getEnvOrDefault("SIMULATION_SCENARIO_DIR", ""),
+			EventCalendar:    getEnvOrDefault("SIMULATION_EVENT_CALENDAR", ""),
+			Patterns:         getFloatMapOrDefault("SIMULATION_PATTERNS", map[string]float64{}),
 		},
+		Risk: pkgconfig.RiskFromEnv(),
 		Health: HealthConfig{
 			Enabled:       getBoolOrDefault("HEALTH_ENABLED", true),
 			CheckInterval: getDurationOrDefault("HEALTH_CHECK_INTERVAL", 30*time.Second),
@@ -163,6 +211,39 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("simulation symbols are required when simulation is enabled")
 	}
 
+	if c.Simulation.Enabled && c.Simulation.Duration <= 0 {
+		return fmt.Errorf("simulation duration must be positive")
+	}
+
+	if err := priceprocess.ValidateModels(c.Simulation.PriceModels); err != nil {
+		return err
+	}
+
+	if err := c.Simulation.Correlation.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Simulation.Clock.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Simulation.Seasonality.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Risk.Validate(); err != nil {
+		return err
+	}
+
+	for name, probability := range c.Simulation.Patterns {
+		if probability < 0 || probability > 1 {
+			return fmt.Errorf("probability of pattern %s must be between 0 and 1", name)
+		}
+	}
+	if _, err := priceprocess.NewCorrelation(c.Simulation.Correlation, c.Simulation.Symbols); err != nil {
+		return fmt.Errorf("invalid price correlation: %w", err)
+	}
+
 	return nil
 }
 
@@ -226,4 +307,25 @@ func getStringSliceOrDefault(key string, defaultValue []string) []string {
 		return strings.Split(value, ",")
 	}
 	return defaultValue
+}
+
+// getFloatMapOrDefault reads comma separated name=value pairs, skipping
+// invalid ones
+func getFloatMapOrDefault(key string, defaultValue map[string]float64) map[string]float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	values := make(map[string]float64)
+	for _, entry := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+			values[strings.TrimSpace(name)] = parsed
+		}
+	}
+	return values
 }
\ No newline at end of file
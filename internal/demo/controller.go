@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/pkg/clock"
 )
 
 // StandardDemoController implements the DemoController interface
@@ -16,6 +17,7 @@ type StandardDemoController struct {
 	scenarioManager  ScenarioManager
 	tradingEngine    TradingEngineIntegration
 	logger           *slog.Logger
+	clock            clock.Clock
 
 	// State management
 	mu                sync.RWMutex
@@ -50,6 +52,7 @@ func NewStandardDemoController(
 		scenarioManager: scenarioManager,
 		tradingEngine:   tradingEngine,
 		logger:          logger,
+		clock:           clock.Real(),
 		subscribers:     make(map[string]DemoSubscriber),
 		updateChan:      make(chan DemoUpdate, 1000),
 		stopLoadTest:    make(chan struct{}),
@@ -83,6 +86,23 @@ func NewStandardDemoController(
 	return controller
 }
 
+// SetClock sets the clock load and chaos tests measure their duration and
+// progress on, so a scenario can run on scaled time
+func (dc *StandardDemoController) SetClock(clk clock.Clock) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	dc.clock = clk
+}
+
+// getClock returns the current clock
+func (dc *StandardDemoController) getClock() clock.Clock {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+
+	return dc.clock
+}
+
 // StartLoadTest begins a load testing scenario
 func (dc *StandardDemoController) StartLoadTest(ctx context.Context, scenario LoadTestScenario) error {
 	dc.mu.Lock()
@@ -98,7 +118,7 @@ func (dc *StandardDemoController) StartLoadTest(ctx context.Context, scenario Lo
 	dc.loadTestStatus = &LoadTestStatus{
 		IsRunning:       true,
 		Scenario:        &scenario,
-		StartTime:       time.Now(),
+		StartTime:       dc.clock.Now(),
 		Progress:        0.0,
 		Phase:           LoadPhaseRampUp,
 		CurrentMetrics:  &LoadTestMetrics{},
@@ -170,7 +190,7 @@ func (dc *StandardDemoController) GetLoadTestStatus(ctx context.Context) (*LoadT
 	// Create a copy to avoid race conditions
 	status := *dc.loadTestStatus
 	if dc.loadTestStatus.IsRunning {
-		status.ElapsedTime = time.Since(dc.loadTestStatus.StartTime)
+		status.ElapsedTime = dc.clock.Since(dc.loadTestStatus.StartTime)
 		status.RemainingTime = dc.loadTestStatus.Scenario.Duration - status.ElapsedTime
 		if dc.loadTestStatus.Scenario.Duration > 0 {
 			status.Progress = float64(status.ElapsedTime) / float64(dc.loadTestStatus.Scenario.Duration) * 100
@@ -195,7 +215,7 @@ func (dc *StandardDemoController) TriggerChaosTest(ctx context.Context, scenario
 	dc.chaosTestStatus = &ChaosTestStatus{
 		IsRunning:     true,
 		Scenario:      &scenario,
-		StartTime:     time.Now(),
+		StartTime:     dc.clock.Now(),
 		Progress:      0.0,
 		Phase:         ChaosPhaseInjection,
 		AffectedTargets: []string{},
@@ -263,7 +283,7 @@ func (dc *StandardDemoController) GetChaosTestStatus(ctx context.Context) (*Chao
 	// Create a copy to avoid race conditions
 	status := *dc.chaosTestStatus
 	if dc.chaosTestStatus.IsRunning {
-		status.ElapsedTime = time.Since(dc.chaosTestStatus.StartTime)
+		status.ElapsedTime = dc.clock.Since(dc.chaosTestStatus.StartTime)
 		status.RemainingTime = dc.chaosTestStatus.Scenario.Duration - status.ElapsedTime
 		if dc.chaosTestStatus.Scenario.Duration > 0 {
 			status.Progress = float64(status.ElapsedTime) / float64(dc.chaosTestStatus.Scenario.Duration) * 100
@@ -395,10 +415,11 @@ func (dc *StandardDemoController) BroadcastUpdate(update DemoUpdate) error {
 func (dc *StandardDemoController) executeLoadTest(ctx context.Context, scenario LoadTestScenario) {
 	dc.logger.Info("Executing load test", "scenario", scenario.Name)
 
-	ticker := time.NewTicker(time.Second)
+	clk := dc.getClock()
+	ticker := clk.NewTicker(time.Second)
 	defer ticker.Stop()
 
-	startTime := time.Now()
+	startTime := clk.Now()
 	orderCounter := 0
 
 	for {
@@ -409,8 +430,8 @@ func (dc *StandardDemoController) executeLoadTest(ctx context.Context, scenario
 		case <-ctx.Done():
 			dc.logger.Info("Load test stopped by context cancellation")
 			return
-		case <-ticker.C:
-			elapsed := time.Since(startTime)
+		case <-ticker.C():
+			elapsed := clk.Since(startTime)
 			if elapsed >= scenario.Duration {
 				dc.logger.Info("Load test completed", "duration", elapsed)
 				dc.completeLoadTest()
@@ -439,10 +460,11 @@ func (dc *StandardDemoController) executeChaosTest(ctx context.Context, scenario
 	// Sustained phase
 	dc.updateChaosTestPhase(ChaosPhaseSustained)
 
-	ticker := time.NewTicker(time.Second)
+	clk := dc.getClock()
+	ticker := clk.NewTicker(time.Second)
 	defer ticker.Stop()
 
-	startTime := time.Now()
+	startTime := clk.Now()
 
 	for {
 		select {
@@ -454,8 +476,8 @@ func (dc *StandardDemoController) executeChaosTest(ctx context.Context, scenario
 			dc.logger.Info("Chaos test stopped by context cancellation")
 			dc.recoverFromChaos(scenario)
 			return
-		case <-ticker.C:
-			elapsed := time.Since(startTime)
+		case <-ticker.C():
+			elapsed := clk.Since(startTime)
 			if elapsed >= scenario.Duration {
 				dc.logger.Info("Chaos test completed", "duration", elapsed)
 				dc.recoverFromChaos(scenario)
@@ -496,7 +518,7 @@ func (dc *StandardDemoController) calculateCurrentLoad(scenario LoadTestScenario
 		return scenario.OrdersPerSecond
 	}
 
-	elapsed := time.Since(dc.loadTestStatus.StartTime)
+	elapsed := dc.getClock().Since(dc.loadTestStatus.StartTime)
 	rampUpProgress := float64(elapsed) / float64(scenario.RampUp.Duration)
 
 	if rampUpProgress >= 1.0 {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"simulated_exchange/internal/demo"
+	"simulated_exchange/pkg/clock"
 )
 
 // Controller implements demo.DemoController interface for testing
@@ -16,6 +17,7 @@ type Controller struct {
 	loadTestStatus  *demo.LoadTestStatus
 	chaosTestStatus *demo.ChaosTestStatus
 	systemStatus    *demo.DemoSystemStatus
+	clock           clock.Clock
 	mutex           sync.RWMutex
 	ctx             context.Context
 	cancel          context.CancelFunc
@@ -27,6 +29,7 @@ func NewController(logger *slog.Logger) *Controller {
 
 	return &Controller{
 		logger: logger,
+		clock:  clock.Real(),
 		ctx:    ctx,
 		cancel: cancel,
 		loadTestStatus: &demo.LoadTestStatus{
@@ -62,6 +65,14 @@ func NewController(logger *slog.Logger) *Controller {
 	}
 }
 
+// SetClock sets the clock simulated load and chaos tests run on
+func (m *Controller) SetClock(clk clock.Clock) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.clock = clk
+}
+
 func (m *Controller) StartLoadTest(ctx context.Context, scenario demo.LoadTestScenario) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -77,9 +88,9 @@ func (m *Controller) StartLoadTest(ctx context.Context, scenario demo.LoadTestSc
 		Phase:      demo.LoadPhaseRampUp,
 		Progress:   0,
 		Scenario:   &scenario,
-		StartTime:  time.Now(),
+		StartTime:  m.clock.Now(),
 		CurrentMetrics: &demo.LoadTestMetrics{
-			Timestamp:       time.Now(),
+			Timestamp:       m.clock.Now(),
 			OrdersPerSecond: 0,
 			AverageLatency:  0,
 			ErrorRate:       0,
@@ -94,12 +105,12 @@ func (m *Controller) StartLoadTest(ctx context.Context, scenario demo.LoadTestSc
 
 	// Start background load test simulation with internal context
 	// Don't use the request context as it will be cancelled when the HTTP request completes
-	go m.simulateLoadTest(m.ctx, scenario)
+	go m.simulateLoadTest(m.ctx, scenario, m.clock)
 
 	return nil
 }
 
-func (m *Controller) simulateLoadTest(ctx context.Context, scenario demo.LoadTestScenario) {
+func (m *Controller) simulateLoadTest(ctx context.Context, scenario demo.LoadTestScenario, clk clock.Clock) {
 	defer func() {
 		m.mutex.Lock()
 		m.loadTestStatus.IsRunning = false
@@ -109,8 +120,8 @@ func (m *Controller) simulateLoadTest(ctx context.Context, scenario demo.LoadTes
 		m.logger.Info("Load test completed")
 	}()
 
-	startTime := time.Now()
-	ticker := time.NewTicker(1 * time.Second)
+	startTime := clk.Now()
+	ticker := clk.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
@@ -119,8 +130,8 @@ func (m *Controller) simulateLoadTest(ctx context.Context, scenario demo.LoadTes
 			return
 		case <-m.ctx.Done():
 			return
-		case <-ticker.C:
-			elapsed := time.Since(startTime)
+		case <-ticker.C():
+			elapsed := clk.Since(startTime)
 			if elapsed >= scenario.Duration {
 				return
 			}
@@ -142,7 +153,7 @@ func (m *Controller) simulateLoadTest(ctx context.Context, scenario demo.LoadTes
 
 			// Simulate metrics
 			if m.loadTestStatus.CurrentMetrics != nil {
-				m.loadTestStatus.CurrentMetrics.Timestamp = time.Now()
+				m.loadTestStatus.CurrentMetrics.Timestamp = clk.Now()
 				m.loadTestStatus.CurrentMetrics.OrdersPerSecond = float64(scenario.OrdersPerSecond)
 				m.loadTestStatus.CurrentMetrics.AverageLatency = 20 + float64(time.Now().UnixNano()%30)
 				m.loadTestStatus.CurrentMetrics.P95Latency = m.loadTestStatus.CurrentMetrics.AverageLatency + 10
@@ -198,7 +209,7 @@ func (m *Controller) TriggerChaosTest(ctx context.Context, scenario demo.ChaosTe
 		IsRunning:       true,
 		Phase:           demo.ChaosPhaseInjection,
 		Scenario:        &scenario,
-		StartTime:       time.Now(),
+		StartTime:       m.clock.Now(),
 		Metrics: &demo.ChaosTestMetrics{
 			ServiceDegradation: 0,
 			ResilienceScore:    1.0,
@@ -209,12 +220,12 @@ func (m *Controller) TriggerChaosTest(ctx context.Context, scenario demo.ChaosTe
 	}
 
 	// Start background chaos test simulation
-	go m.simulateChaosTest(m.ctx, scenario)
+	go m.simulateChaosTest(m.ctx, scenario, m.clock)
 
 	return nil
 }
 
-func (m *Controller) simulateChaosTest(ctx context.Context, scenario demo.ChaosTestScenario) {
+func (m *Controller) simulateChaosTest(ctx context.Context, scenario demo.ChaosTestScenario, clk clock.Clock) {
 	defer func() {
 		m.mutex.Lock()
 		m.chaosTestStatus.IsRunning = false
@@ -223,8 +234,8 @@ func (m *Controller) simulateChaosTest(ctx context.Context, scenario demo.ChaosT
 		m.logger.Info("Chaos test completed")
 	}()
 
-	startTime := time.Now()
-	ticker := time.NewTicker(1 * time.Second)
+	startTime := clk.Now()
+	ticker := clk.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
@@ -233,8 +244,8 @@ func (m *Controller) simulateChaosTest(ctx context.Context, scenario demo.ChaosT
 			return
 		case <-m.ctx.Done():
 			return
-		case <-ticker.C:
-			elapsed := time.Since(startTime)
+		case <-ticker.C():
+			elapsed := clk.Since(startTime)
 			if elapsed >= scenario.Duration {
 				return
 			}
@@ -270,7 +281,7 @@ func (m *Controller) simulateChaosTest(ctx context.Context, scenario demo.ChaosT
 					m.chaosTestStatus.Metrics.ResilienceScore = 1.0 - (0.1 * progress)
 				}
 
-				m.chaosTestStatus.Metrics.Timestamp = time.Now()
+				m.chaosTestStatus.Metrics.Timestamp = clk.Now()
 			}
 
 			m.mutex.Unlock()
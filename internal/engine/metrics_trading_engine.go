@@ -48,7 +48,7 @@ func (mte *MetricsTradingEngine) PlaceOrder(order types.Order) error {
 			Type:      order.Type,
 			Quantity:  order.Quantity,
 			Price:     order.Price,
-			Timestamp: time.Now(),
+			Timestamp: order.Timestamp, // the collector's clock stamps unstamped orders
 			Latency:   latency,
 		}
 		mte.metricsService.RecordOrderEvent(event)
@@ -76,7 +76,6 @@ func (mte *MetricsTradingEngine) CancelOrder(id string) error {
 			Type:      "CANCEL",
 			Quantity:  0,
 			Price:     0,
-			Timestamp: time.Now(),
 			Latency:   latency,
 		}
 		mte.metricsService.RecordOrderEvent(event)
@@ -121,7 +120,7 @@ func (mte *MetricsTradeExecutor) ExecuteTrade(buyOrder types.Order, sellOrder ty
 			Symbol:      trade.Symbol,
 			Quantity:    trade.Quantity,
 			Price:       trade.Price,
-			Timestamp:   time.Now(),
+			Timestamp:   trade.Timestamp,
 			Latency:     latency,
 			BuyOrderID:  trade.BuyOrderID,
 			SellOrderID: trade.SellOrderID,
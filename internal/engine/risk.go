@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+
+	"simulated_exchange/internal/types"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/risk"
+)
+
+// positionKey identifies a user's position in a symbol
+type positionKey struct {
+	userID string
+	symbol string
+}
+
+// EnableRiskChecks runs every order through the pre-trade risk checks before
+// it is saved and matched. With no checks the default checks are used. A nil
+// recorder disables rejection metrics.
+func (te *TradingEngine) EnableRiskChecks(cfg config.RiskConfig, recorder risk.Recorder, checks ...risk.Check) {
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+
+	te.risk = risk.NewPipeline("trading-engine", cfg, &engineRiskState{engine: te}, recorder, nil, checks...)
+}
+
+// checkRisk runs the order through the risk pipeline, if one is enabled.
+// Must be called with the mutex held.
+func (te *TradingEngine) checkRisk(order types.Order) error {
+	if te.risk == nil {
+		return nil
+	}
+
+	return te.risk.Check(context.Background(), &risk.Order{
+		UserID:   order.UserID,
+		Symbol:   order.Symbol,
+		Side:     string(order.Side),
+		Market:   order.Type == types.Market,
+		Quantity: order.Quantity,
+		Price:    order.Price,
+	})
+}
+
+// recordFill updates the last price and the positions of both users after a
+// trade. Must be called with the mutex held.
+func (te *TradingEngine) recordFill(match types.Match, trade types.Trade) {
+	te.lastPrices[trade.Symbol] = trade.Price
+	te.positions[positionKey{userID: match.BuyOrder.UserID, symbol: trade.Symbol}] += trade.Quantity
+	te.positions[positionKey{userID: match.SellOrder.UserID, symbol: trade.Symbol}] -= trade.Quantity
+}
+
+// trackOpenOrder adjusts the number of orders a user has resting in the
+// book. Must be called with the mutex held.
+func (te *TradingEngine) trackOpenOrder(userID string, delta int) {
+	te.openOrders[userID] += delta
+	if te.openOrders[userID] <= 0 {
+		delete(te.openOrders, userID)
+	}
+}
+
+// engineRiskState implements risk.State from the engine's resting order counts
+// and the fills it has executed. Its methods are called with the engine mutex held.
+type engineRiskState struct {
+	engine *TradingEngine
+}
+
+func (s *engineRiskState) OpenOrderCount(ctx context.Context, userID string) (int, error) {
+	return s.engine.openOrders[userID], nil
+}
+
+func (s *engineRiskState) Position(ctx context.Context, userID, symbol string) (float64, error) {
+	return s.engine.positions[positionKey{userID: userID, symbol: symbol}], nil
+}
+
+func (s *engineRiskState) LastPrice(ctx context.Context, symbol string) (float64, error) {
+	return s.engine.lastPrices[symbol], nil
+}
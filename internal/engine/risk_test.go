@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/internal/repository"
+	"simulated_exchange/internal/types"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/risk"
+	"simulated_exchange/pkg/shared"
+)
+
+type recordedRejection struct {
+	service string
+	rule    string
+}
+
+type mockRiskRecorder struct {
+	rejections []recordedRejection
+}
+
+func (m *mockRiskRecorder) RecordRiskRejection(service, rule string) {
+	m.rejections = append(m.rejections, recordedRejection{service: service, rule: rule})
+}
+
+func newRiskTestEngine(cfg config.RiskConfig) (*TradingEngine, *mockRiskRecorder) {
+	orderRepo := repository.NewMemoryOrderRepository()
+	tradeRepo := repository.NewMemoryTradeRepository()
+	engine := NewTradingEngine(orderRepo, tradeRepo, NewPriceTimeOrderMatcher(), NewSimpleTradeExecutor(tradeRepo))
+
+	recorder := &mockRiskRecorder{}
+	engine.EnableRiskChecks(cfg, recorder)
+	return engine, recorder
+}
+
+func requireRejection(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var rejection *risk.Rejection
+	require.Error(t, err)
+	require.True(t, errors.As(err, &rejection), "expected risk rejection, got %v", err)
+	assert.Equal(t, code, rejection.Code)
+}
+
+func limitOrder(id, userID string, side types.OrderSide, quantity, price float64) types.Order {
+	return types.Order{
+		ID:       id,
+		UserID:   userID,
+		Symbol:   "AAPL",
+		Side:     side,
+		Type:     types.Limit,
+		Quantity: quantity,
+		Price:    price,
+	}
+}
+
+func TestTradingEngine_Risk_OrderSizeAndNotional(t *testing.T) {
+	engine, recorder := newRiskTestEngine(config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{MaxOrderQuantity: 100, MaxOrderNotional: 10000},
+	})
+
+	err := engine.PlaceOrder(limitOrder("big", "alice", types.Buy, 101, 10))
+	requireRejection(t, err, shared.ErrCodeOrderSizeLimit)
+
+	err = engine.PlaceOrder(limitOrder("rich", "alice", types.Buy, 100, 150))
+	requireRejection(t, err, shared.ErrCodeOrderNotionalLimit)
+
+	require.NoError(t, engine.PlaceOrder(limitOrder("ok", "alice", types.Buy, 50, 150)))
+
+	orderBook, err := engine.GetOrderBook("AAPL")
+	require.NoError(t, err)
+	assert.Len(t, orderBook.Bids, 1, "rejected orders must not rest in the book")
+
+	assert.Equal(t, []recordedRejection{
+		{service: "trading-engine", rule: string(risk.RuleOrderSize)},
+		{service: "trading-engine", rule: string(risk.RuleOrderNotional)},
+	}, recorder.rejections)
+}
+
+func TestTradingEngine_Risk_PriceCollar(t *testing.T) {
+	engine, _ := newRiskTestEngine(config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{PriceCollar: 0.1},
+	})
+
+	// No last trade yet, so the first orders are not collared
+	require.NoError(t, engine.PlaceOrder(limitOrder("sell1", "bob", types.Sell, 10, 100)))
+	require.NoError(t, engine.PlaceOrder(limitOrder("buy1", "alice", types.Buy, 10, 100)))
+
+	err := engine.PlaceOrder(limitOrder("fat", "alice", types.Buy, 1, 1000))
+	requireRejection(t, err, shared.ErrCodePriceCollar)
+
+	require.NoError(t, engine.PlaceOrder(limitOrder("near", "alice", types.Buy, 1, 109)))
+}
+
+func TestTradingEngine_Risk_OpenOrdersAndPosition(t *testing.T) {
+	engine, _ := newRiskTestEngine(config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{MaxOpenOrders: 2, MaxPosition: 15},
+	})
+
+	require.NoError(t, engine.PlaceOrder(limitOrder("b1", "alice", types.Buy, 1, 90)))
+	require.NoError(t, engine.PlaceOrder(limitOrder("b2", "alice", types.Buy, 1, 91)))
+
+	err := engine.PlaceOrder(limitOrder("b3", "alice", types.Buy, 1, 92))
+	requireRejection(t, err, shared.ErrCodeOpenOrderLimit)
+
+	// Other users have their own open order budget
+	require.NoError(t, engine.PlaceOrder(limitOrder("s1", "bob", types.Sell, 10, 100)))
+	require.NoError(t, engine.PlaceOrder(limitOrder("s2", "carol", types.Sell, 10, 100)))
+
+	// Bob is short 10 after this fill, so another sale of 10 breaches 15
+	require.NoError(t, engine.PlaceOrder(limitOrder("d1", "dave", types.Buy, 10, 100)))
+	err = engine.PlaceOrder(limitOrder("s3", "bob", types.Sell, 10, 100))
+	requireRejection(t, err, shared.ErrCodePositionLimit)
+}
+
+func TestTradingEngine_Risk_OpenOrdersFreedByFillsAndCancels(t *testing.T) {
+	engine, _ := newRiskTestEngine(config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{MaxOpenOrders: 2},
+	})
+
+	require.NoError(t, engine.PlaceOrder(limitOrder("b1", "alice", types.Buy, 5, 90)))
+	require.NoError(t, engine.PlaceOrder(limitOrder("b2", "alice", types.Buy, 5, 91)))
+	requireRejection(t, engine.PlaceOrder(limitOrder("b3", "alice", types.Buy, 5, 92)), shared.ErrCodeOpenOrderLimit)
+
+	// A partial fill leaves the order resting
+	require.NoError(t, engine.PlaceOrder(limitOrder("s1", "bob", types.Sell, 2, 91)))
+	requireRejection(t, engine.PlaceOrder(limitOrder("b3", "alice", types.Buy, 5, 92)), shared.ErrCodeOpenOrderLimit)
+
+	// A complete fill and a cancel each free a slot
+	require.NoError(t, engine.PlaceOrder(limitOrder("s2", "bob", types.Sell, 3, 91)))
+	require.NoError(t, engine.PlaceOrder(limitOrder("b3", "alice", types.Buy, 5, 92)))
+	require.NoError(t, engine.CancelOrder("b1"))
+	require.NoError(t, engine.PlaceOrder(limitOrder("b4", "alice", types.Buy, 5, 89)))
+
+	// Orders filled on arrival never rest
+	require.NoError(t, engine.PlaceOrder(limitOrder("s3", "bob", types.Sell, 5, 92)))
+	require.NoError(t, engine.PlaceOrder(limitOrder("b5", "alice", types.Buy, 5, 89)))
+}
+
+func TestTradingEngine_Risk_OrderRate(t *testing.T) {
+	engine, _ := newRiskTestEngine(config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{MaxOrdersPerSecond: 2},
+	})
+
+	require.NoError(t, engine.PlaceOrder(limitOrder("1", "alice", types.Buy, 1, 90)))
+	require.NoError(t, engine.PlaceOrder(limitOrder("2", "alice", types.Buy, 1, 90)))
+
+	err := engine.PlaceOrder(limitOrder("3", "alice", types.Buy, 1, 90))
+	requireRejection(t, err, shared.ErrCodeOrderRateLimit)
+
+	require.NoError(t, engine.PlaceOrder(limitOrder("4", "bob", types.Buy, 1, 90)))
+}
+
+func TestTradingEngine_Risk_Overrides(t *testing.T) {
+	engine, _ := newRiskTestEngine(config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{MaxOrderQuantity: 10},
+		Symbols: map[string]config.RiskLimits{"AAPL": {MaxOrderQuantity: 50}},
+		Users:   map[string]config.RiskLimits{"whale": {MaxOrderQuantity: 500}},
+	})
+
+	require.NoError(t, engine.PlaceOrder(limitOrder("1", "alice", types.Buy, 50, 90)))
+	requireRejection(t, engine.PlaceOrder(limitOrder("2", "alice", types.Buy, 51, 90)), shared.ErrCodeOrderSizeLimit)
+	require.NoError(t, engine.PlaceOrder(limitOrder("3", "whale", types.Buy, 500, 90)))
+
+	msft := limitOrder("4", "alice", types.Buy, 11, 90)
+	msft.Symbol = "MSFT"
+	requireRejection(t, engine.PlaceOrder(msft), shared.ErrCodeOrderSizeLimit)
+}
+
+func TestTradingEngine_Risk_Disabled(t *testing.T) {
+	engine, recorder := newRiskTestEngine(config.RiskConfig{
+		Enabled: false,
+		Default: config.RiskLimits{MaxOrderQuantity: 1},
+	})
+
+	require.NoError(t, engine.PlaceOrder(limitOrder("1", "alice", types.Buy, 100, 90)))
+	assert.Empty(t, recorder.rejections)
+}
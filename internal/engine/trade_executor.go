@@ -2,22 +2,29 @@ package engine
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/google/uuid"
 	"simulated_exchange/internal/types"
+	"simulated_exchange/pkg/clock"
 )
 
 type SimpleTradeExecutor struct {
 	tradeRepo TradeRepository
+	clock     clock.Clock
 }
 
 func NewSimpleTradeExecutor(tradeRepo TradeRepository) *SimpleTradeExecutor {
 	return &SimpleTradeExecutor{
 		tradeRepo: tradeRepo,
+		clock:     clock.Real(),
 	}
 }
 
+// SetClock makes the executor timestamp trades with clk
+func (te *SimpleTradeExecutor) SetClock(clk clock.Clock) {
+	te.clock = clk
+}
+
 func (te *SimpleTradeExecutor) ExecuteTrade(buyOrder types.Order, sellOrder types.Order, quantity float64, price float64) (types.Trade, error) {
 	if buyOrder.Symbol != sellOrder.Symbol {
 		return types.Trade{}, fmt.Errorf("symbol mismatch: buy order symbol %s, sell order symbol %s", buyOrder.Symbol, sellOrder.Symbol)
@@ -54,7 +61,7 @@ func (te *SimpleTradeExecutor) ExecuteTrade(buyOrder types.Order, sellOrder type
 		Symbol:      buyOrder.Symbol,
 		Quantity:    quantity,
 		Price:       price,
-		Timestamp:   time.Now(),
+		Timestamp:   te.clock.Now(),
 	}
 
 	err := te.tradeRepo.Save(trade)
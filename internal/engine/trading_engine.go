@@ -4,10 +4,11 @@ import (
 	"fmt"
 	"sort"
 	"sync"
-	"time"
 
 	"github.com/google/uuid"
 	"simulated_exchange/internal/types"
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/risk"
 )
 
 type TradingEngine struct {
@@ -15,6 +16,11 @@ type TradingEngine struct {
 	tradeRepo     TradeRepository
 	matcher       OrderMatcher
 	executor      TradeExecutor
+	risk          *risk.Pipeline
+	lastPrices    map[string]float64
+	positions     map[positionKey]float64
+	openOrders    map[string]int
+	clock         clock.Clock
 	mutex         sync.RWMutex
 }
 
@@ -25,17 +31,37 @@ func NewTradingEngine(
 	executor TradeExecutor,
 ) *TradingEngine {
 	return &TradingEngine{
-		orderRepo: orderRepo,
-		tradeRepo: tradeRepo,
-		matcher:   matcher,
-		executor:  executor,
-		mutex:     sync.RWMutex{},
+		orderRepo:  orderRepo,
+		tradeRepo:  tradeRepo,
+		matcher:    matcher,
+		executor:   executor,
+		lastPrices: make(map[string]float64),
+		positions:  make(map[positionKey]float64),
+		openOrders: make(map[string]int),
+		clock:      clock.Real(),
+		mutex:      sync.RWMutex{},
 	}
 }
 
+// SetClock makes the engine timestamp orders with clk; the trade executor
+// keeps its own clock
+func (te *TradingEngine) SetClock(clk clock.Clock) {
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+
+	te.clock = clk
+}
+
 func (te *TradingEngine) PlaceOrder(order types.Order) error {
+	_, err := te.PlaceOrderWithTrades(order)
+	return err
+}
+
+// PlaceOrderWithTrades places an order and returns the trades it executed,
+// in execution order
+func (te *TradingEngine) PlaceOrderWithTrades(order types.Order) ([]types.Trade, error) {
 	if err := te.validateOrder(order); err != nil {
-		return fmt.Errorf("invalid order: %w", err)
+		return nil, fmt.Errorf("invalid order: %w", err)
 	}
 
 	if order.ID == "" {
@@ -43,19 +69,23 @@ func (te *TradingEngine) PlaceOrder(order types.Order) error {
 	}
 
 	if order.Timestamp.IsZero() {
-		order.Timestamp = time.Now()
+		order.Timestamp = te.clock.Now()
 	}
 
 	te.mutex.Lock()
 	defer te.mutex.Unlock()
 
+	if err := te.checkRisk(order); err != nil {
+		return nil, fmt.Errorf("order rejected: %w", err)
+	}
+
 	if err := te.orderRepo.Save(order); err != nil {
-		return fmt.Errorf("failed to save order: %w", err)
+		return nil, fmt.Errorf("failed to save order: %w", err)
 	}
 
 	existingOrders, err := te.orderRepo.GetBySymbol(order.Symbol)
 	if err != nil {
-		return fmt.Errorf("failed to get existing orders: %w", err)
+		return nil, fmt.Errorf("failed to get existing orders: %w", err)
 	}
 
 	var ordersToMatch []types.Order
@@ -67,6 +97,7 @@ func (te *TradingEngine) PlaceOrder(order types.Order) error {
 
 	matches := te.matcher.FindMatches(order, ordersToMatch)
 
+	var trades []types.Trade
 	remainingQuantity := order.Quantity
 	for _, match := range matches {
 		if remainingQuantity <= 0 {
@@ -75,12 +106,14 @@ func (te *TradingEngine) PlaceOrder(order types.Order) error {
 
 		trade, err := te.executor.ExecuteTrade(match.BuyOrder, match.SellOrder, match.Quantity, match.Price)
 		if err != nil {
-			return fmt.Errorf("failed to execute trade: %w", err)
+			return nil, fmt.Errorf("failed to execute trade: %w", err)
 		}
+		te.recordFill(match, trade)
+		trades = append(trades, trade)
 
 		updatedOrder, err := te.updateOrderQuantities(match, trade.Quantity, order.ID)
 		if err != nil {
-			return fmt.Errorf("failed to update order quantities: %w", err)
+			return nil, fmt.Errorf("failed to update order quantities: %w", err)
 		}
 
 		if updatedOrder != nil {
@@ -93,15 +126,16 @@ func (te *TradingEngine) PlaceOrder(order types.Order) error {
 	if remainingQuantity > 0 {
 		order.Quantity = remainingQuantity
 		if err := te.orderRepo.Save(order); err != nil {
-			return fmt.Errorf("failed to save remaining order: %w", err)
+			return nil, fmt.Errorf("failed to save remaining order: %w", err)
 		}
+		te.trackOpenOrder(order.UserID, 1)
 	} else {
 		if err := te.orderRepo.Delete(order.ID); err != nil {
-			return fmt.Errorf("failed to delete filled order: %w", err)
+			return nil, fmt.Errorf("failed to delete filled order: %w", err)
 		}
 	}
 
-	return nil
+	return trades, nil
 }
 
 func (te *TradingEngine) CancelOrder(id string) error {
@@ -112,7 +146,7 @@ func (te *TradingEngine) CancelOrder(id string) error {
 	te.mutex.Lock()
 	defer te.mutex.Unlock()
 
-	_, err := te.orderRepo.GetByID(id)
+	order, err := te.orderRepo.GetByID(id)
 	if err != nil {
 		return fmt.Errorf("order not found: %w", err)
 	}
@@ -120,6 +154,7 @@ func (te *TradingEngine) CancelOrder(id string) error {
 	if err := te.orderRepo.Delete(id); err != nil {
 		return fmt.Errorf("failed to delete order: %w", err)
 	}
+	te.trackOpenOrder(order.UserID, -1)
 
 	return nil
 }
@@ -215,6 +250,7 @@ func (te *TradingEngine) updateOrderQuantities(match types.Match, tradeQuantity
 			if err := te.orderRepo.Delete(buyOrder.ID); err != nil {
 				return nil, fmt.Errorf("failed to delete filled buy order: %w", err)
 			}
+			te.trackOpenOrder(buyOrder.UserID, -1)
 		} else {
 			if err := te.orderRepo.Save(buyOrder); err != nil {
 				return nil, fmt.Errorf("failed to update buy order: %w", err)
@@ -229,6 +265,7 @@ func (te *TradingEngine) updateOrderQuantities(match types.Match, tradeQuantity
 			if err := te.orderRepo.Delete(sellOrder.ID); err != nil {
 				return nil, fmt.Errorf("failed to delete filled sell order: %w", err)
 			}
+			te.trackOpenOrder(sellOrder.UserID, -1)
 		} else {
 			if err := te.orderRepo.Save(sellOrder); err != nil {
 				return nil, fmt.Errorf("failed to update sell order: %w", err)
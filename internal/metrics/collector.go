@@ -4,6 +4,8 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"simulated_exchange/pkg/clock"
 )
 
 // RealTimeMetrics implements MetricsCollector with time-windowed metrics calculation
@@ -13,6 +15,7 @@ type RealTimeMetrics struct {
 	tradeEvents   []TradeEvent
 	windowSize    time.Duration
 	symbolMetrics map[string]*symbolData
+	clock         clock.Clock
 }
 
 type symbolData struct {
@@ -34,16 +37,26 @@ func NewRealTimeMetrics(windowSize time.Duration) *RealTimeMetrics {
 		tradeEvents:   make([]TradeEvent, 0),
 		windowSize:    windowSize,
 		symbolMetrics: make(map[string]*symbolData),
+		clock:         clock.Real(),
 	}
 }
 
+// SetClock makes the metrics windows follow clk, so that they span
+// simulated rather than wall clock time
+func (rtm *RealTimeMetrics) SetClock(clk clock.Clock) {
+	rtm.mutex.Lock()
+	defer rtm.mutex.Unlock()
+
+	rtm.clock = clk
+}
+
 // RecordOrder records an order event
 func (rtm *RealTimeMetrics) RecordOrder(event OrderEvent) {
 	rtm.mutex.Lock()
 	defer rtm.mutex.Unlock()
 
 	if event.Timestamp.IsZero() {
-		event.Timestamp = time.Now()
+		event.Timestamp = rtm.clock.Now()
 	}
 
 	rtm.orderEvents = append(rtm.orderEvents, event)
@@ -57,7 +70,7 @@ func (rtm *RealTimeMetrics) RecordTrade(event TradeEvent) {
 	defer rtm.mutex.Unlock()
 
 	if event.Timestamp.IsZero() {
-		event.Timestamp = time.Now()
+		event.Timestamp = rtm.clock.Now()
 	}
 
 	rtm.tradeEvents = append(rtm.tradeEvents, event)
@@ -70,7 +83,7 @@ func (rtm *RealTimeMetrics) CalculateMetrics(windowDuration time.Duration) Metri
 	rtm.mutex.RLock()
 	defer rtm.mutex.RUnlock()
 
-	now := time.Now()
+	now := rtm.clock.Now()
 	windowStart := now.Add(-windowDuration)
 
 	return rtm.calculateMetricsForWindow(windowStart, now)
@@ -223,7 +236,7 @@ func (rtm *RealTimeMetrics) updateSymbolMetrics(symbol string, isOrder, isTrade
 
 // cleanOldEvents removes events outside the current window
 func (rtm *RealTimeMetrics) cleanOldEvents() {
-	now := time.Now()
+	now := rtm.clock.Now()
 	cutoff := now.Add(-rtm.windowSize)
 
 	// Clean order events
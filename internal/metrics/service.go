@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"simulated_exchange/pkg/clock"
 )
 
 // RealTimeMetricsService implements MetricsService for orchestrating metrics collection and analysis
@@ -25,6 +27,9 @@ type RealTimeMetricsService struct {
 
 	// Latest analysis results
 	lastAnalysis PerformanceAnalysis
+
+	// Time source of the analysis schedule and timestamps
+	clock clock.Clock
 }
 
 // NewRealTimeMetricsService creates a new metrics service
@@ -37,6 +42,7 @@ func NewRealTimeMetricsService(collector MetricsCollector, analyzer PerformanceA
 		analysisInterval: 10 * time.Second,
 		windowSize:       60 * time.Second,
 		healthy:          true,
+		clock:            clock.Real(),
 	}
 }
 
@@ -56,6 +62,19 @@ func NewRealTimeMetricsServiceWithConfig(
 		analysisInterval: analysisInterval,
 		windowSize:       windowSize,
 		healthy:          true,
+		clock:            clock.Real(),
+	}
+}
+
+// SetClock runs the analysis schedule and the collector's windows on clk;
+// call it before Start
+func (rms *RealTimeMetricsService) SetClock(clk clock.Clock) {
+	rms.mutex.Lock()
+	defer rms.mutex.Unlock()
+
+	rms.clock = clk
+	if clocked, ok := rms.collector.(interface{ SetClock(clock.Clock) }); ok {
+		clocked.SetClock(clk)
 	}
 }
 
@@ -158,14 +177,14 @@ func (rms *RealTimeMetricsService) ResetMetrics() {
 func (rms *RealTimeMetricsService) analysisRoutine() {
 	defer rms.wg.Done()
 
-	ticker := time.NewTicker(rms.analysisInterval)
+	ticker := rms.clock.NewTicker(rms.analysisInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-rms.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			rms.performAnalysis()
 		}
 	}
@@ -206,7 +225,7 @@ func (rms *RealTimeMetricsService) performAnalysis() {
 
 		// Create performance analysis
 		analysis := PerformanceAnalysis{
-			Timestamp:           time.Now(),
+			Timestamp:           rms.clock.Now(),
 			LatencyTrend:        latencyAnalysis.Trend,
 			ThroughputTrend:     throughputPrediction.Trend,
 			PredictedThroughput: throughputPrediction.PredictedThroughput,
@@ -274,7 +293,7 @@ func (rms *RealTimeMetricsService) GetDetailedHealthStatus() HealthStatus {
 	// Calculate uptime if running
 	if rms.running && rms.ctx != nil {
 		// Note: For a proper uptime calculation, you'd want to store the start time
-		status.Uptime = time.Since(rms.lastAnalysis.Timestamp)
+		status.Uptime = rms.clock.Since(rms.lastAnalysis.Timestamp)
 	}
 
 	return status
@@ -297,7 +316,7 @@ func (rms *RealTimeMetricsService) GetMetricsSummary() MetricsSummary {
 	healthStatus := rms.GetDetailedHealthStatus()
 
 	return MetricsSummary{
-		Timestamp:        time.Now(),
+		Timestamp:        rms.clock.Now(),
 		CurrentMetrics:   currentMetrics,
 		Analysis:         analysis,
 		HealthStatus:     healthStatus,
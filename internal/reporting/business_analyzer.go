@@ -13,6 +13,8 @@ type StandardBusinessAnalyzer struct {
 	benchmarkData    BenchmarkData
 	industryStandards map[string]float64
 	riskThresholds   map[string]float64
+	feeRevenue       FeeRevenueSource
+	revenueWindow    time.Duration
 }
 
 // NewStandardBusinessAnalyzer creates a new business analyzer
@@ -23,6 +25,14 @@ func NewStandardBusinessAnalyzer() *StandardBusinessAnalyzer {
 	}
 }
 
+// SetFeeRevenueSource makes KPI and benchmark analysis use the fee revenue
+// earned over the trailing window instead of the revenue passed in
+// FinancialMetrics
+func (sba *StandardBusinessAnalyzer) SetFeeRevenueSource(source FeeRevenueSource, window time.Duration) {
+	sba.feeRevenue = source
+	sba.revenueWindow = window
+}
+
 // AnalyzePerformance evaluates business performance metrics
 func (sba *StandardBusinessAnalyzer) AnalyzePerformance(ctx context.Context, data PerformanceData) (*PerformanceAnalysis, error) {
 	if err := ctx.Err(); err != nil {
@@ -144,6 +154,11 @@ func (sba *StandardBusinessAnalyzer) CalculateKPIs(ctx context.Context, data Bus
 		return nil, fmt.Errorf("context error: %w", err)
 	}
 
+	revenueBySymbol, err := sba.applyFeeRevenue(ctx, &data.FinancialMetrics)
+	if err != nil {
+		return nil, err
+	}
+
 	kpis := &KPIMetrics{
 		CustomerSatisfaction:  sba.calculateCustomerSatisfaction(data.CustomerMetrics),
 		EmployeeEngagement:    sba.calculateEmployeeEngagement(data.EmployeeMetrics),
@@ -158,6 +173,9 @@ func (sba *StandardBusinessAnalyzer) CalculateKPIs(ctx context.Context, data Bus
 	kpis.CustomKPIs["customer_acquisition_cost"] = sba.calculateCAC(data.CustomerMetrics, data.FinancialMetrics)
 	kpis.CustomKPIs["customer_lifetime_value"] = sba.calculateCLV(data.CustomerMetrics)
 	kpis.CustomKPIs["employee_productivity"] = sba.calculateEmployeeProductivity(data.EmployeeMetrics, data.FinancialMetrics)
+	if revenueBySymbol != nil {
+		kpis.CustomKPIs["fee_revenue_by_symbol"] = revenueBySymbol
+	}
 
 	return kpis, nil
 }
@@ -168,6 +186,10 @@ func (sba *StandardBusinessAnalyzer) BenchmarkAnalysis(ctx context.Context, data
 		return nil, fmt.Errorf("context error: %w", err)
 	}
 
+	if _, err := sba.applyFeeRevenue(ctx, &data.FinancialMetrics); err != nil {
+		return nil, err
+	}
+
 	analysis := &BenchmarkAnalysis{
 		CompanyMetrics:    sba.extractCompanyMetrics(data),
 		IndustryBenchmarks: benchmarks.IndustryAverages,
@@ -1563,6 +1585,32 @@ func (sba *StandardBusinessAnalyzer) calculateMarketShare(data MarketData) float
 	return data.MarketShare
 }
 
+// applyFeeRevenue replaces the reported revenue with the fee revenue earned
+// over the revenue window, recomputes profit from expenses and returns the
+// revenue by symbol. Without a fee revenue source the metrics are unchanged.
+func (sba *StandardBusinessAnalyzer) applyFeeRevenue(ctx context.Context, metrics *FinancialMetrics) (map[string]float64, error) {
+	if sba.feeRevenue == nil {
+		return nil, nil
+	}
+
+	end := time.Now()
+	revenue, err := sba.feeRevenue.GetFeeRevenue(ctx, end.Add(-sba.revenueWindow), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee revenue: %w", err)
+	}
+
+	bySymbol := make(map[string]float64, len(revenue))
+	total := 0.0
+	for _, symbol := range revenue {
+		bySymbol[symbol.Symbol] = symbol.TotalFees
+		total += symbol.TotalFees
+	}
+
+	metrics.Revenue = total
+	metrics.Profit = total - metrics.Expenses
+	return bySymbol, nil
+}
+
 // calculateRevenueGrowth calculates revenue growth rate
 func (sba *StandardBusinessAnalyzer) calculateRevenueGrowth(metrics FinancialMetrics) float64 {
 	return metrics.GrowthRate
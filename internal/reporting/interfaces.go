@@ -3,6 +3,8 @@ package reporting
 import (
 	"context"
 	"time"
+
+	"simulated_exchange/pkg/shared"
 )
 
 // ReportGenerator interface defines the main report generation capabilities
@@ -318,6 +320,11 @@ type ReportingPeriod struct {
 	Label     string    `json:"label"`
 }
 
+// FeeRevenueSource provides the fee revenue earned from trading, per symbol
+type FeeRevenueSource interface {
+	GetFeeRevenue(ctx context.Context, start, end time.Time) ([]*shared.FeeRevenue, error)
+}
+
 type FinancialMetrics struct {
 	Revenue     float64 `json:"revenue"`
 	Profit      float64 `json:"profit"`
@@ -0,0 +1,345 @@
+package simulation
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ScheduledEvent is a market event of the event calendar. Unlike the random
+// events of the event generator it fires at an exact time of the simulation
+// clock, so exercises can be repeated.
+type ScheduledEvent struct {
+	// At is when the event fires. A time on 0000-01-01, like the trading
+	// hours, is a time of day: the event fires at its first occurrence once
+	// the simulation has started.
+	At time.Time `json:"at"`
+
+	// Event is injected when the event fires, with a price impact drawn
+	// from Impact
+	Event MarketEvent `json:"event"`
+
+	// Impact is the expected price move of the affected symbols in percent;
+	// negative values move prices down
+	Impact ImpactRange `json:"impact"`
+
+	// BuildUp is how long volatility builds up before the event
+	BuildUp time.Duration `json:"build_up"`
+
+	// Drift is how long prices keep drifting after the event, by
+	// DriftImpact percent in the direction of the event's move
+	Drift       time.Duration `json:"drift"`
+	DriftImpact float64       `json:"drift_impact"`
+}
+
+// calendarEntry is a scheduled event of a running simulation
+type calendarEntry struct {
+	ScheduledEvent
+	due       time.Time
+	fired     bool
+	firedAt   time.Time
+	direction float64
+}
+
+// calendarFile is an event calendar as written in a YAML or JSON file
+type calendarFile struct {
+	Events []calendarEvent `json:"events" yaml:"events"`
+}
+
+type calendarEvent struct {
+	ID              string   `json:"id" yaml:"id"`
+	At              string   `json:"at" yaml:"at"`
+	Type            string   `json:"type" yaml:"type"`
+	Severity        string   `json:"severity" yaml:"severity"`
+	AffectedSymbols []string `json:"affected_symbols" yaml:"affected_symbols"`
+	Impact          struct {
+		Min float64 `json:"min" yaml:"min"`
+		Max float64 `json:"max" yaml:"max"`
+	} `json:"impact" yaml:"impact"`
+	Duration    string  `json:"duration" yaml:"duration"`
+	Description string  `json:"description" yaml:"description"`
+	BuildUp     string  `json:"build_up" yaml:"build_up"`
+	Drift       string  `json:"drift" yaml:"drift"`
+	DriftImpact float64 `json:"drift_impact" yaml:"drift_impact"`
+}
+
+// calendarTimeLayouts are the times of day an event may be scheduled at
+var calendarTimeLayouts = []string{"15:04", "15:04:05"}
+
+// ParseEventCalendar parses and validates a YAML or JSON event calendar.
+// source names the calendar in errors.
+func ParseEventCalendar(data []byte, source string) ([]ScheduledEvent, error) {
+	var file calendarFile
+	if err := decodeStrict(data, &file); err != nil {
+		return nil, SimulationError{
+			Code:    ErrorCodeInvalidConfig,
+			Message: fmt.Sprintf("invalid event calendar %s: %v", source, err),
+		}
+	}
+
+	events, problems := file.toEvents()
+	if len(problems) > 0 {
+		return nil, SimulationError{
+			Code:    ErrorCodeInvalidConfig,
+			Message: fmt.Sprintf("invalid event calendar %s: %s", source, strings.Join(problems, "; ")),
+			Details: strings.Join(problems, "\n"),
+		}
+	}
+	return events, nil
+}
+
+// LoadEventCalendar reads an event calendar file
+func LoadEventCalendar(path string) ([]ScheduledEvent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event calendar %s: %w", path, err)
+	}
+	return ParseEventCalendar(data, path)
+}
+
+// toEvents validates the calendar and converts it to scheduled events; it
+// returns every problem found
+func (f *calendarFile) toEvents() ([]ScheduledEvent, []string) {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	events := make([]ScheduledEvent, 0, len(f.Events))
+	ids := make(map[string]bool, len(f.Events))
+	for i, spec := range f.Events {
+		path := fmt.Sprintf("events[%d]", i)
+
+		event := ScheduledEvent{
+			Event: MarketEvent{
+				ID:              spec.ID,
+				Type:            EventType(spec.Type),
+				Severity:        EventSeverity(spec.Severity),
+				AffectedSymbols: spec.AffectedSymbols,
+				Description:     spec.Description,
+			},
+			Impact:      ImpactRange{MinImpact: spec.Impact.Min, MaxImpact: spec.Impact.Max},
+			DriftImpact: spec.DriftImpact,
+		}
+		if event.Event.ID == "" {
+			event.Event.ID = fmt.Sprintf("calendar_%d", i)
+		}
+		if ids[event.Event.ID] {
+			addProblem("%s.id: duplicate event %q", path, event.Event.ID)
+		}
+		ids[event.Event.ID] = true
+		if event.Event.Description == "" {
+			event.Event.Description = fmt.Sprintf("Scheduled %s %s event", event.Event.Severity, event.Event.Type)
+		}
+
+		at, err := parseCalendarTime(spec.At)
+		if err != nil {
+			addProblem("%s.at: %v", path, err)
+		}
+		event.At = at
+
+		if !containsValue(scenarioEventTypes, event.Event.Type) {
+			addProblem("%s.type: %q must be one of %s", path, spec.Type, joinValues(scenarioEventTypes))
+		}
+		if !containsValue(scenarioSeverities, event.Event.Severity) {
+			addProblem("%s.severity: %q must be one of %s", path, spec.Severity, joinValues(scenarioSeverities))
+		}
+		if len(spec.AffectedSymbols) == 0 {
+			addProblem("%s.affected_symbols: at least one symbol is required", path)
+		}
+		for _, symbol := range spec.AffectedSymbols {
+			if symbol == "" {
+				addProblem("%s.affected_symbols: empty symbol", path)
+			}
+		}
+
+		if spec.Impact.Min > spec.Impact.Max {
+			addProblem("%s.impact: min %g is above max %g", path, spec.Impact.Min, spec.Impact.Max)
+		}
+		if spec.Impact.Min <= -100 || spec.Impact.Max > 100 {
+			addProblem("%s.impact: moves must be above -100 and at most 100 percent", path)
+		}
+
+		event.Event.Duration, err = parseScenarioDuration(spec.Duration)
+		if err != nil {
+			addProblem("%s.duration: %v", path, err)
+		}
+		if spec.BuildUp != "" {
+			if event.BuildUp, err = parseScenarioDuration(spec.BuildUp); err != nil {
+				addProblem("%s.build_up: %v", path, err)
+			}
+		}
+		if spec.Drift != "" {
+			if event.Drift, err = parseScenarioDuration(spec.Drift); err != nil {
+				addProblem("%s.drift: %v", path, err)
+			}
+		}
+		if spec.DriftImpact != 0 && spec.Drift == "" {
+			addProblem("%s.drift_impact: needs a drift duration", path)
+		}
+		if math.Abs(spec.DriftImpact) > 100 {
+			addProblem("%s.drift_impact: %g must be between -100 and 100 percent", path, spec.DriftImpact)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, problems
+}
+
+// parseCalendarTime parses a time of day such as 10:30, or an RFC 3339
+// time
+func parseCalendarTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("required")
+	}
+	for _, layout := range calendarTimeLayouts {
+		if at, err := time.Parse(layout, value); err == nil {
+			return at, nil
+		}
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time of day such as 10:30 or an RFC 3339 time", value)
+}
+
+// isTimeOfDay reports whether a scheduled time has no date
+func isTimeOfDay(at time.Time) bool {
+	return at.Year() == 0
+}
+
+// scheduleCalendar resolves the calendar's times against the start of the
+// simulation; the caller holds rs.mu
+func (rs *RealisticSimulator) scheduleCalendar(start time.Time) {
+	rs.calendar = make([]*calendarEntry, 0, len(rs.config.EventCalendar))
+	rs.lastCalendarUpdate = start
+
+	for _, event := range rs.config.EventCalendar {
+		due := event.At
+		if isTimeOfDay(due) {
+			due = time.Date(start.Year(), start.Month(), start.Day(),
+				due.Hour(), due.Minute(), due.Second(), 0, start.Location())
+			if due.Before(start) {
+				due = due.AddDate(0, 0, 1)
+			}
+		} else if due.Before(start) {
+			log.Printf("Scheduled event %s skipped: %v is before the simulation start", event.Event.ID, due)
+			continue
+		}
+
+		rs.calendar = append(rs.calendar, &calendarEntry{ScheduledEvent: event, due: due})
+	}
+
+	// Events due at the same time fire in calendar order
+	sort.SliceStable(rs.calendar, func(i, j int) bool { return rs.calendar[i].due.Before(rs.calendar[j].due) })
+}
+
+// nextCalendarEvent returns when the next scheduled event is due
+func (rs *RealisticSimulator) nextCalendarEvent() (time.Time, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	for _, entry := range rs.calendar {
+		if !entry.fired {
+			return entry.due, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fireScheduledEvents injects every scheduled event that is due, with a
+// price move drawn from its impact range
+func (rs *RealisticSimulator) fireScheduledEvents() error {
+	rs.mu.Lock()
+	now := rs.clock.Now()
+	var due []*calendarEntry
+	var moves []float64
+	for _, entry := range rs.calendar {
+		if entry.fired || entry.due.After(now) {
+			continue
+		}
+		move := entry.Impact.MinImpact + rs.randFloat()*(entry.Impact.MaxImpact-entry.Impact.MinImpact)
+		entry.fired = true
+		entry.firedAt = now
+		entry.direction = 1.0
+		if move < 0 {
+			entry.direction = -1.0
+		}
+		due = append(due, entry)
+		moves = append(moves, move)
+	}
+	rs.mu.Unlock()
+
+	for i, entry := range due {
+		event := entry.Event
+		event.PriceImpact = math.Abs(moves[i])
+		if rs.eventGenerator != nil {
+			if err := rs.eventGenerator.InjectEvent(event); err != nil {
+				return fmt.Errorf("failed to inject scheduled event %s: %w", event.ID, err)
+			}
+		}
+
+		rs.applyEventMove(event, entry.direction)
+
+		rs.mu.Lock()
+		rs.stats.EventsTriggered++
+		rs.mu.Unlock()
+
+		log.Printf("Scheduled market event: %s (%s, %+.2f%%)", event.Description, event.Type, moves[i])
+	}
+
+	return nil
+}
+
+// applyCalendarEffects builds up volatility ahead of scheduled events and
+// lets prices drift after them, up to now; the caller holds rs.mu
+func (rs *RealisticSimulator) applyCalendarEffects(now time.Time) {
+	last := rs.lastCalendarUpdate
+	rs.lastCalendarUpdate = now
+
+	for _, entry := range rs.calendar {
+		// Volatility rises towards the event's intensity as it approaches
+		if !entry.fired && entry.BuildUp > 0 {
+			start := entry.due.Add(-entry.BuildUp)
+			if !now.Before(start) && now.Before(entry.due) {
+				progress := float64(now.Sub(start)) / float64(entry.BuildUp)
+				rs.priceGenerator.SimulateVolatility(VolatilityNews, rs.mapSeverityToIntensity(entry.Event.Severity)*progress)
+			}
+		}
+
+		// Spread the drift evenly over its duration
+		if entry.fired && entry.Drift > 0 && entry.DriftImpact != 0 {
+			from := maxTime(last, entry.firedAt)
+			to := minTime(now, entry.firedAt.Add(entry.Drift))
+			if !to.After(from) {
+				continue
+			}
+
+			move := entry.DriftImpact / 100.0 * entry.direction * float64(to.Sub(from)) / float64(entry.Drift)
+			for _, symbol := range entry.Event.AffectedSymbols {
+				if price, exists := rs.status.CurrentPrices[symbol]; exists && price*(1+move) > 0 {
+					rs.status.CurrentPrices[symbol] = price * (1 + move)
+				}
+			}
+		}
+	}
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
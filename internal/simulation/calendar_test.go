@@ -0,0 +1,239 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"simulated_exchange/pkg/clock"
+)
+
+const earningsCalendar = `
+events:
+  - id: btc_earnings
+    at: "00:10"
+    type: EARNINGS
+    severity: MEDIUM
+    affected_symbols: [BTCUSD]
+    impact: {min: 5, max: 5}
+    duration: 30m
+    build_up: 5m
+    drift: 10m
+    drift_impact: 2
+  - at: 2024-01-01T00:20:00Z
+    type: ECONOMIC
+    severity: HIGH
+    affected_symbols: [ETHUSD]
+    impact: {min: -3, max: -1}
+    duration: 15m
+    description: Central bank raises rates
+`
+
+// volatilityRecorder records the volatility the simulator applies
+type volatilityRecorder struct {
+	*MockPriceGenerator
+	news []float64
+}
+
+func (vr *volatilityRecorder) SimulateVolatility(pattern VolatilityPattern, intensity float64) {
+	if pattern == VolatilityNews {
+		vr.news = append(vr.news, intensity)
+	}
+	vr.MockPriceGenerator.SimulateVolatility(pattern, intensity)
+}
+
+func TestParseEventCalendar(t *testing.T) {
+	events, err := ParseEventCalendar([]byte(earningsCalendar), "calendar.yaml")
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	earnings := events[0]
+	if !isTimeOfDay(earnings.At) || earnings.At.Hour() != 0 || earnings.At.Minute() != 10 {
+		t.Errorf("Expected a time of day of 00:10, got %v", earnings.At)
+	}
+	if earnings.Event.ID != "btc_earnings" || earnings.Event.Type != EventEarnings || earnings.Event.Duration != 30*time.Minute {
+		t.Errorf("Unexpected earnings event: %+v", earnings.Event)
+	}
+	if earnings.BuildUp != 5*time.Minute || earnings.Drift != 10*time.Minute || earnings.DriftImpact != 2 {
+		t.Errorf("Unexpected build-up and drift: %+v", earnings)
+	}
+
+	rates := events[1]
+	if isTimeOfDay(rates.At) || !rates.At.Equal(DeterministicEpoch.Add(20*time.Minute)) {
+		t.Errorf("Expected 2024-01-01 00:20 UTC, got %v", rates.At)
+	}
+	if rates.Event.ID != "calendar_1" || rates.Impact.MinImpact != -3 || rates.Impact.MaxImpact != -1 {
+		t.Errorf("Unexpected rates event: %+v", rates)
+	}
+}
+
+func TestParseEventCalendar_Invalid(t *testing.T) {
+	calendar := `
+events:
+  - id: a
+    at: half past ten
+    type: RUMOR
+    severity: HIGH
+    impact: {min: 4, max: -2}
+    duration: 10m
+    drift_impact: 1
+  - id: a
+    at: "10:30"
+    type: NEWS
+    severity: LOW
+    affected_symbols: [BTCUSD]
+`
+	_, err := ParseEventCalendar([]byte(calendar), "calendar.yaml")
+	if err == nil {
+		t.Fatal("Expected the calendar to be rejected")
+	}
+
+	for _, problem := range []string{
+		`events[0].at: "half past ten" is not a time of day`,
+		`events[0].type: "RUMOR" must be one of`,
+		"events[0].affected_symbols: at least one symbol is required",
+		"events[0].impact: min 4 is above max -2",
+		"events[0].drift_impact: needs a drift duration",
+		`events[1].id: duplicate event "a"`,
+		"events[1].duration: required",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected %q in %q", problem, err.Error())
+		}
+	}
+}
+
+func TestRealisticSimulator_EventCalendar(t *testing.T) {
+	events, err := ParseEventCalendar([]byte(earningsCalendar), "calendar.yaml")
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+
+	priceGen := &volatilityRecorder{MockPriceGenerator: NewMockPriceGenerator()}
+	eventGen := NewMockEventGenerator()
+	simulator := NewRealisticSimulator(priceGen, NewMockOrderGenerator(), eventGen, NewMockTradingEngine())
+
+	config := DefaultSimulationConfig()
+	config.SimulationDuration = 30 * time.Minute
+	config.NewsEventFrequency = time.Hour
+	config.EnablePatterns = false
+	config.EventCalendar = events
+
+	if _, err := simulator.RunDeterministic(context.Background(), config); err != nil {
+		t.Fatalf("Deterministic run failed: %v", err)
+	}
+
+	if len(eventGen.events) != 2 {
+		t.Fatalf("Expected both scheduled events to be injected, got %d", len(eventGen.events))
+	}
+	if eventGen.events[0].ID != "btc_earnings" || eventGen.events[0].PriceImpact != 5 {
+		t.Errorf("Expected the earnings event with a 5%% impact first, got %+v", eventGen.events[0])
+	}
+	if impact := eventGen.events[1].PriceImpact; impact < 1 || impact > 3 {
+		t.Errorf("Expected the rates impact within 1%% to 3%%, got %f", impact)
+	}
+
+	// Events fire at their exact times
+	if at := simulator.calendar[0].firedAt; !at.Equal(DeterministicEpoch.Add(10 * time.Minute)) {
+		t.Errorf("Expected the earnings event at 00:10, got %v", at)
+	}
+	if at := simulator.calendar[1].firedAt; !at.Equal(DeterministicEpoch.Add(20 * time.Minute)) {
+		t.Errorf("Expected the rates event at 00:20, got %v", at)
+	}
+
+	// Volatility builds up over the five minutes before the earnings
+	if len(priceGen.news) == 0 {
+		t.Fatal("Expected volatility to build up before the earnings")
+	}
+	for i := 1; i < len(priceGen.news); i++ {
+		if priceGen.news[i] < priceGen.news[i-1] {
+			t.Errorf("Expected volatility to rise towards the event, got %v", priceGen.news)
+			break
+		}
+	}
+
+	// The earnings move of 5% is followed by a 2% drift, and the rates
+	// decision moves ETHUSD down
+	status := simulator.GetSimulationStatus()
+	expected := config.InitialPrices["BTCUSD"] * 1.05 * math.Exp(0.02)
+	if btc := status.CurrentPrices["BTCUSD"]; math.Abs(btc-expected)/expected > 0.001 {
+		t.Errorf("Expected BTCUSD near %.2f, got %.2f", expected, btc)
+	}
+	if eth := status.CurrentPrices["ETHUSD"]; eth >= config.InitialPrices["ETHUSD"] {
+		t.Errorf("Expected ETHUSD below %.2f after the rates decision, got %.2f", config.InitialPrices["ETHUSD"], eth)
+	}
+}
+
+func TestRealisticOrderGenerator_MomentumFollowsEvents(t *testing.T) {
+	clk := clock.NewVirtual(DeterministicEpoch)
+	orderGen := NewRealisticOrderGenerator(DefaultOrderGeneratorConfig())
+	orderGen.Reseed(7, clk)
+
+	buyShare := func() float64 {
+		orderGen.mu.Lock()
+		defer orderGen.mu.Unlock()
+
+		buys := 0
+		for i := 0; i < 1000; i++ {
+			if orderGen.determineOrderSideBehaviorDriven("BTCUSD", BehaviorMomentum, 0.5) == "buy" {
+				buys++
+			}
+		}
+		return float64(buys) / 1000
+	}
+
+	orderGen.ObserveEvent(MarketEvent{AffectedSymbols: []string{"BTCUSD"}, Duration: 10 * time.Minute}, -1)
+	if share := buyShare(); share > 0.2 {
+		t.Errorf("Expected momentum traders to sell after bad news, got %.2f buys", share)
+	}
+
+	orderGen.ObserveEvent(MarketEvent{AffectedSymbols: []string{"BTCUSD"}, Duration: 20 * time.Minute}, 1)
+	orderGen.ObserveEvent(MarketEvent{AffectedSymbols: []string{"BTCUSD"}, Duration: 20 * time.Minute}, 1)
+	if share := buyShare(); share < 0.8 {
+		t.Errorf("Expected momentum traders to buy after net good news, got %.2f buys", share)
+	}
+
+	// Once the events are over, momentum traders go back to their usual mix
+	clk.Advance(30 * time.Minute)
+	if share := buyShare(); share < 0.5 || share > 0.7 {
+		t.Errorf("Expected about 0.6 buys without events, got %.2f", share)
+	}
+}
+
+func TestRealisticSimulator_StopWithPendingCalendar(t *testing.T) {
+	events, err := ParseEventCalendar([]byte(earningsCalendar), "calendar.yaml")
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+
+	simulator := NewRealisticSimulator(NewMockPriceGenerator(), NewMockOrderGenerator(), NewMockEventGenerator(), NewMockTradingEngine())
+	config := DefaultSimulationConfig()
+	config.SimulationDuration = time.Hour
+	config.EventCalendar = events
+
+	for i := 0; i < 3; i++ {
+		if err := simulator.StartSimulation(context.Background(), config); err != nil {
+			t.Fatalf("Failed to start simulation: %v", err)
+		}
+
+		// The calendar worker waits for its next event while the
+		// simulation stops, which must not wait for the stop timeout
+		start := time.Now()
+		if err := simulator.StopSimulation(); err != nil {
+			t.Fatalf("Failed to stop simulation: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("Expected the workers to stop right away, took %v", elapsed)
+		}
+	}
+
+	if err := simulator.StopSimulation(); err == nil {
+		t.Error("Expected stopping a stopped simulation to fail")
+	}
+}
@@ -0,0 +1,254 @@
+package simulation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"simulated_exchange/internal/api/dto"
+)
+
+// DeterministicEpoch is the virtual time deterministic runs start at
+var DeterministicEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// RunSummary summarizes a deterministic run. Two runs with the same seed and
+// configuration have the same order log and trade hashes.
+type RunSummary struct {
+	Seed            int64     `json:"seed"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	Ticks           int64     `json:"ticks"`
+	OrdersSubmitted int64     `json:"orders_submitted"`
+	OrdersRejected  int64     `json:"orders_rejected"`
+	TradesExecuted  int64     `json:"trades_executed"`
+	OrderLogHash    string    `json:"order_log_hash"`
+	TradeHash       string    `json:"trade_hash"`
+	Completed       bool      `json:"completed"`
+}
+
+// runLog hashes what a deterministic run does. The order log hash covers
+// every order the run submits to the trading engine, in submission order,
+// with its virtual timestamp and whether the engine accepted it. The trade
+// hash covers every trade the engine executed, in execution order, with the
+// submission sequence of its buy and sell orders; it stays empty for engines
+// that don't report their trades.
+type runLog struct {
+	orderHash hash.Hash
+	tradeHash hash.Hash
+	orders    int64
+	rejected  int64
+	trades    int64
+	reporting bool
+
+	// resting maps the engine's IDs of orders with quantity left to fill to
+	// their submission sequence
+	resting map[string]*restingOrder
+	mutex   sync.Mutex
+}
+
+// restingOrder is a submitted order that trades may still fill
+type restingOrder struct {
+	sequence  int64
+	remaining float64
+}
+
+func newRunLog() *runLog {
+	return &runLog{
+		orderHash: sha256.New(),
+		tradeHash: sha256.New(),
+		resting:   make(map[string]*restingOrder),
+	}
+}
+
+// record adds an order and the trades it executed to the log. orderID is
+// the engine's ID of the order, empty if the engine doesn't report trades.
+func (rl *runLog) record(timestamp time.Time, source string, order dto.PlaceOrderRequest, orderID string, trades []ExecutedTrade, err error) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	rl.orders++
+	result := "accepted"
+	if err != nil {
+		rl.rejected++
+		result = "rejected: " + err.Error()
+	}
+
+	fmt.Fprintf(rl.orderHash, "%d|%d|%s|%s|%s|%s|%s|%s|%s\n",
+		rl.orders,
+		timestamp.UnixNano(),
+		source,
+		order.Symbol,
+		order.Side,
+		order.Type,
+		strconv.FormatFloat(order.Quantity, 'g', -1, 64),
+		strconv.FormatFloat(order.Price, 'g', -1, 64),
+		result,
+	)
+
+	if orderID == "" || err != nil {
+		return
+	}
+
+	rl.reporting = true
+	rl.resting[orderID] = &restingOrder{sequence: rl.orders, remaining: order.Quantity}
+	for _, trade := range trades {
+		rl.trades++
+		fmt.Fprintf(rl.tradeHash, "%d|%d|%s|%s|%s|%d|%d\n",
+			rl.trades,
+			timestamp.UnixNano(),
+			trade.Symbol,
+			strconv.FormatFloat(trade.Price, 'g', -1, 64),
+			strconv.FormatFloat(trade.Quantity, 'g', -1, 64),
+			rl.fill(trade.BuyOrderID, trade.Quantity),
+			rl.fill(trade.SellOrderID, trade.Quantity),
+		)
+	}
+}
+
+// fill takes a trade's quantity off a resting order and returns the order's
+// submission sequence, 0 for orders the run didn't submit
+func (rl *runLog) fill(orderID string, quantity float64) int64 {
+	order, ok := rl.resting[orderID]
+	if !ok {
+		return 0
+	}
+	order.remaining -= quantity
+	if order.remaining <= 1e-9 {
+		delete(rl.resting, orderID)
+	}
+	return order.sequence
+}
+
+// sum fills in the hashes and counts of a run summary
+func (rl *runLog) sum(summary *RunSummary) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	summary.OrdersSubmitted = rl.orders
+	summary.OrdersRejected = rl.rejected
+	summary.TradesExecuted = rl.trades
+	summary.OrderLogHash = hex.EncodeToString(rl.orderHash.Sum(nil))
+	if rl.reporting {
+		summary.TradeHash = hex.EncodeToString(rl.tradeHash.Sum(nil))
+	}
+}
+
+// RunDeterministic runs a deterministic simulation to the end of its
+// duration and returns its summary
+func (rs *RealisticSimulator) RunDeterministic(ctx context.Context, config SimulationConfig) (RunSummary, error) {
+	config.Deterministic = true
+	if err := rs.StartSimulation(ctx, config); err != nil {
+		return RunSummary{}, err
+	}
+
+	rs.mu.RLock()
+	done := rs.doneChan
+	rs.mu.RUnlock()
+	<-done
+
+	summary := rs.GetRunSummary()
+	if err := rs.StopSimulation(); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// GetRunSummary returns the summary of the current or last deterministic
+// run
+func (rs *RealisticSimulator) GetRunSummary() RunSummary {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return rs.runSummary()
+}
+
+// runSummary builds the run summary; the caller holds rs.mu
+func (rs *RealisticSimulator) runSummary() RunSummary {
+	summary := RunSummary{
+		Seed:      rs.config.Seed,
+		StartTime: rs.stats.StartTime,
+		EndTime:   rs.clock.Now(),
+		Ticks:     rs.ticks,
+		Completed: rs.completed,
+	}
+	if rs.runLog != nil {
+		rs.runLog.sum(&summary)
+	}
+	return summary
+}
+
+// runDeterministic is the single event loop of a deterministic run. It
+// advances the virtual clock to the next due worker and runs it, so workers
+// always run in the same order: by due time, then in the order they are
+// listed. Scheduled workers, such as the event calendar, are due whenever
+// their schedule says. The loop runs until the simulation duration has
+// passed on the virtual clock.
+func (rs *RealisticSimulator) runDeterministic(ctx context.Context, workers []*Worker) {
+	defer close(rs.doneChan)
+
+	start := rs.clock.Now()
+	end := start.Add(rs.config.SimulationDuration)
+
+	var scheduled []*Worker
+	var next []time.Time
+	for _, worker := range workers {
+		if worker.interval <= 0 && worker.schedule == nil {
+			continue
+		}
+		scheduled = append(scheduled, worker)
+		next = append(next, start.Add(worker.interval))
+	}
+
+	for {
+		select {
+		case <-rs.stopChan:
+			log.Println("Stop signal received")
+			return
+		case <-ctx.Done():
+			log.Println("Context cancelled")
+			return
+		default:
+		}
+
+		due := -1
+		for i, worker := range scheduled {
+			if worker.schedule != nil {
+				at, ok := worker.schedule()
+				if !ok {
+					continue
+				}
+				next[i] = at
+			}
+			if due < 0 || next[i].Before(next[due]) {
+				due = i
+			}
+		}
+		if due < 0 || next[due].After(end) {
+			break
+		}
+
+		rs.virtualClock.AdvanceTo(next[due])
+		rs.runWork(scheduled[due])
+		if scheduled[due].schedule == nil {
+			next[due] = next[due].Add(scheduled[due].interval)
+		}
+
+		rs.mu.Lock()
+		rs.ticks++
+		rs.mu.Unlock()
+	}
+
+	rs.mu.Lock()
+	rs.completed = true
+	summary := rs.runSummary()
+	rs.mu.Unlock()
+
+	log.Printf("Deterministic simulation completed: seed %d, %d ticks, %d orders, %d trades, order log hash %s, trade hash %s",
+		summary.Seed, summary.Ticks, summary.OrdersSubmitted, summary.TradesExecuted, summary.OrderLogHash, summary.TradeHash)
+}
@@ -2,6 +2,7 @@ package simulation
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -14,6 +15,7 @@ type IntegrationTradingEngine struct {
 	orderBook    map[string]*OrderBook
 	orderHistory []dto.PlaceOrderRequest
 	tradeHistory []Trade
+	orders       int
 	mu           sync.RWMutex
 }
 
@@ -58,9 +60,33 @@ func NewIntegrationTradingEngine() *IntegrationTradingEngine {
 
 // PlaceOrder places an order in the integration engine
 func (ite *IntegrationTradingEngine) PlaceOrder(order dto.PlaceOrderRequest) (interface{}, error) {
+	response, _ := ite.placeOrder(order)
+	return response, nil
+}
+
+// PlaceOrderWithTrades places an order and reports the trades it executed
+func (ite *IntegrationTradingEngine) PlaceOrderWithTrades(order dto.PlaceOrderRequest) (string, []ExecutedTrade, error) {
+	response, trades := ite.placeOrder(order)
+
+	executed := make([]ExecutedTrade, len(trades))
+	for i, trade := range trades {
+		executed[i] = ExecutedTrade{
+			Symbol:      trade.Symbol,
+			Price:       trade.Price,
+			Quantity:    trade.Quantity,
+			BuyOrderID:  trade.BuyOrderID,
+			SellOrderID: trade.SellOrderID,
+		}
+	}
+	return response["order_id"].(string), executed, nil
+}
+
+func (ite *IntegrationTradingEngine) placeOrder(order dto.PlaceOrderRequest) (map[string]interface{}, []Trade) {
 	ite.mu.Lock()
 	defer ite.mu.Unlock()
 
+	traded := len(ite.tradeHistory)
+
 	// Add to order history
 	ite.orderHistory = append(ite.orderHistory, order)
 
@@ -79,7 +105,7 @@ func (ite *IntegrationTradingEngine) PlaceOrder(order dto.PlaceOrderRequest) (in
 
 	// Create order
 	newOrder := Order{
-		ID:       generateOrderID(),
+		ID:       ite.nextOrderID(),
 		Side:     order.Side,
 		Quantity: order.Quantity,
 		UserID:   "simulated_user", // Default user ID for simulation
@@ -116,7 +142,9 @@ func (ite *IntegrationTradingEngine) PlaceOrder(order dto.PlaceOrderRequest) (in
 		response["status"] = "OPEN"
 	}
 
-	return response, nil
+	trades := make([]Trade, len(ite.tradeHistory)-traded)
+	copy(trades, ite.tradeHistory[traded:])
+	return response, trades
 }
 
 // GetOrderBook returns the current order book for a symbol
@@ -258,8 +286,10 @@ func min(a, b float64) float64 {
 	return b
 }
 
-func generateOrderID() string {
-	return "order_" + time.Now().Format("20060102150405") + "_" + string(rune(time.Now().Nanosecond()%1000))
+// nextOrderID returns a new order ID; the caller holds ite.mu
+func (ite *IntegrationTradingEngine) nextOrderID() string {
+	ite.orders++
+	return fmt.Sprintf("order_%d", ite.orders)
 }
 
 func generateTradeID() string {
@@ -323,6 +353,63 @@ func TestIntegration_FullSimulationWithTradingEngine(t *testing.T) {
 		stats.OrdersGenerated, stats.PriceUpdates, stats.EventsTriggered)
 }
 
+func TestIntegration_DeterministicRunsAreReproducible(t *testing.T) {
+	run := func(seed int64) (RunSummary, []Trade) {
+		priceGen := NewRealisticPriceGenerator(DefaultPriceGeneratorConfig())
+		orderGen := NewRealisticOrderGenerator(DefaultOrderGeneratorConfig())
+		eventGen := NewPatternEventGenerator(DefaultEventGeneratorConfig())
+		engine := NewIntegrationTradingEngine()
+		simulator := NewRealisticSimulator(priceGen, orderGen, eventGen, engine)
+
+		config := DefaultSimulationConfig()
+		config.SimulationDuration = 1 * time.Minute
+		config.NewsEventFrequency = 10 * time.Second
+		config.Seed = seed
+
+		summary, err := simulator.RunDeterministic(context.Background(), config)
+		if err != nil {
+			t.Fatalf("Deterministic run failed: %v", err)
+		}
+		return summary, engine.GetTradeHistory()
+	}
+
+	first, firstTrades := run(42)
+	second, secondTrades := run(42)
+
+	if !first.Completed {
+		t.Error("Expected the run to complete its duration")
+	}
+	if first.OrdersSubmitted == 0 {
+		t.Fatal("Expected orders to be submitted")
+	}
+	if !first.EndTime.Equal(DeterministicEpoch.Add(time.Minute)) {
+		t.Errorf("Expected the run to end at %v of virtual time, got %v", DeterministicEpoch.Add(time.Minute), first.EndTime)
+	}
+	if first.OrderLogHash != second.OrderLogHash {
+		t.Errorf("Expected identical order log hashes, got %s and %s", first.OrderLogHash, second.OrderLogHash)
+	}
+	if first.TradesExecuted == 0 || first.TradeHash == "" {
+		t.Fatalf("Expected the run to hash its trades, got %+v", first)
+	}
+	if first.TradesExecuted != int64(len(firstTrades)) {
+		t.Errorf("Expected %d trades in the summary, got %d", len(firstTrades), first.TradesExecuted)
+	}
+	if first != second {
+		t.Errorf("Expected identical runs, got %+v and %+v", first, second)
+	}
+	if len(firstTrades) != len(secondTrades) {
+		t.Errorf("Expected identical trades, got %d and %d", len(firstTrades), len(secondTrades))
+	}
+
+	other, _ := run(7)
+	if other.OrderLogHash == first.OrderLogHash || other.TradeHash == first.TradeHash {
+		t.Error("Expected a different seed to produce different orders and trades")
+	}
+
+	t.Logf("Deterministic run: %d ticks, %d orders, %d trades, order log hash %s, trade hash %s",
+		first.Ticks, first.OrdersSubmitted, first.TradesExecuted, first.OrderLogHash, first.TradeHash)
+}
+
 func TestIntegration_OrderMatching(t *testing.T) {
 	engine := NewIntegrationTradingEngine()
 
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/pkg/clock"
 )
 
 // TradingEngine interface for simulation integration
@@ -13,6 +14,25 @@ type TradingEngine interface {
 	PlaceOrder(order dto.PlaceOrderRequest) (interface{}, error)
 }
 
+// TradeReportingEngine is a TradingEngine that reports the trades each order
+// executes. Deterministic runs hash the trades of engines that implement it.
+type TradeReportingEngine interface {
+	TradingEngine
+
+	// PlaceOrderWithTrades places an order and returns its ID and the trades
+	// it executed, in execution order
+	PlaceOrderWithTrades(order dto.PlaceOrderRequest) (string, []ExecutedTrade, error)
+}
+
+// ExecutedTrade is a trade reported by a TradeReportingEngine
+type ExecutedTrade struct {
+	Symbol      string
+	Price       float64
+	Quantity    float64
+	BuyOrderID  string
+	SellOrderID string
+}
+
 // MarketSimulator interface defines the main simulation control
 type MarketSimulator interface {
 	// StartSimulation begins the market simulation with given parameters
@@ -49,6 +69,41 @@ type PriceGenerator interface {
 	Reset()
 }
 
+// CorrelatedPriceGenerator is implemented by price generators whose symbols
+// move together
+type CorrelatedPriceGenerator interface {
+	// PropagateShock spreads moves of a few symbols to the others through
+	// their correlations and returns the move of every symbol
+	PropagateShock(moves map[string]float64) map[string]float64
+
+	// RealizedCorrelations returns the correlations of recent returns
+	RealizedCorrelations() map[string]map[string]float64
+}
+
+// ClockedGenerator is implemented by generators that read the time from an
+// injectable clock
+type ClockedGenerator interface {
+	SetClock(clk clock.Clock)
+}
+
+// ReproducibleGenerator is implemented by generators that can be replayed
+// exactly: deterministic runs reseed them and move them onto the run's
+// virtual clock
+type ReproducibleGenerator interface {
+	// Reseed restarts the generator's random numbers from seed and makes it
+	// read the time from clk
+	Reseed(seed int64, clk clock.Clock)
+}
+
+// EventAwareGenerator is implemented by order generators whose users see
+// market events as they happen, e.g. momentum traders following an
+// earnings surprise
+type EventAwareGenerator interface {
+	// ObserveEvent shows the users an event and the direction it moved
+	// prices in: positive up, negative down
+	ObserveEvent(event MarketEvent, direction float64)
+}
+
 // OrderGenerator interface defines order generation behavior
 type OrderGenerator interface {
 	// GenerateRealisticOrders creates orders based on current market conditions
@@ -108,6 +163,16 @@ type SimulationConfig struct {
 	EnablePatterns        bool               `json:"enable_patterns"`
 	PatternProbabilities  map[string]float64 `json:"pattern_probabilities"`
 	ReactionDelays        ReactionDelays     `json:"reaction_delays"`
+
+	// Deterministic runs the simulation on a virtual clock from a single
+	// event loop seeded with Seed, so that runs with the same seed and
+	// configuration submit identical orders. The run completes as fast as
+	// possible instead of in real time.
+	Deterministic bool  `json:"deterministic"`
+	Seed          int64 `json:"seed"`
+
+	// EventCalendar schedules market events at exact times of the run
+	EventCalendar []ScheduledEvent `json:"event_calendar,omitempty"`
 }
 
 // SimulationStatus represents current simulation state
@@ -121,6 +186,12 @@ type SimulationStatus struct {
 	ActivePatterns      []string           `json:"active_patterns"`
 	MarketCondition     MarketCondition    `json:"market_condition"`
 	LastError           error              `json:"last_error,omitempty"`
+
+	// Correlations are the realized correlations of the symbols' returns
+	Correlations map[string]map[string]float64 `json:"correlations,omitempty"`
+
+	// RunSummary summarizes a deterministic run
+	RunSummary *RunSummary `json:"run_summary,omitempty"`
 }
 
 // Market Condition Enums and Types
@@ -303,6 +374,9 @@ type PatternPhase struct {
 	OrderIntensity  float64         `json:"order_intensity"`
 	PriceDirection  TrendDirection  `json:"price_direction"`
 	UserBehavior    map[UserBehaviorPattern]float64 `json:"user_behavior"`
+
+	// Events are injected into the market when the phase begins
+	Events []MarketEvent `json:"events,omitempty"`
 }
 
 // PatternTrigger defines conditions that activate pattern changes
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
 	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/pkg/clock"
 )
 
 // RealisticSimulator implements MarketSimulator interface
@@ -23,15 +25,22 @@ type RealisticSimulator struct {
 	config           SimulationConfig
 	status           SimulationStatus
 	isRunning        bool
+	stopping         bool // StopSimulation is waiting for the workers
 	stopChan         chan struct{}
 	doneChan         chan struct{}
 	mu               sync.RWMutex
 	orderSemaphore   chan struct{} // Limits concurrent order operations
 
 	// Pattern management
+	patterns         *PatternManager
 	activePatterns   map[string]*SimulationPattern
+	activePhases     map[string]int // phase of each active pattern applied last
 	patternHistory   []PatternEvent
 
+	// Event calendar of the run
+	calendar           []*calendarEntry
+	lastCalendarUpdate time.Time
+
 	// Statistics tracking
 	stats            SimulationStatistics
 	lastStatsUpdate  time.Time
@@ -41,6 +50,19 @@ type RealisticSimulator struct {
 	orderWorker      *Worker
 	eventWorker      *Worker
 	patternWorker    *Worker
+	calendarWorker   *Worker
+
+	// Time source and random numbers of the run. Deterministic runs use a
+	// virtual clock and a seeded generator, and hash their orders and
+	// trades; other runs use liveClock.
+	clock        clock.Clock
+	liveClock    clock.Clock
+	virtualClock *clock.Virtual
+	rng          *rand.Rand
+	rngMu        sync.Mutex
+	runLog       *runLog
+	ticks        int64
+	completed    bool
 }
 
 // Worker represents a background worker goroutine
@@ -50,6 +72,9 @@ type Worker struct {
 	stopChan chan struct{}
 	doneChan chan struct{}
 	workFunc func() error
+
+	// schedule returns when a worker without an interval is next due
+	schedule func() (time.Time, bool)
 }
 
 // PatternEvent records pattern activation/deactivation
@@ -93,7 +118,9 @@ func NewRealisticSimulator(
 		orderGenerator: orderGen,
 		eventGenerator: eventGen,
 		tradingEngine:  tradingEngine,
+		patterns:       NewPatternManager(eventGen),
 		activePatterns: make(map[string]*SimulationPattern),
+		activePhases:   make(map[string]int),
 		patternHistory: make([]PatternEvent, 0),
 		orderSemaphore: make(chan struct{}, 10), // Limit to 10 concurrent orders
 		status: SimulationStatus{
@@ -104,6 +131,9 @@ func NewRealisticSimulator(
 		stats: SimulationStatistics{
 			PerformanceMetrics: PerformanceMetrics{},
 		},
+		clock:     clock.Real(),
+		liveClock: clock.Real(),
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
@@ -128,11 +158,22 @@ func (rs *RealisticSimulator) StartSimulation(ctx context.Context, config Simula
 	rs.isRunning = true
 	rs.stopChan = make(chan struct{})
 	rs.doneChan = make(chan struct{})
+	rs.ticks = 0
+	rs.completed = false
+
+	if config.Deterministic {
+		rs.prepareDeterministicRun(config.Seed)
+	} else {
+		rs.clock = rs.liveClock
+		rs.virtualClock = nil
+		rs.runLog = nil
+		rs.setGeneratorClocks(rs.liveClock)
+	}
 
 	// Initialize status
 	rs.status = SimulationStatus{
 		IsRunning:       true,
-		StartTime:       time.Now(),
+		StartTime:       rs.clock.Now(),
 		RunningDuration: 0,
 		CurrentPrices:   make(map[string]float64),
 		ActivePatterns:  make([]string, 0),
@@ -141,10 +182,13 @@ func (rs *RealisticSimulator) StartSimulation(ctx context.Context, config Simula
 
 	// Initialize statistics
 	rs.stats = SimulationStatistics{
-		StartTime:         time.Now(),
+		StartTime:         rs.clock.Now(),
 		PerformanceMetrics: PerformanceMetrics{},
 	}
 
+	// Schedule the event calendar from the start of the run
+	rs.scheduleCalendar(rs.clock.Now())
+
 	// Copy initial prices
 	for symbol, price := range config.InitialPrices {
 		rs.status.CurrentPrices[symbol] = price
@@ -158,8 +202,13 @@ func (rs *RealisticSimulator) StartSimulation(ctx context.Context, config Simula
 	// Start background workers
 	rs.startWorkers(ctx)
 
-	log.Printf("Market simulation started with %d symbols for %v",
-		len(config.Symbols), config.SimulationDuration)
+	if config.Deterministic {
+		log.Printf("Deterministic market simulation started with %d symbols for %v of virtual time, seed %d",
+			len(config.Symbols), config.SimulationDuration, config.Seed)
+	} else {
+		log.Printf("Market simulation started with %d symbols for %v",
+			len(config.Symbols), config.SimulationDuration)
+	}
 
 	return nil
 }
@@ -167,9 +216,8 @@ func (rs *RealisticSimulator) StartSimulation(ctx context.Context, config Simula
 // StopSimulation gracefully stops the simulation
 func (rs *RealisticSimulator) StopSimulation() error {
 	rs.mu.Lock()
-	defer rs.mu.Unlock()
-
-	if !rs.isRunning {
+	if !rs.isRunning || rs.stopping {
+		rs.mu.Unlock()
 		return SimulationError{
 			Code:    ErrorCodeSimulationFailed,
 			Message: "Simulation is not running",
@@ -179,20 +227,28 @@ func (rs *RealisticSimulator) StopSimulation() error {
 	log.Println("Stopping market simulation...")
 
 	// Signal all workers to stop
+	rs.stopping = true
 	close(rs.stopChan)
+	done := rs.doneChan
+	rs.mu.Unlock()
 
-	// Wait for workers to finish (with timeout)
+	// Wait for workers to finish (with timeout). The lock is released
+	// meanwhile, as workers take it to finish their round.
 	select {
-	case <-rs.doneChan:
+	case <-done:
 		log.Println("All workers stopped gracefully")
 	case <-time.After(5 * time.Second):
 		log.Println("Warning: Workers did not stop within timeout")
 	}
 
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
 	// Update final status
+	rs.stopping = false
 	rs.isRunning = false
 	rs.status.IsRunning = false
-	rs.stats.TotalRuntime = time.Since(rs.stats.StartTime)
+	rs.stats.TotalRuntime = rs.clock.Now().Sub(rs.stats.StartTime)
 
 	// Reset generators
 	rs.priceGenerator.Reset()
@@ -224,8 +280,9 @@ func (rs *RealisticSimulator) InjectVolatility(pattern VolatilityPattern, durati
 	rs.priceGenerator.SimulateVolatility(pattern, intensity)
 
 	// Schedule volatility removal
+	clk := rs.clock
 	go func() {
-		time.Sleep(duration)
+		clk.Sleep(duration)
 		rs.priceGenerator.SimulateVolatility(VolatilityDecay, 0.1)
 	}()
 
@@ -240,7 +297,7 @@ func (rs *RealisticSimulator) GetSimulationStatus() SimulationStatus {
 
 	// Update running duration
 	if rs.isRunning {
-		rs.status.RunningDuration = time.Since(rs.status.StartTime)
+		rs.status.RunningDuration = rs.clock.Now().Sub(rs.status.StartTime)
 	}
 
 	// Copy active patterns
@@ -255,6 +312,15 @@ func (rs *RealisticSimulator) GetSimulationStatus() SimulationStatus {
 	rs.status.PriceUpdates = rs.stats.PriceUpdates
 	rs.status.LastError = rs.stats.LastError
 
+	if correlated, ok := rs.priceGenerator.(CorrelatedPriceGenerator); ok {
+		rs.status.Correlations = correlated.RealizedCorrelations()
+	}
+
+	if rs.runLog != nil {
+		summary := rs.runSummary()
+		rs.status.RunSummary = &summary
+	}
+
 	return rs.status
 }
 
@@ -368,26 +434,44 @@ func (rs *RealisticSimulator) startWorkers(ctx context.Context) {
 		workFunc: rs.managePatterns,
 	}
 
-	workers := []*Worker{rs.priceWorker, rs.orderWorker, rs.eventWorker, rs.patternWorker}
+	// Event calendar worker, due whenever the next scheduled event is
+	rs.calendarWorker = &Worker{
+		name:     "calendar_worker",
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+		workFunc: rs.fireScheduledEvents,
+		schedule: rs.nextCalendarEvent,
+	}
+
+	workers := []*Worker{rs.priceWorker, rs.orderWorker, rs.eventWorker, rs.patternWorker, rs.calendarWorker}
+
+	// Deterministic runs execute the workers one at a time on virtual time
+	if rs.config.Deterministic {
+		go rs.runDeterministic(ctx, workers)
+		return
+	}
 
 	// Start all workers
 	for _, worker := range workers {
 		go rs.runWorker(worker)
 	}
 
-	// Supervisor goroutine
+	// Supervisor goroutine, timed while the caller holds the lock
+	timer := rs.clock.NewTimer(rs.config.SimulationDuration)
+	stop := rs.stopChan
 	go func() {
 		defer close(rs.doneChan)
 
 		// Wait for stop signal or simulation duration
 		select {
-		case <-rs.stopChan:
+		case <-stop:
 			log.Println("Stop signal received")
-		case <-time.After(rs.config.SimulationDuration):
+		case <-timer.C():
 			log.Println("Simulation duration completed")
 		case <-ctx.Done():
 			log.Println("Context cancelled")
 		}
+		timer.Stop()
 
 		// Stop all workers
 		for _, worker := range workers {
@@ -404,7 +488,12 @@ func (rs *RealisticSimulator) startWorkers(ctx context.Context) {
 func (rs *RealisticSimulator) runWorker(worker *Worker) {
 	defer close(worker.doneChan)
 
-	ticker := time.NewTicker(worker.interval)
+	if worker.schedule != nil {
+		rs.runScheduledWorker(worker)
+		return
+	}
+
+	ticker := rs.clock.NewTicker(worker.interval)
 	defer ticker.Stop()
 
 	log.Printf("Starting worker: %s", worker.name)
@@ -414,27 +503,133 @@ func (rs *RealisticSimulator) runWorker(worker *Worker) {
 		case <-worker.stopChan:
 			log.Printf("Worker %s received stop signal", worker.name)
 			return
-		case <-ticker.C:
-			start := time.Now()
-			if err := worker.workFunc(); err != nil {
-				rs.mu.Lock()
-				rs.stats.ErrorCount++
-				rs.stats.LastError = err
-				rs.mu.Unlock()
-				log.Printf("Worker %s error: %v", worker.name, err)
-			}
+		case <-ticker.C():
+			rs.runWork(worker)
+		}
+	}
+}
 
-			// Update performance metrics
-			rs.updatePerformanceMetrics(worker.name, time.Since(start))
+// runScheduledWorker runs a worker whenever its schedule is due, until it
+// has nothing left to do
+func (rs *RealisticSimulator) runScheduledWorker(worker *Worker) {
+	for {
+		select {
+		case <-worker.stopChan:
+			return
+		default:
 		}
+
+		due, ok := worker.schedule()
+		if !ok {
+			return
+		}
+
+		timer := rs.clock.NewTimer(due.Sub(rs.clock.Now()))
+		select {
+		case <-worker.stopChan:
+			timer.Stop()
+			return
+		case <-timer.C():
+			rs.runWork(worker)
+		}
+	}
+}
+
+// runWork runs one round of a worker's work
+func (rs *RealisticSimulator) runWork(worker *Worker) {
+	start := time.Now()
+	if err := worker.workFunc(); err != nil {
+		rs.mu.Lock()
+		rs.stats.ErrorCount++
+		rs.stats.LastError = err
+		rs.mu.Unlock()
+		log.Printf("Worker %s error: %v", worker.name, err)
+	}
+
+	// Update performance metrics
+	rs.updatePerformanceMetrics(worker.name, time.Since(start))
+}
+
+// SetClock sets the clock simulations run on unless they are
+// deterministic, e.g. a scaled clock to run a trading day in minutes. It
+// takes effect when the next simulation starts.
+func (rs *RealisticSimulator) SetClock(clk clock.Clock) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.liveClock = clk
+}
+
+// Patterns returns the patterns the simulation activates by name, the
+// standard ones and any loaded from scenario files
+func (rs *RealisticSimulator) Patterns() *PatternManager {
+	return rs.patterns
+}
+
+// setGeneratorClocks moves the generators onto clk
+func (rs *RealisticSimulator) setGeneratorClocks(clk clock.Clock) {
+	generators := []interface{}{rs.priceGenerator, rs.orderGenerator, rs.eventGenerator}
+	for _, generator := range generators {
+		if clocked, ok := generator.(ClockedGenerator); ok {
+			clocked.SetClock(clk)
+		}
+	}
+}
+
+// prepareDeterministicRun moves the simulator and its generators onto a
+// virtual clock and seeds them; the caller holds rs.mu
+func (rs *RealisticSimulator) prepareDeterministicRun(seed int64) {
+	rs.virtualClock = clock.NewVirtual(DeterministicEpoch)
+	rs.clock = rs.virtualClock
+	rs.rngMu.Lock()
+	rs.rng = rand.New(rand.NewSource(seed))
+	rs.rngMu.Unlock()
+	rs.runLog = newRunLog()
+
+	// Start from a clean price history, and give every generator a seed of
+	// its own
+	rs.priceGenerator.Reset()
+	generators := []interface{}{rs.priceGenerator, rs.orderGenerator, rs.eventGenerator}
+	for i, generator := range generators {
+		if reproducible, ok := generator.(ReproducibleGenerator); ok {
+			reproducible.Reseed(seed+int64(i)+1, rs.virtualClock)
+		}
+	}
+}
+
+// randFloat returns a random number in [0, 1) from the simulator's own
+// generator, which workers share
+func (rs *RealisticSimulator) randFloat() float64 {
+	rs.rngMu.Lock()
+	defer rs.rngMu.Unlock()
+	return rs.rng.Float64()
+}
+
+// placeOrder submits an order to the trading engine and records it, and the
+// trades it executed, in the run log of a deterministic run
+func (rs *RealisticSimulator) placeOrder(source string, order dto.PlaceOrderRequest) error {
+	if rs.runLog == nil {
+		_, err := rs.tradingEngine.PlaceOrder(order)
+		return err
 	}
+
+	var orderID string
+	var trades []ExecutedTrade
+	var err error
+	if reporting, ok := rs.tradingEngine.(TradeReportingEngine); ok {
+		orderID, trades, err = reporting.PlaceOrderWithTrades(order)
+	} else {
+		_, err = rs.tradingEngine.PlaceOrder(order)
+	}
+	rs.runLog.record(rs.clock.Now(), source, order, orderID, trades, err)
+	return err
 }
 
 func (rs *RealisticSimulator) updatePrices() error {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	timeElapsed := time.Since(rs.stats.StartTime)
+	timeElapsed := rs.clock.Now().Sub(rs.stats.StartTime)
 
 	for _, symbol := range rs.config.Symbols {
 		currentPrice := rs.status.CurrentPrices[symbol]
@@ -444,6 +639,8 @@ func (rs *RealisticSimulator) updatePrices() error {
 		rs.stats.PriceUpdates++
 	}
 
+	rs.applyCalendarEffects(rs.clock.Now())
+
 	return nil
 }
 
@@ -470,8 +667,7 @@ func (rs *RealisticSimulator) generateOrders() error {
 		// Submit orders to trading engine
 		for _, orderReq := range orders {
 			if rs.tradingEngine != nil {
-				_, err := rs.tradingEngine.PlaceOrder(orderReq)
-				if err != nil {
+				if err := rs.placeOrder("flow", orderReq); err != nil {
 					log.Printf("Failed to place simulated order: %v", err)
 				}
 			}
@@ -491,7 +687,7 @@ func (rs *RealisticSimulator) processEvents() error {
 	}
 
 	// Generate random market event
-	if rand.Float64() < 0.3 { // 30% chance of event per interval
+	if rs.randFloat() < 0.3 { // 30% chance of event per interval
 		event := rs.eventGenerator.GenerateMarketEvent()
 
 		if err := rs.eventGenerator.InjectEvent(event); err != nil {
@@ -528,29 +724,42 @@ func (rs *RealisticSimulator) managePatterns() error {
 func (rs *RealisticSimulator) calculateVolatilityIntensity(pattern VolatilityPattern) float64 {
 	switch pattern {
 	case VolatilitySpike:
-		return 0.8 + rand.Float64()*0.2 // 0.8 to 1.0
+		return 0.8 + rs.randFloat()*0.2 // 0.8 to 1.0
 	case VolatilityDecay:
-		return 0.1 + rand.Float64()*0.2 // 0.1 to 0.3
+		return 0.1 + rs.randFloat()*0.2 // 0.1 to 0.3
 	case VolatilityOscillate:
-		return 0.4 + rand.Float64()*0.4 // 0.4 to 0.8
+		return 0.4 + rs.randFloat()*0.4 // 0.4 to 0.8
 	case VolatilityRandom:
-		return rand.Float64() // 0.0 to 1.0
+		return rs.randFloat() // 0.0 to 1.0
 	case VolatilityNews:
-		return 0.5 + rand.Float64()*0.3 // 0.5 to 0.8
+		return 0.5 + rs.randFloat()*0.3 // 0.5 to 0.8
 	default:
 		return 0.3
 	}
 }
 
 func (rs *RealisticSimulator) applyEventEffects(event MarketEvent) {
-	// Apply price impact
+	// Determine direction based on event type and severity
+	rs.applyEventMove(event, rs.determineEventDirection(event))
+}
+
+// applyEventMove moves the affected symbols together in direction, shows
+// the event to the simulated users and submits their reaction
+func (rs *RealisticSimulator) applyEventMove(event MarketEvent, direction float64) {
+	moves := make(map[string]float64, len(event.AffectedSymbols))
 	for _, symbol := range event.AffectedSymbols {
-		if currentPrice, exists := rs.status.CurrentPrices[symbol]; exists {
-			priceChange := currentPrice * (event.PriceImpact / 100.0)
+		moves[symbol] = event.PriceImpact / 100.0 * direction
+	}
+
+	// Spread the shock to correlated symbols
+	if correlated, ok := rs.priceGenerator.(CorrelatedPriceGenerator); ok {
+		moves = correlated.PropagateShock(moves)
+	}
 
-			// Determine direction based on event type and severity
-			direction := rs.determineEventDirection(event)
-			newPrice := currentPrice + (priceChange * direction)
+	// Apply price impact
+	for symbol, move := range moves {
+		if currentPrice, exists := rs.status.CurrentPrices[symbol]; exists {
+			newPrice := currentPrice * (1 + move)
 
 			if newPrice > 0 {
 				rs.status.CurrentPrices[symbol] = newPrice
@@ -558,15 +767,25 @@ func (rs *RealisticSimulator) applyEventEffects(event MarketEvent) {
 		}
 	}
 
+	// Let users that follow events see it
+	if aware, ok := rs.orderGenerator.(EventAwareGenerator); ok {
+		aware.ObserveEvent(event, direction)
+	}
+
 	// Trigger user behavior changes
 	behaviorPattern := rs.mapEventToBehavior(event)
 	intensity := rs.mapSeverityToIntensity(event.Severity)
 
 	orders := rs.orderGenerator.SimulateUserBehavior(behaviorPattern, intensity)
 
-	// Submit behavior-driven orders
+	// Submit behavior-driven orders; deterministic runs submit them in
+	// order
 	for _, orderReq := range orders {
-		if rs.tradingEngine != nil {
+		if rs.tradingEngine != nil && rs.runLog != nil {
+			if err := rs.placeOrder("event", orderReq); err != nil {
+				log.Printf("Failed to place event-driven order: %v", err)
+			}
+		} else if rs.tradingEngine != nil {
 			go func(req dto.PlaceOrderRequest) {
 				// Acquire semaphore to limit concurrent operations
 				rs.orderSemaphore <- struct{}{}
@@ -585,7 +804,7 @@ func (rs *RealisticSimulator) determineEventDirection(event MarketEvent) float64
 	switch event.Type {
 	case EventEarnings:
 		// Random direction for earnings
-		if rand.Float64() < 0.6 {
+		if rs.randFloat() < 0.6 {
 			return 1.0 // Positive earnings
 		}
 		return -1.0
@@ -593,9 +812,9 @@ func (rs *RealisticSimulator) determineEventDirection(event MarketEvent) float64
 		return -0.5 // Usually negative
 	case EventEconomic:
 		// Context-dependent
-		return (rand.Float64() - 0.5) * 2
+		return (rs.randFloat() - 0.5) * 2
 	default:
-		return (rand.Float64() - 0.5) * 2 // Random direction
+		return (rs.randFloat() - 0.5) * 2 // Random direction
 	}
 }
 
@@ -635,8 +854,15 @@ func (rs *RealisticSimulator) checkPatternTriggers() {
 	// This would check various market conditions and activate patterns
 	// For now, implement basic random pattern activation
 
-	for patternName, probability := range rs.config.PatternProbabilities {
-		if rand.Float64() < probability {
+	// Draw in a stable order so that a seed activates the same patterns
+	patternNames := make([]string, 0, len(rs.config.PatternProbabilities))
+	for patternName := range rs.config.PatternProbabilities {
+		patternNames = append(patternNames, patternName)
+	}
+	sort.Strings(patternNames)
+
+	for _, patternName := range patternNames {
+		if rs.randFloat() < rs.config.PatternProbabilities[patternName] {
 			rs.activatePattern(patternName)
 		}
 	}
@@ -657,7 +883,7 @@ func (rs *RealisticSimulator) activatePattern(patternName string) {
 		rs.patternHistory = append(rs.patternHistory, PatternEvent{
 			PatternName: patternName,
 			EventType:   "activated",
-			Timestamp:   time.Now(),
+			Timestamp:   rs.clock.Now(),
 		})
 		rs.stats.PatternsActivated++
 
@@ -666,38 +892,36 @@ func (rs *RealisticSimulator) activatePattern(patternName string) {
 }
 
 func (rs *RealisticSimulator) createPattern(patternName string) *SimulationPattern {
-	// Create pattern based on name
-	// This is a simplified implementation
-	switch patternName {
-	case "flash_crash":
-		return &SimulationPattern{
-			Name:        "Flash Crash",
-			Description: "Sudden dramatic price decline",
-			Duration:    2 * time.Minute,
-		}
-	case "fomo_spike":
-		return &SimulationPattern{
-			Name:        "FOMO Spike",
-			Description: "Fear of missing out price spike",
-			Duration:    5 * time.Minute,
-		}
-	case "whale_dump":
-		return &SimulationPattern{
-			Name:        "Whale Dump",
-			Description: "Large holder selling pressure",
-			Duration:    10 * time.Minute,
-		}
-	default:
-		return nil
-	}
+	return rs.patterns.GetPattern(patternName)
 }
 
 func (rs *RealisticSimulator) updateActivePatterns() {
+	phases := rs.advancePatternPhases()
+
+	// Apply phases outside the lock, since they submit orders
+	for _, phase := range phases {
+		rs.applyPatternPhase(phase)
+	}
+}
+
+// advancePatternPhases expires finished patterns and returns the phases
+// active patterns have entered since the last check
+func (rs *RealisticSimulator) advancePatternPhases() []PatternPhase {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	// Remove expired patterns
-	for name, pattern := range rs.activePatterns {
+	// Walk the patterns in a stable order so that seeded runs apply phases
+	// in the same order
+	names := make([]string, 0, len(rs.activePatterns))
+	for name := range rs.activePatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entered []PatternPhase
+	for _, name := range names {
+		pattern := rs.activePatterns[name]
+
 		// Find activation time from history
 		var activationTime time.Time
 		for i := len(rs.patternHistory) - 1; i >= 0; i-- {
@@ -707,18 +931,86 @@ func (rs *RealisticSimulator) updateActivePatterns() {
 			}
 		}
 
-		if time.Since(activationTime) > pattern.Duration {
+		if rs.clock.Now().Sub(activationTime) > pattern.Duration {
 			delete(rs.activePatterns, name)
+			delete(rs.activePhases, name)
 			rs.patternHistory = append(rs.patternHistory, PatternEvent{
 				PatternName: name,
 				EventType:   "deactivated",
-				Timestamp:   time.Now(),
-				Duration:    time.Since(activationTime),
+				Timestamp:   rs.clock.Now(),
+				Duration:    rs.clock.Now().Sub(activationTime),
 			})
 
-			log.Printf("Pattern deactivated: %s (duration: %v)", name, time.Since(activationTime))
+			log.Printf("Pattern deactivated: %s (duration: %v)", name, rs.clock.Now().Sub(activationTime))
+			continue
 		}
+
+		// Enter the phase the pattern has reached, once
+		index := currentPhase(pattern, rs.clock.Now().Sub(activationTime))
+		if last, applied := rs.activePhases[name]; index >= 0 && (!applied || index > last) {
+			rs.activePhases[name] = index
+			entered = append(entered, pattern.Phases[index])
+		}
+	}
+
+	return entered
+}
+
+// currentPhase returns the index of the phase a pattern is in after
+// elapsed, the last phase once they have all passed, or -1 without phases
+func currentPhase(pattern *SimulationPattern, elapsed time.Duration) int {
+	for i, phase := range pattern.Phases {
+		if elapsed < phase.Duration {
+			return i
+		}
+		elapsed -= phase.Duration
+	}
+	return len(pattern.Phases) - 1
+}
+
+// applyPatternPhase sets the phase's volatility, injects its market events
+// and submits orders from its mix of user behaviors
+func (rs *RealisticSimulator) applyPatternPhase(phase PatternPhase) {
+	volatilityPattern := rs.patterns.mapMarketConditionToVolatility(phase.MarketCondition)
+	rs.priceGenerator.SimulateVolatility(volatilityPattern, phase.VolatilityLevel)
+
+	for _, event := range phase.Events {
+		if rs.eventGenerator == nil {
+			break
+		}
+		if err := rs.eventGenerator.InjectEvent(event); err != nil {
+			log.Printf("Failed to inject pattern event %s: %v", event.ID, err)
+			continue
+		}
+		rs.applyEventEffects(event)
+
+		rs.mu.Lock()
+		rs.stats.EventsTriggered++
+		rs.mu.Unlock()
+	}
+
+	behaviors := make([]UserBehaviorPattern, 0, len(phase.UserBehavior))
+	for behavior := range phase.UserBehavior {
+		behaviors = append(behaviors, behavior)
+	}
+	sort.Slice(behaviors, func(i, j int) bool { return behaviors[i] < behaviors[j] })
+
+	for _, behavior := range behaviors {
+		orders := rs.orderGenerator.SimulateUserBehavior(behavior, phase.UserBehavior[behavior]*phase.OrderIntensity)
+		for _, orderReq := range orders {
+			if rs.tradingEngine != nil {
+				if err := rs.placeOrder("pattern", orderReq); err != nil {
+					log.Printf("Failed to place pattern order: %v", err)
+				}
+			}
+		}
+
+		rs.mu.Lock()
+		rs.stats.OrdersGenerated += int64(len(orders))
+		rs.mu.Unlock()
 	}
+
+	log.Printf("Pattern phase entered: %s (%s)", phase.Name, phase.MarketCondition)
 }
 
 func (rs *RealisticSimulator) updatePerformanceMetrics(workerName string, duration time.Duration) {
@@ -757,7 +1049,7 @@ func (rs *RealisticSimulator) GetStatistics() SimulationStatistics {
 
 	stats := rs.stats
 	if rs.isRunning {
-		stats.TotalRuntime = time.Since(rs.stats.StartTime)
+		stats.TotalRuntime = rs.clock.Now().Sub(rs.stats.StartTime)
 	}
 
 	return stats
@@ -1,12 +1,15 @@
 package simulation
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 	"sync"
 	"time"
 
 	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/seasonality"
 )
 
 // RealisticOrderGenerator implements OrderGenerator interface
@@ -29,13 +32,30 @@ type RealisticOrderGenerator struct {
 	spreadModeling   SpreadModel
 	liquidityModel   LiquidityModel
 
+	// Market events users have seen, by symbol
+	eventSignals map[string][]eventSignal
+
 	// Random number generator
 	rng *rand.Rand
 
+	// Time source; the wall clock unless a deterministic run reseeds the
+	// generator
+	clock clock.Clock
+
+	// Intraday, weekly and holiday profile of the order arrival rate
+	seasonality *seasonality.Profile
+
 	// Configuration
 	config OrderGeneratorConfig
 }
 
+// eventSignal is the direction a market event moved a symbol in, which
+// momentum traders follow until the event is over
+type eventSignal struct {
+	direction float64
+	until     time.Time
+}
+
 // BehaviorModel defines how different user types behave
 type BehaviorModel struct {
 	OrderSizeDistribution  DistributionParams `json:"order_size_distribution"`
@@ -116,7 +136,6 @@ type LiquidityLevel struct {
 // OrderGeneratorConfig configures order generation behavior
 type OrderGeneratorConfig struct {
 	BaseOrderRate      float64           `json:"base_order_rate"`
-	MarketHoursBoost   float64           `json:"market_hours_boost"`
 	VolatilityBoost    float64           `json:"volatility_boost"`
 	NewsEventBoost     float64           `json:"news_event_boost"`
 	UserTypeMix        map[string]float64 `json:"user_type_mix"`
@@ -135,7 +154,10 @@ func NewRealisticOrderGenerator(config OrderGeneratorConfig) *RealisticOrderGene
 		marketSentiment:  SentimentNeutral,
 		behaviorModels:   make(map[UserBehaviorPattern]*BehaviorModel),
 		sentimentFactors: make(map[MarketSentiment]SentimentFactors),
+		eventSignals:     make(map[string][]eventSignal),
 		rng:              rand.New(rand.NewSource(config.RandomSeed)),
+		clock:            clock.Real(),
+		seasonality:      seasonality.Standard(),
 		config:           config,
 		orderStatistics: OrderStatistics{
 			OrdersBySymbol:   make(map[string]int64),
@@ -241,6 +263,53 @@ func (rog *RealisticOrderGenerator) UpdateMarketSentiment(sentiment MarketSentim
 	rog.marketSentiment = sentiment
 }
 
+// SetClock makes the order generator read the time from clk
+func (rog *RealisticOrderGenerator) SetClock(clk clock.Clock) {
+	rog.mu.Lock()
+	defer rog.mu.Unlock()
+
+	rog.clock = clk
+}
+
+// SetSeasonality sets the profile the order arrival rate follows over the
+// day, the week and holidays
+func (rog *RealisticOrderGenerator) SetSeasonality(profile *seasonality.Profile) {
+	rog.mu.Lock()
+	defer rog.mu.Unlock()
+
+	rog.seasonality = profile
+}
+
+// Reseed restarts the random numbers from seed and reads the time from clk
+func (rog *RealisticOrderGenerator) Reseed(seed int64, clk clock.Clock) {
+	rog.mu.Lock()
+	defer rog.mu.Unlock()
+
+	rog.config.RandomSeed = seed
+	rog.rng = rand.New(rand.NewSource(seed))
+	rog.clock = clk
+	rog.eventSignals = make(map[string][]eventSignal)
+}
+
+// ObserveEvent shows the users a market event; momentum traders follow its
+// direction in the affected symbols while it lasts
+func (rog *RealisticOrderGenerator) ObserveEvent(event MarketEvent, direction float64) {
+	rog.mu.Lock()
+	defer rog.mu.Unlock()
+
+	if direction == 0 {
+		return
+	}
+
+	signal := eventSignal{
+		direction: math.Copysign(1, direction),
+		until:     rog.clock.Now().Add(event.Duration),
+	}
+	for _, symbol := range event.AffectedSymbols {
+		rog.eventSignals[symbol] = append(rog.eventSignals[symbol], signal)
+	}
+}
+
 // Private helper methods
 
 func (rog *RealisticOrderGenerator) initializeBehaviorModels() {
@@ -566,27 +635,14 @@ func (rog *RealisticOrderGenerator) calculateOrderGenerationRate(marketCondition
 	sentimentFactors := rog.sentimentFactors[rog.marketSentiment]
 	sentimentMultiplier := sentimentFactors.OrderVolumeMultiplier
 
-	// Time-based adjustments (market hours, etc.)
+	// Time-based adjustments (session, weekday, holidays)
 	timeMultiplier := rog.calculateTimeMultiplier()
 
 	return baseRate * conditionMultiplier * sentimentMultiplier * timeMultiplier
 }
 
 func (rog *RealisticOrderGenerator) calculateTimeMultiplier() float64 {
-	now := time.Now()
-	hour := now.Hour()
-
-	// Peak hours: 9-11 AM and 2-4 PM (simplified)
-	if (hour >= 9 && hour <= 11) || (hour >= 14 && hour <= 16) {
-		return rog.config.MarketHoursBoost
-	}
-
-	// Weekend trading (crypto markets)
-	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
-		return 0.8 // Reduced weekend activity
-	}
-
-	return 1.0
+	return rog.seasonality.At(rog.clock.Now()).Volume
 }
 
 func (rog *RealisticOrderGenerator) sampleOrderCount(rate float64) int {
@@ -637,7 +693,7 @@ func (rog *RealisticOrderGenerator) generateOrderForUser(symbol string, currentP
 	}
 
 	// Determine order side
-	side := rog.determineOrderSide(userProfile, behaviorModel, marketCondition)
+	side := rog.determineOrderSide(symbol, userProfile, behaviorModel, marketCondition)
 
 	// Determine order size
 	orderSize := rog.sampleOrderSize(behaviorModel.OrderSizeDistribution, userProfile)
@@ -647,6 +703,7 @@ func (rog *RealisticOrderGenerator) generateOrderForUser(symbol string, currentP
 
 	// Create order request
 	order := &dto.PlaceOrderRequest{
+		UserID:   simulatedUserID(userProfile.BehaviorPattern, rog.orderCount),
 		Symbol:   symbol,
 		Side:     side,
 		Quantity: orderSize,
@@ -655,7 +712,7 @@ func (rog *RealisticOrderGenerator) generateOrderForUser(symbol string, currentP
 	}
 
 	rog.orderCount++
-	rog.lastOrderTime = time.Now()
+	rog.lastOrderTime = rog.clock.Now()
 
 	return order
 }
@@ -667,11 +724,12 @@ func (rog *RealisticOrderGenerator) generateBehaviorDrivenOrder(symbol string, c
 	adjustedModel.PriceOffsetBehavior.AggressiveOrderProb *= (1 + intensity*0.5)
 
 	// Generate order with adjusted behavior
-	side := rog.determineOrderSideBehaviorDriven(pattern, intensity)
+	side := rog.determineOrderSideBehaviorDriven(symbol, pattern, intensity)
 	orderSize := rog.sampleOrderSize(adjustedModel.OrderSizeDistribution, UserProfile{})
 	orderType, price := rog.determineOrderTypeAndPrice(symbol, currentPrice, &adjustedModel, side)
 
 	order := &dto.PlaceOrderRequest{
+		UserID:   simulatedUserID(pattern, rog.orderCount),
 		Symbol:   symbol,
 		Side:     side,
 		Quantity: orderSize,
@@ -682,7 +740,17 @@ func (rog *RealisticOrderGenerator) generateBehaviorDrivenOrder(symbol string, c
 	return order
 }
 
-func (rog *RealisticOrderGenerator) determineOrderSide(userProfile UserProfile, behaviorModel *BehaviorModel, marketCondition MarketCondition) string {
+// simulatedUsersPerBehavior is how many simulated users share the orders of
+// a behavior, so per-user risk limits see a population rather than one user
+const simulatedUsersPerBehavior = 50
+
+// simulatedUserID returns the simulated user placing the nth order of a
+// behavior
+func simulatedUserID(pattern UserBehaviorPattern, n int64) string {
+	return fmt.Sprintf("sim-%s-%d", pattern, n%simulatedUsersPerBehavior)
+}
+
+func (rog *RealisticOrderGenerator) determineOrderSide(symbol string, userProfile UserProfile, behaviorModel *BehaviorModel, marketCondition MarketCondition) string {
 	// Base bias from behavior model
 	bias := behaviorModel.BuySellBias
 
@@ -702,6 +770,11 @@ func (rog *RealisticOrderGenerator) determineOrderSide(userProfile UserProfile,
 		bias += 0.2 // Buying the dip
 	}
 
+	// Momentum traders follow the market events they have seen
+	if userProfile.BehaviorPattern == BehaviorMomentum {
+		bias += 0.5 * rog.eventTrend(symbol)
+	}
+
 	// Add some randomness
 	bias += (rog.rng.Float64() - 0.5) * 0.2
 
@@ -719,7 +792,7 @@ func (rog *RealisticOrderGenerator) determineOrderSide(userProfile UserProfile,
 	}
 }
 
-func (rog *RealisticOrderGenerator) determineOrderSideBehaviorDriven(pattern UserBehaviorPattern, intensity float64) string {
+func (rog *RealisticOrderGenerator) determineOrderSideBehaviorDriven(symbol string, pattern UserBehaviorPattern, intensity float64) string {
 	switch pattern {
 	case BehaviorFOMO:
 		// FOMO is usually buying
@@ -734,8 +807,13 @@ func (rog *RealisticOrderGenerator) determineOrderSideBehaviorDriven(pattern Use
 		}
 		return "buy"
 	case BehaviorMomentum:
-		// Momentum follows the trend (simplified as random for now)
-		if rog.rng.Float64() < 0.6 {
+		// Momentum follows the market events traders have seen, and leans
+		// to buying without any
+		buyProbability := 0.6
+		if trend := rog.eventTrend(symbol); trend != 0 {
+			buyProbability = 0.5 + 0.4*trend
+		}
+		if rog.rng.Float64() < buyProbability {
 			return "buy"
 		}
 		return "sell"
@@ -753,6 +831,32 @@ func (rog *RealisticOrderGenerator) determineOrderSideBehaviorDriven(pattern Use
 	}
 }
 
+// eventTrend is the net direction of the market events still moving
+// symbol, from -1 to 1; the caller holds rog.mu
+func (rog *RealisticOrderGenerator) eventTrend(symbol string) float64 {
+	signals := rog.eventSignals[symbol]
+	if len(signals) == 0 {
+		return 0
+	}
+
+	now := rog.clock.Now()
+	active := signals[:0]
+	trend := 0.0
+	for _, signal := range signals {
+		if now.Before(signal.until) {
+			active = append(active, signal)
+			trend += signal.direction
+		}
+	}
+	if len(active) == 0 {
+		delete(rog.eventSignals, symbol)
+	} else {
+		rog.eventSignals[symbol] = active
+	}
+
+	return math.Max(-1, math.Min(1, trend))
+}
+
 func (rog *RealisticOrderGenerator) sampleOrderSize(distribution DistributionParams, userProfile UserProfile) float64 {
 	var size float64
 
@@ -839,10 +943,9 @@ func (rog *RealisticOrderGenerator) updateOrderStatistics(symbol string, orders
 // DefaultOrderGeneratorConfig returns default configuration
 func DefaultOrderGeneratorConfig() OrderGeneratorConfig {
 	return OrderGeneratorConfig{
-		BaseOrderRate:    5.0, // 5 orders per minute base rate
-		MarketHoursBoost: 1.5,
-		VolatilityBoost:  2.0,
-		NewsEventBoost:   3.0,
+		BaseOrderRate:   5.0, // 5 orders per minute base rate
+		VolatilityBoost: 2.0,
+		NewsEventBoost:  3.0,
 		UserTypeMix: map[string]float64{
 			"conservative": 0.6,
 			"aggressive":   0.25,
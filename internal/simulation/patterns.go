@@ -3,14 +3,21 @@ package simulation
 import (
 	"fmt"
 	"math/rand"
+	"sort"
+	"sync"
 	"time"
+
+	"simulated_exchange/pkg/clock"
 )
 
 // PatternManager manages predefined simulation patterns
 type PatternManager struct {
 	patterns        map[string]*SimulationPattern
+	standard        map[string]bool // ids of the standard patterns
 	eventGenerator  EventGenerator
 	rng            *rand.Rand
+	clock          clock.Clock
+	mu             sync.RWMutex
 }
 
 // PatternEventGenerator implements EventGenerator for pattern-driven events
@@ -19,6 +26,7 @@ type PatternEventGenerator struct {
 	eventProbs      map[EventType]float64
 	activeEvents    []MarketEvent
 	rng            *rand.Rand
+	clock          clock.Clock
 	config         EventGeneratorConfig
 }
 
@@ -43,9 +51,14 @@ func NewPatternManager(eventGen EventGenerator) *PatternManager {
 		patterns:       make(map[string]*SimulationPattern),
 		eventGenerator: eventGen,
 		rng:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		clock:         clock.Real(),
 	}
 
 	pm.initializeStandardPatterns()
+	pm.standard = make(map[string]bool, len(pm.patterns))
+	for id := range pm.patterns {
+		pm.standard[id] = true
+	}
 	return pm
 }
 
@@ -60,6 +73,7 @@ func NewPatternEventGenerator(config EventGeneratorConfig) *PatternEventGenerato
 		eventProbs:     make(map[EventType]float64),
 		activeEvents:   make([]MarketEvent, 0),
 		rng:           rand.New(rand.NewSource(config.RandomSeed)),
+		clock:         clock.Real(),
 		config:        config,
 	}
 
@@ -67,17 +81,29 @@ func NewPatternEventGenerator(config EventGeneratorConfig) *PatternEventGenerato
 	return peg
 }
 
+// SetClock makes the pattern manager time pattern phases on clk
+func (pm *PatternManager) SetClock(clk clock.Clock) {
+	pm.clock = clk
+}
+
 // GetPattern returns a simulation pattern by name
 func (pm *PatternManager) GetPattern(name string) *SimulationPattern {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	return pm.patterns[name]
 }
 
-// ListPatterns returns all available pattern names
+// ListPatterns returns all available pattern names, sorted
 func (pm *PatternManager) ListPatterns() []string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	names := make([]string, 0, len(pm.patterns))
 	for name := range pm.patterns {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
@@ -92,10 +118,18 @@ func (pm *PatternManager) CreateCustomPattern(name, description string, phases [
 		Parameters:  make(map[string]interface{}),
 	}
 
-	pm.patterns[name] = pattern
+	pm.setPattern(name, pattern)
 	return pattern
 }
 
+// setPattern adds a pattern or replaces the one of the same name
+func (pm *PatternManager) setPattern(name string, pattern *SimulationPattern) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.patterns[name] = pattern
+}
+
 // initializeStandardPatterns creates predefined simulation patterns
 func (pm *PatternManager) initializeStandardPatterns() {
 	// Flash Crash Pattern
@@ -433,14 +467,14 @@ func (peg *PatternEventGenerator) GenerateMarketEvent() MarketEvent {
 	severity := peg.selectEventSeverity()
 
 	event := MarketEvent{
-		ID:              fmt.Sprintf("event_%d_%s", time.Now().Unix(), eventType),
+		ID:              fmt.Sprintf("event_%d_%s", peg.clock.Now().Unix(), eventType),
 		Type:            eventType,
 		Severity:        severity,
 		AffectedSymbols: peg.selectAffectedSymbols(),
 		PriceImpact:     peg.calculatePriceImpact(severity),
 		Duration:        peg.getEventDuration(eventType),
 		Description:     peg.generateEventDescription(eventType, severity),
-		StartTime:       time.Now(),
+		StartTime:       peg.clock.Now(),
 		IsActive:        true,
 	}
 
@@ -449,7 +483,7 @@ func (peg *PatternEventGenerator) GenerateMarketEvent() MarketEvent {
 
 // InjectEvent manually introduces a specific event
 func (peg *PatternEventGenerator) InjectEvent(event MarketEvent) error {
-	event.StartTime = time.Now()
+	event.StartTime = peg.clock.Now()
 	event.IsActive = true
 
 	peg.activeEvents = append(peg.activeEvents, event)
@@ -457,7 +491,7 @@ func (peg *PatternEventGenerator) InjectEvent(event MarketEvent) error {
 
 	// Schedule event deactivation
 	go func() {
-		time.Sleep(event.Duration)
+		peg.clock.Sleep(event.Duration)
 		peg.deactivateEvent(event.ID)
 	}()
 
@@ -467,7 +501,7 @@ func (peg *PatternEventGenerator) InjectEvent(event MarketEvent) error {
 // GetActiveEvents returns currently active market events
 func (peg *PatternEventGenerator) GetActiveEvents() []MarketEvent {
 	// Clean up expired events
-	now := time.Now()
+	now := peg.clock.Now()
 	activeEvents := make([]MarketEvent, 0)
 
 	for _, event := range peg.activeEvents {
@@ -485,6 +519,18 @@ func (peg *PatternEventGenerator) SetEventProbability(eventType EventType, proba
 	peg.eventProbs[eventType] = probability
 }
 
+// SetClock makes the event generator read the time from clk
+func (peg *PatternEventGenerator) SetClock(clk clock.Clock) {
+	peg.clock = clk
+}
+
+// Reseed restarts the random numbers from seed and reads the time from clk
+func (peg *PatternEventGenerator) Reseed(seed int64, clk clock.Clock) {
+	peg.config.RandomSeed = seed
+	peg.rng = rand.New(rand.NewSource(seed))
+	peg.clock = clk
+}
+
 // Private helper methods for event generation
 
 func (peg *PatternEventGenerator) initializeDefaultProbabilities() {
@@ -500,16 +546,24 @@ func (peg *PatternEventGenerator) initializeDefaultProbabilities() {
 }
 
 func (peg *PatternEventGenerator) selectEventType() EventType {
+	// Walk the event types in a stable order so that a seed always picks
+	// the same type
+	eventTypes := make([]EventType, 0, len(peg.eventProbs))
+	for eventType := range peg.eventProbs {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Slice(eventTypes, func(i, j int) bool { return eventTypes[i] < eventTypes[j] })
+
 	totalProb := 0.0
-	for _, prob := range peg.eventProbs {
-		totalProb += prob
+	for _, eventType := range eventTypes {
+		totalProb += peg.eventProbs[eventType]
 	}
 
 	r := peg.rng.Float64() * totalProb
 	cumProb := 0.0
 
-	for eventType, prob := range peg.eventProbs {
-		cumProb += prob
+	for _, eventType := range eventTypes {
+		cumProb += peg.eventProbs[eventType]
 		if r <= cumProb {
 			return eventType
 		}
@@ -663,7 +717,7 @@ func (peg *PatternEventGenerator) deactivateEvent(eventID string) {
 
 // ExecutePattern applies a simulation pattern to the market
 func (pm *PatternManager) ExecutePattern(patternName string, priceGen PriceGenerator, orderGen OrderGenerator) error {
-	pattern := pm.patterns[patternName]
+	pattern := pm.GetPattern(patternName)
 	if pattern == nil {
 		return fmt.Errorf("pattern '%s' not found", patternName)
 	}
@@ -688,8 +742,15 @@ func (pm *PatternManager) executePhase(phase PatternPhase, priceGen PriceGenerat
 		orderGen.SimulateUserBehavior(behavior, weight*phase.OrderIntensity)
 	}
 
+	// Inject the phase's market events
+	for _, event := range phase.Events {
+		if err := pm.eventGenerator.InjectEvent(event); err != nil {
+			return fmt.Errorf("failed to inject event '%s': %w", event.ID, err)
+		}
+	}
+
 	// Wait for phase duration
-	time.Sleep(phase.Duration)
+	pm.clock.Sleep(phase.Duration)
 
 	return nil
 }
@@ -1,10 +1,17 @@
 package simulation
 
 import (
+	"log"
 	"math"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
+
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/priceprocess"
+	"simulated_exchange/pkg/seasonality"
 )
 
 // RealisticPriceGenerator implements PriceGenerator interface
@@ -23,6 +30,15 @@ type RealisticPriceGenerator struct {
 	spreadPercentage  float64
 	priceStepSize     float64
 
+	// Stochastic price processes of symbols not on the builtin model
+	processes map[string]priceprocess.Process
+
+	// Correlated shocks across symbols and the realized correlations of
+	// their returns
+	correlated []string
+	shocks     *priceprocess.CorrelatedShocks
+	realized   *priceprocess.RealizedCorrelation
+
 	// Trend analysis
 	trendAnalysis     map[string]*TrendAnalysis
 	supportResistance map[string]*SupportResistance
@@ -30,6 +46,13 @@ type RealisticPriceGenerator struct {
 	// Random number generator with seed
 	rng *rand.Rand
 
+	// Time source; the wall clock unless a deterministic run reseeds the
+	// generator
+	clock clock.Clock
+
+	// Intraday, weekly and holiday profile of the volatility
+	seasonality *seasonality.Profile
+
 	// Configuration
 	config PriceGeneratorConfig
 }
@@ -93,6 +116,14 @@ type PriceGeneratorConfig struct {
 	RandomSeed         int64             `json:"random_seed"`
 	MarketHours        TradingHours      `json:"market_hours"`
 	HolidayAdjustment  float64           `json:"holiday_adjustment"`
+
+	// Models selects a stochastic price process per symbol; symbols on the
+	// builtin model use the trend, mean reversion and support/resistance
+	// formula
+	Models config.PriceModelsConfig `json:"models"`
+
+	// Correlation sets how the symbols' shocks move together
+	Correlation config.CorrelationConfig `json:"correlation"`
 }
 
 // NewRealisticPriceGenerator creates a new price generator
@@ -105,6 +136,7 @@ func NewRealisticPriceGenerator(config PriceGeneratorConfig) *RealisticPriceGene
 		prices:            make(map[string]*PriceState),
 		basePrices:        make(map[string]float64),
 		currentVolatility: make(map[string]float64),
+		processes:         make(map[string]priceprocess.Process),
 		trendAnalysis:     make(map[string]*TrendAnalysis),
 		supportResistance: make(map[string]*SupportResistance),
 		baseVolatility:    config.BaseVolatility,
@@ -112,6 +144,8 @@ func NewRealisticPriceGenerator(config PriceGeneratorConfig) *RealisticPriceGene
 		spreadPercentage:  config.SpreadPercentage,
 		priceStepSize:     config.PriceStepSize,
 		rng:               rand.New(rand.NewSource(config.RandomSeed)),
+		clock:             clock.Real(),
+		seasonality:       seasonality.Standard(),
 		config:            config,
 	}
 }
@@ -123,6 +157,7 @@ func (rpg *RealisticPriceGenerator) GeneratePrice(symbol string, currentPrice fl
 
 	// Initialize price state if needed
 	if _, exists := rpg.prices[symbol]; !exists {
+		rpg.correlateSymbol(symbol)
 		rpg.initializePriceState(symbol, currentPrice)
 	}
 
@@ -132,28 +167,37 @@ func (rpg *RealisticPriceGenerator) GeneratePrice(symbol string, currentPrice fl
 	priceState.PreviousPrice = priceState.CurrentPrice
 	priceState.CurrentPrice = currentPrice
 
-	// Calculate time-based factors
-	timeFactor := rpg.calculateTimeFactor(timeElapsed)
-
 	// Get current volatility for symbol
 	volatility := rpg.getCurrentVolatility(symbol)
 
-	// Calculate price change components
-	trendComponent := rpg.calculateTrendComponent(symbol, timeFactor)
-	randomComponent := rpg.calculateRandomComponent(symbol, volatility, timeFactor)
-	meanReversionComponent := rpg.calculateMeanReversionComponent(symbol)
-	supportResistanceComponent := rpg.calculateSupportResistanceComponent(symbol, currentPrice)
+	shock := rpg.nextShock(symbol)
 
-	// Combine components
-	totalChange := trendComponent + randomComponent + meanReversionComponent + supportResistanceComponent
+	var newPrice float64
+	if process := rpg.processFor(symbol, currentPrice); process != nil {
+		newPrice = process.Step(rpg.rng, shock, currentPrice, timeElapsed, rpg.volatilityScale(volatility))
+	} else {
+		// Calculate time-based factors
+		timeFactor := rpg.calculateTimeFactor(timeElapsed)
 
-	// Apply change to current price
-	newPrice := currentPrice * (1 + totalChange)
+		// Calculate price change components
+		trendComponent := rpg.calculateTrendComponent(symbol, timeFactor)
+		randomComponent := rpg.calculateRandomComponent(shock, volatility, timeFactor)
+		meanReversionComponent := rpg.calculateMeanReversionComponent(symbol)
+		supportResistanceComponent := rpg.calculateSupportResistanceComponent(symbol, currentPrice)
+
+		// Combine components and apply change to current price
+		totalChange := trendComponent + randomComponent + meanReversionComponent + supportResistanceComponent
+		newPrice = currentPrice * (1 + totalChange)
+	}
 
 	// Ensure price is positive and apply step size
 	newPrice = math.Max(newPrice, rpg.priceStepSize)
 	newPrice = rpg.roundToStepSize(newPrice)
 
+	if rpg.realized != nil && currentPrice > 0 {
+		rpg.realized.Observe(symbol, math.Log(newPrice/currentPrice))
+	}
+
 	// Update price state
 	rpg.updatePriceState(symbol, newPrice, timeElapsed)
 
@@ -174,8 +218,15 @@ func (rpg *RealisticPriceGenerator) SimulateVolatility(pattern VolatilityPattern
 	rpg.mu.Lock()
 	defer rpg.mu.Unlock()
 
-	// Apply volatility to all symbols
+	// Apply volatility to all symbols, in a stable order so that random
+	// bursts are reproducible
+	symbols := make([]string, 0, len(rpg.prices))
 	for symbol := range rpg.prices {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
 		switch pattern {
 		case VolatilitySpike:
 			rpg.currentVolatility[symbol] = rpg.baseVolatility * (1 + intensity*3)
@@ -183,7 +234,7 @@ func (rpg *RealisticPriceGenerator) SimulateVolatility(pattern VolatilityPattern
 			rpg.currentVolatility[symbol] *= (1 - intensity*0.1)
 		case VolatilityOscillate:
 			// Create oscillating volatility
-			oscFactor := math.Sin(float64(time.Now().Unix())/60) * intensity
+			oscFactor := math.Sin(float64(rpg.clock.Now().Unix())/60) * intensity
 			rpg.currentVolatility[symbol] = rpg.baseVolatility * (1 + oscFactor)
 		case VolatilityRandom:
 			// Random volatility bursts
@@ -210,13 +261,13 @@ func (rpg *RealisticPriceGenerator) GetPriceTrend(symbol string) PriceTrend {
 		Symbol:      symbol,
 		Direction:   TrendSideways,
 		Strength:    0.0,
-		LastUpdate:  time.Now(),
+		LastUpdate:  rpg.clock.Now(),
 	}
 
 	if analysis, exists := rpg.trendAnalysis[symbol]; exists {
 		trend.Direction = analysis.Direction
 		trend.Strength = analysis.Strength
-		trend.Duration = time.Since(analysis.StartTime)
+		trend.Duration = rpg.clock.Now().Sub(analysis.StartTime)
 		trend.VolatilityLevel = rpg.getCurrentVolatility(symbol)
 
 		if sr, exists := rpg.supportResistance[symbol]; exists {
@@ -239,9 +290,38 @@ func (rpg *RealisticPriceGenerator) SetBasePrice(symbol string, price float64) {
 
 	rpg.basePrices[symbol] = price
 	rpg.currentVolatility[symbol] = rpg.baseVolatility
+	delete(rpg.processes, symbol)
+	if _, exists := rpg.prices[symbol]; !exists {
+		rpg.correlateSymbol(symbol)
+	}
 	rpg.initializePriceState(symbol, price)
 }
 
+// PropagateShock spreads moves of a few symbols to the other symbols in
+// proportion to their correlations. Without correlations only the given
+// symbols move.
+func (rpg *RealisticPriceGenerator) PropagateShock(moves map[string]float64) map[string]float64 {
+	rpg.mu.RLock()
+	defer rpg.mu.RUnlock()
+
+	if rpg.shocks == nil {
+		return moves
+	}
+	return rpg.shocks.Correlation().Propagate(moves)
+}
+
+// RealizedCorrelations returns the correlations of the symbols' recent log
+// returns, keyed by symbol
+func (rpg *RealisticPriceGenerator) RealizedCorrelations() map[string]map[string]float64 {
+	rpg.mu.RLock()
+	defer rpg.mu.RUnlock()
+
+	if rpg.realized == nil {
+		return map[string]map[string]float64{}
+	}
+	return rpg.realized.Matrix()
+}
+
 // Reset clears all price history and patterns
 func (rpg *RealisticPriceGenerator) Reset() {
 	rpg.mu.Lock()
@@ -250,14 +330,105 @@ func (rpg *RealisticPriceGenerator) Reset() {
 	rpg.prices = make(map[string]*PriceState)
 	rpg.basePrices = make(map[string]float64)
 	rpg.currentVolatility = make(map[string]float64)
+	rpg.processes = make(map[string]priceprocess.Process)
+	rpg.correlated = nil
+	rpg.shocks = nil
+	rpg.realized = nil
 	rpg.trendAnalysis = make(map[string]*TrendAnalysis)
 	rpg.supportResistance = make(map[string]*SupportResistance)
 }
 
+// SetClock makes the price generator read the time from clk
+func (rpg *RealisticPriceGenerator) SetClock(clk clock.Clock) {
+	rpg.mu.Lock()
+	defer rpg.mu.Unlock()
+
+	rpg.clock = clk
+}
+
+// SetSeasonality sets the profile volatility follows over the day, the
+// week and holidays
+func (rpg *RealisticPriceGenerator) SetSeasonality(profile *seasonality.Profile) {
+	rpg.mu.Lock()
+	defer rpg.mu.Unlock()
+
+	rpg.seasonality = profile
+}
+
+// Reseed restarts the random numbers from seed and reads the time from clk
+func (rpg *RealisticPriceGenerator) Reseed(seed int64, clk clock.Clock) {
+	rpg.mu.Lock()
+	defer rpg.mu.Unlock()
+
+	rpg.config.RandomSeed = seed
+	rpg.rng = rand.New(rand.NewSource(seed))
+	rpg.clock = clk
+}
+
 // Private helper methods
 
+// processFor returns the symbol's price process, creating it from the
+// symbol's model on first use, or nil for the builtin model
+func (rpg *RealisticPriceGenerator) processFor(symbol string, price float64) priceprocess.Process {
+	if process, exists := rpg.processes[symbol]; exists {
+		return process
+	}
+
+	model := rpg.config.Models.ModelFor(symbol)
+	if model.Name == "" || model.Name == config.PriceModelBuiltin {
+		return nil
+	}
+
+	// Models are validated with the configuration, so this only fails for
+	// generators built without it; those keep the builtin formula
+	process, err := priceprocess.New(model, price)
+	if err != nil {
+		return nil
+	}
+	rpg.processes[symbol] = process
+	return process
+}
+
+// correlateSymbol rebuilds the correlation matrix to include a new symbol.
+// Pair overrides can leave the matrix invalid; the new symbol then moves
+// independently. Realized correlations restart with the set of symbols.
+func (rpg *RealisticPriceGenerator) correlateSymbol(symbol string) {
+	symbols := append(append([]string(nil), rpg.correlated...), symbol)
+	correlation, err := priceprocess.NewCorrelation(rpg.config.Correlation, symbols)
+	if err != nil {
+		log.Printf("Symbol %s left uncorrelated: %v", symbol, err)
+		return
+	}
+
+	window := rpg.config.Correlation.Window
+	if window < 2 {
+		window = rpg.config.HistorySize
+	}
+	rpg.correlated = symbols
+	rpg.shocks = priceprocess.NewCorrelatedShocks(correlation)
+	rpg.realized = priceprocess.NewRealizedCorrelation(symbols, window)
+}
+
+// nextShock returns the standard normal shock of a symbol's next price,
+// correlated with the other symbols' shocks
+func (rpg *RealisticPriceGenerator) nextShock(symbol string) float64 {
+	if rpg.shocks == nil {
+		return rpg.rng.NormFloat64()
+	}
+	return rpg.shocks.Next(rpg.rng, symbol)
+}
+
+// volatilityScale is how far volatility patterns have moved a symbol's
+// volatility from the base; price processes scale their own volatility by it
+func (rpg *RealisticPriceGenerator) volatilityScale(volatility float64) float64 {
+	if rpg.baseVolatility <= 0 {
+		return 1
+	}
+	return volatility / rpg.baseVolatility
+}
+
 func (rpg *RealisticPriceGenerator) initializePriceState(symbol string, price float64) {
-	now := time.Now()
+	now := rpg.clock.Now()
 
 	rpg.prices[symbol] = &PriceState{
 		Symbol:          symbol,
@@ -299,10 +470,11 @@ func (rpg *RealisticPriceGenerator) initializePriceState(symbol string, price fl
 }
 
 func (rpg *RealisticPriceGenerator) getCurrentVolatility(symbol string) float64 {
-	if vol, exists := rpg.currentVolatility[symbol]; exists {
-		return vol
+	vol, exists := rpg.currentVolatility[symbol]
+	if !exists {
+		vol = rpg.baseVolatility
 	}
-	return rpg.baseVolatility
+	return vol * rpg.seasonality.At(rpg.clock.Now()).Volatility
 }
 
 func (rpg *RealisticPriceGenerator) calculateTimeFactor(timeElapsed time.Duration) float64 {
@@ -321,7 +493,7 @@ func (rpg *RealisticPriceGenerator) calculateTrendComponent(symbol string, timeF
 	}
 
 	// Calculate trend strength decay over time
-	trendAge := time.Since(analysis.StartTime).Hours()
+	trendAge := rpg.clock.Now().Sub(analysis.StartTime).Hours()
 	ageDecay := math.Exp(-trendAge / 24.0) // Decay over 24 hours
 
 	// Apply momentum and persistence
@@ -341,12 +513,9 @@ func (rpg *RealisticPriceGenerator) calculateTrendComponent(symbol string, timeF
 	return trendStrength * directionMultiplier * timeFactor * 0.001 // Scale down
 }
 
-func (rpg *RealisticPriceGenerator) calculateRandomComponent(symbol string, volatility, timeFactor float64) float64 {
-	// Generate normally distributed random walk
-	randomValue := rpg.rng.NormFloat64()
-
-	// Scale by volatility and time
-	return randomValue * volatility * timeFactor * 0.01
+func (rpg *RealisticPriceGenerator) calculateRandomComponent(shock, volatility, timeFactor float64) float64 {
+	// Scale the normally distributed shock by volatility and time
+	return shock * volatility * timeFactor * 0.01
 }
 
 func (rpg *RealisticPriceGenerator) calculateMeanReversionComponent(symbol string) float64 {
@@ -405,7 +574,8 @@ func (rpg *RealisticPriceGenerator) roundToStepSize(price float64) float64 {
 
 func (rpg *RealisticPriceGenerator) updatePriceState(symbol string, newPrice float64, timeElapsed time.Duration) {
 	priceState := rpg.prices[symbol]
-	now := time.Now()
+	now := rpg.clock.Now()
+	lastUpdate := priceState.LastUpdate
 
 	// Update current values
 	priceState.CurrentPrice = newPrice
@@ -437,7 +607,7 @@ func (rpg *RealisticPriceGenerator) updatePriceState(symbol string, newPrice flo
 	priceState.VolatilityIndex = rpg.calculateVolatilityIndex(symbol)
 
 	// Reset daily values if new day
-	if rpg.isNewTradingDay(priceState.LastUpdate, now) {
+	if rpg.isNewTradingDay(lastUpdate, now) {
 		priceState.DailyOpen = newPrice
 		priceState.DailyHigh = newPrice
 		priceState.DailyLow = newPrice
@@ -543,12 +713,12 @@ func (rpg *RealisticPriceGenerator) updateTrendAnalysis(symbol string, newPrice
 	// Update trend if direction changed
 	if newDirection != analysis.Direction {
 		analysis.Direction = newDirection
-		analysis.StartTime = time.Now()
+		analysis.StartTime = rpg.clock.Now()
 		analysis.PriceChange = 0.0
 	}
 
 	analysis.Strength = math.Min(strength, 1.0) // Cap at 1.0
-	analysis.Duration = time.Since(analysis.StartTime)
+	analysis.Duration = rpg.clock.Now().Sub(analysis.StartTime)
 
 	// Update price change since trend start
 	if len(priceState.PriceHistory) > 0 {
@@ -598,10 +768,10 @@ func (rpg *RealisticPriceGenerator) updateSupportResistance(symbol string, newPr
 		return // Need more data
 	}
 
-	now := time.Now()
+	now := rpg.clock.Now()
 
 	// Update every 5 minutes to avoid too frequent recalculations
-	if time.Since(sr.LastUpdate) < 5*time.Minute {
+	if rpg.clock.Now().Sub(sr.LastUpdate) < 5*time.Minute {
 		return
 	}
 
@@ -735,8 +905,8 @@ func (rpg *RealisticPriceGenerator) decayVolatility(symbol string) {
 }
 
 func (rpg *RealisticPriceGenerator) isNewTradingDay(lastUpdate, currentTime time.Time) bool {
-	// Simple check: different day
-	return lastUpdate.Day() != currentTime.Day()
+	// Simple check: different calendar day
+	return lastUpdate.YearDay() != currentTime.YearDay() || lastUpdate.Year() != currentTime.Year()
 }
 
 // DefaultPriceGeneratorConfig returns default configuration
@@ -0,0 +1,373 @@
+package simulation
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScenarioSchema is the JSON Schema of scenario files
+//
+//go:embed scenario.schema.json
+var ScenarioSchema []byte
+
+// scenarioFile is a scenario as written in a YAML or JSON file. Durations
+// are Go duration strings such as "90s" or "1h30m".
+type scenarioFile struct {
+	ID          string                 `json:"id" yaml:"id"`
+	Name        string                 `json:"name" yaml:"name"`
+	Description string                 `json:"description" yaml:"description"`
+	Duration    string                 `json:"duration" yaml:"duration"`
+	Parameters  map[string]interface{} `json:"parameters" yaml:"parameters"`
+	Phases      []scenarioPhase        `json:"phases" yaml:"phases"`
+	Triggers    []scenarioTrigger      `json:"triggers" yaml:"triggers"`
+}
+
+type scenarioPhase struct {
+	Name            string             `json:"name" yaml:"name"`
+	Duration        string             `json:"duration" yaml:"duration"`
+	MarketCondition string             `json:"market_condition" yaml:"market_condition"`
+	VolatilityLevel float64            `json:"volatility_level" yaml:"volatility_level"`
+	OrderIntensity  *float64           `json:"order_intensity" yaml:"order_intensity"`
+	PriceDirection  string             `json:"price_direction" yaml:"price_direction"`
+	UserBehavior    map[string]float64 `json:"user_behavior" yaml:"user_behavior"`
+	Events          []scenarioEvent    `json:"events" yaml:"events"`
+}
+
+type scenarioEvent struct {
+	Type            string   `json:"type" yaml:"type"`
+	Severity        string   `json:"severity" yaml:"severity"`
+	AffectedSymbols []string `json:"affected_symbols" yaml:"affected_symbols"`
+	PriceImpact     float64  `json:"price_impact" yaml:"price_impact"`
+	Duration        string   `json:"duration" yaml:"duration"`
+	Description     string   `json:"description" yaml:"description"`
+}
+
+type scenarioTrigger struct {
+	Type       string                 `json:"type" yaml:"type"`
+	Condition  string                 `json:"condition" yaml:"condition"`
+	Parameters map[string]interface{} `json:"parameters" yaml:"parameters"`
+	NextPhase  string                 `json:"next_phase" yaml:"next_phase"`
+}
+
+// Values the schema allows
+var (
+	scenarioIDPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+	scenarioMarketConditions = []MarketCondition{
+		MarketSteady, MarketVolatile, MarketBullish, MarketBearish, MarketSideways, MarketCrash, MarketRecovery,
+	}
+	scenarioDirections = []TrendDirection{TrendUp, TrendDown, TrendSideways}
+	scenarioBehaviors  = []UserBehaviorPattern{
+		BehaviorConservative, BehaviorAggressive, BehaviorMomentum, BehaviorMeanRevert,
+		BehaviorFOMO, BehaviorPanic, BehaviorArbitrage,
+	}
+	scenarioEventTypes = []EventType{
+		EventEarnings, EventNews, EventRegulatory, EventEconomic, EventGeopolitical, EventTechnical, EventCorporate,
+	}
+	scenarioSeverities   = []EventSeverity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCrisis}
+	scenarioTriggerTypes = []TriggerType{
+		TriggerTime, TriggerPrice, TriggerVolume, TriggerVolatility, TriggerEvent, TriggerRandom,
+	}
+)
+
+// scenarioExtensions are the file types LoadScenarios reads
+var scenarioExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// ParseScenario parses and validates a YAML or JSON scenario and returns
+// its id and pattern. source names the scenario in errors.
+func ParseScenario(data []byte, source string) (string, *SimulationPattern, error) {
+	var file scenarioFile
+	if err := decodeStrict(data, &file); err != nil {
+		return "", nil, SimulationError{
+			Code:    "INVALID_SCENARIO",
+			Message: fmt.Sprintf("invalid scenario %s: %v", source, err),
+		}
+	}
+
+	pattern, problems := file.toPattern()
+	if len(problems) > 0 {
+		return "", nil, SimulationError{
+			Code:    "INVALID_SCENARIO",
+			Message: fmt.Sprintf("invalid scenario %s: %s", source, strings.Join(problems, "; ")),
+			Details: strings.Join(problems, "\n"),
+		}
+	}
+	return file.ID, pattern, nil
+}
+
+// decodeStrict decodes JSON, or else YAML, into v, rejecting fields v
+// doesn't define
+func decodeStrict(data []byte, v interface{}) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(v)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	return decoder.Decode(v)
+}
+
+// toPattern validates the scenario against the schema and converts it to a
+// pattern; it returns every problem found
+func (f *scenarioFile) toPattern() (*SimulationPattern, []string) {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !scenarioIDPattern.MatchString(f.ID) {
+		addProblem("id: %q must be lowercase letters, digits and underscores, starting with a letter", f.ID)
+	}
+	if len(f.Phases) == 0 {
+		addProblem("phases: at least one phase is required")
+	}
+
+	pattern := &SimulationPattern{
+		Name:        f.Name,
+		Description: f.Description,
+		Phases:      make([]PatternPhase, 0, len(f.Phases)),
+		Triggers:    make([]PatternTrigger, 0, len(f.Triggers)),
+		Parameters:  f.Parameters,
+	}
+	if pattern.Name == "" {
+		pattern.Name = f.ID
+	}
+	if pattern.Parameters == nil {
+		pattern.Parameters = make(map[string]interface{})
+	}
+
+	phaseNames := make(map[string]bool, len(f.Phases))
+	var phasesDuration time.Duration
+	for i, spec := range f.Phases {
+		path := fmt.Sprintf("phases[%d]", i)
+
+		phase := PatternPhase{
+			Name:            spec.Name,
+			MarketCondition: MarketCondition(spec.MarketCondition),
+			VolatilityLevel: spec.VolatilityLevel,
+			OrderIntensity:  1.0,
+			PriceDirection:  TrendSideways,
+			UserBehavior:    make(map[UserBehaviorPattern]float64, len(spec.UserBehavior)),
+		}
+
+		if spec.Name == "" {
+			addProblem("%s.name: required", path)
+		} else if phaseNames[spec.Name] {
+			addProblem("%s.name: duplicate phase %q", path, spec.Name)
+		}
+		phaseNames[spec.Name] = true
+
+		duration, err := parseScenarioDuration(spec.Duration)
+		if err != nil {
+			addProblem("%s.duration: %v", path, err)
+		}
+		phase.Duration = duration
+		phasesDuration += duration
+
+		if !containsValue(scenarioMarketConditions, phase.MarketCondition) {
+			addProblem("%s.market_condition: %q must be one of %s", path, spec.MarketCondition, joinValues(scenarioMarketConditions))
+		}
+		if spec.VolatilityLevel < 0 || spec.VolatilityLevel > 1 {
+			addProblem("%s.volatility_level: %g must be between 0 and 1", path, spec.VolatilityLevel)
+		}
+		if spec.OrderIntensity != nil {
+			phase.OrderIntensity = *spec.OrderIntensity
+			if phase.OrderIntensity < 0 {
+				addProblem("%s.order_intensity: %g must not be negative", path, phase.OrderIntensity)
+			}
+		}
+		if spec.PriceDirection != "" {
+			phase.PriceDirection = TrendDirection(spec.PriceDirection)
+			if !containsValue(scenarioDirections, phase.PriceDirection) {
+				addProblem("%s.price_direction: %q must be one of %s", path, spec.PriceDirection, joinValues(scenarioDirections))
+			}
+		}
+
+		total := 0.0
+		for behavior, weight := range spec.UserBehavior {
+			if !containsValue(scenarioBehaviors, UserBehaviorPattern(behavior)) {
+				addProblem("%s.user_behavior: %q must be one of %s", path, behavior, joinValues(scenarioBehaviors))
+			}
+			if weight < 0 || weight > 1 {
+				addProblem("%s.user_behavior.%s: %g must be between 0 and 1", path, behavior, weight)
+			}
+			phase.UserBehavior[UserBehaviorPattern(behavior)] = weight
+			total += weight
+		}
+		if len(spec.UserBehavior) > 0 && math.Abs(total-1) > 0.01 {
+			addProblem("%s.user_behavior: shares add up to %g instead of 1", path, total)
+		}
+
+		for j, eventSpec := range spec.Events {
+			eventPath := fmt.Sprintf("%s.events[%d]", path, j)
+			event := MarketEvent{
+				ID:              fmt.Sprintf("%s_%d_%d", f.ID, i, j),
+				Type:            EventType(eventSpec.Type),
+				Severity:        EventSeverity(eventSpec.Severity),
+				AffectedSymbols: eventSpec.AffectedSymbols,
+				PriceImpact:     eventSpec.PriceImpact,
+				Description:     eventSpec.Description,
+			}
+			if event.Description == "" {
+				event.Description = fmt.Sprintf("%s: %s %s event", pattern.Name, event.Severity, event.Type)
+			}
+
+			if !containsValue(scenarioEventTypes, event.Type) {
+				addProblem("%s.type: %q must be one of %s", eventPath, eventSpec.Type, joinValues(scenarioEventTypes))
+			}
+			if !containsValue(scenarioSeverities, event.Severity) {
+				addProblem("%s.severity: %q must be one of %s", eventPath, eventSpec.Severity, joinValues(scenarioSeverities))
+			}
+			for _, symbol := range eventSpec.AffectedSymbols {
+				if symbol == "" {
+					addProblem("%s.affected_symbols: empty symbol", eventPath)
+				}
+			}
+			if eventSpec.PriceImpact < 0 || eventSpec.PriceImpact > 100 {
+				addProblem("%s.price_impact: %g must be between 0 and 100 percent", eventPath, eventSpec.PriceImpact)
+			}
+			event.Duration, err = parseScenarioDuration(eventSpec.Duration)
+			if err != nil {
+				addProblem("%s.duration: %v", eventPath, err)
+			}
+
+			phase.Events = append(phase.Events, event)
+		}
+
+		pattern.Phases = append(pattern.Phases, phase)
+	}
+
+	pattern.Duration = phasesDuration
+	if f.Duration != "" {
+		duration, err := parseScenarioDuration(f.Duration)
+		if err != nil {
+			addProblem("duration: %v", err)
+		} else if duration < phasesDuration {
+			addProblem("duration: %v is shorter than the phases' %v", duration, phasesDuration)
+		}
+		pattern.Duration = duration
+	}
+
+	for i, spec := range f.Triggers {
+		path := fmt.Sprintf("triggers[%d]", i)
+		trigger := PatternTrigger{
+			Type:       TriggerType(spec.Type),
+			Condition:  spec.Condition,
+			Parameters: spec.Parameters,
+			NextPhase:  spec.NextPhase,
+		}
+		if trigger.Parameters == nil {
+			trigger.Parameters = make(map[string]interface{})
+		}
+
+		if !containsValue(scenarioTriggerTypes, trigger.Type) {
+			addProblem("%s.type: %q must be one of %s", path, spec.Type, joinValues(scenarioTriggerTypes))
+		}
+		if spec.NextPhase != "" && !phaseNames[spec.NextPhase] {
+			addProblem("%s.next_phase: %q is not a phase of the scenario", path, spec.NextPhase)
+		}
+
+		pattern.Triggers = append(pattern.Triggers, trigger)
+	}
+
+	return pattern, problems
+}
+
+// parseScenarioDuration parses a required, positive duration
+func parseScenarioDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, fmt.Errorf("required")
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration such as 90s or 5m", value)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("%v must be positive", duration)
+	}
+	return duration, nil
+}
+
+func containsValue[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func joinValues[T ~string](values []T) string {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = string(v)
+	}
+	return strings.Join(names, ", ")
+}
+
+// LoadScenarios adds every YAML and JSON scenario in dir to the patterns,
+// in file name order, and returns their ids. A scenario replaces the
+// pattern of the same id. It stops at the first invalid file.
+func (pm *PatternManager) LoadScenarios(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && scenarioExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return ids, fmt.Errorf("failed to read scenario %s: %w", path, err)
+		}
+
+		id, pattern, err := ParseScenario(data, path)
+		if err != nil {
+			return ids, err
+		}
+		pm.setPattern(id, pattern)
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// AddScenario parses, validates and adds an uploaded scenario, replacing
+// the uploaded or loaded scenario of the same id. Uploads cannot replace
+// the standard patterns.
+func (pm *PatternManager) AddScenario(data []byte) (string, *SimulationPattern, error) {
+	id, pattern, err := ParseScenario(data, "upload")
+	if err != nil {
+		return "", nil, err
+	}
+	if pm.standard[id] {
+		return "", nil, SimulationError{
+			Code:    "INVALID_SCENARIO",
+			Message: fmt.Sprintf("invalid scenario upload: id %q is a standard pattern", id),
+		}
+	}
+	pm.setPattern(id, pattern)
+	return id, pattern, nil
+}
@@ -0,0 +1,526 @@
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const openingGapScenario = `
+id: opening_gap
+name: Opening Gap
+description: Overnight news gaps the open, then the gap partially fills
+parameters:
+  gap: 0.04
+phases:
+  - name: Gap
+    duration: 2m
+    market_condition: VOLATILE
+    volatility_level: 0.7
+    order_intensity: 3
+    price_direction: UP
+    user_behavior:
+      FOMO: 0.5
+      MOMENTUM: 0.3
+      AGGRESSIVE: 0.2
+    events:
+      - type: NEWS
+        severity: HIGH
+        affected_symbols: [BTCUSD]
+        price_impact: 4
+        duration: 10m
+  - name: Gap Fill
+    duration: 8m
+    market_condition: BEARISH
+    volatility_level: 0.3
+    price_direction: DOWN
+    user_behavior:
+      MEAN_REVERT: 0.6
+      CONSERVATIVE: 0.4
+triggers:
+  - type: TIME
+    condition: elapsed
+    parameters:
+      after: 120
+    next_phase: Gap Fill
+`
+
+func TestParseScenario_YAML(t *testing.T) {
+	id, pattern, err := ParseScenario([]byte(openingGapScenario), "opening_gap.yaml")
+	if err != nil {
+		t.Fatalf("Failed to parse scenario: %v", err)
+	}
+
+	if id != "opening_gap" || pattern.Name != "Opening Gap" {
+		t.Errorf("Expected opening_gap named Opening Gap, got %s named %s", id, pattern.Name)
+	}
+	if pattern.Duration != 10*time.Minute {
+		t.Errorf("Expected the duration to default to the phases' 10m, got %v", pattern.Duration)
+	}
+	if len(pattern.Phases) != 2 {
+		t.Fatalf("Expected 2 phases, got %d", len(pattern.Phases))
+	}
+
+	gap := pattern.Phases[0]
+	if gap.Duration != 2*time.Minute || gap.MarketCondition != MarketVolatile || gap.OrderIntensity != 3 {
+		t.Errorf("Unexpected gap phase: %+v", gap)
+	}
+	if gap.UserBehavior[BehaviorFOMO] != 0.5 {
+		t.Errorf("Expected FOMO share 0.5, got %f", gap.UserBehavior[BehaviorFOMO])
+	}
+	if len(gap.Events) != 1 || gap.Events[0].Type != EventNews || gap.Events[0].Duration != 10*time.Minute {
+		t.Errorf("Unexpected gap events: %+v", gap.Events)
+	}
+	if pattern.Phases[1].OrderIntensity != 1 {
+		t.Errorf("Expected the order intensity to default to 1, got %f", pattern.Phases[1].OrderIntensity)
+	}
+	if len(pattern.Triggers) != 1 || pattern.Triggers[0].NextPhase != "Gap Fill" {
+		t.Errorf("Unexpected triggers: %+v", pattern.Triggers)
+	}
+}
+
+func TestParseScenario_JSON(t *testing.T) {
+	scenario := `{
+		"id": "quiet_lunch",
+		"duration": "1h",
+		"phases": [
+			{"name": "Lunch", "duration": "45m", "market_condition": "STEADY", "volatility_level": 0.05}
+		]
+	}`
+
+	id, pattern, err := ParseScenario([]byte(scenario), "quiet_lunch.json")
+	if err != nil {
+		t.Fatalf("Failed to parse scenario: %v", err)
+	}
+	if id != "quiet_lunch" || pattern.Name != "quiet_lunch" || pattern.Duration != time.Hour {
+		t.Errorf("Unexpected scenario %s: %+v", id, pattern)
+	}
+}
+
+func TestParseScenario_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		problems []string
+	}{
+		{
+			name:     "unknown field",
+			scenario: "id: a\nphases: []\nspeed: 2\n",
+			problems: []string{"field speed not found"},
+		},
+		{
+			name:     "unknown field in JSON",
+			scenario: `{"id": "a", "speed": 2}`,
+			problems: []string{`unknown field "speed"`},
+		},
+		{
+			name:     "no phases",
+			scenario: "id: Bad-Id\n",
+			problems: []string{"id: \"Bad-Id\"", "phases: at least one phase is required"},
+		},
+		{
+			name: "every problem is reported",
+			scenario: `
+id: broken
+duration: 1m
+phases:
+  - name: One
+    duration: 90
+    market_condition: CALM
+    volatility_level: 2
+    user_behavior:
+      FOMO: 0.5
+      HODL: 0.2
+    events:
+      - type: NEWS
+        severity: EXTREME
+        price_impact: 150
+        duration: 5m
+  - name: One
+    duration: 2m
+    market_condition: STEADY
+triggers:
+  - type: RANDOM
+    next_phase: Two
+`,
+			problems: []string{
+				`phases[0].duration: "90" is not a duration`,
+				`phases[0].market_condition: "CALM" must be one of`,
+				"phases[0].volatility_level: 2 must be between 0 and 1",
+				`phases[0].user_behavior: "HODL" must be one of`,
+				"phases[0].user_behavior: shares add up to 0.7 instead of 1",
+				`phases[0].events[0].severity: "EXTREME" must be one of`,
+				"phases[0].events[0].price_impact: 150 must be between 0 and 100 percent",
+				`phases[1].name: duplicate phase "One"`,
+				"duration: 1m0s is shorter than the phases' 2m0s",
+				`triggers[0].next_phase: "Two" is not a phase of the scenario`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseScenario([]byte(tt.scenario), "test.yaml")
+			if err == nil {
+				t.Fatal("Expected the scenario to be rejected")
+			}
+
+			simErr, ok := err.(SimulationError)
+			if !ok || simErr.Code != "INVALID_SCENARIO" {
+				t.Fatalf("Expected an INVALID_SCENARIO error, got %v", err)
+			}
+			for _, problem := range tt.problems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("Expected %q in %q", problem, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestPatternManager_LoadScenarios(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"opening_gap.yaml": openingGapScenario,
+		"flash_crash.json": `{"id": "flash_crash", "name": "Custom Crash", "phases": [{"name": "Crash", "duration": "1m", "market_condition": "CRASH"}]}`,
+		"README.md":        "not a scenario",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pm := NewPatternManager(NewMockEventGenerator())
+	ids, err := pm.LoadScenarios(dir)
+	if err != nil {
+		t.Fatalf("Failed to load scenarios: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "flash_crash" || ids[1] != "opening_gap" {
+		t.Errorf("Expected flash_crash and opening_gap in file name order, got %v", ids)
+	}
+
+	patterns := pm.ListPatterns()
+	found := false
+	for _, name := range patterns {
+		if name == "opening_gap" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected opening_gap among the patterns, got %v", patterns)
+	}
+	if pm.GetPattern("flash_crash").Name != "Custom Crash" {
+		t.Error("Expected the scenario file to replace the standard flash_crash pattern")
+	}
+
+	// A bad file stops the load and names the file
+	if err := os.WriteFile(filepath.Join(dir, "z_bad.yml"), []byte("id: bad\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.LoadScenarios(dir); err == nil || !strings.Contains(err.Error(), "z_bad.yml") {
+		t.Errorf("Expected an error naming z_bad.yml, got %v", err)
+	}
+}
+
+func TestRealisticSimulator_ScenarioPhases(t *testing.T) {
+	priceGen := NewRealisticPriceGenerator(DefaultPriceGeneratorConfig())
+	orderGen := NewRealisticOrderGenerator(DefaultOrderGeneratorConfig())
+	eventGen := NewPatternEventGenerator(DefaultEventGeneratorConfig())
+	engine := NewIntegrationTradingEngine()
+	simulator := NewRealisticSimulator(priceGen, orderGen, eventGen, engine)
+
+	if _, _, err := simulator.Patterns().AddScenario([]byte(openingGapScenario)); err != nil {
+		t.Fatalf("Failed to add scenario: %v", err)
+	}
+
+	config := DefaultSimulationConfig()
+	config.SimulationDuration = 5 * time.Minute
+	config.PatternProbabilities = map[string]float64{"opening_gap": 1.0}
+	config.Seed = 3
+
+	if _, err := simulator.RunDeterministic(context.Background(), config); err != nil {
+		t.Fatalf("Deterministic run failed: %v", err)
+	}
+
+	var activated int
+	for _, event := range simulator.patternHistory {
+		if event.PatternName == "opening_gap" && event.EventType == "activated" {
+			activated++
+		}
+	}
+	if activated != 1 {
+		t.Errorf("Expected opening_gap to be activated once, got %d", activated)
+	}
+	if simulator.activePhases["opening_gap"] != 1 {
+		t.Errorf("Expected opening_gap to reach its Gap Fill phase after 5m, got phase %d", simulator.activePhases["opening_gap"])
+	}
+
+	// The gap phase injected its news event
+	injected := false
+	for _, event := range eventGen.eventHistory {
+		if event.ID == "opening_gap_0_0" {
+			injected = true
+		}
+	}
+	if !injected {
+		t.Error("Expected the gap phase's news event to be injected")
+	}
+}
+
+// schemaCheckScenario sets every field the scenario schema defines
+const schemaCheckScenario = `{
+	"id": "schema_check",
+	"name": "Schema Check",
+	"description": "Every field",
+	"duration": "10m",
+	"parameters": {"gap": 0.04},
+	"phases": [{
+		"name": "One",
+		"duration": "1m",
+		"market_condition": "STEADY",
+		"volatility_level": 0.5,
+		"order_intensity": 2,
+		"price_direction": "UP",
+		"user_behavior": {"FOMO": 1},
+		"events": [{
+			"type": "NEWS",
+			"severity": "LOW",
+			"affected_symbols": ["BTCUSD"],
+			"price_impact": 1,
+			"duration": "1m",
+			"description": "News"
+		}]
+	}],
+	"triggers": [{"type": "TIME", "condition": "elapsed", "parameters": {"after": 60}, "next_phase": "One"}]
+}`
+
+// schemaObject is an object of the scenario schema and where the check
+// scenario sets it
+type schemaObject struct {
+	name   string
+	schema map[string]interface{}
+	fields reflect.Type
+	object func(scenario map[string]interface{}) map[string]interface{}
+}
+
+func scenarioSchemaObjects(t *testing.T) []schemaObject {
+	t.Helper()
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(ScenarioSchema, &schema); err != nil {
+		t.Fatalf("Invalid scenario schema: %v", err)
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	phase := func(scenario map[string]interface{}) map[string]interface{} {
+		return scenario["phases"].([]interface{})[0].(map[string]interface{})
+	}
+
+	return []schemaObject{
+		{"scenario", schema, reflect.TypeOf(scenarioFile{}), func(s map[string]interface{}) map[string]interface{} { return s }},
+		{"phase", defs["phase"].(map[string]interface{}), reflect.TypeOf(scenarioPhase{}), phase},
+		{"event", defs["event"].(map[string]interface{}), reflect.TypeOf(scenarioEvent{}), func(s map[string]interface{}) map[string]interface{} {
+			return phase(s)["events"].([]interface{})[0].(map[string]interface{})
+		}},
+		{"trigger", defs["trigger"].(map[string]interface{}), reflect.TypeOf(scenarioTrigger{}), func(s map[string]interface{}) map[string]interface{} {
+			return s["triggers"].([]interface{})[0].(map[string]interface{})
+		}},
+	}
+}
+
+// parseModified parses the schema check scenario after modify changed it
+func parseModified(t *testing.T, modify func(scenario map[string]interface{})) error {
+	t.Helper()
+
+	var scenario map[string]interface{}
+	if err := json.Unmarshal([]byte(schemaCheckScenario), &scenario); err != nil {
+		t.Fatal(err)
+	}
+	modify(scenario)
+	data, err := json.Marshal(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = ParseScenario(data, "schema_check.json")
+	return err
+}
+
+func TestScenarioSchema_AgreesWithValidation(t *testing.T) {
+	if _, _, err := ParseScenario([]byte(schemaCheckScenario), "schema_check.json"); err != nil {
+		t.Fatalf("Expected the schema check scenario to be valid: %v", err)
+	}
+
+	for _, obj := range scenarioSchemaObjects(t) {
+		properties := obj.schema["properties"].(map[string]interface{})
+		required := map[string]bool{}
+		for _, name := range obj.schema["required"].([]interface{}) {
+			required[name.(string)] = true
+		}
+
+		// The schema defines exactly the fields the scenario decodes, and
+		// neither allows others
+		var fields []string
+		for i := 0; i < obj.fields.NumField(); i++ {
+			fields = append(fields, obj.fields.Field(i).Tag.Get("json"))
+		}
+		var names []string
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(fields)
+		sort.Strings(names)
+		if !reflect.DeepEqual(fields, names) {
+			t.Errorf("%s: schema properties %v, scenario fields %v", obj.name, names, fields)
+		}
+		if obj.schema["additionalProperties"] != false {
+			t.Errorf("%s: expected the schema to reject unknown fields", obj.name)
+		}
+		if err := parseModified(t, func(s map[string]interface{}) { obj.object(s)["unknown"] = 1 }); err == nil {
+			t.Errorf("%s: expected an unknown field to be rejected", obj.name)
+		}
+
+		for name, property := range properties {
+			property := property.(map[string]interface{})
+
+			// Exactly the required fields can't be left out
+			err := parseModified(t, func(s map[string]interface{}) { delete(obj.object(s), name) })
+			if required[name] && err == nil {
+				t.Errorf("%s.%s: required by the schema but not by validation", obj.name, name)
+			}
+			if !required[name] && err != nil {
+				t.Errorf("%s.%s: optional in the schema but required by validation: %v", obj.name, name, err)
+			}
+
+			// Every enum value is valid, and nothing else
+			if values, ok := property["enum"].([]interface{}); ok {
+				for _, value := range append(values, "UNKNOWN") {
+					err := parseModified(t, func(s map[string]interface{}) { obj.object(s)[name] = value })
+					if (value == "UNKNOWN") != (err != nil) {
+						t.Errorf("%s.%s: schema and validation disagree on %q: %v", obj.name, name, value, err)
+					}
+				}
+			}
+
+			// Numbers are valid exactly within their bounds
+			type bound struct {
+				value float64
+				valid bool
+			}
+			var bounds []bound
+			if minimum, ok := property["minimum"].(float64); ok {
+				bounds = append(bounds, bound{minimum, true}, bound{minimum - 0.01, false})
+			}
+			if maximum, ok := property["maximum"].(float64); ok {
+				bounds = append(bounds, bound{maximum, true}, bound{maximum + 0.01, false})
+			}
+			for _, b := range bounds {
+				err := parseModified(t, func(s map[string]interface{}) { obj.object(s)[name] = b.value })
+				if b.valid != (err == nil) {
+					t.Errorf("%s.%s: schema and validation disagree on %g: %v", obj.name, name, b.value, err)
+				}
+			}
+		}
+	}
+}
+
+func TestScenarioSchema_EnumsMatchTheValidatedValues(t *testing.T) {
+	defs := map[string]interface{}{}
+	for _, obj := range scenarioSchemaObjects(t) {
+		defs[obj.name] = obj.schema["properties"]
+	}
+	enum := func(object, property string) []string {
+		schema := defs[object].(map[string]interface{})[property].(map[string]interface{})
+		if names, ok := schema["propertyNames"].(map[string]interface{}); ok {
+			schema = names
+		}
+		var values []string
+		for _, value := range schema["enum"].([]interface{}) {
+			values = append(values, value.(string))
+		}
+		return values
+	}
+
+	tests := []struct {
+		object, property string
+		validated        string
+	}{
+		{"phase", "market_condition", joinValues(scenarioMarketConditions)},
+		{"phase", "price_direction", joinValues(scenarioDirections)},
+		{"phase", "user_behavior", joinValues(scenarioBehaviors)},
+		{"event", "type", joinValues(scenarioEventTypes)},
+		{"event", "severity", joinValues(scenarioSeverities)},
+		{"trigger", "type", joinValues(scenarioTriggerTypes)},
+	}
+	for _, tt := range tests {
+		if schema := strings.Join(enum(tt.object, tt.property), ", "); schema != tt.validated {
+			t.Errorf("%s.%s: schema allows %s, validation %s", tt.object, tt.property, schema, tt.validated)
+		}
+	}
+}
+
+func TestScenarioSchema_Patterns(t *testing.T) {
+	var schema struct {
+		Properties struct {
+			ID struct {
+				Pattern string `json:"pattern"`
+			} `json:"id"`
+		} `json:"properties"`
+		Defs struct {
+			Duration struct {
+				Pattern string `json:"pattern"`
+			} `json:"duration"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(ScenarioSchema, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.Properties.ID.Pattern != scenarioIDPattern.String() {
+		t.Errorf("Schema id pattern %q, validation %q", schema.Properties.ID.Pattern, scenarioIDPattern)
+	}
+
+	// Zero durations match the schema's pattern but are not positive
+	durationPattern := regexp.MustCompile(schema.Defs.Duration.Pattern)
+	for _, value := range []string{
+		"90s", "5m", "1h30m", "1.5h", ".5s", "1.s", "+5m", "250ms", "10us", "10µs", "10μs", "100ns",
+		"90", "5 m", "-5m", "5M", "1d", "h", ".s", "", "1h 30m",
+	} {
+		_, err := parseScenarioDuration(value)
+		if durationPattern.MatchString(value) != (err == nil) {
+			t.Errorf("Schema and validation disagree on the duration %q: %v", value, err)
+		}
+	}
+}
+
+func TestPatternManager_AddScenarioKeepsStandardPatterns(t *testing.T) {
+	pm := NewPatternManager(NewMockEventGenerator())
+	standard := pm.GetPattern("flash_crash")
+
+	upload := `{"id": "flash_crash", "phases": [{"name": "Crash", "duration": "1m", "market_condition": "CRASH"}]}`
+	_, _, err := pm.AddScenario([]byte(upload))
+	if simErr, ok := err.(SimulationError); !ok || simErr.Code != "INVALID_SCENARIO" || !strings.Contains(err.Error(), "standard pattern") {
+		t.Fatalf("Expected an INVALID_SCENARIO error for a standard id, got %v", err)
+	}
+	if pm.GetPattern("flash_crash") != standard {
+		t.Error("Expected the standard flash_crash pattern to be kept")
+	}
+
+	// Uploads replace uploaded scenarios
+	if _, _, err := pm.AddScenario([]byte(openingGapScenario)); err != nil {
+		t.Fatalf("Failed to add scenario: %v", err)
+	}
+	replacement := strings.Replace(openingGapScenario, "name: Opening Gap", "name: Opening Gap v2", 1)
+	if _, _, err := pm.AddScenario([]byte(replacement)); err != nil {
+		t.Fatalf("Failed to replace scenario: %v", err)
+	}
+	if pm.GetPattern("opening_gap").Name != "Opening Gap v2" {
+		t.Error("Expected the upload to replace the opening_gap scenario")
+	}
+}
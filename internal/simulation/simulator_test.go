@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"simulated_exchange/internal/api/dto"
+	"simulated_exchange/pkg/clock"
+	pkgconfig "simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/seasonality"
 )
 
 // Mock implementations for testing
@@ -671,4 +674,134 @@ func TestSimulationErrorConditions(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when injecting volatility while not running")
 	}
-}
\ No newline at end of file
+}
+func TestRealisticPriceGenerator_TradingDayOnVirtualClock(t *testing.T) {
+	virtualClock := clock.NewVirtual(DeterministicEpoch.Add(9*time.Hour + 30*time.Minute))
+	priceGen := NewRealisticPriceGenerator(DefaultPriceGeneratorConfig())
+	priceGen.SetClock(virtualClock)
+	priceGen.SetBasePrice("BTCUSD", 50000)
+
+	// A trading session passes in simulated minutes
+	price := 50000.0
+	for i := 0; i < 390; i++ {
+		virtualClock.Advance(time.Minute)
+		price = priceGen.GeneratePrice("BTCUSD", price, time.Minute)
+	}
+
+	state := priceGen.prices["BTCUSD"]
+	if !state.LastUpdate.Equal(virtualClock.Now()) {
+		t.Errorf("Expected last update at virtual time %v, got %v", virtualClock.Now(), state.LastUpdate)
+	}
+	if state.DailyOpen != 50000 {
+		t.Errorf("Expected daily open to stay 50000 within the day, got %f", state.DailyOpen)
+	}
+
+	// The next day's first price resets the daily values
+	virtualClock.Advance(24 * time.Hour)
+	price = priceGen.GeneratePrice("BTCUSD", price, time.Minute)
+
+	if state.DailyOpen != price || state.DailyHigh != price || state.DailyLow != price {
+		t.Errorf("Expected daily values reset to %f, got open %f high %f low %f",
+			price, state.DailyOpen, state.DailyHigh, state.DailyLow)
+	}
+}
+
+func TestRealisticGenerators_Seasonality(t *testing.T) {
+	virtualClock := clock.NewVirtual(DeterministicEpoch)
+	priceGen := NewRealisticPriceGenerator(DefaultPriceGeneratorConfig())
+	priceGen.SetClock(virtualClock)
+	orderGen := NewRealisticOrderGenerator(DefaultOrderGeneratorConfig())
+	orderGen.SetClock(virtualClock)
+
+	at := func(day, hour, minute int) (rate, volatility float64) {
+		virtualClock.AdvanceTo(DeterministicEpoch.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute))
+		return orderGen.calculateOrderGenerationRate(MarketSteady, 50000), priceGen.getCurrentVolatility("BTCUSD")
+	}
+
+	// Orders and volatility pick up together at the open and the close,
+	// and both quieten over lunch
+	nightRate, nightVol := at(0, 3, 0)
+	openRate, openVol := at(0, 9, 35)
+	lunchRate, lunchVol := at(0, 12, 30)
+	closeRate, closeVol := at(0, 15, 45)
+	if openRate <= lunchRate || closeRate <= lunchRate || lunchRate >= nightRate {
+		t.Errorf("Expected a U-shaped order rate, got %.2f at the open, %.2f over lunch, %.2f at the close and %.2f at night",
+			openRate, lunchRate, closeRate, nightRate)
+	}
+	if openVol <= lunchVol || closeVol <= lunchVol || lunchVol >= nightVol {
+		t.Errorf("Expected U-shaped volatility, got %.4f at the open, %.4f over lunch, %.4f at the close and %.4f at night",
+			openVol, lunchVol, closeVol, nightVol)
+	}
+
+	// The next day is a holiday, and quieter
+	profile, err := seasonality.New(pkgconfig.SeasonalityConfig{Enabled: true, Holidays: []string{"2024-01-02"}, Location: "UTC"})
+	if err != nil {
+		t.Fatalf("Failed to build the profile: %v", err)
+	}
+	orderGen.SetSeasonality(profile)
+	priceGen.SetSeasonality(profile)
+	if rate, vol := at(1, 9, 35); rate >= openRate || vol >= openVol {
+		t.Errorf("Expected a quieter open on a holiday, got rate %.2f and volatility %.4f", rate, vol)
+	}
+}
+
+func TestRunLog_HashesTradesApartFromOrders(t *testing.T) {
+	at := DeterministicEpoch
+	buy := dto.PlaceOrderRequest{Symbol: "AAPL", Side: "buy", Type: "limit", Quantity: 10, Price: 100}
+	sell := dto.PlaceOrderRequest{Symbol: "AAPL", Side: "sell", Type: "limit", Quantity: 10, Price: 99}
+
+	summarize := func(price float64) RunSummary {
+		rl := newRunLog()
+		rl.record(at, "flow", buy, "b1", nil, nil)
+		rl.record(at, "flow", sell, "s1", []ExecutedTrade{
+			{Symbol: "AAPL", Price: price, Quantity: 4, BuyOrderID: "b1", SellOrderID: "s1"},
+		}, nil)
+		var summary RunSummary
+		rl.sum(&summary)
+		return summary
+	}
+
+	first, second, repriced := summarize(100), summarize(100), summarize(99)
+	if first != second {
+		t.Errorf("Expected identical summaries, got %+v and %+v", first, second)
+	}
+	if first.TradesExecuted != 1 || first.OrdersSubmitted != 2 {
+		t.Errorf("Expected 2 orders and 1 trade, got %+v", first)
+	}
+
+	// The same orders executing at another price only change the trade hash
+	if repriced.OrderLogHash != first.OrderLogHash {
+		t.Error("Expected the order rl hash to cover orders only")
+	}
+	if repriced.TradeHash == first.TradeHash {
+		t.Error("Expected the trade hash to cover the trade price")
+	}
+
+	// Engines that don't report trades leave the trade hash empty
+	unreported := newRunLog()
+	unreported.record(at, "flow", buy, "", nil, nil)
+	var summary RunSummary
+	unreported.sum(&summary)
+	if summary.TradeHash != "" || summary.OrderLogHash == "" {
+		t.Errorf("Expected only an order rl hash, got %+v", summary)
+	}
+}
+
+func TestRunLog_TradesReferToSubmissionSequence(t *testing.T) {
+	rl := newRunLog()
+	order := dto.PlaceOrderRequest{Symbol: "AAPL", Side: "sell", Type: "limit", Quantity: 5, Price: 100}
+	rl.record(DeterministicEpoch, "flow", order, "s1", nil, nil)
+
+	if got := rl.fill("s1", 2); got != 1 {
+		t.Errorf("Expected sequence 1, got %d", got)
+	}
+	if got := rl.fill("unknown", 2); got != 0 {
+		t.Errorf("Expected sequence 0 for an order the run didn't submit, got %d", got)
+	}
+
+	// Filled orders are forgotten
+	rl.fill("s1", 3)
+	if len(rl.resting) != 0 {
+		t.Errorf("Expected no resting orders, got %d", len(rl.resting))
+	}
+}
@@ -19,6 +19,7 @@ const (
 
 type Order struct {
 	ID        string
+	UserID    string
 	Symbol    string
 	Side      OrderSide
 	Type      OrderType
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"simulated_exchange/pkg/shared"
+)
+
+// CAT event types the order events are reported as
+const (
+	CATNewOrder      = "MENO"
+	CATOrderModified = "MEOM"
+	CATOrderCanceled = "MEOC"
+	CATTrade         = "MEOT"
+)
+
+// catTimestampFormat is the CAT timestamp format with nanosecond precision
+const catTimestampFormat = "20060102 150405.000000000"
+
+// CATRecord is one order event in a CAT-style export. Quantity is the order
+// quantity, or the traded quantity of a trade; LeavesQty is what remains
+// open after the event.
+type CATRecord struct {
+	ActionType      string  `json:"actionType"`
+	FirmROEID       string  `json:"firmROEID"`
+	Type            string  `json:"type"`
+	CATReporterIMID string  `json:"CATReporterIMID"`
+	Symbol          string  `json:"symbol"`
+	EventTimestamp  string  `json:"eventTimestamp"`
+	OrderID         string  `json:"orderID"`
+	Side            string  `json:"side"`
+	OrderType       string  `json:"orderType"`
+	Price           float64 `json:"price,omitempty"`
+	Quantity        float64 `json:"quantity"`
+	LeavesQty       float64 `json:"leavesQty"`
+	TradeID         string  `json:"tradeID,omitempty"`
+	CancelReason    string  `json:"cancelReason,omitempty"`
+	SourceSystem    string  `json:"sourceSystem"`
+}
+
+// CATFileName returns the name of the export file of a UTC trade date
+func CATFileName(reporterIMID string, tradeDate time.Time) string {
+	return fmt.Sprintf("%s_%s_OrderEvents_000001.json", reporterIMID, tradeDate.UTC().Format("20060102"))
+}
+
+// WriteCAT writes order events as newline-delimited CAT records. Accepted
+// orders are new orders, fills are trades and amendments modifications.
+// Rejected orders never rest on the book and are reported as cancelled
+// with their rejection reason.
+func WriteCAT(w io.Writer, reporterIMID string, events []*shared.OrderEvent) error {
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+
+	for _, event := range events {
+		if err := encoder.Encode(ToCATRecord(reporterIMID, event)); err != nil {
+			return fmt.Errorf("failed to write CAT record for order event %d: %w", event.ID, err)
+		}
+	}
+
+	return buffered.Flush()
+}
+
+// ToCATRecord converts an order event to a CAT record
+func ToCATRecord(reporterIMID string, event *shared.OrderEvent) *CATRecord {
+	record := &CATRecord{
+		ActionType:      "NEW",
+		FirmROEID:       fmt.Sprintf("%s_%d", event.CreatedAt.UTC().Format("20060102"), event.ID),
+		CATReporterIMID: reporterIMID,
+		Symbol:          event.Symbol,
+		EventTimestamp:  event.CreatedAt.UTC().Format(catTimestampFormat),
+		OrderID:         event.OrderID,
+		Side:            catSide(event.Side),
+		OrderType:       catOrderType(event.OrderType),
+		Price:           event.Price,
+		Quantity:        event.Quantity,
+		LeavesQty:       event.Quantity,
+		SourceSystem:    event.Source,
+	}
+
+	switch event.EventType {
+	case shared.OrderEventAccepted:
+		record.Type = CATNewOrder
+	case shared.OrderEventAmended:
+		record.Type = CATOrderModified
+	case shared.OrderEventPartiallyFilled, shared.OrderEventFilled:
+		record.Type = CATTrade
+		record.TradeID = event.TradeID
+		record.Price = event.FillPrice
+		record.Quantity = event.FillQuantity
+	case shared.OrderEventCancelled, shared.OrderEventRejected:
+		record.Type = CATOrderCanceled
+		record.CancelReason = event.Reason
+	}
+
+	if event.Status == shared.OrderStatusCancelled || event.Status == shared.OrderStatusRejected {
+		record.LeavesQty = 0
+	}
+
+	return record
+}
+
+func catSide(side shared.OrderSide) string {
+	if side == shared.OrderSideBuy {
+		return "B"
+	}
+	return "SL"
+}
+
+func catOrderType(orderType shared.OrderType) string {
+	if orderType == shared.OrderTypeMarket {
+		return "MKT"
+	}
+	return "LMT"
+}
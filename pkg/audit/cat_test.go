@@ -0,0 +1,177 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/shared"
+)
+
+func testEvent(eventType shared.OrderEventType, status shared.OrderStatus) *shared.OrderEvent {
+	return &shared.OrderEvent{
+		ID:        42,
+		OrderID:   "order-1",
+		UserID:    "user-1",
+		Symbol:    "AAPL",
+		Side:      shared.OrderSideBuy,
+		OrderType: shared.OrderTypeLimit,
+		EventType: eventType,
+		Status:    status,
+		Price:     150.25,
+		Quantity:  6,
+		Source:    shared.OrderEventSourceClient,
+		CreatedAt: time.Date(2024, 1, 15, 14, 30, 5, 123456789, time.UTC),
+	}
+}
+
+func TestToCATRecord(t *testing.T) {
+	tests := []struct {
+		name         string
+		event        *shared.OrderEvent
+		catType      string
+		price        float64
+		quantity     float64
+		leavesQty    float64
+		tradeID      string
+		cancelReason string
+	}{
+		{
+			name:      "accepted order is a new order",
+			event:     testEvent(shared.OrderEventAccepted, shared.OrderStatusPending),
+			catType:   CATNewOrder,
+			price:     150.25,
+			quantity:  6,
+			leavesQty: 6,
+		},
+		{
+			name:      "amendment is a modification",
+			event:     testEvent(shared.OrderEventAmended, shared.OrderStatusPartial),
+			catType:   CATOrderModified,
+			price:     150.25,
+			quantity:  6,
+			leavesQty: 6,
+		},
+		{
+			name: "partial fill is a trade at the fill price",
+			event: func() *shared.OrderEvent {
+				event := testEvent(shared.OrderEventPartiallyFilled, shared.OrderStatusPartial)
+				event.TradeID, event.FillQuantity, event.FillPrice = "trade-1", 4, 150
+				return event
+			}(),
+			catType:   CATTrade,
+			price:     150,
+			quantity:  4,
+			leavesQty: 6,
+			tradeID:   "trade-1",
+		},
+		{
+			name: "fill leaves nothing open",
+			event: func() *shared.OrderEvent {
+				event := testEvent(shared.OrderEventFilled, shared.OrderStatusFilled)
+				event.Quantity, event.TradeID, event.FillQuantity, event.FillPrice = 0, "trade-2", 6, 150.2
+				return event
+			}(),
+			catType:  CATTrade,
+			price:    150.2,
+			quantity: 6,
+			tradeID:  "trade-2",
+		},
+		{
+			name: "cancellation leaves nothing open",
+			event: func() *shared.OrderEvent {
+				event := testEvent(shared.OrderEventCancelled, shared.OrderStatusCancelled)
+				event.Reason = "cancelled by user"
+				return event
+			}(),
+			catType:      CATOrderCanceled,
+			price:        150.25,
+			quantity:     6,
+			cancelReason: "cancelled by user",
+		},
+		{
+			name: "rejection is a cancellation with its reason",
+			event: func() *shared.OrderEvent {
+				event := testEvent(shared.OrderEventRejected, shared.OrderStatusRejected)
+				event.Reason = "INSUFFICIENT_BALANCE: order requires 901.50 cash"
+				event.Source = shared.OrderEventSourceAccounts
+				return event
+			}(),
+			catType:      CATOrderCanceled,
+			price:        150.25,
+			quantity:     6,
+			cancelReason: "INSUFFICIENT_BALANCE: order requires 901.50 cash",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := ToCATRecord("SIMX", tt.event)
+
+			assert.Equal(t, tt.catType, record.Type)
+			assert.Equal(t, "NEW", record.ActionType)
+			assert.Equal(t, "20240115_42", record.FirmROEID)
+			assert.Equal(t, "SIMX", record.CATReporterIMID)
+			assert.Equal(t, "20240115 143005.123456789", record.EventTimestamp)
+			assert.Equal(t, "order-1", record.OrderID)
+			assert.Equal(t, "AAPL", record.Symbol)
+			assert.Equal(t, tt.price, record.Price)
+			assert.Equal(t, tt.quantity, record.Quantity)
+			assert.Equal(t, tt.leavesQty, record.LeavesQty)
+			assert.Equal(t, tt.tradeID, record.TradeID)
+			assert.Equal(t, tt.cancelReason, record.CancelReason)
+			assert.Equal(t, tt.event.Source, record.SourceSystem)
+		})
+	}
+}
+
+func TestToCATRecord_SideAndOrderType(t *testing.T) {
+	event := testEvent(shared.OrderEventAccepted, shared.OrderStatusPending)
+	record := ToCATRecord("SIMX", event)
+	assert.Equal(t, "B", record.Side)
+	assert.Equal(t, "LMT", record.OrderType)
+
+	event.Side, event.OrderType = shared.OrderSideSell, shared.OrderTypeMarket
+	record = ToCATRecord("SIMX", event)
+	assert.Equal(t, "SL", record.Side)
+	assert.Equal(t, "MKT", record.OrderType)
+}
+
+func TestToCATRecord_UsesUTC(t *testing.T) {
+	event := testEvent(shared.OrderEventAccepted, shared.OrderStatusPending)
+	// 20:00 in New York on the 14th is already the 15th in UTC
+	event.CreatedAt = time.Date(2024, 1, 14, 20, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	record := ToCATRecord("SIMX", event)
+	assert.Equal(t, "20240115_42", record.FirmROEID)
+	assert.Equal(t, "20240115 010000.000000000", record.EventTimestamp)
+}
+
+func TestWriteCAT(t *testing.T) {
+	events := []*shared.OrderEvent{
+		testEvent(shared.OrderEventAccepted, shared.OrderStatusPending),
+		testEvent(shared.OrderEventAmended, shared.OrderStatusPending),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCAT(&buf, "SIMX", events))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var types []string
+	for _, line := range lines {
+		var record CATRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		types = append(types, record.Type)
+	}
+	assert.Equal(t, []string{CATNewOrder, CATOrderModified}, types)
+}
+
+func TestCATFileName(t *testing.T) {
+	tradeDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "SIMX_20240115_OrderEvents_000001.json", CATFileName("SIMX", tradeDate))
+}
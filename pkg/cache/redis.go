@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -237,6 +238,87 @@ func (r *RedisClient) TrimList(ctx context.Context, key string, start, stop int6
 	return nil
 }
 
+// tokenBucketScript atomically refills a set of token buckets stored as
+// hashes and takes cost tokens from each of them, or from none if any is
+// short. Redis server time is used so that all replicas share one clock.
+// ARGV holds the cost followed by the rate and burst of each key. Returns
+// {allowed, remaining_tokens...}; tokens are returned as strings to
+// preserve the fractional part.
+var tokenBucketScript = redis.NewScript(`
+local cost = tonumber(ARGV[1])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local tokens = {}
+local allowed = 1
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[2 * i])
+	local burst = tonumber(ARGV[2 * i + 1])
+	local state = redis.call('HMGET', key, 'tokens', 'ts')
+	local level = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if level == nil or ts == nil then
+		level = burst
+		ts = now
+	end
+	tokens[i] = math.min(burst, level + math.max(0, now - ts) * rate)
+	if tokens[i] < cost then
+		allowed = 0
+	end
+end
+local result = {allowed}
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[2 * i])
+	local burst = tonumber(ARGV[2 * i + 1])
+	if allowed == 1 then
+		tokens[i] = tokens[i] - cost
+	end
+	redis.call('HSET', key, 'tokens', tostring(tokens[i]), 'ts', tostring(now))
+	redis.call('PEXPIRE', key, math.ceil(burst / rate * 1000) + 1000)
+	result[i + 1] = tostring(tokens[i])
+end
+return result
+`)
+
+// TokenBucket identifies a token bucket stored at Key that refills at Rate
+// tokens per second up to Burst
+type TokenBucket struct {
+	Key   string
+	Rate  float64
+	Burst int
+}
+
+// TakeTokens atomically takes cost tokens from every bucket if each of them
+// holds enough, and from none otherwise. It returns whether the tokens were
+// granted and the tokens left in each bucket.
+func (r *RedisClient) TakeTokens(ctx context.Context, buckets []TokenBucket, cost float64) (bool, []float64, error) {
+	keys := make([]string, len(buckets))
+	args := make([]interface{}, 0, 1+2*len(buckets))
+	args = append(args, cost)
+	for i, bucket := range buckets {
+		keys[i] = bucket.Key
+		args = append(args, bucket.Rate, bucket.Burst)
+	}
+
+	result, err := tokenBucketScript.Run(ctx, r.client, keys, args...).Slice()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to take tokens for %v: %w", keys, err)
+	}
+	if len(result) != len(buckets)+1 {
+		return false, nil, fmt.Errorf("unexpected token bucket result for %v: %v", keys, result)
+	}
+
+	allowed, _ := result[0].(int64)
+	remaining := make([]float64, len(buckets))
+	for i := range buckets {
+		remainingStr, _ := result[i+1].(string)
+		if remaining[i], err = strconv.ParseFloat(remainingStr, 64); err != nil {
+			return false, nil, fmt.Errorf("failed to parse remaining tokens for %s: %w", keys[i], err)
+		}
+	}
+
+	return allowed == 1, remaining, nil
+}
+
 // RedisHealthChecker implements the shared.HealthChecker interface for Redis
 type RedisHealthChecker struct {
 	client *RedisClient
@@ -0,0 +1,398 @@
+package clock
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"simulated_exchange/pkg/config"
+)
+
+// Clock is the time source of a simulation. Components that read the time
+// and wait through a Clock instead of the time package can run on scaled or
+// virtual time, so a full trading day can be simulated in seconds.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+
+	// NewTicker returns a ticker that ticks every d of the clock's time
+	NewTicker(d time.Duration) Ticker
+
+	// NewTimer returns a timer that fires once d of the clock's time has
+	// passed, unless it is stopped first
+	NewTimer(d time.Duration) Timer
+
+	// After returns a channel that receives the clock's time once d of the
+	// clock's time has passed. Callers that may stop waiting use NewTimer
+	// and stop it instead.
+	After(d time.Duration) <-chan time.Time
+
+	// Sleep blocks until d of the clock's time has passed
+	Sleep(d time.Duration)
+}
+
+// Ticker delivers ticks of a clock
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer delivers a single tick of a clock. Stop releases a timer that is
+// no longer waited for.
+type Timer interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// New creates the clock a configuration asks for: the wall clock at scale
+// 1, else a scaled clock
+func New(cfg config.ClockConfig) Clock {
+	if cfg.Scale == 1 && cfg.Start.IsZero() {
+		return Real()
+	}
+	start := cfg.Start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	return NewScaled(start, cfg.Scale)
+}
+
+type realClock struct{}
+
+// Real returns the wall clock
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() {
+	t.timer.Stop()
+}
+
+// Scaled is a clock that runs a fixed factor faster than the wall clock
+// from a start time: at 60x, an hour passes every minute
+type Scaled struct {
+	start  time.Time
+	anchor time.Time
+	factor float64
+}
+
+// NewScaled creates a clock standing at start that runs factor times as
+// fast as the wall clock
+func NewScaled(start time.Time, factor float64) *Scaled {
+	return &Scaled{start: start, anchor: time.Now(), factor: factor}
+}
+
+// Now returns the current scaled time
+func (s *Scaled) Now() time.Time {
+	return s.start.Add(time.Duration(float64(time.Since(s.anchor)) * s.factor))
+}
+
+// Since returns the scaled time passed since t
+func (s *Scaled) Since(t time.Time) time.Duration {
+	return s.Now().Sub(t)
+}
+
+// NewTicker returns a ticker that ticks every d of scaled time
+func (s *Scaled) NewTicker(d time.Duration) Ticker {
+	ticker := &scaledTicker{
+		ticker: time.NewTicker(s.wall(d)),
+		c:      make(chan time.Time, 1),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		for {
+			select {
+			case <-ticker.done:
+				return
+			case <-ticker.ticker.C:
+				select {
+				case ticker.c <- s.Now():
+				default:
+				}
+			}
+		}
+	}()
+	return ticker
+}
+
+// NewTimer returns a timer that fires once d of scaled time has passed
+func (s *Scaled) NewTimer(d time.Duration) Timer {
+	c := make(chan time.Time, 1)
+	return &scaledTimer{timer: time.AfterFunc(s.wall(d), func() { c <- s.Now() }), c: c}
+}
+
+// After returns a channel that receives the scaled time once d of scaled
+// time has passed
+func (s *Scaled) After(d time.Duration) <-chan time.Time {
+	return s.NewTimer(d).C()
+}
+
+// Sleep blocks until d of scaled time has passed
+func (s *Scaled) Sleep(d time.Duration) {
+	time.Sleep(s.wall(d))
+}
+
+// wall converts a scaled duration to wall clock time, at least 1ns so that
+// tickers stay valid
+func (s *Scaled) wall(d time.Duration) time.Duration {
+	wall := time.Duration(float64(d) / s.factor)
+	if wall < 1 {
+		return 1
+	}
+	return wall
+}
+
+type scaledTicker struct {
+	ticker *time.Ticker
+	c      chan time.Time
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (t *scaledTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *scaledTicker) Stop() {
+	t.once.Do(func() {
+		t.ticker.Stop()
+		close(t.done)
+	})
+}
+
+type scaledTimer struct {
+	timer *time.Timer
+	c     chan time.Time
+}
+
+func (t *scaledTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *scaledTimer) Stop() {
+	t.timer.Stop()
+}
+
+// Virtual is a step-driven clock that only moves when it is advanced.
+// Deterministic runs and tests drive it, so timestamps don't depend on how
+// fast the code runs. Tickers, timers and sleepers fire in order of their
+// due time as the clock passes it; those due at the same time fire in the
+// order they were created.
+type Virtual struct {
+	now     time.Time
+	waiters waiterQueue
+	created uint64
+	mutex   sync.RWMutex
+}
+
+// virtualWaiter is a ticker or timer of a virtual clock; timers have no
+// period
+type virtualWaiter struct {
+	due    time.Time
+	period time.Duration
+	c      chan time.Time
+
+	// seq orders waiters due at the same time; index is the position in
+	// the queue, -1 once the waiter has left it
+	seq   uint64
+	index int
+}
+
+// waiterQueue is a min-heap of waiters by due time
+type waiterQueue []*virtualWaiter
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+	if !q[i].due.Equal(q[j].due) {
+		return q[i].due.Before(q[j].due)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiterQueue) Push(x any) {
+	waiter := x.(*virtualWaiter)
+	waiter.index = len(*q)
+	*q = append(*q, waiter)
+}
+
+func (q *waiterQueue) Pop() any {
+	old := *q
+	waiter := old[len(old)-1]
+	old[len(old)-1] = nil
+	waiter.index = -1
+	*q = old[:len(old)-1]
+	return waiter
+}
+
+// NewVirtual creates a virtual clock standing at start
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{now: start}
+}
+
+// Now returns the current virtual time
+func (v *Virtual) Now() time.Time {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	return v.now
+}
+
+// Since returns the virtual time passed since t
+func (v *Virtual) Since(t time.Time) time.Duration {
+	return v.Now().Sub(t)
+}
+
+// NewTicker returns a ticker that ticks every d of virtual time
+func (v *Virtual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	waiter := &virtualWaiter{due: v.now.Add(d), period: d, c: make(chan time.Time, 1), index: -1}
+	v.add(waiter)
+	return &virtualTicker{clock: v, waiter: waiter}
+}
+
+// NewTimer returns a timer that fires once the clock has been advanced by
+// d. Stopping it removes it from the clock.
+func (v *Virtual) NewTimer(d time.Duration) Timer {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	waiter := &virtualWaiter{due: v.now.Add(d), c: make(chan time.Time, 1), index: -1}
+	if d <= 0 {
+		waiter.c <- v.now
+	} else {
+		v.add(waiter)
+	}
+	return &virtualTicker{clock: v, waiter: waiter}
+}
+
+// After returns a channel that receives the virtual time once the clock
+// has been advanced by d
+func (v *Virtual) After(d time.Duration) <-chan time.Time {
+	return v.NewTimer(d).C()
+}
+
+// Sleep blocks until the clock has been advanced by d
+func (v *Virtual) Sleep(d time.Duration) {
+	<-v.After(d)
+}
+
+// Advance moves the clock forward by d
+func (v *Virtual) Advance(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	v.AdvanceTo(v.Now().Add(d))
+}
+
+// AdvanceTo moves the clock forward to t, firing every ticker and timer due
+// on the way at its due time; the clock never moves back
+func (v *Virtual) AdvanceTo(t time.Time) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	for len(v.waiters) > 0 && !v.waiters[0].due.After(t) {
+		waiter := v.waiters[0]
+		v.now = waiter.due
+		select {
+		case waiter.c <- v.now:
+		default:
+		}
+		if waiter.period > 0 {
+			waiter.due = waiter.due.Add(waiter.period)
+			heap.Fix(&v.waiters, 0)
+		} else {
+			heap.Pop(&v.waiters)
+		}
+	}
+
+	if t.After(v.now) {
+		v.now = t
+	}
+}
+
+// add queues a waiter; the caller holds the lock
+func (v *Virtual) add(waiter *virtualWaiter) {
+	v.created++
+	waiter.seq = v.created
+	heap.Push(&v.waiters, waiter)
+}
+
+// remove drops a waiter if it is still queued; the caller holds the lock
+func (v *Virtual) remove(waiter *virtualWaiter) {
+	if waiter.index >= 0 {
+		heap.Remove(&v.waiters, waiter.index)
+	}
+}
+
+// virtualTicker is a ticker or, without a period, a timer of a virtual
+// clock
+type virtualTicker struct {
+	clock  *Virtual
+	waiter *virtualWaiter
+}
+
+func (t *virtualTicker) C() <-chan time.Time {
+	return t.waiter.c
+}
+
+func (t *virtualTicker) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	t.clock.remove(t.waiter)
+}
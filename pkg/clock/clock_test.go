@@ -0,0 +1,237 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+)
+
+var testStart = time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+
+// fired returns the time a channel holds, false if it holds none
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestNew(t *testing.T) {
+	assert.Equal(t, Real(), New(config.ClockConfig{Scale: 1}))
+
+	scaled, ok := New(config.ClockConfig{Scale: 60, Start: testStart}).(*Scaled)
+	require.True(t, ok)
+	assert.Equal(t, 60.0, scaled.factor)
+	assert.Equal(t, testStart, scaled.start)
+
+	// A start time alone runs a scaled clock at the wall clock's pace
+	scaled, ok = New(config.ClockConfig{Scale: 1, Start: testStart}).(*Scaled)
+	require.True(t, ok)
+	assert.Equal(t, 1.0, scaled.factor)
+}
+
+func TestVirtual_TimersFireInDueOrder(t *testing.T) {
+	clk := NewVirtual(testStart)
+
+	late := clk.NewTimer(15 * time.Second)
+	early := clk.NewTimer(5 * time.Second)
+	ticker := clk.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	clk.Advance(4 * time.Second)
+	_, ok := fired(early.C())
+	assert.False(t, ok)
+
+	clk.Advance(time.Second)
+	at, ok := fired(early.C())
+	require.True(t, ok)
+	assert.Equal(t, testStart.Add(5*time.Second), at)
+
+	clk.Advance(10 * time.Second)
+	at, ok = fired(ticker.C())
+	require.True(t, ok)
+	assert.Equal(t, testStart.Add(10*time.Second), at)
+	at, ok = fired(late.C())
+	require.True(t, ok)
+	assert.Equal(t, testStart.Add(15*time.Second), at)
+
+	// Fired timers leave the clock
+	assert.Len(t, clk.waiters, 1)
+	assert.Equal(t, testStart.Add(15*time.Second), clk.Now())
+}
+
+func TestVirtual_WaitersDueTogetherFireInCreationOrder(t *testing.T) {
+	clk := NewVirtual(testStart)
+
+	var waiters []*virtualWaiter
+	for i := 0; i < 5; i++ {
+		clk.NewTimer(time.Second)
+		waiters = append(waiters, clk.waiters[len(clk.waiters)-1])
+	}
+
+	for _, waiter := range waiters {
+		next := clk.waiters[0]
+		assert.Same(t, waiter, next)
+		clk.remove(next)
+	}
+	assert.Empty(t, clk.waiters)
+}
+
+func TestVirtual_AdvanceToAcrossSeveralPeriods(t *testing.T) {
+	clk := NewVirtual(testStart)
+	ticker := clk.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	// Ticks are dropped while the previous one is unread, like a time.Ticker
+	clk.AdvanceTo(testStart.Add(35 * time.Second))
+	at, ok := fired(ticker.C())
+	require.True(t, ok)
+	assert.Equal(t, testStart.Add(10*time.Second), at)
+	_, ok = fired(ticker.C())
+	assert.False(t, ok)
+	assert.Equal(t, testStart.Add(35*time.Second), clk.Now())
+
+	// The ticker keeps its phase
+	clk.Advance(5 * time.Second)
+	at, ok = fired(ticker.C())
+	require.True(t, ok)
+	assert.Equal(t, testStart.Add(40*time.Second), at)
+
+	// Read one period at a time, every tick arrives
+	for i := 5; i <= 8; i++ {
+		clk.Advance(10 * time.Second)
+		at, ok = fired(ticker.C())
+		require.True(t, ok)
+		assert.Equal(t, testStart.Add(time.Duration(i)*10*time.Second), at)
+	}
+}
+
+func TestVirtual_NeverMovesBack(t *testing.T) {
+	clk := NewVirtual(testStart)
+	clk.AdvanceTo(testStart.Add(-time.Hour))
+	clk.Advance(-time.Hour)
+	assert.Equal(t, testStart, clk.Now())
+}
+
+func TestVirtual_StoppedWaitersLeaveTheClock(t *testing.T) {
+	clk := NewVirtual(testStart)
+
+	ticker := clk.NewTicker(time.Second)
+	timer := clk.NewTimer(2 * time.Second)
+	kept := clk.NewTimer(3 * time.Second)
+	require.Len(t, clk.waiters, 3)
+
+	ticker.Stop()
+	timer.Stop()
+	timer.Stop()
+	assert.Len(t, clk.waiters, 1)
+
+	clk.Advance(5 * time.Second)
+	_, ok := fired(ticker.C())
+	assert.False(t, ok)
+	_, ok = fired(timer.C())
+	assert.False(t, ok)
+	_, ok = fired(kept.C())
+	assert.True(t, ok)
+	assert.Empty(t, clk.waiters)
+
+	// Stopping a fired timer is harmless
+	kept.Stop()
+}
+
+func TestVirtual_ImmediateTimer(t *testing.T) {
+	clk := NewVirtual(testStart)
+
+	at, ok := fired(clk.After(0))
+	require.True(t, ok)
+	assert.Equal(t, testStart, at)
+	assert.Empty(t, clk.waiters)
+}
+
+func TestVirtual_Sleep(t *testing.T) {
+	clk := NewVirtual(testStart)
+
+	woke := make(chan time.Time)
+	go func() {
+		clk.Sleep(time.Minute)
+		woke <- clk.Now()
+	}()
+
+	// Advance once the sleeper is waiting
+	require.Eventually(t, func() bool {
+		clk.mutex.RLock()
+		defer clk.mutex.RUnlock()
+		return len(clk.waiters) == 1
+	}, time.Second, time.Millisecond)
+	clk.Advance(time.Minute)
+
+	select {
+	case at := <-woke:
+		assert.Equal(t, testStart.Add(time.Minute), at)
+	case <-time.After(time.Second):
+		t.Fatal("sleeper did not wake")
+	}
+}
+
+func TestScaled_Wall(t *testing.T) {
+	tests := []struct {
+		factor float64
+		scaled time.Duration
+		wall   time.Duration
+	}{
+		{60, time.Hour, time.Minute},
+		{3600, 8 * time.Hour, 8 * time.Second},
+		{0.5, time.Second, 2 * time.Second},
+		{1, time.Millisecond, time.Millisecond},
+		{1e12, time.Second, 1},
+		{60, 0, 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.wall, NewScaled(testStart, tt.factor).wall(tt.scaled), "%v at %vx", tt.scaled, tt.factor)
+	}
+}
+
+func TestScaled_Now(t *testing.T) {
+	clk := NewScaled(testStart, 3600)
+	assert.False(t, clk.Now().Before(testStart))
+
+	time.Sleep(10 * time.Millisecond)
+
+	// 10ms of wall time is at least 36s at 3600x
+	assert.GreaterOrEqual(t, clk.Since(testStart), 36*time.Second)
+	assert.Less(t, clk.Since(testStart), time.Hour)
+}
+
+func TestScaled_TickerAndTimer(t *testing.T) {
+	clk := NewScaled(testStart, 1000)
+
+	ticker := clk.NewTicker(time.Second)
+	defer ticker.Stop()
+	for i := 0; i < 3; i++ {
+		select {
+		case at := <-ticker.C():
+			assert.True(t, at.After(testStart))
+		case <-time.After(time.Second):
+			t.Fatal("scaled ticker did not tick")
+		}
+	}
+
+	select {
+	case at := <-clk.After(time.Second):
+		assert.False(t, at.Before(testStart.Add(time.Second)))
+	case <-time.After(time.Second):
+		t.Fatal("scaled timer did not fire")
+	}
+
+	stopped := clk.NewTimer(time.Second)
+	stopped.Stop()
+	time.Sleep(5 * time.Millisecond)
+	_, ok := fired(stopped.C())
+	assert.False(t, ok)
+}
@@ -6,16 +6,36 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	// Embedded time zones, so that SEASONALITY_TIMEZONE loads in minimal
+	// containers
+	_ "time/tzdata"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Service  ServiceConfig  `json:"service"`
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	Redis    RedisConfig    `json:"redis"`
-	Logging  LoggingConfig  `json:"logging"`
-	Metrics  MetricsConfig  `json:"metrics"`
+	Service      ServiceConfig      `json:"service"`
+	Server       ServerConfig       `json:"server"`
+	Database     DatabaseConfig     `json:"database"`
+	Redis        RedisConfig        `json:"redis"`
+	Logging      LoggingConfig      `json:"logging"`
+	Metrics      MetricsConfig      `json:"metrics"`
+	Auth         AuthConfig         `json:"auth"`
+	RateLimit    RateLimitConfig    `json:"rate_limit"`
+	Accounts     AccountsConfig     `json:"accounts"`
+	Risk         RiskConfig         `json:"risk"`
+	Clearing     ClearingConfig     `json:"clearing"`
+	Fees         FeesConfig         `json:"fees"`
+	Ledger       LedgerConfig       `json:"ledger"`
+	MarketMaking MarketMakingConfig `json:"market_making"`
+	Surveillance SurveillanceConfig `json:"surveillance"`
+	Audit        AuditConfig        `json:"audit"`
+	Replay       ReplayConfig       `json:"replay"`
+	PriceModels  PriceModelsConfig  `json:"price_models"`
+	Correlation  CorrelationConfig  `json:"correlation"`
+	Agents       AgentsConfig       `json:"agents"`
+	Clock        ClockConfig        `json:"clock"`
+	Seasonality  SeasonalityConfig  `json:"seasonality"`
 }
 
 // ServiceConfig contains service-specific configuration
@@ -71,8 +91,340 @@ type MetricsConfig struct {
 	ExportInterval time.Duration `json:"export_interval"`
 }
 
+// AuthConfig contains API authentication settings. SigningKeyEncryptionKey
+// encrypts the request signing keys stored with API keys; without it keys
+// can't sign requests.
+type AuthConfig struct {
+	Enabled                 bool          `json:"enabled"`
+	AdminKey                string        `json:"-"`
+	AdminUserID             string        `json:"admin_user_id"`
+	SignatureTolerance      time.Duration `json:"signature_tolerance"`
+	SigningKeyEncryptionKey string        `json:"-"`
+}
+
+// RateLimitConfig contains API rate limiting settings. Rates are in
+// requests per second; bursts are the token bucket capacity. FailOpen
+// allows requests while the rate limit backend is unavailable.
+type RateLimitConfig struct {
+	Enabled   bool                     `json:"enabled"`
+	Backend   string                   `json:"backend"`
+	IPRate    float64                  `json:"ip_rate"`
+	IPBurst   int                      `json:"ip_burst"`
+	KeyRate   float64                  `json:"key_rate"`
+	KeyBurst  int                      `json:"key_burst"`
+	Endpoints map[string]EndpointLimit `json:"endpoints"`
+	FailOpen  bool                     `json:"fail_open"`
+}
+
+// AccountsConfig contains account and buying power settings. Accounts are
+// opened on first use with InitialCash. Each symbol position is opened with
+// InitialPosition shares, posted to the ledger as a deposit; it defaults to
+// none so users only sell shares they bought or were deposited.
+type AccountsConfig struct {
+	Enabled           bool    `json:"enabled"`
+	InitialCash       float64 `json:"initial_cash"`
+	InitialPosition   float64 `json:"initial_position"`
+	MarketOrderBuffer float64 `json:"market_order_buffer"`
+}
+
+// RiskConfig contains the pre-trade risk limits. Defaults apply to every
+// order; symbol overrides replace the defaults for a symbol and user
+// overrides take precedence over both.
+type RiskConfig struct {
+	Enabled bool                  `json:"enabled"`
+	Default RiskLimits            `json:"default"`
+	Symbols map[string]RiskLimits `json:"symbols"`
+	Users   map[string]RiskLimits `json:"users"`
+}
+
+// LedgerConfig contains the ledger audit settings. Every ReconcileInterval
+// the ledger invariants are checked and the ledger is reconciled against the
+// accounts and positions tables.
+type LedgerConfig struct {
+	ReconcileInterval time.Duration `json:"reconcile_interval"`
+}
+
+// MarketMakingConfig contains the designated market maker program settings.
+// Every SampleInterval each market maker's quotes are sampled from the book;
+// a sample is compliant when the market maker quotes both sides no wider
+// than MaxSpreadBps with at least MinQuoteSize at its best bid and ask.
+// Market makers whose compliant share of the day's samples is at least
+// MinUptime earn RebateBps off their maker fees. The obligations and rebate
+// are defaults for registrations that don't set their own.
+type MarketMakingConfig struct {
+	Enabled        bool          `json:"enabled"`
+	SampleInterval time.Duration `json:"sample_interval"`
+	MaxSpreadBps   float64       `json:"max_spread_bps"`
+	MinQuoteSize   float64       `json:"min_quote_size"`
+	MinUptime      float64       `json:"min_uptime"`
+	RebateBps      float64       `json:"rebate_bps"`
+}
+
+// SurveillanceConfig contains the market surveillance thresholds. Patterns
+// are looked for within Window before the trade that completes them. An
+// order is large when its quantity is at least LargeOrderMultiple times the
+// symbol's average order quantity. Layering takes cancelled orders at
+// LayeringMinLevels or more price levels; momentum ignition takes
+// MomentumMinTrades or more aggressive trades moving the price at least
+// MomentumMinMoveBps. Marking the close takes as many aggressive trades on
+// one side, moving the price as far, within CloseWindow before the end of the
+// UTC trade day.
+type SurveillanceConfig struct {
+	Enabled            bool          `json:"enabled"`
+	Window             time.Duration `json:"window"`
+	LargeOrderMultiple float64       `json:"large_order_multiple"`
+	LayeringMinLevels  int           `json:"layering_min_levels"`
+	MomentumMinTrades  int           `json:"momentum_min_trades"`
+	MomentumMinMoveBps float64       `json:"momentum_min_move_bps"`
+	CloseWindow        time.Duration `json:"close_window"`
+}
+
+// AuditConfig contains the order audit trail export settings. A CAT-style
+// file of each UTC day's order events is written to ExportDir once the day
+// is over; an empty ExportDir disables the daily file. ReporterIMID
+// identifies the exchange in exported records.
+type AuditConfig struct {
+	ExportDir    string `json:"export_dir"`
+	ReporterIMID string `json:"reporter_imid"`
+}
+
+// ReplayConfig contains the market data replay settings. Recorded tick and
+// OHLCV files are only loaded from DataDir.
+type ReplayConfig struct {
+	DataDir string `json:"data_dir"`
+}
+
+// Stochastic price models. PriceModelBuiltin is the simulators' own trend,
+// noise and mean reversion formula.
+const (
+	PriceModelBuiltin = "builtin"
+	PriceModelGBM     = "gbm"
+	PriceModelMerton  = "merton"
+	PriceModelHeston  = "heston"
+	PriceModelGARCH   = "garch"
+	PriceModelOU      = "ou"
+)
+
+// PriceModel selects a stochastic price model and overrides its default
+// parameters
+type PriceModel struct {
+	Name   string             `json:"name"`
+	Params map[string]float64 `json:"params"`
+}
+
+// PriceModelsConfig selects the price model of each simulated symbol.
+// Symbols without their own model use Default.
+type PriceModelsConfig struct {
+	Default PriceModel            `json:"default"`
+	Symbols map[string]PriceModel `json:"symbols"`
+}
+
+// ModelFor returns the price model of a symbol
+func (c PriceModelsConfig) ModelFor(symbol string) PriceModel {
+	if model, ok := c.Symbols[symbol]; ok {
+		return model
+	}
+	return c.Default
+}
+
+// CorrelationConfig sets how simulated symbols move together. Shocks follow
+// a factor model: every symbol loads MarketLoading on a market factor and
+// SectorLoading on its sector's factor, so two symbols correlate by
+// MarketLoading² plus SectorLoading² when they share a sector. Pairs
+// override the correlation of individual symbol pairs, keyed "A/B".
+// Realized correlations are measured over the last Window price updates.
+type CorrelationConfig struct {
+	MarketLoading float64            `json:"market_loading"`
+	SectorLoading float64            `json:"sector_loading"`
+	Sectors       map[string]string  `json:"sectors"`
+	Pairs         map[string]float64 `json:"pairs"`
+	Window        int                `json:"window"`
+}
+
+// ClockConfig sets the simulated time. At a Scale above 1 simulated time
+// runs that many times faster than the wall clock, so that a trading day
+// passes in minutes; Start sets the time it starts at, the current time if
+// zero.
+type ClockConfig struct {
+	Scale float64   `json:"scale"`
+	Start time.Time `json:"start"`
+
+	// malformedStart is a CLOCK_START that could not be parsed, reported by
+	// Validate
+	malformedStart string
+}
+
+// SeasonalityConfig shapes simulated trading over the day, the week and
+// holidays: order arrival rates and price volatility follow the same
+// profile. Profile is a file to load it from, either historical bars to
+// calibrate it from (CSV) or the profile itself (YAML or JSON); empty uses
+// the standard U-shaped profile. Holidays are dates in the Location of the
+// market, as "2006-01-02".
+type SeasonalityConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Profile  string   `json:"profile"`
+	Holidays []string `json:"holidays"`
+	Location string   `json:"location"`
+}
+
+// Simulation modes. In SimulationModeOracle the market-simulator generates
+// prices and order flow trades around them; in SimulationModeAgents trading
+// agents discover prices in the matching engine and the market-simulator
+// publishes the resulting trade prices.
+const (
+	SimulationModeOracle = "oracle"
+	SimulationModeAgents = "agents"
+)
+
+// Trading agent types of the agent-based mode
+const (
+	AgentZeroIntelligence = "zero_intelligence"
+	AgentNoise            = "noise"
+	AgentFundamental      = "fundamental"
+	AgentMarketMaker      = "market_maker"
+)
+
+// AgentsConfig contains the agent-based simulation settings. Populations is
+// the number of agents of each type; agents act every TickInterval and
+// fundamental traders value symbols by a random walk with annualized
+// FundamentalVolatility. Market makers only quote in the oracle mode when
+// MarketMaker.Oracle is set.
+type AgentsConfig struct {
+	Mode                  string                 `json:"mode"`
+	Populations           map[string]int         `json:"populations"`
+	TickInterval          time.Duration          `json:"tick_interval"`
+	FundamentalVolatility float64                `json:"fundamental_volatility"`
+	MarketMaker           MarketMakerAgentConfig `json:"market_maker"`
+
+	// malformedPopulations are the AGENT_POPULATIONS entries that could not
+	// be parsed, reported by Validate
+	malformedPopulations []string
+}
+
+// MarketMakerAgentConfig contains the Avellaneda-Stoikov parameters of
+// market maker agents. Volatility is the price volatility over the quoting
+// horizon in calm markets, as a fraction; it scales with the published
+// volatility index. OrderArrivalDecay is how fast the arrival of orders
+// filling a quote falls with its relative distance from the price, and
+// MaxInventory is the position, in quote sizes, beyond which the side that
+// would grow it stops quoting. Oracle also spawns market makers in the
+// oracle mode, where they trade through the simulated users' exchange
+// accounts; those must be funded with the shares they sell.
+type MarketMakerAgentConfig struct {
+	RiskAversion      float64 `json:"risk_aversion"`
+	OrderArrivalDecay float64 `json:"order_arrival_decay"`
+	Volatility        float64 `json:"volatility"`
+	MaxInventory      float64 `json:"max_inventory"`
+	Oracle            bool    `json:"oracle"`
+}
+
+// Enabled reports whether prices are discovered by trading agents
+func (c AgentsConfig) Enabled() bool {
+	return c.Mode == SimulationModeAgents
+}
+
+// ClearingConfig contains the post-trade clearing settings. Trades are netted
+// per user and symbol at the end of each UTC day and settle SettlementDays
+// business days later; FailRate is the probability that a settlement attempt
+// fails.
+type ClearingConfig struct {
+	Enabled        bool          `json:"enabled"`
+	SettlementDays int           `json:"settlement_days"`
+	CycleInterval  time.Duration `json:"cycle_interval"`
+	FailRate       float64       `json:"fail_rate"`
+}
+
+// FeesConfig contains the trading fee schedules. Each user is placed in the
+// highest tier whose MinVolume their traded notional over VolumeWindow
+// reaches; tiers are recomputed every RecomputeInterval. Symbols without
+// their own schedule use Tiers.
+type FeesConfig struct {
+	Enabled           bool                 `json:"enabled"`
+	Tiers             []FeeTier            `json:"tiers"`
+	Symbols           map[string][]FeeTier `json:"symbols"`
+	VolumeWindow      time.Duration        `json:"volume_window"`
+	RecomputeInterval time.Duration        `json:"recompute_interval"`
+}
+
+// FeeTier is a volume tier of a fee schedule. Rates are in basis points of
+// the trade value; a negative maker rate is a rebate.
+type FeeTier struct {
+	MinVolume float64 `json:"min_volume"`
+	MakerBps  float64 `json:"maker_bps"`
+	TakerBps  float64 `json:"taker_bps"`
+}
+
+// ScheduleFor returns the fee tiers that apply to a symbol, lowest first
+func (c FeesConfig) ScheduleFor(symbol string) []FeeTier {
+	if tiers, ok := c.Symbols[symbol]; ok {
+		return tiers
+	}
+	return c.Tiers
+}
+
+// RiskLimits is a set of pre-trade limits. A zero value disables the limit,
+// or in an override, inherits it.
+type RiskLimits struct {
+	MaxOrderQuantity   float64 `json:"max_order_quantity"`
+	MaxOrderNotional   float64 `json:"max_order_notional"`
+	PriceCollar        float64 `json:"price_collar"`
+	MaxOpenOrders      int     `json:"max_open_orders"`
+	MaxPosition        float64 `json:"max_position"`
+	MaxOrdersPerSecond float64 `json:"max_orders_per_second"`
+}
+
+// Merge returns l with every non-zero limit in override applied
+func (l RiskLimits) Merge(override RiskLimits) RiskLimits {
+	if override.MaxOrderQuantity != 0 {
+		l.MaxOrderQuantity = override.MaxOrderQuantity
+	}
+	if override.MaxOrderNotional != 0 {
+		l.MaxOrderNotional = override.MaxOrderNotional
+	}
+	if override.PriceCollar != 0 {
+		l.PriceCollar = override.PriceCollar
+	}
+	if override.MaxOpenOrders != 0 {
+		l.MaxOpenOrders = override.MaxOpenOrders
+	}
+	if override.MaxPosition != 0 {
+		l.MaxPosition = override.MaxPosition
+	}
+	if override.MaxOrdersPerSecond != 0 {
+		l.MaxOrdersPerSecond = override.MaxOrdersPerSecond
+	}
+	return l
+}
+
+// LimitsFor returns the limits that apply to a user's orders in a symbol
+func (c RiskConfig) LimitsFor(userID, symbol string) RiskLimits {
+	limits := c.Default
+	if override, ok := c.Symbols[symbol]; ok {
+		limits = limits.Merge(override)
+	}
+	if override, ok := c.Users[userID]; ok {
+		limits = limits.Merge(override)
+	}
+	return limits
+}
+
+// EndpointLimit is a rate limit budget for a single route, e.g. "POST /api/orders"
+type EndpointLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
+	feeTiers, err := getFeeTiersOrDefault("FEE_TIERS", defaultFeeTiers())
+	if err != nil {
+		return nil, err
+	}
+	symbolFeeTiers, err := getSymbolFeeTiersOrDefault("FEE_SYMBOL_TIERS", nil)
+	if err != nil {
+		return nil, err
+	}
+
 	config := &Config{
 		Service: ServiceConfig{
 			Name:        getEnvOrDefault("SERVICE_NAME", "trading-api"),
@@ -115,6 +467,75 @@ func LoadConfig() (*Config, error) {
 			RetentionTime:  getDurationOrDefault("METRICS_RETENTION_TIME", 24*time.Hour),
 			ExportInterval: getDurationOrDefault("METRICS_EXPORT_INTERVAL", 5*time.Minute),
 		},
+		Auth: AuthConfig{
+			Enabled:                 getBoolOrDefault("AUTH_ENABLED", false),
+			AdminKey:                getEnvOrDefault("AUTH_ADMIN_KEY", ""),
+			AdminUserID:             getEnvOrDefault("AUTH_ADMIN_USER_ID", "admin"),
+			SignatureTolerance:      getDurationOrDefault("AUTH_SIGNATURE_TOLERANCE", 5*time.Minute),
+			SigningKeyEncryptionKey: getEnvOrDefault("AUTH_SIGNING_ENCRYPTION_KEY", ""),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   getBoolOrDefault("RATE_LIMIT_ENABLED", true),
+			Backend:   getEnvOrDefault("RATE_LIMIT_BACKEND", "memory"),
+			IPRate:    getFloatOrDefault("RATE_LIMIT_IP_RATE", 500),
+			IPBurst:   getIntOrDefault("RATE_LIMIT_IP_BURST", 1000),
+			KeyRate:   getFloatOrDefault("RATE_LIMIT_KEY_RATE", 200),
+			KeyBurst:  getIntOrDefault("RATE_LIMIT_KEY_BURST", 400),
+			Endpoints: getEndpointLimitsOrDefault("RATE_LIMIT_ENDPOINTS", nil),
+			FailOpen:  getBoolOrDefault("RATE_LIMIT_FAIL_OPEN", true),
+		},
+		Accounts: AccountsConfig{
+			Enabled:           getBoolOrDefault("ACCOUNTS_ENABLED", true),
+			InitialCash:       getFloatOrDefault("ACCOUNT_INITIAL_CASH", 1000000),
+			InitialPosition:   getFloatOrDefault("ACCOUNT_INITIAL_POSITION", 0),
+			MarketOrderBuffer: getFloatOrDefault("ACCOUNT_MARKET_ORDER_BUFFER", 0.05),
+		},
+		Risk: RiskFromEnv(),
+		Clearing: ClearingConfig{
+			Enabled:        getBoolOrDefault("CLEARING_ENABLED", true),
+			SettlementDays: getIntOrDefault("CLEARING_SETTLEMENT_DAYS", 2),
+			CycleInterval:  getDurationOrDefault("CLEARING_CYCLE_INTERVAL", time.Minute),
+			FailRate:       getFloatOrDefault("CLEARING_FAIL_RATE", 0.02),
+		},
+		Fees: FeesConfig{
+			Enabled:           getBoolOrDefault("FEES_ENABLED", true),
+			Tiers:             feeTiers,
+			Symbols:           symbolFeeTiers,
+			VolumeWindow:      getDurationOrDefault("FEE_VOLUME_WINDOW", 30*24*time.Hour),
+			RecomputeInterval: getDurationOrDefault("FEE_RECOMPUTE_INTERVAL", 24*time.Hour),
+		},
+		Ledger: LedgerConfig{
+			ReconcileInterval: getDurationOrDefault("LEDGER_RECONCILE_INTERVAL", 5*time.Minute),
+		},
+		MarketMaking: MarketMakingConfig{
+			Enabled:        getBoolOrDefault("MARKET_MAKING_ENABLED", true),
+			SampleInterval: getDurationOrDefault("MM_SAMPLE_INTERVAL", 10*time.Second),
+			MaxSpreadBps:   getFloatOrDefault("MM_MAX_SPREAD_BPS", 50),
+			MinQuoteSize:   getFloatOrDefault("MM_MIN_QUOTE_SIZE", 1),
+			MinUptime:      getFloatOrDefault("MM_MIN_UPTIME", 0.9),
+			RebateBps:      getFloatOrDefault("MM_REBATE_BPS", 2),
+		},
+		Surveillance: SurveillanceConfig{
+			Enabled:            getBoolOrDefault("SURVEILLANCE_ENABLED", true),
+			Window:             getDurationOrDefault("SURVEILLANCE_WINDOW", 10*time.Second),
+			LargeOrderMultiple: getFloatOrDefault("SURVEILLANCE_LARGE_ORDER_MULTIPLE", 5),
+			LayeringMinLevels:  getIntOrDefault("SURVEILLANCE_LAYERING_MIN_LEVELS", 3),
+			MomentumMinTrades:  getIntOrDefault("SURVEILLANCE_MOMENTUM_MIN_TRADES", 3),
+			MomentumMinMoveBps: getFloatOrDefault("SURVEILLANCE_MOMENTUM_MIN_MOVE_BPS", 20),
+			CloseWindow:        getDurationOrDefault("SURVEILLANCE_CLOSE_WINDOW", 5*time.Minute),
+		},
+		Audit: AuditConfig{
+			ExportDir:    getEnvOrDefault("AUDIT_EXPORT_DIR", ""),
+			ReporterIMID: getEnvOrDefault("AUDIT_REPORTER_IMID", "SIMX"),
+		},
+		Replay: ReplayConfig{
+			DataDir: getEnvOrDefault("REPLAY_DATA_DIR", "data/replay"),
+		},
+		PriceModels: PriceModelsFromEnv(),
+		Correlation: CorrelationFromEnv(),
+		Agents:      AgentsFromEnv(),
+		Clock:       ClockFromEnv(),
+		Seasonality: SeasonalityFromEnv(),
 	}
 
 	if err := config.Validate(); err != nil {
@@ -150,6 +571,187 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("redis host is required")
 	}
 
+	if c.Auth.SignatureTolerance <= 0 {
+		return fmt.Errorf("auth signature tolerance must be positive")
+	}
+
+	if c.RateLimit.Backend != "memory" && c.RateLimit.Backend != "redis" {
+		return fmt.Errorf("invalid rate limit backend: %s", c.RateLimit.Backend)
+	}
+
+	if c.RateLimit.IPRate <= 0 || c.RateLimit.KeyRate <= 0 || c.RateLimit.IPBurst <= 0 || c.RateLimit.KeyBurst <= 0 {
+		return fmt.Errorf("rate limit rates and bursts must be positive")
+	}
+	for route, limit := range c.RateLimit.Endpoints {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid rate limit endpoint %q: expected \"METHOD /route=rate:burst\"", route)
+		}
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			return fmt.Errorf("invalid rate limit for endpoint %q: rate and burst must be positive numbers", route)
+		}
+	}
+
+	if c.Accounts.InitialCash < 0 || c.Accounts.InitialPosition < 0 || c.Accounts.MarketOrderBuffer < 0 {
+		return fmt.Errorf("account initial balances and market order buffer must not be negative")
+	}
+
+	if err := c.Risk.Validate(); err != nil {
+		return err
+	}
+
+	if c.Clearing.SettlementDays < 0 {
+		return fmt.Errorf("clearing settlement days must not be negative")
+	}
+
+	if c.Clearing.CycleInterval <= 0 {
+		return fmt.Errorf("clearing cycle interval must be positive")
+	}
+
+	if c.Clearing.FailRate < 0 || c.Clearing.FailRate > 1 {
+		return fmt.Errorf("clearing fail rate must be between 0 and 1")
+	}
+
+	if c.Fees.VolumeWindow <= 0 || c.Fees.RecomputeInterval <= 0 {
+		return fmt.Errorf("fee volume window and recompute interval must be positive")
+	}
+	if err := validateFeeTiers(c.Fees.Tiers); err != nil {
+		return fmt.Errorf("invalid fee tiers: %w", err)
+	}
+	for symbol, tiers := range c.Fees.Symbols {
+		if err := validateFeeTiers(tiers); err != nil {
+			return fmt.Errorf("invalid fee tiers for symbol %s: %w", symbol, err)
+		}
+	}
+
+	if c.Ledger.ReconcileInterval <= 0 {
+		return fmt.Errorf("ledger reconcile interval must be positive")
+	}
+
+	if c.MarketMaking.SampleInterval <= 0 {
+		return fmt.Errorf("market maker sample interval must be positive")
+	}
+
+	if err := validateObligations(c.MarketMaking.MaxSpreadBps, c.MarketMaking.MinQuoteSize, c.MarketMaking.MinUptime, c.MarketMaking.RebateBps); err != nil {
+		return fmt.Errorf("invalid market maker defaults: %w", err)
+	}
+
+	if c.Surveillance.Window <= 0 || c.Surveillance.LargeOrderMultiple <= 0 || c.Surveillance.MomentumMinMoveBps < 0 {
+		return fmt.Errorf("surveillance window and large order multiple must be positive and momentum move must not be negative")
+	}
+	if c.Surveillance.LayeringMinLevels < 2 || c.Surveillance.MomentumMinTrades < 1 {
+		return fmt.Errorf("surveillance layering needs at least 2 levels and momentum ignition at least 1 trade")
+	}
+	if c.Surveillance.CloseWindow <= 0 || c.Surveillance.CloseWindow >= 24*time.Hour {
+		return fmt.Errorf("surveillance close window must be positive and shorter than a day")
+	}
+
+	if c.Audit.ReporterIMID == "" {
+		return fmt.Errorf("audit reporter IMID is required")
+	}
+
+	if err := c.PriceModels.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Correlation.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Agents.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Clock.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Seasonality.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateObligations checks market maker obligations and rebate
+func validateObligations(maxSpreadBps, minQuoteSize, minUptime, rebateBps float64) error {
+	if maxSpreadBps <= 0 {
+		return fmt.Errorf("max spread must be positive")
+	}
+	if minQuoteSize <= 0 {
+		return fmt.Errorf("min quote size must be positive")
+	}
+	if minUptime < 0 || minUptime > 1 {
+		return fmt.Errorf("min uptime must be between 0 and 1")
+	}
+	if rebateBps < 0 {
+		return fmt.Errorf("rebate must not be negative")
+	}
+	return nil
+}
+
+// validateFeeTiers checks that a schedule starts at zero volume, is ordered
+// by volume and never pays out more in maker rebates than it charges takers
+func validateFeeTiers(tiers []FeeTier) error {
+	if len(tiers) == 0 {
+		return fmt.Errorf("at least one tier is required")
+	}
+	if tiers[0].MinVolume != 0 {
+		return fmt.Errorf("first tier must start at zero volume")
+	}
+	for i, tier := range tiers {
+		if i > 0 && tier.MinVolume <= tiers[i-1].MinVolume {
+			return fmt.Errorf("tier volumes must be increasing")
+		}
+		if tier.TakerBps < 0 || tier.MakerBps+tier.TakerBps < 0 {
+			return fmt.Errorf("taker fees must cover maker rebates")
+		}
+	}
+	return nil
+}
+
+// RiskFromEnv reads the pre-trade risk limits. RISK_SYMBOL_LIMITS and
+// RISK_USER_LIMITS override the defaults for a symbol or user.
+func RiskFromEnv() RiskConfig {
+	return RiskConfig{
+		Enabled: getBoolOrDefault("RISK_ENABLED", true),
+		Default: RiskLimits{
+			MaxOrderQuantity:   getFloatOrDefault("RISK_MAX_ORDER_QUANTITY", 100000),
+			MaxOrderNotional:   getFloatOrDefault("RISK_MAX_ORDER_NOTIONAL", 10000000),
+			PriceCollar:        getFloatOrDefault("RISK_PRICE_COLLAR", 0.10),
+			MaxOpenOrders:      getIntOrDefault("RISK_MAX_OPEN_ORDERS", 1000),
+			MaxPosition:        getFloatOrDefault("RISK_MAX_POSITION", 0),
+			MaxOrdersPerSecond: getFloatOrDefault("RISK_MAX_ORDERS_PER_SECOND", 100),
+		},
+		Symbols: getRiskLimitsOrDefault("RISK_SYMBOL_LIMITS", nil),
+		Users:   getRiskLimitsOrDefault("RISK_USER_LIMITS", nil),
+	}
+}
+
+// Validate checks that no default, symbol or user limit is negative
+func (c RiskConfig) Validate() error {
+	if err := c.Default.validate(); err != nil {
+		return fmt.Errorf("invalid default risk limits: %w", err)
+	}
+	for symbol, limits := range c.Symbols {
+		if err := limits.validate(); err != nil {
+			return fmt.Errorf("invalid risk limits for symbol %s: %w", symbol, err)
+		}
+	}
+	for userID, limits := range c.Users {
+		if err := limits.validate(); err != nil {
+			return fmt.Errorf("invalid risk limits for user %s: %w", userID, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that no limit is negative
+func (l RiskLimits) validate() error {
+	if l.MaxOrderQuantity < 0 || l.MaxOrderNotional < 0 || l.PriceCollar < 0 ||
+		l.MaxOpenOrders < 0 || l.MaxPosition < 0 || l.MaxOrdersPerSecond < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
 	return nil
 }
 
@@ -225,4 +827,417 @@ func getStringSliceOrDefault(key string, defaultValue []string) []string {
 		return strings.Split(value, ",")
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEndpointLimitsOrDefault parses "METHOD /path=rate:burst" entries separated by commas
+func getEndpointLimitsOrDefault(key string, defaultValue map[string]EndpointLimit) map[string]EndpointLimit {
+	entries := getStringSliceOrDefault(key, nil)
+	if len(entries) == 0 {
+		return defaultValue
+	}
+
+	// Malformed entries are kept with a zero budget for Validate to reject
+	limits := make(map[string]EndpointLimit, len(entries))
+	for _, entry := range entries {
+		route, budget, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		rateStr, burstStr, _ := strings.Cut(budget, ":")
+
+		var limit EndpointLimit
+		if rate, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64); err == nil {
+			limit.Rate = rate
+		}
+		if burst, err := strconv.Atoi(strings.TrimSpace(burstStr)); err == nil {
+			limit.Burst = burst
+		}
+		limits[strings.TrimSpace(route)] = limit
+	}
+
+	return limits
+}
+
+
+// getRiskLimitsOrDefault parses limit overrides of the form
+// "KEY:limit=value,limit=value;KEY:limit=value", e.g.
+// "AAPL:max_order_quantity=5000,price_collar=0.05;TSLA:max_position=200".
+// Limit names match the RiskLimits JSON field names.
+func getRiskLimitsOrDefault(key string, defaultValue map[string]RiskLimits) map[string]RiskLimits {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	overrides := make(map[string]RiskLimits)
+	for _, entry := range strings.Split(value, ";") {
+		name, fields, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || name == "" {
+			continue
+		}
+
+		var limits RiskLimits
+		for _, field := range strings.Split(fields, ",") {
+			limit, raw, ok := strings.Cut(strings.TrimSpace(field), "=")
+			if !ok {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if err != nil {
+				continue
+			}
+			switch strings.TrimSpace(limit) {
+			case "max_order_quantity":
+				limits.MaxOrderQuantity = parsed
+			case "max_order_notional":
+				limits.MaxOrderNotional = parsed
+			case "price_collar":
+				limits.PriceCollar = parsed
+			case "max_open_orders":
+				limits.MaxOpenOrders = int(parsed)
+			case "max_position":
+				limits.MaxPosition = parsed
+			case "max_orders_per_second":
+				limits.MaxOrdersPerSecond = parsed
+			}
+		}
+		overrides[strings.TrimSpace(name)] = limits
+	}
+
+	return overrides
+}
+
+// PriceModelsFromEnv reads the price models from PRICE_MODEL, the default
+// model, and PRICE_SYMBOL_MODELS. A model has the form
+// "name:param=value,param=value", e.g. "gbm:volatility=0.6"; per-symbol
+// models have the form "SYMBOL:model;SYMBOL:model", e.g.
+// "BTCUSD:heston:kappa=2;ETHUSD:garch".
+func PriceModelsFromEnv() PriceModelsConfig {
+	models := PriceModelsConfig{
+		Default: PriceModel{Name: PriceModelBuiltin},
+	}
+	if value := os.Getenv("PRICE_MODEL"); value != "" {
+		models.Default = parsePriceModel(value)
+	}
+
+	if value := os.Getenv("PRICE_SYMBOL_MODELS"); value != "" {
+		models.Symbols = make(map[string]PriceModel)
+		for _, entry := range strings.Split(value, ";") {
+			symbol, model, ok := strings.Cut(strings.TrimSpace(entry), ":")
+			if !ok || symbol == "" {
+				continue
+			}
+			models.Symbols[strings.TrimSpace(symbol)] = parsePriceModel(model)
+		}
+	}
+
+	return models
+}
+
+// parsePriceModel parses "name:param=value,param=value", skipping
+// malformed parameters
+func parsePriceModel(value string) PriceModel {
+	name, params, _ := strings.Cut(strings.TrimSpace(value), ":")
+	model := PriceModel{Name: strings.ToLower(strings.TrimSpace(name)), Params: make(map[string]float64)}
+
+	for _, field := range strings.Split(params, ",") {
+		param, raw, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			continue
+		}
+		model.Params[strings.TrimSpace(param)] = parsed
+	}
+
+	return model
+}
+
+// Validate checks that every price model is a known model
+func (c PriceModelsConfig) Validate() error {
+	models := map[string]PriceModel{"default": c.Default}
+	for symbol, model := range c.Symbols {
+		models[symbol] = model
+	}
+
+	for name, model := range models {
+		switch model.Name {
+		case PriceModelBuiltin, PriceModelGBM, PriceModelMerton, PriceModelHeston, PriceModelGARCH, PriceModelOU:
+		default:
+			return fmt.Errorf("unknown price model %q for %s", model.Name, name)
+		}
+	}
+	return nil
+}
+
+// CorrelationFromEnv reads the correlation settings. PRICE_SECTORS assigns
+// sectors as "SYMBOL:sector,SYMBOL:sector" and PRICE_CORRELATIONS overrides
+// pairs as "A/B=0.8,C/D=-0.2".
+func CorrelationFromEnv() CorrelationConfig {
+	correlation := CorrelationConfig{
+		MarketLoading: getFloatOrDefault("PRICE_MARKET_LOADING", 0.6),
+		SectorLoading: getFloatOrDefault("PRICE_SECTOR_LOADING", 0.5),
+		Sectors:       make(map[string]string),
+		Pairs:         make(map[string]float64),
+		Window:        getIntOrDefault("PRICE_CORRELATION_WINDOW", 500),
+	}
+
+	for _, entry := range strings.Split(os.Getenv("PRICE_SECTORS"), ",") {
+		symbol, sector, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || symbol == "" || sector == "" {
+			continue
+		}
+		correlation.Sectors[strings.TrimSpace(symbol)] = strings.TrimSpace(sector)
+	}
+
+	for _, entry := range strings.Split(os.Getenv("PRICE_CORRELATIONS"), ",") {
+		pair, raw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			continue
+		}
+		correlation.Pairs[strings.TrimSpace(pair)] = parsed
+	}
+
+	return correlation
+}
+
+// Validate checks the factor loadings and pair correlations. Whether the
+// resulting matrix is positive definite depends on the simulated symbols
+// and is checked when it is built.
+func (c CorrelationConfig) Validate() error {
+	if c.MarketLoading < 0 || c.SectorLoading < 0 {
+		return fmt.Errorf("correlation factor loadings must not be negative")
+	}
+	if c.MarketLoading*c.MarketLoading+c.SectorLoading*c.SectorLoading > 1 {
+		return fmt.Errorf("squared market and sector loadings must not exceed 1")
+	}
+	for pair, value := range c.Pairs {
+		a, b, ok := strings.Cut(pair, "/")
+		if !ok || a == "" || b == "" || a == b {
+			return fmt.Errorf("invalid correlation pair %q, expected A/B", pair)
+		}
+		if value < -1 || value > 1 {
+			return fmt.Errorf("correlation of %s must be between -1 and 1", pair)
+		}
+	}
+	if c.Window < 2 {
+		return fmt.Errorf("correlation window must be at least 2")
+	}
+	return nil
+}
+
+// ClockFromEnv reads the simulated time settings. CLOCK_START is an RFC 3339
+// time, e.g. "2024-03-04T08:55:00Z" to start just before a market opens.
+func ClockFromEnv() ClockConfig {
+	clock := ClockConfig{
+		Scale: getFloatOrDefault("CLOCK_SCALE", 1),
+	}
+	if value := os.Getenv("CLOCK_START"); value != "" {
+		start, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			clock.malformedStart = value
+		}
+		clock.Start = start
+	}
+	return clock
+}
+
+// Validate checks the clock scale and start time
+func (c ClockConfig) Validate() error {
+	if c.malformedStart != "" {
+		return fmt.Errorf("invalid CLOCK_START %q, expected an RFC 3339 time", c.malformedStart)
+	}
+	if c.Scale <= 0 {
+		return fmt.Errorf("clock scale must be positive")
+	}
+	return nil
+}
+
+// SeasonalityFromEnv reads the intraday, weekly and holiday profile
+// settings. SEASONALITY_HOLIDAYS lists dates as "2024-12-25,2025-01-01".
+func SeasonalityFromEnv() SeasonalityConfig {
+	return SeasonalityConfig{
+		Enabled:  getBoolOrDefault("SEASONALITY_ENABLED", true),
+		Profile:  getEnvOrDefault("SEASONALITY_PROFILE", ""),
+		Holidays: getStringSliceOrDefault("SEASONALITY_HOLIDAYS", []string{}),
+		Location: getEnvOrDefault("SEASONALITY_TIMEZONE", "UTC"),
+	}
+}
+
+// Validate checks the holiday dates and the time zone
+func (c SeasonalityConfig) Validate() error {
+	for _, holiday := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", strings.TrimSpace(holiday)); err != nil {
+			return fmt.Errorf("invalid seasonality holiday %q, expected YYYY-MM-DD", holiday)
+		}
+	}
+	if _, err := time.LoadLocation(c.Location); err != nil {
+		return fmt.Errorf("invalid seasonality time zone %q: %w", c.Location, err)
+	}
+	return nil
+}
+
+// AgentsFromEnv reads the agent-based simulation settings. AGENT_POPULATIONS
+// has the form "type:count,type:count", e.g. "noise:20,market_maker:4".
+func AgentsFromEnv() AgentsConfig {
+	agents := AgentsConfig{
+		Mode: strings.ToLower(getEnvOrDefault("SIMULATION_MODE", SimulationModeOracle)),
+		Populations: map[string]int{
+			AgentZeroIntelligence: 40,
+			AgentNoise:            20,
+			AgentFundamental:      20,
+			AgentMarketMaker:      4,
+		},
+		TickInterval:          getDurationOrDefault("AGENT_TICK_INTERVAL", time.Second),
+		FundamentalVolatility: getFloatOrDefault("AGENT_FUNDAMENTAL_VOLATILITY", 0.8),
+		MarketMaker: MarketMakerAgentConfig{
+			RiskAversion:      getFloatOrDefault("AGENT_MM_RISK_AVERSION", 50),
+			OrderArrivalDecay: getFloatOrDefault("AGENT_MM_ORDER_ARRIVAL_DECAY", 2000),
+			Volatility:        getFloatOrDefault("AGENT_MM_VOLATILITY", 0.002),
+			MaxInventory:      getFloatOrDefault("AGENT_MM_MAX_INVENTORY", 10),
+			Oracle:            getBoolOrDefault("AGENT_MM_ORACLE", false),
+		},
+	}
+
+	if value := os.Getenv("AGENT_POPULATIONS"); value != "" {
+		agents.Populations = make(map[string]int)
+		for _, entry := range strings.Split(value, ",") {
+			agentType, raw, ok := strings.Cut(strings.TrimSpace(entry), ":")
+			count, err := strconv.Atoi(strings.TrimSpace(raw))
+			agentType = strings.ToLower(strings.TrimSpace(agentType))
+			if !ok || err != nil || agentType == "" {
+				agents.malformedPopulations = append(agents.malformedPopulations, entry)
+				continue
+			}
+			agents.Populations[agentType] = count
+		}
+	}
+
+	return agents
+}
+
+// Validate checks the simulation mode, agent populations and market maker
+// parameters
+func (c AgentsConfig) Validate() error {
+	if c.Mode != SimulationModeOracle && c.Mode != SimulationModeAgents {
+		return fmt.Errorf("unknown simulation mode %q", c.Mode)
+	}
+
+	if len(c.malformedPopulations) > 0 {
+		return fmt.Errorf("invalid AGENT_POPULATIONS entry %q, expected type:count", c.malformedPopulations[0])
+	}
+
+	total := 0
+	for agentType, count := range c.Populations {
+		switch agentType {
+		case AgentZeroIntelligence, AgentNoise, AgentFundamental, AgentMarketMaker:
+		default:
+			return fmt.Errorf("unknown agent type %q", agentType)
+		}
+		if count < 0 {
+			return fmt.Errorf("population of %s agents must not be negative", agentType)
+		}
+		total += count
+	}
+	if total == 0 && c.Enabled() {
+		return fmt.Errorf("agent mode needs at least one agent")
+	}
+	if c.TickInterval <= 0 {
+		return fmt.Errorf("agent tick interval must be positive")
+	}
+	if c.FundamentalVolatility < 0 {
+		return fmt.Errorf("fundamental volatility must not be negative")
+	}
+	if c.MarketMaker.RiskAversion <= 0 || c.MarketMaker.OrderArrivalDecay <= 0 || c.MarketMaker.MaxInventory <= 0 {
+		return fmt.Errorf("market maker risk aversion, order arrival decay and max inventory must be positive")
+	}
+	if c.MarketMaker.Volatility < 0 {
+		return fmt.Errorf("market maker volatility must not be negative")
+	}
+	return nil
+}
+
+// defaultFeeTiers returns the default volume tiers
+func defaultFeeTiers() []FeeTier {
+	return []FeeTier{
+		{MinVolume: 0, MakerBps: 10, TakerBps: 20},
+		{MinVolume: 1000000, MakerBps: 5, TakerBps: 15},
+		{MinVolume: 10000000, MakerBps: 0, TakerBps: 10},
+		{MinVolume: 100000000, MakerBps: -2, TakerBps: 8},
+	}
+}
+
+// getFeeTiersOrDefault parses fee tiers of the form
+// "min_volume@maker_bps/taker_bps,...", e.g. "0@10/20,1000000@5/15"
+func getFeeTiersOrDefault(key string, defaultValue []FeeTier) ([]FeeTier, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	tiers, err := parseFeeTiers(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return tiers, nil
+}
+
+// getSymbolFeeTiersOrDefault parses per-symbol fee tiers of the form
+// "SYMBOL:tiers;SYMBOL:tiers", e.g. "BTCUSD:0@5/10,5000000@2/6;ETHUSD:0@8/16"
+func getSymbolFeeTiersOrDefault(key string, defaultValue map[string][]FeeTier) (map[string][]FeeTier, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	schedules := make(map[string][]FeeTier)
+	for _, entry := range strings.Split(value, ";") {
+		symbol, tiers, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		symbol = strings.TrimSpace(symbol)
+		if !ok || symbol == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: expected \"SYMBOL:tiers\"", key, entry)
+		}
+		parsed, err := parseFeeTiers(tiers)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s for symbol %s: %w", key, symbol, err)
+		}
+		schedules[symbol] = parsed
+	}
+
+	return schedules, nil
+}
+
+// parseFeeTiers parses a comma separated list of "min_volume@maker/taker"
+// tiers
+func parseFeeTiers(value string) ([]FeeTier, error) {
+	var tiers []FeeTier
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		volumeStr, rates, ok := strings.Cut(entry, "@")
+		if !ok {
+			return nil, fmt.Errorf("fee tier %q: expected \"min_volume@maker_bps/taker_bps\"", entry)
+		}
+		makerStr, takerStr, ok := strings.Cut(rates, "/")
+		if !ok {
+			return nil, fmt.Errorf("fee tier %q: expected \"min_volume@maker_bps/taker_bps\"", entry)
+		}
+		volume, err := strconv.ParseFloat(strings.TrimSpace(volumeStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("fee tier %q: invalid volume", entry)
+		}
+		maker, err := strconv.ParseFloat(strings.TrimSpace(makerStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("fee tier %q: invalid maker rate", entry)
+		}
+		taker, err := strconv.ParseFloat(strings.TrimSpace(takerStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("fee tier %q: invalid taker rate", entry)
+		}
+		tiers = append(tiers, FeeTier{MinVolume: volume, MakerBps: maker, TakerBps: taker})
+	}
+
+	return tiers, nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_RateLimitEndpoints(t *testing.T) {
+	t.Setenv("RATE_LIMIT_ENDPOINTS", "POST /api/orders=50:100, DELETE /api/orders/:id=20.5:40")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]EndpointLimit{
+		"POST /api/orders":       {Rate: 50, Burst: 100},
+		"DELETE /api/orders/:id": {Rate: 20.5, Burst: 40},
+	}, cfg.RateLimit.Endpoints)
+	assert.True(t, cfg.RateLimit.FailOpen)
+}
+
+func TestLoadConfig_RejectsMalformedRateLimitEndpoints(t *testing.T) {
+	for _, entries := range []string{
+		"POST /api/orders",
+		"POST /api/orders=50",
+		"POST /api/orders=fast:100",
+		"POST /api/orders=50:1.5",
+		"POST /api/orders=0:100",
+		"POST /api/orders=50:-1",
+		"/api/orders=50:100",
+		"POST api/orders=50:100",
+		"POST /api/orders=50:100,=1:1",
+	} {
+		t.Run(entries, func(t *testing.T) {
+			t.Setenv("RATE_LIMIT_ENDPOINTS", entries)
+			_, err := LoadConfig()
+			assert.ErrorContains(t, err, "rate limit")
+		})
+	}
+}
+
+func TestLoadConfig_FeeTiers(t *testing.T) {
+	t.Setenv("FEE_TIERS", "0@10/20, 1000000@5/15")
+	t.Setenv("FEE_SYMBOL_TIERS", "BTCUSD:0@5/10;ETHUSD:0@8/16")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []FeeTier{
+		{MinVolume: 0, MakerBps: 10, TakerBps: 20},
+		{MinVolume: 1000000, MakerBps: 5, TakerBps: 15},
+	}, cfg.Fees.Tiers)
+	assert.Equal(t, []FeeTier{{MinVolume: 0, MakerBps: 8, TakerBps: 16}}, cfg.Fees.Symbols["ETHUSD"])
+}
+
+func TestLoadConfig_RejectsMalformedFeeTiers(t *testing.T) {
+	for _, tiers := range []string{
+		"0@10",
+		"10/20",
+		"0@ten/20",
+		"0@10/20,",
+		"x@10/20",
+		"0@10/20,1000@5/fifteen",
+	} {
+		t.Run(tiers, func(t *testing.T) {
+			t.Setenv("FEE_TIERS", tiers)
+			_, err := LoadConfig()
+			assert.ErrorContains(t, err, "FEE_TIERS")
+		})
+	}
+
+	for _, tiers := range []string{"BTCUSD", ":0@5/10", "BTCUSD:0@5"} {
+		t.Run(tiers, func(t *testing.T) {
+			t.Setenv("FEE_SYMBOL_TIERS", tiers)
+			_, err := LoadConfig()
+			assert.ErrorContains(t, err, "FEE_SYMBOL_TIERS")
+		})
+	}
+}
+
+func TestAgentsFromEnv_Populations(t *testing.T) {
+	t.Setenv("AGENT_POPULATIONS", "Noise:5, market_maker: 2")
+
+	agents := AgentsFromEnv()
+	require.NoError(t, agents.Validate())
+	assert.Equal(t, map[string]int{AgentNoise: 5, AgentMarketMaker: 2}, agents.Populations)
+}
+
+func TestAgentsFromEnv_RejectsMalformedPopulations(t *testing.T) {
+	for _, populations := range []string{
+		"noise",
+		"noise:many",
+		"noise:2.5",
+		":5",
+		"noise:5,",
+		"noise:5,fundamental",
+		"noise:5,whale:1",
+		"noise:-1",
+	} {
+		t.Run(populations, func(t *testing.T) {
+			t.Setenv("AGENT_POPULATIONS", populations)
+			assert.Error(t, AgentsFromEnv().Validate())
+
+			_, err := LoadConfig()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAgentsFromEnv_OracleMarketMakers(t *testing.T) {
+	assert.False(t, AgentsFromEnv().MarketMaker.Oracle)
+
+	t.Setenv("AGENT_MM_ORACLE", "true")
+	assert.True(t, AgentsFromEnv().MarketMaker.Oracle)
+}
+
+func TestClockFromEnv(t *testing.T) {
+	t.Setenv("CLOCK_SCALE", "60")
+	t.Setenv("CLOCK_START", "2024-03-04T09:30:00-05:00")
+
+	clock := ClockFromEnv()
+	require.NoError(t, clock.Validate())
+	assert.Equal(t, 60.0, clock.Scale)
+	assert.True(t, clock.Start.Equal(time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)))
+}
+
+func TestClockFromEnv_RejectsMalformedStart(t *testing.T) {
+	for _, start := range []string{"2024-03-04", "2024-03-04 09:30:00", "yesterday"} {
+		t.Run(start, func(t *testing.T) {
+			t.Setenv("CLOCK_START", start)
+			assert.ErrorContains(t, ClockFromEnv().Validate(), "CLOCK_START")
+
+			_, err := LoadConfig()
+			assert.ErrorContains(t, err, "CLOCK_START")
+		})
+	}
+}
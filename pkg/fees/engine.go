@@ -0,0 +1,196 @@
+package fees
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// feePrecision matches the DECIMAL(20, 8) fee columns
+const feePrecision = 1e8
+
+// VolumeSource provides the traded notional per user used to place users in
+// volume tiers
+type VolumeSource interface {
+	GetUserVolumes(ctx context.Context, since time.Time) (map[string]float64, error)
+}
+
+// RebateSource provides the rebates designated market makers earn off their
+// maker fees
+type RebateSource interface {
+	// RebateBps returns the rebate in basis points a user currently earns
+	// as maker in a symbol, or zero
+	RebateBps(userID, symbol string) float64
+}
+
+// Engine computes maker/taker fees on trades from per-symbol tiered
+// schedules. The resting order of a match is the maker and the incoming
+// order the taker. User volumes, and so tiers, are recomputed every
+// RecomputeInterval; until the first recompute every user is in the first
+// tier.
+type Engine struct {
+	config  config.FeesConfig
+	volumes VolumeSource
+	rebates RebateSource
+	logger  *slog.Logger
+
+	userVolumes map[string]float64
+	computedAt  time.Time
+	mutex       sync.RWMutex
+}
+
+// NewEngine creates a new fee engine
+func NewEngine(cfg config.FeesConfig, volumes VolumeSource, logger *slog.Logger) *Engine {
+	return &Engine{
+		config:      cfg,
+		volumes:     volumes,
+		logger:      logger,
+		userVolumes: make(map[string]float64),
+	}
+}
+
+// SetRebateSource sets the source of market maker rebates. It must be called
+// before the engine is used.
+func (e *Engine) SetRebateSource(source RebateSource) {
+	e.rebates = source
+}
+
+// Run recomputes user tiers now and then every RecomputeInterval until the
+// context is done
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.RecomputeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Recompute(ctx); err != nil && ctx.Err() == nil {
+			e.logger.Error("Failed to recompute fee tiers", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Recompute reloads every user's traded volume over the volume window
+func (e *Engine) Recompute(ctx context.Context) error {
+	now := time.Now()
+	volumes, err := e.volumes.GetUserVolumes(ctx, now.Add(-e.config.VolumeWindow))
+	if err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	e.userVolumes = volumes
+	e.computedAt = now
+	e.mutex.Unlock()
+
+	e.logger.Info("Fee tiers recomputed", "users", len(volumes), "window", e.config.VolumeWindow)
+	return nil
+}
+
+// Apply sets the taker side, the buy and sell fees and the maker rebate of a
+// trade. takerSide is the side of the incoming order. The maker rebate is
+// deducted from the maker's fee.
+func (e *Engine) Apply(trade *shared.Trade, buyerID, sellerID string, takerSide shared.OrderSide) {
+	buyer := e.TierFor(buyerID, trade.Symbol)
+	seller := e.TierFor(sellerID, trade.Symbol)
+
+	trade.TakerSide = takerSide
+	if takerSide == shared.OrderSideBuy {
+		trade.MakerRebate = e.rebate(trade, sellerID)
+		trade.BuyFee = fee(trade.Value(), buyer.TakerBps)
+		trade.SellFee = fee(trade.Value(), seller.MakerBps) - trade.MakerRebate
+	} else {
+		trade.MakerRebate = e.rebate(trade, buyerID)
+		trade.BuyFee = fee(trade.Value(), buyer.MakerBps) - trade.MakerRebate
+		trade.SellFee = fee(trade.Value(), seller.TakerBps)
+	}
+}
+
+// rebate returns the market maker rebate the maker of a trade earns
+func (e *Engine) rebate(trade *shared.Trade, makerID string) float64 {
+	if e.rebates == nil {
+		return 0
+	}
+	return fee(trade.Value(), e.rebates.RebateBps(makerID, trade.Symbol))
+}
+
+// TierFor returns the tier a user currently trades at in a symbol
+func (e *Engine) TierFor(userID, symbol string) *shared.UserFeeTier {
+	e.mutex.RLock()
+	volume := e.userVolumes[userID]
+	computedAt := e.computedAt
+	e.mutex.RUnlock()
+
+	tiers := e.config.ScheduleFor(symbol)
+	index := sort.Search(len(tiers), func(i int) bool {
+		return tiers[i].MinVolume > volume
+	}) - 1
+	if index < 0 {
+		index = 0
+	}
+
+	return &shared.UserFeeTier{
+		UserID:     userID,
+		Symbol:     symbol,
+		Tier:       index,
+		Volume:     volume,
+		MakerBps:   tiers[index].MakerBps,
+		TakerBps:   tiers[index].TakerBps,
+		ComputedAt: computedAt,
+	}
+}
+
+// MaxFeeBps returns the highest maker or taker fee of any tier of a
+// symbol's schedule, the most a trade can be charged whatever tier the user
+// is in when it executes
+func (e *Engine) MaxFeeBps(symbol string) float64 {
+	var highest float64
+	for _, tier := range e.config.ScheduleFor(symbol) {
+		highest = math.Max(highest, math.Max(tier.MakerBps, tier.TakerBps))
+	}
+	return highest
+}
+
+// MaxRebateBps returns the highest market maker rebate that keeps every
+// trade in a symbol from costing the exchange: the lowest maker rate plus the
+// lowest taker rate of any tier of the symbol's schedule
+func (e *Engine) MaxRebateBps(symbol string) float64 {
+	tiers := e.config.ScheduleFor(symbol)
+	if len(tiers) == 0 {
+		return 0
+	}
+
+	maker, taker := tiers[0].MakerBps, tiers[0].TakerBps
+	for _, tier := range tiers[1:] {
+		maker = math.Min(maker, tier.MakerBps)
+		taker = math.Min(taker, tier.TakerBps)
+	}
+	return math.Max(maker+taker, 0)
+}
+
+// Schedules returns the default schedule under "*" and every symbol
+// schedule under its symbol
+func (e *Engine) Schedules() map[string][]config.FeeTier {
+	schedules := make(map[string][]config.FeeTier, len(e.config.Symbols)+1)
+	schedules["*"] = e.config.Tiers
+	for symbol, tiers := range e.config.Symbols {
+		schedules[symbol] = tiers
+	}
+	return schedules
+}
+
+// fee returns the fee in basis points on a trade value, rounded to the
+// precision fees are stored with
+func fee(value, bps float64) float64 {
+	return math.Round(value*bps/10000*feePrecision) / feePrecision
+}
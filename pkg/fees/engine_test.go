@@ -0,0 +1,194 @@
+package fees
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// staticVolumes is a VolumeSource returning fixed user volumes
+type staticVolumes struct {
+	volumes map[string]float64
+	err     error
+	since   time.Time
+}
+
+func (s *staticVolumes) GetUserVolumes(ctx context.Context, since time.Time) (map[string]float64, error) {
+	s.since = since
+	return s.volumes, s.err
+}
+
+// staticRebates is a RebateSource with a rebate per user
+type staticRebates map[string]float64
+
+func (r staticRebates) RebateBps(userID, symbol string) float64 {
+	return r[userID]
+}
+
+func testFeesConfig() config.FeesConfig {
+	return config.FeesConfig{
+		Enabled: true,
+		Tiers: []config.FeeTier{
+			{MinVolume: 0, MakerBps: 10, TakerBps: 20},
+			{MinVolume: 1000000, MakerBps: 5, TakerBps: 15},
+			{MinVolume: 10000000, MakerBps: -1, TakerBps: 10},
+		},
+		Symbols: map[string][]config.FeeTier{
+			"BTCUSD": {{MinVolume: 0, MakerBps: 2, TakerBps: 30}},
+		},
+		VolumeWindow:      30 * 24 * time.Hour,
+		RecomputeInterval: time.Hour,
+	}
+}
+
+func newTestEngine(volumes *staticVolumes) *Engine {
+	return NewEngine(testFeesConfig(), volumes, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestEngine_TierFor(t *testing.T) {
+	volumes := &staticVolumes{volumes: map[string]float64{
+		"small":    999999,
+		"boundary": 1000000,
+		"large":    25000000,
+	}}
+	engine := newTestEngine(volumes)
+
+	// Until the first recompute everyone trades in the first tier
+	assert.Equal(t, 0, engine.TierFor("large", "AAPL").Tier)
+
+	require.NoError(t, engine.Recompute(context.Background()))
+	assert.WithinDuration(t, time.Now().Add(-30*24*time.Hour), volumes.since, time.Minute)
+
+	tests := []struct {
+		userID string
+		symbol string
+		tier   int
+		maker  float64
+		taker  float64
+	}{
+		{"unknown", "AAPL", 0, 10, 20},
+		{"small", "AAPL", 0, 10, 20},
+		{"boundary", "AAPL", 1, 5, 15},
+		{"large", "AAPL", 2, -1, 10},
+		// Symbols with their own schedule ignore the default tiers
+		{"large", "BTCUSD", 0, 2, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.userID+"/"+tt.symbol, func(t *testing.T) {
+			tier := engine.TierFor(tt.userID, tt.symbol)
+			assert.Equal(t, tt.tier, tier.Tier)
+			assert.Equal(t, tt.maker, tier.MakerBps)
+			assert.Equal(t, tt.taker, tier.TakerBps)
+			assert.Equal(t, volumes.volumes[tt.userID], tier.Volume)
+		})
+	}
+}
+
+func TestEngine_RecomputeKeepsTiersOnError(t *testing.T) {
+	volumes := &staticVolumes{volumes: map[string]float64{"large": 25000000}}
+	engine := newTestEngine(volumes)
+	require.NoError(t, engine.Recompute(context.Background()))
+
+	volumes.err = errors.New("database unavailable")
+	assert.Error(t, engine.Recompute(context.Background()))
+	assert.Equal(t, 2, engine.TierFor("large", "AAPL").Tier)
+}
+
+func TestEngine_Apply(t *testing.T) {
+	volumes := &staticVolumes{volumes: map[string]float64{"buyer": 1000000}}
+	engine := newTestEngine(volumes)
+	require.NoError(t, engine.Recompute(context.Background()))
+
+	tests := []struct {
+		name      string
+		takerSide shared.OrderSide
+		rebates   RebateSource
+		buyFee    float64
+		sellFee   float64
+		rebate    float64
+	}{
+		{
+			name:      "buyer takes at its tier",
+			takerSide: shared.OrderSideBuy,
+			buyFee:    15,
+			sellFee:   10,
+		},
+		{
+			name:      "seller takes",
+			takerSide: shared.OrderSideSell,
+			buyFee:    5,
+			sellFee:   20,
+		},
+		{
+			name:      "maker rebate comes off the maker fee",
+			takerSide: shared.OrderSideBuy,
+			rebates:   staticRebates{"seller": 4},
+			buyFee:    15,
+			sellFee:   6,
+			rebate:    4,
+		},
+		{
+			name:      "the taker earns no rebate",
+			takerSide: shared.OrderSideSell,
+			rebates:   staticRebates{"seller": 4},
+			buyFee:    5,
+			sellFee:   20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine.SetRebateSource(tt.rebates)
+			trade := &shared.Trade{Symbol: "AAPL", Price: 100, Quantity: 100}
+
+			engine.Apply(trade, "buyer", "seller", tt.takerSide)
+
+			assert.Equal(t, tt.takerSide, trade.TakerSide)
+			assert.InDelta(t, tt.buyFee, trade.BuyFee, 1e-9)
+			assert.InDelta(t, tt.sellFee, trade.SellFee, 1e-9)
+			assert.InDelta(t, tt.rebate, trade.MakerRebate, 1e-9)
+		})
+	}
+}
+
+func TestEngine_NegativeMakerRate(t *testing.T) {
+	volumes := &staticVolumes{volumes: map[string]float64{"seller": 10000000}}
+	engine := newTestEngine(volumes)
+	require.NoError(t, engine.Recompute(context.Background()))
+
+	trade := &shared.Trade{Symbol: "AAPL", Price: 100, Quantity: 100}
+	engine.Apply(trade, "buyer", "seller", shared.OrderSideBuy)
+
+	assert.InDelta(t, 20, trade.BuyFee, 1e-9)
+	assert.InDelta(t, -1, trade.SellFee, 1e-9)
+}
+
+func TestEngine_MaxFeeBps(t *testing.T) {
+	engine := newTestEngine(&staticVolumes{})
+
+	assert.Equal(t, 20.0, engine.MaxFeeBps("AAPL"))
+	assert.Equal(t, 30.0, engine.MaxFeeBps("BTCUSD"))
+}
+
+func TestFee_RoundsToStoredPrecision(t *testing.T) {
+	assert.Equal(t, 0.00000001, fee(0.000005, 20))
+	assert.Equal(t, 0.0, fee(0.000002, 20))
+	assert.Equal(t, 0.24691358, fee(123.45678901, 20))
+	assert.Equal(t, -0.01, fee(100, -1))
+}
+
+func TestEngine_MaxRebateBps(t *testing.T) {
+	engine := newTestEngine(&staticVolumes{})
+
+	// The lowest maker and taker rates may come from different tiers
+	assert.Equal(t, 9.0, engine.MaxRebateBps("AAPL"))
+	assert.Equal(t, 32.0, engine.MaxRebateBps("BTCUSD"))
+}
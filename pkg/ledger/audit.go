@@ -0,0 +1,209 @@
+package ledger
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+// Store provides the ledger sums the auditor checks, and the balances held
+// in the accounts and positions tables it reconciles against
+type Store interface {
+	// GetAssetTotals returns the sum of all entries per asset
+	GetAssetTotals(ctx context.Context) (map[string]float64, error)
+
+	// GetUnbalancedJournals returns the IDs of journals whose entries don't
+	// sum to zero for some asset
+	GetUnbalancedJournals(ctx context.Context) ([]string, error)
+
+	// GetBalances returns, from one consistent snapshot, the balance of every
+	// user account per asset as derived from the ledger, and every user's
+	// cash balance and position quantities from the accounts and positions
+	// tables keyed by ledger account and asset
+	GetBalances(ctx context.Context) (ledgerBalances, bookBalances []*Balance, err error)
+}
+
+// InvariantReport is the result of checking that the ledger balances
+type InvariantReport struct {
+	Balanced           bool               `json:"balanced"`
+	AssetTotals        map[string]float64 `json:"asset_totals"`
+	UnbalancedAssets   []string           `json:"unbalanced_assets"`
+	UnbalancedJournals []string           `json:"unbalanced_journals"`
+	CheckedAt          time.Time          `json:"checked_at"`
+}
+
+// Break is a difference between the ledger and the accounts and positions
+// tables for one user and asset
+type Break struct {
+	UserID     string  `json:"user_id"`
+	Asset      string  `json:"asset"`
+	Ledger     float64 `json:"ledger"`
+	Book       float64 `json:"book"`
+	Difference float64 `json:"difference"`
+}
+
+// ReconciliationReport is the result of reconciling the ledger against the
+// accounts and positions tables
+type ReconciliationReport struct {
+	Reconciled      bool      `json:"reconciled"`
+	BalancesChecked int       `json:"balances_checked"`
+	Breaks          []Break   `json:"breaks"`
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// Auditor proves the ledger invariants and reconciles the ledger against the
+// balances the trading path maintains
+type Auditor struct {
+	store    Store
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewAuditor creates a new ledger auditor
+func NewAuditor(store Store, interval time.Duration, logger *slog.Logger) *Auditor {
+	return &Auditor{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run checks the invariants and reconciles every interval until the context
+// is done. Failures are logged as errors.
+func (a *Auditor) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		invariants, err := a.CheckInvariants(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				a.logger.Error("Failed to check ledger invariants", "error", err)
+			}
+			continue
+		}
+		if !invariants.Balanced {
+			a.logger.Error("Ledger invariant violated",
+				"unbalanced_assets", invariants.UnbalancedAssets,
+				"unbalanced_journals", len(invariants.UnbalancedJournals),
+			)
+		}
+
+		reconciliation, err := a.Reconcile(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				a.logger.Error("Failed to reconcile ledger", "error", err)
+			}
+			continue
+		}
+		if !reconciliation.Reconciled {
+			a.logger.Error("Ledger reconciliation breaks found",
+				"breaks", len(reconciliation.Breaks),
+				"balances_checked", reconciliation.BalancesChecked,
+			)
+		}
+	}
+}
+
+// CheckInvariants proves that the sum of all accounts is zero for every
+// asset and that every journal balances on its own
+func (a *Auditor) CheckInvariants(ctx context.Context) (*InvariantReport, error) {
+	totals, err := a.store.GetAssetTotals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	journals, err := a.store.GetUnbalancedJournals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &InvariantReport{
+		AssetTotals:        totals,
+		UnbalancedAssets:   make([]string, 0),
+		UnbalancedJournals: journals,
+		CheckedAt:          time.Now(),
+	}
+	if report.UnbalancedJournals == nil {
+		report.UnbalancedJournals = make([]string, 0)
+	}
+
+	for asset, total := range totals {
+		if math.Abs(total) > Tolerance {
+			report.UnbalancedAssets = append(report.UnbalancedAssets, asset)
+		}
+	}
+	sort.Strings(report.UnbalancedAssets)
+
+	report.Balanced = len(report.UnbalancedAssets) == 0 && len(report.UnbalancedJournals) == 0
+	return report, nil
+}
+
+// Reconcile compares every user balance derived from the ledger with the
+// cash balance and position quantities in the accounts and positions tables
+func (a *Auditor) Reconcile(ctx context.Context) (*ReconciliationReport, error) {
+	ledgerBalances, bookBalances, err := a.store.GetBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		account string
+		asset   string
+	}
+	type pair struct {
+		ledger float64
+		book   float64
+	}
+
+	pairs := make(map[key]*pair)
+	for _, balance := range ledgerBalances {
+		pairs[key{balance.Account, balance.Asset}] = &pair{ledger: balance.Amount}
+	}
+	for _, balance := range bookBalances {
+		k := key{balance.Account, balance.Asset}
+		if p, exists := pairs[k]; exists {
+			p.book = balance.Amount
+		} else {
+			pairs[k] = &pair{book: balance.Amount}
+		}
+	}
+
+	report := &ReconciliationReport{
+		BalancesChecked: len(pairs),
+		Breaks:          make([]Break, 0),
+		CheckedAt:       time.Now(),
+	}
+
+	for k, p := range pairs {
+		if math.Abs(p.ledger-p.book) <= Tolerance {
+			continue
+		}
+		userID, _ := UserID(k.account)
+		report.Breaks = append(report.Breaks, Break{
+			UserID:     userID,
+			Asset:      k.asset,
+			Ledger:     p.ledger,
+			Book:       p.book,
+			Difference: p.book - p.ledger,
+		})
+	}
+
+	sort.Slice(report.Breaks, func(i, j int) bool {
+		if report.Breaks[i].UserID != report.Breaks[j].UserID {
+			return report.Breaks[i].UserID < report.Breaks[j].UserID
+		}
+		return report.Breaks[i].Asset < report.Breaks[j].Asset
+	})
+
+	report.Reconciled = len(report.Breaks) == 0
+	return report, nil
+}
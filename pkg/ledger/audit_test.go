@@ -0,0 +1,163 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore is a Store with fixed sums and balances
+type fakeStore struct {
+	totals     map[string]float64
+	unbalanced []string
+	ledger     []*Balance
+	book       []*Balance
+	err        error
+}
+
+func (s *fakeStore) GetAssetTotals(ctx context.Context) (map[string]float64, error) {
+	return s.totals, s.err
+}
+
+func (s *fakeStore) GetUnbalancedJournals(ctx context.Context) ([]string, error) {
+	return s.unbalanced, s.err
+}
+
+func (s *fakeStore) GetBalances(ctx context.Context) ([]*Balance, []*Balance, error) {
+	return s.ledger, s.book, s.err
+}
+
+func newTestAuditor(store Store) *Auditor {
+	return NewAuditor(store, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestAuditor_CheckInvariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		store    *fakeStore
+		balanced bool
+		assets   []string
+		journals []string
+	}{
+		{
+			name:     "balanced ledger",
+			store:    &fakeStore{totals: map[string]float64{CashAsset: 0, "AAPL": 1e-9}},
+			balanced: true,
+			assets:   []string{},
+			journals: []string{},
+		},
+		{
+			name:     "empty ledger",
+			store:    &fakeStore{},
+			balanced: true,
+			assets:   []string{},
+			journals: []string{},
+		},
+		{
+			name:     "assets not summing to zero",
+			store:    &fakeStore{totals: map[string]float64{"MSFT": -2, CashAsset: 0.01, "AAPL": 0}},
+			assets:   []string{"MSFT", CashAsset},
+			journals: []string{},
+		},
+		{
+			name:     "unbalanced journal",
+			store:    &fakeStore{totals: map[string]float64{CashAsset: 0}, unbalanced: []string{"j1"}},
+			assets:   []string{},
+			journals: []string{"j1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := newTestAuditor(tt.store).CheckInvariants(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.balanced, report.Balanced)
+			assert.Equal(t, tt.assets, report.UnbalancedAssets)
+			assert.Equal(t, tt.journals, report.UnbalancedJournals)
+			assert.False(t, report.CheckedAt.IsZero())
+		})
+	}
+}
+
+func TestAuditor_Reconcile(t *testing.T) {
+	tests := []struct {
+		name    string
+		ledger  []*Balance
+		book    []*Balance
+		checked int
+		breaks  []Break
+	}{
+		{
+			name: "ledger and book agree",
+			ledger: []*Balance{
+				{Account: "user:u1", Asset: CashAsset, Amount: 9500},
+				{Account: "user:u1", Asset: "AAPL", Amount: 5},
+			},
+			book: []*Balance{
+				{Account: "user:u1", Asset: "AAPL", Amount: 5 + 1e-9},
+				{Account: "user:u1", Asset: CashAsset, Amount: 9500},
+			},
+			checked: 2,
+			breaks:  []Break{},
+		},
+		{
+			name: "balances differ",
+			ledger: []*Balance{
+				{Account: "user:u2", Asset: CashAsset, Amount: 100},
+				{Account: "user:u1", Asset: "AAPL", Amount: 5},
+				{Account: "user:u1", Asset: CashAsset, Amount: 9500},
+			},
+			book: []*Balance{
+				{Account: "user:u2", Asset: CashAsset, Amount: 100},
+				{Account: "user:u1", Asset: "AAPL", Amount: 4},
+				{Account: "user:u1", Asset: CashAsset, Amount: 9600},
+			},
+			checked: 3,
+			breaks: []Break{
+				{UserID: "u1", Asset: "AAPL", Ledger: 5, Book: 4, Difference: -1},
+				{UserID: "u1", Asset: CashAsset, Ledger: 9500, Book: 9600, Difference: 100},
+			},
+		},
+		{
+			name:    "balance only in the ledger",
+			ledger:  []*Balance{{Account: "user:u1", Asset: "AAPL", Amount: 5}},
+			checked: 1,
+			breaks:  []Break{{UserID: "u1", Asset: "AAPL", Ledger: 5, Difference: -5}},
+		},
+		{
+			name:    "balance only in the book",
+			book:    []*Balance{{Account: "user:u1", Asset: CashAsset, Amount: 250}},
+			checked: 1,
+			breaks:  []Break{{UserID: "u1", Asset: CashAsset, Book: 250, Difference: 250}},
+		},
+		{
+			name:    "zero balance only on one side",
+			ledger:  []*Balance{{Account: "user:u1", Asset: "AAPL", Amount: 0}},
+			checked: 1,
+			breaks:  []Break{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := newTestAuditor(&fakeStore{ledger: tt.ledger, book: tt.book}).Reconcile(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.breaks) == 0, report.Reconciled)
+			assert.Equal(t, tt.checked, report.BalancesChecked)
+			assert.Equal(t, tt.breaks, report.Breaks)
+		})
+	}
+}
+
+func TestAuditor_StoreErrors(t *testing.T) {
+	auditor := newTestAuditor(&fakeStore{err: errors.New("database unavailable")})
+
+	_, err := auditor.CheckInvariants(context.Background())
+	assert.EqualError(t, err, "database unavailable")
+	_, err = auditor.Reconcile(context.Background())
+	assert.EqualError(t, err, "database unavailable")
+}
@@ -0,0 +1,180 @@
+package ledger
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"simulated_exchange/pkg/shared"
+)
+
+// JournalType identifies the business event a journal records
+type JournalType string
+
+const (
+	JournalTrade      JournalType = "TRADE"
+	JournalFee        JournalType = "FEE"
+	JournalDeposit    JournalType = "DEPOSIT"
+	JournalWithdrawal JournalType = "WITHDRAWAL"
+)
+
+// CashAsset is the asset cash balances are held in. Shares are held in an
+// asset named after their symbol.
+const CashAsset = "USD"
+
+// System accounts. ExternalAccount is the counterparty of every deposit and
+// withdrawal, so its balance is the negative of everything held on the
+// exchange.
+const (
+	ExchangeFeesAccount = "exchange:fees"
+	ExternalAccount     = "external"
+)
+
+// userAccountPrefix prefixes the ledger account of every user
+const userAccountPrefix = "user:"
+
+// amountPrecision matches the DECIMAL(28, 8) entry amount column
+const amountPrecision = 1e8
+
+// Tolerance absorbs float rounding when comparing ledger sums
+const Tolerance = 1e-6
+
+// UserAccount returns the ledger account holding a user's balances
+func UserAccount(userID string) string {
+	return userAccountPrefix + userID
+}
+
+// UserID returns the user a ledger account belongs to, or false for system
+// accounts
+func UserID(account string) (string, bool) {
+	return strings.CutPrefix(account, userAccountPrefix)
+}
+
+// Entry is one posting to an account. Positive amounts increase the
+// account's balance of the asset and negative amounts decrease it.
+type Entry struct {
+	ID        int64     `json:"id" db:"id"`
+	JournalID string    `json:"journal_id" db:"journal_id"`
+	Account   string    `json:"account" db:"account"`
+	Asset     string    `json:"asset" db:"asset"`
+	Amount    float64   `json:"amount" db:"amount"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Journal is a set of entries recording one business event. The entries of
+// a journal sum to zero for every asset.
+type Journal struct {
+	ID          string      `json:"id" db:"id"`
+	Type        JournalType `json:"type" db:"journal_type"`
+	Reference   string      `json:"reference" db:"reference"`
+	Description string      `json:"description" db:"description"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	Entries     []Entry     `json:"entries" db:"-"`
+}
+
+// Balance is an account's balance of one asset
+type Balance struct {
+	Account string  `json:"account" db:"account"`
+	Asset   string  `json:"asset" db:"asset"`
+	Amount  float64 `json:"amount" db:"amount"`
+}
+
+// NewJournal creates an empty journal
+func NewJournal(journalType JournalType, reference, description string) *Journal {
+	return &Journal{
+		ID:          uuid.New().String(),
+		Type:        journalType,
+		Reference:   reference,
+		Description: description,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// Post adds an entry to the journal. Amounts are rounded to the precision
+// they are stored with and zero amounts are skipped.
+func (j *Journal) Post(account, asset string, amount float64) *Journal {
+	amount = math.Round(amount*amountPrecision) / amountPrecision
+	if amount == 0 {
+		return j
+	}
+
+	j.Entries = append(j.Entries, Entry{
+		JournalID: j.ID,
+		Account:   account,
+		Asset:     asset,
+		Amount:    amount,
+		CreatedAt: j.CreatedAt,
+	})
+	return j
+}
+
+// Validate checks that the journal has entries and that they sum to zero for
+// every asset
+func (j *Journal) Validate() error {
+	if len(j.Entries) < 2 {
+		return fmt.Errorf("journal %s has %d entries, at least 2 are required", j.ID, len(j.Entries))
+	}
+
+	totals := make(map[string]float64)
+	for _, entry := range j.Entries {
+		if entry.Account == "" || entry.Asset == "" {
+			return fmt.Errorf("journal %s has an entry without account or asset", j.ID)
+		}
+		totals[entry.Asset] += entry.Amount
+	}
+
+	assets := make([]string, 0, len(totals))
+	for asset := range totals {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	for _, asset := range assets {
+		if math.Abs(totals[asset]) > Tolerance {
+			return fmt.Errorf("journal %s is unbalanced: %s entries sum to %.8f", j.ID, asset, totals[asset])
+		}
+	}
+	return nil
+}
+
+// TradeJournal records the exchange of cash for shares between buyer and
+// seller. Fees are recorded separately by FeeJournal.
+func TradeJournal(trade *shared.Trade, buyerID, sellerID string) *Journal {
+	return NewJournal(JournalTrade, trade.ID,
+		fmt.Sprintf("%s %.8g @ %.8g", trade.Symbol, trade.Quantity, trade.Price)).
+		Post(UserAccount(buyerID), CashAsset, -trade.Value()).
+		Post(UserAccount(sellerID), CashAsset, trade.Value()).
+		Post(UserAccount(sellerID), trade.Symbol, -trade.Quantity).
+		Post(UserAccount(buyerID), trade.Symbol, trade.Quantity)
+}
+
+// FeeJournal records the fees charged on a trade, or returns nil if the
+// trade carries no fees. Maker rebates are negative fees.
+func FeeJournal(trade *shared.Trade, buyerID, sellerID string) *Journal {
+	if trade.BuyFee == 0 && trade.SellFee == 0 {
+		return nil
+	}
+
+	return NewJournal(JournalFee, trade.ID,
+		fmt.Sprintf("%s trade fees, taker %s", trade.Symbol, trade.TakerSide)).
+		Post(UserAccount(buyerID), CashAsset, -trade.BuyFee).
+		Post(UserAccount(sellerID), CashAsset, -trade.SellFee).
+		Post(ExchangeFeesAccount, CashAsset, trade.BuyFee+trade.SellFee)
+}
+
+// DepositJournal records an asset brought onto the exchange by a user
+func DepositJournal(userID, asset string, amount float64, reference string) *Journal {
+	return NewJournal(JournalDeposit, reference, fmt.Sprintf("deposit %.8g %s", amount, asset)).
+		Post(ExternalAccount, asset, -amount).
+		Post(UserAccount(userID), asset, amount)
+}
+
+// WithdrawalJournal records an asset taken off the exchange by a user
+func WithdrawalJournal(userID, asset string, amount float64, reference string) *Journal {
+	return NewJournal(JournalWithdrawal, reference, fmt.Sprintf("withdraw %.8g %s", amount, asset)).
+		Post(UserAccount(userID), asset, -amount).
+		Post(ExternalAccount, asset, amount)
+}
@@ -0,0 +1,211 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/shared"
+)
+
+// assetTotals sums a journal's entries per asset
+func assetTotals(journal *Journal) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, entry := range journal.Entries {
+		totals[entry.Asset] += entry.Amount
+	}
+	return totals
+}
+
+// balances sums a journal's entries per account and asset
+func balances(journal *Journal) map[string]float64 {
+	result := make(map[string]float64)
+	for _, entry := range journal.Entries {
+		result[entry.Account+" "+entry.Asset] += entry.Amount
+	}
+	return result
+}
+
+func TestJournal_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		journal *Journal
+		err     string
+	}{
+		{
+			name: "balanced in every asset",
+			journal: NewJournal(JournalTrade, "t1", "").
+				Post("user:a", CashAsset, -100).
+				Post("user:b", CashAsset, 100).
+				Post("user:b", "AAPL", -1).
+				Post("user:a", "AAPL", 1),
+		},
+		{
+			name:    "no entries",
+			journal: NewJournal(JournalDeposit, "d1", ""),
+			err:     "has 0 entries, at least 2 are required",
+		},
+		{
+			name:    "single entry",
+			journal: NewJournal(JournalDeposit, "d1", "").Post("user:a", CashAsset, 100),
+			err:     "has 1 entries, at least 2 are required",
+		},
+		{
+			name: "zero amounts are not entries",
+			journal: NewJournal(JournalFee, "t1", "").
+				Post("user:a", CashAsset, 0).
+				Post(ExchangeFeesAccount, CashAsset, 1e-10),
+			err: "has 0 entries",
+		},
+		{
+			name: "unbalanced",
+			journal: NewJournal(JournalDeposit, "d1", "").
+				Post(ExternalAccount, CashAsset, -100).
+				Post("user:a", CashAsset, 99.5),
+			err: "is unbalanced: USD entries sum to -0.50000000",
+		},
+		{
+			name: "balanced cash but unbalanced shares",
+			journal: NewJournal(JournalTrade, "t1", "").
+				Post("user:a", CashAsset, -100).
+				Post("user:b", CashAsset, 100).
+				Post("user:a", "AAPL", 1),
+			err: "is unbalanced: AAPL entries sum to 1.00000000",
+		},
+		{
+			name: "balanced within the stored precision",
+			journal: NewJournal(JournalDeposit, "d1", "").
+				Post(ExternalAccount, CashAsset, -0.1).
+				Post("user:a", CashAsset, 0.06).
+				Post("user:b", CashAsset, 0.04),
+		},
+		{
+			name: "entry without an account",
+			journal: NewJournal(JournalDeposit, "d1", "").
+				Post("", CashAsset, -1).
+				Post("user:a", CashAsset, 1),
+			err: "has an entry without account or asset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.journal.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.journal.ID)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestJournal_PostRoundsToStoredPrecision(t *testing.T) {
+	journal := NewJournal(JournalTrade, "t1", "").Post("user:a", CashAsset, 0.123456789)
+
+	require.Len(t, journal.Entries, 1)
+	assert.Equal(t, 0.12345679, journal.Entries[0].Amount)
+	assert.Equal(t, journal.ID, journal.Entries[0].JournalID)
+	assert.Equal(t, journal.CreatedAt, journal.Entries[0].CreatedAt)
+}
+
+func TestTradeJournal(t *testing.T) {
+	trade := &shared.Trade{ID: "t1", Symbol: "AAPL", Price: 150.25, Quantity: 3, BuyFee: 1, SellFee: 1}
+
+	journal := TradeJournal(trade, "buyer", "seller")
+	require.NoError(t, journal.Validate())
+	assert.Equal(t, JournalTrade, journal.Type)
+	assert.Equal(t, "t1", journal.Reference)
+
+	// Fees are left to the fee journal
+	assert.Equal(t, map[string]float64{CashAsset: 0, "AAPL": 0}, assetTotals(journal))
+	assert.Equal(t, map[string]float64{
+		"user:buyer USD":   -450.75,
+		"user:seller USD":  450.75,
+		"user:seller AAPL": -3,
+		"user:buyer AAPL":  3,
+	}, balances(journal))
+}
+
+func TestFeeJournal(t *testing.T) {
+	tests := []struct {
+		name     string
+		trade    *shared.Trade
+		balances map[string]float64
+	}{
+		{
+			name:  "both sides pay",
+			trade: &shared.Trade{ID: "t1", Symbol: "AAPL", Price: 100, Quantity: 10, TakerSide: shared.OrderSideBuy, BuyFee: 0.5, SellFee: 0.2},
+			balances: map[string]float64{
+				"user:buyer USD":    -0.5,
+				"user:seller USD":   -0.2,
+				"exchange:fees USD": 0.7,
+			},
+		},
+		{
+			name:  "maker rebate",
+			trade: &shared.Trade{ID: "t1", Symbol: "AAPL", Price: 100, Quantity: 10, TakerSide: shared.OrderSideBuy, BuyFee: 0.5, SellFee: -0.1, MakerRebate: 0.1},
+			balances: map[string]float64{
+				"user:buyer USD":    -0.5,
+				"user:seller USD":   0.1,
+				"exchange:fees USD": 0.4,
+			},
+		},
+		{
+			name:  "rebate beyond the taker fee",
+			trade: &shared.Trade{ID: "t1", Symbol: "AAPL", Price: 100, Quantity: 10, TakerSide: shared.OrderSideSell, BuyFee: -0.3, SellFee: 0.2, MakerRebate: 0.3},
+			balances: map[string]float64{
+				"user:buyer USD":    0.3,
+				"user:seller USD":   -0.2,
+				"exchange:fees USD": -0.1,
+			},
+		},
+		{
+			name:  "only the taker pays",
+			trade: &shared.Trade{ID: "t1", Symbol: "AAPL", Price: 100, Quantity: 10, TakerSide: shared.OrderSideSell, SellFee: 0.2},
+			balances: map[string]float64{
+				"user:seller USD":   -0.2,
+				"exchange:fees USD": 0.2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			journal := FeeJournal(tt.trade, "buyer", "seller")
+			require.NotNil(t, journal)
+			require.NoError(t, journal.Validate())
+			assert.Equal(t, JournalFee, journal.Type)
+			assert.InDelta(t, 0, assetTotals(journal)[CashAsset], Tolerance)
+
+			got := balances(journal)
+			require.Len(t, got, len(tt.balances))
+			for account, amount := range tt.balances {
+				assert.InDelta(t, amount, got[account], Tolerance, account)
+			}
+		})
+	}
+
+	// A trade without fees posts no journal
+	assert.Nil(t, FeeJournal(&shared.Trade{ID: "t1", Symbol: "AAPL", Price: 100, Quantity: 10}, "buyer", "seller"))
+}
+
+func TestDepositAndWithdrawalJournals(t *testing.T) {
+	deposit := DepositJournal("u1", CashAsset, 1000, "ref-1")
+	require.NoError(t, deposit.Validate())
+	assert.Equal(t, map[string]float64{"external USD": -1000, "user:u1 USD": 1000}, balances(deposit))
+
+	withdrawal := WithdrawalJournal("u1", "AAPL", 5, "ref-2")
+	require.NoError(t, withdrawal.Validate())
+	assert.Equal(t, map[string]float64{"external AAPL": 5, "user:u1 AAPL": -5}, balances(withdrawal))
+}
+
+func TestUserID(t *testing.T) {
+	userID, ok := UserID(UserAccount("u1"))
+	assert.True(t, ok)
+	assert.Equal(t, "u1", userID)
+
+	_, ok = UserID(ExchangeFeesAccount)
+	assert.False(t, ok)
+}
@@ -248,6 +248,9 @@ func (r *RedisEventBus) PublishPriceUpdate(ctx context.Context, update *shared.P
 			"timestamp": update.Timestamp,
 		},
 	}
+	if update.VolatilityIndex > 0 {
+		event.Data["volatility_index"] = update.VolatilityIndex
+	}
 	return r.Publish(ctx, event)
 }
 
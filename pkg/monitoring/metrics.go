@@ -21,6 +21,7 @@ type MetricsCollector struct {
 	httpRequestsTotal    *prometheus.CounterVec
 	httpRequestDuration  *prometheus.HistogramVec
 	httpRequestsInFlight *prometheus.GaugeVec
+	httpRateLimited      *prometheus.CounterVec
 
 	// Trading specific metrics
 	ordersTotal          *prometheus.CounterVec
@@ -28,6 +29,7 @@ type MetricsCollector struct {
 	tradesTotal          *prometheus.CounterVec
 	activeOrders         *prometheus.GaugeVec
 	orderBookDepth       *prometheus.GaugeVec
+	riskRejections       *prometheus.CounterVec
 
 	// Market simulation metrics
 	priceUpdatesTotal    *prometheus.CounterVec
@@ -94,6 +96,14 @@ func (mc *MetricsCollector) initializeMetrics() {
 		[]string{"service", "method", "endpoint"},
 	)
 
+	mc.httpRateLimited = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_rate_limited_total",
+			Help: "Total number of HTTP requests rejected by rate limiting",
+		},
+		[]string{"service", "scope", "endpoint"},
+	)
+
 	// Trading metrics
 	mc.ordersTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -112,6 +122,14 @@ func (mc *MetricsCollector) initializeMetrics() {
 		[]string{"service", "type", "side"},
 	)
 
+	mc.riskRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "risk_rejections_total",
+			Help: "Total number of orders rejected by pre-trade risk checks",
+		},
+		[]string{"service", "rule"},
+	)
+
 	mc.tradesTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "trades_total",
@@ -235,11 +253,13 @@ func (mc *MetricsCollector) registerMetrics() {
 		mc.httpRequestsTotal,
 		mc.httpRequestDuration,
 		mc.httpRequestsInFlight,
+		mc.httpRateLimited,
 		mc.ordersTotal,
 		mc.orderProcessingTime,
 		mc.tradesTotal,
 		mc.activeOrders,
 		mc.orderBookDepth,
+		mc.riskRejections,
 		mc.priceUpdatesTotal,
 		mc.currentPrices,
 		mc.marketVolatility,
@@ -280,12 +300,20 @@ func (mc *MetricsCollector) HTTPRequestEnd(service, method, endpoint string) {
 	mc.httpRequestsInFlight.WithLabelValues(service, method, endpoint).Dec()
 }
 
+func (mc *MetricsCollector) RecordRateLimited(service, scope, endpoint string) {
+	mc.httpRateLimited.WithLabelValues(service, scope, endpoint).Inc()
+}
+
 // Trading Metrics methods
 func (mc *MetricsCollector) RecordOrder(service, orderType, side, status string, processingTime time.Duration) {
 	mc.ordersTotal.WithLabelValues(service, orderType, side, status).Inc()
 	mc.orderProcessingTime.WithLabelValues(service, orderType, side).Observe(processingTime.Seconds())
 }
 
+func (mc *MetricsCollector) RecordRiskRejection(service, rule string) {
+	mc.riskRejections.WithLabelValues(service, rule).Inc()
+}
+
 func (mc *MetricsCollector) RecordTrade(service, symbol string) {
 	mc.tradesTotal.WithLabelValues(service, symbol).Inc()
 }
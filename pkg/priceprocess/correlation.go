@@ -0,0 +1,349 @@
+package priceprocess
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"simulated_exchange/pkg/config"
+)
+
+// Correlation is the correlation matrix of a set of symbols and its
+// Cholesky factor
+type Correlation struct {
+	symbols  []string
+	index    map[string]int
+	matrix   [][]float64
+	cholesky [][]float64
+}
+
+// NewCorrelation builds the correlation matrix of symbols from the factor
+// model and pair overrides of cfg. It fails if the overrides leave the
+// matrix without a Cholesky factor, i.e. not positive definite.
+func NewCorrelation(cfg config.CorrelationConfig, symbols []string) (*Correlation, error) {
+	sorted := append([]string(nil), symbols...)
+	sort.Strings(sorted)
+
+	c := &Correlation{
+		symbols: sorted,
+		index:   make(map[string]int, len(sorted)),
+		matrix:  make([][]float64, len(sorted)),
+	}
+	for i, symbol := range sorted {
+		c.index[symbol] = i
+	}
+
+	market := cfg.MarketLoading * cfg.MarketLoading
+	sector := cfg.SectorLoading * cfg.SectorLoading
+	for i, a := range sorted {
+		c.matrix[i] = make([]float64, len(sorted))
+		for j, b := range sorted {
+			switch {
+			case i == j:
+				c.matrix[i][j] = 1
+			case cfg.Sectors[a] != "" && cfg.Sectors[a] == cfg.Sectors[b]:
+				c.matrix[i][j] = market + sector
+			default:
+				c.matrix[i][j] = market
+			}
+		}
+	}
+
+	for pair, value := range cfg.Pairs {
+		a, b, _ := strings.Cut(pair, "/")
+		i, okA := c.index[a]
+		j, okB := c.index[b]
+		if !okA || !okB || i == j {
+			continue
+		}
+		c.matrix[i][j] = value
+		c.matrix[j][i] = value
+	}
+
+	cholesky, err := choleskyFactor(c.matrix)
+	if err != nil {
+		return nil, fmt.Errorf("correlation matrix of %s: %w", strings.Join(sorted, ", "), err)
+	}
+	c.cholesky = cholesky
+
+	return c, nil
+}
+
+// Symbols returns the correlated symbols in matrix order
+func (c *Correlation) Symbols() []string {
+	return c.symbols
+}
+
+// Coefficient returns the correlation of two symbols, 0 if either is not
+// part of the matrix
+func (c *Correlation) Coefficient(a, b string) float64 {
+	i, okA := c.index[a]
+	j, okB := c.index[b]
+	if !okA || !okB {
+		return 0
+	}
+	return c.matrix[i][j]
+}
+
+// Draw returns one vector of standard normal shocks correlated by the
+// matrix, indexed like Symbols
+func (c *Correlation) Draw(rng *rand.Rand) []float64 {
+	independent := make([]float64, len(c.symbols))
+	for i := range independent {
+		independent[i] = rng.NormFloat64()
+	}
+
+	shocks := make([]float64, len(c.symbols))
+	for i, row := range c.cholesky {
+		for j := 0; j <= i; j++ {
+			shocks[i] += row[j] * independent[j]
+		}
+	}
+	return shocks
+}
+
+// Propagate spreads shocks to a few symbols through the correlation
+// structure. Given the moves of the shocked symbols it returns the expected
+// move of every symbol, Σ_BA Σ_AA⁻¹ x, treating moves as comparable across
+// symbols. Shocked symbols keep their own moves, including symbols outside
+// the matrix, which don't spread.
+func (c *Correlation) Propagate(shocks map[string]float64) map[string]float64 {
+	var shocked []int
+	for symbol := range shocks {
+		if i, ok := c.index[symbol]; ok {
+			shocked = append(shocked, i)
+		}
+	}
+	sort.Ints(shocked)
+
+	moves := make(map[string]float64, len(c.symbols))
+	for symbol, move := range shocks {
+		moves[symbol] = move
+	}
+	if len(shocked) == 0 {
+		return moves
+	}
+
+	// Solve Σ_AA w = x; a small ridge keeps perfectly correlated shocked
+	// symbols solvable
+	sub := make([][]float64, len(shocked))
+	x := make([]float64, len(shocked))
+	for a, i := range shocked {
+		sub[a] = make([]float64, len(shocked))
+		for b, j := range shocked {
+			sub[a][b] = c.matrix[i][j]
+		}
+		sub[a][a] += 1e-9
+		x[a] = shocks[c.symbols[i]]
+	}
+	factor, err := choleskyFactor(sub)
+	if err != nil {
+		return moves
+	}
+	weights := choleskySolve(factor, x)
+
+	for i, symbol := range c.symbols {
+		if _, ok := shocks[symbol]; ok {
+			continue
+		}
+		for a, j := range shocked {
+			moves[symbol] += c.matrix[i][j] * weights[a]
+		}
+	}
+
+	return moves
+}
+
+// choleskyFactor returns the lower triangular L with L·Lᵀ = m
+func choleskyFactor(m [][]float64) ([][]float64, error) {
+	n := len(m)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			sum := m[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, fmt.Errorf("not positive definite")
+				}
+				l[i][i] = math.Sqrt(sum)
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
+
+// choleskySolve solves L·Lᵀ·x = b
+func choleskySolve(l [][]float64, b []float64) []float64 {
+	n := len(l)
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		sum := b[i]
+		for k := 0; k < i; k++ {
+			sum -= l[i][k] * y[k]
+		}
+		y[i] = sum / l[i][i]
+	}
+
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := y[i]
+		for k := i + 1; k < n; k++ {
+			sum -= l[k][i] * x[k]
+		}
+		x[i] = sum / l[i][i]
+	}
+	return x
+}
+
+// CorrelatedShocks hands out correlated shocks to generators that step one
+// symbol at a time. Each round draws one correlated vector; a symbol asking
+// for a second shock starts the next round, so symbols stepped on the same
+// cadence receive shocks from the same draw. It is not safe for concurrent
+// use; generators call it under their own lock.
+type CorrelatedShocks struct {
+	correlation *Correlation
+	current     []float64
+	used        []bool
+}
+
+// NewCorrelatedShocks creates the shock source of a correlation matrix
+func NewCorrelatedShocks(correlation *Correlation) *CorrelatedShocks {
+	return &CorrelatedShocks{
+		correlation: correlation,
+		used:        make([]bool, len(correlation.symbols)),
+	}
+}
+
+// Correlation returns the matrix the shocks are drawn from
+func (s *CorrelatedShocks) Correlation() *Correlation {
+	return s.correlation
+}
+
+// Next returns the symbol's shock of the current round. Symbols outside the
+// matrix get an independent shock.
+func (s *CorrelatedShocks) Next(rng *rand.Rand, symbol string) float64 {
+	i, ok := s.correlation.index[symbol]
+	if !ok {
+		return rng.NormFloat64()
+	}
+
+	if s.current == nil || s.used[i] {
+		s.current = s.correlation.Draw(rng)
+		for j := range s.used {
+			s.used[j] = false
+		}
+	}
+	s.used[i] = true
+	return s.current[i]
+}
+
+// RealizedCorrelation measures the correlation of symbols' log returns over
+// a rolling window of rounds. Like CorrelatedShocks, a symbol reporting a
+// second return starts the next round. It is not safe for concurrent use.
+type RealizedCorrelation struct {
+	symbols []string
+	index   map[string]int
+	window  int
+	rows    [][]float64
+	current []float64
+}
+
+// NewRealizedCorrelation creates a tracker of symbols over window rounds
+func NewRealizedCorrelation(symbols []string, window int) *RealizedCorrelation {
+	r := &RealizedCorrelation{
+		symbols: append([]string(nil), symbols...),
+		index:   make(map[string]int, len(symbols)),
+		window:  window,
+	}
+	sort.Strings(r.symbols)
+	for i, symbol := range r.symbols {
+		r.index[symbol] = i
+	}
+	return r
+}
+
+// Observe records a symbol's log return
+func (r *RealizedCorrelation) Observe(symbol string, logReturn float64) {
+	i, ok := r.index[symbol]
+	if !ok {
+		return
+	}
+
+	if r.current == nil || !math.IsNaN(r.current[i]) {
+		r.startRound()
+	}
+	r.current[i] = logReturn
+}
+
+func (r *RealizedCorrelation) startRound() {
+	if r.current != nil {
+		r.rows = append(r.rows, r.current)
+		if len(r.rows) > r.window {
+			r.rows = r.rows[len(r.rows)-r.window:]
+		}
+	}
+	r.current = make([]float64, len(r.symbols))
+	for i := range r.current {
+		r.current[i] = math.NaN()
+	}
+}
+
+// Matrix returns the pairwise correlations of completed rounds in which
+// both symbols moved, keyed by symbol. Pairs with fewer than two such
+// rounds, or a symbol that never moved, are left out.
+func (r *RealizedCorrelation) Matrix() map[string]map[string]float64 {
+	result := make(map[string]map[string]float64, len(r.symbols))
+	for i, a := range r.symbols {
+		for j := i + 1; j < len(r.symbols); j++ {
+			value, ok := r.pair(i, j)
+			if !ok {
+				continue
+			}
+			b := r.symbols[j]
+			if result[a] == nil {
+				result[a] = make(map[string]float64)
+			}
+			if result[b] == nil {
+				result[b] = make(map[string]float64)
+			}
+			result[a][b] = value
+			result[b][a] = value
+		}
+	}
+	return result
+}
+
+func (r *RealizedCorrelation) pair(i, j int) (float64, bool) {
+	var n, sumX, sumY, sumXX, sumYY, sumXY float64
+	for _, row := range r.rows {
+		x, y := row[i], row[j]
+		if math.IsNaN(x) || math.IsNaN(y) {
+			continue
+		}
+		n++
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumYY += y * y
+		sumXY += x * y
+	}
+	if n < 2 {
+		return 0, false
+	}
+
+	covariance := sumXY - sumX*sumY/n
+	varianceX := sumXX - sumX*sumX/n
+	varianceY := sumYY - sumY*sumY/n
+	if varianceX <= 0 || varianceY <= 0 {
+		return 0, false
+	}
+	return covariance / math.Sqrt(varianceX*varianceY), true
+}
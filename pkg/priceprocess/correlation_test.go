@@ -0,0 +1,123 @@
+package priceprocess
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"simulated_exchange/pkg/config"
+)
+
+var testCorrelationConfig = config.CorrelationConfig{
+	MarketLoading: 0.6,
+	SectorLoading: 0.5,
+	Sectors:       map[string]string{"AAPL": "tech", "MSFT": "tech", "XOM": "energy"},
+	Window:        500,
+}
+
+func TestNewCorrelation_FactorModel(t *testing.T) {
+	c, err := NewCorrelation(testCorrelationConfig, []string{"XOM", "MSFT", "AAPL"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"AAPL", "MSFT", "XOM"}, c.Symbols())
+	assert.InDelta(t, 0.36+0.25, c.Coefficient("AAPL", "MSFT"), 1e-12)
+	assert.InDelta(t, 0.36, c.Coefficient("AAPL", "XOM"), 1e-12)
+	assert.Equal(t, 1.0, c.Coefficient("XOM", "XOM"))
+	assert.Equal(t, 0.0, c.Coefficient("AAPL", "GOOG"))
+}
+
+func TestNewCorrelation_PairOverrides(t *testing.T) {
+	cfg := testCorrelationConfig
+	cfg.Pairs = map[string]float64{"XOM/AAPL": -0.2}
+
+	c, err := NewCorrelation(cfg, []string{"AAPL", "MSFT", "XOM"})
+	require.NoError(t, err)
+	assert.Equal(t, -0.2, c.Coefficient("AAPL", "XOM"))
+	assert.Equal(t, -0.2, c.Coefficient("XOM", "AAPL"))
+
+	// A and B move with C but against each other: no such market exists
+	cfg.Pairs = map[string]float64{"AAPL/MSFT": -0.9, "AAPL/XOM": 0.9, "MSFT/XOM": 0.9}
+	_, err = NewCorrelation(cfg, []string{"AAPL", "MSFT", "XOM"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not positive definite")
+}
+
+func TestCorrelatedShocks_MatchMatrix(t *testing.T) {
+	c, err := NewCorrelation(testCorrelationConfig, []string{"AAPL", "MSFT", "XOM"})
+	require.NoError(t, err)
+
+	shocks := NewCorrelatedShocks(c)
+	rng := rand.New(rand.NewSource(testSeed))
+	realized := NewRealizedCorrelation(c.Symbols(), 100000)
+	draws := map[string][]float64{}
+	for i := 0; i < 100000; i++ {
+		for _, symbol := range []string{"XOM", "AAPL", "MSFT"} {
+			shock := shocks.Next(rng, symbol)
+			draws[symbol] = append(draws[symbol], shock)
+			realized.Observe(symbol, shock)
+		}
+	}
+
+	for _, symbol := range c.Symbols() {
+		assert.InDelta(t, 1, variance(draws[symbol]), 0.02, symbol)
+	}
+	assert.InDelta(t, 0.61, correlation(draws["AAPL"], draws["MSFT"]), 0.01)
+	assert.InDelta(t, 0.36, correlation(draws["AAPL"], draws["XOM"]), 0.01)
+
+	matrix := realized.Matrix()
+	assert.InDelta(t, 0.61, matrix["AAPL"]["MSFT"], 0.01)
+	assert.InDelta(t, 0.36, matrix["XOM"]["MSFT"], 0.01)
+	assert.Equal(t, matrix["AAPL"]["XOM"], matrix["XOM"]["AAPL"])
+}
+
+func TestCorrelatedShocks_UnknownSymbolIsIndependent(t *testing.T) {
+	c, err := NewCorrelation(testCorrelationConfig, []string{"AAPL", "MSFT"})
+	require.NoError(t, err)
+
+	shocks := NewCorrelatedShocks(c)
+	rng := rand.New(rand.NewSource(testSeed))
+	var aapl, goog []float64
+	for i := 0; i < 50000; i++ {
+		aapl = append(aapl, shocks.Next(rng, "AAPL"))
+		goog = append(goog, shocks.Next(rng, "GOOG"))
+	}
+	assert.InDelta(t, 0, correlation(aapl, goog), 4/math.Sqrt(50000))
+}
+
+func TestCorrelation_Propagate(t *testing.T) {
+	c, err := NewCorrelation(testCorrelationConfig, []string{"AAPL", "MSFT", "XOM"})
+	require.NoError(t, err)
+
+	// A shock to one symbol moves the others by their correlation with it
+	moves := c.Propagate(map[string]float64{"AAPL": -0.1})
+	assert.Equal(t, -0.1, moves["AAPL"])
+	assert.InDelta(t, -0.061, moves["MSFT"], 1e-9)
+	assert.InDelta(t, -0.036, moves["XOM"], 1e-9)
+
+	// Two tech names crashing imply more for energy than one alone, but the
+	// shared sector factor is not double counted
+	moves = c.Propagate(map[string]float64{"AAPL": -0.1, "MSFT": -0.1})
+	assert.Less(t, moves["XOM"], -0.036)
+	assert.Greater(t, moves["XOM"], -0.072)
+
+	// Symbols outside the matrix keep their move without spreading
+	moves = c.Propagate(map[string]float64{"GOOG": 0.05})
+	assert.Equal(t, map[string]float64{"GOOG": 0.05}, moves)
+}
+
+func TestRealizedCorrelation_Rounds(t *testing.T) {
+	r := NewRealizedCorrelation([]string{"A", "B"}, 3)
+
+	// Rounds close when a symbol reports twice; the open round and rounds
+	// beyond the window are not counted
+	for _, move := range []float64{1, 2, 3, 4, 5} {
+		r.Observe("A", move)
+		r.Observe("B", -move)
+	}
+	assert.InDelta(t, -1, r.Matrix()["A"]["B"], 1e-12)
+	assert.Len(t, r.rows, 3)
+
+	assert.Empty(t, NewRealizedCorrelation([]string{"A", "B"}, 3).Matrix())
+}
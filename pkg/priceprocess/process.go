@@ -0,0 +1,313 @@
+package priceprocess
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"simulated_exchange/pkg/config"
+)
+
+// Year is the time unit of model parameters. Simulated markets trade around
+// the clock, so a year is 365 days.
+const Year = 365 * 24 * time.Hour
+
+// Process is a stochastic price process of one symbol. Processes with
+// state, such as stochastic variance, keep it between steps, so each symbol
+// needs its own process.
+type Process interface {
+	// Step advances the process by dt from price and returns the new price.
+	// shock is the standard normal shock driving the price, drawn from rng
+	// or from CorrelatedShocks so symbols move together; rng supplies any
+	// other randomness of the model. volScale multiplies the volatility of
+	// the step, e.g. during injected volatility events; 1 leaves it
+	// unchanged.
+	Step(rng *rand.Rand, shock, price float64, dt time.Duration, volScale float64) float64
+}
+
+// Model parameters and their defaults. Rates, volatilities and variances
+// are annualized; the defaults are in the range of large cap crypto assets.
+var modelParams = map[string]map[string]float64{
+	config.PriceModelGBM: {
+		"drift":      0,
+		"volatility": 0.6,
+	},
+	config.PriceModelMerton: {
+		"drift":           0,
+		"volatility":      0.5,
+		"jump_intensity":  25,
+		"jump_mean":       -0.01,
+		"jump_volatility": 0.04,
+	},
+	config.PriceModelHeston: {
+		"drift": 0,
+		"kappa": 4,
+		"theta": 0.36,
+		"xi":    0.9,
+		"rho":   -0.6,
+		"v0":    0.36,
+	},
+	config.PriceModelGARCH: {
+		"drift":      0,
+		"volatility": 0.6,
+		"alpha":      0.08,
+		"beta":       0.9,
+	},
+	config.PriceModelOU: {
+		"kappa":      6,
+		"volatility": 0.4,
+		"mean":       0,
+	},
+}
+
+// New creates the process of a price model. initialPrice is the mean the
+// Ornstein-Uhlenbeck model reverts to unless its mean is set.
+func New(model config.PriceModel, initialPrice float64) (Process, error) {
+	params, err := resolveParams(model)
+	if err != nil {
+		return nil, err
+	}
+
+	switch model.Name {
+	case config.PriceModelGBM:
+		return &GBM{Drift: params["drift"], Volatility: params["volatility"]}, nil
+	case config.PriceModelMerton:
+		return &MertonJumpDiffusion{
+			Drift:          params["drift"],
+			Volatility:     params["volatility"],
+			JumpIntensity:  params["jump_intensity"],
+			JumpMean:       params["jump_mean"],
+			JumpVolatility: params["jump_volatility"],
+		}, nil
+	case config.PriceModelHeston:
+		return &Heston{
+			Drift:    params["drift"],
+			Kappa:    params["kappa"],
+			Theta:    params["theta"],
+			Xi:       params["xi"],
+			Rho:      params["rho"],
+			Variance: params["v0"],
+		}, nil
+	case config.PriceModelGARCH:
+		return &GARCH{
+			Drift:      params["drift"],
+			Volatility: params["volatility"],
+			Alpha:      params["alpha"],
+			Beta:       params["beta"],
+			Variance:   params["volatility"] * params["volatility"],
+		}, nil
+	case config.PriceModelOU:
+		mean := params["mean"]
+		if mean == 0 {
+			mean = initialPrice
+		}
+		if mean <= 0 {
+			return nil, fmt.Errorf("ou: mean price must be positive")
+		}
+		return &OrnsteinUhlenbeck{Kappa: params["kappa"], Volatility: params["volatility"], Mean: mean}, nil
+	default:
+		return nil, fmt.Errorf("unknown price model %q", model.Name)
+	}
+}
+
+// Validate checks that a price model and its parameters are valid. The
+// builtin model has no process.
+func Validate(model config.PriceModel) error {
+	if model.Name == config.PriceModelBuiltin {
+		return nil
+	}
+	_, err := New(model, 1)
+	return err
+}
+
+// ValidateModels validates the default and every per-symbol price model
+func ValidateModels(models config.PriceModelsConfig) error {
+	if err := Validate(models.Default); err != nil {
+		return fmt.Errorf("invalid default price model: %w", err)
+	}
+	for symbol, model := range models.Symbols {
+		if err := Validate(model); err != nil {
+			return fmt.Errorf("invalid price model for %s: %w", symbol, err)
+		}
+	}
+	return nil
+}
+
+// resolveParams merges a model's parameters over its defaults and checks
+// them
+func resolveParams(model config.PriceModel) (map[string]float64, error) {
+	defaults, ok := modelParams[model.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown price model %q", model.Name)
+	}
+
+	params := make(map[string]float64, len(defaults))
+	for name, value := range defaults {
+		params[name] = value
+	}
+	for name, value := range model.Params {
+		if _, ok := defaults[name]; !ok {
+			known := make([]string, 0, len(defaults))
+			for name := range defaults {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("%s: unknown parameter %q, expected one of %s", model.Name, name, strings.Join(known, ", "))
+		}
+		params[name] = value
+	}
+
+	for _, name := range []string{"volatility", "jump_intensity", "jump_volatility", "kappa", "theta", "xi", "v0", "alpha", "beta", "mean"} {
+		if value, ok := params[name]; ok && value < 0 {
+			return nil, fmt.Errorf("%s: %s must not be negative", model.Name, name)
+		}
+	}
+	if rho, ok := params["rho"]; ok && (rho < -1 || rho > 1) {
+		return nil, fmt.Errorf("%s: rho must be between -1 and 1", model.Name)
+	}
+	if model.Name == config.PriceModelGARCH && params["alpha"]+params["beta"] >= 1 {
+		return nil, fmt.Errorf("%s: alpha + beta must be below 1", model.Name)
+	}
+	if model.Name == config.PriceModelOU && params["kappa"] == 0 {
+		return nil, fmt.Errorf("%s: kappa must be positive", model.Name)
+	}
+
+	return params, nil
+}
+
+// years converts a step to the time unit of model parameters
+func years(dt time.Duration) float64 {
+	return dt.Seconds() / Year.Seconds()
+}
+
+// GBM is geometric Brownian motion: log returns are normal with mean
+// (Drift - Volatility²/2)dt and variance Volatility²dt.
+type GBM struct {
+	Drift      float64
+	Volatility float64
+}
+
+// Step advances the price by dt
+func (p *GBM) Step(rng *rand.Rand, shock, price float64, dt time.Duration, volScale float64) float64 {
+	t := years(dt)
+	sigma := p.Volatility * volScale
+	return price * math.Exp((p.Drift-sigma*sigma/2)*t+sigma*math.Sqrt(t)*shock)
+}
+
+// MertonJumpDiffusion is geometric Brownian motion with jumps arriving at
+// JumpIntensity per year whose log sizes are normal with mean JumpMean and
+// standard deviation JumpVolatility. The drift is compensated so the
+// expected return is Drift.
+type MertonJumpDiffusion struct {
+	Drift          float64
+	Volatility     float64
+	JumpIntensity  float64
+	JumpMean       float64
+	JumpVolatility float64
+}
+
+// Step advances the price by dt
+func (p *MertonJumpDiffusion) Step(rng *rand.Rand, shock, price float64, dt time.Duration, volScale float64) float64 {
+	t := years(dt)
+	sigma := p.Volatility * volScale
+	compensation := p.JumpIntensity * (math.Exp(p.JumpMean+p.JumpVolatility*p.JumpVolatility/2) - 1)
+
+	logReturn := (p.Drift-sigma*sigma/2-compensation)*t + sigma*math.Sqrt(t)*shock
+	if jumps := poisson(rng, p.JumpIntensity*t); jumps > 0 {
+		n := float64(jumps)
+		logReturn += n*p.JumpMean + math.Sqrt(n)*p.JumpVolatility*rng.NormFloat64()
+	}
+
+	return price * math.Exp(logReturn)
+}
+
+// Heston has a stochastic variance that reverts to Theta at speed Kappa
+// with volatility Xi, correlated by Rho with price shocks. Variance is the
+// current variance and is advanced with a full truncation Euler scheme.
+type Heston struct {
+	Drift    float64
+	Kappa    float64
+	Theta    float64
+	Xi       float64
+	Rho      float64
+	Variance float64
+}
+
+// Step advances the price and variance by dt
+func (p *Heston) Step(rng *rand.Rand, shock, price float64, dt time.Duration, volScale float64) float64 {
+	t := years(dt)
+	variance := math.Max(p.Variance, 0)
+	z1 := shock
+	z2 := p.Rho*z1 + math.Sqrt(1-p.Rho*p.Rho)*rng.NormFloat64()
+
+	priceVariance := variance * volScale * volScale
+	next := price * math.Exp((p.Drift-priceVariance/2)*t+math.Sqrt(priceVariance*t)*z1)
+
+	p.Variance += p.Kappa*(p.Theta-variance)*t + p.Xi*math.Sqrt(variance*t)*z2
+	return next
+}
+
+// GARCH is a GARCH(1,1) process: each step's shock feeds the next step's
+// variance, so large moves cluster. Variance is the current annualized
+// variance and reverts to Volatility² at a rate set by Alpha + Beta.
+type GARCH struct {
+	Drift      float64
+	Volatility float64
+	Alpha      float64
+	Beta       float64
+	Variance   float64
+}
+
+// Step advances the price and variance by dt
+func (p *GARCH) Step(rng *rand.Rand, shock, price float64, dt time.Duration, volScale float64) float64 {
+	t := years(dt)
+	if t <= 0 {
+		return price
+	}
+
+	variance := p.Variance
+	innovation := math.Sqrt(variance*t) * volScale * shock
+	omega := p.Volatility * p.Volatility * (1 - p.Alpha - p.Beta)
+	p.Variance = omega + p.Alpha*innovation*innovation/t + p.Beta*variance
+
+	return price * math.Exp((p.Drift-variance/2)*t+innovation)
+}
+
+// OrnsteinUhlenbeck is a mean-reverting process on the log price: the log
+// price reverts to log(Mean) at speed Kappa with volatility Volatility,
+// using the exact discretization so any step length is stable.
+type OrnsteinUhlenbeck struct {
+	Kappa      float64
+	Volatility float64
+	Mean       float64
+}
+
+// Step advances the price by dt
+func (p *OrnsteinUhlenbeck) Step(rng *rand.Rand, shock, price float64, dt time.Duration, volScale float64) float64 {
+	t := years(dt)
+	mean := math.Log(p.Mean)
+	decay := math.Exp(-p.Kappa * t)
+	stdDev := p.Volatility * volScale * math.Sqrt((1-decay*decay)/(2*p.Kappa))
+
+	return math.Exp(mean + (math.Log(price)-mean)*decay + stdDev*shock)
+}
+
+// poisson draws a Poisson distributed count with the given mean
+func poisson(rng *rand.Rand, mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	if mean > 30 {
+		return int(math.Max(0, math.Round(mean+math.Sqrt(mean)*rng.NormFloat64())))
+	}
+
+	limit := math.Exp(-mean)
+	count := 0
+	for product := rng.Float64(); product > limit; product *= rng.Float64() {
+		count++
+	}
+	return count
+}
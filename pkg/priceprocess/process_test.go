@@ -0,0 +1,228 @@
+package priceprocess
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"simulated_exchange/pkg/config"
+)
+
+const (
+	testSeed  = 42
+	testSteps = 200000
+	testStep  = time.Hour
+)
+
+// simulate steps a process from price 100 and returns its log returns and
+// log prices
+func simulate(t *testing.T, model config.PriceModel) (returns, logPrices []float64) {
+	t.Helper()
+
+	process, err := New(model, 100)
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(testSeed))
+	price := 100.0
+	returns = make([]float64, testSteps)
+	logPrices = make([]float64, testSteps)
+	for i := range returns {
+		next := process.Step(rng, rng.NormFloat64(), price, testStep, 1)
+		require.False(t, math.IsNaN(next) || next <= 0, "step %d produced price %v", i, next)
+		returns[i] = math.Log(next / price)
+		logPrices[i] = math.Log(next)
+		price = next
+	}
+	return returns, logPrices
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func variance(values []float64) float64 {
+	m := mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(values)-1)
+}
+
+// excessKurtosis is zero for normal returns and positive for fat tails
+func excessKurtosis(values []float64) float64 {
+	m := mean(values)
+	v := variance(values)
+	sum := 0.0
+	for _, x := range values {
+		sum += math.Pow(x-m, 4)
+	}
+	return sum/float64(len(values))/(v*v) - 3
+}
+
+func autocorrelation(values []float64, lag int) float64 {
+	m := mean(values)
+	v := variance(values)
+	sum := 0.0
+	for i := lag; i < len(values); i++ {
+		sum += (values[i] - m) * (values[i-lag] - m)
+	}
+	return sum / float64(len(values)-lag) / v
+}
+
+// squared returns the squared values; their autocorrelation measures
+// volatility clustering
+func squared(values []float64) []float64 {
+	result := make([]float64, len(values))
+	for i, v := range values {
+		result[i] = v * v
+	}
+	return result
+}
+
+var dt = years(testStep)
+
+// correlationBound is about four standard errors of a sample
+// autocorrelation of independent values
+var correlationBound = 4 / math.Sqrt(testSteps)
+
+func TestGBM_Moments(t *testing.T) {
+	returns, _ := simulate(t, config.PriceModel{Name: config.PriceModelGBM, Params: map[string]float64{"drift": 0.1, "volatility": 0.5}})
+
+	expectedVariance := 0.25 * dt
+	assert.InDelta(t, (0.1-0.125)*dt, mean(returns), 4*math.Sqrt(expectedVariance/testSteps))
+	assert.InEpsilon(t, expectedVariance, variance(returns), 0.02)
+	assert.InDelta(t, 0, excessKurtosis(returns), 0.1)
+
+	// Independent increments: no volatility clustering
+	assert.InDelta(t, 0, autocorrelation(squared(returns), 1), correlationBound)
+}
+
+func TestMertonJumpDiffusion_Moments(t *testing.T) {
+	params := map[string]float64{"volatility": 0.4, "jump_intensity": 100, "jump_mean": -0.02, "jump_volatility": 0.05}
+	returns, _ := simulate(t, config.PriceModel{Name: config.PriceModelMerton, Params: params})
+
+	// Diffusion variance plus jump variance λ(μJ² + σJ²)
+	expectedVariance := (0.16 + 100*(0.0004+0.0025)) * dt
+	assert.InEpsilon(t, expectedVariance, variance(returns), 0.05)
+
+	// Rare jumps make the tails fat
+	assert.Greater(t, excessKurtosis(returns), 5.0)
+	assert.InDelta(t, 0, autocorrelation(squared(returns), 1), correlationBound)
+}
+
+func TestHeston_MomentsAndClustering(t *testing.T) {
+	params := map[string]float64{"kappa": 4, "theta": 0.36, "xi": 0.9, "rho": -0.6, "v0": 0.36}
+	returns, _ := simulate(t, config.PriceModel{Name: config.PriceModelHeston, Params: params})
+
+	// The variance averages theta, so returns do too once it is spread over
+	// many mean reversion periods
+	assert.InEpsilon(t, 0.36*dt, variance(returns), 0.1)
+
+	// Persistent stochastic variance clusters large moves and fattens tails
+	assert.Greater(t, autocorrelation(squared(returns), 1), correlationBound)
+	assert.Greater(t, excessKurtosis(returns), 0.5)
+
+	// Negative rho: falling prices coincide with rising variance
+	next := make([]float64, len(returns)-1)
+	for i := range next {
+		next[i] = returns[i+1] * returns[i+1]
+	}
+	assert.Less(t, correlation(returns[:len(returns)-1], next), 0.0)
+}
+
+func TestGARCH_MomentsAndClustering(t *testing.T) {
+	params := map[string]float64{"volatility": 0.6, "alpha": 0.1, "beta": 0.85}
+	returns, _ := simulate(t, config.PriceModel{Name: config.PriceModelGARCH, Params: params})
+
+	// Unconditional variance is the long run variance
+	assert.InEpsilon(t, 0.36*dt, variance(returns), 0.05)
+
+	// Squared returns autocorrelate and decay at rate alpha + beta
+	lag1 := autocorrelation(squared(returns), 1)
+	lag10 := autocorrelation(squared(returns), 10)
+	assert.Greater(t, lag1, 0.1)
+	assert.Greater(t, lag10, correlationBound)
+	assert.Less(t, lag10, lag1)
+
+	// GARCH(1,1) kurtosis is 3(1-(α+β)²)/(1-(α+β)²-2α²) for normal shocks
+	persistence := 0.95
+	expectedKurtosis := 3*(1-persistence*persistence)/(1-persistence*persistence-2*0.01) - 3
+	assert.InEpsilon(t, expectedKurtosis, excessKurtosis(returns), 0.25)
+
+	// Returns themselves stay uncorrelated
+	assert.InDelta(t, 0, autocorrelation(returns, 1), correlationBound)
+}
+
+func TestOrnsteinUhlenbeck_MeanReversion(t *testing.T) {
+	params := map[string]float64{"kappa": 6, "volatility": 0.4, "mean": 100}
+	_, logPrices := simulate(t, config.PriceModel{Name: config.PriceModelOU, Params: params})
+
+	// Stationary log price: mean log(Mean), variance σ²/2κ
+	assert.InDelta(t, math.Log(100), mean(logPrices), 0.01)
+	assert.InEpsilon(t, 0.16/12, variance(logPrices), 0.1)
+
+	// Deviations decay by exp(-κ dt) per step
+	assert.InDelta(t, math.Exp(-6*dt), autocorrelation(logPrices, 1), 0.001)
+}
+
+func TestNew_DefaultsAreValid(t *testing.T) {
+	for name := range modelParams {
+		t.Run(name, func(t *testing.T) {
+			simulate(t, config.PriceModel{Name: name})
+		})
+	}
+}
+
+func TestNew_FixedSeedIsReproducible(t *testing.T) {
+	model := config.PriceModel{Name: config.PriceModelHeston}
+	first, _ := simulate(t, model)
+	second, _ := simulate(t, model)
+	assert.Equal(t, first, second)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   config.PriceModel
+		wantErr string
+	}{
+		{"builtin", config.PriceModel{Name: config.PriceModelBuiltin}, ""},
+		{"overrides", config.PriceModel{Name: config.PriceModelGBM, Params: map[string]float64{"volatility": 0.8}}, ""},
+		{"unknown model", config.PriceModel{Name: "brownian"}, "unknown price model"},
+		{"unknown parameter", config.PriceModel{Name: config.PriceModelGBM, Params: map[string]float64{"kappa": 1}}, "unknown parameter"},
+		{"negative volatility", config.PriceModel{Name: config.PriceModelGBM, Params: map[string]float64{"volatility": -1}}, "must not be negative"},
+		{"explosive garch", config.PriceModel{Name: config.PriceModelGARCH, Params: map[string]float64{"alpha": 0.2, "beta": 0.8}}, "alpha + beta"},
+		{"rho out of range", config.PriceModel{Name: config.PriceModelHeston, Params: map[string]float64{"rho": -1.5}}, "rho"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.model)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func correlation(x, y []float64) float64 {
+	mx, my := mean(x), mean(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		sxy += (x[i] - mx) * (y[i] - my)
+		sxx += (x[i] - mx) * (x[i] - mx)
+		syy += (y[i] - my) * (y[i] - my)
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
@@ -0,0 +1,457 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/jmoiron/sqlx"
+	"simulated_exchange/pkg/ledger"
+	"simulated_exchange/pkg/shared"
+)
+
+// PostgresAccountRepository implements shared.AccountRepository using PostgreSQL
+type PostgresAccountRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresAccountRepository creates a new PostgreSQL account repository
+func NewPostgresAccountRepository(db *sqlx.DB) *PostgresAccountRepository {
+	return &PostgresAccountRepository{db: db}
+}
+
+// OpenAccount creates an account with the initial cash balance if the user
+// has none yet, and returns the user's account. The initial cash is recorded
+// in the ledger as a deposit.
+func (r *PostgresAccountRepository) OpenAccount(ctx context.Context, userID string, initialCash float64) (*shared.Account, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin account transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO trading.accounts (user_id, cash_balance, cash_reserved, created_at, updated_at)
+		VALUES ($1, $2, 0, NOW(), NOW())
+		ON CONFLICT (user_id) DO NOTHING`
+
+	result, err := tx.ExecContext(ctx, query, userID, initialCash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open account: %w", err)
+	}
+
+	opened, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if opened > 0 && initialCash > 0 {
+		if err := postJournal(ctx, tx, ledger.DepositJournal(userID, ledger.CashAsset, initialCash, userID)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit account: %w", err)
+	}
+
+	return r.GetAccount(ctx, userID)
+}
+
+// Deposit credits cash to a user's account. Returns
+// shared.ErrAccountNotFound if the user has no account.
+func (r *PostgresAccountRepository) Deposit(ctx context.Context, userID string, amount float64, reference string) (*shared.Account, error) {
+	return r.moveCash(ctx, ledger.DepositJournal(userID, ledger.CashAsset, amount, reference), `
+		UPDATE trading.accounts
+		SET cash_balance = cash_balance + $2, updated_at = NOW()
+		WHERE user_id = $1`, userID, amount, shared.ErrAccountNotFound)
+}
+
+// Withdraw debits cash from a user's account. Returns
+// shared.ErrInsufficientBalance if the amount exceeds the unreserved cash or
+// the user has no account.
+func (r *PostgresAccountRepository) Withdraw(ctx context.Context, userID string, amount float64, reference string) (*shared.Account, error) {
+	return r.moveCash(ctx, ledger.WithdrawalJournal(userID, ledger.CashAsset, amount, reference), `
+		UPDATE trading.accounts
+		SET cash_balance = cash_balance - $2, updated_at = NOW()
+		WHERE user_id = $1 AND cash_balance - cash_reserved >= $2`, userID, amount, shared.ErrInsufficientBalance)
+}
+
+// moveCash applies a cash update and posts its journal in one transaction.
+// Returns noRowsErr if the update matched no account.
+func (r *PostgresAccountRepository) moveCash(ctx context.Context, journal *ledger.Journal, query, userID string, amount float64, noRowsErr error) (*shared.Account, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin cash transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, userID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update cash balance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, noRowsErr
+	}
+
+	if err := postJournal(ctx, tx, journal); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit cash movement: %w", err)
+	}
+
+	return r.GetAccount(ctx, userID)
+}
+
+// GetAccount retrieves a user's account
+func (r *PostgresAccountRepository) GetAccount(ctx context.Context, userID string) (*shared.Account, error) {
+	query := `
+		SELECT user_id, cash_balance, cash_reserved, created_at, updated_at
+		FROM trading.accounts
+		WHERE user_id = $1`
+
+	var account shared.Account
+	err := r.db.GetContext(ctx, &account, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetPositions retrieves all positions held by a user
+func (r *PostgresAccountRepository) GetPositions(ctx context.Context, userID string) ([]*shared.Position, error) {
+	query := `
+		SELECT user_id, symbol, quantity, reserved_quantity, average_price, updated_at
+		FROM trading.positions
+		WHERE user_id = $1
+		ORDER BY symbol`
+
+	var positions []shared.Position
+	err := r.db.SelectContext(ctx, &positions, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	// Convert to slice of pointers
+	result := make([]*shared.Position, len(positions))
+	for i := range positions {
+		result[i] = &positions[i]
+	}
+
+	return result, nil
+}
+
+// Reserve holds cash (buy) or shares (sell) for an order. The user's
+// position in the symbol is opened with initialPosition shares if it doesn't
+// exist yet, recorded in the ledger as a deposit. Returns
+// shared.ErrInsufficientBalance or shared.ErrInsufficientPosition if the user
+// can't cover the order.
+func (r *PostgresAccountRepository) Reserve(ctx context.Context, reservation *shared.Reservation, initialPosition float64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin reservation transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	seedQuery := `
+		INSERT INTO trading.positions (user_id, symbol, quantity, reserved_quantity, average_price, updated_at)
+		VALUES ($1, $2, $3, 0, 0, NOW())
+		ON CONFLICT (user_id, symbol) DO NOTHING`
+
+	seeded, err := tx.ExecContext(ctx, seedQuery, reservation.UserID, reservation.Symbol, initialPosition)
+	if err != nil {
+		return fmt.Errorf("failed to open position: %w", err)
+	}
+	if opened, err := seeded.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if opened > 0 && initialPosition > 0 {
+		journal := ledger.DepositJournal(reservation.UserID, reservation.Symbol, initialPosition, reservation.UserID)
+		if err := postJournal(ctx, tx, journal); err != nil {
+			return err
+		}
+	}
+
+	var result sql.Result
+	if reservation.Side == shared.OrderSideBuy {
+		result, err = tx.ExecContext(ctx, `
+			UPDATE trading.accounts
+			SET cash_reserved = cash_reserved + $2, updated_at = NOW()
+			WHERE user_id = $1 AND cash_balance - cash_reserved >= $2`,
+			reservation.UserID, reservation.Amount())
+	} else {
+		result, err = tx.ExecContext(ctx, `
+			UPDATE trading.positions
+			SET reserved_quantity = reserved_quantity + $3, updated_at = NOW()
+			WHERE user_id = $1 AND symbol = $2 AND quantity - reserved_quantity >= $3`,
+			reservation.UserID, reservation.Symbol, reservation.Amount())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reserve funds: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		if reservation.Side == shared.OrderSideBuy {
+			return shared.ErrInsufficientBalance
+		}
+		return shared.ErrInsufficientPosition
+	}
+
+	insertQuery := `
+		INSERT INTO trading.reservations (order_id, user_id, symbol, side, price, remaining, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.ExecContext(ctx, insertQuery,
+		reservation.OrderID, reservation.UserID, reservation.Symbol, reservation.Side,
+		reservation.Price, reservation.Remaining, reservation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reservation: %w", err)
+	}
+
+	return nil
+}
+
+// Release returns whatever is still reserved for an order to the user and
+// removes the reservation. It returns nil without error if the order holds
+// no reservation.
+func (r *PostgresAccountRepository) Release(ctx context.Context, orderID string) (*shared.Reservation, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin release transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM trading.reservations
+		WHERE order_id = $1
+		RETURNING order_id, user_id, symbol, side, price, remaining, created_at`
+
+	var reservation shared.Reservation
+	if err := tx.GetContext(ctx, &reservation, query, orderID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to delete reservation: %w", err)
+	}
+
+	if err := unreserve(ctx, tx, &reservation, reservation.Amount()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit release: %w", err)
+	}
+
+	return &reservation, nil
+}
+
+// settleTrade moves cash and shares between buyer and seller for an
+// executed trade, consumes the matching part of both orders' reservations
+// and posts the trade and its fees to the ledger, within the transaction
+// that stores the trade. Returns shared.ErrInsufficientBalance if the buyer
+// can't pay, e.g. for a market buy filled beyond its reserved cash.
+func settleTrade(ctx context.Context, tx *sqlx.Tx, trade *shared.Trade, settlement *shared.TradeSettlement) error {
+	buyerID, sellerID := settlement.BuyerID, settlement.SellerID
+
+	// Lock rows in a consistent user order to avoid deadlocks between
+	// concurrent settlements of the same two users
+	legs := []func() error{
+		func() error { return settleBuy(ctx, tx, trade, buyerID) },
+		func() error { return settleSell(ctx, tx, trade, sellerID) },
+	}
+	if sellerID < buyerID {
+		legs[0], legs[1] = legs[1], legs[0]
+	}
+
+	for _, leg := range legs {
+		if err := leg(); err != nil {
+			return err
+		}
+	}
+
+	if err := postJournal(ctx, tx, ledger.TradeJournal(trade, buyerID, sellerID)); err != nil {
+		return err
+	}
+	return postJournal(ctx, tx, ledger.FeeJournal(trade, buyerID, sellerID))
+}
+
+// settleBuy debits the trade value and buy fee from the buyer and credits
+// the shares
+func settleBuy(ctx context.Context, tx *sqlx.Tx, trade *shared.Trade, buyerID string) error {
+	if err := consumeReservation(ctx, tx, trade.BuyOrderID, trade.Quantity); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE trading.accounts
+		SET cash_balance = cash_balance - $2, updated_at = NOW()
+		WHERE user_id = $1 AND cash_balance >= $2`,
+		buyerID, trade.Value()+trade.BuyFee)
+	if err != nil {
+		return fmt.Errorf("failed to debit buyer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return shared.ErrInsufficientBalance
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO trading.positions (user_id, symbol, quantity, reserved_quantity, average_price, updated_at)
+		VALUES ($1, $2, $3, 0, $4, NOW())
+		ON CONFLICT (user_id, symbol) DO UPDATE SET
+			average_price = CASE
+				WHEN positions.quantity + EXCLUDED.quantity > 0
+				THEN (positions.quantity * positions.average_price + EXCLUDED.quantity * EXCLUDED.average_price) / (positions.quantity + EXCLUDED.quantity)
+				ELSE EXCLUDED.average_price
+			END,
+			quantity = positions.quantity + EXCLUDED.quantity,
+			updated_at = NOW()`,
+		buyerID, trade.Symbol, trade.Quantity, trade.Price)
+	if err != nil {
+		return fmt.Errorf("failed to credit buyer position: %w", err)
+	}
+
+	return nil
+}
+
+// settleSell debits the shares from the seller and credits the trade value
+// less the sell fee
+func settleSell(ctx context.Context, tx *sqlx.Tx, trade *shared.Trade, sellerID string) error {
+	if err := consumeReservation(ctx, tx, trade.SellOrderID, trade.Quantity); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE trading.positions
+		SET quantity = quantity - $3, updated_at = NOW()
+		WHERE user_id = $1 AND symbol = $2`,
+		sellerID, trade.Symbol, trade.Quantity)
+	if err != nil {
+		return fmt.Errorf("failed to debit seller position: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE trading.accounts
+		SET cash_balance = cash_balance + $2, updated_at = NOW()
+		WHERE user_id = $1`,
+		sellerID, trade.Value()-trade.SellFee)
+	if err != nil {
+		return fmt.Errorf("failed to credit seller: %w", err)
+	}
+
+	return nil
+}
+
+// consumeReservation releases the filled quantity of an order's reservation.
+// Orders without a reservation are ignored.
+func consumeReservation(ctx context.Context, tx *sqlx.Tx, orderID string, quantity float64) error {
+	query := `
+		SELECT order_id, user_id, symbol, side, price, remaining, created_at
+		FROM trading.reservations
+		WHERE order_id = $1
+		FOR UPDATE`
+
+	var reservation shared.Reservation
+	if err := tx.GetContext(ctx, &reservation, query, orderID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	consumed := math.Min(quantity, reservation.Remaining)
+	released := consumed
+	if reservation.Side == shared.OrderSideBuy {
+		released = consumed * reservation.Price
+	}
+
+	if err := unreserve(ctx, tx, &reservation, released); err != nil {
+		return err
+	}
+
+	if reservation.Remaining-consumed <= 0 {
+		_, err := tx.ExecContext(ctx, `DELETE FROM trading.reservations WHERE order_id = $1`, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to delete reservation: %w", err)
+		}
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE trading.reservations
+		SET remaining = remaining - $2
+		WHERE order_id = $1`,
+		orderID, consumed)
+	if err != nil {
+		return fmt.Errorf("failed to update reservation: %w", err)
+	}
+
+	return nil
+}
+
+// shrinkReservation releases the part of an order's reservation above
+// quantity. Orders without a reservation are ignored.
+func shrinkReservation(ctx context.Context, tx *sqlx.Tx, orderID string, quantity float64) error {
+	var remaining float64
+	err := tx.GetContext(ctx, &remaining, `
+		SELECT remaining FROM trading.reservations
+		WHERE order_id = $1
+		FOR UPDATE`,
+		orderID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	if remaining <= quantity {
+		return nil
+	}
+	return consumeReservation(ctx, tx, orderID, remaining-quantity)
+}
+
+// unreserve returns amount of reserved cash (buy) or shares (sell) to the user
+func unreserve(ctx context.Context, tx *sqlx.Tx, reservation *shared.Reservation, amount float64) error {
+	var err error
+	if reservation.Side == shared.OrderSideBuy {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE trading.accounts
+			SET cash_reserved = GREATEST(cash_reserved - $2, 0), updated_at = NOW()
+			WHERE user_id = $1`,
+			reservation.UserID, amount)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE trading.positions
+			SET reserved_quantity = GREATEST(reserved_quantity - $3, 0), updated_at = NOW()
+			WHERE user_id = $1 AND symbol = $2`,
+			reservation.UserID, reservation.Symbol, amount)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release reservation: %w", err)
+	}
+
+	return nil
+}
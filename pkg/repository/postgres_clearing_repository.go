@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"simulated_exchange/pkg/shared"
+)
+
+const obligationColumns = `
+		id, trade_date, settlement_date, user_id, symbol, net_quantity, net_cash,
+		gross_buy_quantity, gross_sell_quantity, gross_notional, trade_count,
+		status, fail_count, COALESCE(fail_reason, '') AS fail_reason, created_at, settled_at`
+
+// PostgresClearingRepository implements shared.ClearingRepository using PostgreSQL
+type PostgresClearingRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresClearingRepository creates a new PostgreSQL clearing repository
+func NewPostgresClearingRepository(db *sqlx.DB) *PostgresClearingRepository {
+	return &PostgresClearingRepository{db: db}
+}
+
+// NetTrades nets the trades of a UTC trade date, fees included, into one
+// obligation per user and symbol, and records the clearing run. Re-running a
+// trade date picks up late trades for obligations that haven't started
+// settling.
+func (r *PostgresClearingRepository) NetTrades(ctx context.Context, tradeDate, settlementDate time.Time) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO trading.settlement_obligations (
+			trade_date, settlement_date, user_id, symbol, net_quantity, net_cash,
+			gross_buy_quantity, gross_sell_quantity, gross_notional, trade_count,
+			status, created_at, updated_at
+		)
+		SELECT $1::date, $2::date, legs.user_id, legs.symbol,
+			SUM(legs.quantity), SUM(legs.cash),
+			SUM(GREATEST(legs.quantity, 0)), SUM(GREATEST(-legs.quantity, 0)),
+			SUM(legs.notional), COUNT(*),
+			'PENDING', NOW(), NOW()
+		FROM (
+			SELECT o.user_id, t.symbol, t.quantity,
+				-t.quantity * t.price - t.buy_fee AS cash, t.quantity * t.price AS notional
+			FROM trading.trades t
+			JOIN trading.orders o ON o.id = t.buy_order_id
+			WHERE t.created_at >= $3 AND t.created_at < $4
+			UNION ALL
+			SELECT o.user_id, t.symbol, -t.quantity,
+				t.quantity * t.price - t.sell_fee, t.quantity * t.price
+			FROM trading.trades t
+			JOIN trading.orders o ON o.id = t.sell_order_id
+			WHERE t.created_at >= $3 AND t.created_at < $4
+		) legs
+		GROUP BY legs.user_id, legs.symbol
+		ON CONFLICT (trade_date, user_id, symbol) DO UPDATE SET
+			net_quantity = EXCLUDED.net_quantity,
+			net_cash = EXCLUDED.net_cash,
+			gross_buy_quantity = EXCLUDED.gross_buy_quantity,
+			gross_sell_quantity = EXCLUDED.gross_sell_quantity,
+			gross_notional = EXCLUDED.gross_notional,
+			trade_count = EXCLUDED.trade_count,
+			updated_at = NOW()
+		WHERE trading.settlement_obligations.status = 'PENDING'
+			AND trading.settlement_obligations.fail_count = 0`
+
+	start := tradeDate.UTC().Truncate(24 * time.Hour)
+	if _, err := tx.ExecContext(ctx, query, start, settlementDate, start, start.Add(24*time.Hour)); err != nil {
+		return 0, fmt.Errorf("failed to net trades: %w", err)
+	}
+
+	var obligations int
+	err = tx.GetContext(ctx, &obligations,
+		`SELECT COUNT(*) FROM trading.settlement_obligations WHERE trade_date = $1::date`, start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count obligations: %w", err)
+	}
+
+	runQuery := `
+		INSERT INTO trading.clearing_runs (trade_date, settlement_date, obligations, cleared_at)
+		VALUES ($1::date, $2::date, $3, NOW())
+		ON CONFLICT (trade_date) DO UPDATE SET
+			obligations = EXCLUDED.obligations,
+			cleared_at = EXCLUDED.cleared_at`
+
+	if _, err := tx.ExecContext(ctx, runQuery, start, settlementDate, obligations); err != nil {
+		return 0, fmt.Errorf("failed to record clearing run: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit clearing run: %w", err)
+	}
+
+	return obligations, nil
+}
+
+// GetUnclearedTradeDates returns the UTC trade dates before the given time
+// that have trades but no clearing run, oldest first
+func (r *PostgresClearingRepository) GetUnclearedTradeDates(ctx context.Context, before time.Time) ([]time.Time, error) {
+	query := `
+		SELECT DISTINCT (t.created_at AT TIME ZONE 'UTC')::date AS trade_date
+		FROM trading.trades t
+		WHERE t.created_at < $1
+			AND NOT EXISTS (
+				SELECT 1 FROM trading.clearing_runs cr
+				WHERE cr.trade_date = (t.created_at AT TIME ZONE 'UTC')::date
+			)
+		ORDER BY trade_date`
+
+	var dates []time.Time
+	if err := r.db.SelectContext(ctx, &dates, query, before); err != nil {
+		return nil, fmt.Errorf("failed to get uncleared trade dates: %w", err)
+	}
+
+	return dates, nil
+}
+
+// GetDueObligations returns unsettled obligations whose settlement date has
+// been reached, oldest first
+func (r *PostgresClearingRepository) GetDueObligations(ctx context.Context, asOf time.Time, limit int) ([]*shared.SettlementObligation, error) {
+	query := `
+		SELECT ` + obligationColumns + `
+		FROM trading.settlement_obligations
+		WHERE status <> 'SETTLED' AND settlement_date <= $1::date
+		ORDER BY settlement_date, trade_date, id
+		LIMIT $2`
+
+	return r.selectObligations(ctx, query, asOf.UTC(), limit)
+}
+
+// GetObligations returns every obligation of a trade date
+func (r *PostgresClearingRepository) GetObligations(ctx context.Context, tradeDate time.Time) ([]*shared.SettlementObligation, error) {
+	query := `
+		SELECT ` + obligationColumns + `
+		FROM trading.settlement_obligations
+		WHERE trade_date = $1::date
+		ORDER BY symbol, user_id`
+
+	return r.selectObligations(ctx, query, tradeDate.UTC())
+}
+
+// MarkSettled marks an obligation as settled
+func (r *PostgresClearingRepository) MarkSettled(ctx context.Context, id string, settledAt time.Time) error {
+	query := `
+		UPDATE trading.settlement_obligations
+		SET status = 'SETTLED', settled_at = $2, updated_at = NOW()
+		WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, settledAt); err != nil {
+		return fmt.Errorf("failed to mark obligation settled: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed settlement attempt
+func (r *PostgresClearingRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	query := `
+		UPDATE trading.settlement_obligations
+		SET status = 'FAILED', fail_reason = $2, fail_count = fail_count + 1, updated_at = NOW()
+		WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("failed to mark obligation failed: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresClearingRepository) selectObligations(ctx context.Context, query string, args ...interface{}) ([]*shared.SettlementObligation, error) {
+	var obligations []shared.SettlementObligation
+	if err := r.db.SelectContext(ctx, &obligations, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get settlement obligations: %w", err)
+	}
+
+	result := make([]*shared.SettlementObligation, len(obligations))
+	for i := range obligations {
+		result[i] = &obligations[i]
+	}
+
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"simulated_exchange/pkg/ledger"
+)
+
+// PostgresLedgerRepository implements ledger.Store using PostgreSQL and
+// provides read access to journals and entries. Journals are written by the
+// account repository in the same transaction as the balance change they
+// record.
+type PostgresLedgerRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresLedgerRepository creates a new PostgreSQL ledger repository
+func NewPostgresLedgerRepository(db *sqlx.DB) *PostgresLedgerRepository {
+	return &PostgresLedgerRepository{db: db}
+}
+
+// GetJournalsByReference retrieves the journals recorded for a business
+// reference, such as a trade ID, with their entries
+func (r *PostgresLedgerRepository) GetJournalsByReference(ctx context.Context, reference string) ([]*ledger.Journal, error) {
+	query := `
+		SELECT id, journal_type, reference, COALESCE(description, '') AS description, created_at
+		FROM trading.ledger_journals
+		WHERE reference = $1
+		ORDER BY created_at, id`
+
+	var journals []ledger.Journal
+	if err := r.db.SelectContext(ctx, &journals, query, reference); err != nil {
+		return nil, fmt.Errorf("failed to get journals: %w", err)
+	}
+
+	result := make([]*ledger.Journal, len(journals))
+	for i := range journals {
+		entries, err := r.getJournalEntries(ctx, journals[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		journals[i].Entries = entries
+		result[i] = &journals[i]
+	}
+
+	return result, nil
+}
+
+// GetAccountEntries retrieves the most recent entries posted to an account,
+// newest first
+func (r *PostgresLedgerRepository) GetAccountEntries(ctx context.Context, account string, limit int) ([]*ledger.Entry, error) {
+	query := `
+		SELECT id, journal_id, account, asset, amount, created_at
+		FROM trading.ledger_entries
+		WHERE account = $1
+		ORDER BY id DESC
+		LIMIT $2`
+
+	var entries []ledger.Entry
+	if err := r.db.SelectContext(ctx, &entries, query, account, limit); err != nil {
+		return nil, fmt.Errorf("failed to get account entries: %w", err)
+	}
+
+	result := make([]*ledger.Entry, len(entries))
+	for i := range entries {
+		result[i] = &entries[i]
+	}
+
+	return result, nil
+}
+
+// GetAssetTotals returns the sum of all entries per asset
+func (r *PostgresLedgerRepository) GetAssetTotals(ctx context.Context) (map[string]float64, error) {
+	query := `
+		SELECT asset, SUM(amount) AS amount
+		FROM trading.ledger_entries
+		GROUP BY asset`
+
+	var rows []ledger.Balance
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to get ledger asset totals: %w", err)
+	}
+
+	totals := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		totals[row.Asset] = row.Amount
+	}
+
+	return totals, nil
+}
+
+// GetUnbalancedJournals returns the IDs of journals whose entries don't sum
+// to zero for some asset
+func (r *PostgresLedgerRepository) GetUnbalancedJournals(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT DISTINCT journal_id
+		FROM trading.ledger_entries
+		GROUP BY journal_id, asset
+		HAVING SUM(amount) <> 0
+		ORDER BY journal_id`
+
+	var journals []string
+	if err := r.db.SelectContext(ctx, &journals, query); err != nil {
+		return nil, fmt.Errorf("failed to get unbalanced journals: %w", err)
+	}
+
+	return journals, nil
+}
+
+// GetBalances returns the ledger-derived user balances and the balances in
+// the accounts and positions tables from one repeatable read snapshot
+func (r *PostgresLedgerRepository) GetBalances(ctx context.Context) ([]*ledger.Balance, []*ledger.Balance, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin snapshot: %w", err)
+	}
+	defer tx.Rollback()
+
+	ledgerQuery := `
+		SELECT account, asset, SUM(amount) AS amount
+		FROM trading.ledger_entries
+		WHERE account LIKE 'user:%'
+		GROUP BY account, asset`
+
+	var ledgerBalances []*ledger.Balance
+	if err := tx.SelectContext(ctx, &ledgerBalances, ledgerQuery); err != nil {
+		return nil, nil, fmt.Errorf("failed to get ledger balances: %w", err)
+	}
+
+	bookQuery := `
+		SELECT 'user:' || user_id AS account, $1 AS asset, cash_balance AS amount
+		FROM trading.accounts
+		UNION ALL
+		SELECT 'user:' || user_id, symbol, quantity
+		FROM trading.positions`
+
+	var bookBalances []*ledger.Balance
+	if err := tx.SelectContext(ctx, &bookBalances, bookQuery, ledger.CashAsset); err != nil {
+		return nil, nil, fmt.Errorf("failed to get account balances: %w", err)
+	}
+
+	return ledgerBalances, bookBalances, nil
+}
+
+func (r *PostgresLedgerRepository) getJournalEntries(ctx context.Context, journalID string) ([]ledger.Entry, error) {
+	query := `
+		SELECT id, journal_id, account, asset, amount, created_at
+		FROM trading.ledger_entries
+		WHERE journal_id = $1
+		ORDER BY id`
+
+	var entries []ledger.Entry
+	if err := r.db.SelectContext(ctx, &entries, query, journalID); err != nil {
+		return nil, fmt.Errorf("failed to get journal entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// postJournal validates a journal and appends it to the ledger in the given
+// transaction. Nil journals are ignored.
+func postJournal(ctx context.Context, tx *sqlx.Tx, journal *ledger.Journal) error {
+	if journal == nil {
+		return nil
+	}
+
+	if err := journal.Validate(); err != nil {
+		return fmt.Errorf("refusing to post journal: %w", err)
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO trading.ledger_journals (id, journal_type, reference, description, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		journal.ID, journal.Type, journal.Reference, journal.Description, journal.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+
+	for _, entry := range journal.Entries {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO trading.ledger_entries (journal_id, account, asset, amount, created_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			entry.JournalID, entry.Account, entry.Asset, entry.Amount, entry.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create journal entry: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"simulated_exchange/pkg/shared"
+)
+
+// PostgresMarketMakerRepository implements shared.MarketMakerRepository using PostgreSQL
+type PostgresMarketMakerRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresMarketMakerRepository creates a new PostgreSQL market maker repository
+func NewPostgresMarketMakerRepository(db *sqlx.DB) *PostgresMarketMakerRepository {
+	return &PostgresMarketMakerRepository{db: db}
+}
+
+// Register creates a market maker registration, or replaces the obligations
+// of an existing one and reactivates it
+func (r *PostgresMarketMakerRepository) Register(ctx context.Context, marketMaker *shared.MarketMaker) error {
+	query := `
+		INSERT INTO trading.market_makers (user_id, symbol, max_spread_bps, min_quote_size, min_uptime, rebate_bps, active, registered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, TRUE, $7)
+		ON CONFLICT (user_id, symbol) DO UPDATE SET
+			max_spread_bps = EXCLUDED.max_spread_bps,
+			min_quote_size = EXCLUDED.min_quote_size,
+			min_uptime = EXCLUDED.min_uptime,
+			rebate_bps = EXCLUDED.rebate_bps,
+			active = TRUE,
+			registered_at = EXCLUDED.registered_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		marketMaker.UserID, marketMaker.Symbol, marketMaker.MaxSpreadBps, marketMaker.MinQuoteSize,
+		marketMaker.MinUptime, marketMaker.RebateBps, marketMaker.RegisteredAt)
+	if err != nil {
+		return fmt.Errorf("failed to register market maker: %w", err)
+	}
+
+	return nil
+}
+
+// Deregister deactivates a market maker registration. Its statistics are
+// kept for compliance reports.
+func (r *PostgresMarketMakerRepository) Deregister(ctx context.Context, userID, symbol string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE trading.market_makers SET active = FALSE
+		WHERE user_id = $1 AND symbol = $2 AND active`,
+		userID, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to deregister market maker: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return shared.ErrMarketMakerNotFound
+	}
+
+	return nil
+}
+
+// GetActive retrieves every active market maker registration
+func (r *PostgresMarketMakerRepository) GetActive(ctx context.Context) ([]*shared.MarketMaker, error) {
+	query := `
+		SELECT user_id, symbol, max_spread_bps, min_quote_size, min_uptime, rebate_bps, active, registered_at
+		FROM trading.market_makers
+		WHERE active
+		ORDER BY symbol, user_id`
+
+	return r.selectMarketMakers(ctx, query)
+}
+
+// GetByUserID retrieves a user's market maker registrations, active or not
+func (r *PostgresMarketMakerRepository) GetByUserID(ctx context.Context, userID string) ([]*shared.MarketMaker, error) {
+	query := `
+		SELECT user_id, symbol, max_spread_bps, min_quote_size, min_uptime, rebate_bps, active, registered_at
+		FROM trading.market_makers
+		WHERE user_id = $1
+		ORDER BY symbol`
+
+	return r.selectMarketMakers(ctx, query, userID)
+}
+
+// RecordSamples adds quote samples to the daily statistics in one transaction
+func (r *PostgresMarketMakerRepository) RecordSamples(ctx context.Context, samples []*shared.MarketMakerStats) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO trading.market_maker_stats (
+			user_id, symbol, trade_date, samples, quoted_samples, compliant_samples, spread_bps_sum, quote_size_sum
+		)
+		VALUES ($1, $2, $3::date, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, symbol, trade_date) DO UPDATE SET
+			samples = trading.market_maker_stats.samples + EXCLUDED.samples,
+			quoted_samples = trading.market_maker_stats.quoted_samples + EXCLUDED.quoted_samples,
+			compliant_samples = trading.market_maker_stats.compliant_samples + EXCLUDED.compliant_samples,
+			spread_bps_sum = trading.market_maker_stats.spread_bps_sum + EXCLUDED.spread_bps_sum,
+			quote_size_sum = trading.market_maker_stats.quote_size_sum + EXCLUDED.quote_size_sum`
+
+	for _, sample := range samples {
+		_, err := tx.ExecContext(ctx, query,
+			sample.UserID, sample.Symbol, sample.TradeDate.UTC(), sample.Samples, sample.QuotedSamples,
+			sample.CompliantSamples, sample.SpreadBpsSum, sample.QuoteSizeSum)
+		if err != nil {
+			return fmt.Errorf("failed to record market maker samples: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit market maker samples: %w", err)
+	}
+
+	return nil
+}
+
+// GetDailyStats retrieves the statistics of every market maker for a UTC
+// day, without trading activity
+func (r *PostgresMarketMakerRepository) GetDailyStats(ctx context.Context, tradeDate time.Time) ([]*shared.MarketMakerStats, error) {
+	query := `
+		SELECT user_id, symbol, trade_date, samples, quoted_samples, compliant_samples,
+		       spread_bps_sum, quote_size_sum, 0 AS maker_volume, 0 AS rebates
+		FROM trading.market_maker_stats
+		WHERE trade_date = $1::date`
+
+	return r.selectStats(ctx, query, tradeDate.UTC())
+}
+
+// GetStats retrieves a market maker's daily statistics for the UTC days in
+// [start, end), with the notional it traded as maker and the rebates it
+// earned each day
+func (r *PostgresMarketMakerRepository) GetStats(ctx context.Context, userID string, start, end time.Time) ([]*shared.MarketMakerStats, error) {
+	query := `
+		SELECT s.user_id, s.symbol, s.trade_date, s.samples, s.quoted_samples, s.compliant_samples,
+		       s.spread_bps_sum, s.quote_size_sum,
+		       COALESCE(a.maker_volume, 0) AS maker_volume, COALESCE(a.rebates, 0) AS rebates
+		FROM trading.market_maker_stats s
+		LEFT JOIN (
+			SELECT t.symbol, (t.created_at AT TIME ZONE 'UTC')::date AS trade_date,
+			       SUM(t.quantity * t.price) AS maker_volume, SUM(t.maker_rebate) AS rebates
+			FROM trading.trades t
+			JOIN trading.orders o ON o.id = CASE WHEN t.taker_side = 'BUY' THEN t.sell_order_id ELSE t.buy_order_id END
+			WHERE o.user_id = $1 AND t.taker_side IS NOT NULL
+			  AND t.created_at >= $2 AND t.created_at < $3
+			GROUP BY t.symbol, (t.created_at AT TIME ZONE 'UTC')::date
+		) a ON a.symbol = s.symbol AND a.trade_date = s.trade_date
+		WHERE s.user_id = $1 AND s.trade_date >= $2::date AND s.trade_date < $3::date
+		ORDER BY s.trade_date, s.symbol`
+
+	return r.selectStats(ctx, query, userID, start.UTC(), end.UTC())
+}
+
+func (r *PostgresMarketMakerRepository) selectMarketMakers(ctx context.Context, query string, args ...interface{}) ([]*shared.MarketMaker, error) {
+	var marketMakers []shared.MarketMaker
+	if err := r.db.SelectContext(ctx, &marketMakers, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get market makers: %w", err)
+	}
+
+	result := make([]*shared.MarketMaker, len(marketMakers))
+	for i := range marketMakers {
+		result[i] = &marketMakers[i]
+	}
+
+	return result, nil
+}
+
+func (r *PostgresMarketMakerRepository) selectStats(ctx context.Context, query string, args ...interface{}) ([]*shared.MarketMakerStats, error) {
+	var stats []shared.MarketMakerStats
+	if err := r.db.SelectContext(ctx, &stats, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get market maker stats: %w", err)
+	}
+
+	result := make([]*shared.MarketMakerStats, len(stats))
+	for i := range stats {
+		result[i] = &stats[i]
+	}
+
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"simulated_exchange/pkg/shared"
+)
+
+// PostgresOrderEventRepository implements shared.OrderEventRepository using PostgreSQL
+type PostgresOrderEventRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresOrderEventRepository creates a new PostgreSQL order event repository
+func NewPostgresOrderEventRepository(db *sqlx.DB) *PostgresOrderEventRepository {
+	return &PostgresOrderEventRepository{db: db}
+}
+
+// orderEventColumns are the columns scanned into a shared.OrderEvent
+const orderEventColumns = `
+	id, order_id, user_id, symbol, side, order_type, event_type, status, price, quantity,
+	COALESCE(trade_id::text, '') AS trade_id, fill_quantity, fill_price, reason, source, created_at`
+
+// Append records an order event and sets its ID
+func (r *PostgresOrderEventRepository) Append(ctx context.Context, event *shared.OrderEvent) error {
+	return appendOrderEvent(ctx, r.db, event)
+}
+
+// appendOrderEvent inserts an order event through db, which may be a
+// transaction writing the order the event describes
+func appendOrderEvent(ctx context.Context, db sqlx.QueryerContext, event *shared.OrderEvent) error {
+	query := `
+		INSERT INTO trading.order_events (
+			order_id, user_id, symbol, side, order_type, event_type, status, price, quantity,
+			trade_id, fill_quantity, fill_price, reason, source, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')::uuid, $11, $12, $13, $14, $15)
+		RETURNING id`
+
+	err := db.QueryRowxContext(ctx, query,
+		event.OrderID, event.UserID, event.Symbol, event.Side, event.OrderType, event.EventType,
+		event.Status, event.Price, event.Quantity, event.TradeID, event.FillQuantity, event.FillPrice,
+		event.Reason, event.Source, event.CreatedAt,
+	).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to append order event: %w", err)
+	}
+
+	return nil
+}
+
+// GetByOrderID retrieves an order's events in the order they happened
+func (r *PostgresOrderEventRepository) GetByOrderID(ctx context.Context, orderID string) ([]*shared.OrderEvent, error) {
+	query := `SELECT ` + orderEventColumns + `
+		FROM trading.order_events
+		WHERE order_id = $1
+		ORDER BY id`
+
+	return r.selectEvents(ctx, query, orderID)
+}
+
+// GetInTimeRange retrieves the events recorded in [start, end) in the order
+// they happened
+func (r *PostgresOrderEventRepository) GetInTimeRange(ctx context.Context, start, end time.Time) ([]*shared.OrderEvent, error) {
+	query := `SELECT ` + orderEventColumns + `
+		FROM trading.order_events
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at, id`
+
+	return r.selectEvents(ctx, query, start, end)
+}
+
+func (r *PostgresOrderEventRepository) selectEvents(ctx context.Context, query string, args ...interface{}) ([]*shared.OrderEvent, error) {
+	var events []shared.OrderEvent
+	if err := r.db.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get order events: %w", err)
+	}
+
+	result := make([]*shared.OrderEvent, len(events))
+	for i := range events {
+		result[i] = &events[i]
+	}
+
+	return result, nil
+}
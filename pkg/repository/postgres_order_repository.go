@@ -7,9 +7,18 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"simulated_exchange/pkg/shared"
 )
 
+const (
+	// uniqueViolation is the PostgreSQL error code for unique constraint violations
+	uniqueViolation = "23505"
+
+	// clientOrderIDIndex enforces one client order ID per user
+	clientOrderIDIndex = "idx_orders_user_client_order_id"
+)
+
 // PostgresOrderRepository implements shared.OrderRepository using PostgreSQL
 type PostgresOrderRepository struct {
 	db *sqlx.DB
@@ -20,27 +29,37 @@ func NewPostgresOrderRepository(db *sqlx.DB) *PostgresOrderRepository {
 	return &PostgresOrderRepository{db: db}
 }
 
-// Create inserts a new order into the database
-func (r *PostgresOrderRepository) Create(ctx context.Context, order *shared.Order) error {
+// Create inserts a new order into the database and appends its event, if
+// any, in the same transaction
+func (r *PostgresOrderRepository) Create(ctx context.Context, order *shared.Order, event *shared.OrderEvent) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin order transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO trading.orders (id, user_id, symbol, side, type, price, quantity, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+		INSERT INTO trading.orders (id, client_order_id, user_id, symbol, side, type, price, quantity, status, reject_reason, created_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12)`
 
-	_, err := r.db.ExecContext(ctx, query,
-		order.ID, order.UserID, order.Symbol, order.Side, order.Type,
-		order.Price, order.Quantity, order.Status, order.CreatedAt, order.UpdatedAt)
+	_, err = tx.ExecContext(ctx, query,
+		order.ID, order.ClientOrderID, order.UserID, order.Symbol, order.Side, order.Type,
+		order.Price, order.Quantity, order.Status, order.RejectReason, order.CreatedAt, order.UpdatedAt)
 
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation && pqErr.Constraint == clientOrderIDIndex {
+			return shared.ErrOrderAlreadyExists
+		}
 		return fmt.Errorf("failed to create order: %w", err)
 	}
 
-	return nil
+	return r.commitWithEvent(ctx, tx, event)
 }
 
 // GetByID retrieves an order by its ID
 func (r *PostgresOrderRepository) GetByID(ctx context.Context, id string) (*shared.Order, error) {
 	query := `
-		SELECT id, user_id, symbol, side, type, price, quantity, status, created_at, updated_at
+		SELECT id, COALESCE(client_order_id, '') AS client_order_id, user_id, symbol, side, type, price, quantity, status, COALESCE(reject_reason, '') AS reject_reason, created_at, updated_at
 		FROM trading.orders
 		WHERE id = $1`
 
@@ -56,10 +75,29 @@ func (r *PostgresOrderRepository) GetByID(ctx context.Context, id string) (*shar
 	return &order, nil
 }
 
+// GetByClientOrderID retrieves an order by the client-supplied ID unique to a user
+func (r *PostgresOrderRepository) GetByClientOrderID(ctx context.Context, userID, clientOrderID string) (*shared.Order, error) {
+	query := `
+		SELECT id, client_order_id, user_id, symbol, side, type, price, quantity, status, COALESCE(reject_reason, '') AS reject_reason, created_at, updated_at
+		FROM trading.orders
+		WHERE user_id = $1 AND client_order_id = $2`
+
+	var order shared.Order
+	err := r.db.GetContext(ctx, &order, query, userID, clientOrderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order by client order ID: %w", err)
+	}
+
+	return &order, nil
+}
+
 // GetByUserID retrieves all orders for a specific user
 func (r *PostgresOrderRepository) GetByUserID(ctx context.Context, userID string) ([]*shared.Order, error) {
 	query := `
-		SELECT id, user_id, symbol, side, type, price, quantity, status, created_at, updated_at
+		SELECT id, COALESCE(client_order_id, '') AS client_order_id, user_id, symbol, side, type, price, quantity, status, COALESCE(reject_reason, '') AS reject_reason, created_at, updated_at
 		FROM trading.orders
 		WHERE user_id = $1
 		ORDER BY created_at DESC`
@@ -82,7 +120,7 @@ func (r *PostgresOrderRepository) GetByUserID(ctx context.Context, userID string
 // GetBySymbol retrieves all orders for a specific symbol
 func (r *PostgresOrderRepository) GetBySymbol(ctx context.Context, symbol string) ([]*shared.Order, error) {
 	query := `
-		SELECT id, user_id, symbol, side, type, price, quantity, status, created_at, updated_at
+		SELECT id, COALESCE(client_order_id, '') AS client_order_id, user_id, symbol, side, type, price, quantity, status, COALESCE(reject_reason, '') AS reject_reason, created_at, updated_at
 		FROM trading.orders
 		WHERE symbol = $1 AND status IN ('PENDING', 'PARTIAL')
 		ORDER BY created_at ASC`
@@ -105,7 +143,7 @@ func (r *PostgresOrderRepository) GetBySymbol(ctx context.Context, symbol string
 // GetByStatus retrieves all orders with a specific status
 func (r *PostgresOrderRepository) GetByStatus(ctx context.Context, status shared.OrderStatus) ([]*shared.Order, error) {
 	query := `
-		SELECT id, user_id, symbol, side, type, price, quantity, status, created_at, updated_at
+		SELECT id, COALESCE(client_order_id, '') AS client_order_id, user_id, symbol, side, type, price, quantity, status, COALESCE(reject_reason, '') AS reject_reason, created_at, updated_at
 		FROM trading.orders
 		WHERE status = $1
 		ORDER BY created_at DESC`
@@ -125,31 +163,177 @@ func (r *PostgresOrderRepository) GetByStatus(ctx context.Context, status shared
 	return result, nil
 }
 
-// Update updates an existing order
-func (r *PostgresOrderRepository) Update(ctx context.Context, order *shared.Order) error {
+// Update updates an existing order and appends its event, if any, in the
+// same transaction. The update only applies if the stored status may move to
+// the order's status, so a concurrent cancel and fill cannot both succeed;
+// otherwise a *shared.OrderTransitionError from the stored status is
+// returned. The open quantity is only changed by fills and amendments, which
+// apply it to the stored quantity; Update leaves it alone and refreshes the
+// order, and its event, from the stored row.
+func (r *PostgresOrderRepository) Update(ctx context.Context, order *shared.Order, event *shared.OrderEvent) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin order transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE trading.orders
 		SET user_id = $2, symbol = $3, side = $4, type = $5, price = $6,
-		    quantity = $7, status = $8, updated_at = $9
-		WHERE id = $1`
+		    status = $7, reject_reason = NULLIF($8, ''), updated_at = $9
+		WHERE id = $1 AND status = ANY($10)
+		RETURNING quantity`
 
 	order.UpdatedAt = time.Now()
 
-	result, err := r.db.ExecContext(ctx, query,
+	predecessors := pq.StringArray{}
+	for _, status := range shared.OrderStatusPredecessors(order.Status) {
+		predecessors = append(predecessors, string(status))
+	}
+
+	var quantity float64
+	err = tx.GetContext(ctx, &quantity, query,
 		order.ID, order.UserID, order.Symbol, order.Side, order.Type,
-		order.Price, order.Quantity, order.Status, order.UpdatedAt)
+		order.Price, order.Status, order.RejectReason, order.UpdatedAt, predecessors)
 
+	if err == sql.ErrNoRows {
+		current, err := currentOrderStatus(ctx, tx, order.ID)
+		if err != nil {
+			return err
+		}
+		return &shared.OrderTransitionError{OrderID: order.ID, From: current, To: order.Status}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
+	order.Quantity = quantity
+	if event != nil {
+		event.Quantity = quantity
+	}
+
+	return r.commitWithEvent(ctx, tx, event)
+}
+
+// Amend sets the price and open quantity of an order and appends its event,
+// if any, in the same transaction. The amend only applies while the order is
+// open and its stored quantity is still previousQuantity: an order that was
+// closed since it was loaded fails with a *shared.OrderTransitionError, one
+// that was filled with shared.ErrOrderModified.
+func (r *PostgresOrderRepository) Amend(ctx context.Context, order *shared.Order, previousQuantity float64, event *shared.OrderEvent) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin order transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE trading.orders
+		SET price = $2, quantity = $3, updated_at = $4
+		WHERE id = $1 AND status IN ('PENDING', 'PARTIAL') AND quantity = $5`
+
+	order.UpdatedAt = time.Now()
+
+	result, err := tx.ExecContext(ctx, query, order.ID, order.Price, order.Quantity, order.UpdatedAt, previousQuantity)
+	if err != nil {
+		return fmt.Errorf("failed to amend order: %w", err)
+	}
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return shared.ErrOrderNotFound
+		current, err := currentOrderStatus(ctx, tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if !current.IsOpen() {
+			return &shared.OrderTransitionError{OrderID: order.ID, From: current, To: order.Status}
+		}
+		return shared.ErrOrderModified
+	}
+
+	// Hold only what the reduced order needs
+	if err := shrinkReservation(ctx, tx, order.ID, order.Quantity); err != nil {
+		return err
+	}
+
+	return r.commitWithEvent(ctx, tx, event)
+}
+
+// currentOrderStatus reads the stored status of an order that a conditional
+// write did not apply to
+func currentOrderStatus(ctx context.Context, tx *sqlx.Tx, orderID string) (shared.OrderStatus, error) {
+	var current shared.OrderStatus
+	err := tx.GetContext(ctx, &current, `SELECT status FROM trading.orders WHERE id = $1`, orderID)
+	if err == sql.ErrNoRows {
+		return "", shared.ErrOrderNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get order status: %w", err)
+	}
+	return current, nil
+}
+
+// applyOrderFill reduces the stored open quantity of an order by a fill and
+// appends the fill's event, if any, in the caller's transaction. The fill
+// is computed from the stored quantity rather than the caller's copy, so
+// concurrent fills of one order add up. An order that is no longer open
+// fails with a *shared.OrderTransitionError, one without enough open
+// quantity left with shared.ErrOrderModified.
+func applyOrderFill(ctx context.Context, tx *sqlx.Tx, fill *shared.OrderFill) error {
+	query := `
+		UPDATE trading.orders
+		SET quantity = quantity - $2,
+		    status = CASE WHEN quantity - $2 <= 0 THEN 'FILLED' ELSE 'PARTIAL' END,
+		    updated_at = $3
+		WHERE id = $1 AND status IN ('PENDING', 'PARTIAL') AND quantity >= $2
+		RETURNING quantity, status`
+
+	now := time.Now()
+
+	var filled struct {
+		Quantity float64            `db:"quantity"`
+		Status   shared.OrderStatus `db:"status"`
+	}
+	err := tx.GetContext(ctx, &filled, query, fill.Order.ID, fill.Quantity, now)
+	if err == sql.ErrNoRows {
+		current, err := currentOrderStatus(ctx, tx, fill.Order.ID)
+		if err != nil {
+			return err
+		}
+		if !current.IsOpen() {
+			return &shared.OrderTransitionError{OrderID: fill.Order.ID, From: current, To: shared.OrderStatusFilled}
+		}
+		return shared.ErrOrderModified
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fill order: %w", err)
+	}
+
+	fill.Filled(filled.Quantity, filled.Status, now)
+	if fill.Event != nil {
+		if err := appendOrderEvent(ctx, tx, fill.Event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitWithEvent appends the event of an order write, if any, and commits
+// the write. The order write is rolled back if the event cannot be recorded.
+func (r *PostgresOrderRepository) commitWithEvent(ctx context.Context, tx *sqlx.Tx, event *shared.OrderEvent) error {
+	if event != nil {
+		if err := appendOrderEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order: %w", err)
 	}
 
 	return nil
@@ -179,7 +363,7 @@ func (r *PostgresOrderRepository) Delete(ctx context.Context, id string) error {
 // GetActiveOrders retrieves all active orders (PENDING or PARTIAL status)
 func (r *PostgresOrderRepository) GetActiveOrders(ctx context.Context) ([]*shared.Order, error) {
 	query := `
-		SELECT id, user_id, symbol, side, type, price, quantity, status, created_at, updated_at
+		SELECT id, COALESCE(client_order_id, '') AS client_order_id, user_id, symbol, side, type, price, quantity, status, COALESCE(reject_reason, '') AS reject_reason, created_at, updated_at
 		FROM trading.orders
 		WHERE status IN ('PENDING', 'PARTIAL')
 		ORDER BY created_at ASC`
@@ -199,10 +383,36 @@ func (r *PostgresOrderRepository) GetActiveOrders(ctx context.Context) ([]*share
 	return result, nil
 }
 
+// GetOpenOrders retrieves active orders matching the filter, oldest first
+func (r *PostgresOrderRepository) GetOpenOrders(ctx context.Context, filter shared.OrderFilter) ([]*shared.Order, error) {
+	query := `
+		SELECT id, COALESCE(client_order_id, '') AS client_order_id, user_id, symbol, side, type, price, quantity, status, COALESCE(reject_reason, '') AS reject_reason, created_at, updated_at
+		FROM trading.orders
+		WHERE status IN ('PENDING', 'PARTIAL')
+		  AND ($1 = '' OR user_id::text = $1)
+		  AND ($2 = '' OR symbol = $2)
+		  AND ($3 = '' OR side = $3)
+		ORDER BY created_at ASC`
+
+	var orders []shared.Order
+	err := r.db.SelectContext(ctx, &orders, query, filter.UserID, filter.Symbol, string(filter.Side))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	// Convert to slice of pointers
+	result := make([]*shared.Order, len(orders))
+	for i := range orders {
+		result[i] = &orders[i]
+	}
+
+	return result, nil
+}
+
 // GetOrdersInTimeRange retrieves orders within a specific time range
 func (r *PostgresOrderRepository) GetOrdersInTimeRange(ctx context.Context, start, end time.Time) ([]*shared.Order, error) {
 	query := `
-		SELECT id, user_id, symbol, side, type, price, quantity, status, created_at, updated_at
+		SELECT id, COALESCE(client_order_id, '') AS client_order_id, user_id, symbol, side, type, price, quantity, status, COALESCE(reject_reason, '') AS reject_reason, created_at, updated_at
 		FROM trading.orders
 		WHERE created_at >= $1 AND created_at <= $2
 		ORDER BY created_at DESC`
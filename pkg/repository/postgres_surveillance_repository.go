@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"simulated_exchange/pkg/shared"
+)
+
+// defaultAlertLimit bounds the alerts returned when the filter sets no limit
+const defaultAlertLimit = 100
+
+// PostgresSurveillanceRepository implements shared.SurveillanceRepository using PostgreSQL
+type PostgresSurveillanceRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresSurveillanceRepository creates a new PostgreSQL surveillance repository
+func NewPostgresSurveillanceRepository(db *sqlx.DB) *PostgresSurveillanceRepository {
+	return &PostgresSurveillanceRepository{db: db}
+}
+
+// alertColumns are the columns scanned into an alertRow
+const alertColumns = `
+	id, alert_type, symbol, user_id, COALESCE(counterparty_id::text, '') AS counterparty_id,
+	COALESCE(trade_id::text, '') AS trade_id, order_ids, quantity, description, status,
+	detected_at, reviewed_at`
+
+type alertRow struct {
+	shared.SurveillanceAlert
+	OrderIDs pq.StringArray `db:"order_ids"`
+}
+
+func (row *alertRow) toAlert() *shared.SurveillanceAlert {
+	alert := row.SurveillanceAlert
+	alert.OrderIDs = []string(row.OrderIDs)
+	if alert.OrderIDs == nil {
+		alert.OrderIDs = make([]string, 0)
+	}
+	return &alert
+}
+
+// CreateAlert inserts a new surveillance alert
+func (r *PostgresSurveillanceRepository) CreateAlert(ctx context.Context, alert *shared.SurveillanceAlert) error {
+	query := `
+		INSERT INTO trading.surveillance_alerts (
+			id, alert_type, symbol, user_id, counterparty_id, trade_id, order_ids,
+			quantity, description, status, detected_at
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, NULLIF($6, '')::uuid, $7, $8, $9, $10, $11)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		alert.ID, alert.Type, alert.Symbol, alert.UserID, alert.CounterpartyID, alert.TradeID,
+		pq.Array(alert.OrderIDs), alert.Quantity, alert.Description, alert.Status, alert.DetectedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create surveillance alert: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlert retrieves a surveillance alert by ID
+func (r *PostgresSurveillanceRepository) GetAlert(ctx context.Context, id string) (*shared.SurveillanceAlert, error) {
+	query := `SELECT ` + alertColumns + ` FROM trading.surveillance_alerts WHERE id = $1`
+
+	var row alertRow
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrAlertNotFound
+		}
+		return nil, fmt.Errorf("failed to get surveillance alert: %w", err)
+	}
+
+	return row.toAlert(), nil
+}
+
+// GetAlerts retrieves the alerts matching the filter, newest first
+func (r *PostgresSurveillanceRepository) GetAlerts(ctx context.Context, filter shared.AlertFilter) ([]*shared.SurveillanceAlert, error) {
+	query := `SELECT ` + alertColumns + `
+		FROM trading.surveillance_alerts
+		WHERE ($1 = '' OR alert_type = $1)
+		  AND ($2 = '' OR status = $2)
+		  AND ($3 = '' OR user_id::text = $3 OR counterparty_id::text = $3)
+		  AND ($4 = '' OR symbol = $4)
+		  AND ($5::timestamptz IS NULL OR detected_at >= $5)
+		  AND ($6::timestamptz IS NULL OR detected_at < $6)
+		ORDER BY detected_at DESC
+		LIMIT $7`
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAlertLimit
+	}
+
+	var rows []alertRow
+	err := r.db.SelectContext(ctx, &rows, query,
+		string(filter.Type), string(filter.Status), filter.UserID, filter.Symbol,
+		nullTime(filter.Start), nullTime(filter.End), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get surveillance alerts: %w", err)
+	}
+
+	result := make([]*shared.SurveillanceAlert, len(rows))
+	for i := range rows {
+		result[i] = rows[i].toAlert()
+	}
+
+	return result, nil
+}
+
+// UpdateAlertStatus records the review outcome of an alert
+func (r *PostgresSurveillanceRepository) UpdateAlertStatus(ctx context.Context, id string, status shared.AlertStatus) (*shared.SurveillanceAlert, error) {
+	query := `
+		UPDATE trading.surveillance_alerts
+		SET status = $2, reviewed_at = CASE WHEN $2 = 'OPEN' THEN NULL ELSE NOW() END
+		WHERE id = $1
+		RETURNING ` + alertColumns
+
+	var row alertRow
+	if err := r.db.GetContext(ctx, &row, query, id, string(status)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrAlertNotFound
+		}
+		return nil, fmt.Errorf("failed to update surveillance alert: %w", err)
+	}
+
+	return row.toAlert(), nil
+}
+
+// GetBeneficialOwners returns the beneficial owner of every account that
+// has one recorded
+func (r *PostgresSurveillanceRepository) GetBeneficialOwners(ctx context.Context) (map[string]string, error) {
+	var rows []struct {
+		UserID  string `db:"user_id"`
+		OwnerID string `db:"owner_id"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT user_id, owner_id FROM trading.beneficial_owners`); err != nil {
+		return nil, fmt.Errorf("failed to get beneficial owners: %w", err)
+	}
+
+	owners := make(map[string]string, len(rows))
+	for _, row := range rows {
+		owners[row.UserID] = row.OwnerID
+	}
+
+	return owners, nil
+}
+
+// SetBeneficialOwner records the beneficial owner of an account
+func (r *PostgresSurveillanceRepository) SetBeneficialOwner(ctx context.Context, userID, ownerID string) error {
+	query := `
+		INSERT INTO trading.beneficial_owners (user_id, owner_id, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			owner_id = EXCLUDED.owner_id,
+			updated_at = EXCLUDED.updated_at`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, ownerID); err != nil {
+		return fmt.Errorf("failed to set beneficial owner: %w", err)
+	}
+
+	return nil
+}
+
+// nullTime returns nil for the zero time so it can be bound as SQL NULL
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -20,27 +20,151 @@ func NewPostgresTradeRepository(db *sqlx.DB) *PostgresTradeRepository {
 	return &PostgresTradeRepository{db: db}
 }
 
-// Create inserts a new trade into the database
-func (r *PostgresTradeRepository) Create(ctx context.Context, trade *shared.Trade) error {
+// Create inserts a new trade into the database, applies its fills to the
+// traded orders, settles it if a settlement is given and folds it into the
+// continuously aggregated candles in one transaction
+func (r *PostgresTradeRepository) Create(ctx context.Context, trade *shared.Trade, fills []*shared.OrderFill, settlement *shared.TradeSettlement) error {
 	query := `
-		INSERT INTO trading.trades (id, buy_order_id, sell_order_id, symbol, price, quantity, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		INSERT INTO trading.trades (id, buy_order_id, sell_order_id, symbol, price, quantity, taker_side, buy_fee, sell_fee, maker_rebate, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin trade transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// An order that can no longer take its fill rolls the whole trade back
+	for _, fill := range fills {
+		if err := applyOrderFill(ctx, tx, fill); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, query,
 		trade.ID, trade.BuyOrderID, trade.SellOrderID, trade.Symbol,
-		trade.Price, trade.Quantity, trade.CreatedAt)
+		trade.Price, trade.Quantity, string(trade.TakerSide), trade.BuyFee, trade.SellFee, trade.MakerRebate, trade.CreatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create trade: %w", err)
 	}
 
+	if settlement != nil {
+		if err := settleTrade(ctx, tx, trade, settlement); err != nil {
+			return err
+		}
+	}
+
+	if err := r.aggregateCandles(ctx, tx, trade); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit trade: %w", err)
+	}
+
 	return nil
 }
 
+// aggregateCandles upserts the trade into its bucket for every candle
+// interval. Open and close follow trade time rather than insertion order so
+// late trades don't corrupt a bucket.
+func (r *PostgresTradeRepository) aggregateCandles(ctx context.Context, tx *sqlx.Tx, trade *shared.Trade) error {
+	query := `
+		INSERT INTO trading.candles (symbol, bucket_interval, bucket_start, open, high, low, close, volume, trade_count, first_trade_at, last_trade_at)
+		SELECT $1, i.name, to_timestamp(floor(extract(epoch FROM $4::timestamptz) / i.seconds) * i.seconds),
+		       $2, $2, $2, $2, $3, 1, $4, $4
+		FROM (VALUES ('1m', 60), ('5m', 300), ('1h', 3600)) AS i(name, seconds)
+		ON CONFLICT (symbol, bucket_interval, bucket_start) DO UPDATE SET
+			open = CASE WHEN EXCLUDED.first_trade_at < candles.first_trade_at THEN EXCLUDED.open ELSE candles.open END,
+			high = GREATEST(candles.high, EXCLUDED.high),
+			low = LEAST(candles.low, EXCLUDED.low),
+			close = CASE WHEN EXCLUDED.last_trade_at >= candles.last_trade_at THEN EXCLUDED.close ELSE candles.close END,
+			volume = candles.volume + EXCLUDED.volume,
+			trade_count = candles.trade_count + 1,
+			first_trade_at = LEAST(candles.first_trade_at, EXCLUDED.first_trade_at),
+			last_trade_at = GREATEST(candles.last_trade_at, EXCLUDED.last_trade_at)`
+
+	_, err := tx.ExecContext(ctx, query, trade.Symbol, trade.Price, trade.Quantity, trade.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate candles: %w", err)
+	}
+
+	return nil
+}
+
+// BackfillCandles aggregates candles from trades that were stored without
+// them, e.g. before the candles table existed, and returns the number of
+// candles written. The buckets of those trades are rebuilt from every trade
+// since the earliest of them, so it must run before trades are accepted.
+func (r *PostgresTradeRepository) BackfillCandles(ctx context.Context) (int, error) {
+	var since sql.NullTime
+	err := r.db.GetContext(ctx, &since, `
+		SELECT MIN(t.created_at)
+		FROM trading.trades t
+		WHERE NOT EXISTS (
+			SELECT 1 FROM trading.candles c
+			WHERE c.symbol = t.symbol AND c.bucket_interval = '1m'
+			  AND c.bucket_start = to_timestamp(floor(extract(epoch FROM t.created_at) / 60) * 60)
+		)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find trades without candles: %w", err)
+	}
+	if !since.Valid {
+		return 0, nil
+	}
+
+	// Start at the widest bucket so every rebuilt bucket holds all its trades
+	start := shared.CandleInterval1h.BucketStart(since.Time)
+	var trades []*shared.Trade
+	err = r.db.SelectContext(ctx, &trades, `
+		SELECT id, symbol, price, quantity, created_at
+		FROM trading.trades
+		WHERE created_at >= $1`, start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load trades to backfill: %w", err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin candle backfill: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO trading.candles (symbol, bucket_interval, bucket_start, open, high, low, close, volume, trade_count, first_trade_at, last_trade_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (symbol, bucket_interval, bucket_start) DO UPDATE SET
+			open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low, close = EXCLUDED.close,
+			volume = EXCLUDED.volume, trade_count = EXCLUDED.trade_count,
+			first_trade_at = EXCLUDED.first_trade_at, last_trade_at = EXCLUDED.last_trade_at`
+
+	written := 0
+	for _, interval := range shared.CandleIntervals {
+		for _, candle := range shared.AggregateCandles(trades, interval) {
+			_, err := tx.ExecContext(ctx, query,
+				candle.Symbol, string(candle.Interval), candle.BucketStart,
+				candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, candle.TradeCount,
+				candle.FirstTradeAt, candle.LastTradeAt)
+			if err != nil {
+				return 0, fmt.Errorf("failed to backfill candle: %w", err)
+			}
+			written++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit candle backfill: %w", err)
+	}
+
+	return written, nil
+}
+
 // GetByID retrieves a trade by its ID
 func (r *PostgresTradeRepository) GetByID(ctx context.Context, id string) (*shared.Trade, error) {
 	query := `
-		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity, created_at
+		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity,
+		       COALESCE(taker_side, '') AS taker_side, buy_fee, sell_fee, maker_rebate, created_at
 		FROM trading.trades
 		WHERE id = $1`
 
@@ -59,7 +183,8 @@ func (r *PostgresTradeRepository) GetByID(ctx context.Context, id string) (*shar
 // GetByOrderID retrieves all trades for a specific order
 func (r *PostgresTradeRepository) GetByOrderID(ctx context.Context, orderID string) ([]*shared.Trade, error) {
 	query := `
-		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity, created_at
+		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity,
+		       COALESCE(taker_side, '') AS taker_side, buy_fee, sell_fee, maker_rebate, created_at
 		FROM trading.trades
 		WHERE buy_order_id = $1 OR sell_order_id = $1
 		ORDER BY created_at DESC`
@@ -82,7 +207,8 @@ func (r *PostgresTradeRepository) GetByOrderID(ctx context.Context, orderID stri
 // GetBySymbol retrieves all trades for a specific symbol
 func (r *PostgresTradeRepository) GetBySymbol(ctx context.Context, symbol string) ([]*shared.Trade, error) {
 	query := `
-		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity, created_at
+		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity,
+		       COALESCE(taker_side, '') AS taker_side, buy_fee, sell_fee, maker_rebate, created_at
 		FROM trading.trades
 		WHERE symbol = $1
 		ORDER BY created_at DESC`
@@ -105,7 +231,8 @@ func (r *PostgresTradeRepository) GetBySymbol(ctx context.Context, symbol string
 // GetTradesInTimeRange retrieves trades within a specific time range
 func (r *PostgresTradeRepository) GetTradesInTimeRange(ctx context.Context, start, end time.Time) ([]*shared.Trade, error) {
 	query := `
-		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity, created_at
+		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity,
+		       COALESCE(taker_side, '') AS taker_side, buy_fee, sell_fee, maker_rebate, created_at
 		FROM trading.trades
 		WHERE created_at >= $1 AND created_at <= $2
 		ORDER BY created_at DESC`
@@ -128,7 +255,8 @@ func (r *PostgresTradeRepository) GetTradesInTimeRange(ctx context.Context, star
 // GetRecentTrades retrieves the most recent trades with a limit
 func (r *PostgresTradeRepository) GetRecentTrades(ctx context.Context, limit int) ([]*shared.Trade, error) {
 	query := `
-		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity, created_at
+		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity,
+		       COALESCE(taker_side, '') AS taker_side, buy_fee, sell_fee, maker_rebate, created_at
 		FROM trading.trades
 		ORDER BY created_at DESC
 		LIMIT $1`
@@ -215,4 +343,177 @@ func (r *PostgresTradeRepository) GetLatestPriceBySymbol(ctx context.Context, sy
 	}
 
 	return price, nil
-}
\ No newline at end of file
+}
+
+// GetTradesBySymbolBefore retrieves up to limit trades for a symbol, newest
+// first, starting after the given cursor (or from the latest trade if nil)
+func (r *PostgresTradeRepository) GetTradesBySymbolBefore(ctx context.Context, symbol string, before *shared.TradeCursor, limit int) ([]*shared.Trade, error) {
+	query := `
+		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity,
+		       COALESCE(taker_side, '') AS taker_side, buy_fee, sell_fee, maker_rebate, created_at
+		FROM trading.trades
+		WHERE symbol = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+	args := []interface{}{symbol, limit}
+
+	if before != nil {
+		query = `
+		SELECT id, buy_order_id, sell_order_id, symbol, price, quantity,
+		       COALESCE(taker_side, '') AS taker_side, buy_fee, sell_fee, maker_rebate, created_at
+		FROM trading.trades
+		WHERE symbol = $1 AND (created_at, id) < ($3, $4::uuid)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+		args = append(args, before.CreatedAt, before.ID)
+	}
+
+	var trades []shared.Trade
+	err := r.db.SelectContext(ctx, &trades, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trades by symbol: %w", err)
+	}
+
+	// Convert to slice of pointers
+	result := make([]*shared.Trade, len(trades))
+	for i := range trades {
+		result[i] = &trades[i]
+	}
+
+	return result, nil
+}
+
+// GetCandles retrieves the most recent candles for a symbol and interval
+// with bucket_start in [start, end], returned oldest first
+func (r *PostgresTradeRepository) GetCandles(ctx context.Context, symbol string, interval shared.CandleInterval, start, end time.Time, limit int) ([]*shared.Candle, error) {
+	query := `
+		SELECT symbol, bucket_interval, bucket_start, open, high, low, close, volume, trade_count
+		FROM (
+			SELECT symbol, bucket_interval, bucket_start, open, high, low, close, volume, trade_count
+			FROM trading.candles
+			WHERE symbol = $1 AND bucket_interval = $2 AND bucket_start >= $3 AND bucket_start <= $4
+			ORDER BY bucket_start DESC
+			LIMIT $5
+		) recent
+		ORDER BY bucket_start ASC`
+
+	var candles []shared.Candle
+	err := r.db.SelectContext(ctx, &candles, query, symbol, string(interval), start, end, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candles: %w", err)
+	}
+
+	// Convert to slice of pointers
+	result := make([]*shared.Candle, len(candles))
+	for i := range candles {
+		result[i] = &candles[i]
+	}
+
+	return result, nil
+}
+
+// GetTickers returns trade statistics per symbol for trades since the given time
+func (r *PostgresTradeRepository) GetTickers(ctx context.Context, since time.Time) ([]*shared.Ticker, error) {
+	query := `
+		SELECT symbol,
+		       (array_agg(price ORDER BY created_at DESC, id DESC))[1] AS last_price,
+		       (array_agg(price ORDER BY created_at ASC, id ASC))[1] AS open_price,
+		       MAX(price) AS high_price,
+		       MIN(price) AS low_price,
+		       SUM(quantity) AS volume,
+		       SUM(quantity * price) AS quote_volume,
+		       COALESCE(SUM(quantity * price) / NULLIF(SUM(quantity), 0), 0) AS vwap,
+		       COUNT(*) AS trade_count,
+		       MAX(created_at) AS last_trade_at
+		FROM trading.trades
+		WHERE created_at >= $1
+		GROUP BY symbol
+		ORDER BY symbol`
+
+	var tickers []shared.Ticker
+	err := r.db.SelectContext(ctx, &tickers, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tickers: %w", err)
+	}
+
+	// Convert to slice of pointers
+	result := make([]*shared.Ticker, len(tickers))
+	for i := range tickers {
+		result[i] = &tickers[i]
+	}
+
+	return result, nil
+}
+
+// GetFeeRevenue returns fee revenue per symbol for trades in [start, end)
+func (r *PostgresTradeRepository) GetFeeRevenue(ctx context.Context, start, end time.Time) ([]*shared.FeeRevenue, error) {
+	query := `
+		SELECT symbol,
+		       COUNT(*) AS trade_count,
+		       SUM(quantity * price) AS volume,
+		       SUM(CASE WHEN taker_side = 'BUY' THEN sell_fee WHEN taker_side = 'SELL' THEN buy_fee ELSE 0 END) AS maker_fees,
+		       SUM(CASE WHEN taker_side = 'BUY' THEN buy_fee WHEN taker_side = 'SELL' THEN sell_fee ELSE buy_fee + sell_fee END) AS taker_fees,
+		       SUM(buy_fee + sell_fee) AS total_fees
+		FROM trading.trades
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY symbol
+		ORDER BY symbol`
+
+	var revenue []shared.FeeRevenue
+	err := r.db.SelectContext(ctx, &revenue, query, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee revenue: %w", err)
+	}
+
+	// Convert to slice of pointers
+	result := make([]*shared.FeeRevenue, len(revenue))
+	for i := range revenue {
+		result[i] = &revenue[i]
+	}
+
+	return result, nil
+}
+
+// GetUserVolumes returns the traded notional of every user with trades since
+// the given time. Both sides of a trade count towards their user's volume.
+func (r *PostgresTradeRepository) GetUserVolumes(ctx context.Context, since time.Time) (map[string]float64, error) {
+	query := `
+		SELECT o.user_id, SUM(t.quantity * t.price) AS volume
+		FROM trading.trades t
+		JOIN trading.orders o ON o.id IN (t.buy_order_id, t.sell_order_id)
+		WHERE t.created_at >= $1
+		GROUP BY o.user_id`
+
+	var rows []struct {
+		UserID string  `db:"user_id"`
+		Volume float64 `db:"volume"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, since); err != nil {
+		return nil, fmt.Errorf("failed to get user volumes: %w", err)
+	}
+
+	volumes := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		volumes[row.UserID] = row.Volume
+	}
+
+	return volumes, nil
+}
+
+// GetNetPosition returns the shares a user has bought less the shares the
+// user has sold in a symbol. A trade between two orders of the same user
+// nets out.
+func (r *PostgresTradeRepository) GetNetPosition(ctx context.Context, userID, symbol string) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN o.side = 'BUY' THEN t.quantity ELSE -t.quantity END), 0)
+		FROM trading.trades t
+		JOIN trading.orders o ON o.id IN (t.buy_order_id, t.sell_order_id)
+		WHERE o.user_id = $1 AND t.symbol = $2`
+
+	var position float64
+	if err := r.db.GetContext(ctx, &position, query, userID, symbol); err != nil {
+		return 0, fmt.Errorf("failed to get net position: %w", err)
+	}
+
+	return position, nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"simulated_exchange/pkg/shared"
 )
 
@@ -191,4 +192,130 @@ func (r *PostgresUserRepository) CheckEmailExists(ctx context.Context, email str
 	}
 
 	return exists, nil
+}
+
+// apiKeyRow is the database representation of an API key
+type apiKeyRow struct {
+	ID         string         `db:"id"`
+	UserID     string         `db:"user_id"`
+	Name       string         `db:"name"`
+	SecretHash string         `db:"secret_hash"`
+	SigningKey sql.NullString `db:"signing_key_encrypted"`
+	Scopes     pq.StringArray `db:"scopes"`
+	CreatedAt  time.Time      `db:"created_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+}
+
+func (row *apiKeyRow) toAPIKey() *shared.APIKey {
+	scopes := make([]shared.APIKeyScope, len(row.Scopes))
+	for i, scope := range row.Scopes {
+		scopes[i] = shared.APIKeyScope(scope)
+	}
+
+	return &shared.APIKey{
+		ID:                  row.ID,
+		UserID:              row.UserID,
+		Name:                row.Name,
+		SecretHash:          row.SecretHash,
+		SigningKeyEncrypted: row.SigningKey.String,
+		Scopes:              scopes,
+		CreatedAt:           row.CreatedAt,
+		LastUsedAt:          row.LastUsedAt,
+		RevokedAt:           row.RevokedAt,
+	}
+}
+
+// CreateAPIKey inserts a new API key for a user
+func (r *PostgresUserRepository) CreateAPIKey(ctx context.Context, key *shared.APIKey) error {
+	query := `
+		INSERT INTO trading.api_keys (id, user_id, name, secret_hash, signing_key_encrypted, scopes, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)`
+
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		key.ID, key.UserID, key.Name, key.SecretHash, key.SigningKeyEncrypted, pq.Array(scopes), key.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKey retrieves an API key by its key ID
+func (r *PostgresUserRepository) GetAPIKey(ctx context.Context, keyID string) (*shared.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, secret_hash, signing_key_encrypted, scopes, created_at, last_used_at, revoked_at
+		FROM trading.api_keys
+		WHERE id = $1`
+
+	var row apiKeyRow
+	err := r.db.GetContext(ctx, &row, query, keyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, shared.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return row.toAPIKey(), nil
+}
+
+// GetAPIKeysByUserID retrieves all API keys issued to a user
+func (r *PostgresUserRepository) GetAPIKeysByUserID(ctx context.Context, userID string) ([]*shared.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, secret_hash, signing_key_encrypted, scopes, created_at, last_used_at, revoked_at
+		FROM trading.api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	var rows []apiKeyRow
+	err := r.db.SelectContext(ctx, &rows, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys by user ID: %w", err)
+	}
+
+	result := make([]*shared.APIKey, len(rows))
+	for i := range rows {
+		result[i] = rows[i].toAPIKey()
+	}
+
+	return result, nil
+}
+
+// RevokeAPIKey marks an API key as revoked
+func (r *PostgresUserRepository) RevokeAPIKey(ctx context.Context, keyID string) error {
+	query := `UPDATE trading.api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, keyID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return shared.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchAPIKey records the last time an API key was used
+func (r *PostgresUserRepository) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	query := `UPDATE trading.api_keys SET last_used_at = $2 WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, keyID, usedAt); err != nil {
+		return fmt.Errorf("failed to touch api key: %w", err)
+	}
+
+	return nil
 }
\ No newline at end of file
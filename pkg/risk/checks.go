@@ -0,0 +1,220 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// OrderSizeCheck rejects orders larger than MaxOrderQuantity
+type OrderSizeCheck struct{}
+
+func (OrderSizeCheck) Rule() Rule { return RuleOrderSize }
+
+func (OrderSizeCheck) Evaluate(ctx context.Context, order *Order, limits config.RiskLimits, state State) (*Rejection, error) {
+	if limits.MaxOrderQuantity <= 0 || order.Quantity <= limits.MaxOrderQuantity {
+		return nil, nil
+	}
+	return &Rejection{
+		Rule:   RuleOrderSize,
+		Code:   shared.ErrCodeOrderSizeLimit,
+		Reason: fmt.Sprintf("quantity %.8g exceeds limit %.8g", order.Quantity, limits.MaxOrderQuantity),
+	}, nil
+}
+
+// OrderNotionalCheck rejects orders worth more than MaxOrderNotional. Market
+// orders without a price are valued at the last traded price.
+type OrderNotionalCheck struct{}
+
+func (OrderNotionalCheck) Rule() Rule { return RuleOrderNotional }
+
+func (OrderNotionalCheck) Evaluate(ctx context.Context, order *Order, limits config.RiskLimits, state State) (*Rejection, error) {
+	if limits.MaxOrderNotional <= 0 {
+		return nil, nil
+	}
+
+	price := order.Price
+	if price <= 0 {
+		lastPrice, err := state.LastPrice(ctx, order.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		price = lastPrice
+	}
+
+	notional := order.Quantity * price
+	if notional <= limits.MaxOrderNotional {
+		return nil, nil
+	}
+	return &Rejection{
+		Rule:   RuleOrderNotional,
+		Code:   shared.ErrCodeOrderNotionalLimit,
+		Reason: fmt.Sprintf("notional %.2f exceeds limit %.2f", notional, limits.MaxOrderNotional),
+	}, nil
+}
+
+// PriceCollarCheck rejects limit orders priced further than PriceCollar (a
+// fraction, e.g. 0.1 for 10%) from the last traded price. Symbols that
+// haven't traded yet are not collared.
+type PriceCollarCheck struct{}
+
+func (PriceCollarCheck) Rule() Rule { return RulePriceCollar }
+
+func (PriceCollarCheck) Evaluate(ctx context.Context, order *Order, limits config.RiskLimits, state State) (*Rejection, error) {
+	if limits.PriceCollar <= 0 || order.Market || order.Price <= 0 {
+		return nil, nil
+	}
+
+	lastPrice, err := state.LastPrice(ctx, order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if lastPrice <= 0 {
+		return nil, nil
+	}
+
+	deviation := math.Abs(order.Price-lastPrice) / lastPrice
+	if deviation <= limits.PriceCollar {
+		return nil, nil
+	}
+	return &Rejection{
+		Rule: RulePriceCollar,
+		Code: shared.ErrCodePriceCollar,
+		Reason: fmt.Sprintf("price %.2f is %.1f%% from last trade %.2f, collar is %.1f%%",
+			order.Price, deviation*100, lastPrice, limits.PriceCollar*100),
+	}, nil
+}
+
+// OpenOrdersCheck rejects orders from users who already have MaxOpenOrders
+// resting orders
+type OpenOrdersCheck struct{}
+
+func (OpenOrdersCheck) Rule() Rule { return RuleOpenOrders }
+
+func (OpenOrdersCheck) Evaluate(ctx context.Context, order *Order, limits config.RiskLimits, state State) (*Rejection, error) {
+	if limits.MaxOpenOrders <= 0 {
+		return nil, nil
+	}
+
+	open, err := state.OpenOrderCount(ctx, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if open < limits.MaxOpenOrders {
+		return nil, nil
+	}
+	return &Rejection{
+		Rule:   RuleOpenOrders,
+		Code:   shared.ErrCodeOpenOrderLimit,
+		Reason: fmt.Sprintf("%d open orders, limit is %d", open, limits.MaxOpenOrders),
+	}, nil
+}
+
+// PositionCheck rejects orders that would take the user's net position in
+// the symbol beyond MaxPosition, long or short, if fully filled
+type PositionCheck struct{}
+
+func (PositionCheck) Rule() Rule { return RulePosition }
+
+func (PositionCheck) Evaluate(ctx context.Context, order *Order, limits config.RiskLimits, state State) (*Rejection, error) {
+	if limits.MaxPosition <= 0 {
+		return nil, nil
+	}
+
+	position, err := state.Position(ctx, order.UserID, order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	projected := position - order.Quantity
+	if order.IsBuy() {
+		projected = position + order.Quantity
+	}
+	if math.Abs(projected) <= limits.MaxPosition {
+		return nil, nil
+	}
+	return &Rejection{
+		Rule:   RulePosition,
+		Code:   shared.ErrCodePositionLimit,
+		Reason: fmt.Sprintf("position would be %.8g, limit is %.8g", projected, limits.MaxPosition),
+	}, nil
+}
+
+// OrderRateCheck rejects orders from users submitting faster than
+// MaxOrdersPerSecond. Each user has a token bucket holding one second's
+// worth of orders.
+type OrderRateCheck struct {
+	buckets   map[string]*orderBucket
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+// orderBucket is the token bucket of a single user
+type orderBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewOrderRateCheck creates an order rate check
+func NewOrderRateCheck() *OrderRateCheck {
+	return &OrderRateCheck{
+		buckets:   make(map[string]*orderBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *OrderRateCheck) Rule() Rule { return RuleOrderRate }
+
+func (c *OrderRateCheck) Evaluate(ctx context.Context, order *Order, limits config.RiskLimits, state State) (*Rejection, error) {
+	rate := limits.MaxOrdersPerSecond
+	if rate <= 0 {
+		return nil, nil
+	}
+	capacity := math.Max(1, math.Ceil(rate))
+	now := time.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.sweep(now)
+
+	bucket, exists := c.buckets[order.UserID]
+	if !exists {
+		bucket = &orderBucket{tokens: capacity, lastRefill: now}
+		c.buckets[order.UserID] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*rate)
+	bucket.lastRefill = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return nil, nil
+	}
+	return &Rejection{
+		Rule:   RuleOrderRate,
+		Code:   shared.ErrCodeOrderRateLimit,
+		Reason: fmt.Sprintf("more than %.8g orders per second", rate),
+	}, nil
+}
+
+// sweep drops buckets idle for long enough to have refilled. Must be called
+// with the mutex held.
+func (c *OrderRateCheck) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < time.Minute {
+		return
+	}
+	c.lastSweep = now
+
+	for userID, bucket := range c.buckets {
+		if now.Sub(bucket.lastRefill) > time.Minute {
+			delete(c.buckets, userID)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// fakeState is a State with fixed answers
+type fakeState struct {
+	openOrders int
+	position   float64
+	lastPrice  float64
+	err        error
+}
+
+func (s *fakeState) OpenOrderCount(ctx context.Context, userID string) (int, error) {
+	return s.openOrders, s.err
+}
+
+func (s *fakeState) Position(ctx context.Context, userID, symbol string) (float64, error) {
+	return s.position, s.err
+}
+
+func (s *fakeState) LastPrice(ctx context.Context, symbol string) (float64, error) {
+	return s.lastPrice, s.err
+}
+
+func limitBuy(quantity, price float64) *Order {
+	return &Order{UserID: "u1", Symbol: "AAPL", Side: "BUY", Quantity: quantity, Price: price}
+}
+
+func limitSell(quantity, price float64) *Order {
+	return &Order{UserID: "u1", Symbol: "AAPL", Side: "SELL", Quantity: quantity, Price: price}
+}
+
+func marketBuy(quantity float64) *Order {
+	return &Order{UserID: "u1", Symbol: "AAPL", Side: "BUY", Market: true, Quantity: quantity}
+}
+
+// checkCase is an order evaluated by a check, and the code it is rejected
+// with, empty if it passes
+type checkCase struct {
+	name   string
+	order  *Order
+	limits config.RiskLimits
+	state  *fakeState
+	code   string
+}
+
+func runCheckCases(t *testing.T, check Check, tests []checkCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			if state == nil {
+				state = &fakeState{}
+			}
+
+			rejection, err := check.Evaluate(context.Background(), tt.order, tt.limits, state)
+			require.NoError(t, err)
+			if tt.code == "" {
+				assert.Nil(t, rejection)
+				return
+			}
+			require.NotNil(t, rejection)
+			assert.Equal(t, check.Rule(), rejection.Rule)
+			assert.Equal(t, tt.code, rejection.Code)
+			assert.NotEmpty(t, rejection.Reason)
+		})
+	}
+}
+
+func TestOrderSizeCheck(t *testing.T) {
+	limits := config.RiskLimits{MaxOrderQuantity: 100}
+	runCheckCases(t, OrderSizeCheck{}, []checkCase{
+		{name: "within the limit", order: limitBuy(99, 10), limits: limits},
+		{name: "at the limit", order: limitBuy(100, 10), limits: limits},
+		{name: "beyond the limit", order: limitBuy(101, 10), limits: limits, code: shared.ErrCodeOrderSizeLimit},
+		{name: "disabled", order: limitBuy(1e9, 10)},
+	})
+}
+
+func TestOrderNotionalCheck(t *testing.T) {
+	limits := config.RiskLimits{MaxOrderNotional: 1000}
+	runCheckCases(t, OrderNotionalCheck{}, []checkCase{
+		{name: "limit order at the limit", order: limitBuy(10, 100), limits: limits},
+		{name: "limit order beyond the limit", order: limitBuy(10, 100.01), limits: limits, code: shared.ErrCodeOrderNotionalLimit},
+		{name: "limit order valued at its own price", order: limitSell(10, 100), limits: limits, state: &fakeState{lastPrice: 500}},
+		{name: "market order valued at the last price", order: marketBuy(10), limits: limits, state: &fakeState{lastPrice: 101}, code: shared.ErrCodeOrderNotionalLimit},
+		{name: "market order within the limit", order: marketBuy(10), limits: limits, state: &fakeState{lastPrice: 99}},
+		{name: "market order before the first trade", order: marketBuy(1e9), limits: limits},
+		{name: "disabled", order: limitBuy(1e9, 1e9)},
+	})
+}
+
+func TestPriceCollarCheck(t *testing.T) {
+	limits := config.RiskLimits{PriceCollar: 0.1}
+	traded := &fakeState{lastPrice: 100}
+	runCheckCases(t, PriceCollarCheck{}, []checkCase{
+		{name: "at the last price", order: limitBuy(1, 100), limits: limits, state: traded},
+		{name: "at the top of the collar", order: limitBuy(1, 110), limits: limits, state: traded},
+		{name: "at the bottom of the collar", order: limitSell(1, 90), limits: limits, state: traded},
+		{name: "above the collar", order: limitBuy(1, 110.5), limits: limits, state: traded, code: shared.ErrCodePriceCollar},
+		{name: "below the collar", order: limitSell(1, 89.5), limits: limits, state: traded, code: shared.ErrCodePriceCollar},
+		{name: "market orders are not collared", order: &Order{Side: "BUY", Market: true, Quantity: 1, Price: 500}, limits: limits, state: traded},
+		{name: "symbols without trades are not collared", order: limitBuy(1, 500), limits: limits},
+		{name: "disabled", order: limitBuy(1, 500), state: traded},
+	})
+}
+
+func TestOpenOrdersCheck(t *testing.T) {
+	limits := config.RiskLimits{MaxOpenOrders: 3}
+	runCheckCases(t, OpenOrdersCheck{}, []checkCase{
+		{name: "below the limit", order: limitBuy(1, 10), limits: limits, state: &fakeState{openOrders: 2}},
+		{name: "at the limit", order: limitBuy(1, 10), limits: limits, state: &fakeState{openOrders: 3}, code: shared.ErrCodeOpenOrderLimit},
+		{name: "disabled", order: limitBuy(1, 10), state: &fakeState{openOrders: 1e6}},
+	})
+}
+
+func TestPositionCheck(t *testing.T) {
+	limits := config.RiskLimits{MaxPosition: 100}
+	runCheckCases(t, PositionCheck{}, []checkCase{
+		{name: "buy up to the limit", order: limitBuy(40, 10), limits: limits, state: &fakeState{position: 60}},
+		{name: "buy beyond the limit", order: limitBuy(41, 10), limits: limits, state: &fakeState{position: 60}, code: shared.ErrCodePositionLimit},
+		{name: "sell down to the short limit", order: limitSell(160, 10), limits: limits, state: &fakeState{position: 60}},
+		{name: "sell beyond the short limit", order: limitSell(161, 10), limits: limits, state: &fakeState{position: 60}, code: shared.ErrCodePositionLimit},
+		{name: "sell leaving a position beyond the limit", order: limitSell(10, 10), limits: limits, state: &fakeState{position: 150}, code: shared.ErrCodePositionLimit},
+		{name: "sell bringing a position back to the limit", order: limitSell(50, 10), limits: limits, state: &fakeState{position: 150}},
+		{name: "buy reducing a short position", order: marketBuy(50), limits: limits, state: &fakeState{position: -120}},
+		{name: "disabled", order: limitBuy(1e9, 10)},
+	})
+}
+
+func TestChecksReportStateErrors(t *testing.T) {
+	broken := &fakeState{err: errors.New("database unavailable")}
+	limits := config.RiskLimits{MaxOrderNotional: 1, PriceCollar: 0.1, MaxOpenOrders: 1, MaxPosition: 1}
+
+	for _, check := range []Check{OrderNotionalCheck{}, PriceCollarCheck{}, OpenOrdersCheck{}, PositionCheck{}} {
+		t.Run(string(check.Rule()), func(t *testing.T) {
+			order := limitBuy(1, 10)
+			if check.Rule() == RuleOrderNotional {
+				order = marketBuy(1)
+			}
+			rejection, err := check.Evaluate(context.Background(), order, limits, broken)
+			assert.EqualError(t, err, "database unavailable")
+			assert.Nil(t, rejection)
+		})
+	}
+}
+
+func TestOrderRateCheck(t *testing.T) {
+	ctx := context.Background()
+	check := NewOrderRateCheck()
+	limits := config.RiskLimits{MaxOrdersPerSecond: 2}
+	state := &fakeState{}
+
+	evaluate := func(userID string) *Rejection {
+		t.Helper()
+		rejection, err := check.Evaluate(ctx, &Order{UserID: userID, Side: "BUY", Quantity: 1}, limits, state)
+		require.NoError(t, err)
+		return rejection
+	}
+
+	// A bucket holds one second's worth of orders
+	assert.Nil(t, evaluate("u1"))
+	assert.Nil(t, evaluate("u1"))
+	rejection := evaluate("u1")
+	require.NotNil(t, rejection)
+	assert.Equal(t, RuleOrderRate, rejection.Rule)
+	assert.Equal(t, shared.ErrCodeOrderRateLimit, rejection.Code)
+
+	// Users have their own buckets
+	assert.Nil(t, evaluate("u2"))
+
+	// Tokens refill at the rate
+	check.buckets["u1"].lastRefill = check.buckets["u1"].lastRefill.Add(-500 * time.Millisecond)
+	assert.Nil(t, evaluate("u1"))
+	assert.NotNil(t, evaluate("u1"))
+
+	// Disabled, nothing is rate limited
+	rejection, err := check.Evaluate(ctx, &Order{UserID: "u1"}, config.RiskLimits{}, state)
+	require.NoError(t, err)
+	assert.Nil(t, rejection)
+}
+
+func TestOrderRateCheck_FractionalRateHoldsOneOrder(t *testing.T) {
+	check := NewOrderRateCheck()
+	limits := config.RiskLimits{MaxOrdersPerSecond: 0.5}
+
+	rejection, err := check.Evaluate(context.Background(), limitBuy(1, 10), limits, &fakeState{})
+	require.NoError(t, err)
+	assert.Nil(t, rejection)
+	rejection, err = check.Evaluate(context.Background(), limitBuy(1, 10), limits, &fakeState{})
+	require.NoError(t, err)
+	assert.NotNil(t, rejection)
+}
+
+func TestOrderRateCheck_SweepsIdleBuckets(t *testing.T) {
+	check := NewOrderRateCheck()
+	limits := config.RiskLimits{MaxOrdersPerSecond: 10}
+	for _, userID := range []string{"idle", "active"} {
+		_, err := check.Evaluate(context.Background(), &Order{UserID: userID}, limits, &fakeState{})
+		require.NoError(t, err)
+	}
+
+	check.lastSweep = check.lastSweep.Add(-2 * time.Minute)
+	check.buckets["idle"].lastRefill = check.buckets["idle"].lastRefill.Add(-2 * time.Minute)
+	_, err := check.Evaluate(context.Background(), &Order{UserID: "active"}, limits, &fakeState{})
+	require.NoError(t, err)
+
+	assert.NotContains(t, check.buckets, "idle")
+	assert.Contains(t, check.buckets, "active")
+}
@@ -0,0 +1,160 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"simulated_exchange/pkg/config"
+)
+
+// Rule identifies a pre-trade check in metrics and logs
+type Rule string
+
+const (
+	RuleOrderSize     Rule = "order_size"
+	RuleOrderNotional Rule = "order_notional"
+	RulePriceCollar   Rule = "price_collar"
+	RuleOrderRate     Rule = "order_rate"
+	RuleOpenOrders    Rule = "open_orders"
+	RulePosition      Rule = "position"
+)
+
+// Order is the view of an order the risk checks evaluate. Price is zero for
+// market orders without a price.
+type Order struct {
+	UserID   string
+	Symbol   string
+	Side     string
+	Market   bool
+	Quantity float64
+	Price    float64
+}
+
+// IsBuy reports whether the order buys
+func (o *Order) IsBuy() bool {
+	return o.Side == "BUY"
+}
+
+// State provides the exchange state the checks compare orders against
+type State interface {
+	// OpenOrderCount returns the number of resting orders a user has
+	OpenOrderCount(ctx context.Context, userID string) (int, error)
+
+	// Position returns a user's net position in a symbol
+	Position(ctx context.Context, userID, symbol string) (float64, error)
+
+	// LastPrice returns the last traded price of a symbol, or zero if the
+	// symbol hasn't traded yet
+	LastPrice(ctx context.Context, symbol string) (float64, error)
+}
+
+// Check is a single pre-trade rule. Evaluate returns a *Rejection if the
+// order breaches the limits, and an error only if the check couldn't run.
+type Check interface {
+	Rule() Rule
+	Evaluate(ctx context.Context, order *Order, limits config.RiskLimits, state State) (*Rejection, error)
+}
+
+// Recorder records rejected orders
+type Recorder interface {
+	RecordRiskRejection(service, rule string)
+}
+
+// Rejection describes an order refused by a risk check. Code is one of the
+// shared.ErrCode constants.
+type Rejection struct {
+	Rule   Rule
+	Code   string
+	Reason string
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("risk check %s failed: %s - %s", r.Rule, r.Code, r.Reason)
+}
+
+// Pipeline runs a sequence of checks against every order before matching.
+// The first failing check rejects the order.
+type Pipeline struct {
+	service  string
+	config   config.RiskConfig
+	checks   []Check
+	state    State
+	recorder Recorder
+	logger   *slog.Logger
+}
+
+// NewPipeline creates a risk pipeline. With no checks the default checks
+// are used. A nil recorder disables rejection metrics.
+func NewPipeline(
+	service string,
+	cfg config.RiskConfig,
+	state State,
+	recorder Recorder,
+	logger *slog.Logger,
+	checks ...Check,
+) *Pipeline {
+	if len(checks) == 0 {
+		checks = DefaultChecks()
+	}
+
+	return &Pipeline{
+		service:  service,
+		config:   cfg,
+		checks:   checks,
+		state:    state,
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// DefaultChecks returns the standard checks, cheapest first
+func DefaultChecks() []Check {
+	return []Check{
+		OrderSizeCheck{},
+		OrderNotionalCheck{},
+		PriceCollarCheck{},
+		NewOrderRateCheck(),
+		OpenOrdersCheck{},
+		PositionCheck{},
+	}
+}
+
+// Check runs the order through every check. It returns a *Rejection if a
+// check refuses the order.
+func (p *Pipeline) Check(ctx context.Context, order *Order) error {
+	if !p.config.Enabled {
+		return nil
+	}
+
+	limits := p.config.LimitsFor(order.UserID, order.Symbol)
+
+	for _, check := range p.checks {
+		rejection, err := check.Evaluate(ctx, order, limits, p.state)
+		if err != nil {
+			return fmt.Errorf("risk check %s: %w", check.Rule(), err)
+		}
+		if rejection == nil {
+			continue
+		}
+
+		if p.recorder != nil {
+			p.recorder.RecordRiskRejection(p.service, string(rejection.Rule))
+		}
+		if p.logger != nil {
+			p.logger.Info("Order failed risk check",
+				"user_id", order.UserID,
+				"symbol", order.Symbol,
+				"side", order.Side,
+				"quantity", order.Quantity,
+				"price", order.Price,
+				"rule", rejection.Rule,
+				"code", rejection.Code,
+				"reason", rejection.Reason,
+			)
+		}
+		return rejection
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+)
+
+// stubCheck rejects or fails every order and records the limits it ran with
+type stubCheck struct {
+	rule      Rule
+	rejection *Rejection
+	err       error
+	limits    []config.RiskLimits
+}
+
+func (c *stubCheck) Rule() Rule { return c.rule }
+
+func (c *stubCheck) Evaluate(ctx context.Context, order *Order, limits config.RiskLimits, state State) (*Rejection, error) {
+	c.limits = append(c.limits, limits)
+	return c.rejection, c.err
+}
+
+// countingRecorder counts rejections per service and rule
+type countingRecorder map[string]int
+
+func (r countingRecorder) RecordRiskRejection(service, rule string) {
+	r[service+"/"+rule]++
+}
+
+func TestPipeline_FirstRejectionWins(t *testing.T) {
+	passing := &stubCheck{rule: RuleOrderSize}
+	rejecting := &stubCheck{rule: RulePriceCollar, rejection: &Rejection{Rule: RulePriceCollar, Code: "PRICE_COLLAR", Reason: "too far"}}
+	skipped := &stubCheck{rule: RulePosition, rejection: &Rejection{Rule: RulePosition}}
+	recorder := countingRecorder{}
+
+	pipeline := NewPipeline("trading-api", config.RiskConfig{Enabled: true}, &fakeState{}, recorder, nil, passing, rejecting, skipped)
+	err := pipeline.Check(context.Background(), limitBuy(1, 10))
+
+	var rejection *Rejection
+	require.ErrorAs(t, err, &rejection)
+	assert.Same(t, rejecting.rejection, rejection)
+	assert.EqualError(t, err, "risk check price_collar failed: PRICE_COLLAR - too far")
+	assert.Len(t, passing.limits, 1)
+	assert.Empty(t, skipped.limits)
+	assert.Equal(t, countingRecorder{"trading-api/price_collar": 1}, recorder)
+}
+
+func TestPipeline_PassesOrdersWithinLimits(t *testing.T) {
+	checks := []*stubCheck{{rule: RuleOrderSize}, {rule: RuleOpenOrders}}
+	recorder := countingRecorder{}
+
+	pipeline := NewPipeline("trading-api", config.RiskConfig{Enabled: true}, &fakeState{}, recorder, nil, checks[0], checks[1])
+	require.NoError(t, pipeline.Check(context.Background(), limitBuy(1, 10)))
+
+	for _, check := range checks {
+		assert.Len(t, check.limits, 1)
+	}
+	assert.Empty(t, recorder)
+}
+
+func TestPipeline_Disabled(t *testing.T) {
+	check := &stubCheck{rule: RuleOrderSize, rejection: &Rejection{Rule: RuleOrderSize}}
+
+	pipeline := NewPipeline("trading-api", config.RiskConfig{}, &fakeState{}, nil, nil, check)
+	assert.NoError(t, pipeline.Check(context.Background(), limitBuy(1, 10)))
+	assert.Empty(t, check.limits)
+}
+
+func TestPipeline_CheckErrors(t *testing.T) {
+	failing := &stubCheck{rule: RuleOpenOrders, err: errors.New("database unavailable")}
+	skipped := &stubCheck{rule: RulePosition}
+	recorder := countingRecorder{}
+
+	pipeline := NewPipeline("trading-api", config.RiskConfig{Enabled: true}, &fakeState{}, recorder, nil, failing, skipped)
+	err := pipeline.Check(context.Background(), limitBuy(1, 10))
+
+	assert.EqualError(t, err, "risk check open_orders: database unavailable")
+	var rejection *Rejection
+	assert.False(t, errors.As(err, &rejection))
+	assert.Empty(t, skipped.limits)
+	assert.Empty(t, recorder)
+}
+
+func TestPipeline_AppliesOverrides(t *testing.T) {
+	cfg := config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{MaxOrderQuantity: 100, PriceCollar: 0.1, MaxOpenOrders: 10},
+		Symbols: map[string]config.RiskLimits{"AAPL": {MaxOrderQuantity: 50, PriceCollar: 0.05}},
+		Users:   map[string]config.RiskLimits{"vip": {MaxOrderQuantity: 500}},
+	}
+	check := &stubCheck{rule: RuleOrderSize}
+	pipeline := NewPipeline("trading-api", cfg, &fakeState{}, nil, nil, check)
+
+	orders := []*Order{
+		{UserID: "u1", Symbol: "MSFT"},
+		{UserID: "u1", Symbol: "AAPL"},
+		{UserID: "vip", Symbol: "AAPL"},
+	}
+	for _, order := range orders {
+		require.NoError(t, pipeline.Check(context.Background(), order))
+	}
+
+	assert.Equal(t, []config.RiskLimits{
+		{MaxOrderQuantity: 100, PriceCollar: 0.1, MaxOpenOrders: 10},
+		{MaxOrderQuantity: 50, PriceCollar: 0.05, MaxOpenOrders: 10},
+		{MaxOrderQuantity: 500, PriceCollar: 0.05, MaxOpenOrders: 10},
+	}, check.limits)
+}
+
+func TestNewPipeline_DefaultChecks(t *testing.T) {
+	pipeline := NewPipeline("trading-api", config.RiskConfig{Enabled: true}, &fakeState{}, nil, nil)
+
+	var rules []Rule
+	for _, check := range pipeline.checks {
+		rules = append(rules, check.Rule())
+	}
+	assert.Equal(t, []Rule{RuleOrderSize, RuleOrderNotional, RulePriceCollar, RuleOrderRate, RuleOpenOrders, RulePosition}, rules)
+
+	// Each pipeline counts order rates on its own
+	other := NewPipeline("trading-api", config.RiskConfig{Enabled: true}, &fakeState{}, nil, nil)
+	assert.NotSame(t, pipeline.checks[3], other.checks[3])
+}
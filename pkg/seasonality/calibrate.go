@@ -0,0 +1,239 @@
+package seasonality
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBucket is the width of the intraday buckets of profiles
+// calibrated from a file
+const DefaultBucket = 30 * time.Minute
+
+// Bar is a historical price bar: its time, close and traded volume
+type Bar struct {
+	Time   time.Time
+	Close  float64
+	Volume float64
+}
+
+// barTimeLayouts are the timestamps of bars without a time zone; they are
+// read in the market's location
+var barTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05"}
+
+// ReadBars reads historical bars from CSV with a header naming the
+// timestamp (or time), close and volume columns; other columns are
+// ignored. Timestamps are RFC 3339, Unix seconds or "2006-01-02 15:04:05"
+// in location.
+func ReadBars(r io.Reader, location *time.Location) ([]Bar, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := map[string]int{"time": -1, "close": -1, "volume": -1}
+	for i, name := range header {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "timestamp", "time":
+			columns["time"] = i
+		case "close", "volume":
+			columns[name] = i
+		}
+	}
+	for _, name := range []string{"time", "close", "volume"} {
+		if columns[name] < 0 {
+			return nil, fmt.Errorf("header has no %s column", name)
+		}
+	}
+
+	var bars []Bar
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(record) <= columns["time"] || len(record) <= columns["close"] || len(record) <= columns["volume"] {
+			return nil, fmt.Errorf("line %d: missing columns", line)
+		}
+
+		var bar Bar
+		if bar.Time, err = parseBarTime(strings.TrimSpace(record[columns["time"]]), location); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if bar.Close, err = strconv.ParseFloat(strings.TrimSpace(record[columns["close"]]), 64); err != nil || bar.Close <= 0 {
+			return nil, fmt.Errorf("line %d: invalid close %q", line, record[columns["close"]])
+		}
+		if bar.Volume, err = strconv.ParseFloat(strings.TrimSpace(record[columns["volume"]]), 64); err != nil || bar.Volume < 0 {
+			return nil, fmt.Errorf("line %d: invalid volume %q", line, record[columns["volume"]])
+		}
+		bars = append(bars, bar)
+	}
+
+	return bars, nil
+}
+
+// parseBarTime parses the timestamp of a bar
+func parseBarTime(value string, location *time.Location) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	for _, layout := range barTimeLayouts {
+		if at, err := time.ParseInLocation(layout, value, location); err == nil {
+			return at, nil
+		}
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+// sample accumulates the volume and squared log returns of bars
+type sample struct {
+	volume  float64
+	squares float64
+	bars    int
+	returns int
+}
+
+func (s *sample) add(other sample) {
+	s.volume += other.volume
+	s.squares += other.squares
+	s.bars += other.bars
+	s.returns += other.returns
+}
+
+// factors returns the mean volume and the root mean square return of the
+// sample relative to those of base. A sample without returns keeps the
+// base volatility.
+func (s sample) factors(base sample) Factors {
+	factors := Factors{
+		Volume:     (s.volume / float64(s.bars)) / (base.volume / float64(base.bars)),
+		Volatility: 1,
+	}
+	if s.returns > 0 {
+		factors.Volatility = math.Sqrt((s.squares / float64(s.returns)) / (base.squares / float64(base.returns)))
+	}
+	return factors
+}
+
+// Calibrate measures a profile from historical bars. Each intraday bucket,
+// weekday and the holidays get the mean bar volume and the root mean
+// square log return of their bars relative to those of all bars other
+// than the holidays', so that the average bar is neutral. Returns only
+// count within a day, as overnight gaps aren't traded. Buckets and weekdays
+// without bars take the factors of the quietest one, and holidays without
+// bars the standard profile's.
+func Calibrate(bars []Bar, bucket time.Duration, location *time.Location, holidays []string) (*Profile, error) {
+	if bucket <= 0 || (24*time.Hour)%bucket != 0 {
+		return nil, fmt.Errorf("bucket %v does not divide a day", bucket)
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	holidaySet, err := parseHolidays(holidays)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]Bar(nil), bars...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	intraday := make([]sample, 24*time.Hour/bucket)
+	var weekdays [7]sample
+	var holiday, total sample
+	var previousDate string
+	for i, bar := range sorted {
+		local := bar.Time.In(location)
+		date := local.Format(dateLayout)
+
+		s := sample{volume: bar.Volume, bars: 1}
+		if i > 0 && date == previousDate && sorted[i-1].Close > 0 && bar.Close > 0 {
+			r := math.Log(bar.Close / sorted[i-1].Close)
+			s.squares = r * r
+			s.returns = 1
+		}
+		previousDate = date
+
+		if holidaySet[date] {
+			holiday.add(s)
+			continue
+		}
+		offset := timeOfDay(local.Hour(), local.Minute()) + time.Duration(local.Second())*time.Second
+		intraday[offset/bucket].add(s)
+		weekdays[local.Weekday()].add(s)
+		total.add(s)
+	}
+
+	if total.bars == 0 || total.volume <= 0 || total.squares <= 0 {
+		return nil, fmt.Errorf("not enough bars: need traded volume and price moves within a day")
+	}
+
+	p := Flat()
+	p.location = location
+	for date := range holidaySet {
+		p.holidays[date] = true
+	}
+
+	p.Intraday = make([]Bucket, len(intraday))
+	for i, s := range intraday {
+		p.Intraday[i].Start = time.Duration(i) * bucket
+		if s.bars > 0 {
+			p.Intraday[i].Factors = s.factors(total)
+		}
+	}
+	quietest := quietestFactors(intraday, total)
+	for i, s := range intraday {
+		if s.bars == 0 {
+			p.Intraday[i].Factors = quietest
+		}
+	}
+
+	quietest = quietestFactors(weekdays[:], total)
+	for day, s := range weekdays {
+		p.Weekdays[day] = quietest
+		if s.bars > 0 {
+			p.Weekdays[day] = s.factors(total)
+		}
+	}
+
+	p.Holiday = standardHoliday
+	if holiday.bars > 0 {
+		p.Holiday = holiday.factors(total)
+	}
+
+	return p, nil
+}
+
+// quietestFactors returns the factors of the sample with the least volume
+// per bar
+func quietestFactors(samples []sample, base sample) Factors {
+	quietest := Neutral
+	found := false
+	for _, s := range samples {
+		if s.bars == 0 {
+			continue
+		}
+		if factors := s.factors(base); !found || factors.Volume < quietest.Volume {
+			quietest = factors
+			found = true
+		}
+	}
+	return quietest
+}
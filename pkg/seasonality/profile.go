@@ -0,0 +1,353 @@
+// Package seasonality models how trading activity varies over the day, the
+// week and holidays. A Profile gives the factors order arrival rates and
+// price volatility are scaled by at any time, so that every simulator
+// follows the same session shape.
+package seasonality
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"simulated_exchange/pkg/config"
+)
+
+// dateLayout is the layout of holidays
+const dateLayout = "2006-01-02"
+
+// Factors scale order arrival rates and price volatility relative to their
+// configured base
+type Factors struct {
+	Volume     float64 `json:"volume" yaml:"volume"`
+	Volatility float64 `json:"volatility" yaml:"volatility"`
+}
+
+// Neutral leaves rates and volatility unchanged
+var Neutral = Factors{Volume: 1, Volatility: 1}
+
+// standardHoliday is how quiet holidays are unless a profile says otherwise
+var standardHoliday = Factors{Volume: 0.5, Volatility: 0.8}
+
+// Bucket applies its factors from Start, the time since midnight, up to the
+// start of the next bucket
+type Bucket struct {
+	Start time.Duration
+	Factors
+}
+
+// Profile is an intraday, weekly and holiday activity profile. Times are
+// read in the profile's location; the factors of a time are those of its
+// intraday bucket times those of its weekday, or of Holiday on holidays.
+type Profile struct {
+	// Intraday is sorted by start; before the first bucket the last one
+	// carries over from the previous day
+	Intraday []Bucket
+	Weekdays [7]Factors
+	Holiday  Factors
+
+	holidays map[string]bool
+	location *time.Location
+}
+
+// Flat returns a profile that leaves activity unchanged at all times
+func Flat() *Profile {
+	p := &Profile{
+		Holiday:  Neutral,
+		holidays: make(map[string]bool),
+		location: time.UTC,
+	}
+	for day := range p.Weekdays {
+		p.Weekdays[day] = Neutral
+	}
+	return p
+}
+
+// Standard returns the U-shaped profile of an equity session from 09:30 to
+// 16:00: volume and volatility peak at the open and the close with a lull
+// over lunch. Outside the session activity stays at its base since the
+// simulated markets trade around the clock; weekends and holidays are
+// quieter.
+func Standard() *Profile {
+	p := Flat()
+	p.Intraday = []Bucket{
+		{Start: 0, Factors: Neutral},
+		{Start: timeOfDay(9, 30), Factors: Factors{Volume: 1.8, Volatility: 1.6}},
+		{Start: timeOfDay(10, 0), Factors: Factors{Volume: 1.4, Volatility: 1.25}},
+		{Start: timeOfDay(11, 0), Factors: Factors{Volume: 1.1, Volatility: 1.0}},
+		{Start: timeOfDay(12, 0), Factors: Factors{Volume: 0.7, Volatility: 0.75}},
+		{Start: timeOfDay(13, 30), Factors: Factors{Volume: 1.0, Volatility: 0.9}},
+		{Start: timeOfDay(15, 0), Factors: Factors{Volume: 1.4, Volatility: 1.2}},
+		{Start: timeOfDay(15, 30), Factors: Factors{Volume: 1.9, Volatility: 1.5}},
+		{Start: timeOfDay(16, 0), Factors: Neutral},
+	}
+	p.Weekdays[time.Saturday] = Factors{Volume: 0.8, Volatility: 0.9}
+	p.Weekdays[time.Sunday] = Factors{Volume: 0.8, Volatility: 0.9}
+	p.Holiday = standardHoliday
+	return p
+}
+
+// New builds the profile of cfg: flat when seasonality is disabled, read
+// from cfg.Profile if set and the standard profile otherwise. A CSV profile
+// holds historical bars the profile is calibrated from, see ReadBars; a
+// YAML or JSON profile holds the factors themselves, see ParseProfile.
+func New(cfg config.SeasonalityConfig) (*Profile, error) {
+	if !cfg.Enabled {
+		return Flat(), nil
+	}
+
+	location, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seasonality time zone %q: %w", cfg.Location, err)
+	}
+
+	p := Standard()
+	if cfg.Profile != "" {
+		if p, err = load(cfg.Profile, location, cfg.Holidays); err != nil {
+			return nil, err
+		}
+	}
+
+	p.location = location
+	if err := p.addHolidays(cfg.Holidays); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// load reads a profile file
+func load(path string, location *time.Location, holidays []string) (*Profile, error) {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seasonality profile %s: %w", path, err)
+		}
+		defer file.Close()
+
+		bars, err := ReadBars(file, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seasonality profile %s: %w", path, err)
+		}
+		p, err := Calibrate(bars, DefaultBucket, location, holidays)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calibrate seasonality profile %s: %w", path, err)
+		}
+		return p, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seasonality profile %s: %w", path, err)
+	}
+	return ParseProfile(data, path)
+}
+
+// At returns the factors at t. A nil profile is flat.
+func (p *Profile) At(t time.Time) Factors {
+	if p == nil {
+		return Neutral
+	}
+
+	location := p.location
+	if location == nil {
+		location = time.UTC
+	}
+	local := t.In(location)
+
+	day := p.Weekdays[local.Weekday()]
+	if p.holidays[local.Format(dateLayout)] {
+		day = p.Holiday
+	}
+	intraday := p.intradayAt(timeOfDay(local.Hour(), local.Minute()) + time.Duration(local.Second())*time.Second)
+
+	return Factors{
+		Volume:     day.Volume * intraday.Volume,
+		Volatility: day.Volatility * intraday.Volatility,
+	}
+}
+
+// intradayAt returns the factors of the bucket offset since midnight falls
+// in
+func (p *Profile) intradayAt(offset time.Duration) Factors {
+	if len(p.Intraday) == 0 {
+		return Neutral
+	}
+
+	i := sort.Search(len(p.Intraday), func(i int) bool { return p.Intraday[i].Start > offset }) - 1
+	if i < 0 {
+		i = len(p.Intraday) - 1
+	}
+	return p.Intraday[i].Factors
+}
+
+// IsHoliday reports whether t is on a holiday of the profile
+func (p *Profile) IsHoliday(t time.Time) bool {
+	if p == nil || p.location == nil {
+		return false
+	}
+	return p.holidays[t.In(p.location).Format(dateLayout)]
+}
+
+// addHolidays adds dates as "2006-01-02" to the profile's holidays
+func (p *Profile) addHolidays(dates []string) error {
+	parsed, err := parseHolidays(dates)
+	if err != nil {
+		return err
+	}
+	if p.holidays == nil {
+		p.holidays = make(map[string]bool, len(parsed))
+	}
+	for date := range parsed {
+		p.holidays[date] = true
+	}
+	return nil
+}
+
+// parseHolidays parses dates as "2006-01-02"
+func parseHolidays(dates []string) (map[string]bool, error) {
+	holidays := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		day, err := time.Parse(dateLayout, strings.TrimSpace(date))
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q, expected YYYY-MM-DD", date)
+		}
+		holidays[day.Format(dateLayout)] = true
+	}
+	return holidays, nil
+}
+
+// profileFile is a profile as written in a YAML or JSON file
+type profileFile struct {
+	Intraday []struct {
+		Start       string `json:"start" yaml:"start"`
+		fileFactors `yaml:",inline"`
+	} `json:"intraday" yaml:"intraday"`
+	Weekdays map[string]fileFactors `json:"weekdays" yaml:"weekdays"`
+	Holiday  *fileFactors           `json:"holiday" yaml:"holiday"`
+	Holidays []string               `json:"holidays" yaml:"holidays"`
+}
+
+// fileFactors are factors as written in a profile; a missing factor is
+// neutral
+type fileFactors struct {
+	Volume     *float64 `json:"volume" yaml:"volume"`
+	Volatility *float64 `json:"volatility" yaml:"volatility"`
+}
+
+func (f fileFactors) factors() Factors {
+	factors := Neutral
+	if f.Volume != nil {
+		factors.Volume = *f.Volume
+	}
+	if f.Volatility != nil {
+		factors.Volatility = *f.Volatility
+	}
+	return factors
+}
+
+// ParseProfile parses and validates a YAML or JSON profile. Weekdays are
+// named in lowercase; missing weekdays and factors are neutral, and
+// holidays default to the standard profile's. source names the profile in
+// errors.
+func ParseProfile(data []byte, source string) (*Profile, error) {
+	var file profileFile
+	if err := decodeStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid seasonality profile %s: %w", source, err)
+	}
+
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	checkFactors := func(path string, f Factors) {
+		if f.Volume < 0 || f.Volatility < 0 || math.IsNaN(f.Volume) || math.IsNaN(f.Volatility) {
+			addProblem("%s: volume and volatility must not be negative", path)
+		}
+	}
+
+	p := Flat()
+	p.Holiday = standardHoliday
+
+	for i, spec := range file.Intraday {
+		path := fmt.Sprintf("intraday[%d]", i)
+		start, err := time.Parse("15:04", spec.Start)
+		if err != nil {
+			addProblem("%s.start: %q is not a time of day such as 09:30", path, spec.Start)
+		}
+		bucket := Bucket{
+			Start:   timeOfDay(start.Hour(), start.Minute()),
+			Factors: spec.factors(),
+		}
+		if i > 0 && err == nil && bucket.Start <= p.Intraday[i-1].Start {
+			addProblem("%s.start: %s is not after the previous bucket", path, spec.Start)
+		}
+		checkFactors(path, bucket.Factors)
+		p.Intraday = append(p.Intraday, bucket)
+	}
+
+	names := make([]string, 0, len(file.Weekdays))
+	for name := range file.Weekdays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		factors := file.Weekdays[name].factors()
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			addProblem("weekdays.%s: not a day of the week", name)
+			continue
+		}
+		checkFactors("weekdays."+name, factors)
+		p.Weekdays[day] = factors
+	}
+
+	if file.Holiday != nil {
+		p.Holiday = file.Holiday.factors()
+		checkFactors("holiday", p.Holiday)
+	}
+	if err := p.addHolidays(file.Holidays); err != nil {
+		addProblem("holidays: %v", err)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid seasonality profile %s: %s", source, strings.Join(problems, "; "))
+	}
+	return p, nil
+}
+
+// weekdays are the days of the week by name
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// decodeStrict decodes JSON, or else YAML, into v, rejecting fields v
+// doesn't define
+func decodeStrict(data []byte, v interface{}) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(v)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	return decoder.Decode(v)
+}
+
+// timeOfDay returns the time since midnight of hour:minute
+func timeOfDay(hour, minute int) time.Duration {
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+}
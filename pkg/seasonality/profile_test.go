@@ -0,0 +1,261 @@
+package seasonality
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"simulated_exchange/pkg/config"
+)
+
+// monday is a Monday in the middle of a month
+var monday = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+func at(day time.Time, hour, minute int) time.Time {
+	return day.Add(timeOfDay(hour, minute))
+}
+
+func TestStandard_UShape(t *testing.T) {
+	p := Standard()
+
+	overnight := p.At(at(monday, 3, 0))
+	open := p.At(at(monday, 9, 35))
+	lunch := p.At(at(monday, 12, 30))
+	close := p.At(at(monday, 15, 45))
+
+	assert.Equal(t, Neutral, overnight)
+	assert.Greater(t, open.Volume, lunch.Volume)
+	assert.Greater(t, close.Volume, lunch.Volume)
+	assert.Greater(t, open.Volatility, lunch.Volatility)
+	assert.Greater(t, close.Volatility, lunch.Volatility)
+	assert.Less(t, lunch.Volume, overnight.Volume)
+
+	// Weekends are quieter at the same time of day
+	saturday := p.At(at(monday.AddDate(0, 0, 5), 9, 35))
+	assert.InDelta(t, open.Volume*0.8, saturday.Volume, 1e-12)
+	assert.InDelta(t, open.Volatility*0.9, saturday.Volatility, 1e-12)
+}
+
+func TestProfile_NilIsFlat(t *testing.T) {
+	var p *Profile
+	assert.Equal(t, Neutral, p.At(at(monday, 9, 35)))
+	assert.False(t, p.IsHoliday(monday))
+}
+
+func TestNew(t *testing.T) {
+	p, err := New(config.SeasonalityConfig{Enabled: false, Holidays: []string{"2024-03-04"}})
+	require.NoError(t, err)
+	assert.Equal(t, Neutral, p.At(at(monday, 9, 35)))
+
+	// The session follows the market's time zone and holidays replace the
+	// weekday
+	p, err = New(config.SeasonalityConfig{
+		Enabled:  true,
+		Holidays: []string{"2024-03-05"},
+		Location: "America/New_York",
+	})
+	require.NoError(t, err)
+
+	newYorkOpen := time.Date(2024, 3, 4, 14, 35, 0, 0, time.UTC)
+	assert.Equal(t, Standard().At(at(monday, 9, 35)), p.At(newYorkOpen))
+	assert.Equal(t, Neutral, p.At(at(monday, 9, 35)))
+
+	holidayOpen := newYorkOpen.AddDate(0, 0, 1)
+	assert.True(t, p.IsHoliday(holidayOpen))
+	assert.InDelta(t, 1.8*0.5, p.At(holidayOpen).Volume, 1e-12)
+
+	_, err = New(config.SeasonalityConfig{Enabled: true, Location: "Mars/Olympus"})
+	assert.Error(t, err)
+	_, err = New(config.SeasonalityConfig{Enabled: true, Location: "UTC", Profile: "missing.yaml"})
+	assert.Error(t, err)
+}
+
+func TestParseProfile(t *testing.T) {
+	profile := `
+intraday:
+  - start: "08:00"
+    volume: 2
+    volatility: 1.5
+  - start: "12:00"
+    volume: 0.5
+  - start: "17:00"
+    volume: 0.2
+    volatility: 0.4
+weekdays:
+  friday: {volume: 0.9}
+holiday: {volume: 0.1, volatility: 0.3}
+holidays: ["2024-03-11"]
+`
+	p, err := ParseProfile([]byte(profile), "profile.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, Factors{Volume: 2, Volatility: 1.5}, p.At(at(monday, 8, 0)))
+	assert.Equal(t, Factors{Volume: 0.5, Volatility: 1}, p.At(at(monday, 13, 0)))
+
+	// The last bucket carries over past midnight
+	assert.Equal(t, Factors{Volume: 0.2, Volatility: 0.4}, p.At(at(monday, 2, 0)))
+
+	thursday := monday.AddDate(0, 0, 3)
+	assert.InDelta(t, 0.9*2, p.At(at(thursday.AddDate(0, 0, 1), 8, 0)).Volume, 1e-12)
+	assert.True(t, p.IsHoliday(thursday.AddDate(0, 0, 4)))
+	assert.False(t, p.IsHoliday(thursday))
+
+	p, err = ParseProfile([]byte(`{"intraday": [{"start": "09:30", "volume": 1.5}]}`), "profile.json")
+	require.NoError(t, err)
+	assert.Equal(t, Factors{Volume: 1.5, Volatility: 1}, p.At(at(monday, 3, 0)))
+	assert.Equal(t, standardHoliday, p.Holiday)
+}
+
+func TestParseProfile_Invalid(t *testing.T) {
+	profile := `
+intraday:
+  - start: "12:00"
+    volume: -1
+  - start: "09:30"
+  - start: noon
+weekdays:
+  someday: {volume: 1}
+holidays: [christmas]
+`
+	_, err := ParseProfile([]byte(profile), "profile.yaml")
+	require.Error(t, err)
+
+	for _, problem := range []string{
+		"intraday[0]: volume and volatility must not be negative",
+		"intraday[1].start: 09:30 is not after the previous bucket",
+		`intraday[2].start: "noon" is not a time of day`,
+		"weekdays.someday: not a day of the week",
+		`holidays: invalid holiday "christmas"`,
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+
+	_, err = ParseProfile([]byte("intraday: []\nspeed: 2\n"), "profile.yaml")
+	assert.ErrorContains(t, err, "field speed not found")
+}
+
+func TestReadBars(t *testing.T) {
+	data := `symbol,timestamp,open,close,volume
+SPY,2024-03-04T09:30:00-05:00,100,101,5000
+SPY,2024-03-04 10:00,101,100.5,3000
+SPY,1709568000,100.5,100,1000
+`
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	bars, err := ReadBars(strings.NewReader(data), newYork)
+	require.NoError(t, err)
+	require.Len(t, bars, 3)
+
+	assert.True(t, bars[0].Time.Equal(time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)))
+	assert.True(t, bars[1].Time.Equal(time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC)))
+	assert.True(t, bars[2].Time.Equal(time.Unix(1709568000, 0)))
+	assert.Equal(t, 100.5, bars[1].Close)
+	assert.Equal(t, 3000.0, bars[1].Volume)
+
+	_, err = ReadBars(strings.NewReader("timestamp,close\n"), newYork)
+	assert.ErrorContains(t, err, "no volume column")
+	_, err = ReadBars(strings.NewReader("timestamp,close,volume\nyesterday,1,1\n"), newYork)
+	assert.ErrorContains(t, err, "line 2")
+}
+
+// sessionBars simulates weeks of five minute bars of a session from 09:30
+// to 16:00 whose volume and volatility follow shape, with quieter Fridays
+func sessionBars(weeks int, shape func(offset time.Duration) float64) []Bar {
+	rng := rand.New(rand.NewSource(1))
+	var bars []Bar
+	for day := 0; day < weeks*7; day++ {
+		date := monday.AddDate(0, 0, day)
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+		weekday := 1.0
+		if date.Weekday() == time.Friday {
+			weekday = 0.5
+		}
+
+		price := 100.0
+		for offset := timeOfDay(9, 30); offset < timeOfDay(16, 0); offset += 5 * time.Minute {
+			level := shape(offset) * weekday
+			price *= math.Exp(0.001 * level * rng.NormFloat64())
+			bars = append(bars, Bar{Time: date.Add(offset), Close: price, Volume: 1000 * level})
+		}
+	}
+	return bars
+}
+
+func TestCalibrate(t *testing.T) {
+	// Twice the activity in the first and last half hours
+	shape := func(offset time.Duration) float64 {
+		if offset < timeOfDay(10, 0) || offset >= timeOfDay(15, 30) {
+			return 2
+		}
+		return 1
+	}
+	bars := sessionBars(8, shape)
+
+	p, err := Calibrate(bars, DefaultBucket, time.UTC, []string{"2024-03-13"})
+	require.NoError(t, err)
+	require.Len(t, p.Intraday, 48)
+
+	open := p.Intraday[19].Factors   // 09:30
+	midday := p.Intraday[24].Factors // 12:00
+	close := p.Intraday[31].Factors  // 15:30
+	assert.InDelta(t, 2, open.Volume/midday.Volume, 0.05)
+	assert.InDelta(t, 2, close.Volume/midday.Volume, 0.05)
+	assert.InDelta(t, 2, close.Volatility/midday.Volatility, 0.3)
+
+	// Outside the session the profile is as quiet as the quietest bucket
+	assert.Equal(t, midday.Volume, p.Intraday[2].Volume)
+
+	// Fridays trade half as much, and weekends like the quietest day
+	assert.InDelta(t, 0.5, p.Weekdays[time.Friday].Volume/p.Weekdays[time.Monday].Volume, 0.05)
+	assert.Equal(t, p.Weekdays[time.Friday], p.Weekdays[time.Saturday])
+
+	// The holiday traded like any Wednesday
+	assert.True(t, p.IsHoliday(time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)))
+	assert.InDelta(t, p.Weekdays[time.Wednesday].Volume, p.Holiday.Volume, 0.05)
+
+	// Session bars average to a neutral volume
+	var sum float64
+	var count int
+	for _, bar := range bars {
+		if bar.Time.Format(dateLayout) != "2024-03-13" {
+			sum += p.intradayAt(bar.Time.Sub(bar.Time.Truncate(24 * time.Hour))).Volume
+			count++
+		}
+	}
+	assert.InDelta(t, 1, sum/float64(count), 1e-9)
+
+	_, err = Calibrate(bars, 7*time.Minute, time.UTC, nil)
+	assert.Error(t, err)
+	_, err = Calibrate(nil, DefaultBucket, time.UTC, nil)
+	assert.Error(t, err)
+}
+
+func TestNew_CalibratesFromCSV(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("timestamp,close,volume\n")
+	for _, bar := range sessionBars(2, func(offset time.Duration) float64 {
+		if offset < timeOfDay(10, 0) {
+			return 3
+		}
+		return 1
+	}) {
+		fmt.Fprintf(&csv, "%s,%g,%g\n", bar.Time.Format(time.RFC3339), bar.Close, bar.Volume)
+	}
+
+	path := filepath.Join(t.TempDir(), "history.csv")
+	require.NoError(t, os.WriteFile(path, []byte(csv.String()), 0o644))
+
+	p, err := New(config.SeasonalityConfig{Enabled: true, Profile: path, Location: "UTC"})
+	require.NoError(t, err)
+	assert.InDelta(t, 3, p.At(at(monday, 9, 45)).Volume/p.At(at(monday, 11, 0)).Volume, 0.05)
+}
@@ -0,0 +1,83 @@
+package shared
+
+import (
+	"sort"
+	"time"
+)
+
+// BucketStart returns the start of the bucket t falls in. Buckets are
+// aligned to the Unix epoch in UTC, like the continuously aggregated
+// candles.
+func (i CandleInterval) BucketStart(t time.Time) time.Time {
+	return t.UTC().Truncate(i.Duration())
+}
+
+// Add folds a trade into the candle. Open and close follow trade time rather
+// than the order trades are added in, so a late trade doesn't corrupt the
+// bucket; of trades at the same time the first added opens and the last
+// added closes.
+func (c *Candle) Add(trade *Trade) {
+	if c.TradeCount == 0 {
+		c.Open, c.High, c.Low, c.Close = trade.Price, trade.Price, trade.Price, trade.Price
+		c.FirstTradeAt, c.LastTradeAt = trade.CreatedAt, trade.CreatedAt
+	} else {
+		if trade.CreatedAt.Before(c.FirstTradeAt) {
+			c.Open = trade.Price
+			c.FirstTradeAt = trade.CreatedAt
+		}
+		if !trade.CreatedAt.Before(c.LastTradeAt) {
+			c.Close = trade.Price
+			c.LastTradeAt = trade.CreatedAt
+		}
+		if trade.Price > c.High {
+			c.High = trade.Price
+		}
+		if trade.Price < c.Low {
+			c.Low = trade.Price
+		}
+	}
+	c.Volume += trade.Quantity
+	c.TradeCount++
+}
+
+// AggregateCandles builds the candles of an interval from trades, ordered by
+// symbol and bucket. Trades are taken in time order, ties broken by ID.
+func AggregateCandles(trades []*Trade, interval CandleInterval) []*Candle {
+	if interval.Duration() == 0 {
+		return nil
+	}
+
+	sorted := append([]*Trade(nil), trades...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	type bucketKey struct {
+		symbol string
+		start  int64
+	}
+	buckets := make(map[bucketKey]*Candle)
+	var candles []*Candle
+	for _, trade := range sorted {
+		start := interval.BucketStart(trade.CreatedAt)
+		key := bucketKey{symbol: trade.Symbol, start: start.UnixNano()}
+		candle, ok := buckets[key]
+		if !ok {
+			candle = &Candle{Symbol: trade.Symbol, Interval: interval, BucketStart: start}
+			buckets[key] = candle
+			candles = append(candles, candle)
+		}
+		candle.Add(trade)
+	}
+
+	sort.SliceStable(candles, func(i, j int) bool {
+		if candles[i].Symbol != candles[j].Symbol {
+			return candles[i].Symbol < candles[j].Symbol
+		}
+		return candles[i].BucketStart.Before(candles[j].BucketStart)
+	})
+	return candles
+}
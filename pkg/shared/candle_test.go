@@ -0,0 +1,117 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// base is on an hour boundary
+var base = time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC)
+
+func trade(id, symbol string, offset time.Duration, price, quantity float64) *Trade {
+	return &Trade{ID: id, Symbol: symbol, CreatedAt: base.Add(offset), Price: price, Quantity: quantity}
+}
+
+func TestCandleInterval_BucketStart(t *testing.T) {
+	newYork := time.FixedZone("EST", -5*3600)
+	at := time.Date(2024, 3, 4, 9, 37, 42, 500, newYork)
+
+	assert.Equal(t, time.Date(2024, 3, 4, 14, 37, 0, 0, time.UTC), CandleInterval1m.BucketStart(at))
+	assert.Equal(t, time.Date(2024, 3, 4, 14, 35, 0, 0, time.UTC), CandleInterval5m.BucketStart(at))
+	assert.Equal(t, time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC), CandleInterval1h.BucketStart(at))
+
+	// Buckets are aligned to the Unix epoch like the SQL aggregation
+	for _, interval := range CandleIntervals {
+		start := interval.BucketStart(at)
+		assert.Zero(t, start.Unix()%int64(interval.Duration()/time.Second), interval)
+	}
+}
+
+func TestAggregateCandles(t *testing.T) {
+	trades := []*Trade{
+		trade("1", "AAPL", 10*time.Second, 100, 1),
+		trade("2", "AAPL", 20*time.Second, 104, 2),
+		trade("3", "AAPL", 30*time.Second, 98, 3),
+		trade("4", "AAPL", 50*time.Second, 101, 4),
+		trade("5", "AAPL", 61*time.Second, 102, 5),
+		trade("6", "MSFT", 5*time.Second, 300, 1),
+		trade("7", "AAPL", 5*time.Minute, 99, 6),
+	}
+
+	candles := AggregateCandles(trades, CandleInterval1m)
+	require.Len(t, candles, 4)
+
+	first := candles[0]
+	assert.Equal(t, "AAPL", first.Symbol)
+	assert.Equal(t, CandleInterval1m, first.Interval)
+	assert.Equal(t, base, first.BucketStart)
+	assert.Equal(t, 100.0, first.Open)
+	assert.Equal(t, 104.0, first.High)
+	assert.Equal(t, 98.0, first.Low)
+	assert.Equal(t, 101.0, first.Close)
+	assert.Equal(t, 10.0, first.Volume)
+	assert.Equal(t, int64(4), first.TradeCount)
+	assert.Equal(t, base.Add(10*time.Second), first.FirstTradeAt)
+	assert.Equal(t, base.Add(50*time.Second), first.LastTradeAt)
+
+	// The trade on the bucket boundary opens the next bucket
+	assert.Equal(t, base.Add(time.Minute), candles[1].BucketStart)
+	assert.Equal(t, int64(1), candles[1].TradeCount)
+	assert.Equal(t, base.Add(5*time.Minute), candles[2].BucketStart)
+
+	// Candles are ordered by symbol, then bucket
+	assert.Equal(t, "MSFT", candles[3].Symbol)
+
+	candles = AggregateCandles(trades, CandleInterval5m)
+	require.Len(t, candles, 3)
+	assert.Equal(t, int64(5), candles[0].TradeCount)
+	assert.Equal(t, 102.0, candles[0].Close)
+	assert.Equal(t, 15.0, candles[0].Volume)
+
+	candles = AggregateCandles(trades, CandleInterval1h)
+	require.Len(t, candles, 2)
+	assert.Equal(t, 100.0, candles[0].Open)
+	assert.Equal(t, 99.0, candles[0].Close)
+	assert.Equal(t, 21.0, candles[0].Volume)
+
+	assert.Nil(t, AggregateCandles(trades, "1d"))
+	assert.Empty(t, AggregateCandles(nil, CandleInterval1m))
+}
+
+func TestCandle_AddLateTrades(t *testing.T) {
+	// Trades folded in out of time order, as when a late trade is stored
+	var candle Candle
+	candle.Add(trade("2", "AAPL", 20*time.Second, 104, 1))
+	candle.Add(trade("3", "AAPL", 40*time.Second, 102, 1))
+	candle.Add(trade("1", "AAPL", 10*time.Second, 100, 1))
+	candle.Add(trade("0", "AAPL", 30*time.Second, 97, 1))
+
+	assert.Equal(t, 100.0, candle.Open)
+	assert.Equal(t, 102.0, candle.Close)
+	assert.Equal(t, 104.0, candle.High)
+	assert.Equal(t, 97.0, candle.Low)
+	assert.Equal(t, 4.0, candle.Volume)
+
+	// Aggregating the same trades in any order gives the same candle
+	trades := []*Trade{
+		trade("3", "AAPL", 40*time.Second, 102, 1),
+		trade("1", "AAPL", 10*time.Second, 100, 1),
+		trade("0", "AAPL", 30*time.Second, 97, 1),
+		trade("2", "AAPL", 20*time.Second, 104, 1),
+	}
+	aggregated := AggregateCandles(trades, CandleInterval1m)
+	require.Len(t, aggregated, 1)
+	assert.Equal(t, candle.Open, aggregated[0].Open)
+	assert.Equal(t, candle.Close, aggregated[0].Close)
+
+	// Of simultaneous trades the first opens and the last closes
+	simultaneous := AggregateCandles([]*Trade{
+		trade("b", "AAPL", 10*time.Second, 101, 1),
+		trade("a", "AAPL", 10*time.Second, 100, 1),
+	}, CandleInterval1m)
+	assert.Equal(t, 100.0, simultaneous[0].Open)
+	assert.Equal(t, 101.0, simultaneous[0].Close)
+}
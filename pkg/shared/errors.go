@@ -14,7 +14,9 @@ var (
 	ErrInvalidOrder        = errors.New("invalid order")
 	ErrOrderAlreadyFilled  = errors.New("order already filled")
 	ErrOrderAlreadyCancelled = errors.New("order already cancelled")
+	ErrOrderModified       = errors.New("order modified concurrently")
 	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInsufficientPosition = errors.New("insufficient position")
 
 	// Trade related errors
 	ErrTradeNotFound      = errors.New("trade not found")
@@ -23,9 +25,21 @@ var (
 
 	// User related errors
 	ErrUserNotFound      = errors.New("user not found")
+	ErrAccountNotFound   = errors.New("account not found")
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrInvalidUser       = errors.New("invalid user")
 	ErrUnauthorized      = errors.New("unauthorized")
+	ErrForbidden         = errors.New("forbidden")
+	ErrMarketMakerNotFound = errors.New("market maker not found")
+	ErrAlertNotFound       = errors.New("surveillance alert not found")
+
+	// Authentication related errors
+	ErrAPIKeyNotFound   = errors.New("api key not found")
+	ErrAPIKeyRevoked    = errors.New("api key revoked")
+	ErrInvalidAPIKey    = errors.New("invalid api key")
+	ErrInvalidSignature = errors.New("invalid request signature")
+	ErrSignatureExpired = errors.New("request signature expired")
+	ErrSignatureReplay  = errors.New("request nonce already used")
 
 	// System related errors
 	ErrServiceUnavailable = errors.New("service unavailable")
@@ -120,6 +134,29 @@ func (e *ValidationErrors) HasErrors() bool {
 	return len(e.Errors) > 0
 }
 
+// DuplicateOrderError is returned when an order is resubmitted with a client
+// order ID that the user has already used. Existing is the original order.
+type DuplicateOrderError struct {
+	Existing *Order
+}
+
+func (e *DuplicateOrderError) Error() string {
+	return fmt.Sprintf("duplicate client order ID %q (order %s)", e.Existing.ClientOrderID, e.Existing.ID)
+}
+
+// OrderRejectedError is returned when an order is refused before entering
+// the book. The order is persisted with OrderStatusRejected; Code is one of
+// the ErrCode constants and Reason a human-readable explanation.
+type OrderRejectedError struct {
+	Order  *Order
+	Code   string
+	Reason string
+}
+
+func (e *OrderRejectedError) Error() string {
+	return fmt.Sprintf("order rejected: %s - %s", e.Code, e.Reason)
+}
+
 // ServiceError represents a service-level error
 type ServiceError struct {
 	Service   string `json:"service"`
@@ -161,17 +198,33 @@ func NewServiceErrorWithCause(service, operation, message string, cause error) *
 
 // Error code constants
 const (
-	ErrCodeOrderNotFound         = "ORDER_NOT_FOUND"
-	ErrCodeOrderInvalid          = "ORDER_INVALID"
-	ErrCodeOrderAlreadyFilled    = "ORDER_ALREADY_FILLED"
-	ErrCodeOrderAlreadyCancelled = "ORDER_ALREADY_CANCELLED"
-	ErrCodeInsufficientBalance   = "INSUFFICIENT_BALANCE"
-	ErrCodeTradeExecutionFailed  = "TRADE_EXECUTION_FAILED"
-	ErrCodeUserNotFound          = "USER_NOT_FOUND"
-	ErrCodeUnauthorized          = "UNAUTHORIZED"
-	ErrCodeServiceUnavailable    = "SERVICE_UNAVAILABLE"
-	ErrCodeValidationFailed      = "VALIDATION_FAILED"
-	ErrCodeDatabaseError         = "DATABASE_ERROR"
-	ErrCodeCacheError            = "CACHE_ERROR"
-	ErrCodeTimeout               = "TIMEOUT"
+	ErrCodeOrderNotFound          = "ORDER_NOT_FOUND"
+	ErrCodeOrderInvalid           = "ORDER_INVALID"
+	ErrCodeOrderAlreadyFilled     = "ORDER_ALREADY_FILLED"
+	ErrCodeOrderAlreadyCancelled  = "ORDER_ALREADY_CANCELLED"
+	ErrCodeInvalidOrderTransition = "INVALID_ORDER_TRANSITION"
+	ErrCodeOrderModified          = "ORDER_MODIFIED"
+	ErrCodeInsufficientBalance    = "INSUFFICIENT_BALANCE"
+	ErrCodeInsufficientPosition   = "INSUFFICIENT_POSITION"
+	ErrCodeNoReferencePrice       = "NO_REFERENCE_PRICE"
+	ErrCodeOrderSizeLimit         = "ORDER_SIZE_LIMIT"
+	ErrCodeOrderNotionalLimit     = "ORDER_NOTIONAL_LIMIT"
+	ErrCodePriceCollar            = "PRICE_COLLAR"
+	ErrCodeOpenOrderLimit         = "OPEN_ORDER_LIMIT"
+	ErrCodePositionLimit          = "POSITION_LIMIT"
+	ErrCodeOrderRateLimit         = "ORDER_RATE_LIMIT"
+	ErrCodeTradeExecutionFailed   = "TRADE_EXECUTION_FAILED"
+	ErrCodeUserNotFound           = "USER_NOT_FOUND"
+	ErrCodeUnauthorized           = "UNAUTHORIZED"
+	ErrCodeForbidden              = "FORBIDDEN"
+	ErrCodeInvalidAPIKey          = "INVALID_API_KEY"
+	ErrCodeInvalidSignature       = "INVALID_SIGNATURE"
+	ErrCodeInsufficientScope      = "INSUFFICIENT_SCOPE"
+	ErrCodeRateLimited            = "RATE_LIMITED"
+	ErrCodeIdempotencyConflict    = "IDEMPOTENCY_CONFLICT"
+	ErrCodeServiceUnavailable     = "SERVICE_UNAVAILABLE"
+	ErrCodeValidationFailed       = "VALIDATION_FAILED"
+	ErrCodeDatabaseError          = "DATABASE_ERROR"
+	ErrCodeCacheError             = "CACHE_ERROR"
+	ErrCodeTimeout                = "TIMEOUT"
 )
\ No newline at end of file
@@ -7,27 +7,53 @@ import (
 
 // Repository Interfaces (Dependency Inversion Principle)
 
-// OrderRepository defines the interface for order persistence
+// OrderRepository defines the interface for order persistence. Update must
+// only apply legal status transitions from the stored status and return an
+// *OrderTransitionError otherwise. Writes taking an *OrderEvent append it to
+// the order's audit trail in the same transaction; a nil event writes the
+// order alone. Amend also shrinks the order's reservation, if any, to the
+// amended quantity in that transaction.
 type OrderRepository interface {
-	Create(ctx context.Context, order *Order) error
+	Create(ctx context.Context, order *Order, event *OrderEvent) error
 	GetByID(ctx context.Context, id string) (*Order, error)
+	GetByClientOrderID(ctx context.Context, userID, clientOrderID string) (*Order, error)
 	GetByUserID(ctx context.Context, userID string) ([]*Order, error)
 	GetBySymbol(ctx context.Context, symbol string) ([]*Order, error)
 	GetByStatus(ctx context.Context, status OrderStatus) ([]*Order, error)
-	Update(ctx context.Context, order *Order) error
+	Update(ctx context.Context, order *Order, event *OrderEvent) error
+	Amend(ctx context.Context, order *Order, previousQuantity float64, event *OrderEvent) error
 	Delete(ctx context.Context, id string) error
 	GetActiveOrders(ctx context.Context) ([]*Order, error)
+	GetOpenOrders(ctx context.Context, filter OrderFilter) ([]*Order, error)
 	GetOrdersInTimeRange(ctx context.Context, start, end time.Time) ([]*Order, error)
 }
 
-// TradeRepository defines the interface for trade persistence
+// OrderEventRepository defines the interface for the append-only order
+// audit trail
+type OrderEventRepository interface {
+	Append(ctx context.Context, event *OrderEvent) error
+	GetByOrderID(ctx context.Context, orderID string) ([]*OrderEvent, error)
+	GetInTimeRange(ctx context.Context, start, end time.Time) ([]*OrderEvent, error)
+}
+
+// TradeRepository defines the interface for trade persistence. Create
+// stores a trade, applies its fills to the traded orders and settles it,
+// unless settlement is nil, in one transaction: if any order can no longer
+// take its fill, or the buyer can't pay (ErrInsufficientBalance), nothing is
+// stored.
 type TradeRepository interface {
-	Create(ctx context.Context, trade *Trade) error
+	Create(ctx context.Context, trade *Trade, fills []*OrderFill, settlement *TradeSettlement) error
 	GetByID(ctx context.Context, id string) (*Trade, error)
 	GetByOrderID(ctx context.Context, orderID string) ([]*Trade, error)
 	GetBySymbol(ctx context.Context, symbol string) ([]*Trade, error)
 	GetTradesInTimeRange(ctx context.Context, start, end time.Time) ([]*Trade, error)
 	GetRecentTrades(ctx context.Context, limit int) ([]*Trade, error)
+	GetTradesBySymbolBefore(ctx context.Context, symbol string, before *TradeCursor, limit int) ([]*Trade, error)
+	GetCandles(ctx context.Context, symbol string, interval CandleInterval, start, end time.Time, limit int) ([]*Candle, error)
+	GetTickers(ctx context.Context, since time.Time) ([]*Ticker, error)
+	GetFeeRevenue(ctx context.Context, start, end time.Time) ([]*FeeRevenue, error)
+	GetUserVolumes(ctx context.Context, since time.Time) (map[string]float64, error)
+	GetNetPosition(ctx context.Context, userID, symbol string) (float64, error)
 }
 
 // UserRepository defines the interface for user persistence
@@ -40,6 +66,62 @@ type UserRepository interface {
 	Delete(ctx context.Context, id string) error
 }
 
+// APIKeyRepository defines the interface for API key persistence
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	GetAPIKey(ctx context.Context, keyID string) (*APIKey, error)
+	GetAPIKeysByUserID(ctx context.Context, userID string) ([]*APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) error
+	TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error
+}
+
+// AccountRepository defines the interface for account, position and
+// reservation persistence. Reservation methods must be atomic; trades are
+// settled by TradeRepository.Create.
+type AccountRepository interface {
+	OpenAccount(ctx context.Context, userID string, initialCash float64) (*Account, error)
+	GetAccount(ctx context.Context, userID string) (*Account, error)
+	GetPositions(ctx context.Context, userID string) ([]*Position, error)
+	Reserve(ctx context.Context, reservation *Reservation, initialPosition float64) error
+	Release(ctx context.Context, orderID string) (*Reservation, error)
+	Deposit(ctx context.Context, userID string, amount float64, reference string) (*Account, error)
+	Withdraw(ctx context.Context, userID string, amount float64, reference string) (*Account, error)
+}
+
+// ClearingRepository defines the interface for netting trades into
+// settlement obligations and tracking their settlement
+type ClearingRepository interface {
+	NetTrades(ctx context.Context, tradeDate, settlementDate time.Time) (int, error)
+	GetUnclearedTradeDates(ctx context.Context, before time.Time) ([]time.Time, error)
+	GetDueObligations(ctx context.Context, asOf time.Time, limit int) ([]*SettlementObligation, error)
+	GetObligations(ctx context.Context, tradeDate time.Time) ([]*SettlementObligation, error)
+	MarkSettled(ctx context.Context, id string, settledAt time.Time) error
+	MarkFailed(ctx context.Context, id, reason string) error
+}
+
+// MarketMakerRepository defines the interface for market maker registrations
+// and their daily quote statistics
+type MarketMakerRepository interface {
+	Register(ctx context.Context, marketMaker *MarketMaker) error
+	Deregister(ctx context.Context, userID, symbol string) error
+	GetActive(ctx context.Context) ([]*MarketMaker, error)
+	GetByUserID(ctx context.Context, userID string) ([]*MarketMaker, error)
+	RecordSamples(ctx context.Context, samples []*MarketMakerStats) error
+	GetDailyStats(ctx context.Context, tradeDate time.Time) ([]*MarketMakerStats, error)
+	GetStats(ctx context.Context, userID string, start, end time.Time) ([]*MarketMakerStats, error)
+}
+
+// SurveillanceRepository defines the interface for surveillance alerts and
+// the beneficial owners of user accounts
+type SurveillanceRepository interface {
+	CreateAlert(ctx context.Context, alert *SurveillanceAlert) error
+	GetAlert(ctx context.Context, id string) (*SurveillanceAlert, error)
+	GetAlerts(ctx context.Context, filter AlertFilter) ([]*SurveillanceAlert, error)
+	UpdateAlertStatus(ctx context.Context, id string, status AlertStatus) (*SurveillanceAlert, error)
+	GetBeneficialOwners(ctx context.Context) (map[string]string, error)
+	SetBeneficialOwner(ctx context.Context, userID, ownerID string) error
+}
+
 // Cache Interface (for Redis integration)
 
 // CacheRepository defines the interface for caching operations
@@ -72,12 +154,21 @@ type EventHandler func(ctx context.Context, event *Event) error
 // TradingService defines the interface for trading operations
 type TradingService interface {
 	PlaceOrder(ctx context.Context, order *Order) (*Order, error)
+	PlaceOrders(ctx context.Context, orders []*Order) []*BatchOrderResult
 	CancelOrder(ctx context.Context, orderID string) error
+	CancelOrders(ctx context.Context, filter OrderFilter) ([]*Order, error)
+	CancelOrderByClientID(ctx context.Context, userID, clientOrderID string) (*Order, error)
+	AmendOrder(ctx context.Context, orderID string, quantity float64) (*Order, error)
 	GetOrder(ctx context.Context, orderID string) (*Order, error)
+	GetOrderByClientID(ctx context.Context, userID, clientOrderID string) (*Order, error)
+	GetOrderHistory(ctx context.Context, orderID string) ([]*OrderEvent, error)
 	GetRecentOrders(ctx context.Context, limit int) ([]*Order, error)
 	GetOrderBook(ctx context.Context, symbol string) (*OrderBook, error)
 	GetUserOrders(ctx context.Context, userID string) ([]*Order, error)
 	GetTrades(ctx context.Context, symbol string, limit int) ([]*Trade, error)
+	GetTradeHistory(ctx context.Context, symbol, cursor string, limit int) (*TradePage, error)
+	GetCandles(ctx context.Context, symbol string, interval CandleInterval, start, end time.Time, limit int) ([]*Candle, error)
+	GetTickers(ctx context.Context) ([]*Ticker, error)
 }
 
 // PriceService defines the interface for price and market data operations
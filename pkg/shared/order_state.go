@@ -0,0 +1,90 @@
+package shared
+
+import "fmt"
+
+// orderTransitions lists the statuses each order status may move to. Orders
+// are created PENDING, or REJECTED when refused before entering the book;
+// FILLED, CANCELLED and REJECTED are terminal. PARTIAL to PARTIAL is a
+// further partial fill.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	"":                   {OrderStatusPending, OrderStatusRejected},
+	OrderStatusPending:   {OrderStatusPartial, OrderStatusFilled, OrderStatusCancelled, OrderStatusRejected},
+	OrderStatusPartial:   {OrderStatusPartial, OrderStatusFilled, OrderStatusCancelled},
+	OrderStatusFilled:    {},
+	OrderStatusCancelled: {},
+	OrderStatusRejected:  {},
+}
+
+// OrderTransitionError is returned when an order is moved to a status that
+// cannot follow its current one
+type OrderTransitionError struct {
+	OrderID string
+	From    OrderStatus
+	To      OrderStatus
+}
+
+func (e *OrderTransitionError) Error() string {
+	return fmt.Sprintf("order %s cannot move from %s to %s", e.OrderID, e.From, e.To)
+}
+
+// Code returns the ErrCode constant describing the transition error
+func (e *OrderTransitionError) Code() string {
+	switch e.From {
+	case OrderStatusFilled:
+		return ErrCodeOrderAlreadyFilled
+	case OrderStatusCancelled:
+		return ErrCodeOrderAlreadyCancelled
+	default:
+		return ErrCodeInvalidOrderTransition
+	}
+}
+
+// CanTransitionTo reports whether an order may move from this status to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transitions are possible
+func (s OrderStatus) IsTerminal() bool {
+	allowed, known := orderTransitions[s]
+	return known && len(allowed) == 0
+}
+
+// IsOpen reports whether an order with this status rests on the book
+func (s OrderStatus) IsOpen() bool {
+	return s == OrderStatusPending || s == OrderStatusPartial
+}
+
+// OrderStatusPredecessors returns the stored statuses an order may move to
+// status from. Repositories use it to make status updates conditional on the
+// current stored status, so concurrent updates cannot make illegal moves.
+func OrderStatusPredecessors(status OrderStatus) []OrderStatus {
+	var predecessors []OrderStatus
+	for from, allowed := range orderTransitions {
+		if from == "" {
+			continue
+		}
+		for _, next := range allowed {
+			if next == status {
+				predecessors = append(predecessors, from)
+				break
+			}
+		}
+	}
+	return predecessors
+}
+
+// TransitionTo moves the order to next, or returns an *OrderTransitionError
+// if next cannot follow the current status
+func (o *Order) TransitionTo(next OrderStatus) error {
+	if !o.Status.CanTransitionTo(next) {
+		return &OrderTransitionError{OrderID: o.ID, From: o.Status, To: next}
+	}
+	o.Status = next
+	return nil
+}
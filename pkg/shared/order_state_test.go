@@ -0,0 +1,136 @@
+package shared
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allOrderStatuses = []OrderStatus{
+	"",
+	OrderStatusPending,
+	OrderStatusPartial,
+	OrderStatusFilled,
+	OrderStatusCancelled,
+	OrderStatusRejected,
+}
+
+func TestOrderStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from  OrderStatus
+		to    OrderStatus
+		legal bool
+	}{
+		{"", OrderStatusPending, true},
+		{"", OrderStatusRejected, true},
+		{"", OrderStatusFilled, false},
+		{OrderStatusPending, OrderStatusPartial, true},
+		{OrderStatusPending, OrderStatusFilled, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPending, OrderStatusRejected, true},
+		{OrderStatusPending, OrderStatusPending, false},
+		{OrderStatusPartial, OrderStatusPartial, true},
+		{OrderStatusPartial, OrderStatusFilled, true},
+		{OrderStatusPartial, OrderStatusCancelled, true},
+		{OrderStatusPartial, OrderStatusRejected, false},
+		{OrderStatusPartial, OrderStatusPending, false},
+		{OrderStatusFilled, OrderStatusCancelled, false},
+		{OrderStatusFilled, OrderStatusPartial, false},
+		{OrderStatusCancelled, OrderStatusFilled, false},
+		{OrderStatusCancelled, OrderStatusCancelled, false},
+		{OrderStatusRejected, OrderStatusPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.legal, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestOrder_TransitionTo_Errors(t *testing.T) {
+	tests := []struct {
+		from OrderStatus
+		code string
+	}{
+		{OrderStatusFilled, ErrCodeOrderAlreadyFilled},
+		{OrderStatusCancelled, ErrCodeOrderAlreadyCancelled},
+		{OrderStatusRejected, ErrCodeInvalidOrderTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from), func(t *testing.T) {
+			order := &Order{ID: "order-1", Status: tt.from}
+
+			err := order.TransitionTo(OrderStatusCancelled)
+
+			var transitionErr *OrderTransitionError
+			require.ErrorAs(t, err, &transitionErr)
+			assert.Equal(t, tt.code, transitionErr.Code())
+			assert.Equal(t, tt.from, order.Status)
+		})
+	}
+}
+
+// randomStatuses draws a sequence of requested statuses
+type randomStatuses []OrderStatus
+
+func (randomStatuses) Generate(r *rand.Rand, size int) reflect.Value {
+	statuses := make(randomStatuses, r.Intn(size+1))
+	for i := range statuses {
+		statuses[i] = allOrderStatuses[1+r.Intn(len(allOrderStatuses)-1)]
+	}
+	return reflect.ValueOf(statuses)
+}
+
+func TestOrder_TransitionTo_Properties(t *testing.T) {
+	property := func(requested randomStatuses) bool {
+		order := &Order{ID: "order-1"}
+		for _, next := range requested {
+			before := order.Status
+			err := order.TransitionTo(next)
+
+			// Legal moves apply, illegal moves leave the order untouched
+			if before.CanTransitionTo(next) != (err == nil) {
+				return false
+			}
+			if err != nil && order.Status != before {
+				return false
+			}
+			// Terminal statuses never change
+			if before.IsTerminal() && order.Status != before {
+				return false
+			}
+			// Open and terminal are mutually exclusive
+			if order.Status.IsOpen() && order.Status.IsTerminal() {
+				return false
+			}
+		}
+		return true
+	}
+
+	require.NoError(t, quick.Check(property, nil))
+}
+
+func TestOrderStatusPredecessors_MatchTransitions(t *testing.T) {
+	for _, to := range allOrderStatuses[1:] {
+		predecessors := OrderStatusPredecessors(to)
+		for _, from := range allOrderStatuses[1:] {
+			assert.Equal(t, from.CanTransitionTo(to), containsStatus(predecessors, from),
+				"%s -> %s", from, to)
+		}
+	}
+}
+
+func containsStatus(statuses []OrderStatus, status OrderStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -31,16 +31,154 @@ const (
 
 // Order represents a trading order
 type Order struct {
-	ID        string      `json:"id" db:"id"`
-	UserID    string      `json:"user_id" db:"user_id"`
-	Symbol    string      `json:"symbol" db:"symbol"`
-	Side      OrderSide   `json:"side" db:"side"`
-	Type      OrderType   `json:"type" db:"type"`
-	Price     float64     `json:"price" db:"price"`
-	Quantity  float64     `json:"quantity" db:"quantity"`
-	Status    OrderStatus `json:"status" db:"status"`
-	CreatedAt time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at" db:"updated_at"`
+	ID            string      `json:"id" db:"id"`
+	ClientOrderID string      `json:"client_order_id,omitempty" db:"client_order_id"`
+	UserID        string      `json:"user_id" db:"user_id"`
+	Symbol        string      `json:"symbol" db:"symbol"`
+	Side          OrderSide   `json:"side" db:"side"`
+	Type          OrderType   `json:"type" db:"type"`
+	Price         float64     `json:"price" db:"price"`
+	Quantity      float64     `json:"quantity" db:"quantity"`
+	Status        OrderStatus `json:"status" db:"status"`
+	RejectReason  string      `json:"reject_reason,omitempty" db:"reject_reason"`
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// OrderEventType represents a transition in an order's lifecycle
+type OrderEventType string
+
+const (
+	OrderEventAccepted        OrderEventType = "ACCEPTED"
+	OrderEventPartiallyFilled OrderEventType = "PARTIALLY_FILLED"
+	OrderEventFilled          OrderEventType = "FILLED"
+	OrderEventAmended         OrderEventType = "AMENDED"
+	OrderEventCancelled       OrderEventType = "CANCELLED"
+	OrderEventRejected        OrderEventType = "REJECTED"
+)
+
+// Sources of order events
+const (
+	OrderEventSourceClient   = "CLIENT"
+	OrderEventSourceMatching = "MATCHING_ENGINE"
+	OrderEventSourceRisk     = "RISK_CHECK"
+	OrderEventSourceAccounts = "BUYING_POWER"
+)
+
+// OrderEvent is one entry in an order's append-only audit trail. Quantity is
+// the order's remaining quantity after the event; fills also carry the trade
+// and its quantity and price.
+type OrderEvent struct {
+	ID           int64          `json:"id" db:"id"`
+	OrderID      string         `json:"order_id" db:"order_id"`
+	UserID       string         `json:"user_id" db:"user_id"`
+	Symbol       string         `json:"symbol" db:"symbol"`
+	Side         OrderSide      `json:"side" db:"side"`
+	OrderType    OrderType      `json:"order_type" db:"order_type"`
+	EventType    OrderEventType `json:"event_type" db:"event_type"`
+	Status       OrderStatus    `json:"status" db:"status"`
+	Price        float64        `json:"price" db:"price"`
+	Quantity     float64        `json:"quantity" db:"quantity"`
+	TradeID      string         `json:"trade_id,omitempty" db:"trade_id"`
+	FillQuantity float64        `json:"fill_quantity,omitempty" db:"fill_quantity"`
+	FillPrice    float64        `json:"fill_price,omitempty" db:"fill_price"`
+	Reason       string         `json:"reason,omitempty" db:"reason"`
+	Source       string         `json:"source" db:"source"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+}
+
+// OrderFill is the part of a trade that fills one order, with the event
+// recording it in the order's audit trail (nil when the audit trail is
+// disabled). Fills are applied to the stored order, so concurrent fills of
+// the same order cannot fill more than its open quantity.
+type OrderFill struct {
+	Order    *Order
+	Quantity float64
+	Event    *OrderEvent
+}
+
+// Filled sets the order, and its event, to the open quantity and status the
+// order was left with by the fill
+func (f *OrderFill) Filled(quantity float64, status OrderStatus, at time.Time) {
+	f.Order.Quantity = quantity
+	f.Order.Status = status
+	f.Order.UpdatedAt = at
+	if f.Event == nil {
+		return
+	}
+
+	f.Event.Status = status
+	f.Event.Quantity = quantity
+	f.Event.EventType = OrderEventPartiallyFilled
+	if status == OrderStatusFilled {
+		f.Event.EventType = OrderEventFilled
+	}
+}
+
+// TradeSettlement names the buyer and seller whose cash and shares a trade
+// moves. A trade is settled in the transaction that stores it, so a trade
+// the buyer can't pay for is not stored.
+type TradeSettlement struct {
+	BuyerID  string
+	SellerID string
+}
+
+// Account holds a user's cash. Reserved cash backs open buy orders and is
+// not available for new orders.
+type Account struct {
+	UserID       string    `json:"user_id" db:"user_id"`
+	CashBalance  float64   `json:"cash_balance" db:"cash_balance"`
+	CashReserved float64   `json:"cash_reserved" db:"cash_reserved"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AvailableCash returns the cash available for new buy orders
+func (a *Account) AvailableCash() float64 {
+	return a.CashBalance - a.CashReserved
+}
+
+// Position holds a user's shares in a symbol. Reserved shares back open
+// sell orders and are not available for new orders.
+type Position struct {
+	UserID           string    `json:"user_id" db:"user_id"`
+	Symbol           string    `json:"symbol" db:"symbol"`
+	Quantity         float64   `json:"quantity" db:"quantity"`
+	ReservedQuantity float64   `json:"reserved_quantity" db:"reserved_quantity"`
+	AveragePrice     float64   `json:"average_price" db:"average_price"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AvailableQuantity returns the shares available for new sell orders
+func (p *Position) AvailableQuantity() float64 {
+	return p.Quantity - p.ReservedQuantity
+}
+
+// AccountSummary is a user's account together with their positions
+type AccountSummary struct {
+	Account   *Account    `json:"account"`
+	Positions []*Position `json:"positions"`
+}
+
+// Reservation tracks the cash (buy orders) or shares (sell orders) held for
+// an open order. Price is the per-share cash reserved for buy orders;
+// Remaining is the unfilled quantity still covered by the reservation.
+type Reservation struct {
+	OrderID   string    `json:"order_id" db:"order_id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Symbol    string    `json:"symbol" db:"symbol"`
+	Side      OrderSide `json:"side" db:"side"`
+	Price     float64   `json:"price" db:"price"`
+	Remaining float64   `json:"remaining" db:"remaining"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Amount returns the cash (buy) or shares (sell) still reserved
+func (r *Reservation) Amount() float64 {
+	if r.Side == OrderSideBuy {
+		return r.Remaining * r.Price
+	}
+	return r.Remaining
 }
 
 // Trade represents an executed trade
@@ -51,9 +189,302 @@ type Trade struct {
 	Symbol      string    `json:"symbol" db:"symbol"`
 	Price       float64   `json:"price" db:"price"`
 	Quantity    float64   `json:"quantity" db:"quantity"`
+	TakerSide   OrderSide `json:"taker_side,omitempty" db:"taker_side"`
+	BuyFee      float64   `json:"buy_fee" db:"buy_fee"`
+	SellFee     float64   `json:"sell_fee" db:"sell_fee"`
+	MakerRebate float64   `json:"maker_rebate" db:"maker_rebate"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// Value returns the notional value of the trade
+func (t *Trade) Value() float64 {
+	return t.Price * t.Quantity
+}
+
+// FeeRevenue is the fee revenue earned in one symbol over a period. Maker
+// fees are negative when rebates exceed maker charges.
+type FeeRevenue struct {
+	Symbol     string  `json:"symbol" db:"symbol"`
+	TradeCount int     `json:"trade_count" db:"trade_count"`
+	Volume     float64 `json:"volume" db:"volume"`
+	MakerFees  float64 `json:"maker_fees" db:"maker_fees"`
+	TakerFees  float64 `json:"taker_fees" db:"taker_fees"`
+	TotalFees  float64 `json:"total_fees" db:"total_fees"`
+}
+
+// UserFeeTier is the fee tier a user currently trades at in a symbol
+type UserFeeTier struct {
+	UserID     string    `json:"user_id"`
+	Symbol     string    `json:"symbol"`
+	Tier       int       `json:"tier"`
+	Volume     float64   `json:"volume"`
+	MakerBps   float64   `json:"maker_bps"`
+	TakerBps   float64   `json:"taker_bps"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// MarketMaker is a user registered as designated market maker in a symbol,
+// with the quoting obligations it is measured against and the rebate it
+// earns off maker fees while meeting them
+type MarketMaker struct {
+	UserID       string    `json:"user_id" db:"user_id"`
+	Symbol       string    `json:"symbol" db:"symbol"`
+	MaxSpreadBps float64   `json:"max_spread_bps" db:"max_spread_bps"`
+	MinQuoteSize float64   `json:"min_quote_size" db:"min_quote_size"`
+	MinUptime    float64   `json:"min_uptime" db:"min_uptime"`
+	RebateBps    float64   `json:"rebate_bps" db:"rebate_bps"`
+	Active       bool      `json:"active" db:"active"`
+	RegisteredAt time.Time `json:"registered_at" db:"registered_at"`
+}
+
+// MarketMakerStats aggregates a market maker's quote samples in a symbol over
+// one UTC day. Spread and quote size are summed over two-sided samples only.
+type MarketMakerStats struct {
+	UserID           string    `json:"user_id" db:"user_id"`
+	Symbol           string    `json:"symbol" db:"symbol"`
+	TradeDate        time.Time `json:"trade_date" db:"trade_date"`
+	Samples          int       `json:"samples" db:"samples"`
+	QuotedSamples    int       `json:"quoted_samples" db:"quoted_samples"`
+	CompliantSamples int       `json:"compliant_samples" db:"compliant_samples"`
+	SpreadBpsSum     float64   `json:"spread_bps_sum" db:"spread_bps_sum"`
+	QuoteSizeSum     float64   `json:"quote_size_sum" db:"quote_size_sum"`
+	MakerVolume      float64   `json:"maker_volume" db:"maker_volume"`
+	Rebates          float64   `json:"rebates" db:"rebates"`
+}
+
+// Uptime returns the share of samples in which the market maker met its
+// obligations
+func (s *MarketMakerStats) Uptime() float64 {
+	if s.Samples == 0 {
+		return 0
+	}
+	return float64(s.CompliantSamples) / float64(s.Samples)
+}
+
+// MarketMakerStatus is a market maker registration with its compliance so
+// far today
+type MarketMakerStatus struct {
+	MarketMaker
+	Samples        int     `json:"samples"`
+	Uptime         float64 `json:"uptime"`
+	RebateEligible bool    `json:"rebate_eligible"`
+}
+
+// MarketMakerCompliance is a market maker's compliance with its obligations
+// in a symbol for one UTC day
+type MarketMakerCompliance struct {
+	Symbol       string    `json:"symbol"`
+	TradeDate    time.Time `json:"trade_date"`
+	Samples      int       `json:"samples"`
+	Uptime       float64   `json:"uptime"`
+	AvgSpreadBps float64   `json:"avg_spread_bps"`
+	AvgQuoteSize float64   `json:"avg_quote_size"`
+	MaxSpreadBps float64   `json:"max_spread_bps"`
+	MinQuoteSize float64   `json:"min_quote_size"`
+	MinUptime    float64   `json:"min_uptime"`
+	Compliant    bool      `json:"compliant"`
+	MakerVolume  float64   `json:"maker_volume"`
+	Rebates      float64   `json:"rebates"`
+}
+
+// MarketMakerReport is a market maker's compliance report over a period
+type MarketMakerReport struct {
+	UserID      string                   `json:"user_id"`
+	From        time.Time                `json:"from"`
+	To          time.Time                `json:"to"`
+	Compliant   bool                     `json:"compliant"`
+	MakerVolume float64                  `json:"maker_volume"`
+	Rebates     float64                  `json:"rebates"`
+	Days        []*MarketMakerCompliance `json:"days"`
+}
+
+// TradeCursor identifies a position in a symbol's trade history. Trades are
+// paged newest first, ordered by creation time and then ID.
+type TradeCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// TradePage is a page of trade history
+type TradePage struct {
+	Trades     []*Trade `json:"trades"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// CandleInterval is the bucket width of an OHLCV candle
+type CandleInterval string
+
+const (
+	CandleInterval1m CandleInterval = "1m"
+	CandleInterval5m CandleInterval = "5m"
+	CandleInterval1h CandleInterval = "1h"
+)
+
+// CandleIntervals lists the intervals that are continuously aggregated
+var CandleIntervals = []CandleInterval{CandleInterval1m, CandleInterval5m, CandleInterval1h}
+
+// Duration returns the bucket width, or zero for unknown intervals
+func (i CandleInterval) Duration() time.Duration {
+	switch i {
+	case CandleInterval1m:
+		return time.Minute
+	case CandleInterval5m:
+		return 5 * time.Minute
+	case CandleInterval1h:
+		return time.Hour
+	}
+	return 0
+}
+
+// Candle represents OHLCV data for one interval bucket of a symbol
+type Candle struct {
+	Symbol      string         `json:"symbol" db:"symbol"`
+	Interval    CandleInterval `json:"interval" db:"bucket_interval"`
+	BucketStart time.Time      `json:"bucket_start" db:"bucket_start"`
+	Open        float64        `json:"open" db:"open"`
+	High        float64        `json:"high" db:"high"`
+	Low         float64        `json:"low" db:"low"`
+	Close       float64        `json:"close" db:"close"`
+	Volume      float64        `json:"volume" db:"volume"`
+	TradeCount  int64          `json:"trade_count" db:"trade_count"`
+
+	// FirstTradeAt and LastTradeAt order late trades into the bucket
+	FirstTradeAt time.Time `json:"-" db:"first_trade_at"`
+	LastTradeAt  time.Time `json:"-" db:"last_trade_at"`
+}
+
+// Ticker represents rolling 24 hour statistics for a symbol
+type Ticker struct {
+	Symbol          string    `json:"symbol" db:"symbol"`
+	LastPrice       float64   `json:"last_price" db:"last_price"`
+	OpenPrice       float64   `json:"open_price" db:"open_price"`
+	HighPrice       float64   `json:"high_price" db:"high_price"`
+	LowPrice        float64   `json:"low_price" db:"low_price"`
+	Volume          float64   `json:"volume" db:"volume"`
+	QuoteVolume     float64   `json:"quote_volume" db:"quote_volume"`
+	VWAP            float64   `json:"vwap" db:"vwap"`
+	TradeCount      int64     `json:"trade_count" db:"trade_count"`
+	PriceChange     float64   `json:"price_change" db:"-"`
+	PriceChangePerc float64   `json:"price_change_percent" db:"-"`
+	LastTradeAt     time.Time `json:"last_trade_at" db:"last_trade_at"`
+}
+
+// AlertType identifies the manipulation pattern a surveillance alert flags
+type AlertType string
+
+const (
+	AlertTypeWashTrade        AlertType = "WASH_TRADE"
+	AlertTypeSpoofing         AlertType = "SPOOFING"
+	AlertTypeLayering         AlertType = "LAYERING"
+	AlertTypeMomentumIgnition AlertType = "MOMENTUM_IGNITION"
+	AlertTypeMarkingTheClose  AlertType = "MARKING_THE_CLOSE"
+)
+
+// AlertStatus represents the review state of a surveillance alert
+type AlertStatus string
+
+const (
+	AlertStatusOpen      AlertStatus = "OPEN"
+	AlertStatusDismissed AlertStatus = "DISMISSED"
+	AlertStatusEscalated AlertStatus = "ESCALATED"
+)
+
+// SurveillanceAlert is a suspected market manipulation. OrderIDs are the
+// orders that make up the pattern, such as the cancelled spoof orders, and
+// TradeID the trade that completed it.
+type SurveillanceAlert struct {
+	ID             string      `json:"id" db:"id"`
+	Type           AlertType   `json:"type" db:"alert_type"`
+	Symbol         string      `json:"symbol" db:"symbol"`
+	UserID         string      `json:"user_id" db:"user_id"`
+	CounterpartyID string      `json:"counterparty_id,omitempty" db:"counterparty_id"`
+	TradeID        string      `json:"trade_id,omitempty" db:"trade_id"`
+	OrderIDs       []string    `json:"order_ids" db:"-"`
+	Quantity       float64     `json:"quantity" db:"quantity"`
+	Description    string      `json:"description" db:"description"`
+	Status         AlertStatus `json:"status" db:"status"`
+	DetectedAt     time.Time   `json:"detected_at" db:"detected_at"`
+	ReviewedAt     *time.Time  `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// AlertFilter selects surveillance alerts. Zero fields don't filter.
+type AlertFilter struct {
+	Type   AlertType
+	Status AlertStatus
+	UserID string
+	Symbol string
+	Start  time.Time
+	End    time.Time
+	Limit  int
+}
+
+// SettlementStatus represents the state of a settlement obligation
+type SettlementStatus string
+
+const (
+	SettlementStatusPending SettlementStatus = "PENDING"
+	SettlementStatusSettled SettlementStatus = "SETTLED"
+	SettlementStatusFailed  SettlementStatus = "FAILED"
+)
+
+// Settlement failure reasons
+const (
+	SettlementFailToDeliver = "FAIL_TO_DELIVER"
+	SettlementFailToPay     = "FAIL_TO_PAY"
+)
+
+// SettlementObligation is a user's net position to settle in a symbol for
+// one trade date. A positive NetQuantity is received and a negative one
+// delivered; a positive NetCash is received and a negative one paid.
+type SettlementObligation struct {
+	ID                string           `json:"id" db:"id"`
+	TradeDate         time.Time        `json:"trade_date" db:"trade_date"`
+	SettlementDate    time.Time        `json:"settlement_date" db:"settlement_date"`
+	UserID            string           `json:"user_id" db:"user_id"`
+	Symbol            string           `json:"symbol" db:"symbol"`
+	NetQuantity       float64          `json:"net_quantity" db:"net_quantity"`
+	NetCash           float64          `json:"net_cash" db:"net_cash"`
+	GrossBuyQuantity  float64          `json:"gross_buy_quantity" db:"gross_buy_quantity"`
+	GrossSellQuantity float64          `json:"gross_sell_quantity" db:"gross_sell_quantity"`
+	GrossNotional     float64          `json:"gross_notional" db:"gross_notional"`
+	TradeCount        int              `json:"trade_count" db:"trade_count"`
+	Status            SettlementStatus `json:"status" db:"status"`
+	FailCount         int              `json:"fail_count" db:"fail_count"`
+	FailReason        string           `json:"fail_reason,omitempty" db:"fail_reason"`
+	CreatedAt         time.Time        `json:"created_at" db:"created_at"`
+	SettledAt         *time.Time       `json:"settled_at,omitempty" db:"settled_at"`
+}
+
+// SettlementReport summarizes the settlement of one trade date
+type SettlementReport struct {
+	TradeDate         time.Time                  `json:"trade_date"`
+	SettlementDate    time.Time                  `json:"settlement_date"`
+	Obligations       int                        `json:"obligations"`
+	Settled           int                        `json:"settled"`
+	Failed            int                        `json:"failed"`
+	Pending           int                        `json:"pending"`
+	GrossNotional     float64                    `json:"gross_notional"`
+	NetCash           float64                    `json:"net_cash"`
+	NettingEfficiency float64                    `json:"netting_efficiency"`
+	FailedValue       float64                    `json:"failed_value"`
+	FailRate          float64                    `json:"fail_rate"`
+	Symbols           []*SymbolSettlementSummary `json:"symbols"`
+	Fails             []*SettlementObligation    `json:"fails"`
+	GeneratedAt       time.Time                  `json:"generated_at"`
+}
+
+// SymbolSettlementSummary summarizes the settlement of one symbol
+type SymbolSettlementSummary struct {
+	Symbol          string  `json:"symbol"`
+	Obligations     int     `json:"obligations"`
+	Settled         int     `json:"settled"`
+	Failed          int     `json:"failed"`
+	Pending         int     `json:"pending"`
+	DeliverQuantity float64 `json:"deliver_quantity"`
+	FailedQuantity  float64 `json:"failed_quantity"`
+	FailedValue     float64 `json:"failed_value"`
+}
+
 // OrderBook represents the order book for a symbol
 type OrderBook struct {
 	Symbol    string    `json:"symbol"`
@@ -62,12 +493,15 @@ type OrderBook struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// PriceUpdate represents a price update for a symbol
+// PriceUpdate represents a price update for a symbol. VolatilityIndex is the
+// simulated volatility relative to its base level, zero when the price was
+// not generated.
 type PriceUpdate struct {
-	Symbol    string    `json:"symbol"`
-	Price     float64   `json:"price"`
-	Volume    float64   `json:"volume"`
-	Timestamp time.Time `json:"timestamp"`
+	Symbol          string    `json:"symbol"`
+	Price           float64   `json:"price"`
+	Volume          float64   `json:"volume"`
+	VolatilityIndex float64   `json:"volatility_index,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
 }
 
 // MarketData represents market information
@@ -94,6 +528,83 @@ type User struct {
 	IsActive     bool      `json:"is_active" db:"is_active"`
 }
 
+// APIKeyScope represents a permission granted to an API key
+type APIKeyScope string
+
+const (
+	APIKeyScopeTrade APIKeyScope = "trade"
+	APIKeyScopeRead  APIKeyScope = "read"
+	APIKeyScopeAdmin APIKeyScope = "admin"
+)
+
+// APIKey represents an API credential issued to a user.
+// Only the SHA-256 hash of the secret is persisted, along with the request
+// signing key encrypted under a server-side key.
+type APIKey struct {
+	ID                  string        `json:"id" db:"id"`
+	UserID              string        `json:"user_id" db:"user_id"`
+	Name                string        `json:"name" db:"name"`
+	SecretHash          string        `json:"-" db:"secret_hash"`
+	SigningKeyEncrypted string        `json:"-" db:"signing_key_encrypted"`
+	Scopes              []APIKeyScope `json:"scopes" db:"-"`
+	CreatedAt           time.Time     `json:"created_at" db:"created_at"`
+	LastUsedAt          *time.Time    `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt           *time.Time    `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// IsRevoked returns true if the key has been revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// AuthIdentity represents the authenticated caller of a request
+type AuthIdentity struct {
+	UserID string        `json:"user_id"`
+	KeyID  string        `json:"key_id"`
+	Scopes []APIKeyScope `json:"scopes"`
+}
+
+// HasScope returns true if the identity holds the scope. Admin implies every scope.
+func (i *AuthIdentity) HasScope(scope APIKeyScope) bool {
+	for _, s := range i.Scopes {
+		if s == scope || s == APIKeyScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin returns true if the identity holds the admin scope
+func (i *AuthIdentity) IsAdmin() bool {
+	return i.HasScope(APIKeyScopeAdmin)
+}
+
+// CanAccessUser returns true if the identity may act on behalf of the given user
+func (i *AuthIdentity) CanAccessUser(userID string) bool {
+	return i.UserID == userID || i.IsAdmin()
+}
+
+// OrderFilter selects open orders for mass cancellation. Empty fields match
+// any value.
+type OrderFilter struct {
+	UserID string    `json:"user_id,omitempty"`
+	Symbol string    `json:"symbol,omitempty"`
+	Side   OrderSide `json:"side,omitempty"`
+}
+
+// IsEmpty returns true if the filter would match every order
+func (f OrderFilter) IsEmpty() bool {
+	return f.UserID == "" && f.Symbol == "" && f.Side == ""
+}
+
+// BatchOrderResult is the outcome of a single order in a batch submission.
+// Exactly one of Order and Err is set.
+type BatchOrderResult struct {
+	Index int
+	Order *Order
+	Err   error
+}
+
 // Match represents a matching pair of orders
 type Match struct {
 	BuyOrder  Order   `json:"buy_order"`
@@ -120,6 +631,7 @@ type EventType string
 const (
 	EventTypeOrderPlaced    EventType = "order.placed"
 	EventTypeOrderCancelled EventType = "order.cancelled"
+	EventTypeOrderAmended   EventType = "order.amended"
 	EventTypeTradeExecuted  EventType = "trade.executed"
 	EventTypePriceUpdate    EventType = "price.updated"
 	EventTypeMarketData     EventType = "market.data"
@@ -0,0 +1,456 @@
+package surveillance
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// averageWeight is the weight of each new order in a symbol's average order
+// quantity
+const averageWeight = 0.05
+
+// OrderEvent is an order placed on or cancelled from the book
+type OrderEvent struct {
+	OrderID  string
+	UserID   string
+	Symbol   string
+	Side     shared.OrderSide
+	Price    float64
+	Quantity float64
+	At       time.Time
+}
+
+// TradeEvent is an executed trade. TakerSide is the side of the order that
+// took liquidity.
+type TradeEvent struct {
+	TradeID     string
+	Symbol      string
+	Price       float64
+	Quantity    float64
+	BuyOrderID  string
+	SellOrderID string
+	BuyUserID   string
+	SellUserID  string
+	TakerSide   shared.OrderSide
+	At          time.Time
+}
+
+// Detector looks for manipulation patterns in the order and trade flow. Every
+// pattern is completed by a trade, so alerts are raised from OnTrade:
+//
+//   - wash trade: both sides of the trade share a beneficial owner
+//   - spoofing: the user cancelled large orders on the other side within the
+//     window before the fill
+//   - layering: the user cancelled orders at several price levels on the
+//     other side within the window before the fill
+//   - momentum ignition: the user took liquidity several times on the other
+//     side within the window, moving the price, before the fill
+//   - marking the close: the user took liquidity several times on one side
+//     within the close window before the end of the UTC trade day, moving
+//     the price, ending with the trade
+//
+// The detector keeps only the window of history it needs in memory.
+type Detector struct {
+	config config.SurveillanceConfig
+
+	owners      map[string]string
+	avgQuantity map[string]float64
+	cancels     map[string][]OrderEvent
+	aggressive  map[string][]aggressiveTrade
+	closing     map[string][]aggressiveTrade
+	markedClose map[string]time.Time
+	mutex       sync.Mutex
+}
+
+// aggressiveTrade is a trade in which a user took liquidity
+type aggressiveTrade struct {
+	orderID  string
+	side     shared.OrderSide
+	price    float64
+	quantity float64
+	at       time.Time
+}
+
+// NewDetector creates a new detector
+func NewDetector(cfg config.SurveillanceConfig) *Detector {
+	return &Detector{
+		config:      cfg,
+		owners:      make(map[string]string),
+		avgQuantity: make(map[string]float64),
+		cancels:     make(map[string][]OrderEvent),
+		aggressive:  make(map[string][]aggressiveTrade),
+		closing:     make(map[string][]aggressiveTrade),
+		markedClose: make(map[string]time.Time),
+	}
+}
+
+// SetOwners replaces the beneficial owners of user accounts. Users without
+// an entry are their own beneficial owner.
+func (d *Detector) SetOwners(owners map[string]string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.owners = owners
+}
+
+// SetOwner sets the beneficial owner of one user account
+func (d *Detector) SetOwner(userID, ownerID string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.owners[userID] = ownerID
+}
+
+// OnOrderPlaced folds a new order into its symbol's average order quantity
+func (d *Detector) OnOrderPlaced(event OrderEvent) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	average, exists := d.avgQuantity[event.Symbol]
+	if !exists {
+		d.avgQuantity[event.Symbol] = event.Quantity
+		return
+	}
+	d.avgQuantity[event.Symbol] = average + averageWeight*(event.Quantity-average)
+}
+
+// OnOrderCancelled records a cancellation
+func (d *Detector) OnOrderCancelled(event OrderEvent) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key := userSymbolKey(event.UserID, event.Symbol)
+	d.cancels[key] = append(d.pruneCancels(key, event.At), event)
+}
+
+// OnTrade checks whether a trade completes a manipulation pattern and
+// returns the alerts it raises
+func (d *Detector) OnTrade(event TradeEvent) []*shared.SurveillanceAlert {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var alerts []*shared.SurveillanceAlert
+
+	if owner := d.owner(event.BuyUserID); owner == d.owner(event.SellUserID) {
+		alert := newAlert(shared.AlertTypeWashTrade, event, event.BuyUserID, event.Quantity,
+			fmt.Sprintf("buyer and seller share beneficial owner %s", owner),
+			event.BuyOrderID, event.SellOrderID)
+		alert.CounterpartyID = event.SellUserID
+		alerts = append(alerts, alert)
+	}
+
+	legs := []struct {
+		userID string
+		side   shared.OrderSide
+	}{
+		{event.BuyUserID, shared.OrderSideBuy},
+		{event.SellUserID, shared.OrderSideSell},
+	}
+	for _, leg := range legs {
+		if alert := d.checkCancels(event, leg.userID, leg.side); alert != nil {
+			alerts = append(alerts, alert)
+		}
+		if alert := d.checkMomentum(event, leg.userID, leg.side); alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	takerID, takerOrderID := event.BuyUserID, event.BuyOrderID
+	if event.TakerSide == shared.OrderSideSell {
+		takerID, takerOrderID = event.SellUserID, event.SellOrderID
+	}
+	if event.TakerSide != "" {
+		taken := aggressiveTrade{
+			orderID:  takerOrderID,
+			side:     event.TakerSide,
+			price:    event.Price,
+			quantity: event.Quantity,
+			at:       event.At,
+		}
+		key := userSymbolKey(takerID, event.Symbol)
+		d.aggressive[key] = append(d.pruneAggressive(key, event.At), taken)
+		if alert := d.checkClose(event, takerID, taken); alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts
+}
+
+// Prune drops history older than the window
+func (d *Detector) Prune(now time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for key := range d.cancels {
+		if d.pruneCancels(key, now) == nil {
+			delete(d.cancels, key)
+		}
+	}
+	for key := range d.aggressive {
+		if d.pruneAggressive(key, now) == nil {
+			delete(d.aggressive, key)
+		}
+	}
+	for key, trades := range d.closing {
+		if trades[len(trades)-1].at.Before(now.Add(-d.config.CloseWindow)) {
+			delete(d.closing, key)
+		}
+	}
+	for key, closeAt := range d.markedClose {
+		if closeAt.Before(now) {
+			delete(d.markedClose, key)
+		}
+	}
+}
+
+// checkCancels looks for spoofing and layering by a user filled on a side:
+// orders on the other side cancelled within the window. Orders an alert is
+// raised for are forgotten so they aren't flagged twice.
+func (d *Detector) checkCancels(event TradeEvent, userID string, side shared.OrderSide) *shared.SurveillanceAlert {
+	key := userSymbolKey(userID, event.Symbol)
+	cancels := d.pruneCancels(key, event.At)
+
+	var opposite, kept []OrderEvent
+	levels := make(map[float64]bool)
+	for _, cancel := range cancels {
+		if cancel.Side == side {
+			kept = append(kept, cancel)
+			continue
+		}
+		opposite = append(opposite, cancel)
+		levels[cancel.Price] = true
+	}
+	if len(opposite) == 0 {
+		return nil
+	}
+
+	if len(levels) >= d.config.LayeringMinLevels {
+		d.cancels[key] = kept
+		return newAlert(shared.AlertTypeLayering, event, userID, totalQuantity(opposite),
+			fmt.Sprintf("cancelled %d %s orders at %d price levels within %s before %s fill",
+				len(opposite), oppositeSide(side), len(levels), d.config.Window, side),
+			orderIDs(opposite)...)
+	}
+
+	threshold := d.avgQuantity[event.Symbol] * d.config.LargeOrderMultiple
+	var large []OrderEvent
+	for _, cancel := range opposite {
+		if threshold > 0 && cancel.Quantity >= threshold {
+			large = append(large, cancel)
+		} else {
+			kept = append(kept, cancel)
+		}
+	}
+	if len(large) == 0 {
+		return nil
+	}
+
+	d.cancels[key] = kept
+	return newAlert(shared.AlertTypeSpoofing, event, userID, totalQuantity(large),
+		fmt.Sprintf("cancelled %d large %s orders (%.8g or more) within %s before %s fill",
+			len(large), oppositeSide(side), threshold, d.config.Window, side),
+		orderIDs(large)...)
+}
+
+// checkMomentum looks for momentum ignition by a user filled on a side:
+// trades on the other side in which the user took liquidity within the
+// window, moving the price in their direction
+func (d *Detector) checkMomentum(event TradeEvent, userID string, side shared.OrderSide) *shared.SurveillanceAlert {
+	key := userSymbolKey(userID, event.Symbol)
+	trades := d.pruneAggressive(key, event.At)
+
+	var run, kept []aggressiveTrade
+	for _, trade := range trades {
+		if trade.side == side {
+			kept = append(kept, trade)
+		} else {
+			run = append(run, trade)
+		}
+	}
+	if len(run) < d.config.MomentumMinTrades {
+		return nil
+	}
+
+	first, last := run[0].price, run[len(run)-1].price
+	if first <= 0 {
+		return nil
+	}
+	move := (last - first) / first * 10000
+	if side == shared.OrderSideBuy {
+		// The user sold the price down before buying
+		move = -move
+	}
+	if move < d.config.MomentumMinMoveBps {
+		return nil
+	}
+
+	d.aggressive[key] = kept
+
+	ids := make([]string, 0, len(run))
+	seen := make(map[string]bool, len(run))
+	for _, trade := range run {
+		if !seen[trade.orderID] {
+			seen[trade.orderID] = true
+			ids = append(ids, trade.orderID)
+		}
+	}
+
+	return newAlert(shared.AlertTypeMomentumIgnition, event, userID, event.Quantity,
+		fmt.Sprintf("took liquidity %d times on the %s side moving the price %.1f bps within %s before %s fill",
+			len(run), oppositeSide(side), move, d.config.Window, side),
+		ids...)
+}
+
+// checkClose looks for marking the close by the taker of a trade: trades on
+// one side in which the user took liquidity within the close window before
+// the end of the trade day, moving the price in their direction. A user is
+// flagged at most once per symbol and close.
+func (d *Detector) checkClose(event TradeEvent, userID string, taken aggressiveTrade) *shared.SurveillanceAlert {
+	closeAt := event.At.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	opens := closeAt.Add(-d.config.CloseWindow)
+	if event.At.Before(opens) {
+		return nil
+	}
+
+	key := userSymbolKey(userID, event.Symbol)
+	trades := d.closing[key]
+	start := 0
+	for start < len(trades) && trades[start].at.Before(opens) {
+		start++
+	}
+	trades = append(trades[start:], taken)
+	d.closing[key] = trades
+
+	var run []aggressiveTrade
+	quantity := 0.0
+	for _, trade := range trades {
+		if trade.side == taken.side {
+			run = append(run, trade)
+			quantity += trade.quantity
+		}
+	}
+	if len(run) < d.config.MomentumMinTrades || d.markedClose[key].Equal(closeAt) {
+		return nil
+	}
+
+	first := run[0].price
+	if first <= 0 {
+		return nil
+	}
+	move := (taken.price - first) / first * 10000
+	if taken.side == shared.OrderSideSell {
+		move = -move
+	}
+	if move < d.config.MomentumMinMoveBps {
+		return nil
+	}
+
+	d.markedClose[key] = closeAt
+
+	ids := make([]string, 0, len(run))
+	seen := make(map[string]bool, len(run))
+	for _, trade := range run {
+		if !seen[trade.orderID] {
+			seen[trade.orderID] = true
+			ids = append(ids, trade.orderID)
+		}
+	}
+
+	return newAlert(shared.AlertTypeMarkingTheClose, event, userID, quantity,
+		fmt.Sprintf("took liquidity %d times on the %s side moving the price %.1f bps within %s before the close",
+			len(run), taken.side, move, d.config.CloseWindow),
+		ids...)
+}
+
+// pruneCancels drops a user's cancellations older than the window and
+// returns the rest. The caller must hold the mutex.
+func (d *Detector) pruneCancels(key string, now time.Time) []OrderEvent {
+	cutoff := now.Add(-d.config.Window)
+	cancels := d.cancels[key]
+
+	start := 0
+	for start < len(cancels) && cancels[start].At.Before(cutoff) {
+		start++
+	}
+	if start == len(cancels) {
+		d.cancels[key] = nil
+		return nil
+	}
+
+	d.cancels[key] = cancels[start:]
+	return d.cancels[key]
+}
+
+// pruneAggressive drops a user's aggressive trades older than the window
+// and returns the rest. The caller must hold the mutex.
+func (d *Detector) pruneAggressive(key string, now time.Time) []aggressiveTrade {
+	cutoff := now.Add(-d.config.Window)
+	trades := d.aggressive[key]
+
+	start := 0
+	for start < len(trades) && trades[start].at.Before(cutoff) {
+		start++
+	}
+	if start == len(trades) {
+		d.aggressive[key] = nil
+		return nil
+	}
+
+	d.aggressive[key] = trades[start:]
+	return d.aggressive[key]
+}
+
+// owner returns a user's beneficial owner. The caller must hold the mutex.
+func (d *Detector) owner(userID string) string {
+	if owner, exists := d.owners[userID]; exists {
+		return owner
+	}
+	return userID
+}
+
+// newAlert creates an open alert raised by a trade
+func newAlert(alertType shared.AlertType, event TradeEvent, userID string, quantity float64, description string, orderIDs ...string) *shared.SurveillanceAlert {
+	return &shared.SurveillanceAlert{
+		ID:          uuid.New().String(),
+		Type:        alertType,
+		Symbol:      event.Symbol,
+		UserID:      userID,
+		TradeID:     event.TradeID,
+		OrderIDs:    orderIDs,
+		Quantity:    quantity,
+		Description: description,
+		Status:      shared.AlertStatusOpen,
+		DetectedAt:  event.At,
+	}
+}
+
+func totalQuantity(orders []OrderEvent) float64 {
+	total := 0.0
+	for _, order := range orders {
+		total += order.Quantity
+	}
+	return total
+}
+
+func orderIDs(orders []OrderEvent) []string {
+	ids := make([]string, len(orders))
+	for i, order := range orders {
+		ids[i] = order.OrderID
+	}
+	return ids
+}
+
+func oppositeSide(side shared.OrderSide) shared.OrderSide {
+	if side == shared.OrderSideBuy {
+		return shared.OrderSideSell
+	}
+	return shared.OrderSideBuy
+}
+
+func userSymbolKey(userID, symbol string) string {
+	return userID + "|" + symbol
+}
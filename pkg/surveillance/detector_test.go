@@ -0,0 +1,298 @@
+package surveillance
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// step is one event of a detection fixture. Exactly one of place, cancel and
+// trade is set; at is the offset from the fixture's start.
+type step struct {
+	at     time.Duration
+	place  *OrderEvent
+	cancel *OrderEvent
+	trade  *TradeEvent
+}
+
+func place(at time.Duration, userID string, side shared.OrderSide, price, quantity float64) step {
+	return step{at: at, place: &OrderEvent{UserID: userID, Symbol: "AAPL", Side: side, Price: price, Quantity: quantity}}
+}
+
+func cancel(at time.Duration, userID string, side shared.OrderSide, price, quantity float64) step {
+	return step{at: at, cancel: &OrderEvent{UserID: userID, Symbol: "AAPL", Side: side, Price: price, Quantity: quantity}}
+}
+
+func trade(at time.Duration, buyerID, sellerID string, takerSide shared.OrderSide, price, quantity float64) step {
+	return step{at: at, trade: &TradeEvent{
+		Symbol: "AAPL", Price: price, Quantity: quantity,
+		BuyUserID: buyerID, SellUserID: sellerID, TakerSide: takerSide,
+	}}
+}
+
+// background places ordinary orders so the symbol has an average order size
+func background(count int, quantity float64) []step {
+	steps := make([]step, count)
+	for i := range steps {
+		steps[i] = place(0, "crowd", shared.OrderSideBuy, 100, quantity)
+	}
+	return steps
+}
+
+func testConfig() config.SurveillanceConfig {
+	return config.SurveillanceConfig{
+		Enabled:            true,
+		Window:             10 * time.Second,
+		LargeOrderMultiple: 5,
+		LayeringMinLevels:  3,
+		MomentumMinTrades:  3,
+		MomentumMinMoveBps: 20,
+		CloseWindow:        5 * time.Minute,
+	}
+}
+
+// run feeds the fixture's events to a new detector and returns the alerts
+// it raised
+func run(steps []step, start time.Time, owners map[string]string) []*shared.SurveillanceAlert {
+	detector := NewDetector(testConfig())
+	if owners != nil {
+		detector.SetOwners(owners)
+	}
+
+	var alerts []*shared.SurveillanceAlert
+	for i, s := range steps {
+		at := start.Add(s.at)
+		id := fmt.Sprintf("%d", i)
+		switch {
+		case s.place != nil:
+			s.place.OrderID, s.place.At = id, at
+			detector.OnOrderPlaced(*s.place)
+		case s.cancel != nil:
+			s.cancel.OrderID, s.cancel.At = id, at
+			detector.OnOrderCancelled(*s.cancel)
+		case s.trade != nil:
+			s.trade.TradeID, s.trade.At = id, at
+			s.trade.BuyOrderID, s.trade.SellOrderID = "buy-"+id, "sell-"+id
+			alerts = append(alerts, detector.OnTrade(*s.trade)...)
+		}
+	}
+	return alerts
+}
+
+func alertTypes(alerts []*shared.SurveillanceAlert) []shared.AlertType {
+	types := make([]shared.AlertType, len(alerts))
+	for i, alert := range alerts {
+		types[i] = alert.Type
+	}
+	return types
+}
+
+var (
+	buy  = shared.OrderSideBuy
+	sell = shared.OrderSideSell
+)
+
+func TestDetector_Fixtures(t *testing.T) {
+	midday := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	beforeClose := time.Date(2024, 1, 15, 23, 57, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		start  time.Time
+		owners map[string]string
+		steps  []step
+		alerts []shared.AlertType
+	}{
+		{
+			name:   "wash trade between accounts of one owner",
+			owners: map[string]string{"alice": "owner", "alice2": "owner"},
+			steps:  []step{trade(0, "alice", "alice2", buy, 100, 10)},
+			alerts: []shared.AlertType{shared.AlertTypeWashTrade},
+		},
+		{
+			name:   "self-trade",
+			steps:  []step{trade(0, "alice", "alice", buy, 100, 10)},
+			alerts: []shared.AlertType{shared.AlertTypeWashTrade},
+		},
+		{
+			name: "spoofing: large sell cancelled before a buy fill",
+			steps: append(background(20, 10),
+				place(time.Second, "spoofer", sell, 101, 100),
+				cancel(2*time.Second, "spoofer", sell, 101, 100),
+				trade(3*time.Second, "spoofer", "crowd", sell, 100, 10),
+			),
+			alerts: []shared.AlertType{shared.AlertTypeSpoofing},
+		},
+		{
+			name: "layering: sells cancelled at three levels before a buy fill",
+			steps: append(background(20, 10),
+				cancel(time.Second, "layerer", sell, 101, 10),
+				cancel(time.Second, "layerer", sell, 102, 10),
+				cancel(time.Second, "layerer", sell, 103, 10),
+				trade(2*time.Second, "layerer", "crowd", sell, 100, 10),
+			),
+			alerts: []shared.AlertType{shared.AlertTypeLayering},
+		},
+		{
+			name: "momentum ignition: buys push the price up before selling",
+			steps: []step{
+				trade(0, "igniter", "crowd", buy, 100, 5),
+				trade(time.Second, "igniter", "crowd", buy, 100.2, 5),
+				trade(2*time.Second, "igniter", "crowd", buy, 100.5, 5),
+				trade(3*time.Second, "crowd", "igniter", buy, 100.5, 15),
+			},
+			alerts: []shared.AlertType{shared.AlertTypeMomentumIgnition},
+		},
+		{
+			name:  "marking the close: buys push the closing price up",
+			start: beforeClose,
+			steps: []step{
+				trade(0, "marker", "crowd", buy, 100, 5),
+				trade(time.Minute, "marker", "crowd", buy, 100.1, 5),
+				trade(2*time.Minute, "marker", "crowd", buy, 100.3, 5),
+				// Flagged once per close
+				trade(2*time.Minute+30*time.Second, "marker", "crowd", buy, 100.4, 5),
+			},
+			alerts: []shared.AlertType{shared.AlertTypeMarkingTheClose},
+		},
+		{
+			name:  "marking the close: sells push the closing price down",
+			start: beforeClose,
+			steps: []step{
+				trade(0, "crowd", "marker", sell, 100, 5),
+				trade(time.Minute, "crowd", "marker", sell, 99.9, 5),
+				trade(2*time.Minute, "crowd", "marker", sell, 99.7, 5),
+			},
+			alerts: []shared.AlertType{shared.AlertTypeMarkingTheClose},
+		},
+		{
+			name: "benign: small orders cancelled before a fill",
+			steps: append(background(20, 10),
+				cancel(time.Second, "trader", sell, 101, 10),
+				cancel(time.Second, "trader", sell, 101, 12),
+				trade(2*time.Second, "trader", "crowd", sell, 100, 10),
+			),
+		},
+		{
+			name: "benign: large order cancelled on the same side",
+			steps: append(background(20, 10),
+				cancel(time.Second, "trader", buy, 99, 100),
+				trade(2*time.Second, "trader", "crowd", sell, 100, 10),
+			),
+		},
+		{
+			name: "benign: cancellations older than the window",
+			steps: append(background(20, 10),
+				cancel(time.Second, "trader", sell, 101, 100),
+				cancel(time.Second, "trader", sell, 102, 10),
+				cancel(time.Second, "trader", sell, 103, 10),
+				trade(20*time.Second, "trader", "crowd", sell, 100, 10),
+			),
+		},
+		{
+			name:   "benign: different owners trading",
+			owners: map[string]string{"alice": "a", "bob": "b"},
+			steps:  []step{trade(0, "alice", "bob", buy, 100, 10)},
+		},
+		{
+			name: "benign: repeated buys that don't move the price",
+			steps: []step{
+				trade(0, "buyer", "crowd", buy, 100, 5),
+				trade(time.Second, "buyer", "crowd", buy, 100, 5),
+				trade(2*time.Second, "buyer", "crowd", buy, 100.1, 5),
+				trade(3*time.Second, "crowd", "buyer", buy, 100.1, 15),
+			},
+		},
+		{
+			name:  "benign: price-moving buys during the day",
+			start: midday,
+			steps: []step{
+				trade(0, "buyer", "crowd", buy, 100, 5),
+				trade(time.Minute, "buyer", "crowd", buy, 100.1, 5),
+				trade(2*time.Minute, "buyer", "crowd", buy, 100.3, 5),
+			},
+		},
+		{
+			name:  "benign: buys into the close that don't move the price",
+			start: beforeClose,
+			steps: []step{
+				trade(0, "buyer", "crowd", buy, 100, 5),
+				trade(time.Minute, "buyer", "crowd", buy, 100.05, 5),
+				trade(2*time.Minute, "buyer", "crowd", buy, 100.1, 5),
+			},
+		},
+		{
+			name:  "benign: alternating sides into the close",
+			start: beforeClose,
+			steps: []step{
+				trade(0, "trader", "crowd", buy, 100, 5),
+				trade(time.Minute, "crowd", "trader", sell, 100.3, 5),
+				trade(2*time.Minute, "trader", "crowd", buy, 100.5, 5),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := tt.start
+			if start.IsZero() {
+				start = midday
+			}
+			alerts := run(tt.steps, start, tt.owners)
+			assert.ElementsMatch(t, tt.alerts, alertTypes(alerts))
+		})
+	}
+}
+
+func TestDetector_AlertDetails(t *testing.T) {
+	steps := append(background(20, 10),
+		place(time.Second, "spoofer", sell, 101, 100),
+		cancel(2*time.Second, "spoofer", sell, 101, 80),
+		cancel(2*time.Second, "spoofer", sell, 102, 90),
+		trade(3*time.Second, "spoofer", "crowd", sell, 100, 10),
+	)
+	start := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	alerts := run(steps, start, nil)
+	require.Len(t, alerts, 1)
+	alert := alerts[0]
+	assert.Equal(t, shared.AlertTypeSpoofing, alert.Type)
+	assert.Equal(t, "spoofer", alert.UserID)
+	assert.Equal(t, "AAPL", alert.Symbol)
+	assert.Equal(t, 170.0, alert.Quantity)
+	assert.Equal(t, []string{"21", "22"}, alert.OrderIDs)
+	assert.Equal(t, shared.AlertStatusOpen, alert.Status)
+	assert.Equal(t, start.Add(3*time.Second), alert.DetectedAt)
+}
+
+func TestDetector_FlaggedOrdersAreForgotten(t *testing.T) {
+	steps := append(background(20, 10),
+		cancel(time.Second, "spoofer", sell, 101, 100),
+		trade(2*time.Second, "spoofer", "crowd", sell, 100, 10),
+		trade(3*time.Second, "spoofer", "crowd", sell, 100, 10),
+	)
+
+	alerts := run(steps, time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), nil)
+	assert.Equal(t, []shared.AlertType{shared.AlertTypeSpoofing}, alertTypes(alerts))
+}
+
+func TestDetector_Prune(t *testing.T) {
+	detector := NewDetector(testConfig())
+	now := time.Date(2024, 1, 15, 23, 58, 0, 0, time.UTC)
+	detector.OnOrderCancelled(OrderEvent{OrderID: "1", UserID: "u", Symbol: "AAPL", Side: sell, Price: 101, Quantity: 10, At: now})
+	detector.OnTrade(TradeEvent{Symbol: "AAPL", Price: 100, Quantity: 1, BuyUserID: "u", SellUserID: "v", TakerSide: buy, At: now})
+
+	detector.Prune(now.Add(5 * time.Second))
+	assert.Len(t, detector.cancels, 1)
+	assert.Len(t, detector.closing, 1)
+
+	detector.Prune(now.Add(10 * time.Minute))
+	assert.Empty(t, detector.cancels)
+	assert.Empty(t, detector.aggressive)
+	assert.Empty(t, detector.closing)
+}
@@ -10,9 +10,12 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"simulated_exchange/pkg/cache"
+	"simulated_exchange/pkg/clock"
 	"simulated_exchange/pkg/config"
 	"simulated_exchange/pkg/messaging"
 	"simulated_exchange/pkg/monitoring"
+	"simulated_exchange/pkg/priceprocess"
+	"simulated_exchange/pkg/seasonality"
 	"simulated_exchange/pkg/shared"
 	"simulated_exchange/services/market-simulator/internal/domain"
 	"simulated_exchange/services/market-simulator/internal/handlers"
@@ -33,6 +36,8 @@ type Application struct {
 	simulatorService   shared.SimulatorService
 	priceGenerator     *domain.PriceGenerator
 	marketDataService  *domain.MarketDataService
+	replayService      *domain.ReplayService
+	tradePriceFeed     *domain.TradePriceFeed
 
 	// HTTP Server (for health checks and metrics)
 	server *server.Server
@@ -168,8 +173,8 @@ func (a *Application) Stop() error {
 	// Cancel context to signal all goroutines to stop
 	a.cancel()
 
-	// Stop simulation
-	if a.simulatorService != nil {
+	// Stop simulation; in agent mode it never runs
+	if a.simulatorService != nil && !a.config.Agents.Enabled() {
 		if err := a.simulatorService.Stop(context.Background()); err != nil {
 			a.logger.Warn("Error stopping simulation", "error", err)
 		}
@@ -233,6 +238,14 @@ func (a *Application) initializeInfrastructure() error {
 func (a *Application) initializeServices() error {
 	a.logger.Info("Initializing services")
 
+	// Reject bad price model parameters before any symbol is seeded
+	if err := priceprocess.ValidateModels(a.config.PriceModels); err != nil {
+		return err
+	}
+
+	// Prices and their schedule run on the configured simulated time
+	clk := clock.New(a.config.Clock)
+
 	// Initialize price generator
 	priceConfig := domain.PriceGeneratorConfig{
 		BaseVolatility:    0.02,
@@ -243,8 +256,18 @@ func (a *Application) initializeServices() error {
 		MeanReversion:     0.3,
 		HistorySize:       1000,
 		RandomSeed:        time.Now().UnixNano(),
+		Models:            a.config.PriceModels,
+		Correlation:       a.config.Correlation,
 	}
 	a.priceGenerator = domain.NewPriceGenerator(priceConfig, a.logger)
+	a.priceGenerator.SetClock(clk)
+
+	// Volatility and volume follow the same seasonality as the order flow
+	profile, err := seasonality.New(a.config.Seasonality)
+	if err != nil {
+		return err
+	}
+	a.priceGenerator.SetSeasonality(profile)
 
 	// Initialize market data service
 	a.marketDataService = domain.NewMarketDataService(a.cache, a.eventBus, a.logger)
@@ -253,12 +276,26 @@ func (a *Application) initializeServices() error {
 	a.priceService = domain.NewPriceService(a.priceGenerator, a.marketDataService, a.logger)
 
 	// Initialize simulator service
-	a.simulatorService = domain.NewSimulatorService(
+	simulatorService := domain.NewSimulatorService(
 		a.priceService.(*domain.PriceService),
 		a.marketDataService,
 		a.eventBus,
 		a.logger,
 	)
+	simulatorService.SetClock(clk)
+	a.simulatorService = simulatorService
+
+	// Initialize historical market data replay
+	a.replayService = domain.NewReplayService(
+		a.marketDataService,
+		a.priceService.(*domain.PriceService),
+		a.simulatorService.(*domain.SimulatorService),
+		a.config.Replay,
+		a.logger,
+	)
+
+	// Initialize the trade price feed of the agent-based mode
+	a.tradePriceFeed = domain.NewTradePriceFeed(a.priceService, a.logger)
 
 	a.logger.Info("Services initialized successfully")
 	return nil
@@ -285,12 +322,14 @@ func (a *Application) initializeServer() error {
 	// Create handlers
 	healthHandler := handlers.NewHealthHandler(a.cache, a.logger)
 	simulatorHandler := handlers.NewSimulatorHandler(a.simulatorService, a.priceService.(*domain.PriceService), a.logger)
+	replayHandler := handlers.NewReplayHandler(a.replayService, a.logger)
 
 	// Create server (pass metrics collector)
 	a.server = server.NewServer(
 		a.config,
 		healthHandler,
 		simulatorHandler,
+		replayHandler,
 		a.metricsCollector,
 		a.logger,
 	)
@@ -326,17 +365,40 @@ func (a *Application) startSimulation() error {
 		"ADAUSD": 1.5,
 	}
 
+	// Pair overrides can make the correlation matrix invalid; fail here
+	// rather than leave symbols unseeded
+	symbols := make([]string, 0, len(initialPrices))
+	for symbol := range initialPrices {
+		symbols = append(symbols, symbol)
+	}
+	if _, err := priceprocess.NewCorrelation(a.config.Correlation, symbols); err != nil {
+		return fmt.Errorf("invalid price correlation: %w", err)
+	}
+
 	for symbol, price := range initialPrices {
 		if err := a.priceGenerator.SetBasePrice(symbol, price); err != nil {
 			a.logger.Warn("Failed to set initial price", "symbol", symbol, "price", price, "error", err)
 		}
 	}
 
-	// Start simulation service
-	if err := a.simulatorService.Start(a.ctx); err != nil {
+	// In agent mode prices are discovered by the order flow; publish trade
+	// prices instead of generating them
+	if a.config.Agents.Enabled() {
+		if err := a.eventBus.Subscribe(a.ctx, shared.EventTypeTradeExecuted, a.tradePriceFeed.HandleTradeExecuted); err != nil {
+			return fmt.Errorf("failed to subscribe to trade executions: %w", err)
+		}
+		a.logger.Info("Agent mode: publishing trade prices instead of simulating")
+	} else if err := a.simulatorService.Start(a.ctx); err != nil {
 		return fmt.Errorf("failed to start simulation service: %w", err)
 	}
 
+	// Start the replay loop; it stays idle until a replay is started
+	a.waitGroup.Add(1)
+	go func() {
+		defer a.waitGroup.Done()
+		a.replayService.Run(a.ctx)
+	}()
+
 	a.logger.Info("Market simulation started successfully")
 	return nil
 }
@@ -8,6 +8,10 @@ import (
 	"sync"
 	"time"
 
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/priceprocess"
+	"simulated_exchange/pkg/seasonality"
 	"simulated_exchange/pkg/shared"
 )
 
@@ -21,6 +25,13 @@ type PriceGeneratorConfig struct {
 	MeanReversion     float64 `json:"mean_reversion"`
 	HistorySize       int     `json:"history_size"`
 	RandomSeed        int64   `json:"random_seed"`
+
+	// Models selects a stochastic price process per symbol; symbols on the
+	// builtin model use the trend, random walk and mean reversion formula
+	Models config.PriceModelsConfig `json:"models"`
+
+	// Correlation sets how the symbols' shocks move together
+	Correlation config.CorrelationConfig `json:"correlation"`
 }
 
 // PriceState tracks price information for a symbol
@@ -35,7 +46,7 @@ type PriceState struct {
 	Volume          float64                 `json:"volume"`
 	LastUpdate      time.Time               `json:"last_update"`
 	PriceHistory    []shared.PriceUpdate    `json:"price_history"`
-	VolatilityIndex float64                 `json:"volatility_index"`
+	VolatilityIndex float64                 `json:"volatility_index"` // Current volatility relative to the base
 	TrendDirection  string                  `json:"trend_direction"`
 	TrendStrength   float64                 `json:"trend_strength"`
 }
@@ -49,6 +60,11 @@ type PriceGenerator struct {
 	prices            map[string]*PriceState
 	basePrices        map[string]float64
 	currentVolatility map[string]float64
+	processes         map[string]priceprocess.Process
+	shocks            *priceprocess.CorrelatedShocks
+	realized          *priceprocess.RealizedCorrelation
+	clock             clock.Clock
+	seasonality       *seasonality.Profile
 	mu                sync.RWMutex
 
 	// Random number generator
@@ -67,10 +83,31 @@ func NewPriceGenerator(config PriceGeneratorConfig, logger *slog.Logger) *PriceG
 		prices:            make(map[string]*PriceState),
 		basePrices:        make(map[string]float64),
 		currentVolatility: make(map[string]float64),
+		processes:         make(map[string]priceprocess.Process),
+		clock:             clock.Real(),
+		seasonality:       seasonality.Standard(),
 		rng:               rand.New(rand.NewSource(config.RandomSeed)),
 	}
 }
 
+// SetClock sets the clock prices are timestamped with and trading days
+// are counted on
+func (pg *PriceGenerator) SetClock(clk clock.Clock) {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	pg.clock = clk
+}
+
+// SetSeasonality sets the profile volatility and volume follow over the
+// day, the week and holidays
+func (pg *PriceGenerator) SetSeasonality(profile *seasonality.Profile) {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	pg.seasonality = profile
+}
+
 // SetBasePrice establishes baseline price for symbol
 func (pg *PriceGenerator) SetBasePrice(symbol string, price float64) error {
 	pg.mu.Lock()
@@ -80,6 +117,23 @@ func (pg *PriceGenerator) SetBasePrice(symbol string, price float64) error {
 		return fmt.Errorf("price must be positive")
 	}
 
+	if _, exists := pg.basePrices[symbol]; !exists {
+		if err := pg.correlateSymbols(symbol); err != nil {
+			return err
+		}
+	}
+
+	model := pg.config.Models.ModelFor(symbol)
+	if model.Name == "" || model.Name == config.PriceModelBuiltin {
+		delete(pg.processes, symbol)
+	} else {
+		process, err := priceprocess.New(model, price)
+		if err != nil {
+			return fmt.Errorf("invalid price model for %s: %w", symbol, err)
+		}
+		pg.processes[symbol] = process
+	}
+
 	pg.basePrices[symbol] = price
 	pg.currentVolatility[symbol] = pg.config.BaseVolatility
 	pg.initializePriceState(symbol, price)
@@ -98,27 +152,38 @@ func (pg *PriceGenerator) GeneratePrice(symbol string) (*shared.PriceUpdate, err
 		return nil, fmt.Errorf("symbol %s not found", symbol)
 	}
 
-	// Calculate time-based factors
-	timeFactor := pg.calculateTimeFactor(time.Since(priceState.LastUpdate))
+	elapsed := pg.clock.Since(priceState.LastUpdate)
 
 	// Get current volatility for symbol
 	volatility := pg.getCurrentVolatility(symbol)
 
-	// Calculate price change components
-	trendComponent := pg.calculateTrendComponent(symbol, timeFactor)
-	randomComponent := pg.calculateRandomComponent(volatility, timeFactor)
-	meanReversionComponent := pg.calculateMeanReversionComponent(symbol)
+	shock := pg.nextShock(symbol)
 
-	// Combine components
-	totalChange := trendComponent + randomComponent + meanReversionComponent
+	var newPrice float64
+	if process, ok := pg.processes[symbol]; ok {
+		newPrice = process.Step(pg.rng, shock, priceState.CurrentPrice, elapsed, pg.volatilityScale(volatility))
+	} else {
+		// Calculate time-based factors
+		timeFactor := pg.calculateTimeFactor(elapsed)
+
+		// Calculate price change components
+		trendComponent := pg.calculateTrendComponent(symbol, timeFactor)
+		randomComponent := pg.calculateRandomComponent(shock, volatility, timeFactor)
+		meanReversionComponent := pg.calculateMeanReversionComponent(symbol)
 
-	// Apply change to current price
-	newPrice := priceState.CurrentPrice * (1 + totalChange)
+		// Combine components and apply change to current price
+		newPrice = priceState.CurrentPrice * (1 + trendComponent + randomComponent + meanReversionComponent)
+	}
+	totalChange := (newPrice - priceState.CurrentPrice) / priceState.CurrentPrice
 
 	// Ensure price is positive and apply step size
 	newPrice = math.Max(newPrice, pg.config.PriceStepSize)
 	newPrice = pg.roundToStepSize(newPrice)
 
+	if pg.realized != nil {
+		pg.realized.Observe(symbol, math.Log(newPrice/priceState.CurrentPrice))
+	}
+
 	// Generate volume
 	volume := pg.generateVolume(symbol, newPrice, volatility)
 
@@ -127,10 +192,11 @@ func (pg *PriceGenerator) GeneratePrice(symbol string) (*shared.PriceUpdate, err
 
 	// Create price update
 	priceUpdate := &shared.PriceUpdate{
-		Symbol:    symbol,
-		Price:     newPrice,
-		Volume:    volume,
-		Timestamp: time.Now(),
+		Symbol:          symbol,
+		Price:           newPrice,
+		Volume:          volume,
+		VolatilityIndex: pg.prices[symbol].VolatilityIndex,
+		Timestamp:       pg.clock.Now(),
 	}
 
 	pg.logger.Debug("Generated price",
@@ -176,6 +242,18 @@ func (pg *PriceGenerator) GetPriceHistory(symbol string, limit int) ([]shared.Pr
 	return history, nil
 }
 
+// RealizedCorrelations returns the correlations of the symbols' recent log
+// returns, keyed by symbol
+func (pg *PriceGenerator) RealizedCorrelations() map[string]map[string]float64 {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	if pg.realized == nil {
+		return map[string]map[string]float64{}
+	}
+	return pg.realized.Matrix()
+}
+
 // SimulateVolatility applies volatility pattern to price generation
 func (pg *PriceGenerator) SimulateVolatility(symbol string, pattern string, intensity float64) error {
 	pg.mu.Lock()
@@ -192,7 +270,7 @@ func (pg *PriceGenerator) SimulateVolatility(symbol string, pattern string, inte
 	case "decay":
 		pg.currentVolatility[symbol] *= (1 - intensity*0.1)
 	case "oscillate":
-		oscFactor := math.Sin(float64(time.Now().Unix())/60) * intensity
+		oscFactor := math.Sin(float64(pg.clock.Now().Unix())/60) * intensity
 		pg.currentVolatility[symbol] = pg.config.BaseVolatility * (1 + oscFactor)
 	case "random":
 		randomFactor := pg.rng.Float64() * intensity
@@ -218,7 +296,7 @@ func (pg *PriceGenerator) SimulateVolatility(symbol string, pattern string, inte
 // Private helper methods
 
 func (pg *PriceGenerator) initializePriceState(symbol string, price float64) {
-	now := time.Now()
+	now := pg.clock.Now()
 
 	pg.prices[symbol] = &PriceState{
 		Symbol:          symbol,
@@ -231,7 +309,7 @@ func (pg *PriceGenerator) initializePriceState(symbol string, price float64) {
 		Volume:          0,
 		LastUpdate:      now,
 		PriceHistory:    make([]shared.PriceUpdate, 0, pg.config.HistorySize),
-		VolatilityIndex: pg.config.BaseVolatility,
+		VolatilityIndex: 1,
 		TrendDirection:  "sideways",
 		TrendStrength:   0.0,
 	}
@@ -242,10 +320,51 @@ func (pg *PriceGenerator) initializePriceState(symbol string, price float64) {
 }
 
 func (pg *PriceGenerator) getCurrentVolatility(symbol string) float64 {
-	if vol, exists := pg.currentVolatility[symbol]; exists {
-		return vol
+	vol, exists := pg.currentVolatility[symbol]
+	if !exists {
+		vol = pg.config.BaseVolatility
 	}
-	return pg.config.BaseVolatility
+	return vol * pg.seasonality.At(pg.clock.Now()).Volatility
+}
+
+// correlateSymbols rebuilds the correlation matrix to include a new symbol.
+// Realized correlations restart since the set of symbols changed.
+func (pg *PriceGenerator) correlateSymbols(symbol string) error {
+	symbols := []string{symbol}
+	for existing := range pg.basePrices {
+		symbols = append(symbols, existing)
+	}
+
+	correlation, err := priceprocess.NewCorrelation(pg.config.Correlation, symbols)
+	if err != nil {
+		return fmt.Errorf("invalid correlation for %s: %w", symbol, err)
+	}
+
+	window := pg.config.Correlation.Window
+	if window < 2 {
+		window = pg.config.HistorySize
+	}
+	pg.shocks = priceprocess.NewCorrelatedShocks(correlation)
+	pg.realized = priceprocess.NewRealizedCorrelation(correlation.Symbols(), window)
+	return nil
+}
+
+// nextShock returns the standard normal shock of a symbol's next price,
+// correlated with the other symbols' shocks
+func (pg *PriceGenerator) nextShock(symbol string) float64 {
+	if pg.shocks == nil {
+		return pg.rng.NormFloat64()
+	}
+	return pg.shocks.Next(pg.rng, symbol)
+}
+
+// volatilityScale is how far volatility patterns have moved a symbol's
+// volatility from the base; price processes scale their own volatility by it
+func (pg *PriceGenerator) volatilityScale(volatility float64) float64 {
+	if pg.config.BaseVolatility <= 0 {
+		return 1
+	}
+	return volatility / pg.config.BaseVolatility
 }
 
 func (pg *PriceGenerator) calculateTimeFactor(timeSince time.Duration) float64 {
@@ -278,12 +397,9 @@ func (pg *PriceGenerator) calculateTrendComponent(symbol string, timeFactor floa
 	return trend * pg.config.TrendPersistence * timeFactor * 0.1
 }
 
-func (pg *PriceGenerator) calculateRandomComponent(volatility, timeFactor float64) float64 {
-	// Generate normally distributed random walk
-	randomValue := pg.rng.NormFloat64()
-
-	// Scale by volatility and time
-	return randomValue * volatility * timeFactor * 0.01
+func (pg *PriceGenerator) calculateRandomComponent(shock, volatility, timeFactor float64) float64 {
+	// Scale the normally distributed shock by volatility and time
+	return shock * volatility * timeFactor * 0.01
 }
 
 func (pg *PriceGenerator) calculateMeanReversionComponent(symbol string) float64 {
@@ -333,21 +449,16 @@ func (pg *PriceGenerator) generateVolume(symbol string, price float64, volatilit
 		randomFactor *= 0.1 + pg.rng.Float64()*0.2
 	}
 
-	// Time-of-day pattern (higher volume during "market hours")
-	hour := time.Now().Hour()
-	timeMultiplier := 1.0
-	if hour >= 9 && hour <= 16 { // Simulated market hours
-		timeMultiplier = 1.5 + pg.rng.Float64()*0.5
-	} else {
-		timeMultiplier = 0.3 + pg.rng.Float64()*0.4
-	}
+	// Session, weekday and holiday pattern
+	timeMultiplier := pg.seasonality.At(pg.clock.Now()).Volume * (0.85 + pg.rng.Float64()*0.3)
 
 	return baseVolume * volumeMultiplier * randomFactor * timeMultiplier
 }
 
 func (pg *PriceGenerator) updatePriceState(symbol string, newPrice, volume float64) {
 	priceState := pg.prices[symbol]
-	now := time.Now()
+	now := pg.clock.Now()
+	lastUpdate := priceState.LastUpdate
 
 	// Update prices
 	priceState.PreviousPrice = priceState.CurrentPrice
@@ -378,7 +489,7 @@ func (pg *PriceGenerator) updatePriceState(symbol string, newPrice, volume float
 	}
 
 	// Reset daily values if new day
-	if pg.isNewTradingDay(priceState.LastUpdate, now) {
+	if pg.isNewTradingDay(lastUpdate, now) {
 		priceState.DailyOpen = newPrice
 		priceState.DailyHigh = newPrice
 		priceState.DailyLow = newPrice
@@ -386,6 +497,7 @@ func (pg *PriceGenerator) updatePriceState(symbol string, newPrice, volume float
 
 	// Decay volatility
 	pg.decayVolatility(symbol)
+	priceState.VolatilityIndex = pg.volatilityScale(pg.getCurrentVolatility(symbol))
 }
 
 func (pg *PriceGenerator) decayVolatility(symbol string) {
@@ -399,5 +511,5 @@ func (pg *PriceGenerator) decayVolatility(symbol string) {
 
 func (pg *PriceGenerator) isNewTradingDay(lastUpdate, currentTime time.Time) bool {
 	// Simple check: different day
-	return lastUpdate.Day() != currentTime.Day()
+	return lastUpdate.YearDay() != currentTime.YearDay() || lastUpdate.Year() != currentTime.Year()
 }
\ No newline at end of file
@@ -86,6 +86,12 @@ func (ps *PriceService) SimulateVolatility(symbol, pattern string, intensity flo
 	return ps.priceGenerator.SimulateVolatility(symbol, pattern, intensity)
 }
 
+// GetRealizedCorrelations returns the correlations of the symbols' recent
+// returns
+func (ps *PriceService) GetRealizedCorrelations() map[string]map[string]float64 {
+	return ps.priceGenerator.RealizedCorrelations()
+}
+
 // GetAllSymbols returns all symbols that have price data
 func (ps *PriceService) GetAllSymbols() []string {
 	// This would typically come from configuration or database
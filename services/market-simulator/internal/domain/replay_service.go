@@ -0,0 +1,472 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// Replay states
+const (
+	ReplayStateIdle     = "idle"
+	ReplayStateRunning  = "running"
+	ReplayStatePaused   = "paused"
+	ReplayStateFinished = "finished"
+)
+
+// ErrCodeInvalidReplayState is returned for controls that don't apply to the
+// replay's current state
+const ErrCodeInvalidReplayState = "INVALID_REPLAY_STATE"
+
+// ReplayStatus describes the progress of a replay. CurrentTime is the
+// recorded time the replay has reached.
+type ReplayStatus struct {
+	State       string    `json:"state"`
+	Files       []string  `json:"files"`
+	Symbols     []string  `json:"symbols"`
+	Speed       float64   `json:"speed"`
+	Position    int       `json:"position"`
+	Total       int       `json:"total"`
+	StartTime   time.Time `json:"start_time,omitempty"`
+	EndTime     time.Time `json:"end_time,omitempty"`
+	CurrentTime time.Time `json:"current_time,omitempty"`
+}
+
+// ReplayService publishes recorded market data as price updates in place of
+// the synthetic price generator. Updates keep their recorded timestamps and
+// are paced by Speed: 1 replays in real time, 10 ten times faster and 0 as
+// fast as possible. The synthetic simulation is stopped while a replay runs
+// and resumes from the last replayed prices when it ends.
+type ReplayService struct {
+	marketDataService *MarketDataService
+	priceService      *PriceService
+	simulatorService  *SimulatorService
+	config            config.ReplayConfig
+	logger            *slog.Logger
+
+	mutex    sync.Mutex
+	ctx      context.Context
+	wake     chan struct{}
+	state    string
+	files    []string
+	symbols  []string
+	updates  []shared.PriceUpdate
+	position int
+	speed    float64
+
+	// The wall clock time the replay time anchor was reached; the next
+	// update is due once wall time has advanced by the recorded gap scaled
+	// by speed
+	wallAnchor   time.Time
+	replayAnchor time.Time
+
+	// generation changes on every control so a wait or publish started
+	// before it is abandoned
+	generation       uint64
+	resumeSimulation bool
+}
+
+// NewReplayService creates a new replay service
+func NewReplayService(
+	marketDataService *MarketDataService,
+	priceService *PriceService,
+	simulatorService *SimulatorService,
+	cfg config.ReplayConfig,
+	logger *slog.Logger,
+) *ReplayService {
+	return &ReplayService{
+		marketDataService: marketDataService,
+		priceService:      priceService,
+		simulatorService:  simulatorService,
+		config:            cfg,
+		logger:            logger,
+		wake:              make(chan struct{}, 1),
+		state:             ReplayStateIdle,
+		speed:             1,
+	}
+}
+
+// ListFiles returns the replayable files in the data directory
+func (rs *ReplayService) ListFiles() ([]string, error) {
+	entries, err := os.ReadDir(rs.config.DataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to list replay files: %w", err)
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".csv", ".ndjson", ".jsonl":
+			if !entry.IsDir() {
+				files = append(files, entry.Name())
+			}
+		}
+	}
+	return files, nil
+}
+
+// Start loads files from the data directory and replays them from the
+// beginning at speed, replacing any replay in progress
+func (rs *ReplayService) Start(files []string, speed float64) (*ReplayStatus, error) {
+	if len(files) == 0 {
+		return nil, shared.NewValidationError("files", "at least one file is required")
+	}
+	if err := validateReplaySpeed(speed); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		if file != filepath.Base(file) || strings.HasPrefix(file, ".") {
+			return nil, shared.NewValidationError("files", fmt.Sprintf("%q must be a file name in the replay data directory", file))
+		}
+		paths[i] = filepath.Join(rs.config.DataDir, file)
+	}
+
+	updates, err := LoadReplayFiles(paths)
+	if err != nil {
+		return nil, shared.NewValidationError("files", err.Error())
+	}
+	if len(updates) == 0 {
+		return nil, shared.NewValidationError("files", "files contain no market data")
+	}
+
+	// Pause the synthetic prices so they don't interleave with the replay
+	rs.pauseSimulation()
+
+	rs.mutex.Lock()
+	rs.files = files
+	rs.symbols = replaySymbols(updates)
+	rs.updates = updates
+	rs.position = 0
+	rs.speed = speed
+	rs.state = ReplayStateRunning
+	rs.replayAnchor = updates[0].Timestamp
+	rs.wallAnchor = time.Now()
+	rs.generation++
+	rs.mutex.Unlock()
+
+	rs.signal()
+
+	rs.logger.Info("Replay started",
+		"files", files,
+		"symbols", rs.symbols,
+		"updates", len(updates),
+		"speed", speed,
+	)
+
+	return rs.Status(), nil
+}
+
+// Pause stops publishing until Resume is called
+func (rs *ReplayService) Pause() (*ReplayStatus, error) {
+	rs.mutex.Lock()
+	if rs.state != ReplayStateRunning {
+		rs.mutex.Unlock()
+		return nil, shared.NewBusinessError(ErrCodeInvalidReplayState, "replay is not running")
+	}
+	rs.reanchor()
+	rs.state = ReplayStatePaused
+	rs.mutex.Unlock()
+
+	rs.signal()
+	rs.logger.Info("Replay paused")
+	return rs.Status(), nil
+}
+
+// Resume continues a paused replay
+func (rs *ReplayService) Resume() (*ReplayStatus, error) {
+	rs.mutex.Lock()
+	if rs.state != ReplayStatePaused {
+		rs.mutex.Unlock()
+		return nil, shared.NewBusinessError(ErrCodeInvalidReplayState, "replay is not paused")
+	}
+	rs.reanchor()
+	rs.state = ReplayStateRunning
+	rs.mutex.Unlock()
+
+	rs.signal()
+	rs.logger.Info("Replay resumed")
+	return rs.Status(), nil
+}
+
+// Seek moves the replay to the first update at or after the recorded time.
+// A finished replay seeked back into its data continues running.
+func (rs *ReplayService) Seek(to time.Time) (*ReplayStatus, error) {
+	rs.mutex.Lock()
+	if rs.state == ReplayStateIdle {
+		rs.mutex.Unlock()
+		return nil, shared.NewBusinessError(ErrCodeInvalidReplayState, "no replay loaded")
+	}
+	if to.Before(rs.updates[0].Timestamp) || to.After(rs.updates[len(rs.updates)-1].Timestamp) {
+		rs.mutex.Unlock()
+		return nil, shared.NewValidationError("time", "must be within the replayed data")
+	}
+
+	rs.position = sort.Search(len(rs.updates), func(i int) bool {
+		return !rs.updates[i].Timestamp.Before(to)
+	})
+	restarted := rs.state == ReplayStateFinished
+	if restarted {
+		rs.state = ReplayStateRunning
+	}
+	rs.replayAnchor = to
+	rs.wallAnchor = time.Now()
+	rs.generation++
+	rs.mutex.Unlock()
+
+	if restarted {
+		rs.pauseSimulation()
+	}
+	rs.signal()
+	rs.logger.Info("Replay seeked", "time", to)
+	return rs.Status(), nil
+}
+
+// SetSpeed changes the replay speed multiplier; 0 replays as fast as possible
+func (rs *ReplayService) SetSpeed(speed float64) (*ReplayStatus, error) {
+	if err := validateReplaySpeed(speed); err != nil {
+		return nil, err
+	}
+
+	rs.mutex.Lock()
+	rs.reanchor()
+	rs.speed = speed
+	rs.mutex.Unlock()
+
+	rs.signal()
+	rs.logger.Info("Replay speed changed", "speed", speed)
+	return rs.Status(), nil
+}
+
+// Stop ends the replay and resumes the synthetic simulation
+func (rs *ReplayService) Stop() (*ReplayStatus, error) {
+	rs.mutex.Lock()
+	if rs.state == ReplayStateIdle {
+		rs.mutex.Unlock()
+		return nil, shared.NewBusinessError(ErrCodeInvalidReplayState, "no replay loaded")
+	}
+	handoff := rs.finish()
+	rs.state = ReplayStateIdle
+	rs.updates = nil
+	rs.position = 0
+	rs.mutex.Unlock()
+
+	rs.handOff(handoff)
+	rs.signal()
+	rs.logger.Info("Replay stopped")
+	return rs.Status(), nil
+}
+
+// Status returns the progress of the replay
+func (rs *ReplayService) Status() *ReplayStatus {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	status := &ReplayStatus{
+		State:    rs.state,
+		Files:    rs.files,
+		Symbols:  rs.symbols,
+		Speed:    rs.speed,
+		Position: rs.position,
+		Total:    len(rs.updates),
+	}
+	if len(rs.updates) > 0 {
+		status.StartTime = rs.updates[0].Timestamp
+		status.EndTime = rs.updates[len(rs.updates)-1].Timestamp
+		status.CurrentTime = rs.currentTime()
+	}
+	return status
+}
+
+// Run publishes loaded updates when they are due until the context is
+// cancelled
+func (rs *ReplayService) Run(ctx context.Context) {
+	rs.mutex.Lock()
+	rs.ctx = ctx
+	rs.mutex.Unlock()
+
+	for {
+		rs.mutex.Lock()
+		if rs.state != ReplayStateRunning {
+			rs.mutex.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-rs.wake:
+				continue
+			}
+		}
+
+		if rs.position >= len(rs.updates) {
+			rs.replayAnchor = rs.updates[len(rs.updates)-1].Timestamp
+			rs.state = ReplayStateFinished
+			handoff := rs.finish()
+			rs.mutex.Unlock()
+
+			rs.handOff(handoff)
+			rs.logger.Info("Replay finished", "updates", len(rs.updates))
+			continue
+		}
+
+		update := rs.updates[rs.position]
+		generation := rs.generation
+		wait := time.Until(rs.dueTime(update.Timestamp))
+		rs.mutex.Unlock()
+
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-rs.wake:
+				timer.Stop()
+				continue
+			case <-timer.C:
+			}
+		}
+
+		rs.mutex.Lock()
+		if rs.generation != generation {
+			rs.mutex.Unlock()
+			continue
+		}
+		rs.position++
+		rs.mutex.Unlock()
+
+		if err := rs.marketDataService.UpdateMarketData(ctx, &update); err != nil {
+			rs.logger.Warn("Failed to publish replayed price", "symbol", update.Symbol, "error", err)
+		}
+	}
+}
+
+// dueTime returns the wall clock time a recorded timestamp is published at
+func (rs *ReplayService) dueTime(timestamp time.Time) time.Time {
+	if rs.speed == 0 {
+		return rs.wallAnchor
+	}
+	return rs.wallAnchor.Add(time.Duration(float64(timestamp.Sub(rs.replayAnchor)) / rs.speed))
+}
+
+// currentTime returns the recorded time the replay has reached, which
+// never passes the next unpublished update. Callers hold the mutex.
+func (rs *ReplayService) currentTime() time.Time {
+	if rs.state != ReplayStateRunning || rs.position >= len(rs.updates) {
+		return rs.replayAnchor
+	}
+
+	next := rs.updates[rs.position].Timestamp
+	if rs.speed == 0 {
+		return next
+	}
+	current := rs.replayAnchor.Add(time.Duration(float64(time.Since(rs.wallAnchor)) * rs.speed))
+	if current.After(next) {
+		return next
+	}
+	return current
+}
+
+// reanchor pins the replay clock at the recorded time reached so far and
+// abandons any pending wait. Callers hold the mutex and call it before
+// changing the state or speed.
+func (rs *ReplayService) reanchor() {
+	rs.replayAnchor = rs.currentTime()
+	rs.wallAnchor = time.Now()
+	rs.generation++
+}
+
+// replayHandoff is what a replay leaves the synthetic simulation when it
+// ends: the last replayed price per symbol and whether to restart it
+type replayHandoff struct {
+	ctx    context.Context
+	prices map[string]float64
+	resume bool
+}
+
+// finish abandons any pending wait and collects the handoff of the replay.
+// Callers hold the mutex and pass the handoff to handOff once they have
+// released it.
+func (rs *ReplayService) finish() replayHandoff {
+	rs.generation++
+
+	handoff := replayHandoff{ctx: rs.ctx, prices: make(map[string]float64), resume: rs.resumeSimulation}
+	for _, update := range rs.updates[:rs.position] {
+		handoff.prices[update.Symbol] = update.Price
+	}
+	rs.resumeSimulation = false
+	return handoff
+}
+
+// handOff hands the last replayed prices to the price generator and resumes
+// the synthetic simulation if a replay stopped it. Callers must not hold the
+// mutex: starting the simulation runs its own locking and publishing.
+func (rs *ReplayService) handOff(handoff replayHandoff) {
+	for symbol, price := range handoff.prices {
+		if err := rs.priceService.SetInitialPrice(symbol, price); err != nil {
+			rs.logger.Warn("Failed to hand replayed price to generator", "symbol", symbol, "error", err)
+		}
+	}
+
+	if handoff.resume {
+		if err := rs.simulatorService.Start(handoff.ctx); err != nil {
+			rs.logger.Warn("Failed to resume simulation after replay", "error", err)
+		}
+	}
+}
+
+// pauseSimulation stops the synthetic simulation if it is running and
+// remembers to resume it when the replay ends
+func (rs *ReplayService) pauseSimulation() {
+	if !rs.simulatorService.IsRunning() {
+		return
+	}
+	if err := rs.simulatorService.Stop(context.Background()); err != nil {
+		rs.logger.Warn("Failed to pause simulation for replay", "error", err)
+		return
+	}
+
+	rs.mutex.Lock()
+	rs.resumeSimulation = true
+	rs.mutex.Unlock()
+}
+
+// signal wakes the replay loop to pick up a control change
+func (rs *ReplayService) signal() {
+	select {
+	case rs.wake <- struct{}{}:
+	default:
+	}
+}
+
+func validateReplaySpeed(speed float64) error {
+	if speed < 0 {
+		return shared.NewValidationError("speed", "must not be negative")
+	}
+	return nil
+}
+
+func replaySymbols(updates []shared.PriceUpdate) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, update := range updates {
+		if !seen[update.Symbol] {
+			seen[update.Symbol] = true
+			symbols = append(symbols, update.Symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
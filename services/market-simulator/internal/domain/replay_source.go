@@ -0,0 +1,337 @@
+package domain
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"simulated_exchange/pkg/shared"
+)
+
+// replayTimestampLayouts are the accepted textual timestamp formats of
+// recorded market data. Numeric timestamps are Unix seconds, milliseconds,
+// microseconds or nanoseconds depending on their magnitude.
+var replayTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// replayRow is one recorded row. Tick rows have a price; OHLCV rows have
+// open, high, low and close prices.
+type replayRow struct {
+	Timestamp time.Time
+	Symbol    string
+	Price     float64
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+	isBar     bool
+}
+
+// LoadReplayFiles reads recorded tick or OHLCV files, in CSV or NDJSON
+// format, and merges them into one timeline ordered by timestamp so that
+// several symbols replay against the same clock. Rows without a symbol
+// take it from the file name, e.g. BTCUSD.csv.
+func LoadReplayFiles(paths []string) ([]shared.PriceUpdate, error) {
+	var updates []shared.PriceUpdate
+	for _, path := range paths {
+		fileUpdates, err := loadReplayFile(path)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, fileUpdates...)
+	}
+
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Timestamp.Before(updates[j].Timestamp)
+	})
+
+	return updates, nil
+}
+
+func loadReplayFile(path string) ([]shared.PriceUpdate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer file.Close()
+
+	var rows []replayRow
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = parseReplayCSV(file)
+	case ".ndjson", ".jsonl":
+		rows, err = parseReplayNDJSON(file)
+	default:
+		return nil, fmt.Errorf("unsupported replay file format: %s", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	defaultSymbol := strings.ToUpper(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	for i := range rows {
+		if rows[i].Symbol == "" {
+			rows[i].Symbol = defaultSymbol
+		}
+	}
+
+	return expandReplayRows(rows), nil
+}
+
+// parseReplayCSV reads CSV rows with a header naming the columns. Tick files
+// have a price column, OHLCV files open, high, low and close columns.
+func parseReplayCSV(r io.Reader) ([]replayRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var rows []replayRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		fields := make(map[string]interface{}, len(columns))
+		for name, i := range columns {
+			if i < len(record) && record[i] != "" {
+				fields[name] = record[i]
+			}
+		}
+
+		row, err := parseReplayFields(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseReplayNDJSON reads one JSON object per line with the same field
+// names as the CSV columns
+func parseReplayNDJSON(r io.Reader) ([]replayRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []replayRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		fields := make(map[string]interface{}, len(raw))
+		for name, value := range raw {
+			fields[strings.ToLower(name)] = value
+		}
+
+		row, err := parseReplayFields(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+func parseReplayFields(fields map[string]interface{}) (replayRow, error) {
+	var row replayRow
+
+	timestamp, ok := firstField(fields, "timestamp", "time", "date", "ts")
+	if !ok {
+		return row, fmt.Errorf("missing timestamp")
+	}
+	parsed, err := parseReplayTimestamp(timestamp)
+	if err != nil {
+		return row, err
+	}
+	row.Timestamp = parsed
+
+	if symbol, ok := fields["symbol"]; ok {
+		row.Symbol = strings.ToUpper(fmt.Sprint(symbol))
+	}
+
+	if row.Volume, err = replayNumber(fields, "volume", false); err != nil {
+		return row, err
+	}
+
+	if _, ok := fields["close"]; ok {
+		row.isBar = true
+		for name, target := range map[string]*float64{"open": &row.Open, "high": &row.High, "low": &row.Low, "close": &row.Close} {
+			if *target, err = replayNumber(fields, name, true); err != nil {
+				return row, err
+			}
+		}
+		return row, nil
+	}
+
+	if row.Price, err = replayNumber(fields, "price", true); err != nil {
+		return row, err
+	}
+	return row, nil
+}
+
+func firstField(fields map[string]interface{}, names ...string) (interface{}, bool) {
+	for _, name := range names {
+		if value, ok := fields[name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func replayNumber(fields map[string]interface{}, name string, required bool) (float64, error) {
+	value, ok := fields[name]
+	if !ok {
+		if required {
+			return 0, fmt.Errorf("missing %s", name)
+		}
+		return 0, nil
+	}
+
+	var number float64
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, v)
+		}
+		number = parsed
+	default:
+		return 0, fmt.Errorf("invalid %s %v", name, v)
+	}
+
+	if number < 0 || (required && number == 0) {
+		return 0, fmt.Errorf("invalid %s %v", name, number)
+	}
+	return number, nil
+}
+
+func parseReplayTimestamp(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case float64:
+		if v < 1e11 {
+			return time.Unix(0, int64(v*float64(time.Second))).UTC(), nil
+		}
+		return unixTimestamp(int64(v)), nil
+	case string:
+		v = strings.TrimSpace(v)
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return unixTimestamp(n), nil
+		}
+		for _, layout := range replayTimestampLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+	}
+}
+
+// unixTimestamp interprets n as Unix seconds, milliseconds, microseconds or
+// nanoseconds
+func unixTimestamp(n int64) time.Time {
+	switch {
+	case n > 1e17:
+		return time.Unix(0, n).UTC()
+	case n > 1e14:
+		return time.UnixMicro(n).UTC()
+	case n > 1e11:
+		return time.UnixMilli(n).UTC()
+	default:
+		return time.Unix(n, 0).UTC()
+	}
+}
+
+// expandReplayRows converts rows to price updates. Ticks map one to one.
+// Each OHLCV bar becomes four updates spread evenly over the bar: open, then
+// high and low in the order a down or up bar most likely visited them, then
+// close, each carrying a quarter of the volume. A bar lasts until the
+// symbol's next bar; the last bar reuses the previous bar's length.
+func expandReplayRows(rows []replayRow) []shared.PriceUpdate {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Timestamp.Before(rows[j].Timestamp)
+	})
+
+	nextBar := make(map[string]time.Time)
+	lengths := make([]time.Duration, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		if !rows[i].isBar {
+			continue
+		}
+		if next, ok := nextBar[rows[i].Symbol]; ok {
+			lengths[i] = next.Sub(rows[i].Timestamp)
+		}
+		nextBar[rows[i].Symbol] = rows[i].Timestamp
+	}
+
+	previousLength := make(map[string]time.Duration)
+	updates := make([]shared.PriceUpdate, 0, len(rows))
+	for i, row := range rows {
+		if !row.isBar {
+			updates = append(updates, shared.PriceUpdate{
+				Symbol:    row.Symbol,
+				Price:     row.Price,
+				Volume:    row.Volume,
+				Timestamp: row.Timestamp,
+			})
+			continue
+		}
+
+		length := lengths[i]
+		if length == 0 {
+			length = previousLength[row.Symbol]
+		}
+		previousLength[row.Symbol] = length
+
+		prices := []float64{row.Open, row.Low, row.High, row.Close}
+		if row.Close < row.Open {
+			prices = []float64{row.Open, row.High, row.Low, row.Close}
+		}
+		for step, price := range prices {
+			updates = append(updates, shared.PriceUpdate{
+				Symbol:    row.Symbol,
+				Price:     price,
+				Volume:    row.Volume / float64(len(prices)),
+				Timestamp: row.Timestamp.Add(length * time.Duration(step) / time.Duration(len(prices))),
+			})
+		}
+	}
+
+	return updates
+}
@@ -0,0 +1,250 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/shared"
+)
+
+func writeReplayFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestUnixTimestamp(t *testing.T) {
+	want := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		n    int64
+		want time.Time
+	}{
+		{"seconds", want.Unix(), want},
+		{"milliseconds", want.UnixMilli(), want},
+		{"microseconds", want.UnixMicro(), want},
+		{"nanoseconds", want.UnixNano(), want},
+		{"largest seconds", 1e11, time.Unix(1e11, 0).UTC()},
+		{"smallest milliseconds", 1e11 + 1, time.UnixMilli(1e11 + 1).UTC()},
+		{"largest milliseconds", 1e14, time.UnixMilli(1e14).UTC()},
+		{"smallest microseconds", 1e14 + 1, time.UnixMicro(1e14 + 1).UTC()},
+		{"largest microseconds", 1e17, time.UnixMicro(1e17).UTC()},
+		{"smallest nanoseconds", 1e17 + 1, time.Unix(0, 1e17+1).UTC()},
+		{"epoch", 0, time.Unix(0, 0).UTC()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unixTimestamp(tt.n)
+			assert.True(t, tt.want.Equal(got), "got %v, want %v", got, tt.want)
+			assert.Equal(t, time.UTC, got.Location())
+		})
+	}
+}
+
+func TestParseReplayTimestamp(t *testing.T) {
+	want := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC 3339", "2024-01-15T14:30:00Z", want, false},
+		{"RFC 3339 with offset", "2024-01-15T09:30:00-05:00", want, false},
+		{"space separated", "2024-01-15 14:30:00", want, false},
+		{"without zone", "2024-01-15T14:30:00", want, false},
+		{"date", "2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), false},
+		{"numeric string in milliseconds", "1705329000000", want, false},
+		{"JSON number in seconds", float64(want.Unix()), want, false},
+		{"JSON number with fractional seconds", float64(want.Unix()) + 0.5, want.Add(500 * time.Millisecond), false},
+		{"JSON number in milliseconds", float64(want.UnixMilli()), want, false},
+		{"unparseable", "yesterday", time.Time{}, true},
+		{"wrong type", true, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReplayTimestamp(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %v, want %v", got, tt.want)
+		})
+	}
+}
+
+func TestParseReplayCSV(t *testing.T) {
+	rows, err := parseReplayCSV(strings.NewReader(
+		"Timestamp, Symbol, Price, Volume\n" +
+			"2024-01-15T14:30:00Z, aapl, 150.25, 100\n" +
+			"1705329001, , 150.5,\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "AAPL", rows[0].Symbol)
+	assert.Equal(t, 150.25, rows[0].Price)
+	assert.Equal(t, 100.0, rows[0].Volume)
+	assert.False(t, rows[0].isBar)
+
+	// Empty cells are missing fields
+	assert.Empty(t, rows[1].Symbol)
+	assert.Zero(t, rows[1].Volume)
+	assert.Equal(t, time.Date(2024, 1, 15, 14, 30, 1, 0, time.UTC), rows[1].Timestamp)
+
+	bars, err := parseReplayCSV(strings.NewReader(
+		"date,open,high,low,close,volume\n" +
+			"2024-01-15,100,110,95,105,4000\n"))
+	require.NoError(t, err)
+	require.Len(t, bars, 1)
+	assert.True(t, bars[0].isBar)
+	assert.Equal(t, replayRow{
+		Timestamp: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Open:      100, High: 110, Low: 95, Close: 105, Volume: 4000,
+		isBar: true,
+	}, bars[0])
+}
+
+func TestParseReplayCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"empty file", "", "failed to read header"},
+		{"missing timestamp", "price\n100\n", "line 2: missing timestamp"},
+		{"missing price", "timestamp\n2024-01-15\n", "line 2: missing price"},
+		{"invalid price", "timestamp,price\n2024-01-15,abc\n", `line 2: invalid price "abc"`},
+		{"zero price", "timestamp,price\n2024-01-15,0\n", "line 2: invalid price 0"},
+		{"negative volume", "timestamp,price,volume\n2024-01-15,100,-1\n", "line 2: invalid volume -1"},
+		{"bar missing a price", "timestamp,open,high,close\n2024-01-15,100,110,105\n", "line 2: missing low"},
+		{"invalid timestamp", "timestamp,price\nsoon,100\n", `line 2: invalid timestamp "soon"`},
+		{"ragged row", "timestamp,price\n2024-01-15,100\n2024-01-16\n", "line 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseReplayCSV(strings.NewReader(tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestParseReplayNDJSON(t *testing.T) {
+	rows, err := parseReplayNDJSON(strings.NewReader(
+		`{"ts": 1705329000000, "Symbol": "btcusd", "price": 42000.5, "volume": 0.25}` + "\n" +
+			"\n" +
+			`{"time": "2024-01-15T14:31:00Z", "open": "100", "high": 110, "low": 95, "close": 105}` + "\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "BTCUSD", rows[0].Symbol)
+	assert.Equal(t, 42000.5, rows[0].Price)
+	assert.Equal(t, 0.25, rows[0].Volume)
+	assert.Equal(t, time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC), rows[0].Timestamp)
+
+	assert.True(t, rows[1].isBar)
+	assert.Equal(t, 100.0, rows[1].Open)
+	assert.Equal(t, 105.0, rows[1].Close)
+
+	_, err = parseReplayNDJSON(strings.NewReader(`{"ts": 1705329000, "price": 1}` + "\n" + `{"ts": `))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+
+	_, err = parseReplayNDJSON(strings.NewReader(`{"ts": 1705329000, "price": [1]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid price")
+}
+
+func TestExpandReplayRows(t *testing.T) {
+	start := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	update := func(symbol string, price, volume float64, at time.Time) shared.PriceUpdate {
+		return shared.PriceUpdate{Symbol: symbol, Price: price, Volume: volume, Timestamp: at}
+	}
+
+	updates := expandReplayRows([]replayRow{
+		// An up bar visits the low before the high
+		{Timestamp: at(0), Symbol: "AAPL", Open: 100, High: 110, Low: 95, Close: 105, Volume: 400, isBar: true},
+		// A down bar visits the high before the low; as the last bar it
+		// reuses the previous bar's length
+		{Timestamp: at(4), Symbol: "AAPL", Open: 105, High: 108, Low: 90, Close: 92, Volume: 40, isBar: true},
+		// Ticks map one to one and interleave by time
+		{Timestamp: at(1), Symbol: "MSFT", Price: 300, Volume: 7},
+	})
+
+	assert.Equal(t, []shared.PriceUpdate{
+		update("AAPL", 100, 100, at(0)),
+		update("AAPL", 95, 100, at(1)),
+		update("MSFT", 300, 7, at(1)),
+		update("AAPL", 110, 100, at(2)),
+		update("AAPL", 105, 100, at(3)),
+		update("AAPL", 105, 10, at(4)),
+		update("AAPL", 108, 10, at(5)),
+		update("AAPL", 90, 10, at(6)),
+		update("AAPL", 92, 10, at(7)),
+	}, sortedByTime(updates))
+}
+
+func TestExpandReplayRows_SingleBar(t *testing.T) {
+	at := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	updates := expandReplayRows([]replayRow{
+		{Timestamp: at, Symbol: "AAPL", Open: 100, High: 110, Low: 95, Close: 105, isBar: true},
+	})
+
+	// A lone bar has no length, so its prices share its timestamp
+	require.Len(t, updates, 4)
+	for _, update := range updates {
+		assert.Equal(t, at, update.Timestamp)
+	}
+}
+
+func TestLoadReplayFiles(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := writeReplayFile(t, dir, "aapl.csv", "timestamp,price\n2024-01-15T14:30:02Z,150\n2024-01-15T14:30:00Z,149\n")
+	ndjsonPath := writeReplayFile(t, dir, "feed.ndjson", `{"timestamp": "2024-01-15T14:30:01Z", "symbol": "MSFT", "price": 300}`+"\n")
+
+	updates, err := LoadReplayFiles([]string{csvPath, ndjsonPath})
+	require.NoError(t, err)
+
+	// Files merge into one timeline and rows without a symbol take it from
+	// the file name
+	var got []string
+	for _, update := range updates {
+		got = append(got, update.Symbol)
+	}
+	assert.Equal(t, []string{"AAPL", "MSFT", "AAPL"}, got)
+	assert.Equal(t, 149.0, updates[0].Price)
+
+	_, err = LoadReplayFiles([]string{writeReplayFile(t, dir, "prices.txt", "")})
+	assert.ErrorContains(t, err, "unsupported replay file format: prices.txt")
+
+	_, err = LoadReplayFiles([]string{filepath.Join(dir, "missing.csv")})
+	assert.ErrorContains(t, err, "failed to open replay file")
+
+	_, err = LoadReplayFiles([]string{writeReplayFile(t, dir, "bad.csv", "timestamp,price\n2024-01-15,x\n")})
+	assert.ErrorContains(t, err, "failed to read bad.csv: line 2")
+}
+
+// sortedByTime orders updates by timestamp, then symbol, so updates due at
+// the same time compare deterministically
+func sortedByTime(updates []shared.PriceUpdate) []shared.PriceUpdate {
+	sorted := append([]shared.PriceUpdate(nil), updates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Timestamp.Equal(sorted[j].Timestamp) {
+			return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+		}
+		return sorted[i].Symbol < sorted[j].Symbol
+	})
+	return sorted
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"simulated_exchange/pkg/clock"
 	"simulated_exchange/pkg/messaging"
 	"simulated_exchange/pkg/shared"
 )
@@ -17,6 +18,7 @@ type SimulatorService struct {
 	marketDataService *MarketDataService
 	eventBus          *messaging.RedisEventBus
 	logger            *slog.Logger
+	clock             clock.Clock
 
 	// Simulation state
 	isRunning   bool
@@ -44,12 +46,22 @@ func NewSimulatorService(
 		marketDataService: marketDataService,
 		eventBus:          eventBus,
 		logger:            logger,
+		clock:             clock.Real(),
 		symbols:           []string{"BTCUSD", "ETHUSD", "ADAUSD"},
 		updateInterval:    250 * time.Millisecond,
 		volatilityEvents:  make(map[string]time.Time),
 	}
 }
 
+// SetClock sets the clock price updates and volatility checks are
+// scheduled on; it takes effect at the next start
+func (ss *SimulatorService) SetClock(clk clock.Clock) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.clock = clk
+}
+
 // Start starts the market simulation
 func (ss *SimulatorService) Start(ctx context.Context) error {
 	ss.mutex.Lock()
@@ -61,7 +73,7 @@ func (ss *SimulatorService) Start(ctx context.Context) error {
 
 	ss.ctx, ss.cancel = context.WithCancel(ctx)
 	ss.isRunning = true
-	ss.startTime = time.Now()
+	ss.startTime = ss.clock.Now()
 
 	// Start price generation workers
 	for _, symbol := range ss.symbols {
@@ -102,7 +114,7 @@ func (ss *SimulatorService) Stop(ctx context.Context) error {
 	ss.isRunning = false
 
 	ss.logger.Info("Market simulation stopped",
-		"runtime", time.Since(ss.startTime),
+		"runtime", ss.clock.Since(ss.startTime),
 	)
 
 	return nil
@@ -134,7 +146,7 @@ func (ss *SimulatorService) GetStatus(ctx context.Context) (*shared.ServiceInfo,
 
 	if ss.isRunning {
 		status.Status = "running"
-		status.Uptime = time.Since(ss.startTime)
+		status.Uptime = ss.clock.Since(ss.startTime)
 	}
 
 	return status, nil
@@ -179,7 +191,7 @@ func (ss *SimulatorService) startPriceWorker(symbol string) {
 	go func() {
 		defer ss.waitGroup.Done()
 
-		ticker := time.NewTicker(ss.updateInterval)
+		ticker := ss.clock.NewTicker(ss.updateInterval)
 		defer ticker.Stop()
 
 		ss.logger.Debug("Started price worker", "symbol", symbol)
@@ -189,7 +201,7 @@ func (ss *SimulatorService) startPriceWorker(symbol string) {
 			case <-ss.ctx.Done():
 				ss.logger.Debug("Price worker stopped", "symbol", symbol)
 				return
-			case <-ticker.C:
+			case <-ticker.C():
 				if err := ss.priceService.GenerateAndPublishPrice(ss.ctx, symbol); err != nil {
 					ss.logger.Warn("Failed to generate price",
 						"symbol", symbol,
@@ -207,7 +219,7 @@ func (ss *SimulatorService) startVolatilityWorker() {
 	go func() {
 		defer ss.waitGroup.Done()
 
-		ticker := time.NewTicker(30 * time.Second) // Check every 30 seconds
+		ticker := ss.clock.NewTicker(30 * time.Second) // Check every 30 seconds
 		defer ticker.Stop()
 
 		ss.logger.Debug("Started volatility worker")
@@ -217,7 +229,7 @@ func (ss *SimulatorService) startVolatilityWorker() {
 			case <-ss.ctx.Done():
 				ss.logger.Debug("Volatility worker stopped")
 				return
-			case <-ticker.C:
+			case <-ticker.C():
 				ss.maybeInjectRandomVolatility()
 			}
 		}
@@ -271,7 +283,7 @@ func (ss *SimulatorService) publishSystemStatus() {
 	status := map[string]interface{}{
 		"service":     "market-simulator",
 		"status":      "running",
-		"uptime":      time.Since(ss.startTime).String(),
+		"uptime":      ss.clock.Since(ss.startTime).String(),
 		"symbols":     ss.symbols,
 		"timestamp":   time.Now().Format(time.RFC3339),
 	}
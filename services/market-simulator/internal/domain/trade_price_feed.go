@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"simulated_exchange/pkg/shared"
+)
+
+// TradePriceFeed publishes the prices discovered by the matching engine. In
+// the agent-based mode no prices are generated; every executed trade
+// becomes the symbol's published price.
+type TradePriceFeed struct {
+	priceService shared.PriceService
+	logger       *slog.Logger
+}
+
+// NewTradePriceFeed creates a new trade price feed
+func NewTradePriceFeed(priceService shared.PriceService, logger *slog.Logger) *TradePriceFeed {
+	return &TradePriceFeed{
+		priceService: priceService,
+		logger:       logger,
+	}
+}
+
+// HandleTradeExecuted publishes the price and volume of an executed trade.
+// Events without a symbol, a positive price or a non-negative quantity are
+// rejected without publishing anything.
+func (f *TradePriceFeed) HandleTradeExecuted(ctx context.Context, event *shared.Event) error {
+	symbol, _ := event.Data["symbol"].(string)
+	price, _ := event.Data["price"].(float64)
+	quantity, _ := event.Data["quantity"].(float64)
+	if symbol == "" || !(price > 0) || math.IsInf(price, 1) || !(quantity >= 0) || math.IsInf(quantity, 1) {
+		return fmt.Errorf("invalid trade in %s event", event.Type)
+	}
+
+	timestamp := event.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	if err := f.priceService.UpdatePrice(ctx, &shared.PriceUpdate{
+		Symbol:    symbol,
+		Price:     price,
+		Volume:    quantity,
+		Timestamp: timestamp,
+	}); err != nil {
+		return fmt.Errorf("failed to publish trade price: %w", err)
+	}
+
+	f.logger.Debug("Published trade price", "symbol", symbol, "price", price, "quantity", quantity)
+	return nil
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/shared"
+)
+
+// recordingPriceService records the price updates it is asked to publish
+type recordingPriceService struct {
+	shared.PriceService
+	updates []*shared.PriceUpdate
+	err     error
+}
+
+func (s *recordingPriceService) UpdatePrice(ctx context.Context, update *shared.PriceUpdate) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.updates = append(s.updates, update)
+	return nil
+}
+
+func newTestTradePriceFeed() (*TradePriceFeed, *recordingPriceService) {
+	prices := &recordingPriceService{}
+	return NewTradePriceFeed(prices, slog.New(slog.NewTextHandler(io.Discard, nil))), prices
+}
+
+func tradeEvent(data map[string]interface{}) *shared.Event {
+	return &shared.Event{
+		Type:      shared.EventTypeTradeExecuted,
+		Timestamp: time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC),
+		Data:      data,
+	}
+}
+
+func TestTradePriceFeed_HandleTradeExecuted(t *testing.T) {
+	feed, prices := newTestTradePriceFeed()
+
+	event := tradeEvent(map[string]interface{}{"symbol": "BTC", "price": 42000.5, "quantity": 0.25})
+	require.NoError(t, feed.HandleTradeExecuted(context.Background(), event))
+
+	require.Len(t, prices.updates, 1)
+	assert.Equal(t, &shared.PriceUpdate{
+		Symbol:    "BTC",
+		Price:     42000.5,
+		Volume:    0.25,
+		Timestamp: event.Timestamp,
+	}, prices.updates[0])
+}
+
+func TestTradePriceFeed_HandleTradeExecutedWithoutTimestamp(t *testing.T) {
+	feed, prices := newTestTradePriceFeed()
+
+	before := time.Now()
+	event := tradeEvent(map[string]interface{}{"symbol": "BTC", "price": 42000.0})
+	event.Timestamp = time.Time{}
+	require.NoError(t, feed.HandleTradeExecuted(context.Background(), event))
+
+	// A trade without a quantity publishes its price without volume, timed
+	// on arrival
+	require.Len(t, prices.updates, 1)
+	assert.Zero(t, prices.updates[0].Volume)
+	assert.False(t, prices.updates[0].Timestamp.Before(before))
+}
+
+func TestTradePriceFeed_RejectsMalformedTrades(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]interface{}
+	}{
+		{"no data", nil},
+		{"missing symbol", map[string]interface{}{"price": 100.0, "quantity": 1.0}},
+		{"empty symbol", map[string]interface{}{"symbol": "", "price": 100.0, "quantity": 1.0}},
+		{"symbol of the wrong type", map[string]interface{}{"symbol": 7, "price": 100.0, "quantity": 1.0}},
+		{"missing price", map[string]interface{}{"symbol": "BTC", "quantity": 1.0}},
+		{"price as a string", map[string]interface{}{"symbol": "BTC", "price": "100", "quantity": 1.0}},
+		{"zero price", map[string]interface{}{"symbol": "BTC", "price": 0.0, "quantity": 1.0}},
+		{"negative price", map[string]interface{}{"symbol": "BTC", "price": -100.0, "quantity": 1.0}},
+		{"NaN price", map[string]interface{}{"symbol": "BTC", "price": math.NaN(), "quantity": 1.0}},
+		{"infinite price", map[string]interface{}{"symbol": "BTC", "price": math.Inf(1), "quantity": 1.0}},
+		{"negative quantity", map[string]interface{}{"symbol": "BTC", "price": 100.0, "quantity": -1.0}},
+		{"NaN quantity", map[string]interface{}{"symbol": "BTC", "price": 100.0, "quantity": math.NaN()}},
+		{"infinite quantity", map[string]interface{}{"symbol": "BTC", "price": 100.0, "quantity": math.Inf(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, prices := newTestTradePriceFeed()
+
+			err := feed.HandleTradeExecuted(context.Background(), tradeEvent(tt.data))
+			assert.ErrorContains(t, err, "invalid trade in trade.executed event")
+			assert.Empty(t, prices.updates)
+		})
+	}
+}
+
+func TestTradePriceFeed_PublishFailure(t *testing.T) {
+	feed, prices := newTestTradePriceFeed()
+	prices.err = errors.New("redis unavailable")
+
+	err := feed.HandleTradeExecuted(context.Background(), tradeEvent(map[string]interface{}{"symbol": "BTC", "price": 100.0}))
+	assert.ErrorContains(t, err, "failed to publish trade price: redis unavailable")
+}
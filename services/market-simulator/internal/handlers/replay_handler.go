@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+	"simulated_exchange/services/market-simulator/internal/domain"
+)
+
+// ReplayHandler handles market data replay requests
+type ReplayHandler struct {
+	replayService *domain.ReplayService
+	logger        *slog.Logger
+}
+
+// NewReplayHandler creates a new replay handler
+func NewReplayHandler(replayService *domain.ReplayService, logger *slog.Logger) *ReplayHandler {
+	return &ReplayHandler{
+		replayService: replayService,
+		logger:        logger,
+	}
+}
+
+// StartReplayRequest represents the request body for starting a replay.
+// Speed defaults to 1; 0 replays as fast as possible.
+type StartReplayRequest struct {
+	Files []string `json:"files" binding:"required,min=1"`
+	Speed *float64 `json:"speed"`
+}
+
+// SeekReplayRequest represents the request body for seeking a replay
+type SeekReplayRequest struct {
+	Time time.Time `json:"time" binding:"required"`
+}
+
+// ReplaySpeedRequest represents the request body for changing replay speed
+type ReplaySpeedRequest struct {
+	Speed *float64 `json:"speed" binding:"required"`
+}
+
+// ListFiles handles GET /api/replay/files
+func (h *ReplayHandler) ListFiles(c *gin.Context) {
+	files, err := h.replayService.ListFiles()
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"files": files,
+			"count": len(files),
+		},
+	})
+}
+
+// GetStatus handles GET /api/replay/status
+func (h *ReplayHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    h.replayService.Status(),
+	})
+}
+
+// Start handles POST /api/replay/start
+func (h *ReplayHandler) Start(c *gin.Context) {
+	var req StartReplayRequest
+	if !bindReplayRequest(c, &req) {
+		return
+	}
+
+	speed := 1.0
+	if req.Speed != nil {
+		speed = *req.Speed
+	}
+
+	status, err := h.replayService.Start(req.Files, speed)
+	h.respond(c, status, err)
+}
+
+// Pause handles POST /api/replay/pause
+func (h *ReplayHandler) Pause(c *gin.Context) {
+	status, err := h.replayService.Pause()
+	h.respond(c, status, err)
+}
+
+// Resume handles POST /api/replay/resume
+func (h *ReplayHandler) Resume(c *gin.Context) {
+	status, err := h.replayService.Resume()
+	h.respond(c, status, err)
+}
+
+// Seek handles POST /api/replay/seek
+func (h *ReplayHandler) Seek(c *gin.Context) {
+	var req SeekReplayRequest
+	if !bindReplayRequest(c, &req) {
+		return
+	}
+
+	status, err := h.replayService.Seek(req.Time)
+	h.respond(c, status, err)
+}
+
+// SetSpeed handles PUT /api/replay/speed
+func (h *ReplayHandler) SetSpeed(c *gin.Context) {
+	var req ReplaySpeedRequest
+	if !bindReplayRequest(c, &req) {
+		return
+	}
+
+	status, err := h.replayService.SetSpeed(*req.Speed)
+	h.respond(c, status, err)
+}
+
+// Stop handles POST /api/replay/stop
+func (h *ReplayHandler) Stop(c *gin.Context) {
+	status, err := h.replayService.Stop()
+	h.respond(c, status, err)
+}
+
+func (h *ReplayHandler) respond(c *gin.Context, status *domain.ReplayStatus, err error) {
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    status,
+	})
+}
+
+// respondError maps replay errors: invalid requests are 400 and controls
+// that don't apply to the replay's state 409
+func (h *ReplayHandler) respondError(c *gin.Context, err error) {
+	switch e := err.(type) {
+	case *shared.ValidationError:
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: e.Message,
+				Details: e.Error(),
+			},
+		})
+	case *shared.BusinessError:
+		c.JSON(http.StatusConflict, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    e.Code,
+				Message: e.Message,
+			},
+		})
+	default:
+		h.logger.Error("Replay request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "REPLAY_ERROR",
+				Message: "Replay request failed",
+				Details: err.Error(),
+			},
+		})
+	}
+}
+
+func bindReplayRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+	return true
+}
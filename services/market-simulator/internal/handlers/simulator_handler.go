@@ -51,6 +51,13 @@ type VolatilityRequest struct {
 	Intensity float64 `json:"intensity" binding:"required,min=0.1,max=1.0"`
 }
 
+// SimulatorStatus is the simulator status with the realized correlations of
+// the simulated symbols' returns
+type SimulatorStatus struct {
+	*shared.ServiceInfo
+	Correlations map[string]map[string]float64 `json:"correlations"`
+}
+
 // GetStatus handles GET /api/status
 func (h *SimulatorHandler) GetStatus(c *gin.Context) {
 	status, err := h.simulatorService.GetStatus(c.Request.Context())
@@ -69,7 +76,10 @@ func (h *SimulatorHandler) GetStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
-		Data:    status,
+		Data: SimulatorStatus{
+			ServiceInfo:  status,
+			Correlations: h.priceService.GetRealizedCorrelations(),
+		},
 	})
 }
 
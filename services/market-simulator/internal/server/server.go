@@ -18,6 +18,7 @@ type Server struct {
 	config            *config.Config
 	healthHandler     *handlers.HealthHandler
 	simulatorHandler  *handlers.SimulatorHandler
+	replayHandler     *handlers.ReplayHandler
 	metricsCollector  *monitoring.MetricsCollector
 	logger            *slog.Logger
 	router            *gin.Engine
@@ -29,6 +30,7 @@ func NewServer(
 	config *config.Config,
 	healthHandler *handlers.HealthHandler,
 	simulatorHandler *handlers.SimulatorHandler,
+	replayHandler *handlers.ReplayHandler,
 	metricsCollector *monitoring.MetricsCollector,
 	logger *slog.Logger,
 ) *Server {
@@ -41,6 +43,7 @@ func NewServer(
 		config:           config,
 		healthHandler:    healthHandler,
 		simulatorHandler: simulatorHandler,
+		replayHandler:    replayHandler,
 		metricsCollector: metricsCollector,
 		logger:           logger,
 	}
@@ -95,6 +98,19 @@ func (s *Server) setupRouter() {
 		api.GET("/price/:symbol", s.simulatorHandler.GetCurrentPrice)
 		api.GET("/history/:symbol", s.simulatorHandler.GetPriceHistory)
 		api.GET("/symbols", s.simulatorHandler.GetAllSymbols)
+
+		// Historical market data replay endpoints
+		replay := api.Group("/replay")
+		{
+			replay.GET("/files", s.replayHandler.ListFiles)
+			replay.GET("/status", s.replayHandler.GetStatus)
+			replay.POST("/start", s.replayHandler.Start)
+			replay.POST("/pause", s.replayHandler.Pause)
+			replay.POST("/resume", s.replayHandler.Resume)
+			replay.POST("/seek", s.replayHandler.Seek)
+			replay.PUT("/speed", s.replayHandler.SetSpeed)
+			replay.POST("/stop", s.replayHandler.Stop)
+		}
 	}
 
 	// Service info endpoint
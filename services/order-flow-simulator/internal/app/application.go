@@ -10,9 +10,11 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"simulated_exchange/pkg/cache"
+	"simulated_exchange/pkg/clock"
 	"simulated_exchange/pkg/config"
 	"simulated_exchange/pkg/messaging"
 	"simulated_exchange/pkg/monitoring"
+	"simulated_exchange/pkg/seasonality"
 	"simulated_exchange/pkg/shared"
 	"simulated_exchange/services/order-flow-simulator/internal/domain"
 	"simulated_exchange/services/order-flow-simulator/internal/handlers"
@@ -33,6 +35,9 @@ type Application struct {
 	userSimulator      *domain.UserSimulator
 	flowSimulator      *domain.FlowSimulator
 	tradingAPIClient   *domain.TradingAPIClient
+	positionKeeper     *domain.PositionKeeper
+	agentTrader        *domain.AgentTrader
+	injector           *domain.ManipulationInjector
 
 	// HTTP Server (for health checks and control)
 	server *server.Server
@@ -237,6 +242,9 @@ func (a *Application) initializeServices() error {
 	// Initialize trading API client
 	tradingAPIURL := "http://trading-api:8080" // Docker service name
 	a.tradingAPIClient = domain.NewTradingAPIClient(tradingAPIURL, a.logger)
+	if apiKey := os.Getenv("TRADING_API_KEY"); apiKey != "" {
+		a.tradingAPIClient.SetAPIKey(apiKey)
+	}
 
 	// Initialize order generator
 	orderConfig := domain.OrderGeneratorConfig{
@@ -251,18 +259,73 @@ func (a *Application) initializeServices() error {
 	}
 	a.orderGenerator = domain.NewOrderGenerator(orderConfig, a.logger)
 
+	// Order timestamps and the flow schedule run on the configured
+	// simulated time
+	clk := clock.New(a.config.Clock)
+	a.orderGenerator.SetClock(clk)
+
+	// Order arrivals follow the same seasonality as the market-simulator's
+	// volatility
+	profile, err := seasonality.New(a.config.Seasonality)
+	if err != nil {
+		return err
+	}
+	a.orderGenerator.SetSeasonality(profile)
+
 	// Initialize user simulator
 	a.userSimulator = domain.NewUserSimulator(a.orderGenerator, a.logger)
 
+	// Initialize position keeper (P&L per simulated user)
+	a.positionKeeper = domain.NewPositionKeeper(a.logger)
+
+	// In agent mode trading agents discover prices in the matching engine;
+	// in oracle mode market makers only trade alongside the simulated users
+	// when enabled
+	populations := a.config.Agents.Populations
+	if !a.config.Agents.Enabled() {
+		populations = make(map[string]int)
+		if a.config.Agents.MarketMaker.Oracle {
+			populations[config.AgentMarketMaker] = a.config.Agents.Populations[config.AgentMarketMaker]
+		}
+	}
+	a.userSimulator.SpawnAgents(populations)
+	if a.config.Agents.Enabled() {
+		a.userSimulator.StopUserSessions()
+		a.agentTrader = domain.NewAgentTrader(
+			a.config.Agents,
+			a.orderGenerator,
+			a.userSimulator,
+			a.tradingAPIClient,
+			a.positionKeeper,
+			a.logger,
+		)
+	}
+
+	// Initialize manipulation injector (off until a rate is set)
+	a.injector = domain.NewManipulationInjector(a.orderGenerator, a.userSimulator, a.tradingAPIClient, a.logger)
+
 	// Initialize flow simulator
 	a.flowSimulator = domain.NewFlowSimulator(
 		a.orderGenerator,
 		a.userSimulator,
 		a.tradingAPIClient,
+		a.positionKeeper,
+		a.agentTrader,
 		a.eventBus,
 		a.logger,
 	)
 
+	// Market makers, if any were spawned, quote every symbol
+	a.flowSimulator.SetMarketMakers(domain.NewMarketMakers(
+		a.config.Agents.MarketMaker,
+		a.orderGenerator,
+		a.userSimulator,
+		a.tradingAPIClient,
+		a.positionKeeper,
+		a.logger,
+	), a.config.Agents.TickInterval)
+	a.flowSimulator.SetClock(clk)
+
 	a.logger.Info("Services initialized successfully")
 	return nil
 }
@@ -273,7 +336,7 @@ func (a *Application) initializeServer() error {
 
 	// Create handlers
 	healthHandler := handlers.NewHealthHandler(a.cache, a.tradingAPIClient, a.logger)
-	flowHandler := handlers.NewFlowHandler(a.flowSimulator, a.userSimulator, a.logger)
+	flowHandler := handlers.NewFlowHandler(a.flowSimulator, a.userSimulator, a.positionKeeper, a.injector, a.logger)
 
 	// Create server
 	a.server = server.NewServer(
@@ -333,6 +396,13 @@ func (a *Application) startSimulation() error {
 		return fmt.Errorf("failed to start flow simulation service: %w", err)
 	}
 
+	// Start manipulation injection
+	a.waitGroup.Add(1)
+	go func() {
+		defer a.waitGroup.Done()
+		a.injector.Run(a.ctx)
+	}()
+
 	a.logger.Info("Order flow simulation started successfully")
 	return nil
 }
@@ -351,8 +421,14 @@ func (a *Application) handlePriceUpdate(ctx context.Context, event *shared.Event
 		return fmt.Errorf("invalid price in price update event")
 	}
 
-	// Notify user simulator about price change
+	// Notify user simulator about price change and mark positions to market
 	a.userSimulator.OnPriceUpdate(symbol, price)
+	a.positionKeeper.OnPriceUpdate(symbol, price)
+
+	// Market makers widen their spreads with the generated volatility
+	if index, ok := event.Data["volatility_index"].(float64); ok {
+		a.userSimulator.OnVolatilityIndex(symbol, index)
+	}
 
 	a.logger.Debug("Processed price update", "symbol", symbol, "price", price)
 	return nil
@@ -378,6 +454,26 @@ func (a *Application) handleTradeExecuted(ctx context.Context, event *shared.Eve
 	// Notify user simulator about trade execution
 	a.userSimulator.OnTradeExecuted(symbol, price, quantity)
 
+	// Apply both sides of the trade to the simulated users' positions
+	if clientOrderID, ok := event.Data["buy_client_order_id"].(string); ok && clientOrderID != "" {
+		a.positionKeeper.OnFill(domain.Fill{
+			ClientOrderID: clientOrderID,
+			Symbol:        symbol,
+			Buy:           true,
+			Quantity:      quantity,
+			Price:         price,
+		})
+	}
+	if clientOrderID, ok := event.Data["sell_client_order_id"].(string); ok && clientOrderID != "" {
+		a.positionKeeper.OnFill(domain.Fill{
+			ClientOrderID: clientOrderID,
+			Symbol:        symbol,
+			Buy:           false,
+			Quantity:      quantity,
+			Price:         price,
+		})
+	}
+
 	a.logger.Debug("Processed trade execution", "symbol", symbol, "price", price, "quantity", quantity)
 	return nil
 }
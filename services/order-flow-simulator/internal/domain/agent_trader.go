@@ -0,0 +1,240 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+const (
+	// zeroIntelligenceRange is how far from the reference price zero
+	// intelligence traders place their limit orders, as a fraction
+	zeroIntelligenceRange = 0.01
+
+	// noiseSizeFactor scales the market orders of noise traders relative to
+	// the typical order size
+	noiseSizeFactor = 0.5
+
+	// fundamentalThreshold is the mispricing fundamental traders need before
+	// they trade towards their valuation
+	fundamentalThreshold = 0.002
+)
+
+// AgentTrader decides the orders of trading agents in the agent-based mode.
+// Prices are discovered in the matching engine: agents only see the order
+// book and the last trade price, and fundamental traders keep their own
+// valuation of each symbol as a random walk. Market makers quote on their
+// own, see MarketMaker.
+type AgentTrader struct {
+	config           config.AgentsConfig
+	orderGenerator   *OrderGenerator
+	userSimulator    *UserSimulator
+	tradingAPIClient *TradingAPIClient
+	positionKeeper   *PositionKeeper
+	logger           *slog.Logger
+
+	random       *rand.Rand
+	accounts     map[string]string
+	fundamentals map[string]float64
+	mutex        sync.Mutex
+}
+
+// NewAgentTrader creates a new agent trader
+func NewAgentTrader(
+	cfg config.AgentsConfig,
+	orderGenerator *OrderGenerator,
+	userSimulator *UserSimulator,
+	tradingAPIClient *TradingAPIClient,
+	positionKeeper *PositionKeeper,
+	logger *slog.Logger,
+) *AgentTrader {
+	return &AgentTrader{
+		config:           cfg,
+		orderGenerator:   orderGenerator,
+		userSimulator:    userSimulator,
+		tradingAPIClient: tradingAPIClient,
+		positionKeeper:   positionKeeper,
+		logger:           logger,
+		random:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		accounts:         make(map[string]string),
+		fundamentals:     make(map[string]float64),
+	}
+}
+
+// TickInterval returns how often agents act
+func (at *AgentTrader) TickInterval() time.Duration {
+	return at.config.TickInterval
+}
+
+// Fundamentals returns the fundamental traders' current valuation of each
+// symbol
+func (at *AgentTrader) Fundamentals() map[string]float64 {
+	at.mutex.Lock()
+	defer at.mutex.Unlock()
+
+	fundamentals := make(map[string]float64, len(at.fundamentals))
+	for symbol, value := range at.fundamentals {
+		fundamentals[symbol] = value
+	}
+	return fundamentals
+}
+
+// Tick runs one round of agent decisions in a symbol and returns the orders
+// to submit
+func (at *AgentTrader) Tick(ctx context.Context, symbol string) ([]*shared.Order, error) {
+	book, err := at.tradingAPIClient.GetOrderBook(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order book: %w", err)
+	}
+
+	agents := at.userSimulator.GetAgentSessions()
+
+	at.mutex.Lock()
+	reference := at.reference(symbol, book)
+	fundamental := at.evolveFundamental(symbol, reference)
+
+	var orders []*shared.Order
+	var traders []string
+	for _, agent := range agents {
+		if agent.Behavior.Type == config.AgentMarketMaker || !at.shouldAct(agent) {
+			continue
+		}
+		order := at.decide(agent, symbol, reference, fundamental)
+		if order == nil {
+			continue
+		}
+		at.positionKeeper.TrackOrder(order.ClientOrderID, agent.UserID, agent.Behavior.Type)
+		orders = append(orders, order)
+		traders = append(traders, agent.UserID)
+	}
+	at.mutex.Unlock()
+
+	for _, userID := range traders {
+		at.userSimulator.RecordAgentOrders(userID, 1)
+	}
+
+	return orders, nil
+}
+
+// reference is the price agents trade around: the book mid when both sides
+// are quoted, else the last trade price, else the fundamental value or a
+// default price before anything has traded
+func (at *AgentTrader) reference(symbol string, book *OrderBookSnapshot) float64 {
+	bid, hasBid := book.BestBid()
+	ask, hasAsk := book.BestAsk()
+	if hasBid && hasAsk && bid < ask {
+		return (bid + ask) / 2
+	}
+
+	if state, exists := at.userSimulator.GetMarketState(symbol); exists && state.CurrentPrice > 0 {
+		return state.CurrentPrice
+	}
+	if fundamental, exists := at.fundamentals[symbol]; exists {
+		return fundamental
+	}
+	return at.userSimulator.getDefaultPrice(symbol)
+}
+
+// evolveFundamental steps a symbol's fundamental value by one tick of a
+// driftless geometric random walk, starting from the reference price
+func (at *AgentTrader) evolveFundamental(symbol string, reference float64) float64 {
+	fundamental, exists := at.fundamentals[symbol]
+	if !exists {
+		at.fundamentals[symbol] = reference
+		return reference
+	}
+
+	dt := at.config.TickInterval.Hours() / (365 * 24)
+	sigma := at.config.FundamentalVolatility
+	fundamental *= math.Exp(sigma*math.Sqrt(dt)*at.random.NormFloat64() - sigma*sigma*dt/2)
+	at.fundamentals[symbol] = fundamental
+	return fundamental
+}
+
+// shouldAct reports whether an agent trades a symbol this tick, at its
+// order frequency per minute scaled by the seasonal volume
+func (at *AgentTrader) shouldAct(agent *UserSession) bool {
+	frequency := agent.Behavior.OrderFrequency * at.orderGenerator.SeasonalFactors().Volume
+	return at.random.Float64() < frequency*at.config.TickInterval.Minutes()
+}
+
+// decide returns the order of a trading agent, or nil if it passes
+func (at *AgentTrader) decide(agent *UserSession, symbol string, reference, fundamental float64) *shared.Order {
+	size := typicalQuantity(reference)
+
+	switch agent.Behavior.Type {
+	case config.AgentZeroIntelligence:
+		// Random side, random limit price around the reference
+		price := reference * (1 + (2*at.random.Float64()-1)*zeroIntelligenceRange)
+		return at.order(agent, symbol, shared.OrderTypeLimit, at.randomSide(), size*(0.5+at.random.Float64()), price)
+
+	case config.AgentNoise:
+		return at.order(agent, symbol, shared.OrderTypeMarket, at.randomSide(), size*noiseSizeFactor, 0)
+
+	case config.AgentFundamental:
+		// Trade towards the valuation, limited at it, when the market
+		// misprices the symbol
+		mispricing := (fundamental - reference) / reference
+		if math.Abs(mispricing) < fundamentalThreshold {
+			return nil
+		}
+		side := shared.OrderSideBuy
+		if mispricing < 0 {
+			side = shared.OrderSideSell
+		}
+		return at.order(agent, symbol, shared.OrderTypeLimit, side, size, fundamental)
+	}
+
+	return nil
+}
+
+// order builds an order of an agent, submitted through the exchange
+// account assigned to it
+func (at *AgentTrader) order(agent *UserSession, symbol string, orderType shared.OrderType, side shared.OrderSide, quantity, price float64) *shared.Order {
+	account, exists := at.accounts[agent.UserID]
+	if !exists {
+		account = at.orderGenerator.generateUserID()
+		at.accounts[agent.UserID] = account
+	}
+	return newAgentOrder(account, symbol, orderType, side, quantity, price)
+}
+
+func (at *AgentTrader) randomSide() shared.OrderSide {
+	if at.random.Intn(2) == 0 {
+		return shared.OrderSideBuy
+	}
+	return shared.OrderSideSell
+}
+
+// newAgentOrder builds an order of a trading agent. Its ID doubles as the
+// client order ID that attributes its fills to the agent.
+func newAgentOrder(accountID, symbol string, orderType shared.OrderType, side shared.OrderSide, quantity, price float64) *shared.Order {
+	now := time.Now()
+	id := uuid.New().String()
+	return &shared.Order{
+		ID:            id,
+		ClientOrderID: id,
+		UserID:        accountID,
+		Symbol:        symbol,
+		Type:          orderType,
+		Side:          side,
+		Quantity:      quantity,
+		Price:         price,
+		Status:        shared.OrderStatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// typicalQuantity is an order quantity worth about 1000 at a price
+func typicalQuantity(price float64) float64 {
+	return math.Max(0.1, 1000.0/price)
+}
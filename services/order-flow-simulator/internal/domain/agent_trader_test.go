@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+func newTestAgentTrader(cfg config.AgentsConfig) *AgentTrader {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	orderGenerator := NewOrderGenerator(OrderGeneratorConfig{RandomSeed: 1}, logger)
+	trader := NewAgentTrader(cfg, orderGenerator, NewUserSimulator(orderGenerator, logger), nil, NewPositionKeeper(logger), logger)
+	trader.random = rand.New(rand.NewSource(1))
+	return trader
+}
+
+func testAgent(agentType string) *UserSession {
+	return &UserSession{UserID: "agent-" + agentType, Behavior: UserBehavior{Type: agentType}}
+}
+
+func TestAgentTrader_Decide(t *testing.T) {
+	trader := newTestAgentTrader(config.AgentsConfig{})
+	size := typicalQuantity(200)
+
+	t.Run("zero intelligence places random limit orders around the reference", func(t *testing.T) {
+		sides := map[shared.OrderSide]int{}
+		for i := 0; i < 200; i++ {
+			order := trader.decide(testAgent(config.AgentZeroIntelligence), "BTC", 200, 250)
+			require.NotNil(t, order)
+			assert.Equal(t, shared.OrderTypeLimit, order.Type)
+			assert.InDelta(t, 200, order.Price, 200*zeroIntelligenceRange)
+			assert.GreaterOrEqual(t, order.Quantity, size*0.5)
+			assert.Less(t, order.Quantity, size*1.5)
+			sides[order.Side]++
+		}
+		assert.Positive(t, sides[shared.OrderSideBuy])
+		assert.Positive(t, sides[shared.OrderSideSell])
+	})
+
+	t.Run("noise traders send small market orders", func(t *testing.T) {
+		order := trader.decide(testAgent(config.AgentNoise), "BTC", 200, 250)
+		require.NotNil(t, order)
+		assert.Equal(t, shared.OrderTypeMarket, order.Type)
+		assert.Zero(t, order.Price)
+		assert.Equal(t, size*noiseSizeFactor, order.Quantity)
+	})
+
+	fundamentalTests := []struct {
+		name        string
+		fundamental float64
+		side        shared.OrderSide
+	}{
+		{"fundamental traders buy an underpriced symbol", 201, shared.OrderSideBuy},
+		{"fundamental traders sell an overpriced symbol", 199, shared.OrderSideSell},
+		{"fundamental traders pass on a fairly priced symbol", 200 * (1 + fundamentalThreshold/2), ""},
+		{"fundamental traders pass on a slightly overpriced symbol", 200 * (1 - fundamentalThreshold/2), ""},
+	}
+	for _, tt := range fundamentalTests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := trader.decide(testAgent(config.AgentFundamental), "BTC", 200, tt.fundamental)
+			if tt.side == "" {
+				assert.Nil(t, order)
+				return
+			}
+			require.NotNil(t, order)
+			assert.Equal(t, shared.OrderTypeLimit, order.Type)
+			assert.Equal(t, tt.side, order.Side)
+			assert.Equal(t, tt.fundamental, order.Price)
+			assert.Equal(t, size, order.Quantity)
+		})
+	}
+
+	t.Run("market makers and unknown agents do not trade here", func(t *testing.T) {
+		assert.Nil(t, trader.decide(testAgent(config.AgentMarketMaker), "BTC", 200, 250))
+		assert.Nil(t, trader.decide(testAgent("whale"), "BTC", 200, 250))
+	})
+}
+
+func TestAgentTrader_DecideKeepsTheAgentsAccount(t *testing.T) {
+	trader := newTestAgentTrader(config.AgentsConfig{})
+	agent := testAgent(config.AgentNoise)
+
+	first := trader.decide(agent, "BTC", 200, 200)
+	require.NotNil(t, first)
+	assert.Equal(t, first.ID, first.ClientOrderID)
+	assert.Equal(t, shared.OrderStatusPending, first.Status)
+
+	for i := 0; i < 20; i++ {
+		order := trader.decide(agent, "ETH", 200, 200)
+		require.NotNil(t, order)
+		assert.Equal(t, first.UserID, order.UserID)
+		assert.NotEqual(t, first.ID, order.ID)
+	}
+}
+
+func TestAgentTrader_Reference(t *testing.T) {
+	book := func(bid, ask float64) *OrderBookSnapshot {
+		snapshot := &OrderBookSnapshot{}
+		if bid > 0 {
+			snapshot.Bids = []OrderBookLevel{{Price: bid - 1, Quantity: 1}, {Price: bid, Quantity: 1}}
+		}
+		if ask > 0 {
+			snapshot.Asks = []OrderBookLevel{{Price: ask + 1, Quantity: 1}, {Price: ask, Quantity: 1}}
+		}
+		return snapshot
+	}
+
+	tests := []struct {
+		name        string
+		book        *OrderBookSnapshot
+		lastPrice   float64
+		fundamental float64
+		want        float64
+	}{
+		{"mid of a two sided book", book(99, 101), 120, 130, 100},
+		{"last trade without asks", book(99, 0), 120, 130, 120},
+		{"last trade without bids", book(0, 101), 120, 130, 120},
+		{"last trade on a crossed book", book(101, 99), 120, 130, 120},
+		{"fundamental before the first trade", book(0, 0), 0, 130, 130},
+		{"default price before anything", book(0, 0), 0, 0, 45000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trader := newTestAgentTrader(config.AgentsConfig{})
+			if tt.lastPrice > 0 {
+				trader.userSimulator.OnPriceUpdate("BTC", tt.lastPrice)
+			}
+			if tt.fundamental > 0 {
+				trader.fundamentals["BTC"] = tt.fundamental
+			}
+			assert.Equal(t, tt.want, trader.reference("BTC", tt.book))
+		})
+	}
+
+	// Symbols without a default price start at 100
+	trader := newTestAgentTrader(config.AgentsConfig{})
+	assert.Equal(t, 100.0, trader.reference("XYZ", book(0, 0)))
+}
+
+func TestAgentTrader_EvolveFundamental(t *testing.T) {
+	cfg := config.AgentsConfig{TickInterval: time.Second, FundamentalVolatility: 0.4}
+	trader := newTestAgentTrader(cfg)
+
+	// The first tick starts the walk at the reference
+	assert.Equal(t, 100.0, trader.evolveFundamental("BTC", 100))
+	assert.Equal(t, 100.0, trader.Fundamentals()["BTC"])
+
+	// Later ticks step a driftless geometric random walk and ignore the
+	// reference
+	random := rand.New(rand.NewSource(1))
+	dt := time.Second.Hours() / (365 * 24)
+	want := 100.0
+	for i := 0; i < 10; i++ {
+		want *= math.Exp(0.4*math.Sqrt(dt)*random.NormFloat64() - 0.4*0.4*dt/2)
+		assert.InDelta(t, want, trader.evolveFundamental("BTC", 500), 1e-9)
+	}
+	assert.InDelta(t, want, trader.Fundamentals()["BTC"], 1e-9)
+
+	// Symbols walk independently
+	assert.Equal(t, 3000.0, trader.evolveFundamental("ETH", 3000))
+}
+
+func TestAgentTrader_EvolveFundamentalWithoutVolatility(t *testing.T) {
+	trader := newTestAgentTrader(config.AgentsConfig{TickInterval: time.Second})
+
+	trader.evolveFundamental("BTC", 100)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, 100.0, trader.evolveFundamental("BTC", 120))
+	}
+}
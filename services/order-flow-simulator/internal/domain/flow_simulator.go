@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
 	"simulated_exchange/pkg/messaging"
 	"simulated_exchange/pkg/shared"
 )
@@ -14,6 +16,7 @@ import (
 // FlowSimulatorStatus represents the current status of the flow simulator
 type FlowSimulatorStatus struct {
 	IsRunning       bool                       `json:"is_running"`
+	Mode            string                     `json:"mode"`
 	StartTime       time.Time                  `json:"start_time"`
 	OrdersGenerated int64                      `json:"orders_generated"`
 	OrdersSubmitted int64                      `json:"orders_submitted"`
@@ -21,6 +24,8 @@ type FlowSimulatorStatus struct {
 	ActiveUsers     int                        `json:"active_users"`
 	SymbolStats     map[string]SymbolFlowStats `json:"symbol_stats"`
 	LastUpdate      time.Time                  `json:"last_update"`
+	Fundamentals    map[string]float64         `json:"fundamentals,omitempty"`
+	MarketMakers    []MarketMakerStatus        `json:"market_makers,omitempty"`
 }
 
 // SymbolFlowStats tracks statistics for a specific trading symbol
@@ -37,9 +42,13 @@ type FlowSimulator struct {
 	orderGenerator   *OrderGenerator
 	userSimulator    *UserSimulator
 	tradingAPIClient *TradingAPIClient
+	positionKeeper   *PositionKeeper
+	agentTrader      *AgentTrader
+	marketMakers     []*MarketMaker
 	eventBus         *messaging.RedisEventBus
 	logger           *slog.Logger
 	adaptiveThrottle *AdaptiveThrottle
+	clock            clock.Clock
 
 	// State management
 	isRunning     bool
@@ -58,13 +67,18 @@ type FlowSimulator struct {
 	orderSubmissionInterval time.Duration
 	statisticsUpdateInterval time.Duration
 	maxOrdersPerSecond      float64
+	quoteInterval           time.Duration
 }
 
-// NewFlowSimulator creates a new flow simulator
+// NewFlowSimulator creates a new flow simulator. With an agent trader the
+// trading agents place all orders; without one orders are generated around
+// the published market prices.
 func NewFlowSimulator(
 	orderGenerator *OrderGenerator,
 	userSimulator *UserSimulator,
 	tradingAPIClient *TradingAPIClient,
+	positionKeeper *PositionKeeper,
+	agentTrader *AgentTrader,
 	eventBus *messaging.RedisEventBus,
 	logger *slog.Logger,
 ) *FlowSimulator {
@@ -75,9 +89,12 @@ func NewFlowSimulator(
 		orderGenerator:           orderGenerator,
 		userSimulator:            userSimulator,
 		tradingAPIClient:         tradingAPIClient,
+		positionKeeper:           positionKeeper,
+		agentTrader:              agentTrader,
 		eventBus:                 eventBus,
 		logger:                   logger,
 		adaptiveThrottle:         adaptiveThrottle,
+		clock:                    clock.Real(),
 		symbolStats:              make(map[string]*SymbolFlowStats),
 		orderSubmissionInterval:  1000 * time.Millisecond, // Every 1 second
 		statisticsUpdateInterval: 60 * time.Second,
@@ -85,6 +102,19 @@ func NewFlowSimulator(
 	}
 }
 
+// SetMarketMakers sets the market makers that quote every symbol each
+// interval. It must be called before Start.
+func (fs *FlowSimulator) SetMarketMakers(makers []*MarketMaker, interval time.Duration) {
+	fs.marketMakers = makers
+	fs.quoteInterval = interval
+}
+
+// SetClock sets the clock orders, agents and quotes are scheduled on. It
+// must be called before Start.
+func (fs *FlowSimulator) SetClock(clk clock.Clock) {
+	fs.clock = clk
+}
+
 // Start begins the order flow simulation
 func (fs *FlowSimulator) Start(ctx context.Context) error {
 	fs.statusMutex.Lock()
@@ -99,7 +129,7 @@ func (fs *FlowSimulator) Start(ctx context.Context) error {
 	// Create context for the simulation
 	fs.ctx, fs.cancel = context.WithCancel(ctx)
 	fs.isRunning = true
-	fs.startTime = time.Now()
+	fs.startTime = fs.clock.Now()
 
 	// Initialize statistics
 	fs.initializeStats()
@@ -112,7 +142,17 @@ func (fs *FlowSimulator) Start(ctx context.Context) error {
 
 	// Start order generation and submission
 	fs.wg.Add(1)
-	go fs.runOrderGenerationLoop()
+	if fs.agentTrader != nil {
+		go fs.runAgentLoop()
+	} else {
+		go fs.runOrderGenerationLoop()
+	}
+
+	// Start market maker quoting
+	if len(fs.marketMakers) > 0 {
+		fs.wg.Add(1)
+		go fs.runMarketMakerLoop()
+	}
 
 	// Start statistics collection
 	fs.wg.Add(1)
@@ -156,7 +196,15 @@ func (fs *FlowSimulator) GetStatus() FlowSimulatorStatus {
 	// Create a copy of the stats
 	status := fs.stats
 	status.ActiveUsers = fs.userSimulator.GetActiveUserCount()
-	status.LastUpdate = time.Now()
+	status.LastUpdate = fs.clock.Now()
+	status.Mode = config.SimulationModeOracle
+	if fs.agentTrader != nil {
+		status.Mode = config.SimulationModeAgents
+		status.Fundamentals = fs.agentTrader.Fundamentals()
+	}
+	for _, maker := range fs.marketMakers {
+		status.MarketMakers = append(status.MarketMakers, maker.Status())
+	}
 
 	// Copy symbol stats
 	status.SymbolStats = make(map[string]SymbolFlowStats)
@@ -205,7 +253,7 @@ func (fs *FlowSimulator) initializeStats() {
 		OrdersFailed:    0,
 		ActiveUsers:     0,
 		SymbolStats:     make(map[string]SymbolFlowStats),
-		LastUpdate:      time.Now(),
+		LastUpdate:      fs.clock.Now(),
 	}
 
 	for _, symbol := range symbols {
@@ -222,7 +270,7 @@ func (fs *FlowSimulator) initializeStats() {
 func (fs *FlowSimulator) runOrderGenerationLoop() {
 	defer fs.wg.Done()
 
-	ticker := time.NewTicker(fs.orderSubmissionInterval)
+	ticker := fs.clock.NewTicker(fs.orderSubmissionInterval)
 	defer ticker.Stop()
 
 	symbols := fs.orderGenerator.GetSupportedSymbols()
@@ -231,7 +279,7 @@ func (fs *FlowSimulator) runOrderGenerationLoop() {
 		select {
 		case <-fs.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			// Generate orders for each symbol based on their rates
 			for _, symbol := range symbols {
 				fs.processSymbolOrders(symbol)
@@ -240,6 +288,93 @@ func (fs *FlowSimulator) runOrderGenerationLoop() {
 	}
 }
 
+// runAgentLoop lets the trading agents act in every symbol each tick
+func (fs *FlowSimulator) runAgentLoop() {
+	defer fs.wg.Done()
+
+	ticker := fs.clock.NewTicker(fs.agentTrader.TickInterval())
+	defer ticker.Stop()
+
+	symbols := fs.orderGenerator.GetSupportedSymbols()
+
+	for {
+		select {
+		case <-fs.ctx.Done():
+			return
+		case <-ticker.C():
+			for _, symbol := range symbols {
+				fs.processAgentOrders(symbol)
+			}
+		}
+	}
+}
+
+func (fs *FlowSimulator) processAgentOrders(symbol string) {
+	orders, err := fs.agentTrader.Tick(fs.ctx, symbol)
+	if err != nil {
+		fs.logger.Warn("Agent tick failed", "error", err, "symbol", symbol)
+		fs.adaptiveThrottle.RecordError()
+		return
+	}
+	if len(orders) == 0 {
+		return
+	}
+
+	for range orders {
+		fs.incrementStat("orders_generated")
+		fs.incrementSymbolStat(symbol, "orders_generated")
+	}
+
+	// Submit in the loop so a symbol's next tick sees the book these
+	// orders leave behind
+	fs.submitOrderBatch(orders)
+}
+
+// runMarketMakerLoop keeps the market makers' quotes in every symbol up to
+// date with the order book
+func (fs *FlowSimulator) runMarketMakerLoop() {
+	defer fs.wg.Done()
+
+	ticker := fs.clock.NewTicker(fs.quoteInterval)
+	defer ticker.Stop()
+
+	symbols := fs.orderGenerator.GetSupportedSymbols()
+
+	for {
+		select {
+		case <-fs.ctx.Done():
+			return
+		case <-ticker.C():
+			for _, symbol := range symbols {
+				fs.processMarketMakerQuotes(symbol)
+			}
+		}
+	}
+}
+
+func (fs *FlowSimulator) processMarketMakerQuotes(symbol string) {
+	book, err := fs.tradingAPIClient.GetOrderBook(fs.ctx, symbol)
+	if err != nil {
+		fs.logger.Warn("Failed to get order book for market makers", "error", err, "symbol", symbol)
+		fs.adaptiveThrottle.RecordError()
+		return
+	}
+
+	var orders []*shared.Order
+	for _, maker := range fs.marketMakers {
+		orders = append(orders, maker.Requote(fs.ctx, symbol, book)...)
+	}
+	if len(orders) == 0 {
+		return
+	}
+
+	for range orders {
+		fs.incrementStat("orders_generated")
+		fs.incrementSymbolStat(symbol, "orders_generated")
+	}
+	fs.submitOrderBatch(orders)
+}
+
 func (fs *FlowSimulator) processSymbolOrders(symbol string) {
 	// Generate multiple orders per symbol based on rate with realistic variance
 	baseOrdersPerTick := int(fs.adaptiveThrottle.GetCurrentRate() * fs.orderSubmissionInterval.Seconds() / float64(len(fs.orderGenerator.GetSupportedSymbols())))
@@ -248,6 +383,9 @@ func (fs *FlowSimulator) processSymbolOrders(symbol string) {
 		baseOrdersPerTick = 1
 	}
 
+	// Follow the session, weekday and holiday profile
+	baseOrdersPerTick = fs.orderGenerator.SeasonalOrderCount(baseOrdersPerTick)
+
 	// Add realistic variance to order generation
 	// 70% normal traffic, 20% burst (2-5x), 10% quiet (0.1-0.5x)
 	ordersPerTick := baseOrdersPerTick
@@ -278,9 +416,14 @@ func (fs *FlowSimulator) generateAndSubmitOrder(symbol string) {
 		currentPrice = marketState.CurrentPrice
 	}
 
-	// Select user behavior type (this would normally come from active users)
+	// Trade on behalf of an active simulated user, falling back to the
+	// default behavior mix while no users are active
+	session, hasSession := fs.userSimulator.PickActiveSession()
 	userTypes := []string{"conservative", "aggressive", "momentum"}
 	userType := userTypes[len(userTypes)%3] // Simple rotation
+	if hasSession {
+		userType = session.Behavior.Type
+	}
 
 	// Generate order
 	order, err := fs.orderGenerator.GenerateOrder(fs.ctx, userType, symbol, currentPrice)
@@ -291,6 +434,16 @@ func (fs *FlowSimulator) generateAndSubmitOrder(symbol string) {
 		return
 	}
 
+	// Attribute fills of the order to the simulated user for P&L tracking
+	if order.ClientOrderID == "" {
+		order.ClientOrderID = order.ID
+	}
+	simulatedUserID := order.UserID
+	if hasSession {
+		simulatedUserID = session.UserID
+	}
+	fs.positionKeeper.TrackOrder(order.ClientOrderID, simulatedUserID, userType)
+
 	fs.incrementStat("orders_generated")
 	fs.incrementSymbolStat(symbol, "orders_generated")
 
@@ -334,26 +487,52 @@ func (fs *FlowSimulator) submitOrder(order *shared.Order) {
 	)
 }
 
-// submitOrderBatch submits multiple orders in a batch for better performance
+// submitOrderBatch submits buffered orders with a single batch request,
+// falling back to individual submissions if the batch request fails
 func (fs *FlowSimulator) submitOrderBatch(orders []*shared.Order) {
-	for _, order := range orders {
-		// Add small delay between order submissions in batch
-		time.Sleep(fs.adaptiveThrottle.GetThrottleDelay())
-		fs.submitOrder(order)
+	errs, err := fs.tradingAPIClient.SubmitOrderBatch(fs.ctx, orders)
+	if err != nil {
+		fs.logger.Warn("Batch submission failed, submitting orders individually",
+			"error", err,
+			"orders", len(orders),
+		)
+		for _, order := range orders {
+			// Add small delay between order submissions in batch
+			time.Sleep(fs.adaptiveThrottle.GetThrottleDelay())
+			fs.submitOrder(order)
+		}
+		return
+	}
+
+	for i, order := range orders {
+		if errs[i] != nil {
+			fs.logger.Error("Failed to submit order",
+				"error", errs[i],
+				"order_id", order.ID,
+				"symbol", order.Symbol,
+			)
+			fs.adaptiveThrottle.RecordError()
+			fs.incrementStat("orders_failed")
+			continue
+		}
+
+		fs.adaptiveThrottle.RecordSuccess()
+		fs.incrementStat("orders_submitted")
+		fs.incrementSymbolStat(order.Symbol, "orders_submitted")
 	}
 }
 
 func (fs *FlowSimulator) runStatisticsLoop() {
 	defer fs.wg.Done()
 
-	ticker := time.NewTicker(fs.statisticsUpdateInterval)
+	ticker := fs.clock.NewTicker(fs.statisticsUpdateInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-fs.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			fs.updateStatistics()
 		}
 	}
@@ -363,7 +542,7 @@ func (fs *FlowSimulator) updateStatistics() {
 	fs.statsMutex.Lock()
 	defer fs.statsMutex.Unlock()
 
-	fs.stats.LastUpdate = time.Now()
+	fs.stats.LastUpdate = fs.clock.Now()
 	fs.stats.ActiveUsers = fs.userSimulator.GetActiveUserCount()
 
 	// Update order rates for symbols
@@ -444,6 +623,6 @@ func (fs *FlowSimulator) incrementSymbolStat(symbol string, statName string) {
 		stats.OrdersGenerated++
 	case "orders_submitted":
 		stats.OrdersSubmitted++
-		stats.LastOrderTime = time.Now()
+		stats.LastOrderTime = fs.clock.Now()
 	}
 }
\ No newline at end of file
@@ -0,0 +1,391 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"simulated_exchange/pkg/shared"
+)
+
+// The manipulator accounts share a beneficial owner (see docker/init-db.sql).
+// Every pattern is completed by manipulator1, so the alerts it raises are
+// attributed to that account.
+const (
+	ManipulatorUserID       = "5b0c7e1a-6d2f-4c8e-9a3b-1f4e2d6c8a01"
+	ManipulatorAccompliceID = "5b0c7e1a-6d2f-4c8e-9a3b-1f4e2d6c8a02"
+)
+
+const (
+	// injectionTick is how often the injector decides whether to inject
+	injectionTick = time.Second
+
+	// detectionWindow is how long after an injection a matching alert counts
+	// as detecting it
+	detectionWindow = 30 * time.Second
+
+	// maxInjectionHistory bounds the injections kept for the detection report
+	maxInjectionHistory = 1000
+
+	// Pattern shapes: bait orders rest restingStepBps apart below the market,
+	// the spoof order is spoofSizeMultiple typical orders in size, layering
+	// rests layeringLevels orders and momentum ignition takes liquidity
+	// momentumTrades times with orders momentumSizeFactor times typical size
+	restingStepBps     = 10
+	spoofSizeMultiple  = 20
+	layeringLevels     = 4
+	momentumTrades     = 4
+	momentumSizeFactor = 3
+)
+
+// ManipulationTypes are the manipulative behaviours the injector can perform
+var ManipulationTypes = []shared.AlertType{
+	shared.AlertTypeWashTrade,
+	shared.AlertTypeSpoofing,
+	shared.AlertTypeLayering,
+	shared.AlertTypeMomentumIgnition,
+}
+
+// Injection records one injected manipulation
+type Injection struct {
+	ID         string           `json:"id"`
+	Type       shared.AlertType `json:"type"`
+	Symbol     string           `json:"symbol"`
+	UserID     string           `json:"user_id"`
+	InjectedAt time.Time        `json:"injected_at"`
+	Error      string           `json:"error,omitempty"`
+	Detected   bool             `json:"detected"`
+	AlertID    string           `json:"alert_id,omitempty"`
+}
+
+// DetectionStats summarises how many injections of one type were detected
+type DetectionStats struct {
+	Type          shared.AlertType `json:"type"`
+	Injected      int              `json:"injected"`
+	Failed        int              `json:"failed"`
+	Detected      int              `json:"detected"`
+	DetectionRate float64          `json:"detection_rate"`
+}
+
+// DetectionReport compares the injected manipulations with the alerts
+// raised by the exchange's surveillance
+type DetectionReport struct {
+	GeneratedAt time.Time                            `json:"generated_at"`
+	Rates       map[shared.AlertType]float64         `json:"rates_per_minute"`
+	ByType      map[shared.AlertType]*DetectionStats `json:"by_type"`
+	Injections  []*Injection                         `json:"injections"`
+}
+
+// ManipulationInjector deliberately performs manipulative trading through
+// the manipulator accounts so surveillance detection rates can be measured.
+// Injection is off until a rate is set for a behaviour.
+type ManipulationInjector struct {
+	orderGenerator   *OrderGenerator
+	userSimulator    *UserSimulator
+	tradingAPIClient *TradingAPIClient
+	logger           *slog.Logger
+
+	rates      map[shared.AlertType]float64
+	injections []*Injection
+	random     *rand.Rand
+	mutex      sync.Mutex
+}
+
+// NewManipulationInjector creates a new manipulation injector
+func NewManipulationInjector(
+	orderGenerator *OrderGenerator,
+	userSimulator *UserSimulator,
+	tradingAPIClient *TradingAPIClient,
+	logger *slog.Logger,
+) *ManipulationInjector {
+	return &ManipulationInjector{
+		orderGenerator:   orderGenerator,
+		userSimulator:    userSimulator,
+		tradingAPIClient: tradingAPIClient,
+		logger:           logger,
+		rates:            make(map[shared.AlertType]float64),
+		random:           rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetRate sets how many manipulations of a type are injected per minute.
+// A zero rate turns the behaviour off.
+func (mi *ManipulationInjector) SetRate(manipulation shared.AlertType, perMinute float64) error {
+	if !isManipulationType(manipulation) {
+		return fmt.Errorf("unknown manipulation type %q", manipulation)
+	}
+	if perMinute < 0 || perMinute > 60 {
+		return fmt.Errorf("rate must be between 0 and 60 per minute")
+	}
+
+	mi.mutex.Lock()
+	defer mi.mutex.Unlock()
+
+	if perMinute == 0 {
+		delete(mi.rates, manipulation)
+	} else {
+		mi.rates[manipulation] = perMinute
+	}
+	return nil
+}
+
+// Run injects manipulations at the configured rates until the context is
+// cancelled
+func (mi *ManipulationInjector) Run(ctx context.Context) {
+	ticker := time.NewTicker(injectionTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, manipulation := range mi.due() {
+			mi.Inject(ctx, manipulation, "")
+		}
+	}
+}
+
+// Inject performs one manipulation of a type in a symbol, or in a random
+// symbol when none is given, and records it for the detection report
+func (mi *ManipulationInjector) Inject(ctx context.Context, manipulation shared.AlertType, symbol string) (*Injection, error) {
+	if !isManipulationType(manipulation) {
+		return nil, fmt.Errorf("unknown manipulation type %q", manipulation)
+	}
+
+	if symbol == "" {
+		symbols := mi.orderGenerator.GetSupportedSymbols()
+		mi.mutex.Lock()
+		symbol = symbols[mi.random.Intn(len(symbols))]
+		mi.mutex.Unlock()
+	}
+
+	injection := &Injection{
+		ID:         uuid.New().String(),
+		Type:       manipulation,
+		Symbol:     symbol,
+		UserID:     ManipulatorUserID,
+		InjectedAt: time.Now(),
+	}
+
+	var err error
+	switch manipulation {
+	case shared.AlertTypeWashTrade:
+		err = mi.washTrade(ctx, symbol)
+	case shared.AlertTypeSpoofing:
+		err = mi.spoof(ctx, symbol)
+	case shared.AlertTypeLayering:
+		err = mi.layer(ctx, symbol)
+	case shared.AlertTypeMomentumIgnition:
+		err = mi.igniteMomentum(ctx, symbol)
+	}
+	if err != nil {
+		injection.Error = err.Error()
+		mi.logger.Warn("Manipulation injection failed", "type", manipulation, "symbol", symbol, "error", err)
+	} else {
+		mi.logger.Info("Manipulation injected", "type", manipulation, "symbol", symbol)
+	}
+
+	mi.mutex.Lock()
+	mi.injections = append(mi.injections, injection)
+	if len(mi.injections) > maxInjectionHistory {
+		mi.injections = mi.injections[len(mi.injections)-maxInjectionHistory:]
+	}
+	mi.mutex.Unlock()
+
+	return injection, err
+}
+
+// GetDetectionReport matches the recorded injections with the surveillance
+// alerts raised for the manipulator account. An injection is detected by an
+// alert of its type in its symbol raised within the detection window; each
+// alert detects at most one injection.
+func (mi *ManipulationInjector) GetDetectionReport(ctx context.Context) (*DetectionReport, error) {
+	mi.mutex.Lock()
+	injections := make([]*Injection, len(mi.injections))
+	for i, injection := range mi.injections {
+		copied := *injection
+		copied.Detected, copied.AlertID = false, ""
+		injections[i] = &copied
+	}
+	rates := make(map[shared.AlertType]float64, len(mi.rates))
+	for manipulation, rate := range mi.rates {
+		rates[manipulation] = rate
+	}
+	mi.mutex.Unlock()
+
+	report := &DetectionReport{
+		GeneratedAt: time.Now(),
+		Rates:       rates,
+		ByType:      make(map[shared.AlertType]*DetectionStats, len(ManipulationTypes)),
+		Injections:  injections,
+	}
+	for _, manipulation := range ManipulationTypes {
+		report.ByType[manipulation] = &DetectionStats{Type: manipulation}
+	}
+	if len(injections) == 0 {
+		return report, nil
+	}
+
+	alerts, err := mi.tradingAPIClient.GetSurveillanceAlerts(ctx, ManipulatorUserID, injections[0].InjectedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get surveillance alerts: %w", err)
+	}
+
+	matched := make(map[string]bool, len(alerts))
+	for _, injection := range injections {
+		stats := report.ByType[injection.Type]
+		if injection.Error != "" {
+			stats.Failed++
+			continue
+		}
+		stats.Injected++
+
+		for _, alert := range alerts {
+			if matched[alert.ID] || alert.Type != injection.Type || alert.Symbol != injection.Symbol ||
+				alert.UserID != injection.UserID || alert.DetectedAt.Before(injection.InjectedAt) ||
+				alert.DetectedAt.After(injection.InjectedAt.Add(detectionWindow)) {
+				continue
+			}
+			matched[alert.ID] = true
+			injection.Detected, injection.AlertID = true, alert.ID
+			stats.Detected++
+			break
+		}
+	}
+
+	for _, stats := range report.ByType {
+		if stats.Injected > 0 {
+			stats.DetectionRate = float64(stats.Detected) / float64(stats.Injected)
+		}
+	}
+
+	return report, nil
+}
+
+// due returns the manipulations to inject this tick
+func (mi *ManipulationInjector) due() []shared.AlertType {
+	mi.mutex.Lock()
+	defer mi.mutex.Unlock()
+
+	var due []shared.AlertType
+	for _, manipulation := range ManipulationTypes {
+		rate := mi.rates[manipulation]
+		if rate > 0 && mi.random.Float64() < rate*injectionTick.Minutes() {
+			due = append(due, manipulation)
+		}
+	}
+	return due
+}
+
+// washTrade has the accomplice rest a sell at the market price and the
+// manipulator buy it, so both sides of the trade have the same owner
+func (mi *ManipulationInjector) washTrade(ctx context.Context, symbol string) error {
+	price, quantity := mi.market(symbol)
+
+	if _, err := mi.submit(ctx, ManipulatorAccompliceID, symbol, shared.OrderTypeLimit, shared.OrderSideSell, quantity, price); err != nil {
+		return err
+	}
+	_, err := mi.submit(ctx, ManipulatorUserID, symbol, shared.OrderTypeLimit, shared.OrderSideBuy, quantity, price)
+	return err
+}
+
+// spoof rests a large bid just below the market to suggest buying interest,
+// cancels it and sells into the bids
+func (mi *ManipulationInjector) spoof(ctx context.Context, symbol string) error {
+	price, quantity := mi.market(symbol)
+
+	spoofID, err := mi.submit(ctx, ManipulatorUserID, symbol, shared.OrderTypeLimit, shared.OrderSideBuy,
+		quantity*spoofSizeMultiple, price*(1-restingStepBps/10000.0))
+	if err != nil {
+		return err
+	}
+	if err := mi.tradingAPIClient.CancelOrderByClientID(ctx, ManipulatorUserID, spoofID); err != nil {
+		return err
+	}
+
+	_, err = mi.submit(ctx, ManipulatorUserID, symbol, shared.OrderTypeMarket, shared.OrderSideSell, quantity, 0)
+	return err
+}
+
+// layer rests bids at several price levels below the market, cancels them
+// and sells into the bids
+func (mi *ManipulationInjector) layer(ctx context.Context, symbol string) error {
+	price, quantity := mi.market(symbol)
+
+	layerIDs := make([]string, 0, layeringLevels)
+	for level := 1; level <= layeringLevels; level++ {
+		levelPrice := price * (1 - float64(level*restingStepBps)/10000)
+		layerID, err := mi.submit(ctx, ManipulatorUserID, symbol, shared.OrderTypeLimit, shared.OrderSideBuy, quantity, levelPrice)
+		if err != nil {
+			return err
+		}
+		layerIDs = append(layerIDs, layerID)
+	}
+	for _, layerID := range layerIDs {
+		if err := mi.tradingAPIClient.CancelOrderByClientID(ctx, ManipulatorUserID, layerID); err != nil {
+			return err
+		}
+	}
+
+	_, err := mi.submit(ctx, ManipulatorUserID, symbol, shared.OrderTypeMarket, shared.OrderSideSell, quantity, 0)
+	return err
+}
+
+// igniteMomentum buys aggressively several times to push the price up and
+// then sells into the move
+func (mi *ManipulationInjector) igniteMomentum(ctx context.Context, symbol string) error {
+	_, quantity := mi.market(symbol)
+
+	for i := 0; i < momentumTrades; i++ {
+		if _, err := mi.submit(ctx, ManipulatorUserID, symbol, shared.OrderTypeMarket, shared.OrderSideBuy, quantity*momentumSizeFactor, 0); err != nil {
+			return err
+		}
+	}
+
+	_, err := mi.submit(ctx, ManipulatorUserID, symbol, shared.OrderTypeMarket, shared.OrderSideSell, quantity*momentumSizeFactor, 0)
+	return err
+}
+
+// market returns the current price of a symbol and a typical order quantity
+// at that price
+func (mi *ManipulationInjector) market(symbol string) (float64, float64) {
+	price := mi.userSimulator.getDefaultPrice(symbol)
+	if state, exists := mi.userSimulator.GetMarketState(symbol); exists && state.CurrentPrice > 0 {
+		price = state.CurrentPrice
+	}
+	return price, typicalQuantity(price)
+}
+
+// submit places an order for a manipulator account and returns its client
+// order ID
+func (mi *ManipulationInjector) submit(ctx context.Context, userID, symbol string, orderType shared.OrderType, side shared.OrderSide, quantity, price float64) (string, error) {
+	order := &shared.Order{
+		ID:       uuid.New().String(),
+		UserID:   userID,
+		Symbol:   symbol,
+		Type:     orderType,
+		Side:     side,
+		Quantity: quantity,
+		Price:    price,
+	}
+	if err := mi.tradingAPIClient.SubmitOrder(ctx, order); err != nil {
+		return "", err
+	}
+	return order.ID, nil
+}
+
+func isManipulationType(manipulation shared.AlertType) bool {
+	for _, known := range ManipulationTypes {
+		if known == manipulation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// MarketMakerQuote is a market maker's current quote in one symbol. Prices
+// of sides it doesn't quote, because its inventory is at the limit, are 0.
+type MarketMakerQuote struct {
+	Symbol           string    `json:"symbol"`
+	Inventory        float64   `json:"inventory"`
+	MidPrice         float64   `json:"mid_price"`
+	ReservationPrice float64   `json:"reservation_price"`
+	Spread           float64   `json:"spread"`
+	VolatilityIndex  float64   `json:"volatility_index"`
+	BidPrice         float64   `json:"bid_price"`
+	AskPrice         float64   `json:"ask_price"`
+	QuoteSize        float64   `json:"quote_size"`
+	Requotes         int64     `json:"requotes"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// MarketMakerStatus reports a market maker's quotes, inventory and P&L
+type MarketMakerStatus struct {
+	UserID string             `json:"user_id"`
+	PnL    *UserPnL           `json:"pnl,omitempty"`
+	Quotes []MarketMakerQuote `json:"quotes"`
+}
+
+// restingQuote is one side of a market maker's quote resting in the book
+type restingQuote struct {
+	clientOrderID string
+	price         float64
+}
+
+// marketMakerSymbol is a market maker's state in one symbol
+type marketMakerSymbol struct {
+	quote    MarketMakerQuote
+	bid, ask *restingQuote
+
+	// The best prices of other traders when the quote was placed
+	bestBid, bestAsk float64
+}
+
+// MarketMaker is a market making agent that keeps two-sided quotes in every
+// symbol following Avellaneda-Stoikov. It quotes around a reservation price
+// shifted against its inventory, r = s(1 - qγσ²), with a spread of
+// s(γσ² + (2/γ)ln(1 + γ/k)), where s is the market price, q its inventory
+// in quote sizes, γ its risk aversion and k the order arrival decay. σ
+// scales with the volatility index, so spreads widen in volatile markets.
+// Quotes are cancelled and replaced when its inventory changes or other
+// traders' best prices or the target quote move by more than a quarter of
+// the spread.
+type MarketMaker struct {
+	userID    string
+	accountID string
+	config    config.MarketMakerAgentConfig
+
+	tradingAPIClient *TradingAPIClient
+	positionKeeper   *PositionKeeper
+	userSimulator    *UserSimulator
+	logger           *slog.Logger
+
+	symbols map[string]*marketMakerSymbol
+	mutex   sync.Mutex
+}
+
+// NewMarketMakers creates a market maker for every market maker agent
+// session, each trading through its own exchange account
+func NewMarketMakers(
+	cfg config.MarketMakerAgentConfig,
+	orderGenerator *OrderGenerator,
+	userSimulator *UserSimulator,
+	tradingAPIClient *TradingAPIClient,
+	positionKeeper *PositionKeeper,
+	logger *slog.Logger,
+) []*MarketMaker {
+	var makers []*MarketMaker
+	for _, session := range userSimulator.GetAgentSessions() {
+		if session.Behavior.Type != config.AgentMarketMaker {
+			continue
+		}
+		makers = append(makers, &MarketMaker{
+			userID:           session.UserID,
+			accountID:        orderGenerator.generateUserID(),
+			config:           cfg,
+			tradingAPIClient: tradingAPIClient,
+			positionKeeper:   positionKeeper,
+			userSimulator:    userSimulator,
+			logger:           logger,
+			symbols:          make(map[string]*marketMakerSymbol),
+		})
+	}
+
+	sort.Slice(makers, func(i, j int) bool { return makers[i].userID < makers[j].userID })
+	return makers
+}
+
+// UserID returns the simulated user ID of the market maker
+func (mm *MarketMaker) UserID() string {
+	return mm.userID
+}
+
+// Requote updates the market maker's quote in a symbol from the order book
+// and returns the orders of its new quote. It returns no orders when the
+// resting quote still stands; otherwise the resting quote is cancelled
+// first.
+func (mm *MarketMaker) Requote(ctx context.Context, symbol string, book *OrderBookSnapshot) []*shared.Order {
+	mm.mutex.Lock()
+	state, exists := mm.symbols[symbol]
+	if !exists {
+		state = &marketMakerSymbol{quote: MarketMakerQuote{Symbol: symbol}}
+		mm.symbols[symbol] = state
+	}
+
+	bestBid := othersBest(book.Bids, state.bid, true)
+	bestAsk := othersBest(book.Asks, state.ask, false)
+	mid, volatilityIndex := mm.marketPrice(symbol, bestBid, bestAsk)
+	inventory := mm.positionKeeper.Position(mm.userID, symbol)
+
+	size := typicalQuantity(mid)
+	sigma := mm.config.Volatility * volatilityIndex
+	riskTerm := mm.config.RiskAversion * sigma * sigma
+	reservation := mid * (1 - inventory/size*riskTerm)
+	spread := mid * (riskTerm + 2/mm.config.RiskAversion*math.Log(1+mm.config.RiskAversion/mm.config.OrderArrivalDecay))
+
+	// Stop growing the position beyond the inventory limit
+	bid, ask := reservation-spread/2, reservation+spread/2
+	if inventory/size >= mm.config.MaxInventory || bid <= 0 {
+		bid = 0
+	}
+	if inventory/size <= -mm.config.MaxInventory {
+		ask = 0
+	}
+
+	if !mm.shouldRequote(state, bestBid, bestAsk, inventory, bid, ask, spread) {
+		mm.mutex.Unlock()
+		return nil
+	}
+
+	stale := []*restingQuote{state.bid, state.ask}
+	state.bid, state.ask = nil, nil
+	var orders []*shared.Order
+	if bid > 0 {
+		order := newAgentOrder(mm.accountID, symbol, shared.OrderTypeLimit, shared.OrderSideBuy, size, bid)
+		state.bid = &restingQuote{clientOrderID: order.ClientOrderID, price: bid}
+		orders = append(orders, order)
+	}
+	if ask > 0 {
+		order := newAgentOrder(mm.accountID, symbol, shared.OrderTypeLimit, shared.OrderSideSell, size, ask)
+		state.ask = &restingQuote{clientOrderID: order.ClientOrderID, price: ask}
+		orders = append(orders, order)
+	}
+	for _, order := range orders {
+		mm.positionKeeper.TrackOrder(order.ClientOrderID, mm.userID, config.AgentMarketMaker)
+	}
+
+	state.bestBid, state.bestAsk = bestBid, bestAsk
+	state.quote = MarketMakerQuote{
+		Symbol:           symbol,
+		Inventory:        inventory,
+		MidPrice:         mid,
+		ReservationPrice: reservation,
+		Spread:           spread,
+		VolatilityIndex:  volatilityIndex,
+		BidPrice:         bid,
+		AskPrice:         ask,
+		QuoteSize:        size,
+		Requotes:         state.quote.Requotes + 1,
+		UpdatedAt:        time.Now(),
+	}
+	mm.mutex.Unlock()
+
+	for _, quote := range stale {
+		if quote == nil {
+			continue
+		}
+		// Quotes that have traded in full can no longer be cancelled
+		if err := mm.tradingAPIClient.CancelOrderByClientID(ctx, mm.accountID, quote.clientOrderID); err != nil {
+			mm.logger.Debug("Failed to cancel market maker quote", "user_id", mm.userID, "client_order_id", quote.clientOrderID, "error", err)
+		}
+	}
+
+	mm.userSimulator.RecordAgentOrders(mm.userID, len(orders))
+	return orders
+}
+
+// Status returns the market maker's quotes, inventory and P&L
+func (mm *MarketMaker) Status() MarketMakerStatus {
+	mm.mutex.Lock()
+	quotes := make([]MarketMakerQuote, 0, len(mm.symbols))
+	for _, state := range mm.symbols {
+		quotes = append(quotes, state.quote)
+	}
+	mm.mutex.Unlock()
+
+	sort.Slice(quotes, func(i, j int) bool { return quotes[i].Symbol < quotes[j].Symbol })
+
+	status := MarketMakerStatus{UserID: mm.userID, Quotes: quotes}
+	if pnl, exists := mm.positionKeeper.GetUserPnL(mm.userID); exists {
+		status.PnL = pnl
+	}
+	return status
+}
+
+// marketPrice returns the price the market maker quotes around and the
+// volatility index: the published price when there is one, else the mid of
+// other traders' best prices or a default price
+func (mm *MarketMaker) marketPrice(symbol string, bestBid, bestAsk float64) (float64, float64) {
+	volatilityIndex := 1.0
+	state, exists := mm.userSimulator.GetMarketState(symbol)
+	if exists && state.VolatilityIndex > 0 {
+		volatilityIndex = state.VolatilityIndex
+	}
+
+	switch {
+	case exists && state.CurrentPrice > 0:
+		return state.CurrentPrice, volatilityIndex
+	case bestBid > 0 && bestAsk > bestBid:
+		return (bestBid + bestAsk) / 2, volatilityIndex
+	default:
+		return mm.userSimulator.getDefaultPrice(symbol), volatilityIndex
+	}
+}
+
+// shouldRequote reports whether the resting quote must be replaced: a side
+// is missing, its inventory changed because a quote traded, or other
+// traders' best prices or the target prices moved more than a quarter of
+// the spread. The tolerance keeps market makers from endlessly reacting to
+// each other's requotes.
+func (mm *MarketMaker) shouldRequote(state *marketMakerSymbol, bestBid, bestAsk, inventory, bid, ask, spread float64) bool {
+	if (bid > 0) != (state.bid != nil) || (ask > 0) != (state.ask != nil) || inventory != state.quote.Inventory {
+		return true
+	}
+
+	tolerance := spread / 4
+	if math.Abs(bestBid-state.bestBid) > tolerance || math.Abs(bestAsk-state.bestAsk) > tolerance {
+		return true
+	}
+	if state.bid != nil && math.Abs(state.bid.price-bid) > tolerance {
+		return true
+	}
+	return state.ask != nil && math.Abs(state.ask.price-ask) > tolerance
+}
+
+// othersBest returns the best price of a book side without the market
+// maker's own resting quote, 0 if the side is empty
+func othersBest(levels []OrderBookLevel, own *restingQuote, highest bool) float64 {
+	best := 0.0
+	for _, level := range levels {
+		if level.Price <= 0 || (own != nil && math.Abs(level.Price-own.price) <= own.price*1e-9) {
+			continue
+		}
+		if best == 0 || (highest && level.Price > best) || (!highest && level.Price < best) {
+			best = level.Price
+		}
+	}
+	return best
+}
@@ -0,0 +1,302 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+var testMarketMakerConfig = config.MarketMakerAgentConfig{
+	RiskAversion:      50,
+	OrderArrivalDecay: 2000,
+	Volatility:        0.002,
+	MaxInventory:      10,
+}
+
+// newTestMarketMaker creates a market maker whose cancellations are
+// recorded by a test trading API
+func newTestMarketMaker(t *testing.T) (*MarketMaker, *[]string) {
+	t.Helper()
+
+	var mutex sync.Mutex
+	var cancelled []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		cancelled = append(cancelled, strings.TrimPrefix(r.URL.Path, "/api/orders/client/"))
+		mutex.Unlock()
+		w.Write([]byte(`{"success": true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	orderGenerator := NewOrderGenerator(OrderGeneratorConfig{RandomSeed: 1}, logger)
+	return &MarketMaker{
+		userID:           "agent-mm",
+		accountID:        "account-mm",
+		config:           testMarketMakerConfig,
+		tradingAPIClient: NewTradingAPIClient(server.URL, logger),
+		positionKeeper:   NewPositionKeeper(logger),
+		userSimulator:    NewUserSimulator(orderGenerator, logger),
+		logger:           logger,
+		symbols:          make(map[string]*marketMakerSymbol),
+	}, &cancelled
+}
+
+// setInventory fills the market maker into a position
+func setInventory(mm *MarketMaker, symbol string, quantity float64) {
+	id := "seed-" + symbol
+	mm.positionKeeper.TrackOrder(id, mm.userID, config.AgentMarketMaker)
+	mm.positionKeeper.OnFill(Fill{ClientOrderID: id, Symbol: symbol, Buy: quantity > 0, Quantity: math.Abs(quantity), Price: 100})
+}
+
+func TestMarketMaker_Quote(t *testing.T) {
+	// With γ = 50, k = 2000 and σ = 0.002 the spread at a price of 100 is
+	// 100·(50·0.002² + (2/50)·ln(1 + 50/2000)), and a quote is 10 shares
+	const calmSpread = 0.11877045036148567
+
+	tests := []struct {
+		name            string
+		price           float64
+		volatilityIndex float64
+		book            *OrderBookSnapshot
+		inventory       float64
+		reservation     float64
+		spread          float64
+		bid             float64
+		ask             float64
+	}{
+		{
+			name:        "flat quotes around the price",
+			price:       100,
+			reservation: 100,
+			spread:      calmSpread,
+			bid:         99.94061477481925,
+			ask:         100.05938522518075,
+		},
+		{
+			name:        "long inventory lowers the reservation price",
+			price:       100,
+			inventory:   50,
+			reservation: 99.9,
+			spread:      calmSpread,
+			bid:         99.84061477481926,
+			ask:         99.95938522518075,
+		},
+		{
+			name:        "short inventory raises the reservation price",
+			price:       100,
+			inventory:   -50,
+			reservation: 100.1,
+			spread:      calmSpread,
+			bid:         100.04061477481925,
+			ask:         100.15938522518074,
+		},
+		{
+			name:            "volatility widens the spread",
+			price:           100,
+			volatilityIndex: 2,
+			reservation:     100,
+			spread:          0.17877045036148567,
+			bid:             99.91061477481925,
+			ask:             100.08938522518075,
+		},
+		{
+			name:        "long at the inventory limit stops bidding",
+			price:       100,
+			inventory:   100,
+			reservation: 99.8,
+			spread:      calmSpread,
+			ask:         99.85938522518074,
+		},
+		{
+			name:        "short at the inventory limit stops offering",
+			price:       100,
+			inventory:   -100,
+			reservation: 100.2,
+			spread:      calmSpread,
+			bid:         100.14061477481926,
+		},
+		{
+			name: "mid of other traders' best prices before a price is published",
+			book: &OrderBookSnapshot{
+				Bids: []OrderBookLevel{{Price: 98}, {Price: 99}},
+				Asks: []OrderBookLevel{{Price: 102}, {Price: 101}},
+			},
+			reservation: 100,
+			spread:      calmSpread,
+			bid:         99.94061477481925,
+			ask:         100.05938522518075,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mm, _ := newTestMarketMaker(t)
+			if tt.price > 0 {
+				mm.userSimulator.OnPriceUpdate("AAPL", tt.price)
+			}
+			if tt.volatilityIndex > 0 {
+				mm.userSimulator.OnVolatilityIndex("AAPL", tt.volatilityIndex)
+			}
+			if tt.inventory != 0 {
+				setInventory(mm, "AAPL", tt.inventory)
+			}
+			book := tt.book
+			if book == nil {
+				book = &OrderBookSnapshot{}
+			}
+
+			orders := mm.Requote(context.Background(), "AAPL", book)
+
+			quote := mm.Status().Quotes[0]
+			assert.Equal(t, tt.inventory, quote.Inventory)
+			assert.Equal(t, 100.0, quote.MidPrice)
+			assert.Equal(t, 10.0, quote.QuoteSize)
+			assert.InDelta(t, tt.reservation, quote.ReservationPrice, 1e-9)
+			assert.InDelta(t, tt.spread, quote.Spread, 1e-9)
+			assert.InDelta(t, tt.bid, quote.BidPrice, 1e-9)
+			assert.InDelta(t, tt.ask, quote.AskPrice, 1e-9)
+
+			prices := map[shared.OrderSide]float64{}
+			for _, order := range orders {
+				assert.Equal(t, "account-mm", order.UserID)
+				assert.Equal(t, shared.OrderTypeLimit, order.Type)
+				assert.Equal(t, 10.0, order.Quantity)
+				prices[order.Side] = order.Price
+			}
+			assert.InDelta(t, tt.bid, prices[shared.OrderSideBuy], 1e-9)
+			assert.InDelta(t, tt.ask, prices[shared.OrderSideSell], 1e-9)
+		})
+	}
+}
+
+func TestMarketMaker_RequoteReplacesStaleQuotes(t *testing.T) {
+	mm, cancelled := newTestMarketMaker(t)
+	mm.userSimulator.OnPriceUpdate("AAPL", 100)
+	book := &OrderBookSnapshot{}
+
+	first := mm.Requote(context.Background(), "AAPL", book)
+	require.Len(t, first, 2)
+	assert.Empty(t, *cancelled)
+
+	// The resting quote stands while nothing moves
+	assert.Empty(t, mm.Requote(context.Background(), "AAPL", book))
+
+	// Its own quotes in the book are not other traders' prices
+	book = &OrderBookSnapshot{
+		Bids: []OrderBookLevel{{Price: first[0].Price}},
+		Asks: []OrderBookLevel{{Price: first[1].Price}},
+	}
+	assert.Empty(t, mm.Requote(context.Background(), "AAPL", book))
+
+	// A fill changes the inventory, which replaces both sides
+	setInventory(mm, "AAPL", 10)
+	second := mm.Requote(context.Background(), "AAPL", book)
+	require.Len(t, second, 2)
+	assert.ElementsMatch(t, []string{first[0].ClientOrderID, first[1].ClientOrderID}, *cancelled)
+	assert.Equal(t, int64(2), mm.Status().Quotes[0].Requotes)
+}
+
+func TestMarketMaker_ShouldRequote(t *testing.T) {
+	// A resting quote with a spread of 0.12, so a tolerance of 0.03
+	resting := func() *marketMakerSymbol {
+		return &marketMakerSymbol{
+			quote:   MarketMakerQuote{Inventory: 10},
+			bid:     &restingQuote{clientOrderID: "bid", price: 99.94},
+			ask:     &restingQuote{clientOrderID: "ask", price: 100.06},
+			bestBid: 99,
+			bestAsk: 101,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		state     func() *marketMakerSymbol
+		bestBid   float64
+		bestAsk   float64
+		inventory float64
+		bid       float64
+		ask       float64
+		want      bool
+	}{
+		{"unchanged", resting, 99, 101, 10, 99.94, 100.06, false},
+		{"moves within the tolerance", resting, 99.02, 100.98, 10, 99.96, 100.04, false},
+		{"others' best bid moves", resting, 99.04, 101, 10, 99.94, 100.06, true},
+		{"others' best ask moves", resting, 99, 100.96, 10, 99.94, 100.06, true},
+		{"others' side empties", resting, 0, 101, 10, 99.94, 100.06, true},
+		{"target bid moves", resting, 99, 101, 10, 99.90, 100.06, true},
+		{"target ask moves", resting, 99, 101, 10, 99.94, 100.10, true},
+		{"inventory changes", resting, 99, 101, 11, 99.94, 100.06, true},
+		{"bid side stops quoting", resting, 99, 101, 10, 0, 100.06, true},
+		{"ask side stops quoting", resting, 99, 101, 10, 99.94, 0, true},
+		{
+			name: "bid side resumes quoting",
+			state: func() *marketMakerSymbol {
+				state := resting()
+				state.bid = nil
+				return state
+			},
+			bestBid: 99, bestAsk: 101, inventory: 10, bid: 99.94, ask: 100.06,
+			want: true,
+		},
+		{
+			name: "side at the limit stays out",
+			state: func() *marketMakerSymbol {
+				state := resting()
+				state.bid = nil
+				return state
+			},
+			bestBid: 99, bestAsk: 101, inventory: 10, ask: 100.06,
+			want: false,
+		},
+		{
+			name:  "nothing quoted yet",
+			state: func() *marketMakerSymbol { return &marketMakerSymbol{} },
+			bid:   99.94, ask: 100.06,
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mm := &MarketMaker{}
+			got := mm.shouldRequote(tt.state(), tt.bestBid, tt.bestAsk, tt.inventory, tt.bid, tt.ask, 0.12)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOthersBest(t *testing.T) {
+	levels := []OrderBookLevel{{Price: 99}, {Price: 0}, {Price: 101}, {Price: 100}}
+
+	tests := []struct {
+		name    string
+		levels  []OrderBookLevel
+		own     *restingQuote
+		highest bool
+		want    float64
+	}{
+		{"highest bid", levels, nil, true, 101},
+		{"lowest ask skips empty prices", levels, nil, false, 99},
+		{"own bid is skipped", levels, &restingQuote{price: 101}, true, 100},
+		{"own ask is skipped", levels, &restingQuote{price: 99}, false, 100},
+		{"own quote away from the top", levels, &restingQuote{price: 100}, true, 101},
+		{"own quote alone", []OrderBookLevel{{Price: 99}}, &restingQuote{price: 99}, true, 0},
+		{"empty side", nil, nil, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, othersBest(tt.levels, tt.own, tt.highest))
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"math/rand"
 	"time"
 
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/seasonality"
 	"simulated_exchange/pkg/shared"
 )
 
@@ -26,13 +28,15 @@ type OrderGenerator struct {
 	config         OrderGeneratorConfig
 	logger         *slog.Logger
 	random         *rand.Rand
-	currentRates   map[string]float64 // Current order generation rates per symbol
-	volatilityMode bool               // Whether we're in high volatility mode
-	symbols        []string           // Available trading symbols
-	orderBuffer    []*shared.Order    // Buffer for batch processing
-	lastBatchTime  time.Time          // Last time batch was sent
-	orderCount     int                // Orders generated in current minute
-	minuteStart    time.Time          // Start of current minute for rate limiting
+	currentRates   map[string]float64   // Current order generation rates per symbol
+	volatilityMode bool                 // Whether we're in high volatility mode
+	symbols        []string             // Available trading symbols
+	orderBuffer    []*shared.Order      // Buffer for batch processing
+	lastBatchTime  time.Time            // Last time batch was sent
+	orderCount     int                  // Orders generated in current minute
+	minuteStart    time.Time            // Start of current minute for rate limiting
+	clock          clock.Clock          // Time source of order timestamps and rate limits
+	seasonality    *seasonality.Profile // Intraday, weekly and holiday profile of the order rate
 }
 
 // NewOrderGenerator creates a new order generator
@@ -43,9 +47,40 @@ func NewOrderGenerator(config OrderGeneratorConfig, logger *slog.Logger) *OrderG
 		random:       rand.New(rand.NewSource(config.RandomSeed)),
 		currentRates: make(map[string]float64),
 		symbols:      []string{"BTC", "ETH", "ADA", "DOT", "SOL", "MATIC"},
+		clock:        clock.Real(),
+		seasonality:  seasonality.Standard(),
 	}
 }
 
+// SetClock sets the clock orders are timestamped and rate limited on. It
+// must be called before orders are generated.
+func (og *OrderGenerator) SetClock(clk clock.Clock) {
+	og.clock = clk
+}
+
+// SetSeasonality sets the profile order rates follow over the day, the week
+// and holidays. It must be called before orders are generated.
+func (og *OrderGenerator) SetSeasonality(profile *seasonality.Profile) {
+	og.seasonality = profile
+}
+
+// SeasonalFactors returns the seasonal factors of the current time
+func (og *OrderGenerator) SeasonalFactors() seasonality.Factors {
+	return og.seasonality.At(og.clock.Now())
+}
+
+// SeasonalOrderCount scales a number of orders by the seasonal volume. The
+// fraction of an order left over is placed with that probability, so the
+// expected number of orders follows the profile.
+func (og *OrderGenerator) SeasonalOrderCount(orders int) int {
+	scaled := float64(orders) * og.SeasonalFactors().Volume
+	count := int(scaled)
+	if og.random.Float64() < scaled-float64(count) {
+		count++
+	}
+	return count
+}
+
 // GenerateOrder creates a new order based on current market conditions
 func (og *OrderGenerator) GenerateOrder(ctx context.Context, userType string, symbol string, currentPrice float64) (*shared.Order, error) {
 	order := &shared.Order{
@@ -56,8 +91,8 @@ func (og *OrderGenerator) GenerateOrder(ctx context.Context, userType string, sy
 		Side:      og.determineOrderSide(userType, symbol),
 		Quantity:  og.generateQuantity(userType, currentPrice),
 		Status:    shared.OrderStatusPending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: og.clock.Now(),
+		UpdatedAt: og.clock.Now(),
 	}
 
 	// Set price based on order type
@@ -95,7 +130,7 @@ func (og *OrderGenerator) GetOrderRate(symbol string) float64 {
 		rate *= og.config.VolatilityBoost
 	}
 
-	return rate
+	return rate * og.SeasonalFactors().Volume
 }
 
 // SetVolatilityMode enables or disables high volatility order generation
@@ -0,0 +1,336 @@
+package domain
+
+import (
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// orderAttributionTTL is how long a submitted order is remembered for
+// attributing its fills to a simulated user
+const orderAttributionTTL = time.Hour
+
+// positionEpsilon treats float residue from partial fills as a flat position
+const positionEpsilon = 1e-9
+
+// PositionPnL represents a simulated user's position and P&L in one symbol
+type PositionPnL struct {
+	Symbol        string  `json:"symbol"`
+	Quantity      float64 `json:"quantity"`
+	AverageCost   float64 `json:"average_cost"`
+	MarkPrice     float64 `json:"mark_price"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	Exposure      float64 `json:"exposure"`
+}
+
+// UserPnL represents the P&L of a simulated user across all symbols
+type UserPnL struct {
+	UserID        string        `json:"user_id"`
+	Behavior      string        `json:"behavior"`
+	RealizedPnL   float64       `json:"realized_pnl"`
+	UnrealizedPnL float64       `json:"unrealized_pnl"`
+	TotalPnL      float64       `json:"total_pnl"`
+	Exposure      float64       `json:"exposure"`
+	TradeCount    int           `json:"trade_count"`
+	Positions     []PositionPnL `json:"positions"`
+	LastTradeAt   time.Time     `json:"last_trade_at"`
+}
+
+// BehaviorPnL aggregates the P&L of all users sharing a behavior type
+type BehaviorPnL struct {
+	Behavior      string  `json:"behavior"`
+	Users         int     `json:"users"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	TotalPnL      float64 `json:"total_pnl"`
+	Exposure      float64 `json:"exposure"`
+	TradeCount    int     `json:"trade_count"`
+}
+
+// Fill is one side of an executed trade, as reported by trade.executed events
+type Fill struct {
+	ClientOrderID string
+	Symbol        string
+	Buy           bool
+	Quantity      float64
+	Price         float64
+}
+
+// orderAttribution links a submitted order to the simulated user behind it
+type orderAttribution struct {
+	userID     string
+	behavior   string
+	recordedAt time.Time
+}
+
+// position is the running average-cost position in one symbol
+type position struct {
+	quantity    float64
+	averageCost float64
+	realizedPnL float64
+}
+
+// userBook holds the positions of a single simulated user
+type userBook struct {
+	behavior    string
+	positions   map[string]*position
+	tradeCount  int
+	lastTradeAt time.Time
+}
+
+// PositionKeeper tracks positions and P&L per simulated user. Fills are
+// attributed through the client order IDs of submitted orders and positions
+// are marked to market on price updates.
+type PositionKeeper struct {
+	logger *slog.Logger
+
+	orders    map[string]orderAttribution
+	users     map[string]*userBook
+	marks     map[string]float64
+	lastSweep time.Time
+	mutex     sync.RWMutex
+}
+
+// NewPositionKeeper creates a new position keeper
+func NewPositionKeeper(logger *slog.Logger) *PositionKeeper {
+	return &PositionKeeper{
+		logger:    logger,
+		orders:    make(map[string]orderAttribution),
+		users:     make(map[string]*userBook),
+		marks:     make(map[string]float64),
+		lastSweep: time.Now(),
+	}
+}
+
+// TrackOrder records which simulated user and behavior submitted an order
+func (pk *PositionKeeper) TrackOrder(clientOrderID, userID, behavior string) {
+	now := time.Now()
+
+	pk.mutex.Lock()
+	defer pk.mutex.Unlock()
+
+	pk.sweep(now)
+	pk.orders[clientOrderID] = orderAttribution{userID: userID, behavior: behavior, recordedAt: now}
+}
+
+// OnFill applies one side of a trade to the position of the user who
+// submitted the order. Fills of orders the keeper didn't track are ignored.
+func (pk *PositionKeeper) OnFill(fill Fill) bool {
+	pk.mutex.Lock()
+	defer pk.mutex.Unlock()
+
+	attribution, ok := pk.orders[fill.ClientOrderID]
+	if !ok {
+		return false
+	}
+
+	book, exists := pk.users[attribution.userID]
+	if !exists {
+		book = &userBook{behavior: attribution.behavior, positions: make(map[string]*position)}
+		pk.users[attribution.userID] = book
+	}
+
+	pos, exists := book.positions[fill.Symbol]
+	if !exists {
+		pos = &position{}
+		book.positions[fill.Symbol] = pos
+	}
+
+	quantity := fill.Quantity
+	if !fill.Buy {
+		quantity = -quantity
+	}
+	pos.apply(quantity, fill.Price)
+
+	pk.logger.Debug("Fill applied",
+		"user_id", attribution.userID,
+		"behavior", attribution.behavior,
+		"symbol", fill.Symbol,
+		"quantity", quantity,
+		"price", fill.Price,
+		"position", pos.quantity,
+	)
+
+	book.tradeCount++
+	book.lastTradeAt = time.Now()
+
+	// Until the first price update, mark at the last traded price
+	if _, marked := pk.marks[fill.Symbol]; !marked {
+		pk.marks[fill.Symbol] = fill.Price
+	}
+
+	return true
+}
+
+// OnPriceUpdate marks all positions in a symbol to a new price
+func (pk *PositionKeeper) OnPriceUpdate(symbol string, price float64) {
+	if price <= 0 {
+		return
+	}
+
+	pk.mutex.Lock()
+	pk.marks[symbol] = price
+	pk.mutex.Unlock()
+}
+
+// GetUserPnL returns the P&L of a simulated user
+func (pk *PositionKeeper) GetUserPnL(userID string) (*UserPnL, bool) {
+	pk.mutex.RLock()
+	defer pk.mutex.RUnlock()
+
+	book, exists := pk.users[userID]
+	if !exists {
+		return nil, false
+	}
+	return pk.userPnL(userID, book), true
+}
+
+// Position returns a simulated user's position in a symbol
+func (pk *PositionKeeper) Position(userID, symbol string) float64 {
+	pk.mutex.RLock()
+	defer pk.mutex.RUnlock()
+
+	book, exists := pk.users[userID]
+	if !exists {
+		return 0
+	}
+	if pos, exists := book.positions[symbol]; exists {
+		return pos.quantity
+	}
+	return 0
+}
+
+// GetAllUserPnL returns the P&L of every simulated user that has traded,
+// best performers first
+func (pk *PositionKeeper) GetAllUserPnL() []*UserPnL {
+	pk.mutex.RLock()
+	defer pk.mutex.RUnlock()
+
+	results := make([]*UserPnL, 0, len(pk.users))
+	for userID, book := range pk.users {
+		results = append(results, pk.userPnL(userID, book))
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].TotalPnL != results[j].TotalPnL {
+			return results[i].TotalPnL > results[j].TotalPnL
+		}
+		return results[i].UserID < results[j].UserID
+	})
+
+	return results
+}
+
+// GetBehaviorPnL returns P&L aggregated by behavior type
+func (pk *PositionKeeper) GetBehaviorPnL() []*BehaviorPnL {
+	byBehavior := make(map[string]*BehaviorPnL)
+	for _, user := range pk.GetAllUserPnL() {
+		aggregate, exists := byBehavior[user.Behavior]
+		if !exists {
+			aggregate = &BehaviorPnL{Behavior: user.Behavior}
+			byBehavior[user.Behavior] = aggregate
+		}
+		aggregate.Users++
+		aggregate.RealizedPnL += user.RealizedPnL
+		aggregate.UnrealizedPnL += user.UnrealizedPnL
+		aggregate.TotalPnL += user.TotalPnL
+		aggregate.Exposure += user.Exposure
+		aggregate.TradeCount += user.TradeCount
+	}
+
+	results := make([]*BehaviorPnL, 0, len(byBehavior))
+	for _, aggregate := range byBehavior {
+		results = append(results, aggregate)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Behavior < results[j].Behavior
+	})
+
+	return results
+}
+
+// userPnL values a user's positions at the current marks. Must be called
+// with the mutex held.
+func (pk *PositionKeeper) userPnL(userID string, book *userBook) *UserPnL {
+	result := &UserPnL{
+		UserID:      userID,
+		Behavior:    book.behavior,
+		TradeCount:  book.tradeCount,
+		Positions:   make([]PositionPnL, 0, len(book.positions)),
+		LastTradeAt: book.lastTradeAt,
+	}
+
+	for symbol, pos := range book.positions {
+		mark := pk.marks[symbol]
+		unrealized := pos.quantity * (mark - pos.averageCost)
+		exposure := math.Abs(pos.quantity) * mark
+
+		result.Positions = append(result.Positions, PositionPnL{
+			Symbol:        symbol,
+			Quantity:      pos.quantity,
+			AverageCost:   pos.averageCost,
+			MarkPrice:     mark,
+			RealizedPnL:   pos.realizedPnL,
+			UnrealizedPnL: unrealized,
+			Exposure:      exposure,
+		})
+
+		result.RealizedPnL += pos.realizedPnL
+		result.UnrealizedPnL += unrealized
+		result.Exposure += exposure
+	}
+	result.TotalPnL = result.RealizedPnL + result.UnrealizedPnL
+
+	sort.Slice(result.Positions, func(i, j int) bool {
+		return result.Positions[i].Symbol < result.Positions[j].Symbol
+	})
+
+	return result
+}
+
+// sweep forgets order attributions older than orderAttributionTTL. Must be
+// called with the mutex held.
+func (pk *PositionKeeper) sweep(now time.Time) {
+	if now.Sub(pk.lastSweep) < time.Minute {
+		return
+	}
+	pk.lastSweep = now
+
+	for clientOrderID, attribution := range pk.orders {
+		if now.Sub(attribution.recordedAt) > orderAttributionTTL {
+			delete(pk.orders, clientOrderID)
+		}
+	}
+}
+
+// apply adds a signed fill quantity at price to the position. Fills that
+// reduce the position realize P&L against the average cost; fills that flip
+// it open the new side at the fill price.
+func (p *position) apply(quantity, price float64) {
+	if p.quantity == 0 || (p.quantity > 0) == (quantity > 0) {
+		total := p.quantity + quantity
+		p.averageCost = (math.Abs(p.quantity)*p.averageCost + math.Abs(quantity)*price) / math.Abs(total)
+		p.quantity = total
+		return
+	}
+
+	closed := math.Min(math.Abs(quantity), math.Abs(p.quantity))
+	if p.quantity > 0 {
+		p.realizedPnL += closed * (price - p.averageCost)
+	} else {
+		p.realizedPnL += closed * (p.averageCost - price)
+	}
+
+	p.quantity += quantity
+	switch {
+	case math.Abs(p.quantity) < positionEpsilon:
+		p.quantity = 0
+		p.averageCost = 0
+	case (p.quantity > 0) == (quantity > 0):
+		// The fill flipped the position; the remainder was opened at price
+		p.averageCost = price
+	}
+}
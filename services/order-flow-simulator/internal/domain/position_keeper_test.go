@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPosition_Apply(t *testing.T) {
+	type fill struct {
+		quantity float64
+		price    float64
+	}
+
+	tests := []struct {
+		name        string
+		fills       []fill
+		quantity    float64
+		averageCost float64
+		realizedPnL float64
+	}{
+		{
+			name:        "opening buy",
+			fills:       []fill{{10, 100}},
+			quantity:    10,
+			averageCost: 100,
+		},
+		{
+			name:        "adding to a long averages the cost",
+			fills:       []fill{{10, 100}, {30, 120}},
+			quantity:    40,
+			averageCost: 115,
+		},
+		{
+			name:        "adding to a short averages the cost",
+			fills:       []fill{{-10, 100}, {-10, 90}},
+			quantity:    -20,
+			averageCost: 95,
+		},
+		{
+			name:        "partial close of a long realizes against the average cost",
+			fills:       []fill{{10, 100}, {30, 120}, {-15, 130}},
+			quantity:    25,
+			averageCost: 115,
+			realizedPnL: 15 * 15,
+		},
+		{
+			name:        "partial close of a short at a loss",
+			fills:       []fill{{-20, 50}, {5, 60}},
+			quantity:    -15,
+			averageCost: 50,
+			realizedPnL: -50,
+		},
+		{
+			name:        "full close flattens the cost",
+			fills:       []fill{{10, 100}, {-10, 90}},
+			realizedPnL: -100,
+		},
+		{
+			name:        "float residue of partial closes counts as flat",
+			fills:       []fill{{0.3, 100}, {-0.1, 110}, {-0.2, 110}},
+			realizedPnL: 3,
+		},
+		{
+			name:        "long flips short at the fill price",
+			fills:       []fill{{10, 100}, {-25, 110}},
+			quantity:    -15,
+			averageCost: 110,
+			realizedPnL: 100,
+		},
+		{
+			name:        "short flips long at the fill price",
+			fills:       []fill{{-10, 100}, {14, 105}},
+			quantity:    4,
+			averageCost: 105,
+			realizedPnL: -50,
+		},
+		{
+			name:        "reopening after a flat position starts a new cost",
+			fills:       []fill{{10, 100}, {-10, 120}, {-5, 80}},
+			quantity:    -5,
+			averageCost: 80,
+			realizedPnL: 200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p position
+			for _, f := range tt.fills {
+				p.apply(f.quantity, f.price)
+			}
+
+			assert.InDelta(t, tt.quantity, p.quantity, 1e-9)
+			assert.InDelta(t, tt.averageCost, p.averageCost, 1e-9)
+			assert.InDelta(t, tt.realizedPnL, p.realizedPnL, 1e-9)
+		})
+	}
+}
+
+func TestPositionKeeper_PnL(t *testing.T) {
+	keeper := NewPositionKeeper(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	keeper.TrackOrder("o1", "user-1", "momentum")
+	keeper.TrackOrder("o2", "user-1", "momentum")
+	keeper.TrackOrder("o3", "user-2", "mean_reversion")
+
+	require.True(t, keeper.OnFill(Fill{ClientOrderID: "o1", Symbol: "AAPL", Buy: true, Quantity: 10, Price: 100}))
+	require.True(t, keeper.OnFill(Fill{ClientOrderID: "o2", Symbol: "AAPL", Quantity: 4, Price: 110}))
+	require.True(t, keeper.OnFill(Fill{ClientOrderID: "o3", Symbol: "AAPL", Quantity: 5, Price: 100}))
+
+	// Fills of untracked orders are ignored
+	assert.False(t, keeper.OnFill(Fill{ClientOrderID: "unknown", Symbol: "AAPL", Buy: true, Quantity: 1, Price: 100}))
+
+	// Positions are marked at the first fill until a price update
+	keeper.OnPriceUpdate("AAPL", 120)
+
+	user, ok := keeper.GetUserPnL("user-1")
+	require.True(t, ok)
+	assert.Equal(t, 6.0, keeper.Position("user-1", "AAPL"))
+	assert.InDelta(t, 40, user.RealizedPnL, 1e-9)
+	assert.InDelta(t, 120, user.UnrealizedPnL, 1e-9)
+	assert.InDelta(t, 160, user.TotalPnL, 1e-9)
+	assert.InDelta(t, 720, user.Exposure, 1e-9)
+	assert.Equal(t, 2, user.TradeCount)
+
+	all := keeper.GetAllUserPnL()
+	require.Len(t, all, 2)
+	assert.Equal(t, "user-1", all[0].UserID)
+	assert.InDelta(t, -100, all[1].TotalPnL, 1e-9)
+
+	behaviors := keeper.GetBehaviorPnL()
+	require.Len(t, behaviors, 2)
+	assert.Equal(t, "mean_reversion", behaviors[0].Behavior)
+	assert.Equal(t, 1, behaviors[1].Users)
+}
@@ -7,7 +7,7 @@ import (
 
 // CanGenerateOrder checks if we can generate another order based on rate limits
 func (og *OrderGenerator) CanGenerateOrder() bool {
-	now := time.Now()
+	now := og.clock.Now()
 
 	// Reset counter if new minute
 	if now.Sub(og.minuteStart) >= time.Minute {
@@ -34,7 +34,7 @@ func (og *OrderGenerator) AddToBuffer(order *shared.Order) {
 
 // ShouldFlushBuffer determines if the buffer should be flushed
 func (og *OrderGenerator) ShouldFlushBuffer() bool {
-	now := time.Now()
+	now := og.clock.Now()
 
 	// Flush if buffer is full
 	if len(og.orderBuffer) >= og.config.BatchSize {
@@ -60,7 +60,7 @@ func (og *OrderGenerator) FlushBuffer() []*shared.Order {
 
 	// Clear buffer
 	og.orderBuffer = og.orderBuffer[:0]
-	og.lastBatchTime = time.Now()
+	og.lastBatchTime = og.clock.Now()
 
 	og.logger.Info("Flushing order batch", "batch_size", len(orders))
 	return orders
@@ -13,9 +13,18 @@ import (
 	"simulated_exchange/pkg/shared"
 )
 
+const (
+	// submitMaxAttempts bounds how often an order submission is attempted
+	submitMaxAttempts = 3
+
+	// submitRetryBackoff is the base delay between submission attempts
+	submitRetryBackoff = 200 * time.Millisecond
+)
+
 // TradingAPIClient handles communication with the Trading API service
 type TradingAPIClient struct {
 	baseURL    string
+	apiKey     string
 	httpClient *http.Client
 	logger     *slog.Logger
 }
@@ -36,12 +45,70 @@ type APIError struct {
 
 // OrderSubmissionRequest represents the request for submitting an order
 type OrderSubmissionRequest struct {
-	UserID   string             `json:"user_id"`
-	Symbol   string             `json:"symbol"`
-	Type     shared.OrderType   `json:"type"`
-	Side     shared.OrderSide   `json:"side"`
-	Quantity float64            `json:"quantity"`
-	Price    float64            `json:"price,omitempty"`
+	UserID        string           `json:"user_id"`
+	ClientOrderID string           `json:"client_order_id,omitempty"`
+	Symbol        string           `json:"symbol"`
+	Type          shared.OrderType `json:"type"`
+	Side          shared.OrderSide `json:"side"`
+	Quantity      float64          `json:"quantity"`
+	Price         float64          `json:"price,omitempty"`
+}
+
+// OrderBookLevel is one aggregated price level of an order book
+type OrderBookLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Orders   int     `json:"orders"`
+}
+
+// OrderBookSnapshot represents the aggregated order book of a symbol. Levels
+// are not sorted.
+type OrderBookSnapshot struct {
+	Symbol string           `json:"symbol"`
+	Bids   []OrderBookLevel `json:"bids"`
+	Asks   []OrderBookLevel `json:"asks"`
+}
+
+// BestBid returns the highest bid price, false if there are no bids
+func (b *OrderBookSnapshot) BestBid() (float64, bool) {
+	best := 0.0
+	for _, level := range b.Bids {
+		if level.Price > best {
+			best = level.Price
+		}
+	}
+	return best, best > 0
+}
+
+// BestAsk returns the lowest ask price, false if there are no asks
+func (b *OrderBookSnapshot) BestAsk() (float64, bool) {
+	best := 0.0
+	for _, level := range b.Asks {
+		if level.Price > 0 && (best == 0 || level.Price < best) {
+			best = level.Price
+		}
+	}
+	return best, best > 0
+}
+
+// OrderBatchSubmissionRequest represents the request for submitting a batch of orders
+type OrderBatchSubmissionRequest struct {
+	Orders []OrderSubmissionRequest `json:"orders"`
+}
+
+// OrderBatchResult represents the outcome of one order in a batch
+type OrderBatchResult struct {
+	Index   int       `json:"index"`
+	Success bool      `json:"success"`
+	OrderID string    `json:"order_id,omitempty"`
+	Error   *APIError `json:"error,omitempty"`
+}
+
+// OrderBatchResponse represents the response when submitting a batch of orders
+type OrderBatchResponse struct {
+	Accepted int                `json:"accepted"`
+	Rejected int                `json:"rejected"`
+	Results  []OrderBatchResult `json:"results"`
 }
 
 // OrderResponse represents the response when submitting an order
@@ -67,17 +134,24 @@ func NewTradingAPIClient(baseURL string, logger *slog.Logger) *TradingAPIClient
 	}
 }
 
-// SubmitOrder submits an order to the trading API
-func (c *TradingAPIClient) SubmitOrder(ctx context.Context, order *shared.Order) error {
-	// Convert order to API request format
-	request := OrderSubmissionRequest{
-		UserID:   order.UserID,
-		Symbol:   order.Symbol,
-		Type:     order.Type,
-		Side:     order.Side,
-		Quantity: order.Quantity,
-		Price:    order.Price,
+// SetAPIKey configures the API key sent with every request
+func (c *TradingAPIClient) SetAPIKey(apiKey string) {
+	c.apiKey = apiKey
+}
+
+// setHeaders applies the common request headers
+func (c *TradingAPIClient) setHeaders(httpReq *http.Request) {
+	httpReq.Header.Set("User-Agent", "order-flow-simulator/1.0")
+	if c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
 	}
+}
+
+// SubmitOrder submits an order to the trading API. The order ID is sent as
+// the client order ID so that retries after transport errors or server
+// failures cannot create duplicate orders.
+func (c *TradingAPIClient) SubmitOrder(ctx context.Context, order *shared.Order) error {
+	request := toSubmissionRequest(order)
 
 	// Marshal request to JSON
 	requestBody, err := json.Marshal(request)
@@ -85,55 +159,170 @@ func (c *TradingAPIClient) SubmitOrder(ctx context.Context, order *shared.Order)
 		return fmt.Errorf("failed to marshal order request: %w", err)
 	}
 
+	if err := c.postWithRetry(ctx, "/api/orders", request.ClientOrderID, requestBody, nil); err != nil {
+		return err
+	}
+
+	c.logger.Debug("Order submitted successfully",
+		"order_id", order.ID,
+		"symbol", order.Symbol,
+		"type", order.Type,
+		"side", order.Side,
+		"quantity", order.Quantity,
+		"price", order.Price,
+	)
+
+	return nil
+}
+
+// SubmitOrderBatch submits orders in a single request. It returns one error
+// per order (nil for accepted orders) in the order they were passed, or an
+// error if the batch as a whole could not be submitted.
+func (c *TradingAPIClient) SubmitOrderBatch(ctx context.Context, orders []*shared.Order) ([]error, error) {
+	request := OrderBatchSubmissionRequest{
+		Orders: make([]OrderSubmissionRequest, len(orders)),
+	}
+	for i, order := range orders {
+		request.Orders[i] = toSubmissionRequest(order)
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal order batch request: %w", err)
+	}
+
+	var response OrderBatchResponse
+	if err := c.postWithRetry(ctx, "/api/orders/batch", "", requestBody, &response); err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(orders))
+	for _, result := range response.Results {
+		if result.Index < 0 || result.Index >= len(orders) || result.Success {
+			continue
+		}
+		if result.Error != nil {
+			errs[result.Index] = fmt.Errorf("API error: %s - %s", result.Error.Code, result.Error.Message)
+		} else {
+			errs[result.Index] = fmt.Errorf("order rejected")
+		}
+	}
+
+	c.logger.Debug("Order batch submitted",
+		"orders", len(orders),
+		"accepted", response.Accepted,
+		"rejected", response.Rejected,
+	)
+
+	return errs, nil
+}
+
+// toSubmissionRequest converts an order to the API request format, using the
+// order ID as client order ID when none is set
+func toSubmissionRequest(order *shared.Order) OrderSubmissionRequest {
+	clientOrderID := order.ClientOrderID
+	if clientOrderID == "" {
+		clientOrderID = order.ID
+	}
+
+	return OrderSubmissionRequest{
+		UserID:        order.UserID,
+		ClientOrderID: clientOrderID,
+		Symbol:        order.Symbol,
+		Type:          order.Type,
+		Side:          order.Side,
+		Quantity:      order.Quantity,
+		Price:         order.Price,
+	}
+}
+
+// postWithRetry posts an idempotent request, retrying transport errors and
+// server failures, and decodes the response data into out when non-nil
+func (c *TradingAPIClient) postWithRetry(ctx context.Context, path, idempotencyKey string, requestBody []byte, out interface{}) error {
+	var lastErr error
+	for attempt := 1; attempt <= submitMaxAttempts; attempt++ {
+		retryable, err := c.postOnce(ctx, path, idempotencyKey, requestBody, out)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		if !retryable || attempt == submitMaxAttempts {
+			break
+		}
+
+		c.logger.Debug("Retrying request",
+			"path", path,
+			"attempt", attempt,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * submitRetryBackoff):
+		}
+	}
+
+	return lastErr
+}
+
+// postOnce performs a single POST attempt and reports whether a failure is
+// safe to retry
+func (c *TradingAPIClient) postOnce(ctx context.Context, path, idempotencyKey string, requestBody []byte, out interface{}) (bool, error) {
 	// Create HTTP request
-	url := fmt.Sprintf("%s/api/orders", c.baseURL)
+	url := c.baseURL + path
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return false, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("User-Agent", "order-flow-simulator/1.0")
+	if idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	c.setHeaders(httpReq)
 
 	// Execute request
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("failed to execute HTTP request: %w", err)
+		return ctx.Err() == nil, fmt.Errorf("failed to execute HTTP request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	retryable := resp.StatusCode >= http.StatusInternalServerError
+
 	// Read response body
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return true, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Parse response
-	var apiResponse APIResponse
+	var apiResponse struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data,omitempty"`
+		Error   *APIError       `json:"error,omitempty"`
+	}
 	if err := json.Unmarshal(responseBody, &apiResponse); err != nil {
-		return fmt.Errorf("failed to parse API response: %w", err)
+		return retryable, fmt.Errorf("failed to parse API response: %w", err)
 	}
 
 	// Check for API errors
 	if !apiResponse.Success {
 		if apiResponse.Error != nil {
-			return fmt.Errorf("API error: %s - %s", apiResponse.Error.Code, apiResponse.Error.Message)
+			return retryable, fmt.Errorf("API error: %s - %s", apiResponse.Error.Code, apiResponse.Error.Message)
 		}
-		return fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		return retryable, fmt.Errorf("API request failed with status %d", resp.StatusCode)
 	}
 
-	// Log successful submission
-	c.logger.Debug("Order submitted successfully",
-		"order_id", order.ID,
-		"symbol", order.Symbol,
-		"type", order.Type,
-		"side", order.Side,
-		"quantity", order.Quantity,
-		"price", order.Price,
-		"status_code", resp.StatusCode,
-	)
+	if out != nil {
+		if err := json.Unmarshal(apiResponse.Data, out); err != nil {
+			return false, fmt.Errorf("failed to parse response data: %w", err)
+		}
+	}
 
-	return nil
+	return false, nil
 }
 
 // GetOrderStatus retrieves the status of an order
@@ -145,7 +334,7 @@ func (c *TradingAPIClient) GetOrderStatus(ctx context.Context, orderID string) (
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	httpReq.Header.Set("User-Agent", "order-flow-simulator/1.0")
+	c.setHeaders(httpReq)
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
@@ -193,7 +382,7 @@ func (c *TradingAPIClient) CancelOrder(ctx context.Context, orderID string) erro
 		return fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	httpReq.Header.Set("User-Agent", "order-flow-simulator/1.0")
+	c.setHeaders(httpReq)
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
@@ -222,6 +411,107 @@ func (c *TradingAPIClient) CancelOrder(ctx context.Context, orderID string) erro
 	return nil
 }
 
+// CancelOrderByClientID cancels a user's order identified by its client order ID
+func (c *TradingAPIClient) CancelOrderByClientID(ctx context.Context, userID, clientOrderID string) error {
+	url := fmt.Sprintf("%s/api/orders/client/%s?user_id=%s", c.baseURL, clientOrderID, userID)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	c.setHeaders(httpReq)
+
+	if _, err := c.do(httpReq); err != nil {
+		return err
+	}
+
+	c.logger.Debug("Order cancelled successfully", "user_id", userID, "client_order_id", clientOrderID)
+	return nil
+}
+
+// GetSurveillanceAlerts retrieves the surveillance alerts raised for a user
+// since a time. It requires an API key with the admin scope when
+// authentication is enabled.
+func (c *TradingAPIClient) GetSurveillanceAlerts(ctx context.Context, userID string, since time.Time) ([]*shared.SurveillanceAlert, error) {
+	url := fmt.Sprintf("%s/api/surveillance/alerts?user_id=%s&from=%d&limit=1000", c.baseURL, userID, since.Unix())
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	c.setHeaders(httpReq)
+
+	data, err := c.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []*shared.SurveillanceAlert
+	if err := json.Unmarshal(data, &alerts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal surveillance alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// GetOrderBook retrieves the aggregated order book of a symbol
+func (c *TradingAPIClient) GetOrderBook(ctx context.Context, symbol string) (*OrderBookSnapshot, error) {
+	url := fmt.Sprintf("%s/api/orderbook/%s", c.baseURL, symbol)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	c.setHeaders(httpReq)
+
+	data, err := c.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var book OrderBookSnapshot
+	if err := json.Unmarshal(data, &book); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order book: %w", err)
+	}
+
+	return &book, nil
+}
+
+// do executes a request and returns the data of a successful API response
+func (c *TradingAPIClient) do(httpReq *http.Request) (json.RawMessage, error) {
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var apiResponse struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+		Error   *APIError       `json:"error"`
+	}
+	if err := json.Unmarshal(responseBody, &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+
+	if !apiResponse.Success {
+		if apiResponse.Error != nil {
+			return nil, fmt.Errorf("API error: %s - %s", apiResponse.Error.Code, apiResponse.Error.Message)
+		}
+		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	}
+
+	return apiResponse.Data, nil
+}
+
 // GetMarketData retrieves current market data for a symbol
 func (c *TradingAPIClient) GetMarketData(ctx context.Context, symbol string) (map[string]interface{}, error) {
 	url := fmt.Sprintf("%s/api/market/%s", c.baseURL, symbol)
@@ -231,7 +521,7 @@ func (c *TradingAPIClient) GetMarketData(ctx context.Context, symbol string) (ma
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	httpReq.Header.Set("User-Agent", "order-flow-simulator/1.0")
+	c.setHeaders(httpReq)
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
@@ -274,7 +564,7 @@ func (c *TradingAPIClient) HealthCheck(ctx context.Context) error {
 		return fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	httpReq.Header.Set("User-Agent", "order-flow-simulator/1.0")
+	c.setHeaders(httpReq)
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
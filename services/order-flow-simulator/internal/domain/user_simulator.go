@@ -8,9 +8,14 @@ import (
 	"sync"
 	"time"
 
+	"simulated_exchange/pkg/config"
 	"simulated_exchange/pkg/shared"
 )
 
+// agentSessionType marks the sessions of trading agents. They never expire
+// and trade through the AgentTrader rather than the order generator.
+const agentSessionType = "agent"
+
 // UserBehavior represents different types of user trading behavior
 type UserBehavior struct {
 	Type                string             `json:"type"`
@@ -29,7 +34,7 @@ type UserSession struct {
 	OrderCount  int          `json:"order_count"`
 	IsActive    bool         `json:"is_active"`
 	StartTime   time.Time    `json:"start_time"`
-	SessionType string       `json:"session_type"` // "brief", "normal", "extended"
+	SessionType string       `json:"session_type"` // "brief", "normal", "extended", "agent"
 }
 
 // MarketState tracks current market conditions that affect user behavior
@@ -42,6 +47,7 @@ type MarketState struct {
 	LastUpdate       time.Time `json:"last_update"`
 	TrendDirection   string    `json:"trend_direction"`   // "up", "down", "sideways"
 	TrendStrength    float64   `json:"trend_strength"`    // 0.0 to 1.0
+	VolatilityIndex  float64   `json:"volatility_index"`  // Generated volatility relative to its base, 0 if not published
 }
 
 // UserSimulator simulates realistic user trading behavior
@@ -64,6 +70,7 @@ type UserSimulator struct {
 	// Configuration
 	maxConcurrentUsers int
 	sessionDuration    map[string]time.Duration
+	agentMode          bool
 }
 
 // NewUserSimulator creates a new user simulator
@@ -136,6 +143,16 @@ func (us *UserSimulator) OnPriceUpdate(symbol string, newPrice float64) {
 	go us.reactToMarketChange(symbol, priceChange)
 }
 
+// OnVolatilityIndex records the volatility index published with a price
+func (us *UserSimulator) OnVolatilityIndex(symbol string, index float64) {
+	us.marketMutex.Lock()
+	defer us.marketMutex.Unlock()
+
+	if state, exists := us.marketStates[symbol]; exists {
+		state.VolatilityIndex = index
+	}
+}
+
 // OnTradeExecuted handles trade execution events
 func (us *UserSimulator) OnTradeExecuted(symbol string, price float64, quantity float64) {
 	us.marketMutex.Lock()
@@ -165,6 +182,87 @@ func (us *UserSimulator) GetActiveUserCount() int {
 	return len(us.activeSessions)
 }
 
+// PickActiveSession returns a copy of a randomly chosen active user
+// session, or false if no users are active. Trading agents are not picked.
+func (us *UserSimulator) PickActiveSession() (*UserSession, bool) {
+	us.sessionsMutex.Lock()
+	defer us.sessionsMutex.Unlock()
+
+	users := make([]*UserSession, 0, len(us.activeSessions))
+	for _, session := range us.activeSessions {
+		if session.SessionType != agentSessionType {
+			users = append(users, session)
+		}
+	}
+	if len(users) == 0 {
+		return nil, false
+	}
+
+	sessionCopy := *users[us.random.Intn(len(users))]
+	return &sessionCopy, true
+}
+
+// SpawnAgents creates persistent sessions for trading agent populations,
+// keyed by agent type
+func (us *UserSimulator) SpawnAgents(populations map[string]int) {
+	us.sessionsMutex.Lock()
+	defer us.sessionsMutex.Unlock()
+
+	for agentType, count := range populations {
+		behavior, exists := us.userBehaviors[agentType]
+		if !exists {
+			continue
+		}
+		for i := 0; i < count; i++ {
+			userID := "AGENT-" + us.generateRandomString(8)
+			us.activeSessions[userID] = &UserSession{
+				UserID:      userID,
+				Behavior:    behavior,
+				LastActive:  time.Now(),
+				IsActive:    true,
+				StartTime:   time.Now(),
+				SessionType: agentSessionType,
+			}
+		}
+		us.logger.Info("Trading agents spawned", "agent_type", agentType, "count", count)
+	}
+}
+
+// StopUserSessions stops random users from joining, so only trading agents
+// trade
+func (us *UserSimulator) StopUserSessions() {
+	us.sessionsMutex.Lock()
+	defer us.sessionsMutex.Unlock()
+
+	us.agentMode = true
+}
+
+// GetAgentSessions returns copies of the trading agent sessions
+func (us *UserSimulator) GetAgentSessions() []*UserSession {
+	us.sessionsMutex.RLock()
+	defer us.sessionsMutex.RUnlock()
+
+	agents := make([]*UserSession, 0, len(us.activeSessions))
+	for _, session := range us.activeSessions {
+		if session.SessionType == agentSessionType {
+			sessionCopy := *session
+			agents = append(agents, &sessionCopy)
+		}
+	}
+	return agents
+}
+
+// RecordAgentOrders counts orders placed by a trading agent
+func (us *UserSimulator) RecordAgentOrders(userID string, count int) {
+	us.sessionsMutex.Lock()
+	defer us.sessionsMutex.Unlock()
+
+	if session, exists := us.activeSessions[userID]; exists {
+		session.LastActive = time.Now()
+		session.OrderCount += count
+	}
+}
+
 // GetMarketState returns the current market state for a symbol
 func (us *UserSimulator) GetMarketState(symbol string) (*MarketState, bool) {
 	us.marketMutex.RLock()
@@ -207,6 +305,36 @@ func (us *UserSimulator) initializeUserBehaviors() {
 		RiskTolerance:       0.6,
 		PreferredOrderTypes: []shared.OrderType{shared.OrderTypeMarket, shared.OrderTypeStopLoss},
 	}
+
+	// Trading agents of the agent-based mode
+	us.userBehaviors[config.AgentZeroIntelligence] = UserBehavior{
+		Type:                config.AgentZeroIntelligence,
+		OrderFrequency:      6.0,
+		RiskTolerance:       0.5,
+		PreferredOrderTypes: []shared.OrderType{shared.OrderTypeLimit},
+	}
+
+	us.userBehaviors[config.AgentNoise] = UserBehavior{
+		Type:                config.AgentNoise,
+		OrderFrequency:      3.0,
+		RiskTolerance:       0.3,
+		PreferredOrderTypes: []shared.OrderType{shared.OrderTypeMarket},
+	}
+
+	us.userBehaviors[config.AgentFundamental] = UserBehavior{
+		Type:                config.AgentFundamental,
+		OrderFrequency:      4.0,
+		PriceReactivity:     1.0,
+		RiskTolerance:       0.6,
+		PreferredOrderTypes: []shared.OrderType{shared.OrderTypeLimit},
+	}
+
+	us.userBehaviors[config.AgentMarketMaker] = UserBehavior{
+		Type:                config.AgentMarketMaker,
+		OrderFrequency:      60.0, // Requotes every tick
+		RiskTolerance:       0.4,
+		PreferredOrderTypes: []shared.OrderType{shared.OrderTypeLimit},
+	}
 }
 
 func (us *UserSimulator) manageUserSessions(ctx context.Context) {
@@ -232,6 +360,9 @@ func (us *UserSimulator) cleanupExpiredSessions() {
 	expired := make([]string, 0)
 
 	for userID, session := range us.activeSessions {
+		if session.SessionType == agentSessionType {
+			continue
+		}
 		sessionDuration := us.sessionDuration[session.SessionType]
 		if now.Sub(session.StartTime) > sessionDuration {
 			expired = append(expired, userID)
@@ -247,9 +378,10 @@ func (us *UserSimulator) cleanupExpiredSessions() {
 func (us *UserSimulator) createNewSessions() {
 	us.sessionsMutex.Lock()
 	currentUsers := len(us.activeSessions)
+	agentMode := us.agentMode
 	us.sessionsMutex.Unlock()
 
-	if currentUsers >= us.maxConcurrentUsers {
+	if agentMode || currentUsers >= us.maxConcurrentUsers {
 		return
 	}
 
@@ -335,6 +467,11 @@ func (us *UserSimulator) shouldUserAct(session *UserSession) bool {
 }
 
 func (us *UserSimulator) executeUserAction(session *UserSession) {
+	// Agents trade through the AgentTrader
+	if session.SessionType == agentSessionType {
+		return
+	}
+
 	// Select a random symbol
 	symbols := us.orderGenerator.GetSupportedSymbols()
 	symbol := symbols[us.random.Intn(len(symbols))]
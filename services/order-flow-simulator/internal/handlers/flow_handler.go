@@ -6,26 +6,33 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
 	"simulated_exchange/services/order-flow-simulator/internal/domain"
 )
 
 // FlowHandler handles flow simulation related HTTP requests
 type FlowHandler struct {
-	flowSimulator *domain.FlowSimulator
-	userSimulator *domain.UserSimulator
-	logger        *slog.Logger
+	flowSimulator  *domain.FlowSimulator
+	userSimulator  *domain.UserSimulator
+	positionKeeper *domain.PositionKeeper
+	injector       *domain.ManipulationInjector
+	logger         *slog.Logger
 }
 
 // NewFlowHandler creates a new flow handler
 func NewFlowHandler(
 	flowSimulator *domain.FlowSimulator,
 	userSimulator *domain.UserSimulator,
+	positionKeeper *domain.PositionKeeper,
+	injector *domain.ManipulationInjector,
 	logger *slog.Logger,
 ) *FlowHandler {
 	return &FlowHandler{
-		flowSimulator: flowSimulator,
-		userSimulator: userSimulator,
-		logger:        logger,
+		flowSimulator:  flowSimulator,
+		userSimulator:  userSimulator,
+		positionKeeper: positionKeeper,
+		injector:       injector,
+		logger:         logger,
 	}
 }
 
@@ -54,6 +61,20 @@ type SetVolatilityRequest struct {
 	Enabled bool `json:"enabled"`
 }
 
+// SetManipulationRateRequest represents the request for setting how often a
+// manipulative behaviour is injected
+type SetManipulationRateRequest struct {
+	Type shared.AlertType `json:"type" binding:"required"`
+	Rate float64          `json:"rate_per_minute" binding:"min=0"`
+}
+
+// InjectManipulationRequest represents the request for injecting one
+// manipulation. A random symbol is used when none is given.
+type InjectManipulationRequest struct {
+	Type   shared.AlertType `json:"type" binding:"required"`
+	Symbol string           `json:"symbol,omitempty"`
+}
+
 // GetStatus handles GET /api/status
 func (h *FlowHandler) GetStatus(c *gin.Context) {
 	status := h.flowSimulator.GetStatus()
@@ -147,11 +168,36 @@ func (h *FlowHandler) GetUserSessions(c *gin.Context) {
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
 		Data: map[string]interface{}{
-			"active_users": activeUserCount,
+			"active_users":    activeUserCount,
+			"pnl_by_behavior": h.positionKeeper.GetBehaviorPnL(),
+			"users":           h.positionKeeper.GetAllUserPnL(),
 		},
 	})
 }
 
+// GetUserPnL handles GET /api/users/:id/pnl
+func (h *FlowHandler) GetUserPnL(c *gin.Context) {
+	userID := c.Param("id")
+
+	pnl, exists := h.positionKeeper.GetUserPnL(userID)
+	if !exists {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "USER_PNL_NOT_FOUND",
+				Message: "No trades recorded for user",
+				Details: userID,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    pnl,
+	})
+}
+
 // GetMarketState handles GET /api/market/:symbol
 func (h *FlowHandler) GetMarketState(c *gin.Context) {
 	symbol := c.Param("symbol")
@@ -264,4 +310,112 @@ func (h *FlowHandler) GetSymbolStats(c *gin.Context) {
 		Success: true,
 		Data:    response,
 	})
-}
\ No newline at end of file
+}
+
+// SetManipulationRate handles POST /api/manipulation
+func (h *FlowHandler) SetManipulationRate(c *gin.Context) {
+	var req SetManipulationRateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := h.injector.SetRate(req.Type, req.Rate); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "MANIPULATION_RATE_SET_FAILED",
+				Message: "Failed to set manipulation rate",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"type":            req.Type,
+			"rate_per_minute": req.Rate,
+		},
+	})
+
+	h.logger.Info("Manipulation rate updated", "type", req.Type, "rate_per_minute", req.Rate)
+}
+
+// InjectManipulation handles POST /api/manipulation/inject
+func (h *FlowHandler) InjectManipulation(c *gin.Context) {
+	var req InjectManipulationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	injection, err := h.injector.Inject(c.Request.Context(), req.Type, req.Symbol)
+	if injection == nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_MANIPULATION_TYPE",
+				Message: "Unknown manipulation type",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, APIResponse{
+			Success: false,
+			Data:    injection,
+			Error: &APIError{
+				Code:    "MANIPULATION_INJECTION_FAILED",
+				Message: "Failed to inject manipulation",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    injection,
+	})
+}
+
+// GetDetectionReport handles GET /api/manipulation/report
+func (h *FlowHandler) GetDetectionReport(c *gin.Context) {
+	report, err := h.injector.GetDetectionReport(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to build detection report", "error", err)
+		c.JSON(http.StatusBadGateway, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "DETECTION_REPORT_FAILED",
+				Message: "Failed to build detection report",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
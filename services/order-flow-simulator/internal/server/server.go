@@ -93,6 +93,7 @@ func (s *Server) setupRouter() {
 
 		// User simulation endpoints
 		api.GET("/users", s.flowHandler.GetUserSessions)
+		api.GET("/users/:id/pnl", s.flowHandler.GetUserPnL)
 
 		// Market state endpoints
 		api.GET("/market/:symbol", s.flowHandler.GetMarketState)
@@ -100,6 +101,11 @@ func (s *Server) setupRouter() {
 		// Metrics and statistics endpoints
 		api.GET("/metrics", s.flowHandler.GetSimulationMetrics)
 		api.GET("/symbols/:symbol/stats", s.flowHandler.GetSymbolStats)
+
+		// Manipulation injection endpoints (surveillance testing)
+		api.POST("/manipulation", s.flowHandler.SetManipulationRate)
+		api.POST("/manipulation/inject", s.flowHandler.InjectManipulation)
+		api.GET("/manipulation/report", s.flowHandler.GetDetectionReport)
 	}
 
 	// Service info endpoint
@@ -12,12 +12,17 @@ import (
 	"simulated_exchange/pkg/cache"
 	"simulated_exchange/pkg/config"
 	"simulated_exchange/pkg/database"
+	"simulated_exchange/pkg/fees"
+	"simulated_exchange/pkg/ledger"
 	"simulated_exchange/pkg/messaging"
 	"simulated_exchange/pkg/monitoring"
 	"simulated_exchange/pkg/repository"
+	"simulated_exchange/pkg/risk"
 	"simulated_exchange/pkg/shared"
+	"simulated_exchange/pkg/surveillance"
 	"simulated_exchange/services/trading-api/internal/domain"
 	"simulated_exchange/services/trading-api/internal/handlers"
+	"simulated_exchange/services/trading-api/internal/middleware"
 	"simulated_exchange/services/trading-api/internal/server"
 )
 
@@ -32,13 +37,28 @@ type Application struct {
 	eventBus *messaging.RedisEventBus
 
 	// Repositories
-	orderRepo shared.OrderRepository
-	tradeRepo shared.TradeRepository
-	userRepo  shared.UserRepository
+	orderRepo        shared.OrderRepository
+	orderEventRepo   shared.OrderEventRepository
+	tradeRepo        shared.TradeRepository
+	userRepo         shared.UserRepository
+	apiKeyRepo       shared.APIKeyRepository
+	accountRepo      shared.AccountRepository
+	clearingRepo     shared.ClearingRepository
+	ledgerRepo       *repository.PostgresLedgerRepository
+	mmRepo           shared.MarketMakerRepository
+	surveillanceRepo shared.SurveillanceRepository
 
 	// Services
-	tradingService shared.TradingService
-	orderMatcher   shared.OrderMatcher
+	tradingService      shared.TradingService
+	orderMatcher        shared.OrderMatcher
+	authService         *domain.AuthService
+	accountService      *domain.AccountService
+	clearingService     *domain.ClearingService
+	feeEngine           *fees.Engine
+	ledgerAuditor       *ledger.Auditor
+	mmService           *domain.MarketMakerService
+	surveillanceService *domain.SurveillanceService
+	auditService        *domain.AuditService
 
 	// HTTP Server
 	server *server.Server
@@ -94,16 +114,16 @@ func NewApplication() (*Application, error) {
 		return nil, fmt.Errorf("failed to initialize repositories: %w", err)
 	}
 
-	// Initialize services
-	if err := app.initializeServices(); err != nil {
+	// Initialize metrics (the risk pipeline records rejections)
+	if err := app.initializeMetrics(); err != nil {
 		cancel()
-		return nil, fmt.Errorf("failed to initialize services: %w", err)
+		return nil, fmt.Errorf("failed to initialize metrics: %w", err)
 	}
 
-	// Initialize metrics
-	if err := app.initializeMetrics(); err != nil {
+	// Initialize services
+	if err := app.initializeServices(); err != nil {
 		cancel()
-		return nil, fmt.Errorf("failed to initialize metrics: %w", err)
+		return nil, fmt.Errorf("failed to initialize services: %w", err)
 	}
 
 	// Initialize server
@@ -140,6 +160,60 @@ func (a *Application) Start(ctx context.Context) error {
 	// Set initial service health
 	a.metricsCollector.SetServiceHealth("trading-api", true)
 
+	// Start the daily fee tier recompute
+	if a.feeEngine != nil {
+		a.waitGroup.Add(1)
+		go func() {
+			defer a.waitGroup.Done()
+			a.feeEngine.Run(a.ctx)
+		}()
+	}
+
+	// Start the market maker quote sampling
+	if a.mmService != nil {
+		a.waitGroup.Add(1)
+		go func() {
+			defer a.waitGroup.Done()
+			a.mmService.Run(a.ctx)
+		}()
+	}
+
+	// Start the surveillance owner refresh and history pruning
+	if a.surveillanceService != nil {
+		a.waitGroup.Add(1)
+		go func() {
+			defer a.waitGroup.Done()
+			a.surveillanceService.Run(a.ctx)
+		}()
+	}
+
+	// Start the daily audit trail export
+	if a.config.Audit.ExportDir != "" {
+		a.waitGroup.Add(1)
+		go func() {
+			defer a.waitGroup.Done()
+			a.auditService.Run(a.ctx)
+		}()
+	}
+
+	// Start the ledger invariant checks and reconciliation
+	if a.ledgerAuditor != nil {
+		a.waitGroup.Add(1)
+		go func() {
+			defer a.waitGroup.Done()
+			a.ledgerAuditor.Run(a.ctx)
+		}()
+	}
+
+	// Start the clearing and settlement cycle
+	if a.clearingService != nil {
+		a.waitGroup.Add(1)
+		go func() {
+			defer a.waitGroup.Done()
+			a.clearingService.Run(a.ctx)
+		}()
+	}
+
 	// Subscribe to events
 	if err := a.subscribeToEvents(); err != nil {
 		return fmt.Errorf("failed to subscribe to events: %w", err)
@@ -251,8 +325,28 @@ func (a *Application) initializeRepositories() error {
 	a.logger.Info("Initializing repositories")
 
 	a.orderRepo = repository.NewPostgresOrderRepository(a.db.GetDB())
-	a.tradeRepo = repository.NewPostgresTradeRepository(a.db.GetDB())
-	a.userRepo = repository.NewPostgresUserRepository(a.db.GetDB())
+	a.orderEventRepo = repository.NewPostgresOrderEventRepository(a.db.GetDB())
+	tradeRepo := repository.NewPostgresTradeRepository(a.db.GetDB())
+	a.tradeRepo = tradeRepo
+	userRepo := repository.NewPostgresUserRepository(a.db.GetDB())
+	a.userRepo = userRepo
+	a.apiKeyRepo = userRepo
+	a.accountRepo = repository.NewPostgresAccountRepository(a.db.GetDB())
+	a.clearingRepo = repository.NewPostgresClearingRepository(a.db.GetDB())
+	a.ledgerRepo = repository.NewPostgresLedgerRepository(a.db.GetDB())
+	a.mmRepo = repository.NewPostgresMarketMakerRepository(a.db.GetDB())
+	a.surveillanceRepo = repository.NewPostgresSurveillanceRepository(a.db.GetDB())
+
+	// Aggregate candles for trades stored before candles were
+	ctx, cancel := context.WithTimeout(a.ctx, 5*time.Minute)
+	defer cancel()
+	backfilled, err := tradeRepo.BackfillCandles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to backfill candles: %w", err)
+	}
+	if backfilled > 0 {
+		a.logger.Info("Backfilled candles from existing trades", "candles", backfilled)
+	}
 
 	a.logger.Info("Repositories initialized successfully")
 	return nil
@@ -265,17 +359,83 @@ func (a *Application) initializeServices() error {
 	// Initialize order matcher
 	a.orderMatcher = domain.NewOrderMatcher(a.logger)
 
+	// Initialize account service (buying power checks and settlement). Every
+	// balance movement is posted to the ledger, which the auditor checks.
+	if a.config.Accounts.Enabled {
+		a.accountService = domain.NewAccountService(a.accountRepo, a.cache, a.config.Accounts, a.logger)
+		a.ledgerAuditor = ledger.NewAuditor(a.ledgerRepo, a.config.Ledger.ReconcileInterval, a.logger)
+	}
+
+	// Initialize pre-trade risk checks
+	var riskPipeline *risk.Pipeline
+	if a.config.Risk.Enabled {
+		// Without accounts nothing settles into positions, so the
+		// position limit nets the user's trades instead
+		var positions shared.AccountRepository
+		if a.config.Accounts.Enabled {
+			positions = a.accountRepo
+		}
+		riskState := domain.NewRiskState(a.orderRepo, a.tradeRepo, positions)
+		riskPipeline = risk.NewPipeline("trading-api", a.config.Risk, riskState, a.metricsCollector, a.logger)
+	}
+
+	// Initialize maker/taker fees
+	if a.config.Fees.Enabled {
+		a.feeEngine = fees.NewEngine(a.config.Fees, a.tradeRepo, a.logger)
+		if a.accountService != nil {
+			a.accountService.SetFeeSchedule(a.feeEngine)
+		}
+	}
+
 	// Initialize trading service
 	a.tradingService = domain.NewTradingService(
 		a.orderRepo,
+		a.orderEventRepo,
 		a.tradeRepo,
 		a.cache,
 		a.eventBus,
 		a.orderMatcher,
+		a.accountService,
+		riskPipeline,
+		a.feeEngine,
 		a.logger,
 	)
 
-	a.logger.Info("Services initialized successfully")
+	// Initialize the designated market maker program. Compliant market
+	// makers earn rebates off their maker fees.
+	if a.config.MarketMaking.Enabled {
+		a.mmService = domain.NewMarketMakerService(a.mmRepo, a.tradingService, a.config.MarketMaking, a.logger)
+		if a.feeEngine != nil {
+			a.feeEngine.SetRebateSource(a.mmService)
+			a.mmService.SetRebateLimit(a.feeEngine)
+		}
+	}
+
+	// Initialize post-trade clearing and settlement
+	if a.config.Clearing.Enabled {
+		a.clearingService = domain.NewClearingService(a.clearingRepo, a.config.Clearing, a.logger)
+	}
+
+	// Initialize market surveillance of the order and trade flow
+	if a.config.Surveillance.Enabled {
+		detector := surveillance.NewDetector(a.config.Surveillance)
+		a.surveillanceService = domain.NewSurveillanceService(a.surveillanceRepo, detector, a.config.Surveillance, a.logger)
+	}
+
+	// Initialize the order audit trail export
+	a.auditService = domain.NewAuditService(a.orderEventRepo, a.config.Audit, a.logger)
+
+	// Initialize authentication service
+	a.authService = domain.NewAuthService(a.userRepo, a.apiKeyRepo, a.config.Auth, a.logger)
+
+	a.logger.Info("Services initialized successfully",
+		"auth_enabled", a.config.Auth.Enabled,
+		"accounts_enabled", a.config.Accounts.Enabled,
+		"risk_enabled", a.config.Risk.Enabled,
+		"clearing_enabled", a.config.Clearing.Enabled,
+		"fees_enabled", a.config.Fees.Enabled,
+		"surveillance_enabled", a.config.Surveillance.Enabled,
+	)
 	return nil
 }
 
@@ -301,18 +461,81 @@ func (a *Application) initializeServer() error {
 	orderHandler := handlers.NewOrderHandler(a.tradingService, a.metricsCollector, a.logger)
 	healthHandler := handlers.NewHealthHandler(a.db, a.cache, a.logger)
 	metricsHandler := handlers.NewMetricsHandler(a.tradingService, a.logger, time.Now())
+	apiKeyHandler := handlers.NewAPIKeyHandler(a.authService, a.logger)
+	marketDataHandler := handlers.NewMarketDataHandler(a.tradingService, a.logger)
+	auditHandler := handlers.NewAuditHandler(a.auditService, a.logger)
+
+	var accountHandler *handlers.AccountHandler
+	if a.accountService != nil {
+		accountHandler = handlers.NewAccountHandler(a.accountService, a.logger)
+	}
+
+	var ledgerHandler *handlers.LedgerHandler
+	if a.ledgerAuditor != nil {
+		ledgerHandler = handlers.NewLedgerHandler(a.ledgerAuditor, a.ledgerRepo, a.logger)
+	}
+
+	var clearingHandler *handlers.ClearingHandler
+	if a.clearingService != nil {
+		clearingHandler = handlers.NewClearingHandler(a.clearingService, a.logger)
+	}
+
+	var feeHandler *handlers.FeeHandler
+	if a.feeEngine != nil {
+		feeHandler = handlers.NewFeeHandler(domain.NewFeeService(a.feeEngine, a.tradeRepo), a.logger)
+	}
+
+	var marketMakerHandler *handlers.MarketMakerHandler
+	if a.mmService != nil {
+		marketMakerHandler = handlers.NewMarketMakerHandler(a.mmService, a.logger)
+	}
+
+	var surveillanceHandler *handlers.SurveillanceHandler
+	if a.surveillanceService != nil {
+		surveillanceHandler = handlers.NewSurveillanceHandler(a.surveillanceService, a.logger)
+	}
+
+	// Authentication is opt-in so existing simulators keep working
+	var authenticator middleware.Authenticator
+	if a.config.Auth.Enabled {
+		authenticator = a.authService
+	}
+
+	// Redis-backed limits are shared across replicas; memory limits are per process
+	var rateLimiter middleware.RateLimiter
+	if a.config.RateLimit.Enabled {
+		switch a.config.RateLimit.Backend {
+		case "redis":
+			rateLimiter = middleware.NewRedisRateLimiter(a.cache)
+		default:
+			rateLimiter = middleware.NewMemoryRateLimiter()
+		}
+	}
 
 	// Create server (pass our metrics collector so it's exposed via /metrics)
-	a.server = server.NewServer(
-		a.config,
-		orderHandler,
-		healthHandler,
-		metricsHandler,
-		a.metricsCollector,
-		a.logger,
-	)
+	a.server = server.NewServer(a.config, server.Dependencies{
+		OrderHandler:        orderHandler,
+		HealthHandler:       healthHandler,
+		MetricsHandler:      metricsHandler,
+		APIKeyHandler:       apiKeyHandler,
+		MarketDataHandler:   marketDataHandler,
+		AccountHandler:      accountHandler,
+		ClearingHandler:     clearingHandler,
+		FeeHandler:          feeHandler,
+		LedgerHandler:       ledgerHandler,
+		MarketMakerHandler:  marketMakerHandler,
+		SurveillanceHandler: surveillanceHandler,
+		AuditHandler:        auditHandler,
+		Authenticator:       authenticator,
+		RateLimiter:         rateLimiter,
+		MetricsCollector:    a.metricsCollector,
+		Logger:              a.logger,
+	})
 
-	a.logger.Info("HTTP server initialized successfully")
+	a.logger.Info("HTTP server initialized successfully",
+		"rate_limit_enabled", a.config.RateLimit.Enabled,
+		"rate_limit_backend", a.config.RateLimit.Backend,
+	)
 	return nil
 }
 
@@ -332,6 +555,20 @@ func (a *Application) subscribeToEvents() error {
 		return fmt.Errorf("failed to subscribe to market data: %w", err)
 	}
 
+	// Feed the order and trade flow to surveillance
+	if a.surveillanceService != nil {
+		surveillanceHandlers := map[shared.EventType]shared.EventHandler{
+			shared.EventTypeOrderPlaced:    a.surveillanceService.HandleOrderPlaced,
+			shared.EventTypeOrderCancelled: a.surveillanceService.HandleOrderCancelled,
+			shared.EventTypeTradeExecuted:  a.surveillanceService.HandleTradeExecuted,
+		}
+		for eventType, handler := range surveillanceHandlers {
+			if err := a.eventBus.Subscribe(a.ctx, eventType, handler); err != nil {
+				return fmt.Errorf("failed to subscribe to %s: %w", eventType, err)
+			}
+		}
+	}
+
 	a.logger.Info("Successfully subscribed to events")
 	return nil
 }
@@ -0,0 +1,244 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// PriceSource provides reference prices for sizing market order reservations
+type PriceSource interface {
+	GetPrice(ctx context.Context, symbol string) (float64, error)
+}
+
+// FeeSchedule provides the highest fee a trade in a symbol can be charged,
+// used to hold the fee of buy orders along with their cost
+type FeeSchedule interface {
+	MaxFeeBps(symbol string) float64
+}
+
+// AccountService enforces buying power and keeps balances and positions in
+// step with the order lifecycle: funds are reserved when an order is
+// accepted and released when it is cancelled. Trades are settled by the
+// trade repository as they are stored.
+type AccountService struct {
+	accountRepo shared.AccountRepository
+	prices      PriceSource
+	fees        FeeSchedule
+	config      config.AccountsConfig
+	logger      *slog.Logger
+
+	// opened remembers users whose account is known to exist
+	opened sync.Map
+}
+
+// NewAccountService creates a new account service
+func NewAccountService(
+	accountRepo shared.AccountRepository,
+	prices PriceSource,
+	cfg config.AccountsConfig,
+	logger *slog.Logger,
+) *AccountService {
+	return &AccountService{
+		accountRepo: accountRepo,
+		prices:      prices,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// SetFeeSchedule makes buy reservations include the highest fee the order
+// can be charged. It must be called before the service is used.
+func (s *AccountService) SetFeeSchedule(fees FeeSchedule) {
+	s.fees = fees
+}
+
+// Reserve holds the cash or shares needed by an order. Orders the user can't
+// cover fail with *shared.OrderRejectedError.
+func (s *AccountService) Reserve(ctx context.Context, order *shared.Order) error {
+	if err := s.ensureAccount(ctx, order.UserID); err != nil {
+		return err
+	}
+
+	reservation := &shared.Reservation{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Symbol:    order.Symbol,
+		Side:      order.Side,
+		Remaining: order.Quantity,
+		CreatedAt: time.Now(),
+	}
+
+	if order.Side == shared.OrderSideBuy {
+		price, err := s.reservationPrice(ctx, order)
+		if err != nil {
+			return err
+		}
+		reservation.Price = price
+	}
+
+	err := s.accountRepo.Reserve(ctx, reservation, s.config.InitialPosition)
+	switch err {
+	case nil:
+		return nil
+	case shared.ErrInsufficientBalance:
+		return s.rejection(ctx, order, shared.ErrCodeInsufficientBalance,
+			fmt.Sprintf("order requires %.2f cash", reservation.Amount()))
+	case shared.ErrInsufficientPosition:
+		return s.rejection(ctx, order, shared.ErrCodeInsufficientPosition,
+			fmt.Sprintf("order requires %.8g %s", reservation.Amount(), order.Symbol))
+	default:
+		return shared.NewServiceErrorWithCause("accounts", "reserve", "failed to reserve funds", err)
+	}
+}
+
+// Release returns the funds still reserved for an order
+func (s *AccountService) Release(ctx context.Context, orderID string) error {
+	reservation, err := s.accountRepo.Release(ctx, orderID)
+	if err != nil {
+		return shared.NewServiceErrorWithCause("accounts", "release", "failed to release reservation", err)
+	}
+
+	if reservation != nil {
+		s.logger.Debug("Reservation released",
+			"order_id", orderID,
+			"user_id", reservation.UserID,
+			"side", reservation.Side,
+			"amount", reservation.Amount(),
+		)
+	}
+
+	return nil
+}
+
+// Deposit credits cash to a user's account, opening the account if the user
+// has none yet
+func (s *AccountService) Deposit(ctx context.Context, userID string, amount float64) (*shared.AccountSummary, error) {
+	if err := validateCashAmount(amount); err != nil {
+		return nil, err
+	}
+	if err := s.ensureAccount(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.accountRepo.Deposit(ctx, userID, amount, uuid.New().String()); err != nil {
+		return nil, shared.NewServiceErrorWithCause("accounts", "deposit", "failed to deposit cash", err)
+	}
+
+	s.logger.Info("Cash deposited", "user_id", userID, "amount", amount)
+	return s.GetAccountSummary(ctx, userID)
+}
+
+// Withdraw debits cash from a user's account. Returns
+// shared.ErrInsufficientBalance if the amount exceeds the unreserved cash.
+func (s *AccountService) Withdraw(ctx context.Context, userID string, amount float64) (*shared.AccountSummary, error) {
+	if err := validateCashAmount(amount); err != nil {
+		return nil, err
+	}
+	if err := s.ensureAccount(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.accountRepo.Withdraw(ctx, userID, amount, uuid.New().String()); err != nil {
+		if err == shared.ErrInsufficientBalance {
+			return nil, err
+		}
+		return nil, shared.NewServiceErrorWithCause("accounts", "withdraw", "failed to withdraw cash", err)
+	}
+
+	s.logger.Info("Cash withdrawn", "user_id", userID, "amount", amount)
+	return s.GetAccountSummary(ctx, userID)
+}
+
+// GetAccountSummary returns a user's account and positions, opening the
+// account if the user has none yet
+func (s *AccountService) GetAccountSummary(ctx context.Context, userID string) (*shared.AccountSummary, error) {
+	account, err := s.accountRepo.OpenAccount(ctx, userID, s.config.InitialCash)
+	if err != nil {
+		return nil, err
+	}
+	s.opened.Store(userID, true)
+
+	positions, err := s.accountRepo.GetPositions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &shared.AccountSummary{Account: account, Positions: positions}, nil
+}
+
+// ensureAccount opens the user's account on first use
+func (s *AccountService) ensureAccount(ctx context.Context, userID string) error {
+	if _, ok := s.opened.Load(userID); ok {
+		return nil
+	}
+
+	if _, err := s.accountRepo.OpenAccount(ctx, userID, s.config.InitialCash); err != nil {
+		return shared.NewServiceErrorWithCause("accounts", "open_account", "failed to open account", err)
+	}
+
+	s.opened.Store(userID, true)
+	return nil
+}
+
+// reservationPrice returns the per-share cash to hold for a buy order,
+// including the highest fee it can be charged. Market orders are sized from
+// the order price or the latest reference price plus a buffer for slippage.
+func (s *AccountService) reservationPrice(ctx context.Context, order *shared.Order) (float64, error) {
+	feeRate := 1.0
+	if s.fees != nil {
+		feeRate += s.fees.MaxFeeBps(order.Symbol) / 10000
+	}
+
+	if order.Type == shared.OrderTypeLimit {
+		return order.Price * feeRate, nil
+	}
+
+	price := order.Price
+	if price <= 0 && s.prices != nil {
+		if reference, err := s.prices.GetPrice(ctx, order.Symbol); err == nil {
+			price = reference
+		}
+	}
+	if price <= 0 {
+		return 0, s.rejection(ctx, order, shared.ErrCodeNoReferencePrice,
+			"no reference price available to size market order")
+	}
+
+	return price * (1 + s.config.MarketOrderBuffer) * feeRate, nil
+}
+
+// rejection builds the rejection error for an order the user can't cover
+func (s *AccountService) rejection(ctx context.Context, order *shared.Order, code, reason string) error {
+	if code == shared.ErrCodeInsufficientBalance {
+		if account, err := s.accountRepo.GetAccount(ctx, order.UserID); err == nil {
+			reason = fmt.Sprintf("%s, %.2f available", reason, account.AvailableCash())
+		}
+	}
+
+	s.logger.Info("Order rejected",
+		"user_id", order.UserID,
+		"symbol", order.Symbol,
+		"side", order.Side,
+		"quantity", order.Quantity,
+		"code", code,
+		"reason", reason,
+	)
+
+	return &shared.OrderRejectedError{Order: order, Code: code, Reason: reason}
+}
+
+// validateCashAmount checks that a deposit or withdrawal amount is positive
+func validateCashAmount(amount float64) error {
+	if amount <= 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return shared.NewValidationError("amount", "must be a positive number")
+	}
+	return nil
+}
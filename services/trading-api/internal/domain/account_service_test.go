@@ -0,0 +1,413 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// memoryAccountRepo is an in-memory shared.AccountRepository with the
+// reservation and settlement semantics of the Postgres repository
+type memoryAccountRepo struct {
+	mu           sync.Mutex
+	accounts     map[string]*shared.Account
+	positions    map[string]*shared.Position
+	reservations map[string]*shared.Reservation
+}
+
+func newMemoryAccountRepo() *memoryAccountRepo {
+	return &memoryAccountRepo{
+		accounts:     make(map[string]*shared.Account),
+		positions:    make(map[string]*shared.Position),
+		reservations: make(map[string]*shared.Reservation),
+	}
+}
+
+func (r *memoryAccountRepo) OpenAccount(ctx context.Context, userID string, initialCash float64) (*shared.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.accounts[userID]; !ok {
+		r.accounts[userID] = &shared.Account{UserID: userID, CashBalance: initialCash}
+	}
+	account := *r.accounts[userID]
+	return &account, nil
+}
+
+func (r *memoryAccountRepo) GetAccount(ctx context.Context, userID string) (*shared.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[userID]
+	if !ok {
+		return nil, shared.ErrAccountNotFound
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (r *memoryAccountRepo) GetPositions(ctx context.Context, userID string) ([]*shared.Position, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var positions []*shared.Position
+	for _, position := range r.positions {
+		if position.UserID == userID {
+			copied := *position
+			positions = append(positions, &copied)
+		}
+	}
+	return positions, nil
+}
+
+func (r *memoryAccountRepo) Reserve(ctx context.Context, reservation *shared.Reservation, initialPosition float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	position := r.position(reservation.UserID, reservation.Symbol, initialPosition)
+
+	if reservation.Side == shared.OrderSideBuy {
+		account := r.accounts[reservation.UserID]
+		if account.AvailableCash() < reservation.Amount() {
+			return shared.ErrInsufficientBalance
+		}
+		account.CashReserved += reservation.Amount()
+	} else {
+		if position.AvailableQuantity() < reservation.Amount() {
+			return shared.ErrInsufficientPosition
+		}
+		position.ReservedQuantity += reservation.Amount()
+	}
+
+	copied := *reservation
+	r.reservations[reservation.OrderID] = &copied
+	return nil
+}
+
+func (r *memoryAccountRepo) Release(ctx context.Context, orderID string) (*shared.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[orderID]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.reservations, orderID)
+	r.unreserve(reservation, reservation.Amount())
+	return reservation, nil
+}
+
+// settleLocked settles a trade for memoryTradeRepo.Create, which holds the
+// lock and has checked that the buyer can pay
+func (r *memoryAccountRepo) settleLocked(trade *shared.Trade, buyerID, sellerID string) {
+	r.consume(trade.BuyOrderID, trade.Quantity)
+	r.consume(trade.SellOrderID, trade.Quantity)
+
+	r.accounts[buyerID].CashBalance -= trade.Value() + trade.BuyFee
+	bought := r.position(buyerID, trade.Symbol, 0)
+	bought.AveragePrice = (bought.Quantity*bought.AveragePrice + trade.Value()) / (bought.Quantity + trade.Quantity)
+	bought.Quantity += trade.Quantity
+
+	r.positions[sellerID+"/"+trade.Symbol].Quantity -= trade.Quantity
+	r.accounts[sellerID].CashBalance += trade.Value() - trade.SellFee
+}
+
+func (r *memoryAccountRepo) Deposit(ctx context.Context, userID string, amount float64, reference string) (*shared.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[userID]
+	if !ok {
+		return nil, shared.ErrAccountNotFound
+	}
+	account.CashBalance += amount
+	copied := *account
+	return &copied, nil
+}
+
+func (r *memoryAccountRepo) Withdraw(ctx context.Context, userID string, amount float64, reference string) (*shared.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[userID]
+	if !ok || account.AvailableCash() < amount {
+		return nil, shared.ErrInsufficientBalance
+	}
+	account.CashBalance -= amount
+	copied := *account
+	return &copied, nil
+}
+
+// position returns the user's position, opening it with initial shares
+func (r *memoryAccountRepo) position(userID, symbol string, initial float64) *shared.Position {
+	key := userID + "/" + symbol
+	if _, ok := r.positions[key]; !ok {
+		r.positions[key] = &shared.Position{UserID: userID, Symbol: symbol, Quantity: initial}
+	}
+	return r.positions[key]
+}
+
+func (r *memoryAccountRepo) consume(orderID string, quantity float64) {
+	reservation, ok := r.reservations[orderID]
+	if !ok {
+		return
+	}
+	consumed := math.Min(quantity, reservation.Remaining)
+	released := consumed
+	if reservation.Side == shared.OrderSideBuy {
+		released = consumed * reservation.Price
+	}
+	r.unreserve(reservation, released)
+	reservation.Remaining -= consumed
+	if reservation.Remaining <= 0 {
+		delete(r.reservations, orderID)
+	}
+}
+
+func (r *memoryAccountRepo) unreserve(reservation *shared.Reservation, amount float64) {
+	if reservation.Side == shared.OrderSideBuy {
+		r.accounts[reservation.UserID].CashReserved -= amount
+		return
+	}
+	r.positions[reservation.UserID+"/"+reservation.Symbol].ReservedQuantity -= amount
+}
+
+// fixedPrices is a PriceSource with a price per symbol
+type fixedPrices map[string]float64
+
+func (p fixedPrices) GetPrice(ctx context.Context, symbol string) (float64, error) {
+	if price, ok := p[symbol]; ok {
+		return price, nil
+	}
+	return 0, errors.New("no price")
+}
+
+// flatFee is a FeeSchedule charging the same fee on every symbol
+type flatFee float64
+
+func (f flatFee) MaxFeeBps(symbol string) float64 {
+	return float64(f)
+}
+
+func newTestAccountService(repo shared.AccountRepository, prices PriceSource) *AccountService {
+	return NewAccountService(repo, prices, config.AccountsConfig{
+		Enabled:           true,
+		InitialCash:       10000,
+		MarketOrderBuffer: 0.05,
+	}, discardLogger())
+}
+
+func TestAccountService_Reserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    *shared.Order
+		fees     FeeSchedule
+		reserved float64
+		code     string
+	}{
+		{
+			name:     "limit buy holds quantity times price",
+			order:    &shared.Order{ID: "o1", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeLimit, Quantity: 10, Price: 100},
+			reserved: 1000,
+		},
+		{
+			name:     "market buy holds the reference price plus the buffer",
+			order:    &shared.Order{ID: "o1", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeMarket, Quantity: 10},
+			reserved: 10 * 200 * 1.05,
+		},
+		{
+			name:     "limit buy holds the highest fee",
+			order:    &shared.Order{ID: "o1", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeLimit, Quantity: 10, Price: 100},
+			fees:     flatFee(25),
+			reserved: 1000 * 1.0025,
+		},
+		{
+			name:     "market buy holds the buffer and the highest fee",
+			order:    &shared.Order{ID: "o1", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeMarket, Quantity: 10},
+			fees:     flatFee(25),
+			reserved: 10 * 200 * 1.05 * 1.0025,
+		},
+		{
+			name:  "fee pushes a buy beyond the cash balance",
+			order: &shared.Order{ID: "o1", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeLimit, Quantity: 100, Price: 100},
+			fees:  flatFee(1),
+			code:  shared.ErrCodeInsufficientBalance,
+		},
+		{
+			name:  "market buy without a reference price",
+			order: &shared.Order{ID: "o1", UserID: "u1", Symbol: "MSFT", Side: shared.OrderSideBuy, Type: shared.OrderTypeMarket, Quantity: 10},
+			code:  shared.ErrCodeNoReferencePrice,
+		},
+		{
+			name:  "buy beyond the cash balance",
+			order: &shared.Order{ID: "o1", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeLimit, Quantity: 101, Price: 100},
+			code:  shared.ErrCodeInsufficientBalance,
+		},
+		{
+			name:  "sell without shares",
+			order: &shared.Order{ID: "o1", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideSell, Type: shared.OrderTypeLimit, Quantity: 1, Price: 100},
+			code:  shared.ErrCodeInsufficientPosition,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryAccountRepo()
+			service := newTestAccountService(repo, fixedPrices{"AAPL": 200})
+			if tt.fees != nil {
+				service.SetFeeSchedule(tt.fees)
+			}
+
+			err := service.Reserve(context.Background(), tt.order)
+			if tt.code != "" {
+				var rejected *shared.OrderRejectedError
+				require.ErrorAs(t, err, &rejected)
+				assert.Equal(t, tt.code, rejected.Code)
+				assert.Empty(t, repo.reservations)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.InDelta(t, tt.reserved, repo.accounts["u1"].CashReserved, 1e-9)
+		})
+	}
+}
+
+func TestAccountService_ReserveAndRelease(t *testing.T) {
+	repo := newMemoryAccountRepo()
+	service := newTestAccountService(repo, nil)
+	ctx := context.Background()
+
+	buy := &shared.Order{ID: "buy", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeLimit, Quantity: 60, Price: 100}
+	require.NoError(t, service.Reserve(ctx, buy))
+
+	// Reserved cash isn't available to the next order or a withdrawal
+	second := &shared.Order{ID: "second", UserID: "u1", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeLimit, Quantity: 50, Price: 100}
+	var rejected *shared.OrderRejectedError
+	require.ErrorAs(t, service.Reserve(ctx, second), &rejected)
+	assert.Contains(t, rejected.Reason, "4000.00 available")
+	_, err := service.Withdraw(ctx, "u1", 5000)
+	assert.ErrorIs(t, err, shared.ErrInsufficientBalance)
+
+	// Releasing the first order frees its cash
+	require.NoError(t, service.Release(ctx, "buy"))
+	assert.Zero(t, repo.accounts["u1"].CashReserved)
+	require.NoError(t, service.Reserve(ctx, second))
+
+	// Orders without a reservation release nothing
+	require.NoError(t, service.Release(ctx, "unknown"))
+	assert.Equal(t, 5000.0, repo.accounts["u1"].CashReserved)
+}
+
+func TestAccountService_SettleThroughTrading(t *testing.T) {
+	f := newTradingFixture()
+	repo := newMemoryAccountRepo()
+	f.service.accounts = newTestAccountService(repo, nil)
+	f.trades.accounts = repo
+	ctx := context.Background()
+
+	// The seller is deposited shares; nobody starts with a position
+	_, err := f.service.accounts.Deposit(ctx, "seller", 1)
+	require.NoError(t, err)
+	repo.position("seller", "AAPL", 10)
+
+	buy, err := f.service.PlaceOrder(ctx, limitOrder("buyer", "", shared.OrderSideBuy, 10, 100))
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, repo.accounts["buyer"].CashReserved)
+
+	_, err = f.service.PlaceOrder(ctx, limitOrder("seller", "", shared.OrderSideSell, 4, 100))
+	require.NoError(t, err)
+
+	// The fill moves cash and shares and consumes both reservations
+	buyer, seller := repo.accounts["buyer"], repo.accounts["seller"]
+	assert.Equal(t, 9600.0, buyer.CashBalance)
+	assert.Equal(t, 600.0, buyer.CashReserved)
+	assert.Equal(t, 10401.0, seller.CashBalance)
+	assert.Equal(t, 4.0, repo.positions["buyer/AAPL"].Quantity)
+	assert.Equal(t, 100.0, repo.positions["buyer/AAPL"].AveragePrice)
+	assert.Equal(t, 6.0, repo.positions["seller/AAPL"].Quantity)
+	assert.Zero(t, repo.positions["seller/AAPL"].ReservedQuantity)
+
+	// Cancelling the rest of the buy returns its cash
+	require.NoError(t, f.service.CancelOrder(ctx, buy.ID))
+	assert.Zero(t, repo.accounts["buyer"].CashReserved)
+	assert.Empty(t, repo.reservations)
+}
+
+func TestAccountService_AmendShrinksTheReservation(t *testing.T) {
+	f := newTradingFixture()
+	repo := newMemoryAccountRepo()
+	f.service.accounts = newTestAccountService(repo, nil)
+	f.orders.accounts = repo
+	f.trades.accounts = repo
+	ctx := context.Background()
+
+	_, err := f.service.accounts.Deposit(ctx, "seller", 1)
+	require.NoError(t, err)
+	repo.position("seller", "AAPL", 10)
+
+	buy, err := f.service.PlaceOrder(ctx, limitOrder("buyer", "", shared.OrderSideBuy, 10, 100))
+	require.NoError(t, err)
+	sell, err := f.service.PlaceOrder(ctx, limitOrder("seller", "", shared.OrderSideSell, 8, 120))
+	require.NoError(t, err)
+
+	// The difference is released, nothing is released and reserved again
+	_, err = f.service.AmendOrder(ctx, buy.ID, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 400.0, repo.accounts["buyer"].CashReserved)
+	assert.Equal(t, 4.0, repo.reservations[buy.ID].Remaining)
+	assert.Equal(t, 100.0, repo.reservations[buy.ID].Price)
+
+	_, err = f.service.AmendOrder(ctx, sell.ID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, repo.positions["seller/AAPL"].ReservedQuantity)
+	assert.Equal(t, 3.0, repo.reservations[sell.ID].Remaining)
+
+	// A failed amend keeps the reservation
+	f.orders.eventErr = errors.New("audit trail unavailable")
+	_, err = f.service.AmendOrder(ctx, buy.ID, 1)
+	assert.Error(t, err)
+	assert.Equal(t, 400.0, repo.accounts["buyer"].CashReserved)
+}
+
+func TestAccountService_UnpayableTradeIsNotStored(t *testing.T) {
+	f := newTradingFixture()
+	repo := newMemoryAccountRepo()
+	f.service.accounts = newTestAccountService(repo, fixedPrices{"AAPL": 100})
+	f.trades.accounts = repo
+	ctx := context.Background()
+
+	_, err := f.service.accounts.Deposit(ctx, "seller", 1)
+	require.NoError(t, err)
+	repo.position("seller", "AAPL", 100)
+	sell, err := f.service.PlaceOrder(ctx, limitOrder("seller", "", shared.OrderSideSell, 80, 150))
+	require.NoError(t, err)
+
+	// The market buy holds 80 * 105 but would pay 80 * 150, more than the
+	// buyer's cash
+	buy, err := f.service.PlaceOrder(ctx, &shared.Order{UserID: "buyer", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeMarket, Quantity: 80})
+	require.NoError(t, err)
+	assert.Equal(t, 80.0, buy.Quantity)
+
+	// Neither the trade, the fills nor any cash or shares move
+	assert.Empty(t, f.trades.trades)
+	stored, err := f.orders.GetByID(ctx, sell.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 80.0, stored.Quantity)
+	assert.Equal(t, shared.OrderStatusPending, stored.Status)
+	assert.Equal(t, 10000.0, repo.accounts["buyer"].CashBalance)
+	assert.Equal(t, 8400.0, repo.accounts["buyer"].CashReserved)
+	assert.Equal(t, 100.0, repo.positions["seller/AAPL"].Quantity)
+	assert.Equal(t, 80.0, repo.positions["seller/AAPL"].ReservedQuantity)
+	assert.Equal(t, 10001.0, repo.accounts["seller"].CashBalance)
+
+	// A buyer who can pay takes the same offer
+	_, err = f.service.accounts.Deposit(ctx, "rich", 5000)
+	require.NoError(t, err)
+	_, err = f.service.PlaceOrder(ctx, &shared.Order{UserID: "rich", Symbol: "AAPL", Side: shared.OrderSideBuy, Type: shared.OrderTypeMarket, Quantity: 80})
+	require.NoError(t, err)
+	require.Len(t, f.trades.trades, 1)
+	assert.Equal(t, 3000.0, repo.accounts["rich"].CashBalance)
+	assert.Equal(t, 80.0, repo.positions["rich/AAPL"].Quantity)
+	assert.Equal(t, 22001.0, repo.accounts["seller"].CashBalance)
+}
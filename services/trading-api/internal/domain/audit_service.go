@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"simulated_exchange/pkg/audit"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// auditExportCheckInterval is how often the daily export checks for a
+// finished day without a file
+const auditExportCheckInterval = time.Hour
+
+// AuditService exports the order audit trail as CAT-style daily files
+type AuditService struct {
+	orderEvents shared.OrderEventRepository
+	config      config.AuditConfig
+	logger      *slog.Logger
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(orderEvents shared.OrderEventRepository, cfg config.AuditConfig, logger *slog.Logger) *AuditService {
+	return &AuditService{
+		orderEvents: orderEvents,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// Run writes the previous UTC day's export file to the export directory
+// once the day is over until the context is cancelled
+func (s *AuditService) Run(ctx context.Context) {
+	ticker := time.NewTicker(auditExportCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.writeDailyFile(ctx, truncateToDay(time.Now()).AddDate(0, 0, -1)); err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to write CAT export", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExportCAT writes a UTC trade date's order events as CAT records and
+// returns the export file name
+func (s *AuditService) ExportCAT(ctx context.Context, tradeDate time.Time, w io.Writer) (string, error) {
+	tradeDate = truncateToDay(tradeDate)
+	if tradeDate.After(truncateToDay(time.Now())) {
+		return "", shared.NewValidationError("date", "must not be in the future")
+	}
+
+	events, err := s.orderEvents.GetInTimeRange(ctx, tradeDate, tradeDate.AddDate(0, 0, 1))
+	if err != nil {
+		return "", err
+	}
+
+	if err := audit.WriteCAT(w, s.config.ReporterIMID, events); err != nil {
+		return "", err
+	}
+
+	return audit.CATFileName(s.config.ReporterIMID, tradeDate), nil
+}
+
+// writeDailyFile writes a trade date's export file unless it exists. The
+// file is written under a temporary name and renamed so a partial file is
+// never mistaken for a complete one.
+func (s *AuditService) writeDailyFile(ctx context.Context, tradeDate time.Time) error {
+	if s.config.ExportDir == "" {
+		return nil
+	}
+
+	path := filepath.Join(s.config.ExportDir, audit.CATFileName(s.config.ReporterIMID, tradeDate))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.config.ExportDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(s.config.ExportDir, ".cat-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := s.ExportCAT(ctx, tradeDate, file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close export file: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to publish export file: %w", err)
+	}
+
+	s.logger.Info("CAT export written", "trade_date", tradeDate.Format("2006-01-02"), "path", path)
+	return nil
+}
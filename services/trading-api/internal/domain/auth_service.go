@@ -0,0 +1,425 @@
+package domain
+
+import (
+	"container/heap"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+const (
+	// apiKeyPrefix is prepended to every generated key ID
+	apiKeyPrefix = "ak_"
+
+	// apiKeySeparator separates the key ID from the secret in a plain API key
+	apiKeySeparator = "."
+
+	// bootstrapKeyID identifies requests authenticated with the configured admin key
+	bootstrapKeyID = "bootstrap"
+
+	// touchInterval limits how often last_used_at is written for a key
+	touchInterval = time.Minute
+
+	// signingKeyContext separates the signing key derived from a secret from
+	// the secret's stored hash
+	signingKeyContext = "simulated-exchange request signing"
+
+	// maxNonceLength bounds the nonces remembered for replay protection
+	maxNonceLength = 64
+)
+
+// AuthService authenticates API requests and manages API keys.
+//
+// Two authentication modes are supported:
+//   - Plain: the client sends "<key_id>.<secret>" as its API key.
+//   - Signed: the client sends its key ID, a unix timestamp, a unique nonce and
+//     hex(HMAC-SHA256(signing_key, timestamp + "\n" + nonce + "\n" + method + "\n" + path + "\n" + body)),
+//     where signing_key is derived from the secret by SigningKeyFromSecret.
+//
+// The signing key is stored encrypted with the configured signing key
+// encryption key, so the stored secret hash can't sign requests. Nonces are
+// remembered for the signature tolerance to reject replayed requests.
+type AuthService struct {
+	userRepo   shared.UserRepository
+	apiKeyRepo shared.APIKeyRepository
+	config     config.AuthConfig
+	logger     *slog.Logger
+	clock      clock.Clock
+
+	// nonces holds the used key ID and nonce pairs, and nonceExpiries
+	// orders them by when they may be forgotten
+	noncesMu      sync.Mutex
+	nonces        map[string]struct{}
+	nonceExpiries nonceQueue
+}
+
+// usedNonce is a key ID and nonce pair remembered until expiresAt
+type usedNonce struct {
+	id        string
+	expiresAt time.Time
+}
+
+// nonceQueue is a min-heap of used nonces by expiry
+type nonceQueue []usedNonce
+
+func (q nonceQueue) Len() int           { return len(q) }
+func (q nonceQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+func (q nonceQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *nonceQueue) Push(x any) { *q = append(*q, x.(usedNonce)) }
+
+func (q *nonceQueue) Pop() any {
+	old := *q
+	nonce := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return nonce
+}
+
+// NewAuthService creates a new authentication service
+func NewAuthService(
+	userRepo shared.UserRepository,
+	apiKeyRepo shared.APIKeyRepository,
+	cfg config.AuthConfig,
+	logger *slog.Logger,
+) *AuthService {
+	if cfg.Enabled && cfg.SigningKeyEncryptionKey == "" {
+		logger.Warn("No signing key encryption key configured; API keys can't sign requests")
+	}
+
+	return &AuthService{
+		userRepo:   userRepo,
+		apiKeyRepo: apiKeyRepo,
+		config:     cfg,
+		logger:     logger,
+		clock:      clock.Real(),
+		nonces:     make(map[string]struct{}),
+	}
+}
+
+// SetClock sets the clock signature timestamps are checked against
+func (s *AuthService) SetClock(clk clock.Clock) {
+	s.clock = clk
+}
+
+// Authenticate validates a plain "<key_id>.<secret>" API key
+func (s *AuthService) Authenticate(ctx context.Context, apiKey string) (*shared.AuthIdentity, error) {
+	if s.config.AdminKey != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(s.config.AdminKey)) == 1 {
+		return s.bootstrapIdentity(), nil
+	}
+
+	keyID, secret, ok := strings.Cut(apiKey, apiKeySeparator)
+	if !ok || keyID == "" || secret == "" {
+		return nil, shared.ErrInvalidAPIKey
+	}
+
+	key, err := s.loadActiveKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, shared.ErrInvalidAPIKey
+	}
+
+	return s.identityFor(ctx, key)
+}
+
+// AuthenticateSigned validates an HMAC-signed request. Each nonce is
+// accepted once per key within the signature tolerance.
+func (s *AuthService) AuthenticateSigned(ctx context.Context, keyID, timestamp, nonce, signature, method, path string, body []byte) (*shared.AuthIdentity, error) {
+	unixSeconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, shared.ErrInvalidSignature
+	}
+	if nonce == "" || len(nonce) > maxNonceLength {
+		return nil, shared.ErrInvalidSignature
+	}
+
+	signedAt := time.Unix(unixSeconds, 0)
+	skew := s.clock.Since(signedAt)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > s.config.SignatureTolerance {
+		return nil, shared.ErrSignatureExpired
+	}
+
+	key, err := s.loadActiveKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, err := s.decryptSigningKey(key.SigningKeyEncrypted)
+	if err != nil {
+		s.logger.Warn("API key can't verify signatures", "key_id", key.ID, "error", err)
+		return nil, shared.ErrInvalidSignature
+	}
+
+	expected := SignRequest(signingKey, timestamp, nonce, method, path, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return nil, shared.ErrInvalidSignature
+	}
+
+	// Only remember nonces of valid signatures, so others can't burn them
+	if !s.useNonce(key.ID, nonce, signedAt.Add(s.config.SignatureTolerance)) {
+		return nil, shared.ErrSignatureReplay
+	}
+
+	return s.identityFor(ctx, key)
+}
+
+// useNonce records a nonce of a key until expiresAt and reports whether it
+// was unused. Expired nonces are forgotten, as their timestamps are no
+// longer accepted; only those are visited, soonest first.
+func (s *AuthService) useNonce(keyID, nonce string, expiresAt time.Time) bool {
+	s.noncesMu.Lock()
+	defer s.noncesMu.Unlock()
+
+	now := s.clock.Now()
+	for len(s.nonceExpiries) > 0 && !s.nonceExpiries[0].expiresAt.After(now) {
+		expired := heap.Pop(&s.nonceExpiries).(usedNonce)
+		delete(s.nonces, expired.id)
+	}
+
+	id := keyID + "\n" + nonce
+	if _, used := s.nonces[id]; used {
+		return false
+	}
+	s.nonces[id] = struct{}{}
+	heap.Push(&s.nonceExpiries, usedNonce{id: id, expiresAt: expiresAt})
+	return true
+}
+
+// CreateAPIKey issues a new API key for a user. The returned plain key is
+// only available at creation time.
+func (s *AuthService) CreateAPIKey(ctx context.Context, userID, name string, scopes []shared.APIKeyScope) (*shared.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", shared.NewValidationError("scopes", "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !isValidScope(scope) {
+			return nil, "", shared.NewValidationError("scopes", fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !user.IsActive {
+		return nil, "", shared.NewBusinessError(shared.ErrCodeForbidden, "user is not active")
+	}
+
+	keyID, err := randomHex(8)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate key ID: %w", err)
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate key secret: %w", err)
+	}
+
+	key := &shared.APIKey{
+		ID:         apiKeyPrefix + keyID,
+		UserID:     userID,
+		Name:       name,
+		SecretHash: hashSecret(secret),
+		Scopes:     scopes,
+		CreatedAt:  s.clock.Now(),
+	}
+	if s.config.SigningKeyEncryptionKey != "" {
+		if key.SigningKeyEncrypted, err = s.encryptSigningKey(SigningKeyFromSecret(secret)); err != nil {
+			return nil, "", fmt.Errorf("failed to encrypt signing key: %w", err)
+		}
+	}
+
+	if err := s.apiKeyRepo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", shared.NewServiceErrorWithCause("auth", "create_api_key", "failed to save api key", err)
+	}
+
+	s.logger.Info("API key created", "key_id", key.ID, "user_id", userID, "scopes", scopes)
+
+	return key, key.ID + apiKeySeparator + secret, nil
+}
+
+// ListAPIKeys returns all API keys issued to a user
+func (s *AuthService) ListAPIKeys(ctx context.Context, userID string) ([]*shared.APIKey, error) {
+	return s.apiKeyRepo.GetAPIKeysByUserID(ctx, userID)
+}
+
+// RevokeAPIKey revokes an API key owned by the caller, or any key for admins
+func (s *AuthService) RevokeAPIKey(ctx context.Context, identity *shared.AuthIdentity, keyID string) error {
+	key, err := s.apiKeyRepo.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	if !identity.CanAccessUser(key.UserID) {
+		// Don't reveal the existence of other users' keys
+		return shared.ErrAPIKeyNotFound
+	}
+
+	if err := s.apiKeyRepo.RevokeAPIKey(ctx, keyID); err != nil {
+		return err
+	}
+
+	s.logger.Info("API key revoked", "key_id", keyID, "user_id", key.UserID, "revoked_by", identity.UserID)
+	return nil
+}
+
+// loadActiveKey fetches a key and ensures it has not been revoked
+func (s *AuthService) loadActiveKey(ctx context.Context, keyID string) (*shared.APIKey, error) {
+	key, err := s.apiKeyRepo.GetAPIKey(ctx, keyID)
+	if err != nil {
+		if err == shared.ErrAPIKeyNotFound {
+			return nil, shared.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if key.IsRevoked() {
+		return nil, shared.ErrAPIKeyRevoked
+	}
+
+	return key, nil
+}
+
+// identityFor builds the authenticated identity for a valid key
+func (s *AuthService) identityFor(ctx context.Context, key *shared.APIKey) (*shared.AuthIdentity, error) {
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if err == shared.ErrUserNotFound {
+			return nil, shared.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, shared.ErrUnauthorized
+	}
+
+	now := s.clock.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > touchInterval {
+		if err := s.apiKeyRepo.TouchAPIKey(ctx, key.ID, now); err != nil {
+			s.logger.Warn("Failed to record API key usage", "key_id", key.ID, "error", err)
+		}
+	}
+
+	return &shared.AuthIdentity{
+		UserID: key.UserID,
+		KeyID:  key.ID,
+		Scopes: key.Scopes,
+	}, nil
+}
+
+// bootstrapIdentity returns the identity used for the configured admin key
+func (s *AuthService) bootstrapIdentity() *shared.AuthIdentity {
+	return &shared.AuthIdentity{
+		UserID: s.config.AdminUserID,
+		KeyID:  bootstrapKeyID,
+		Scopes: []shared.APIKeyScope{shared.APIKeyScopeAdmin},
+	}
+}
+
+// SignRequest computes the request signature for the given signing key
+func SignRequest(signingKey, timestamp, nonce, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n" + strings.ToUpper(method) + "\n" + path + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SigningKeyFromSecret derives the HMAC signing key from a key secret. It
+// is hex(HMAC-SHA256(secret, "simulated-exchange request signing")), which
+// can't be computed from the stored secret hash.
+func SigningKeyFromSecret(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingKeyContext))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// encryptSigningKey seals a signing key with AES-256-GCM under the
+// configured encryption key
+func (s *AuthService) encryptSigningKey(signingKey string) (string, error) {
+	aead, err := s.signingKeyCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(signingKey), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSigningKey opens a signing key sealed by encryptSigningKey
+func (s *AuthService) decryptSigningKey(encrypted string) (string, error) {
+	if encrypted == "" {
+		return "", errors.New("key has no signing key")
+	}
+	aead, err := s.signingKeyCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed signing key")
+	}
+	signingKey, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt signing key: %w", err)
+	}
+	return string(signingKey), nil
+}
+
+// signingKeyCipher returns the AEAD keyed by the SHA-256 of the configured
+// encryption key
+func (s *AuthService) signingKeyCipher() (cipher.AEAD, error) {
+	if s.config.SigningKeyEncryptionKey == "" {
+		return nil, errors.New("no signing key encryption key configured")
+	}
+	key := sha256.Sum256([]byte(s.config.SigningKeyEncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func isValidScope(scope shared.APIKeyScope) bool {
+	switch scope {
+	case shared.APIKeyScopeTrade, shared.APIKeyScopeRead, shared.APIKeyScopeAdmin:
+		return true
+	}
+	return false
+}
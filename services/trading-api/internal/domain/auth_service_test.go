@@ -0,0 +1,364 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// memoryUserRepo is an in-memory shared.UserRepository
+type memoryUserRepo struct {
+	users map[string]*shared.User
+}
+
+func (r *memoryUserRepo) Create(ctx context.Context, user *shared.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memoryUserRepo) GetByID(ctx context.Context, id string) (*shared.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, shared.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepo) GetByUsername(ctx context.Context, username string) (*shared.User, error) {
+	return nil, shared.ErrUserNotFound
+}
+
+func (r *memoryUserRepo) GetByEmail(ctx context.Context, email string) (*shared.User, error) {
+	return nil, shared.ErrUserNotFound
+}
+
+func (r *memoryUserRepo) Update(ctx context.Context, user *shared.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memoryUserRepo) Delete(ctx context.Context, id string) error {
+	delete(r.users, id)
+	return nil
+}
+
+// memoryAPIKeyRepo is an in-memory shared.APIKeyRepository
+type memoryAPIKeyRepo struct {
+	mu   sync.Mutex
+	keys map[string]*shared.APIKey
+}
+
+func (r *memoryAPIKeyRepo) CreateAPIKey(ctx context.Context, key *shared.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *key
+	r.keys[key.ID] = &stored
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) GetAPIKey(ctx context.Context, keyID string) (*shared.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[keyID]
+	if !ok {
+		return nil, shared.ErrAPIKeyNotFound
+	}
+	stored := *key
+	return &stored, nil
+}
+
+func (r *memoryAPIKeyRepo) GetAPIKeysByUserID(ctx context.Context, userID string) ([]*shared.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []*shared.APIKey
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepo) RevokeAPIKey(ctx context.Context, keyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.keys[keyID].RevokedAt = &now
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[keyID].LastUsedAt = &usedAt
+	return nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestAuthService returns an auth service on a virtual clock with an
+// API key of the given scopes for an active user
+func newTestAuthService(t *testing.T, scopes ...shared.APIKeyScope) (*AuthService, *clock.Virtual, *memoryAPIKeyRepo, *shared.APIKey, string) {
+	t.Helper()
+
+	users := &memoryUserRepo{users: map[string]*shared.User{
+		"user-1": {ID: "user-1", Username: "trader", IsActive: true},
+	}}
+	keys := &memoryAPIKeyRepo{keys: make(map[string]*shared.APIKey)}
+	service := NewAuthService(users, keys, config.AuthConfig{
+		Enabled:                 true,
+		AdminKey:                "bootstrap-secret",
+		AdminUserID:             "admin",
+		SignatureTolerance:      5 * time.Minute,
+		SigningKeyEncryptionKey: "encryption-key",
+	}, discardLogger())
+
+	clk := clock.NewVirtual(time.Unix(1_700_000_000, 0))
+	service.SetClock(clk)
+
+	key, plain, err := service.CreateAPIKey(context.Background(), "user-1", "test", scopes)
+	require.NoError(t, err)
+	return service, clk, keys, key, plain
+}
+
+// secretOf returns the secret of a plain API key
+func secretOf(plain string) string {
+	return plain[len(plain)-64:]
+}
+
+func TestAuthService_Authenticate(t *testing.T) {
+	service, _, _, key, plain := newTestAuthService(t, shared.APIKeyScopeRead)
+	ctx := context.Background()
+
+	identity, err := service.Authenticate(ctx, plain)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", identity.UserID)
+	assert.Equal(t, key.ID, identity.KeyID)
+
+	_, err = service.Authenticate(ctx, key.ID+".wrong")
+	assert.Equal(t, shared.ErrInvalidAPIKey, err)
+	_, err = service.Authenticate(ctx, "no-separator")
+	assert.Equal(t, shared.ErrInvalidAPIKey, err)
+
+	identity, err = service.Authenticate(ctx, "bootstrap-secret")
+	require.NoError(t, err)
+	assert.True(t, identity.IsAdmin())
+
+	require.NoError(t, service.RevokeAPIKey(ctx, identity, key.ID))
+	_, err = service.Authenticate(ctx, plain)
+	assert.Equal(t, shared.ErrAPIKeyRevoked, err)
+}
+
+func TestAuthService_AuthenticateSigned(t *testing.T) {
+	service, clk, _, key, plain := newTestAuthService(t, shared.APIKeyScopeTrade)
+	ctx := context.Background()
+	signingKey := SigningKeyFromSecret(secretOf(plain))
+	body := []byte(`{"symbol":"BTCUSD"}`)
+	timestamp := strconv.FormatInt(clk.Now().Unix(), 10)
+
+	signature := SignRequest(signingKey, timestamp, "n-1", "POST", "/api/v1/orders", body)
+	identity, err := service.AuthenticateSigned(ctx, key.ID, timestamp, "n-1", signature, "post", "/api/v1/orders", body)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", identity.UserID)
+	assert.True(t, identity.HasScope(shared.APIKeyScopeTrade))
+	assert.False(t, identity.HasScope(shared.APIKeyScopeAdmin))
+
+	tests := []struct {
+		name      string
+		timestamp string
+		nonce     string
+		path      string
+		body      string
+		want      error
+	}{
+		{"tampered body", timestamp, "n-2", "/api/v1/orders", `{"symbol":"ETHUSD"}`, shared.ErrInvalidSignature},
+		{"tampered path", timestamp, "n-2", "/api/v1/orders/batch", `{"symbol":"BTCUSD"}`, shared.ErrInvalidSignature},
+		{"missing nonce", timestamp, "", "/api/v1/orders", `{"symbol":"BTCUSD"}`, shared.ErrInvalidSignature},
+		{"malformed timestamp", "yesterday", "n-2", "/api/v1/orders", `{"symbol":"BTCUSD"}`, shared.ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Signed over the original request, sent with the test's changes
+			signature := SignRequest(signingKey, tt.timestamp, "n-2", "POST", "/api/v1/orders", body)
+			_, err := service.AuthenticateSigned(ctx, key.ID, tt.timestamp, tt.nonce, signature, "POST", tt.path, []byte(tt.body))
+			assert.Equal(t, tt.want, err)
+		})
+	}
+
+	_, err = service.AuthenticateSigned(ctx, "ak_unknown", timestamp, "n-3", signature, "POST", "/api/v1/orders", body)
+	assert.Equal(t, shared.ErrInvalidAPIKey, err)
+}
+
+func TestAuthService_StoredHashCannotSign(t *testing.T) {
+	service, clk, keys, key, _ := newTestAuthService(t, shared.APIKeyScopeTrade)
+	ctx := context.Background()
+	timestamp := strconv.FormatInt(clk.Now().Unix(), 10)
+
+	// Everything the database holds is useless for signing
+	stored, err := keys.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored.SigningKeyEncrypted, stored.SecretHash)
+
+	for _, forgedKey := range []string{stored.SecretHash, stored.SigningKeyEncrypted} {
+		signature := SignRequest(forgedKey, timestamp, "n-1", "GET", "/api/v1/orders", nil)
+		_, err := service.AuthenticateSigned(ctx, key.ID, timestamp, "n-1", signature, "GET", "/api/v1/orders", nil)
+		assert.Equal(t, shared.ErrInvalidSignature, err)
+	}
+}
+
+func TestAuthService_SignatureTimestampSkew(t *testing.T) {
+	service, clk, _, key, plain := newTestAuthService(t, shared.APIKeyScopeRead)
+	ctx := context.Background()
+	signingKey := SigningKeyFromSecret(secretOf(plain))
+
+	tests := []struct {
+		name string
+		skew time.Duration
+		want error
+	}{
+		{"on time", 0, nil},
+		{"late within tolerance", -4 * time.Minute, nil},
+		{"early within tolerance", 4 * time.Minute, nil},
+		{"too old", -6 * time.Minute, shared.ErrSignatureExpired},
+		{"too far ahead", 6 * time.Minute, shared.ErrSignatureExpired},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamp := strconv.FormatInt(clk.Now().Add(tt.skew).Unix(), 10)
+			nonce := "nonce-" + strconv.Itoa(i)
+			signature := SignRequest(signingKey, timestamp, nonce, "GET", "/api/v1/orders", nil)
+			_, err := service.AuthenticateSigned(ctx, key.ID, timestamp, nonce, signature, "GET", "/api/v1/orders", nil)
+			assert.Equal(t, tt.want, err)
+		})
+	}
+}
+
+func TestAuthService_RejectsReplayedNonce(t *testing.T) {
+	service, clk, _, key, plain := newTestAuthService(t, shared.APIKeyScopeRead)
+	ctx := context.Background()
+	signingKey := SigningKeyFromSecret(secretOf(plain))
+
+	send := func(nonce string) error {
+		timestamp := strconv.FormatInt(clk.Now().Unix(), 10)
+		signature := SignRequest(signingKey, timestamp, nonce, "GET", "/api/v1/orders", nil)
+		_, err := service.AuthenticateSigned(ctx, key.ID, timestamp, nonce, signature, "GET", "/api/v1/orders", nil)
+		return err
+	}
+
+	require.NoError(t, send("n-1"))
+	assert.Equal(t, shared.ErrSignatureReplay, send("n-1"))
+	assert.NoError(t, send("n-2"))
+
+	// An invalid signature doesn't burn the nonce
+	timestamp := strconv.FormatInt(clk.Now().Unix(), 10)
+	_, err := service.AuthenticateSigned(ctx, key.ID, timestamp, "n-3", "00", "GET", "/api/v1/orders", nil)
+	assert.Equal(t, shared.ErrInvalidSignature, err)
+	assert.NoError(t, send("n-3"))
+
+	// Once the window has passed the nonce is forgotten
+	clk.Advance(6 * time.Minute)
+	assert.NoError(t, send("n-1"))
+	assert.Len(t, service.nonces, 1)
+	assert.Len(t, service.nonceExpiries, 1)
+}
+
+func TestAuthService_NoncesExpireByTimestamp(t *testing.T) {
+	service, clk, _, key, plain := newTestAuthService(t, shared.APIKeyScopeRead)
+	ctx := context.Background()
+	signingKey := SigningKeyFromSecret(secretOf(plain))
+
+	send := func(nonce string, skew time.Duration) error {
+		timestamp := strconv.FormatInt(clk.Now().Add(skew).Unix(), 10)
+		signature := SignRequest(signingKey, timestamp, nonce, "GET", "/api/v1/orders", nil)
+		_, err := service.AuthenticateSigned(ctx, key.ID, timestamp, nonce, signature, "GET", "/api/v1/orders", nil)
+		return err
+	}
+
+	// Nonces are forgotten by when their timestamps expire, not by arrival
+	require.NoError(t, send("late", 4*time.Minute))
+	require.NoError(t, send("early", -4*time.Minute))
+	require.NoError(t, send("now", 0))
+
+	clk.Advance(2 * time.Minute)
+	require.NoError(t, send("tick", 0))
+	assert.Len(t, service.nonces, 3)
+	assert.Equal(t, shared.ErrSignatureReplay, send("now", -2*time.Minute))
+	assert.Equal(t, shared.ErrSignatureReplay, send("late", 2*time.Minute))
+
+	clk.Advance(4 * time.Minute)
+	require.NoError(t, send("tock", 0))
+	assert.Len(t, service.nonces, 3)
+	assert.Len(t, service.nonceExpiries, 3)
+	assert.Equal(t, shared.ErrSignatureReplay, send("late", -2*time.Minute))
+}
+
+func TestAuthService_KeyTimesFollowTheClock(t *testing.T) {
+	service, clk, keys, key, plain := newTestAuthService(t, shared.APIKeyScopeRead)
+	ctx := context.Background()
+	assert.Equal(t, clk.Now(), key.CreatedAt)
+
+	clk.Advance(time.Hour)
+	_, err := service.Authenticate(ctx, plain)
+	require.NoError(t, err)
+	stored, err := keys.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.Equal(t, clk.Now(), *stored.LastUsedAt)
+
+	// Usage is recorded at most once per touch interval
+	clk.Advance(touchInterval / 2)
+	_, err = service.Authenticate(ctx, plain)
+	require.NoError(t, err)
+	stored, err = keys.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, clk.Now().Add(-touchInterval/2), *stored.LastUsedAt)
+}
+
+func TestAuthService_SigningRequiresEncryptionKey(t *testing.T) {
+	service, clk, keys, key, plain := newTestAuthService(t, shared.APIKeyScopeRead)
+	ctx := context.Background()
+	timestamp := strconv.FormatInt(clk.Now().Unix(), 10)
+	signature := SignRequest(SigningKeyFromSecret(secretOf(plain)), timestamp, "n-1", "GET", "/", nil)
+
+	// A key stored without a signing key only authenticates plainly
+	stored, err := keys.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	stored.SigningKeyEncrypted = ""
+	require.NoError(t, keys.CreateAPIKey(ctx, stored))
+
+	_, err = service.AuthenticateSigned(ctx, key.ID, timestamp, "n-1", signature, "GET", "/", nil)
+	assert.Equal(t, shared.ErrInvalidSignature, err)
+	_, err = service.Authenticate(ctx, plain)
+	assert.NoError(t, err)
+
+	// Without the encryption key no signing key is stored
+	service.config.SigningKeyEncryptionKey = ""
+	created, _, err := service.CreateAPIKey(ctx, "user-1", "plain", []shared.APIKeyScope{shared.APIKeyScopeRead})
+	require.NoError(t, err)
+	assert.Empty(t, created.SigningKeyEncrypted)
+}
+
+func TestAuthService_CreateAPIKeyValidatesScopes(t *testing.T) {
+	service, _, _, _, _ := newTestAuthService(t, shared.APIKeyScopeRead)
+	ctx := context.Background()
+
+	_, _, err := service.CreateAPIKey(ctx, "user-1", "none", nil)
+	assert.Error(t, err)
+	_, _, err = service.CreateAPIKey(ctx, "user-1", "unknown", []shared.APIKeyScope{"superuser"})
+	assert.Error(t, err)
+	_, _, err = service.CreateAPIKey(ctx, "user-2", "missing user", []shared.APIKeyScope{shared.APIKeyScopeRead})
+	assert.Equal(t, shared.ErrUserNotFound, err)
+}
@@ -0,0 +1,301 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// settlementBatchSize bounds the obligations settled per cycle
+const settlementBatchSize = 1000
+
+// ClearingService nets each day's trades into settlement obligations and
+// settles them T+N business days later. Settlement attempts fail at random
+// with the configured fail rate so settlement risk can be studied alongside
+// the chaos scenarios; failed obligations are retried every cycle.
+type ClearingService struct {
+	clearingRepo shared.ClearingRepository
+	config       config.ClearingConfig
+	clock        clock.Clock
+	logger       *slog.Logger
+
+	failRate float64
+	random   *rand.Rand
+	mutex    sync.Mutex
+}
+
+// NewClearingService creates a new clearing service
+func NewClearingService(
+	clearingRepo shared.ClearingRepository,
+	cfg config.ClearingConfig,
+	logger *slog.Logger,
+) *ClearingService {
+	return &ClearingService{
+		clearingRepo: clearingRepo,
+		config:       cfg,
+		clock:        clock.Real(),
+		logger:       logger,
+		failRate:     cfg.FailRate,
+		random:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetClock sets the clock that decides which trade dates are over and when
+// cycles run
+func (s *ClearingService) SetClock(clk clock.Clock) {
+	s.clock = clk
+}
+
+// Run runs a clearing cycle every CycleInterval until the context is done
+func (s *ClearingService) Run(ctx context.Context) {
+	ticker := s.clock.NewTicker(s.config.CycleInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.runCycle(ctx, s.clock.Now().UTC()); err != nil && ctx.Err() == nil {
+			s.logger.Error("Clearing cycle failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// RunClearing nets a trade date and settles everything due as of asOf. It
+// lets operators fast-forward settlement instead of waiting for T+N. Only
+// trade dates that are over can be cleared: obligations of a day still
+// trading would miss its later trades once they start settling.
+func (s *ClearingService) RunClearing(ctx context.Context, tradeDate, asOf time.Time) (*shared.SettlementReport, error) {
+	tradeDate = truncateToDay(tradeDate)
+	if !tradeDate.Before(truncateToDay(s.clock.Now())) {
+		return nil, shared.NewValidationError("trade_date", "must be before the current UTC day")
+	}
+
+	if _, err := s.clearingRepo.NetTrades(ctx, tradeDate, s.SettlementDate(tradeDate)); err != nil {
+		return nil, err
+	}
+	if err := s.settleDue(ctx, asOf); err != nil {
+		return nil, err
+	}
+
+	return s.GetReport(ctx, tradeDate)
+}
+
+// SetFailRate changes the probability that a settlement attempt fails
+func (s *ClearingService) SetFailRate(rate float64) error {
+	if rate < 0 || rate > 1 || math.IsNaN(rate) {
+		return shared.NewValidationError("rate", "must be between 0 and 1")
+	}
+
+	s.mutex.Lock()
+	previous := s.failRate
+	s.failRate = rate
+	s.mutex.Unlock()
+
+	s.logger.Info("Settlement fail rate changed", "previous", previous, "rate", rate)
+	return nil
+}
+
+// FailRate returns the current settlement fail rate
+func (s *ClearingService) FailRate() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.failRate
+}
+
+// SettlementDate returns the date trades of a trade date settle on,
+// SettlementDays business days later
+func (s *ClearingService) SettlementDate(tradeDate time.Time) time.Time {
+	date := truncateToDay(tradeDate)
+	for days := 0; days < s.config.SettlementDays; {
+		date = date.AddDate(0, 0, 1)
+		if date.Weekday() != time.Saturday && date.Weekday() != time.Sunday {
+			days++
+		}
+	}
+	return date
+}
+
+// GetReport builds the end-of-day settlement report for a trade date
+func (s *ClearingService) GetReport(ctx context.Context, tradeDate time.Time) (*shared.SettlementReport, error) {
+	tradeDate = truncateToDay(tradeDate)
+
+	obligations, err := s.clearingRepo.GetObligations(ctx, tradeDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &shared.SettlementReport{
+		TradeDate:      tradeDate,
+		SettlementDate: s.SettlementDate(tradeDate),
+		Obligations:    len(obligations),
+		Symbols:        make([]*shared.SymbolSettlementSummary, 0),
+		Fails:          make([]*shared.SettlementObligation, 0),
+		GeneratedAt:    s.clock.Now(),
+	}
+
+	bySymbol := make(map[string]*shared.SymbolSettlementSummary)
+	for _, obligation := range obligations {
+		summary, exists := bySymbol[obligation.Symbol]
+		if !exists {
+			summary = &shared.SymbolSettlementSummary{Symbol: obligation.Symbol}
+			bySymbol[obligation.Symbol] = summary
+			report.Symbols = append(report.Symbols, summary)
+		}
+
+		summary.Obligations++
+		if obligation.NetQuantity < 0 {
+			summary.DeliverQuantity -= obligation.NetQuantity
+		}
+		report.GrossNotional += obligation.GrossNotional
+		report.NetCash += math.Abs(obligation.NetCash)
+
+		switch obligation.Status {
+		case shared.SettlementStatusSettled:
+			report.Settled++
+			summary.Settled++
+		case shared.SettlementStatusFailed:
+			report.Failed++
+			summary.Failed++
+			summary.FailedQuantity += math.Abs(obligation.NetQuantity)
+			summary.FailedValue += math.Abs(obligation.NetCash)
+			report.FailedValue += math.Abs(obligation.NetCash)
+			report.Fails = append(report.Fails, obligation)
+		default:
+			report.Pending++
+			summary.Pending++
+		}
+	}
+
+	if report.GrossNotional > 0 {
+		report.NettingEfficiency = 1 - report.NetCash/report.GrossNotional
+	}
+	if attempted := report.Settled + report.Failed; attempted > 0 {
+		report.FailRate = float64(report.Failed) / float64(attempted)
+	}
+
+	sort.Slice(report.Symbols, func(i, j int) bool {
+		return report.Symbols[i].Symbol < report.Symbols[j].Symbol
+	})
+
+	return report, nil
+}
+
+// runCycle nets every past trade date that hasn't been cleared and settles
+// the obligations that are due
+func (s *ClearingService) runCycle(ctx context.Context, now time.Time) error {
+	tradeDates, err := s.clearingRepo.GetUnclearedTradeDates(ctx, truncateToDay(now))
+	if err != nil {
+		return err
+	}
+
+	for _, tradeDate := range tradeDates {
+		settlementDate := s.SettlementDate(tradeDate)
+		obligations, err := s.clearingRepo.NetTrades(ctx, tradeDate, settlementDate)
+		if err != nil {
+			return fmt.Errorf("failed to clear %s: %w", tradeDate.Format(time.DateOnly), err)
+		}
+
+		s.logger.Info("Trade date cleared",
+			"trade_date", tradeDate.Format(time.DateOnly),
+			"settlement_date", settlementDate.Format(time.DateOnly),
+			"obligations", obligations,
+		)
+		s.logReport(ctx, tradeDate)
+	}
+
+	return s.settleDue(ctx, now)
+}
+
+// settleDue attempts to settle every obligation due as of asOf
+func (s *ClearingService) settleDue(ctx context.Context, asOf time.Time) error {
+	obligations, err := s.clearingRepo.GetDueObligations(ctx, asOf, settlementBatchSize)
+	if err != nil {
+		return err
+	}
+
+	settled, failed := 0, 0
+	for _, obligation := range obligations {
+		if reason := s.injectFailure(obligation); reason != "" {
+			if err := s.clearingRepo.MarkFailed(ctx, obligation.ID, reason); err != nil {
+				return err
+			}
+			failed++
+
+			s.logger.Warn("Settlement failed",
+				"obligation_id", obligation.ID,
+				"user_id", obligation.UserID,
+				"symbol", obligation.Symbol,
+				"reason", reason,
+				"fail_count", obligation.FailCount+1,
+			)
+			continue
+		}
+
+		if err := s.clearingRepo.MarkSettled(ctx, obligation.ID, asOf); err != nil {
+			return err
+		}
+		settled++
+	}
+
+	if len(obligations) > 0 {
+		s.logger.Info("Settlement cycle completed", "due", len(obligations), "settled", settled, "failed", failed)
+	}
+	return nil
+}
+
+// injectFailure decides whether a settlement attempt fails and why. Sellers
+// fail to deliver shares; buyers fail to pay cash.
+func (s *ClearingService) injectFailure(obligation *shared.SettlementObligation) string {
+	s.mutex.Lock()
+	fail := s.random.Float64() < s.failRate
+	s.mutex.Unlock()
+
+	switch {
+	case !fail:
+		return ""
+	case obligation.NetQuantity < 0:
+		return shared.SettlementFailToDeliver
+	case obligation.NetCash < 0:
+		return shared.SettlementFailToPay
+	default:
+		// Nothing to deliver or pay, so nothing can fail
+		return ""
+	}
+}
+
+// logReport logs the end-of-day summary of a trade date
+func (s *ClearingService) logReport(ctx context.Context, tradeDate time.Time) {
+	report, err := s.GetReport(ctx, tradeDate)
+	if err != nil {
+		s.logger.Warn("Failed to build settlement report", "error", err, "trade_date", tradeDate.Format(time.DateOnly))
+		return
+	}
+
+	s.logger.Info("End of day settlement report",
+		"trade_date", report.TradeDate.Format(time.DateOnly),
+		"settlement_date", report.SettlementDate.Format(time.DateOnly),
+		"obligations", report.Obligations,
+		"symbols", len(report.Symbols),
+		"gross_notional", report.GrossNotional,
+		"net_cash", report.NetCash,
+		"netting_efficiency", report.NettingEfficiency,
+	)
+}
+
+// truncateToDay returns midnight UTC of the time's UTC date
+func truncateToDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,261 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// clearingLeg is one side of a trade, as the clearing repository nets it
+type clearingLeg struct {
+	at       time.Time
+	userID   string
+	symbol   string
+	quantity float64
+	cash     float64
+	notional float64
+}
+
+// memoryClearingRepo is an in-memory shared.ClearingRepository that nets
+// trade legs the way the Postgres repository nets trades
+type memoryClearingRepo struct {
+	legs        []clearingLeg
+	obligations map[string]*shared.SettlementObligation
+	cleared     map[time.Time]bool
+}
+
+func newMemoryClearingRepo() *memoryClearingRepo {
+	return &memoryClearingRepo{
+		obligations: make(map[string]*shared.SettlementObligation),
+		cleared:     make(map[time.Time]bool),
+	}
+}
+
+// trade records a trade between buyer and seller, fees included
+func (r *memoryClearingRepo) trade(at time.Time, buyerID, sellerID, symbol string, quantity, price, buyFee, sellFee float64) {
+	value := quantity * price
+	r.legs = append(r.legs,
+		clearingLeg{at: at, userID: buyerID, symbol: symbol, quantity: quantity, cash: -value - buyFee, notional: value},
+		clearingLeg{at: at, userID: sellerID, symbol: symbol, quantity: -quantity, cash: value - sellFee, notional: value},
+	)
+}
+
+func (r *memoryClearingRepo) NetTrades(ctx context.Context, tradeDate, settlementDate time.Time) (int, error) {
+	netted := make(map[string]*shared.SettlementObligation)
+	for _, leg := range r.legs {
+		if !truncateToDay(leg.at).Equal(tradeDate) {
+			continue
+		}
+		id := fmt.Sprintf("%s/%s/%s", tradeDate.Format(time.DateOnly), leg.userID, leg.symbol)
+		obligation, ok := netted[id]
+		if !ok {
+			obligation = &shared.SettlementObligation{
+				ID: id, TradeDate: tradeDate, SettlementDate: settlementDate,
+				UserID: leg.userID, Symbol: leg.symbol, Status: shared.SettlementStatusPending,
+			}
+			netted[id] = obligation
+		}
+		obligation.NetQuantity += leg.quantity
+		obligation.NetCash += leg.cash
+		obligation.GrossNotional += leg.notional
+		obligation.TradeCount++
+	}
+
+	for id, obligation := range netted {
+		if existing, ok := r.obligations[id]; ok && existing.Status != shared.SettlementStatusPending {
+			continue
+		}
+		r.obligations[id] = obligation
+	}
+	r.cleared[tradeDate] = true
+	return len(netted), nil
+}
+
+func (r *memoryClearingRepo) GetUnclearedTradeDates(ctx context.Context, before time.Time) ([]time.Time, error) {
+	seen := make(map[time.Time]bool)
+	var dates []time.Time
+	for _, leg := range r.legs {
+		date := truncateToDay(leg.at)
+		if date.Before(before) && !r.cleared[date] && !seen[date] {
+			seen[date] = true
+			dates = append(dates, date)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates, nil
+}
+
+func (r *memoryClearingRepo) GetDueObligations(ctx context.Context, asOf time.Time, limit int) ([]*shared.SettlementObligation, error) {
+	var due []*shared.SettlementObligation
+	for _, obligation := range r.obligations {
+		if obligation.Status != shared.SettlementStatusSettled && !obligation.SettlementDate.After(asOf) {
+			copied := *obligation
+			due = append(due, &copied)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *memoryClearingRepo) GetObligations(ctx context.Context, tradeDate time.Time) ([]*shared.SettlementObligation, error) {
+	var obligations []*shared.SettlementObligation
+	for _, obligation := range r.obligations {
+		if obligation.TradeDate.Equal(tradeDate) {
+			copied := *obligation
+			obligations = append(obligations, &copied)
+		}
+	}
+	sort.Slice(obligations, func(i, j int) bool { return obligations[i].ID < obligations[j].ID })
+	return obligations, nil
+}
+
+func (r *memoryClearingRepo) MarkSettled(ctx context.Context, id string, settledAt time.Time) error {
+	r.obligations[id].Status = shared.SettlementStatusSettled
+	r.obligations[id].SettledAt = &settledAt
+	return nil
+}
+
+func (r *memoryClearingRepo) MarkFailed(ctx context.Context, id, reason string) error {
+	r.obligations[id].Status = shared.SettlementStatusFailed
+	r.obligations[id].FailReason = reason
+	r.obligations[id].FailCount++
+	return nil
+}
+
+func newTestClearingService(repo shared.ClearingRepository, now time.Time) (*ClearingService, *clock.Virtual) {
+	service := NewClearingService(repo, config.ClearingConfig{SettlementDays: 2}, discardLogger())
+	clk := clock.NewVirtual(now)
+	service.SetClock(clk)
+	return service, clk
+}
+
+func date(value string) time.Time {
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func TestClearingService_SettlementDate(t *testing.T) {
+	service, _ := newTestClearingService(newMemoryClearingRepo(), date("2024-01-20"))
+
+	tests := []struct {
+		tradeDate  string
+		settlement string
+	}{
+		{"2024-01-15", "2024-01-17"}, // Monday settles Wednesday
+		{"2024-01-18", "2024-01-22"}, // Thursday skips the weekend
+		{"2024-01-19", "2024-01-23"}, // Friday settles Tuesday
+		{"2024-01-20", "2024-01-23"}, // Weekend trades count from Monday
+	}
+	for _, tt := range tests {
+		t.Run(tt.tradeDate, func(t *testing.T) {
+			tradeDate := date(tt.tradeDate).Add(15 * time.Hour)
+			assert.Equal(t, date(tt.settlement), service.SettlementDate(tradeDate))
+		})
+	}
+}
+
+func TestClearingService_RunClearingNetsTrades(t *testing.T) {
+	repo := newMemoryClearingRepo()
+	monday := date("2024-01-15")
+	repo.trade(monday.Add(10*time.Hour), "alice", "bob", "AAPL", 10, 100, 1, 1)
+	repo.trade(monday.Add(11*time.Hour), "bob", "alice", "AAPL", 4, 110, 0.5, 0.5)
+	repo.trade(monday.Add(12*time.Hour), "alice", "bob", "MSFT", 2, 300, 0, 0)
+	// Trades of other days are netted separately
+	repo.trade(monday.Add(30*time.Hour), "alice", "bob", "AAPL", 1, 100, 0, 0)
+
+	service, _ := newTestClearingService(repo, monday.Add(36*time.Hour))
+
+	// Before the settlement date nothing settles
+	report, err := service.RunClearing(context.Background(), monday, monday.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, date("2024-01-17"), report.SettlementDate)
+	assert.Equal(t, 4, report.Obligations)
+	assert.Equal(t, 4, report.Pending)
+
+	obligations, err := repo.GetObligations(context.Background(), monday)
+	require.NoError(t, err)
+	byID := make(map[string]*shared.SettlementObligation)
+	for _, obligation := range obligations {
+		byID[obligation.UserID+"/"+obligation.Symbol] = obligation
+	}
+	assert.Equal(t, 6.0, byID["alice/AAPL"].NetQuantity)
+	assert.InDelta(t, -1000-1+440-0.5, byID["alice/AAPL"].NetCash, 1e-9)
+	assert.Equal(t, 2, byID["alice/AAPL"].TradeCount)
+	assert.Equal(t, -6.0, byID["bob/AAPL"].NetQuantity)
+	assert.Equal(t, -2.0, byID["bob/MSFT"].NetQuantity)
+
+	// Netting cuts the cash that changes hands below the gross notional
+	assert.Equal(t, 2*(1000+440+600.0), report.GrossNotional)
+	assert.Greater(t, report.NettingEfficiency, 0.0)
+	assert.Len(t, report.Symbols, 2)
+	assert.Equal(t, 6.0, report.Symbols[0].DeliverQuantity)
+
+	// On the settlement date everything settles
+	report, err = service.RunClearing(context.Background(), monday, date("2024-01-17"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Settled)
+	assert.Zero(t, report.FailRate)
+}
+
+func TestClearingService_RunClearingRejectsOpenTradeDates(t *testing.T) {
+	repo := newMemoryClearingRepo()
+	now := date("2024-01-15").Add(15 * time.Hour)
+	service, _ := newTestClearingService(repo, now)
+
+	for _, tradeDate := range []time.Time{now, now.Add(48 * time.Hour)} {
+		_, err := service.RunClearing(context.Background(), tradeDate, now)
+		var validation *shared.ValidationError
+		require.ErrorAs(t, err, &validation)
+		assert.Equal(t, "trade_date", validation.Field)
+	}
+	assert.Empty(t, repo.cleared)
+
+	_, err := service.RunClearing(context.Background(), now.Add(-24*time.Hour), now)
+	assert.NoError(t, err)
+}
+
+func TestClearingService_FailuresAreRetried(t *testing.T) {
+	repo := newMemoryClearingRepo()
+	monday := date("2024-01-15")
+	repo.trade(monday.Add(10*time.Hour), "alice", "bob", "AAPL", 10, 100, 0, 0)
+
+	service, _ := newTestClearingService(repo, date("2024-01-17"))
+	require.NoError(t, service.SetFailRate(1))
+
+	// The cycle clears the past trade date and fails both legs
+	require.NoError(t, service.runCycle(context.Background(), date("2024-01-17")))
+	report, err := service.GetReport(context.Background(), monday)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, 1.0, report.FailRate)
+	reasons := []string{report.Fails[0].FailReason, report.Fails[1].FailReason}
+	assert.ElementsMatch(t, []string{shared.SettlementFailToPay, shared.SettlementFailToDeliver}, reasons)
+
+	// Failed obligations are retried every cycle until they settle
+	require.NoError(t, service.runCycle(context.Background(), date("2024-01-17").Add(time.Minute)))
+	assert.Equal(t, 2, repo.obligations["2024-01-15/alice/AAPL"].FailCount)
+
+	require.NoError(t, service.SetFailRate(0))
+	require.NoError(t, service.runCycle(context.Background(), date("2024-01-17").Add(2*time.Minute)))
+	report, err = service.GetReport(context.Background(), monday)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Settled)
+	assert.Zero(t, report.Failed)
+
+	assert.Error(t, service.SetFailRate(1.5))
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"simulated_exchange/internal/reporting"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/fees"
+	"simulated_exchange/pkg/shared"
+)
+
+// kpiRevenueWindow is the trailing period of fee revenue business KPIs are
+// computed from
+const kpiRevenueWindow = 24 * time.Hour
+
+// FeeService exposes fee schedules, user tiers and fee revenue
+type FeeService struct {
+	engine    *fees.Engine
+	tradeRepo shared.TradeRepository
+	analyzer  *reporting.StandardBusinessAnalyzer
+}
+
+// NewFeeService creates a new fee service
+func NewFeeService(engine *fees.Engine, tradeRepo shared.TradeRepository) *FeeService {
+	analyzer := reporting.NewStandardBusinessAnalyzer()
+	analyzer.SetFeeRevenueSource(tradeRepo, kpiRevenueWindow)
+
+	return &FeeService{
+		engine:    engine,
+		tradeRepo: tradeRepo,
+		analyzer:  analyzer,
+	}
+}
+
+// GetSchedules returns the default and per-symbol fee schedules
+func (s *FeeService) GetSchedules() map[string][]config.FeeTier {
+	return s.engine.Schedules()
+}
+
+// GetUserTier returns the tier a user currently trades at in a symbol
+func (s *FeeService) GetUserTier(userID, symbol string) *shared.UserFeeTier {
+	return s.engine.TierFor(userID, symbol)
+}
+
+// GetFeeRevenue returns fee revenue per symbol for trades in [start, end)
+func (s *FeeService) GetFeeRevenue(ctx context.Context, start, end time.Time) ([]*shared.FeeRevenue, error) {
+	if !end.After(start) {
+		return nil, shared.NewValidationError("to", "must be after from")
+	}
+	return s.tradeRepo.GetFeeRevenue(ctx, start, end)
+}
+
+// CalculateKPIs computes business KPIs from the given metrics, with revenue
+// and profit taken from the fee revenue of the last kpiRevenueWindow
+func (s *FeeService) CalculateKPIs(ctx context.Context, data reporting.BusinessData) (*reporting.KPIMetrics, error) {
+	return s.analyzer.CalculateKPIs(ctx, data)
+}
@@ -0,0 +1,461 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// OrderBookSource provides the order books market maker quotes are sampled from
+type OrderBookSource interface {
+	GetOrderBook(ctx context.Context, symbol string) (*shared.OrderBook, error)
+}
+
+// RebateLimit provides the highest rebate a market maker may earn in a symbol
+type RebateLimit interface {
+	MaxRebateBps(symbol string) float64
+}
+
+// MarketMakerService runs the designated market maker program. Every
+// SampleInterval it samples each registered market maker's best bid and ask
+// from the book and measures them against its obligations. A market maker
+// earns its rebate while its compliant share of the day's samples is at
+// least its minimum uptime; until the first sample of the day it is given
+// the benefit of the doubt.
+type MarketMakerService struct {
+	repo   shared.MarketMakerRepository
+	books  OrderBookSource
+	limit  RebateLimit
+	config config.MarketMakingConfig
+	logger *slog.Logger
+
+	marketMakers map[string]*shared.MarketMaker
+	tradeDate    time.Time
+	today        map[string]*shared.MarketMakerStats
+	mutex        sync.RWMutex
+}
+
+// NewMarketMakerService creates a new market maker service
+func NewMarketMakerService(
+	repo shared.MarketMakerRepository,
+	books OrderBookSource,
+	cfg config.MarketMakingConfig,
+	logger *slog.Logger,
+) *MarketMakerService {
+	return &MarketMakerService{
+		repo:         repo,
+		books:        books,
+		config:       cfg,
+		logger:       logger,
+		marketMakers: make(map[string]*shared.MarketMaker),
+		today:        make(map[string]*shared.MarketMakerStats),
+	}
+}
+
+// SetRebateLimit bounds the rebate of registrations by the fee schedule. It
+// must be called before the service is used.
+func (s *MarketMakerService) SetRebateLimit(limit RebateLimit) {
+	s.limit = limit
+}
+
+// Load loads the active registrations and today's statistics
+func (s *MarketMakerService) Load(ctx context.Context) error {
+	marketMakers, err := s.repo.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	tradeDate := truncateToDay(time.Now())
+	stats, err := s.repo.GetDailyStats(ctx, tradeDate)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.marketMakers = make(map[string]*shared.MarketMaker, len(marketMakers))
+	for _, marketMaker := range marketMakers {
+		s.marketMakers[marketMakerKey(marketMaker.UserID, marketMaker.Symbol)] = marketMaker
+	}
+
+	s.tradeDate = tradeDate
+	s.today = make(map[string]*shared.MarketMakerStats, len(stats))
+	for _, stat := range stats {
+		s.today[marketMakerKey(stat.UserID, stat.Symbol)] = stat
+	}
+
+	return nil
+}
+
+// Run loads the program state and samples quotes every SampleInterval until
+// the context is done
+func (s *MarketMakerService) Run(ctx context.Context) {
+	if err := s.Load(ctx); err != nil && ctx.Err() == nil {
+		s.logger.Error("Failed to load market makers", "error", err)
+	}
+
+	ticker := time.NewTicker(s.config.SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Sample(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to sample market maker quotes", "error", err)
+		}
+	}
+}
+
+// NewRegistration returns a registration of a user as market maker in a
+// symbol with the default obligations and rebate
+func (s *MarketMakerService) NewRegistration(userID, symbol string) *shared.MarketMaker {
+	return &shared.MarketMaker{
+		UserID:       userID,
+		Symbol:       strings.ToUpper(symbol),
+		MaxSpreadBps: s.config.MaxSpreadBps,
+		MinQuoteSize: s.config.MinQuoteSize,
+		MinUptime:    s.config.MinUptime,
+		RebateBps:    s.config.RebateBps,
+	}
+}
+
+// Register registers a user as designated market maker in a symbol, or
+// replaces the obligations of an existing registration
+func (s *MarketMakerService) Register(ctx context.Context, marketMaker *shared.MarketMaker) (*shared.MarketMaker, error) {
+	if err := validateMarketMaker(marketMaker); err != nil {
+		return nil, err
+	}
+	if s.limit != nil {
+		if limit := s.limit.MaxRebateBps(marketMaker.Symbol); marketMaker.RebateBps > limit {
+			return nil, shared.NewValidationError("rebate_bps",
+				fmt.Sprintf("must not exceed %g, the lowest maker plus taker fee of %s", limit, marketMaker.Symbol))
+		}
+	}
+
+	marketMaker.Active = true
+	marketMaker.RegisteredAt = time.Now()
+	if err := s.repo.Register(ctx, marketMaker); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.marketMakers[marketMakerKey(marketMaker.UserID, marketMaker.Symbol)] = marketMaker
+	s.mutex.Unlock()
+
+	s.logger.Info("Market maker registered",
+		"user_id", marketMaker.UserID,
+		"symbol", marketMaker.Symbol,
+		"max_spread_bps", marketMaker.MaxSpreadBps,
+		"min_quote_size", marketMaker.MinQuoteSize,
+		"min_uptime", marketMaker.MinUptime,
+		"rebate_bps", marketMaker.RebateBps,
+	)
+	return marketMaker, nil
+}
+
+// Deregister ends a user's registration as market maker in a symbol
+func (s *MarketMakerService) Deregister(ctx context.Context, userID, symbol string) error {
+	symbol = strings.ToUpper(symbol)
+	if err := s.repo.Deregister(ctx, userID, symbol); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	delete(s.marketMakers, marketMakerKey(userID, symbol))
+	s.mutex.Unlock()
+
+	s.logger.Info("Market maker deregistered", "user_id", userID, "symbol", symbol)
+	return nil
+}
+
+// GetMarketMakers returns every active registration with its compliance so
+// far today
+func (s *MarketMakerService) GetMarketMakers() []*shared.MarketMakerStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	statuses := make([]*shared.MarketMakerStatus, 0, len(s.marketMakers))
+	for key, marketMaker := range s.marketMakers {
+		status := &shared.MarketMakerStatus{MarketMaker: *marketMaker}
+		if stats, exists := s.today[key]; exists {
+			status.Samples = stats.Samples
+			status.Uptime = stats.Uptime()
+		}
+		status.RebateEligible = s.eligible(key, marketMaker)
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Symbol != statuses[j].Symbol {
+			return statuses[i].Symbol < statuses[j].Symbol
+		}
+		return statuses[i].UserID < statuses[j].UserID
+	})
+	return statuses
+}
+
+// RebateBps returns the rebate a user currently earns as maker in a symbol.
+// It implements fees.RebateSource.
+func (s *MarketMakerService) RebateBps(userID, symbol string) float64 {
+	key := marketMakerKey(userID, symbol)
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	marketMaker, exists := s.marketMakers[key]
+	if !exists || !s.eligible(key, marketMaker) {
+		return 0
+	}
+	return marketMaker.RebateBps
+}
+
+// GetComplianceReport builds a market maker's compliance report for the UTC
+// days from start's day up to and including the day before end, or end's day
+// if end isn't midnight
+func (s *MarketMakerService) GetComplianceReport(ctx context.Context, userID string, start, end time.Time) (*shared.MarketMakerReport, error) {
+	if !end.After(start) {
+		return nil, shared.NewValidationError("to", "must be after from")
+	}
+	start = truncateToDay(start)
+	if day := truncateToDay(end); !day.Equal(end.UTC()) {
+		end = day.AddDate(0, 0, 1)
+	}
+
+	registrations, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(registrations) == 0 {
+		return nil, shared.ErrMarketMakerNotFound
+	}
+
+	obligations := make(map[string]*shared.MarketMaker, len(registrations))
+	for _, registration := range registrations {
+		obligations[registration.Symbol] = registration
+	}
+
+	stats, err := s.repo.GetStats(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &shared.MarketMakerReport{
+		UserID:    userID,
+		From:      start,
+		To:        end,
+		Compliant: true,
+		Days:      make([]*shared.MarketMakerCompliance, 0, len(stats)),
+	}
+
+	for _, stat := range stats {
+		marketMaker, exists := obligations[stat.Symbol]
+		if !exists {
+			continue
+		}
+
+		day := &shared.MarketMakerCompliance{
+			Symbol:       stat.Symbol,
+			TradeDate:    stat.TradeDate,
+			Samples:      stat.Samples,
+			Uptime:       stat.Uptime(),
+			MaxSpreadBps: marketMaker.MaxSpreadBps,
+			MinQuoteSize: marketMaker.MinQuoteSize,
+			MinUptime:    marketMaker.MinUptime,
+			MakerVolume:  stat.MakerVolume,
+			Rebates:      stat.Rebates,
+		}
+		if stat.QuotedSamples > 0 {
+			day.AvgSpreadBps = stat.SpreadBpsSum / float64(stat.QuotedSamples)
+			day.AvgQuoteSize = stat.QuoteSizeSum / float64(stat.QuotedSamples)
+		}
+		day.Compliant = day.Uptime >= marketMaker.MinUptime
+
+		report.Compliant = report.Compliant && day.Compliant
+		report.MakerVolume += day.MakerVolume
+		report.Rebates += day.Rebates
+		report.Days = append(report.Days, day)
+	}
+
+	return report, nil
+}
+
+// Sample measures every market maker's quotes against its obligations and
+// records the samples
+func (s *MarketMakerService) Sample(ctx context.Context, now time.Time) error {
+	tradeDate := truncateToDay(now)
+
+	s.mutex.RLock()
+	bySymbol := make(map[string][]*shared.MarketMaker)
+	for _, marketMaker := range s.marketMakers {
+		bySymbol[marketMaker.Symbol] = append(bySymbol[marketMaker.Symbol], marketMaker)
+	}
+	s.mutex.RUnlock()
+
+	samples := make([]*shared.MarketMakerStats, 0)
+	for symbol, marketMakers := range bySymbol {
+		book, err := s.books.GetOrderBook(ctx, symbol)
+		if err != nil {
+			return fmt.Errorf("failed to get %s order book: %w", symbol, err)
+		}
+
+		for _, marketMaker := range marketMakers {
+			samples = append(samples, sampleQuote(marketMaker, book, tradeDate))
+		}
+	}
+
+	if err := s.repo.RecordSamples(ctx, samples); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	previousDate, previous := s.tradeDate, s.today
+	if !tradeDate.Equal(s.tradeDate) {
+		s.tradeDate = tradeDate
+		s.today = make(map[string]*shared.MarketMakerStats)
+	}
+	for _, sample := range samples {
+		key := marketMakerKey(sample.UserID, sample.Symbol)
+		stats, exists := s.today[key]
+		if !exists {
+			stats = &shared.MarketMakerStats{UserID: sample.UserID, Symbol: sample.Symbol, TradeDate: tradeDate}
+			s.today[key] = stats
+		}
+		stats.Samples += sample.Samples
+		stats.QuotedSamples += sample.QuotedSamples
+		stats.CompliantSamples += sample.CompliantSamples
+		stats.SpreadBpsSum += sample.SpreadBpsSum
+		stats.QuoteSizeSum += sample.QuoteSizeSum
+	}
+	s.mutex.Unlock()
+
+	if !previousDate.IsZero() && !tradeDate.Equal(previousDate) {
+		s.logCompliance(previousDate, previous)
+	}
+	return nil
+}
+
+// eligible reports whether a market maker currently earns its rebate. The
+// caller must hold the mutex.
+func (s *MarketMakerService) eligible(key string, marketMaker *shared.MarketMaker) bool {
+	stats, exists := s.today[key]
+	if !exists || stats.Samples == 0 {
+		return true
+	}
+	return stats.Uptime() >= marketMaker.MinUptime
+}
+
+// logCompliance logs the end-of-day compliance of every market maker sampled
+// on a trade date
+func (s *MarketMakerService) logCompliance(tradeDate time.Time, stats map[string]*shared.MarketMakerStats) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for key, stat := range stats {
+		marketMaker, exists := s.marketMakers[key]
+		if !exists {
+			continue
+		}
+
+		attrs := []any{
+			"user_id", stat.UserID,
+			"symbol", stat.Symbol,
+			"trade_date", tradeDate.Format(time.DateOnly),
+			"samples", stat.Samples,
+			"uptime", stat.Uptime(),
+			"min_uptime", marketMaker.MinUptime,
+		}
+		if stat.Uptime() >= marketMaker.MinUptime {
+			s.logger.Info("Market maker met obligations", attrs...)
+		} else {
+			s.logger.Warn("Market maker missed obligations", attrs...)
+		}
+	}
+}
+
+// sampleQuote measures a market maker's best bid and ask in a book against
+// its obligations. Quote size is the smaller of the sizes at its best bid and
+// best ask.
+func sampleQuote(marketMaker *shared.MarketMaker, book *shared.OrderBook, tradeDate time.Time) *shared.MarketMakerStats {
+	sample := &shared.MarketMakerStats{
+		UserID:    marketMaker.UserID,
+		Symbol:    marketMaker.Symbol,
+		TradeDate: tradeDate,
+		Samples:   1,
+	}
+
+	bid, bidSize := bestQuote(book.Bids, marketMaker.UserID, shared.OrderSideBuy)
+	ask, askSize := bestQuote(book.Asks, marketMaker.UserID, shared.OrderSideSell)
+	if bidSize == 0 || askSize == 0 || ask <= 0 {
+		return sample
+	}
+
+	spreadBps := (ask - bid) / ((ask + bid) / 2) * 10000
+	quoteSize := math.Min(bidSize, askSize)
+
+	sample.QuotedSamples = 1
+	sample.SpreadBpsSum = spreadBps
+	sample.QuoteSizeSum = quoteSize
+	if spreadBps <= marketMaker.MaxSpreadBps && quoteSize >= marketMaker.MinQuoteSize {
+		sample.CompliantSamples = 1
+	}
+	return sample
+}
+
+// bestQuote returns a user's best limit price on one side of the book and
+// the quantity resting at it
+func bestQuote(orders []shared.Order, userID string, side shared.OrderSide) (float64, float64) {
+	var price, size float64
+	for _, order := range orders {
+		if order.UserID != userID || order.Type != shared.OrderTypeLimit || order.Price <= 0 {
+			continue
+		}
+
+		better := size == 0 ||
+			(side == shared.OrderSideBuy && order.Price > price) ||
+			(side == shared.OrderSideSell && order.Price < price)
+		switch {
+		case better:
+			price, size = order.Price, order.Quantity
+		case order.Price == price:
+			size += order.Quantity
+		}
+	}
+	return price, size
+}
+
+// validateMarketMaker checks a registration's obligations and rebate
+func validateMarketMaker(marketMaker *shared.MarketMaker) error {
+	switch {
+	case marketMaker.UserID == "":
+		return shared.NewValidationError("user_id", "is required")
+	case marketMaker.Symbol == "":
+		return shared.NewValidationError("symbol", "is required")
+	case !(marketMaker.MaxSpreadBps > 0):
+		return shared.NewValidationError("max_spread_bps", "must be positive")
+	case !(marketMaker.MinQuoteSize > 0):
+		return shared.NewValidationError("min_quote_size", "must be positive")
+	case !(marketMaker.MinUptime >= 0 && marketMaker.MinUptime <= 1):
+		return shared.NewValidationError("min_uptime", "must be between 0 and 1")
+	case !(marketMaker.RebateBps >= 0):
+		return shared.NewValidationError("rebate_bps", "must not be negative")
+	}
+	return nil
+}
+
+// marketMakerKey identifies a market maker registration
+func marketMakerKey(userID, symbol string) string {
+	return userID + "|" + symbol
+}
@@ -0,0 +1,290 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// memoryMarketMakerRepo is an in-memory shared.MarketMakerRepository that
+// accumulates samples per user, symbol and day
+type memoryMarketMakerRepo struct {
+	registrations map[string]*shared.MarketMaker
+	stats         map[string]*shared.MarketMakerStats
+}
+
+func newMemoryMarketMakerRepo() *memoryMarketMakerRepo {
+	return &memoryMarketMakerRepo{
+		registrations: make(map[string]*shared.MarketMaker),
+		stats:         make(map[string]*shared.MarketMakerStats),
+	}
+}
+
+func (r *memoryMarketMakerRepo) Register(ctx context.Context, marketMaker *shared.MarketMaker) error {
+	copied := *marketMaker
+	r.registrations[marketMakerKey(marketMaker.UserID, marketMaker.Symbol)] = &copied
+	return nil
+}
+
+func (r *memoryMarketMakerRepo) Deregister(ctx context.Context, userID, symbol string) error {
+	delete(r.registrations, marketMakerKey(userID, symbol))
+	return nil
+}
+
+func (r *memoryMarketMakerRepo) GetActive(ctx context.Context) ([]*shared.MarketMaker, error) {
+	var active []*shared.MarketMaker
+	for _, marketMaker := range r.registrations {
+		active = append(active, marketMaker)
+	}
+	return active, nil
+}
+
+func (r *memoryMarketMakerRepo) GetByUserID(ctx context.Context, userID string) ([]*shared.MarketMaker, error) {
+	var registrations []*shared.MarketMaker
+	for _, marketMaker := range r.registrations {
+		if marketMaker.UserID == userID {
+			registrations = append(registrations, marketMaker)
+		}
+	}
+	return registrations, nil
+}
+
+func (r *memoryMarketMakerRepo) RecordSamples(ctx context.Context, samples []*shared.MarketMakerStats) error {
+	for _, sample := range samples {
+		key := marketMakerKey(sample.UserID, sample.Symbol) + "|" + sample.TradeDate.Format(time.DateOnly)
+		stats, ok := r.stats[key]
+		if !ok {
+			stats = &shared.MarketMakerStats{UserID: sample.UserID, Symbol: sample.Symbol, TradeDate: sample.TradeDate}
+			r.stats[key] = stats
+		}
+		stats.Samples += sample.Samples
+		stats.QuotedSamples += sample.QuotedSamples
+		stats.CompliantSamples += sample.CompliantSamples
+		stats.SpreadBpsSum += sample.SpreadBpsSum
+		stats.QuoteSizeSum += sample.QuoteSizeSum
+	}
+	return nil
+}
+
+func (r *memoryMarketMakerRepo) GetDailyStats(ctx context.Context, tradeDate time.Time) ([]*shared.MarketMakerStats, error) {
+	var stats []*shared.MarketMakerStats
+	for _, stat := range r.stats {
+		if stat.TradeDate.Equal(tradeDate) {
+			stats = append(stats, stat)
+		}
+	}
+	return stats, nil
+}
+
+func (r *memoryMarketMakerRepo) GetStats(ctx context.Context, userID string, start, end time.Time) ([]*shared.MarketMakerStats, error) {
+	var stats []*shared.MarketMakerStats
+	for _, stat := range r.stats {
+		if stat.UserID == userID && !stat.TradeDate.Before(start) && stat.TradeDate.Before(end) {
+			stats = append(stats, stat)
+		}
+	}
+	return stats, nil
+}
+
+// staticBooks is an OrderBookSource serving one book per symbol
+type staticBooks map[string]*shared.OrderBook
+
+func (b staticBooks) GetOrderBook(ctx context.Context, symbol string) (*shared.OrderBook, error) {
+	return b[symbol], nil
+}
+
+// fixedRebateLimit is a RebateLimit with the same limit in every symbol
+type fixedRebateLimit float64
+
+func (l fixedRebateLimit) MaxRebateBps(symbol string) float64 {
+	return float64(l)
+}
+
+func quote(userID string, side shared.OrderSide, price, quantity float64) shared.Order {
+	return shared.Order{UserID: userID, Side: side, Type: shared.OrderTypeLimit, Price: price, Quantity: quantity}
+}
+
+func testMarketMaker() *shared.MarketMaker {
+	return &shared.MarketMaker{UserID: "mm", Symbol: "AAPL", MaxSpreadBps: 50, MinQuoteSize: 10, MinUptime: 0.5, RebateBps: 2}
+}
+
+func TestBestQuote(t *testing.T) {
+	orders := []shared.Order{
+		quote("mm", shared.OrderSideBuy, 99, 5),
+		quote("other", shared.OrderSideBuy, 99.5, 100),
+		quote("mm", shared.OrderSideBuy, 99.2, 3),
+		quote("mm", shared.OrderSideBuy, 99.2, 4),
+		{UserID: "mm", Side: shared.OrderSideBuy, Type: shared.OrderTypeMarket, Quantity: 50},
+	}
+
+	price, size := bestQuote(orders, "mm", shared.OrderSideBuy)
+	assert.Equal(t, 99.2, price)
+	assert.Equal(t, 7.0, size)
+
+	// On the ask side the lowest price is best
+	price, size = bestQuote(orders, "mm", shared.OrderSideSell)
+	assert.Equal(t, 99.0, price)
+	assert.Equal(t, 5.0, size)
+
+	price, size = bestQuote(orders, "nobody", shared.OrderSideBuy)
+	assert.Zero(t, price)
+	assert.Zero(t, size)
+}
+
+func TestSampleQuote(t *testing.T) {
+	tests := []struct {
+		name      string
+		bids      []shared.Order
+		asks      []shared.Order
+		quoted    bool
+		compliant bool
+		spreadBps float64
+		size      float64
+	}{
+		{
+			name:      "tight two-sided quote",
+			bids:      []shared.Order{quote("mm", shared.OrderSideBuy, 99.9, 20)},
+			asks:      []shared.Order{quote("mm", shared.OrderSideSell, 100.1, 15)},
+			quoted:    true,
+			compliant: true,
+			spreadBps: 20,
+			size:      15,
+		},
+		{
+			name:      "spread too wide",
+			bids:      []shared.Order{quote("mm", shared.OrderSideBuy, 99, 20)},
+			asks:      []shared.Order{quote("mm", shared.OrderSideSell, 101, 20)},
+			quoted:    true,
+			spreadBps: 200,
+			size:      20,
+		},
+		{
+			name:      "quote too small on one side",
+			bids:      []shared.Order{quote("mm", shared.OrderSideBuy, 99.9, 20)},
+			asks:      []shared.Order{quote("mm", shared.OrderSideSell, 100.1, 5)},
+			quoted:    true,
+			spreadBps: 20,
+			size:      5,
+		},
+		{
+			name: "one-sided quote",
+			bids: []shared.Order{quote("mm", shared.OrderSideBuy, 99.9, 20)},
+			asks: []shared.Order{quote("other", shared.OrderSideSell, 100.1, 20)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tradeDate := date("2024-01-15")
+			sample := sampleQuote(testMarketMaker(), &shared.OrderBook{Symbol: "AAPL", Bids: tt.bids, Asks: tt.asks}, tradeDate)
+
+			assert.Equal(t, 1, sample.Samples)
+			assert.Equal(t, tradeDate, sample.TradeDate)
+			assert.Equal(t, tt.quoted, sample.QuotedSamples == 1)
+			assert.Equal(t, tt.compliant, sample.CompliantSamples == 1)
+			assert.InDelta(t, tt.spreadBps, sample.SpreadBpsSum, 1e-9)
+			assert.Equal(t, tt.size, sample.QuoteSizeSum)
+		})
+	}
+}
+
+func newTestMarketMakerService(repo shared.MarketMakerRepository, books OrderBookSource) *MarketMakerService {
+	return NewMarketMakerService(repo, books, config.MarketMakingConfig{
+		MaxSpreadBps: 50,
+		MinQuoteSize: 10,
+		MinUptime:    0.5,
+		RebateBps:    2,
+	}, discardLogger())
+}
+
+func TestMarketMakerService_Uptime(t *testing.T) {
+	compliant := &shared.OrderBook{
+		Bids: []shared.Order{quote("mm", shared.OrderSideBuy, 99.9, 20)},
+		Asks: []shared.Order{quote("mm", shared.OrderSideSell, 100.1, 20)},
+	}
+	absent := &shared.OrderBook{}
+	books := staticBooks{"AAPL": compliant}
+
+	repo := newMemoryMarketMakerRepo()
+	service := newTestMarketMakerService(repo, books)
+	ctx := context.Background()
+	_, err := service.Register(ctx, testMarketMaker())
+	require.NoError(t, err)
+
+	// Before the first sample the market maker earns its rebate
+	assert.Equal(t, 2.0, service.RebateBps("mm", "AAPL"))
+	assert.Zero(t, service.RebateBps("other", "AAPL"))
+
+	monday := date("2024-01-15")
+	require.NoError(t, service.Sample(ctx, monday.Add(10*time.Hour)))
+	books["AAPL"] = absent
+	require.NoError(t, service.Sample(ctx, monday.Add(11*time.Hour)))
+	require.NoError(t, service.Sample(ctx, monday.Add(12*time.Hour)))
+
+	statuses := service.GetMarketMakers()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, 3, statuses[0].Samples)
+	assert.InDelta(t, 1.0/3, statuses[0].Uptime, 1e-9)
+	assert.False(t, statuses[0].RebateEligible)
+	assert.Zero(t, service.RebateBps("mm", "AAPL"))
+
+	// A new day starts a new uptime
+	books["AAPL"] = compliant
+	require.NoError(t, service.Sample(ctx, monday.Add(34*time.Hour)))
+	statuses = service.GetMarketMakers()
+	assert.Equal(t, 1, statuses[0].Samples)
+	assert.Equal(t, 1.0, statuses[0].Uptime)
+	assert.Equal(t, 2.0, service.RebateBps("mm", "AAPL"))
+
+	report, err := service.GetComplianceReport(ctx, "mm", monday, monday.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, report.Days, 2)
+	assert.False(t, report.Compliant)
+
+	// A restarted service picks up today's samples
+	restarted := newTestMarketMakerService(repo, books)
+	require.NoError(t, restarted.Load(ctx))
+	assert.Len(t, restarted.GetMarketMakers(), 1)
+}
+
+func TestMarketMakerService_RegisterValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(marketMaker *shared.MarketMaker)
+		field string
+	}{
+		{"valid", func(marketMaker *shared.MarketMaker) {}, ""},
+		{"no spread", func(marketMaker *shared.MarketMaker) { marketMaker.MaxSpreadBps = 0 }, "max_spread_bps"},
+		{"no quote size", func(marketMaker *shared.MarketMaker) { marketMaker.MinQuoteSize = -1 }, "min_quote_size"},
+		{"uptime above one", func(marketMaker *shared.MarketMaker) { marketMaker.MinUptime = 1.5 }, "min_uptime"},
+		{"negative rebate", func(marketMaker *shared.MarketMaker) { marketMaker.RebateBps = -1 }, "rebate_bps"},
+		{"rebate at the fee limit", func(marketMaker *shared.MarketMaker) { marketMaker.RebateBps = 6 }, ""},
+		{"rebate beyond the fee limit", func(marketMaker *shared.MarketMaker) { marketMaker.RebateBps = 6.5 }, "rebate_bps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryMarketMakerRepo()
+			service := newTestMarketMakerService(repo, staticBooks{})
+			service.SetRebateLimit(fixedRebateLimit(6))
+
+			marketMaker := testMarketMaker()
+			tt.edit(marketMaker)
+			_, err := service.Register(context.Background(), marketMaker)
+
+			if tt.field == "" {
+				require.NoError(t, err)
+				assert.Len(t, repo.registrations, 1)
+				return
+			}
+			var validation *shared.ValidationError
+			require.ErrorAs(t, err, &validation)
+			assert.Equal(t, tt.field, validation.Field)
+			assert.Empty(t, repo.registrations)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+
+	"simulated_exchange/pkg/shared"
+)
+
+// RiskState implements risk.State on top of the order, trade and account
+// repositories
+type RiskState struct {
+	orderRepo   shared.OrderRepository
+	tradeRepo   shared.TradeRepository
+	accountRepo shared.AccountRepository
+}
+
+// NewRiskState creates a new risk state. Positions are read from the
+// account repository, which is only kept up to date when accounts are
+// enabled; with a nil account repository they are netted from the user's
+// trades instead.
+func NewRiskState(
+	orderRepo shared.OrderRepository,
+	tradeRepo shared.TradeRepository,
+	accountRepo shared.AccountRepository,
+) *RiskState {
+	return &RiskState{
+		orderRepo:   orderRepo,
+		tradeRepo:   tradeRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+// OpenOrderCount returns the number of pending and partially filled orders
+// a user has
+func (s *RiskState) OpenOrderCount(ctx context.Context, userID string) (int, error) {
+	orders, err := s.orderRepo.GetOpenOrders(ctx, shared.OrderFilter{UserID: userID})
+	if err != nil {
+		return 0, err
+	}
+	return len(orders), nil
+}
+
+// Position returns a user's share position in a symbol
+func (s *RiskState) Position(ctx context.Context, userID, symbol string) (float64, error) {
+	if s.accountRepo == nil {
+		return s.tradeRepo.GetNetPosition(ctx, userID, symbol)
+	}
+
+	positions, err := s.accountRepo.GetPositions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, position := range positions {
+		if position.Symbol == symbol {
+			return position.Quantity, nil
+		}
+	}
+	return 0, nil
+}
+
+// LastPrice returns the price of the most recent trade in a symbol
+func (s *RiskState) LastPrice(ctx context.Context, symbol string) (float64, error) {
+	trades, err := s.tradeRepo.GetTradesBySymbolBefore(ctx, symbol, nil, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(trades) == 0 {
+		return 0, nil
+	}
+	return trades[0].Price, nil
+}
@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+	"simulated_exchange/pkg/surveillance"
+)
+
+// ownerRefreshInterval is how often beneficial owners are reloaded, picking
+// up changes made by other instances
+const ownerRefreshInterval = time.Minute
+
+// SurveillanceService feeds the order and trade events into the detector and
+// stores the alerts it raises for review
+type SurveillanceService struct {
+	repo     shared.SurveillanceRepository
+	detector *surveillance.Detector
+	config   config.SurveillanceConfig
+	logger   *slog.Logger
+}
+
+// NewSurveillanceService creates a new surveillance service
+func NewSurveillanceService(
+	repo shared.SurveillanceRepository,
+	detector *surveillance.Detector,
+	cfg config.SurveillanceConfig,
+	logger *slog.Logger,
+) *SurveillanceService {
+	return &SurveillanceService{
+		repo:     repo,
+		detector: detector,
+		config:   cfg,
+		logger:   logger,
+	}
+}
+
+// Run keeps the beneficial owners current and prunes the detector's history
+// until the context is cancelled
+func (s *SurveillanceService) Run(ctx context.Context) {
+	s.loadOwners(ctx)
+
+	pruneTicker := time.NewTicker(s.config.Window)
+	defer pruneTicker.Stop()
+	ownerTicker := time.NewTicker(ownerRefreshInterval)
+	defer ownerTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-pruneTicker.C:
+			s.detector.Prune(now)
+		case <-ownerTicker.C:
+			s.loadOwners(ctx)
+		}
+	}
+}
+
+// HandleOrderPlaced handles order.placed events
+func (s *SurveillanceService) HandleOrderPlaced(ctx context.Context, event *shared.Event) error {
+	order, err := parseOrderEvent(event)
+	if err != nil {
+		return err
+	}
+
+	s.detector.OnOrderPlaced(order)
+	return nil
+}
+
+// HandleOrderCancelled handles order.cancelled events
+func (s *SurveillanceService) HandleOrderCancelled(ctx context.Context, event *shared.Event) error {
+	order, err := parseOrderEvent(event)
+	if err != nil {
+		return err
+	}
+
+	s.detector.OnOrderCancelled(order)
+	return nil
+}
+
+// HandleTradeExecuted handles trade.executed events, storing any alerts the
+// trade raises
+func (s *SurveillanceService) HandleTradeExecuted(ctx context.Context, event *shared.Event) error {
+	trade, err := parseTradeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range s.detector.OnTrade(trade) {
+		s.logger.Warn("Surveillance alert raised",
+			"alert_id", alert.ID,
+			"type", alert.Type,
+			"symbol", alert.Symbol,
+			"user_id", alert.UserID,
+			"description", alert.Description,
+		)
+
+		if err := s.repo.CreateAlert(ctx, alert); err != nil {
+			s.logger.Error("Failed to store surveillance alert", "alert_id", alert.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// GetAlert retrieves an alert by ID
+func (s *SurveillanceService) GetAlert(ctx context.Context, id string) (*shared.SurveillanceAlert, error) {
+	return s.repo.GetAlert(ctx, id)
+}
+
+// GetAlerts retrieves the alerts matching a filter
+func (s *SurveillanceService) GetAlerts(ctx context.Context, filter shared.AlertFilter) ([]*shared.SurveillanceAlert, error) {
+	return s.repo.GetAlerts(ctx, filter)
+}
+
+// UpdateAlertStatus records the review outcome of an alert
+func (s *SurveillanceService) UpdateAlertStatus(ctx context.Context, id string, status shared.AlertStatus) (*shared.SurveillanceAlert, error) {
+	switch status {
+	case shared.AlertStatusOpen, shared.AlertStatusDismissed, shared.AlertStatusEscalated:
+	default:
+		return nil, shared.NewValidationError("status", "status must be OPEN, DISMISSED or ESCALATED")
+	}
+
+	return s.repo.UpdateAlertStatus(ctx, id, status)
+}
+
+// SetBeneficialOwner records the beneficial owner of an account. Accounts
+// with the same owner trading with each other are flagged as wash trades.
+func (s *SurveillanceService) SetBeneficialOwner(ctx context.Context, userID, ownerID string) error {
+	if ownerID == "" {
+		return shared.NewValidationError("owner_id", "owner_id is required")
+	}
+
+	if err := s.repo.SetBeneficialOwner(ctx, userID, ownerID); err != nil {
+		return err
+	}
+
+	s.detector.SetOwner(userID, ownerID)
+	return nil
+}
+
+func (s *SurveillanceService) loadOwners(ctx context.Context) {
+	owners, err := s.repo.GetBeneficialOwners(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error("Failed to load beneficial owners", "error", err)
+		}
+		return
+	}
+
+	s.detector.SetOwners(owners)
+}
+
+func parseOrderEvent(event *shared.Event) (surveillance.OrderEvent, error) {
+	orderID, _ := event.Data["order_id"].(string)
+	userID, _ := event.Data["user_id"].(string)
+	symbol, _ := event.Data["symbol"].(string)
+	side, _ := event.Data["side"].(string)
+	price, _ := event.Data["price"].(float64)
+	quantity, ok := event.Data["quantity"].(float64)
+	if orderID == "" || userID == "" || symbol == "" || !ok {
+		return surveillance.OrderEvent{}, fmt.Errorf("invalid order in %s event", event.Type)
+	}
+
+	return surveillance.OrderEvent{
+		OrderID:  orderID,
+		UserID:   userID,
+		Symbol:   symbol,
+		Side:     shared.OrderSide(side),
+		Price:    price,
+		Quantity: quantity,
+		At:       event.Timestamp,
+	}, nil
+}
+
+func parseTradeEvent(event *shared.Event) (surveillance.TradeEvent, error) {
+	tradeID, _ := event.Data["trade_id"].(string)
+	symbol, _ := event.Data["symbol"].(string)
+	price, _ := event.Data["price"].(float64)
+	quantity, _ := event.Data["quantity"].(float64)
+	buyOrderID, _ := event.Data["buy_order_id"].(string)
+	sellOrderID, _ := event.Data["sell_order_id"].(string)
+	buyUserID, _ := event.Data["buy_user_id"].(string)
+	sellUserID, _ := event.Data["sell_user_id"].(string)
+	takerSide, _ := event.Data["taker_side"].(string)
+	if tradeID == "" || symbol == "" || buyUserID == "" || sellUserID == "" {
+		return surveillance.TradeEvent{}, fmt.Errorf("invalid trade in %s event", event.Type)
+	}
+
+	return surveillance.TradeEvent{
+		TradeID:     tradeID,
+		Symbol:      symbol,
+		Price:       price,
+		Quantity:    quantity,
+		BuyOrderID:  buyOrderID,
+		SellOrderID: sellOrderID,
+		BuyUserID:   buyUserID,
+		SellUserID:  sellUserID,
+		TakerSide:   shared.OrderSide(takerSide),
+		At:          event.Timestamp,
+	}, nil
+}
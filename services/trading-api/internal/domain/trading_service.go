@@ -2,39 +2,61 @@ package domain
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"simulated_exchange/pkg/fees"
+	"simulated_exchange/pkg/risk"
 	"simulated_exchange/pkg/shared"
 )
 
 // TradingService implements shared.TradingService interface
 type TradingService struct {
 	orderRepo    shared.OrderRepository
+	orderEvents  shared.OrderEventRepository
 	tradeRepo    shared.TradeRepository
 	cache        shared.CacheRepository
 	eventBus     shared.EventBus
 	orderMatcher shared.OrderMatcher
+	accounts     *AccountService
+	risk         *risk.Pipeline
+	fees         *fees.Engine
 	logger       *slog.Logger
 }
 
-// NewTradingService creates a new trading service
+// NewTradingService creates a new trading service. A nil order event
+// repository disables the order audit trail, a nil account service
+// disables buying power checks, a nil risk pipeline disables pre-trade risk
+// checks and a nil fee engine trades without fees.
 func NewTradingService(
 	orderRepo shared.OrderRepository,
+	orderEvents shared.OrderEventRepository,
 	tradeRepo shared.TradeRepository,
 	cache shared.CacheRepository,
 	eventBus shared.EventBus,
 	orderMatcher shared.OrderMatcher,
+	accounts *AccountService,
+	riskPipeline *risk.Pipeline,
+	feeEngine *fees.Engine,
 	logger *slog.Logger,
 ) *TradingService {
 	return &TradingService{
 		orderRepo:    orderRepo,
+		orderEvents:  orderEvents,
 		tradeRepo:    tradeRepo,
 		cache:        cache,
 		eventBus:     eventBus,
 		orderMatcher: orderMatcher,
+		accounts:     accounts,
+		risk:         riskPipeline,
+		fees:         feeEngine,
 		logger:       logger,
 	}
 }
@@ -46,16 +68,31 @@ func (s *TradingService) PlaceOrder(ctx context.Context, order *shared.Order) (*
 		return nil, err
 	}
 
+	// Resubmissions with a known client order ID return the original order
+	if order.ClientOrderID != "" {
+		existing, err := s.orderRepo.GetByClientOrderID(ctx, order.UserID, order.ClientOrderID)
+		if err == nil {
+			return nil, s.duplicateOrderError(order, existing)
+		}
+		if err != shared.ErrOrderNotFound {
+			return nil, shared.NewServiceErrorWithCause("trading", "place_order", "failed to look up client order ID", err)
+		}
+	}
+
 	// Generate order ID if not provided
 	if order.ID == "" {
 		order.ID = uuid.New().String()
 	}
 
+	// New orders start out pending
+	if err := order.TransitionTo(shared.OrderStatusPending); err != nil {
+		return nil, shared.NewValidationError("status", err.Error())
+	}
+
 	// Set timestamps
 	now := time.Now()
 	order.CreatedAt = now
 	order.UpdatedAt = now
-	order.Status = shared.OrderStatusPending
 
 	s.logger.Info("Placing order",
 		"order_id", order.ID,
@@ -67,8 +104,41 @@ func (s *TradingService) PlaceOrder(ctx context.Context, order *shared.Order) (*
 		"price", order.Price,
 	)
 
-	// Save order to database
-	if err := s.orderRepo.Create(ctx, order); err != nil {
+	// Run the pre-trade risk checks; orders that breach a limit are
+	// recorded as rejected
+	if err := s.checkRisk(ctx, order); err != nil {
+		if rejected, ok := err.(*shared.OrderRejectedError); ok {
+			if recordErr := s.recordRejection(ctx, order, rejected, shared.OrderEventSourceRisk); recordErr != nil {
+				return nil, recordErr
+			}
+		}
+		return nil, err
+	}
+
+	// Hold the cash or shares the order needs; orders the user can't cover
+	// are recorded as rejected
+	if s.accounts != nil {
+		if err := s.accounts.Reserve(ctx, order); err != nil {
+			if rejected, ok := err.(*shared.OrderRejectedError); ok {
+				if recordErr := s.recordRejection(ctx, order, rejected, shared.OrderEventSourceAccounts); recordErr != nil {
+					return nil, recordErr
+				}
+			}
+			return nil, err
+		}
+	}
+
+	// Save order to database along with its audit event
+	if err := s.orderRepo.Create(ctx, order, s.orderEvent(order, shared.OrderEventAccepted, shared.OrderEventSourceClient, "")); err != nil {
+		s.releaseReservation(ctx, order.ID)
+
+		// A concurrent submission with the same client order ID won the race
+		if err == shared.ErrOrderAlreadyExists && order.ClientOrderID != "" {
+			existing, lookupErr := s.orderRepo.GetByClientOrderID(ctx, order.UserID, order.ClientOrderID)
+			if lookupErr == nil {
+				return nil, s.duplicateOrderError(order, existing)
+			}
+		}
 		return nil, shared.NewServiceErrorWithCause("trading", "place_order", "failed to save order", err)
 	}
 
@@ -83,13 +153,14 @@ func (s *TradingService) PlaceOrder(ctx context.Context, order *shared.Order) (*
 		Type:   shared.EventTypeOrderPlaced,
 		Source: "trading-api",
 		Data: map[string]interface{}{
-			"order_id": order.ID,
-			"user_id":  order.UserID,
-			"symbol":   order.Symbol,
-			"side":     order.Side,
-			"type":     order.Type,
-			"price":    order.Price,
-			"quantity": order.Quantity,
+			"order_id":        order.ID,
+			"client_order_id": order.ClientOrderID,
+			"user_id":         order.UserID,
+			"symbol":          order.Symbol,
+			"side":            order.Side,
+			"type":            order.Type,
+			"price":           order.Price,
+			"quantity":        order.Quantity,
 		},
 	}); err != nil {
 		s.logger.Warn("Failed to publish order placed event", "error", err)
@@ -103,6 +174,21 @@ func (s *TradingService) PlaceOrder(ctx context.Context, order *shared.Order) (*
 	return order, nil
 }
 
+// PlaceOrders places a batch of orders. Orders are processed in submission
+// order and independently of each other, so one rejected order doesn't
+// prevent the rest of the batch from being placed.
+func (s *TradingService) PlaceOrders(ctx context.Context, orders []*shared.Order) []*shared.BatchOrderResult {
+	results := make([]*shared.BatchOrderResult, len(orders))
+	for i, order := range orders {
+		placed, err := s.PlaceOrder(ctx, order)
+		results[i] = &shared.BatchOrderResult{Index: i, Order: placed, Err: err}
+	}
+
+	s.logger.Info("Order batch processed", "orders", len(orders))
+
+	return results
+}
+
 // CancelOrder cancels an existing order
 func (s *TradingService) CancelOrder(ctx context.Context, orderID string) error {
 	// Get order from database
@@ -111,42 +197,197 @@ func (s *TradingService) CancelOrder(ctx context.Context, orderID string) error
 		return err
 	}
 
-	// Check if order can be cancelled
-	if order.Status == shared.OrderStatusFilled {
-		return shared.NewBusinessError(shared.ErrCodeOrderAlreadyFilled, "order is already filled")
+	if err := s.cancelOrder(ctx, order, "cancelled by user"); err != nil {
+		return err
 	}
-	if order.Status == shared.OrderStatusCancelled {
-		return shared.NewBusinessError(shared.ErrCodeOrderAlreadyCancelled, "order is already cancelled")
+
+	// Update order book cache
+	if err := s.updateOrderBookCache(ctx, order.Symbol); err != nil {
+		s.logger.Warn("Failed to update order book cache", "error", err)
 	}
 
-	s.logger.Info("Cancelling order", "order_id", orderID, "user_id", order.UserID)
+	return nil
+}
 
-	// Update order status
-	order.Status = shared.OrderStatusCancelled
-	order.UpdatedAt = time.Now()
+// CancelOrders cancels all open orders matching the filter and returns the
+// cancelled orders. At least one filter field must be set.
+func (s *TradingService) CancelOrders(ctx context.Context, filter shared.OrderFilter) ([]*shared.Order, error) {
+	if filter.IsEmpty() {
+		return nil, shared.NewValidationError("filter", "at least one of user_id, symbol or side is required")
+	}
+	if filter.Side != "" && filter.Side != shared.OrderSideBuy && filter.Side != shared.OrderSideSell {
+		return nil, shared.NewValidationError("side", "side must be BUY or SELL")
+	}
+
+	orders, err := s.orderRepo.GetOpenOrders(ctx, filter)
+	if err != nil {
+		return nil, shared.NewServiceErrorWithCause("trading", "cancel_orders", "failed to load open orders", err)
+	}
+
+	cancelled := make([]*shared.Order, 0, len(orders))
+	symbols := make(map[string]bool)
+	for _, order := range orders {
+		// Orders filled since they were loaded are skipped rather than failing the batch
+		if err := s.cancelOrder(ctx, order, "mass cancel"); err != nil {
+			s.logger.Warn("Failed to cancel order", "order_id", order.ID, "error", err)
+			continue
+		}
+		cancelled = append(cancelled, order)
+		symbols[order.Symbol] = true
+	}
 
-	if err := s.orderRepo.Update(ctx, order); err != nil {
+	for symbol := range symbols {
+		if err := s.updateOrderBookCache(ctx, symbol); err != nil {
+			s.logger.Warn("Failed to update order book cache", "symbol", symbol, "error", err)
+		}
+	}
+
+	s.logger.Info("Mass cancel completed",
+		"user_id", filter.UserID,
+		"symbol", filter.Symbol,
+		"side", filter.Side,
+		"matched", len(orders),
+		"cancelled", len(cancelled),
+	)
+
+	return cancelled, nil
+}
+
+// cancelOrder marks an open order as cancelled, records why and publishes
+// the event
+func (s *TradingService) cancelOrder(ctx context.Context, order *shared.Order, reason string) error {
+	if err := order.TransitionTo(shared.OrderStatusCancelled); err != nil {
+		return cancelTransitionError(err.(*shared.OrderTransitionError))
+	}
+
+	s.logger.Info("Cancelling order", "order_id", order.ID, "user_id", order.UserID)
+
+	// The update fails if the order was filled since it was loaded
+	order.UpdatedAt = time.Now()
+	event := s.orderEvent(order, shared.OrderEventCancelled, shared.OrderEventSourceClient, reason)
+	if err := s.orderRepo.Update(ctx, order, event); err != nil {
+		if transitionErr, ok := err.(*shared.OrderTransitionError); ok {
+			return cancelTransitionError(transitionErr)
+		}
 		return shared.NewServiceErrorWithCause("trading", "cancel_order", "failed to update order", err)
 	}
 
+	s.releaseReservation(ctx, order.ID)
+
 	// Publish order cancelled event
 	if err := s.eventBus.Publish(ctx, &shared.Event{
 		Type:   shared.EventTypeOrderCancelled,
 		Source: "trading-api",
 		Data: map[string]interface{}{
-			"order_id": orderID,
+			"order_id": order.ID,
 			"user_id":  order.UserID,
+			"symbol":   order.Symbol,
+			"side":     order.Side,
+			"type":     order.Type,
+			"price":    order.Price,
+			"quantity": order.Quantity,
 		},
 	}); err != nil {
 		s.logger.Warn("Failed to publish order cancelled event", "error", err)
 	}
 
+	return nil
+}
+
+// cancelTransitionError reports an order that cannot be cancelled because of
+// its status
+func cancelTransitionError(err *shared.OrderTransitionError) *shared.BusinessError {
+	return shared.NewBusinessErrorWithDetails(err.Code(), "order cannot be cancelled", err.Error())
+}
+
+// CancelOrderByClientID cancels an order identified by the user's client order ID
+func (s *TradingService) CancelOrderByClientID(ctx context.Context, userID, clientOrderID string) (*shared.Order, error) {
+	order, err := s.orderRepo.GetByClientOrderID(ctx, userID, clientOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.CancelOrder(ctx, order.ID); err != nil {
+		return nil, err
+	}
+
+	return s.orderRepo.GetByID(ctx, order.ID)
+}
+
+// AmendOrder reduces the open quantity of a limit order. The order keeps its
+// place in the book and the amendment is recorded as an AMENDED event.
+func (s *TradingService) AmendOrder(ctx context.Context, orderID string, quantity float64) (*shared.Order, error) {
+	if quantity <= 0 {
+		return nil, shared.NewValidationError("quantity", "quantity must be positive")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !order.Status.IsOpen() {
+		return nil, amendTransitionError(&shared.OrderTransitionError{OrderID: order.ID, From: order.Status, To: order.Status})
+	}
+	if order.Type != shared.OrderTypeLimit {
+		return nil, shared.NewValidationError("type", "only limit orders can be amended")
+	}
+	if quantity >= order.Quantity {
+		return nil, shared.NewValidationError("quantity", fmt.Sprintf("quantity must be below the open quantity of %.8g", order.Quantity))
+	}
+
+	s.logger.Info("Amending order", "order_id", order.ID, "user_id", order.UserID, "quantity", quantity)
+
+	// The amend fails if the order was filled or closed since it was loaded,
+	// and releases the reservation above the reduced quantity with it
+	previous := order.Quantity
+	order.Quantity = quantity
+	order.UpdatedAt = time.Now()
+	reason := fmt.Sprintf("quantity reduced from %.8g to %.8g", previous, quantity)
+	event := s.orderEvent(order, shared.OrderEventAmended, shared.OrderEventSourceClient, reason)
+	if err := s.orderRepo.Amend(ctx, order, previous, event); err != nil {
+		if transitionErr, ok := err.(*shared.OrderTransitionError); ok {
+			return nil, amendTransitionError(transitionErr)
+		}
+		switch err {
+		case shared.ErrOrderNotFound:
+			return nil, err
+		case shared.ErrOrderModified:
+			return nil, shared.NewBusinessErrorWithDetails(shared.ErrCodeOrderModified,
+				"order cannot be amended", "the order was filled since it was loaded")
+		}
+		return nil, shared.NewServiceErrorWithCause("trading", "amend_order", "failed to amend order", err)
+	}
+
+	// Publish order amended event
+	if err := s.eventBus.Publish(ctx, &shared.Event{
+		Type:   shared.EventTypeOrderAmended,
+		Source: "trading-api",
+		Data: map[string]interface{}{
+			"order_id":          order.ID,
+			"user_id":           order.UserID,
+			"symbol":            order.Symbol,
+			"side":              order.Side,
+			"price":             order.Price,
+			"quantity":          order.Quantity,
+			"previous_quantity": previous,
+		},
+	}); err != nil {
+		s.logger.Warn("Failed to publish order amended event", "error", err)
+	}
+
 	// Update order book cache
 	if err := s.updateOrderBookCache(ctx, order.Symbol); err != nil {
 		s.logger.Warn("Failed to update order book cache", "error", err)
 	}
 
-	return nil
+	return order, nil
+}
+
+// amendTransitionError reports an order that cannot be amended because of
+// its status
+func amendTransitionError(err *shared.OrderTransitionError) *shared.BusinessError {
+	return shared.NewBusinessErrorWithDetails(err.Code(), "order cannot be amended", err.Error())
 }
 
 // GetOrder retrieves an order by ID
@@ -154,6 +395,19 @@ func (s *TradingService) GetOrder(ctx context.Context, orderID string) (*shared.
 	return s.orderRepo.GetByID(ctx, orderID)
 }
 
+// GetOrderByClientID retrieves an order by the user's client order ID
+func (s *TradingService) GetOrderByClientID(ctx context.Context, userID, clientOrderID string) (*shared.Order, error) {
+	return s.orderRepo.GetByClientOrderID(ctx, userID, clientOrderID)
+}
+
+// GetOrderHistory retrieves the audit trail of an order, oldest event first
+func (s *TradingService) GetOrderHistory(ctx context.Context, orderID string) ([]*shared.OrderEvent, error) {
+	if s.orderEvents == nil {
+		return []*shared.OrderEvent{}, nil
+	}
+	return s.orderEvents.GetByOrderID(ctx, orderID)
+}
+
 // GetRecentOrders retrieves recent orders with optional limit
 func (s *TradingService) GetRecentOrders(ctx context.Context, limit int) ([]*shared.Order, error) {
 	// Get all orders from the last hour for metrics calculation
@@ -224,6 +478,73 @@ func (s *TradingService) GetTrades(ctx context.Context, symbol string, limit int
 	return s.tradeRepo.GetRecentTrades(ctx, limit)
 }
 
+// GetTradeHistory retrieves a page of trades for a symbol, newest first.
+// An empty cursor starts from the latest trade; the returned NextCursor is
+// empty once the history is exhausted.
+func (s *TradingService) GetTradeHistory(ctx context.Context, symbol, cursor string, limit int) (*shared.TradePage, error) {
+	var before *shared.TradeCursor
+	if cursor != "" {
+		decoded, err := decodeTradeCursor(cursor)
+		if err != nil {
+			return nil, shared.NewValidationError("cursor", "invalid cursor")
+		}
+		before = decoded
+	}
+
+	// Fetch one extra trade to find out whether another page exists
+	trades, err := s.tradeRepo.GetTradesBySymbolBefore(ctx, symbol, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &shared.TradePage{Trades: trades}
+	if len(trades) > limit {
+		page.Trades = trades[:limit]
+		last := page.Trades[limit-1]
+		page.NextCursor = encodeTradeCursor(last.CreatedAt, last.ID)
+	}
+
+	return page, nil
+}
+
+// GetCandles retrieves OHLCV candles for a symbol. A zero end defaults to now
+// and a zero start to limit intervals before end.
+func (s *TradingService) GetCandles(ctx context.Context, symbol string, interval shared.CandleInterval, start, end time.Time, limit int) ([]*shared.Candle, error) {
+	width := interval.Duration()
+	if width == 0 {
+		return nil, shared.NewValidationError("interval", "interval must be one of 1m, 5m or 1h")
+	}
+
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-time.Duration(limit) * width)
+	}
+	if start.After(end) {
+		return nil, shared.NewValidationError("from", "from must be before to")
+	}
+
+	return s.tradeRepo.GetCandles(ctx, symbol, interval, start, end, limit)
+}
+
+// GetTickers retrieves rolling 24 hour statistics for every traded symbol
+func (s *TradingService) GetTickers(ctx context.Context) ([]*shared.Ticker, error) {
+	tickers, err := s.tradeRepo.GetTickers(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ticker := range tickers {
+		ticker.PriceChange = ticker.LastPrice - ticker.OpenPrice
+		if ticker.OpenPrice > 0 {
+			ticker.PriceChangePerc = ticker.PriceChange / ticker.OpenPrice * 100
+		}
+	}
+
+	return tickers, nil
+}
+
 // processOrderMatching attempts to match an order with existing orders
 func (s *TradingService) processOrderMatching(ctx context.Context, newOrder *shared.Order) error {
 	// Get existing orders for the same symbol
@@ -252,34 +573,76 @@ func (s *TradingService) processOrderMatching(ctx context.Context, newOrder *sha
 
 	// Execute trades for each match
 	for _, match := range matches {
+		if !newOrder.Status.IsOpen() {
+			break
+		}
+
 		trade, err := s.orderMatcher.ExecuteTrade(ctx, match)
 		if err != nil {
 			s.logger.Error("Failed to execute trade", "error", err)
 			continue
 		}
 
-		// Save trade to database
-		if err := s.tradeRepo.Create(ctx, trade); err != nil {
-			s.logger.Error("Failed to save trade", "trade_id", trade.ID, "error", err)
-			continue
+		// The incoming order takes liquidity from the resting order
+		if s.fees != nil {
+			s.fees.Apply(trade, match.BuyOrder.UserID, match.SellOrder.UserID, newOrder.Side)
+		}
+
+		// Fill both orders and save the trade in one transaction, so an
+		// order cancelled or filled since it was loaded is not traded and
+		// neither order is filled without the other
+		resting, incoming := &match.SellOrder, &match.BuyOrder
+		if newOrder.Side == shared.OrderSideSell {
+			resting, incoming = incoming, resting
 		}
+		fills := []*shared.OrderFill{s.orderFill(resting, trade), s.orderFill(incoming, trade)}
 
-		// Update order quantities and statuses
-		if err := s.updateOrdersAfterTrade(ctx, match, trade.Quantity); err != nil {
-			s.logger.Error("Failed to update orders after trade", "trade_id", trade.ID, "error", err)
+		// Cash and shares move between buyer and seller in the same
+		// transaction, so no trade is stored that can't be settled
+		var settlement *shared.TradeSettlement
+		if s.accounts != nil {
+			settlement = &shared.TradeSettlement{BuyerID: match.BuyOrder.UserID, SellerID: match.SellOrder.UserID}
 		}
 
+		if err := s.tradeRepo.Create(ctx, trade, fills, settlement); err != nil {
+			if errors.Is(err, shared.ErrInsufficientBalance) {
+				// The incoming buyer's later matches only cost more
+				if newOrder.Side == shared.OrderSideBuy {
+					return fmt.Errorf("incoming order can't pay for trade %s: %w", trade.ID, err)
+				}
+				s.logger.Warn("Skipping trade, resting buyer can't pay", "trade_id", trade.ID, "order_id", resting.ID)
+				continue
+			}
+			if !s.stillOpen(ctx, newOrder) {
+				return fmt.Errorf("incoming order no longer fillable: %w", err)
+			}
+			s.logger.Warn("Skipping trade, resting order no longer fillable", "trade_id", trade.ID, "order_id", resting.ID, "error", err)
+			continue
+		}
+
+		// Later matches continue from the incoming order's filled state
+		newOrder.Quantity = incoming.Quantity
+		newOrder.Status = incoming.Status
+		newOrder.UpdatedAt = incoming.UpdatedAt
+
 		// Publish trade executed event
 		if err := s.eventBus.Publish(ctx, &shared.Event{
 			Type:   shared.EventTypeTradeExecuted,
 			Source: "trading-api",
 			Data: map[string]interface{}{
-				"trade_id":      trade.ID,
-				"buy_order_id":  trade.BuyOrderID,
-				"sell_order_id": trade.SellOrderID,
-				"symbol":        trade.Symbol,
-				"price":         trade.Price,
-				"quantity":      trade.Quantity,
+				"trade_id":             trade.ID,
+				"buy_order_id":         trade.BuyOrderID,
+				"sell_order_id":        trade.SellOrderID,
+				"buy_client_order_id":  match.BuyOrder.ClientOrderID,
+				"sell_client_order_id": match.SellOrder.ClientOrderID,
+				"buy_user_id":          match.BuyOrder.UserID,
+				"sell_user_id":         match.SellOrder.UserID,
+				"symbol":               trade.Symbol,
+				"price":                trade.Price,
+				"quantity":             trade.Quantity,
+				"taker_side":           string(trade.TakerSide),
+				"buy_fee":              trade.BuyFee,
+				"sell_fee":             trade.SellFee,
 			},
 		}); err != nil {
 			s.logger.Warn("Failed to publish trade executed event", "error", err)
@@ -296,39 +659,109 @@ func (s *TradingService) processOrderMatching(ctx context.Context, newOrder *sha
 	return nil
 }
 
-// updateOrdersAfterTrade updates order quantities and statuses after a trade
-func (s *TradingService) updateOrdersAfterTrade(ctx context.Context, match *shared.Match, tradeQuantity float64) error {
-	// Update buy order
-	buyOrder := match.BuyOrder
-	buyOrder.Quantity -= tradeQuantity
-	if buyOrder.Quantity <= 0 {
-		buyOrder.Status = shared.OrderStatusFilled
-	} else {
-		buyOrder.Status = shared.OrderStatusPartial
+// orderFill describes the fill of an order by a trade. The stored order is
+// reduced by the trade's quantity and the order and its event take the
+// state it is left in.
+func (s *TradingService) orderFill(order *shared.Order, trade *shared.Trade) *shared.OrderFill {
+	event := s.orderEvent(order, shared.OrderEventPartiallyFilled, shared.OrderEventSourceMatching, "")
+	if event != nil {
+		event.TradeID = trade.ID
+		event.FillQuantity = trade.Quantity
+		event.FillPrice = trade.Price
+	}
+	return &shared.OrderFill{Order: order, Quantity: trade.Quantity, Event: event}
+}
+
+// stillOpen reloads an order after a failed fill and reports whether it can
+// still trade. The order takes the stored state.
+func (s *TradingService) stillOpen(ctx context.Context, order *shared.Order) bool {
+	stored, err := s.orderRepo.GetByID(ctx, order.ID)
+	if err != nil {
+		return false
 	}
-	buyOrder.UpdatedAt = time.Now()
+	order.Quantity = stored.Quantity
+	order.Status = stored.Status
+	return stored.Status.IsOpen()
+}
 
-	if err := s.orderRepo.Update(ctx, &buyOrder); err != nil {
-		return fmt.Errorf("failed to update buy order: %w", err)
+// checkRisk runs the order through the risk pipeline. Breached limits are
+// returned as *shared.OrderRejectedError.
+func (s *TradingService) checkRisk(ctx context.Context, order *shared.Order) error {
+	if s.risk == nil {
+		return nil
+	}
+
+	err := s.risk.Check(ctx, &risk.Order{
+		UserID:   order.UserID,
+		Symbol:   order.Symbol,
+		Side:     string(order.Side),
+		Market:   order.Type == shared.OrderTypeMarket,
+		Quantity: order.Quantity,
+		Price:    order.Price,
+	})
+	if err == nil {
+		return nil
 	}
 
-	// Update sell order
-	sellOrder := match.SellOrder
-	sellOrder.Quantity -= tradeQuantity
-	if sellOrder.Quantity <= 0 {
-		sellOrder.Status = shared.OrderStatusFilled
-	} else {
-		sellOrder.Status = shared.OrderStatusPartial
+	if rejection, ok := err.(*risk.Rejection); ok {
+		return &shared.OrderRejectedError{Order: order, Code: rejection.Code, Reason: rejection.Reason}
 	}
-	sellOrder.UpdatedAt = time.Now()
+	return shared.NewServiceErrorWithCause("trading", "place_order", "failed to run risk checks", err)
+}
 
-	if err := s.orderRepo.Update(ctx, &sellOrder); err != nil {
-		return fmt.Errorf("failed to update sell order: %w", err)
+// recordRejection persists an order refused by the pre-trade checks so it
+// shows up in the user's order history
+func (s *TradingService) recordRejection(ctx context.Context, order *shared.Order, rejected *shared.OrderRejectedError, source string) error {
+	if err := order.TransitionTo(shared.OrderStatusRejected); err != nil {
+		return shared.NewServiceErrorWithCause("trading", "place_order", "failed to reject order", err)
+	}
+	order.RejectReason = rejected.Code
+
+	event := s.orderEvent(order, shared.OrderEventRejected, source, rejected.Code+": "+rejected.Reason)
+	if err := s.orderRepo.Create(ctx, order, event); err != nil {
+		return shared.NewServiceErrorWithCause("trading", "place_order", "failed to record rejected order", err)
 	}
 
 	return nil
 }
 
+// orderEvent describes a transition of an order, in its state after the
+// transition, for the audit trail. It returns nil when the audit trail is
+// disabled.
+func (s *TradingService) orderEvent(order *shared.Order, eventType shared.OrderEventType, source, reason string) *shared.OrderEvent {
+	if s.orderEvents == nil {
+		return nil
+	}
+	return newOrderEvent(order, eventType, source, reason)
+}
+
+func newOrderEvent(order *shared.Order, eventType shared.OrderEventType, source, reason string) *shared.OrderEvent {
+	return &shared.OrderEvent{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Symbol:    order.Symbol,
+		Side:      order.Side,
+		OrderType: order.Type,
+		EventType: eventType,
+		Status:    order.Status,
+		Price:     order.Price,
+		Quantity:  math.Max(order.Quantity, 0),
+		Reason:    reason,
+		Source:    source,
+		CreatedAt: time.Now(),
+	}
+}
+
+// releaseReservation returns any funds held for an order, logging failures
+func (s *TradingService) releaseReservation(ctx context.Context, orderID string) {
+	if s.accounts == nil {
+		return
+	}
+	if err := s.accounts.Release(ctx, orderID); err != nil {
+		s.logger.Error("Failed to release reservation", "order_id", orderID, "error", err)
+	}
+}
+
 // updateOrderBookCache updates the cached order book for a symbol
 func (s *TradingService) updateOrderBookCache(ctx context.Context, symbol string) error {
 	orderBook, err := s.GetOrderBook(ctx, symbol)
@@ -339,6 +772,24 @@ func (s *TradingService) updateOrderBookCache(ctx context.Context, symbol string
 	return s.cache.SetOrderBook(ctx, symbol, orderBook)
 }
 
+// duplicateOrderError reports a resubmitted client order ID. Resubmissions must
+// describe the same order; quantity is not compared because fills reduce it.
+func (s *TradingService) duplicateOrderError(order, existing *shared.Order) error {
+	if existing.Symbol != order.Symbol || existing.Side != order.Side ||
+		existing.Type != order.Type || existing.Price != order.Price {
+		return shared.NewBusinessErrorWithDetails(shared.ErrCodeIdempotencyConflict,
+			"client order ID was already used for a different order", existing.ID)
+	}
+
+	s.logger.Info("Duplicate order submission",
+		"order_id", existing.ID,
+		"client_order_id", existing.ClientOrderID,
+		"user_id", existing.UserID,
+	)
+
+	return &shared.DuplicateOrderError{Existing: existing}
+}
+
 // validateOrder validates an order before processing
 func (s *TradingService) validateOrder(order *shared.Order) error {
 	if order.UserID == "" {
@@ -365,5 +816,38 @@ func (s *TradingService) validateOrder(order *shared.Order) error {
 		return shared.NewValidationError("price", "price must be positive for limit orders")
 	}
 
+	if len(order.ClientOrderID) > 64 {
+		return shared.NewValidationError("client_order_id", "client order ID must be at most 64 characters")
+	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+// encodeTradeCursor builds an opaque pagination cursor for a trade
+func encodeTradeCursor(createdAt time.Time, tradeID string) string {
+	raw := strconv.FormatInt(createdAt.UnixNano(), 10) + ":" + tradeID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTradeCursor parses a cursor produced by encodeTradeCursor
+func decodeTradeCursor(cursor string) (*shared.TradeCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	nanos, tradeID, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	if _, err := uuid.Parse(tradeID); err != nil {
+		return nil, err
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &shared.TradeCursor{CreatedAt: time.Unix(0, unixNano), ID: tradeID}, nil
+}
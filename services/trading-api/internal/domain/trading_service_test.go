@@ -0,0 +1,1051 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/risk"
+	"simulated_exchange/pkg/shared"
+)
+
+// memoryOrderRepo is an in-memory shared.OrderRepository that also serves
+// as the shared.OrderEventRepository. Like the Postgres repository, Update
+// only applies status transitions the stored status allows and leaves the
+// open quantity to fills and amendments, and an order is only written
+// together with its event.
+type memoryOrderRepo struct {
+	mu     sync.Mutex
+	orders map[string]*shared.Order
+	events []*shared.OrderEvent
+
+	// eventErr fails every write that records an event, as if the audit
+	// trail insert failed inside the order's transaction
+	eventErr error
+
+	// createHook runs before an order is stored, e.g. to simulate a
+	// concurrent submission
+	createHook func(order *shared.Order)
+
+	// accounts, if set, holds the reservations amends shrink
+	accounts *memoryAccountRepo
+}
+
+func newMemoryOrderRepo() *memoryOrderRepo {
+	return &memoryOrderRepo{orders: make(map[string]*shared.Order)}
+}
+
+func (r *memoryOrderRepo) Create(ctx context.Context, order *shared.Order, event *shared.OrderEvent) error {
+	if r.createHook != nil {
+		r.createHook(order)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.orders[order.ID]; exists {
+		return shared.ErrOrderAlreadyExists
+	}
+	for _, stored := range r.orders {
+		if order.ClientOrderID != "" && stored.UserID == order.UserID && stored.ClientOrderID == order.ClientOrderID {
+			return shared.ErrOrderAlreadyExists
+		}
+	}
+	if err := r.appendLocked(event); err != nil {
+		return err
+	}
+	stored := *order
+	r.orders[order.ID] = &stored
+	return nil
+}
+
+func (r *memoryOrderRepo) GetByID(ctx context.Context, id string) (*shared.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, shared.ErrOrderNotFound
+	}
+	stored := *order
+	return &stored, nil
+}
+
+func (r *memoryOrderRepo) GetByClientOrderID(ctx context.Context, userID, clientOrderID string) (*shared.Order, error) {
+	orders := r.filter(func(o *shared.Order) bool { return o.UserID == userID && o.ClientOrderID == clientOrderID })
+	if len(orders) == 0 {
+		return nil, shared.ErrOrderNotFound
+	}
+	return orders[0], nil
+}
+
+func (r *memoryOrderRepo) GetByUserID(ctx context.Context, userID string) ([]*shared.Order, error) {
+	return r.filter(func(o *shared.Order) bool { return o.UserID == userID }), nil
+}
+
+func (r *memoryOrderRepo) GetBySymbol(ctx context.Context, symbol string) ([]*shared.Order, error) {
+	return r.filter(func(o *shared.Order) bool { return o.Symbol == symbol }), nil
+}
+
+func (r *memoryOrderRepo) GetByStatus(ctx context.Context, status shared.OrderStatus) ([]*shared.Order, error) {
+	return r.filter(func(o *shared.Order) bool { return o.Status == status }), nil
+}
+
+func (r *memoryOrderRepo) Update(ctx context.Context, order *shared.Order, event *shared.OrderEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.orders[order.ID]
+	if !ok {
+		return shared.ErrOrderNotFound
+	}
+	if !stored.Status.CanTransitionTo(order.Status) {
+		return &shared.OrderTransitionError{OrderID: order.ID, From: stored.Status, To: order.Status}
+	}
+	if err := r.appendLocked(event); err != nil {
+		return err
+	}
+	order.Quantity = stored.Quantity
+	if event != nil {
+		r.events[len(r.events)-1].Quantity = stored.Quantity
+	}
+	updated := *order
+	r.orders[order.ID] = &updated
+	return nil
+}
+
+func (r *memoryOrderRepo) Amend(ctx context.Context, order *shared.Order, previousQuantity float64, event *shared.OrderEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.orders[order.ID]
+	if !ok {
+		return shared.ErrOrderNotFound
+	}
+	if !stored.Status.IsOpen() {
+		return &shared.OrderTransitionError{OrderID: order.ID, From: stored.Status, To: order.Status}
+	}
+	if stored.Quantity != previousQuantity {
+		return shared.ErrOrderModified
+	}
+	if err := r.appendLocked(event); err != nil {
+		return err
+	}
+	stored.Price = order.Price
+	stored.Quantity = order.Quantity
+	stored.UpdatedAt = order.UpdatedAt
+	if r.accounts != nil {
+		r.accounts.mu.Lock()
+		defer r.accounts.mu.Unlock()
+		if reservation, ok := r.accounts.reservations[order.ID]; ok && reservation.Remaining > order.Quantity {
+			r.accounts.consume(order.ID, reservation.Remaining-order.Quantity)
+		}
+	}
+	return nil
+}
+
+// fillLocked applies fills to the stored orders, all or none
+func (r *memoryOrderRepo) fillLocked(fills []*shared.OrderFill) error {
+	for _, fill := range fills {
+		stored, ok := r.orders[fill.Order.ID]
+		if !ok {
+			return shared.ErrOrderNotFound
+		}
+		if !stored.Status.IsOpen() {
+			return &shared.OrderTransitionError{OrderID: stored.ID, From: stored.Status, To: shared.OrderStatusFilled}
+		}
+		if stored.Quantity < fill.Quantity {
+			return shared.ErrOrderModified
+		}
+	}
+	if r.eventErr != nil {
+		for _, fill := range fills {
+			if fill.Event != nil {
+				return r.eventErr
+			}
+		}
+	}
+
+	now := time.Now()
+	for _, fill := range fills {
+		stored := r.orders[fill.Order.ID]
+		stored.Quantity -= fill.Quantity
+		stored.Status = shared.OrderStatusPartial
+		if stored.Quantity <= 0 {
+			stored.Status = shared.OrderStatusFilled
+		}
+		stored.UpdatedAt = now
+		fill.Filled(stored.Quantity, stored.Status, now)
+		if err := r.appendLocked(fill.Event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryOrderRepo) appendLocked(event *shared.OrderEvent) error {
+	if event == nil {
+		return nil
+	}
+	if r.eventErr != nil {
+		return r.eventErr
+	}
+	event.ID = int64(len(r.events) + 1)
+	stored := *event
+	r.events = append(r.events, &stored)
+	return nil
+}
+
+func (r *memoryOrderRepo) Append(ctx context.Context, event *shared.OrderEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.appendLocked(event)
+}
+
+func (r *memoryOrderRepo) GetByOrderID(ctx context.Context, orderID string) ([]*shared.OrderEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []*shared.OrderEvent
+	for _, event := range r.events {
+		if event.OrderID == orderID {
+			stored := *event
+			events = append(events, &stored)
+		}
+	}
+	return events, nil
+}
+
+func (r *memoryOrderRepo) GetInTimeRange(ctx context.Context, start, end time.Time) ([]*shared.OrderEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []*shared.OrderEvent
+	for _, event := range r.events {
+		if !event.CreatedAt.Before(start) && event.CreatedAt.Before(end) {
+			stored := *event
+			events = append(events, &stored)
+		}
+	}
+	return events, nil
+}
+
+func (r *memoryOrderRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.orders, id)
+	return nil
+}
+
+func (r *memoryOrderRepo) GetActiveOrders(ctx context.Context) ([]*shared.Order, error) {
+	return r.filter(func(o *shared.Order) bool { return o.Status.IsOpen() }), nil
+}
+
+func (r *memoryOrderRepo) GetOpenOrders(ctx context.Context, filter shared.OrderFilter) ([]*shared.Order, error) {
+	return r.filter(func(o *shared.Order) bool {
+		return o.Status.IsOpen() &&
+			(filter.UserID == "" || o.UserID == filter.UserID) &&
+			(filter.Symbol == "" || o.Symbol == filter.Symbol) &&
+			(filter.Side == "" || o.Side == filter.Side)
+	}), nil
+}
+
+func (r *memoryOrderRepo) GetOrdersInTimeRange(ctx context.Context, start, end time.Time) ([]*shared.Order, error) {
+	return r.filter(func(o *shared.Order) bool { return !o.CreatedAt.Before(start) && o.CreatedAt.Before(end) }), nil
+}
+
+// filter returns copies of the matching orders, oldest first
+func (r *memoryOrderRepo) filter(match func(o *shared.Order) bool) []*shared.Order {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var orders []*shared.Order
+	for _, order := range r.orders {
+		if match(order) {
+			stored := *order
+			orders = append(orders, &stored)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].CreatedAt.Before(orders[j].CreatedAt) })
+	return orders
+}
+
+// memoryTradeRepo is an in-memory shared.TradeRepository that fills the
+// orders of its order repository and settles trades in its account
+// repository
+type memoryTradeRepo struct {
+	mu       sync.Mutex
+	orders   *memoryOrderRepo
+	accounts *memoryAccountRepo
+	trades   []*shared.Trade
+
+	// createHook runs before a trade is stored, e.g. to simulate a
+	// concurrent cancel
+	createHook func(trade *shared.Trade)
+}
+
+func (r *memoryTradeRepo) Create(ctx context.Context, trade *shared.Trade, fills []*shared.OrderFill, settlement *shared.TradeSettlement) error {
+	if r.createHook != nil {
+		r.createHook(trade)
+	}
+
+	r.orders.mu.Lock()
+	defer r.orders.mu.Unlock()
+	if settlement != nil {
+		r.accounts.mu.Lock()
+		defer r.accounts.mu.Unlock()
+		if r.accounts.accounts[settlement.BuyerID].CashBalance < trade.Value()+trade.BuyFee {
+			return shared.ErrInsufficientBalance
+		}
+	}
+	if err := r.orders.fillLocked(fills); err != nil {
+		return err
+	}
+	if settlement != nil {
+		r.accounts.settleLocked(trade, settlement.BuyerID, settlement.SellerID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trades = append(r.trades, trade)
+	return nil
+}
+
+func (r *memoryTradeRepo) GetByID(ctx context.Context, id string) (*shared.Trade, error) {
+	return nil, shared.ErrTradeNotFound
+}
+
+func (r *memoryTradeRepo) GetByOrderID(ctx context.Context, orderID string) ([]*shared.Trade, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetBySymbol(ctx context.Context, symbol string) ([]*shared.Trade, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetTradesInTimeRange(ctx context.Context, start, end time.Time) ([]*shared.Trade, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetRecentTrades(ctx context.Context, limit int) ([]*shared.Trade, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetTradesBySymbolBefore(ctx context.Context, symbol string, before *shared.TradeCursor, limit int) ([]*shared.Trade, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetCandles(ctx context.Context, symbol string, interval shared.CandleInterval, start, end time.Time, limit int) ([]*shared.Candle, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetTickers(ctx context.Context, since time.Time) ([]*shared.Ticker, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetFeeRevenue(ctx context.Context, start, end time.Time) ([]*shared.FeeRevenue, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetUserVolumes(ctx context.Context, since time.Time) (map[string]float64, error) {
+	return nil, nil
+}
+
+func (r *memoryTradeRepo) GetNetPosition(ctx context.Context, userID, symbol string) (float64, error) {
+	r.orders.mu.Lock()
+	defer r.orders.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var position float64
+	for _, trade := range r.trades {
+		if trade.Symbol != symbol {
+			continue
+		}
+		if r.orders.orders[trade.BuyOrderID].UserID == userID {
+			position += trade.Quantity
+		}
+		if r.orders.orders[trade.SellOrderID].UserID == userID {
+			position -= trade.Quantity
+		}
+	}
+	return position, nil
+}
+
+// noCache is a shared.CacheRepository that never holds anything
+type noCache struct{}
+
+func (noCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return nil
+}
+
+func (noCache) Get(ctx context.Context, key string, dest interface{}) error {
+	return errors.New("cache miss")
+}
+
+func (noCache) Delete(ctx context.Context, key string) error { return nil }
+
+func (noCache) Exists(ctx context.Context, key string) (bool, error) { return false, nil }
+
+func (noCache) SetOrderBook(ctx context.Context, symbol string, orderBook *shared.OrderBook) error {
+	return nil
+}
+
+func (noCache) GetOrderBook(ctx context.Context, symbol string) (*shared.OrderBook, error) {
+	return nil, errors.New("cache miss")
+}
+
+func (noCache) SetMarketData(ctx context.Context, symbol string, data *shared.MarketData) error {
+	return nil
+}
+
+func (noCache) GetMarketData(ctx context.Context, symbol string) (*shared.MarketData, error) {
+	return nil, errors.New("cache miss")
+}
+
+// recordingEventBus is a shared.EventBus that keeps published events
+type recordingEventBus struct {
+	mu     sync.Mutex
+	events []*shared.Event
+}
+
+func (b *recordingEventBus) Publish(ctx context.Context, event *shared.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+	return nil
+}
+
+func (b *recordingEventBus) Subscribe(ctx context.Context, eventType shared.EventType, handler shared.EventHandler) error {
+	return nil
+}
+
+func (b *recordingEventBus) Unsubscribe(ctx context.Context, eventType shared.EventType) error {
+	return nil
+}
+
+func (b *recordingEventBus) Close() error { return nil }
+
+// tradingFixture is a trading service on in-memory repositories
+type tradingFixture struct {
+	service *TradingService
+	orders  *memoryOrderRepo
+	trades  *memoryTradeRepo
+	events  *recordingEventBus
+}
+
+func newTradingFixture() *tradingFixture {
+	f := &tradingFixture{
+		orders: newMemoryOrderRepo(),
+		events: &recordingEventBus{},
+	}
+	f.trades = &memoryTradeRepo{orders: f.orders}
+	f.service = NewTradingService(f.orders, f.orders, f.trades, noCache{}, f.events,
+		NewOrderMatcher(discardLogger()), nil, nil, nil, discardLogger())
+	return f
+}
+
+func limitOrder(userID, clientOrderID string, side shared.OrderSide, quantity, price float64) *shared.Order {
+	return &shared.Order{
+		UserID:        userID,
+		ClientOrderID: clientOrderID,
+		Symbol:        "AAPL",
+		Side:          side,
+		Type:          shared.OrderTypeLimit,
+		Quantity:      quantity,
+		Price:         price,
+	}
+}
+
+func TestTradingService_PlaceOrderReplaysDuplicateClientOrderID(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	placed, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "cid-1", shared.OrderSideBuy, 10, 100))
+	require.NoError(t, err)
+
+	// A retry of the same order returns the original instead of placing
+	// another
+	_, err = f.service.PlaceOrder(ctx, limitOrder("user-1", "cid-1", shared.OrderSideBuy, 10, 100))
+	var duplicate *shared.DuplicateOrderError
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, placed.ID, duplicate.Existing.ID)
+
+	// Partial fills reduce the quantity, which a retry still matches
+	_, err = f.service.PlaceOrder(ctx, limitOrder("user-2", "", shared.OrderSideSell, 4, 100))
+	require.NoError(t, err)
+	_, err = f.service.PlaceOrder(ctx, limitOrder("user-1", "cid-1", shared.OrderSideBuy, 10, 100))
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, shared.OrderStatusPartial, duplicate.Existing.Status)
+
+	// Client order IDs are scoped to the user
+	_, err = f.service.PlaceOrder(ctx, limitOrder("user-3", "cid-1", shared.OrderSideBuy, 10, 100))
+	assert.NoError(t, err)
+
+	buys, err := f.orders.GetByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, buys, 1)
+}
+
+func TestTradingService_PlaceOrderRejectsReusedClientOrderID(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	_, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "cid-1", shared.OrderSideBuy, 10, 100))
+	require.NoError(t, err)
+
+	for _, reused := range []*shared.Order{
+		limitOrder("user-1", "cid-1", shared.OrderSideSell, 10, 100),
+		limitOrder("user-1", "cid-1", shared.OrderSideBuy, 10, 101),
+	} {
+		_, err := f.service.PlaceOrder(ctx, reused)
+		var businessErr *shared.BusinessError
+		require.ErrorAs(t, err, &businessErr)
+		assert.Equal(t, shared.ErrCodeIdempotencyConflict, businessErr.Code)
+	}
+}
+
+func TestTradingService_PlaceOrderLosesClientOrderIDRace(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	// Another submission with the same client order ID is stored between
+	// the lookup and the insert
+	var winner *shared.Order
+	f.orders.createHook = func(order *shared.Order) {
+		f.orders.createHook = nil
+		winner = limitOrder("user-1", "cid-1", shared.OrderSideBuy, 10, 100)
+		winner.ID = "winner"
+		winner.Status = shared.OrderStatusPending
+		require.NoError(t, f.orders.Create(ctx, winner, nil))
+	}
+
+	_, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "cid-1", shared.OrderSideBuy, 10, 100))
+	var duplicate *shared.DuplicateOrderError
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, "winner", duplicate.Existing.ID)
+
+	orders, err := f.orders.GetByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, orders, 1)
+}
+
+func TestTradingService_PlaceOrderConcurrentDuplicates(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	const submissions = 20
+	var wg sync.WaitGroup
+	ids := make(chan string, submissions)
+	for i := 0; i < submissions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			placed, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "cid-1", shared.OrderSideBuy, 10, 100))
+			var duplicate *shared.DuplicateOrderError
+			switch {
+			case err == nil:
+				ids <- placed.ID
+			case errors.As(err, &duplicate):
+				ids <- duplicate.Existing.ID
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	// Every submission resolves to the one stored order
+	orders, err := f.orders.GetByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	for id := range ids {
+		assert.Equal(t, orders[0].ID, id)
+	}
+}
+
+func TestTradingService_CancelOrdersFilters(t *testing.T) {
+	ctx := context.Background()
+	place := func(f *tradingFixture, userID, symbol string, side shared.OrderSide, price float64) string {
+		order := limitOrder(userID, "", side, 1, price)
+		order.Symbol = symbol
+		placed, err := f.service.PlaceOrder(ctx, order)
+		require.NoError(t, err)
+		return placed.ID
+	}
+
+	tests := []struct {
+		name   string
+		filter shared.OrderFilter
+		want   []int
+	}{
+		{"symbol", shared.OrderFilter{Symbol: "AAPL"}, []int{0, 1, 3}},
+		{"side", shared.OrderFilter{Side: shared.OrderSideBuy}, []int{0, 2, 3}},
+		{"symbol and side", shared.OrderFilter{Symbol: "AAPL", Side: shared.OrderSideSell}, []int{1}},
+		{"user, symbol and side", shared.OrderFilter{UserID: "user-2", Symbol: "AAPL", Side: shared.OrderSideBuy}, []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTradingFixture()
+			// Bids below the asks so nothing trades
+			ids := []string{
+				place(f, "user-1", "AAPL", shared.OrderSideBuy, 90),
+				place(f, "user-1", "AAPL", shared.OrderSideSell, 110),
+				place(f, "user-1", "MSFT", shared.OrderSideBuy, 90),
+				place(f, "user-2", "AAPL", shared.OrderSideBuy, 90),
+			}
+
+			cancelled, err := f.service.CancelOrders(ctx, tt.filter)
+			require.NoError(t, err)
+
+			var want, got []string
+			for _, i := range tt.want {
+				want = append(want, ids[i])
+			}
+			for _, order := range cancelled {
+				got = append(got, order.ID)
+			}
+			assert.ElementsMatch(t, want, got)
+
+			for _, id := range ids {
+				order, err := f.orders.GetByID(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, contains(want, id), order.Status == shared.OrderStatusCancelled, id)
+			}
+		})
+	}
+
+	f := newTradingFixture()
+	_, err := f.service.CancelOrders(ctx, shared.OrderFilter{})
+	assert.Error(t, err)
+	_, err = f.service.CancelOrders(ctx, shared.OrderFilter{Side: "HOLD"})
+	assert.Error(t, err)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func eventTypes(events []*shared.OrderEvent) []shared.OrderEventType {
+	types := make([]shared.OrderEventType, len(events))
+	for i, event := range events {
+		types[i] = event.EventType
+	}
+	return types
+}
+
+func TestTradingService_RecordsOrderLifecycle(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	buy, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "", shared.OrderSideBuy, 10, 100))
+	require.NoError(t, err)
+	_, err = f.service.PlaceOrder(ctx, limitOrder("user-2", "", shared.OrderSideSell, 4, 100))
+	require.NoError(t, err)
+
+	amended, err := f.service.AmendOrder(ctx, buy.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, amended.Quantity)
+	require.NoError(t, f.service.CancelOrder(ctx, buy.ID))
+
+	history, err := f.service.GetOrderHistory(ctx, buy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []shared.OrderEventType{
+		shared.OrderEventAccepted,
+		shared.OrderEventPartiallyFilled,
+		shared.OrderEventAmended,
+		shared.OrderEventCancelled,
+	}, eventTypes(history))
+	assert.Equal(t, 6.0, history[1].Quantity)
+	assert.Equal(t, 4.0, history[1].FillQuantity)
+	assert.NotEmpty(t, history[1].TradeID)
+	assert.Equal(t, 2.0, history[2].Quantity)
+	assert.Equal(t, shared.OrderStatusPartial, history[2].Status)
+
+	var amendedEvents int
+	for _, event := range f.events.events {
+		if event.Type == shared.EventTypeOrderAmended {
+			amendedEvents++
+		}
+	}
+	assert.Equal(t, 1, amendedEvents)
+}
+
+func TestTradingService_FailedEventWriteFailsTheOrderChange(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	placed, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "", shared.OrderSideBuy, 10, 100))
+	require.NoError(t, err)
+
+	f.orders.eventErr = errors.New("audit trail unavailable")
+
+	// Nothing is stored without its event
+	_, err = f.service.PlaceOrder(ctx, limitOrder("user-1", "", shared.OrderSideBuy, 5, 99))
+	assert.Error(t, err)
+	orders, err := f.orders.GetByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, orders, 1)
+
+	assert.Error(t, f.service.CancelOrder(ctx, placed.ID))
+	_, err = f.service.AmendOrder(ctx, placed.ID, 5)
+	assert.Error(t, err)
+
+	stored, err := f.orders.GetByID(ctx, placed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, shared.OrderStatusPending, stored.Status)
+	assert.Equal(t, 10.0, stored.Quantity)
+
+	history, err := f.service.GetOrderHistory(ctx, placed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []shared.OrderEventType{shared.OrderEventAccepted}, eventTypes(history))
+}
+
+func TestTradingService_AmendOrderValidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		order    func(f *tradingFixture) string
+		quantity float64
+		field    string
+		code     string
+	}{
+		{
+			name:     "quantity not positive",
+			quantity: 0,
+			field:    "quantity",
+		},
+		{
+			name:     "quantity not reduced",
+			quantity: 10,
+			field:    "quantity",
+		},
+		{
+			name: "market order",
+			order: func(f *tradingFixture) string {
+				order := &shared.Order{ID: "market", UserID: "user-1", Symbol: "AAPL", Side: shared.OrderSideBuy,
+					Type: shared.OrderTypeMarket, Quantity: 10, Status: shared.OrderStatusPending}
+				require.NoError(t, f.orders.Create(ctx, order, nil))
+				return order.ID
+			},
+			quantity: 5,
+			field:    "type",
+		},
+		{
+			name: "cancelled order",
+			order: func(f *tradingFixture) string {
+				placed, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "", shared.OrderSideBuy, 10, 100))
+				require.NoError(t, err)
+				require.NoError(t, f.service.CancelOrder(ctx, placed.ID))
+				return placed.ID
+			},
+			quantity: 5,
+			code:     shared.ErrCodeOrderAlreadyCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTradingFixture()
+			var orderID string
+			if tt.order != nil {
+				orderID = tt.order(f)
+			} else {
+				placed, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "", shared.OrderSideBuy, 10, 100))
+				require.NoError(t, err)
+				orderID = placed.ID
+			}
+
+			_, err := f.service.AmendOrder(ctx, orderID, tt.quantity)
+			if tt.field != "" {
+				var validation *shared.ValidationError
+				require.ErrorAs(t, err, &validation)
+				assert.Equal(t, tt.field, validation.Field)
+				return
+			}
+			var businessErr *shared.BusinessError
+			require.ErrorAs(t, err, &businessErr)
+			assert.Equal(t, tt.code, businessErr.Code)
+		})
+	}
+
+	f := newTradingFixture()
+	_, err := f.service.AmendOrder(ctx, "missing", 5)
+	assert.Equal(t, shared.ErrOrderNotFound, err)
+}
+
+func TestTradingService_ConcurrentFillsOfOneRestingOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		buys     int
+		quantity float64
+	}{
+		{"buys that exactly fill the order", 20, 1},
+		{"buys that overfill the order", 8, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTradingFixture()
+			ctx := context.Background()
+
+			sell, err := f.service.PlaceOrder(ctx, limitOrder("seller", "", shared.OrderSideSell, 10, 100))
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+			buyIDs := make(chan string, tt.buys)
+			for i := 0; i < tt.buys; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					buy, err := f.service.PlaceOrder(ctx, limitOrder("buyer", "", shared.OrderSideBuy, tt.quantity, 100))
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+						return
+					}
+					buyIDs <- buy.ID
+				}()
+			}
+			wg.Wait()
+			close(buyIDs)
+
+			// Every fill of the resting order is backed by a trade and the
+			// order is never filled past its quantity
+			traded := make(map[string]float64)
+			var sold float64
+			for _, trade := range f.trades.trades {
+				assert.Equal(t, sell.ID, trade.SellOrderID)
+				traded[trade.BuyOrderID] += trade.Quantity
+				sold += trade.Quantity
+			}
+			stored, err := f.orders.GetByID(ctx, sell.ID)
+			require.NoError(t, err)
+			assert.InDelta(t, 10, sold+stored.Quantity, 1e-9)
+			assert.GreaterOrEqual(t, stored.Quantity, 0.0)
+			if stored.Quantity == 0 {
+				assert.Equal(t, shared.OrderStatusFilled, stored.Status)
+			}
+
+			for id := range buyIDs {
+				buy, err := f.orders.GetByID(ctx, id)
+				require.NoError(t, err)
+				assert.InDelta(t, tt.quantity, buy.Quantity+traded[id], 1e-9, id)
+			}
+
+			history, err := f.service.GetOrderHistory(ctx, sell.ID)
+			require.NoError(t, err)
+			assert.Len(t, history, len(f.trades.trades)+1)
+		})
+	}
+}
+
+func TestTradingService_ConcurrentCancelAndFill(t *testing.T) {
+	ctx := context.Background()
+	for round := 0; round < 50; round++ {
+		f := newTradingFixture()
+
+		sell, err := f.service.PlaceOrder(ctx, limitOrder("seller", "", shared.OrderSideSell, 10, 100))
+		require.NoError(t, err)
+
+		// Buys fill the resting order one share at a time while its owner
+		// cancels it, racing a different fill each round
+		var wg sync.WaitGroup
+		var cancelErr error
+		var trades int32
+		cancelAt := int32(round%10 + 1)
+		f.trades.createHook = func(trade *shared.Trade) {
+			if atomic.AddInt32(&trades, 1) != cancelAt {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cancelErr = f.service.CancelOrder(ctx, sell.ID)
+			}()
+		}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := f.service.PlaceOrder(ctx, limitOrder("buyer", "", shared.OrderSideBuy, 1, 100)); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// The stored quantity is what the committed fills left over
+		var sold float64
+		for _, trade := range f.trades.trades {
+			require.Equal(t, sell.ID, trade.SellOrderID)
+			sold += trade.Quantity
+		}
+		stored, err := f.orders.GetByID(ctx, sell.ID)
+		require.NoError(t, err)
+		require.InDelta(t, 10-sold, stored.Quantity, 1e-9, "round %d", round)
+
+		// Exactly one of the cancel and the final fill wins
+		switch stored.Status {
+		case shared.OrderStatusCancelled:
+			require.NoError(t, cancelErr, "round %d", round)
+		case shared.OrderStatusFilled:
+			require.Error(t, cancelErr, "round %d", round)
+			require.Zero(t, stored.Quantity, "round %d", round)
+		default:
+			t.Fatalf("round %d: order left %s", round, stored.Status)
+		}
+
+		// The history only made legal moves, one fill per trade, and ends at
+		// the first terminal status
+		history, err := f.service.GetOrderHistory(ctx, sell.ID)
+		require.NoError(t, err)
+		var fills int
+		previous := shared.OrderStatusPending
+		for i, event := range history[1:] {
+			require.True(t, previous.CanTransitionTo(event.Status), "round %d: %s -> %s", round, previous, event.Status)
+			require.False(t, event.Status.IsTerminal() && i != len(history)-2, "round %d: events after %s", round, event.Status)
+			if event.FillQuantity > 0 {
+				fills++
+			}
+			previous = event.Status
+		}
+		require.Equal(t, len(f.trades.trades), fills, "round %d", round)
+	}
+}
+
+func TestTradingService_FillAbortsWhenIncomingOrderIsCancelled(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	sell, err := f.service.PlaceOrder(ctx, limitOrder("seller", "", shared.OrderSideSell, 10, 100))
+	require.NoError(t, err)
+
+	// The buyer cancels the incoming order while its first trade is saved
+	f.trades.createHook = func(trade *shared.Trade) {
+		f.orders.mu.Lock()
+		defer f.orders.mu.Unlock()
+		f.orders.orders[trade.BuyOrderID].Status = shared.OrderStatusCancelled
+	}
+
+	buy, err := f.service.PlaceOrder(ctx, limitOrder("buyer", "", shared.OrderSideBuy, 4, 100))
+	require.NoError(t, err)
+	assert.Equal(t, shared.OrderStatusCancelled, buy.Status)
+
+	// Neither order was filled and no trade was saved
+	assert.Empty(t, f.trades.trades)
+	stored, err := f.orders.GetByID(ctx, sell.ID)
+	require.NoError(t, err)
+	assert.Equal(t, shared.OrderStatusPending, stored.Status)
+	assert.Equal(t, 10.0, stored.Quantity)
+
+	history, err := f.service.GetOrderHistory(ctx, sell.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []shared.OrderEventType{shared.OrderEventAccepted}, eventTypes(history))
+}
+
+func TestTradingService_IncomingOrderFillsAcrossRestingOrders(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	for _, price := range []float64{100, 101} {
+		_, err := f.service.PlaceOrder(ctx, limitOrder("seller", "", shared.OrderSideSell, 3, price))
+		require.NoError(t, err)
+	}
+
+	buy, err := f.service.PlaceOrder(ctx, limitOrder("buyer", "", shared.OrderSideBuy, 5, 101))
+	require.NoError(t, err)
+	assert.Equal(t, shared.OrderStatusFilled, buy.Status)
+	assert.Equal(t, 0.0, buy.Quantity)
+
+	history, err := f.service.GetOrderHistory(ctx, buy.ID)
+	require.NoError(t, err)
+	require.Equal(t, []shared.OrderEventType{
+		shared.OrderEventAccepted,
+		shared.OrderEventPartiallyFilled,
+		shared.OrderEventFilled,
+	}, eventTypes(history))
+	assert.Equal(t, 2.0, history[1].Quantity)
+	assert.Equal(t, 0.0, history[2].Quantity)
+}
+
+func TestTradingService_PlaceOrderStartsPending(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+
+	placed, err := f.service.PlaceOrder(ctx, limitOrder("user-1", "", shared.OrderSideBuy, 1, 100))
+	require.NoError(t, err)
+	assert.Equal(t, shared.OrderStatusPending, placed.Status)
+
+	// Orders submitted in any other state are refused
+	order := limitOrder("user-1", "", shared.OrderSideBuy, 1, 100)
+	order.Status = shared.OrderStatusFilled
+	_, err = f.service.PlaceOrder(ctx, order)
+	var validation *shared.ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, "status", validation.Field)
+}
+
+// countingRecorder is a risk.Recorder counting rejections per rule
+type countingRecorder map[string]int
+
+func (r countingRecorder) RecordRiskRejection(service, rule string) {
+	r[rule]++
+}
+
+func TestTradingService_PlaceOrderRejectsRiskBreaches(t *testing.T) {
+	f := newTradingFixture()
+	ctx := context.Background()
+	recorder := countingRecorder{}
+	f.service.risk = risk.NewPipeline("trading-api", config.RiskConfig{
+		Enabled: true,
+		Default: config.RiskLimits{MaxOrderQuantity: 100, MaxPosition: 50},
+		Symbols: map[string]config.RiskLimits{"AAPL": {MaxOrderQuantity: 20}},
+		Users: map[string]config.RiskLimits{
+			"whale": {MaxOrderQuantity: 40},
+			"mm":    {MaxOrderQuantity: 40},
+		},
+	}, NewRiskState(f.orders, f.trades, nil), recorder, discardLogger())
+
+	place := func(userID, symbol string, side shared.OrderSide, quantity float64) (*shared.Order, error) {
+		order := limitOrder(userID, "", side, quantity, 100)
+		order.Symbol = symbol
+		return f.service.PlaceOrder(ctx, order)
+	}
+	assertRejected := func(err error, code string) {
+		t.Helper()
+		var rejected *shared.OrderRejectedError
+		require.ErrorAs(t, err, &rejected)
+		assert.Equal(t, code, rejected.Code)
+
+		stored, err := f.orders.GetByID(ctx, rejected.Order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, shared.OrderStatusRejected, stored.Status)
+		assert.Equal(t, code, stored.RejectReason)
+	}
+
+	// The symbol override tightens the default order size
+	_, err := place("user-1", "AAPL", shared.OrderSideBuy, 30)
+	assertRejected(err, shared.ErrCodeOrderSizeLimit)
+	_, err = place("user-1", "MSFT", shared.OrderSideBuy, 30)
+	require.NoError(t, err)
+	assert.Equal(t, countingRecorder{"order_size": 1}, recorder)
+
+	// The user override wins over the symbol override
+	_, err = place("whale", "AAPL", shared.OrderSideBuy, 30)
+	require.NoError(t, err)
+	_, err = place("mm", "AAPL", shared.OrderSideSell, 30)
+	require.NoError(t, err)
+	require.Len(t, f.trades.trades, 1)
+
+	// Without accounts the position is netted from the whale's trades
+	_, err = place("whale", "AAPL", shared.OrderSideBuy, 25)
+	assertRejected(err, shared.ErrCodePositionLimit)
+	_, err = place("whale", "AAPL", shared.OrderSideBuy, 20)
+	require.NoError(t, err)
+	assert.Equal(t, countingRecorder{"order_size": 1, "position": 1}, recorder)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+)
+
+// AccountService defines the account operations used by the handler
+type AccountService interface {
+	GetAccountSummary(ctx context.Context, userID string) (*shared.AccountSummary, error)
+	Deposit(ctx context.Context, userID string, amount float64) (*shared.AccountSummary, error)
+	Withdraw(ctx context.Context, userID string, amount float64) (*shared.AccountSummary, error)
+}
+
+// AccountHandler handles account and position HTTP requests
+type AccountHandler struct {
+	accountService AccountService
+	logger         *slog.Logger
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(accountService AccountService, logger *slog.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		logger:         logger,
+	}
+}
+
+// AccountResponse represents a user's balances and positions in API responses
+type AccountResponse struct {
+	UserID        string             `json:"user_id"`
+	CashBalance   float64            `json:"cash_balance"`
+	CashReserved  float64            `json:"cash_reserved"`
+	CashAvailable float64            `json:"cash_available"`
+	Positions     []PositionResponse `json:"positions"`
+}
+
+// CashMovementRequest represents a deposit or withdrawal request
+type CashMovementRequest struct {
+	Amount float64 `json:"amount" binding:"required"`
+}
+
+// PositionResponse represents a single position in API responses
+type PositionResponse struct {
+	Symbol            string  `json:"symbol"`
+	Quantity          float64 `json:"quantity"`
+	ReservedQuantity  float64 `json:"reserved_quantity"`
+	AvailableQuantity float64 `json:"available_quantity"`
+	AveragePrice      float64 `json:"average_price"`
+}
+
+// GetAccount handles GET /api/accounts/:user_id
+func (h *AccountHandler) GetAccount(c *gin.Context) {
+	userID, ok := authorizeUser(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+
+	summary, err := h.accountService.GetAccountSummary(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get account", "error", err, "user_id", userID)
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "ACCOUNT_RETRIEVAL_FAILED",
+				Message: "Failed to retrieve account",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    newAccountResponse(summary),
+	})
+}
+
+// Deposit handles POST /api/accounts/:user_id/deposits
+func (h *AccountHandler) Deposit(c *gin.Context) {
+	h.moveCash(c, "deposit", h.accountService.Deposit)
+}
+
+// Withdraw handles POST /api/accounts/:user_id/withdrawals
+func (h *AccountHandler) Withdraw(c *gin.Context) {
+	h.moveCash(c, "withdrawal", h.accountService.Withdraw)
+}
+
+// moveCash applies a deposit or withdrawal and responds with the account
+func (h *AccountHandler) moveCash(c *gin.Context, kind string, move func(ctx context.Context, userID string, amount float64) (*shared.AccountSummary, error)) {
+	userID, ok := authorizeUser(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+
+	var req CashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	summary, err := move(c.Request.Context(), userID, req.Amount)
+	if err != nil {
+		status := http.StatusInternalServerError
+		apiError := &APIError{Code: "CASH_MOVEMENT_FAILED", Message: "Failed to process " + kind, Details: err.Error()}
+
+		if _, ok := err.(*shared.ValidationError); ok {
+			status = http.StatusBadRequest
+			apiError.Code = "VALIDATION_ERROR"
+		} else if err == shared.ErrInsufficientBalance {
+			status = http.StatusUnprocessableEntity
+			apiError.Code = shared.ErrCodeInsufficientBalance
+			apiError.Message = "Insufficient available cash"
+		} else {
+			h.logger.Error("Failed to move cash", "error", err, "user_id", userID, "kind", kind)
+		}
+
+		c.JSON(status, APIResponse{
+			Success: false,
+			Error:   apiError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    newAccountResponse(summary),
+	})
+}
+
+// newAccountResponse converts an account summary to its API response
+func newAccountResponse(summary *shared.AccountSummary) AccountResponse {
+	response := AccountResponse{
+		UserID:        summary.Account.UserID,
+		CashBalance:   summary.Account.CashBalance,
+		CashReserved:  summary.Account.CashReserved,
+		CashAvailable: summary.Account.AvailableCash(),
+		Positions:     make([]PositionResponse, len(summary.Positions)),
+	}
+	for i, position := range summary.Positions {
+		response.Positions[i] = PositionResponse{
+			Symbol:            position.Symbol,
+			Quantity:          position.Quantity,
+			ReservedQuantity:  position.ReservedQuantity,
+			AvailableQuantity: position.AvailableQuantity(),
+			AveragePrice:      position.AveragePrice,
+		}
+	}
+	return response
+}
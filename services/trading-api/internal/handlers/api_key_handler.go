@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+	"simulated_exchange/services/trading-api/internal/middleware"
+)
+
+// APIKeyService defines the key management operations used by the handler
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID, name string, scopes []shared.APIKeyScope) (*shared.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]*shared.APIKey, error)
+	RevokeAPIKey(ctx context.Context, identity *shared.AuthIdentity, keyID string) error
+}
+
+// APIKeyHandler handles API key management HTTP requests
+type APIKeyHandler struct {
+	keyService APIKeyService
+	logger     *slog.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(keyService APIKeyService, logger *slog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		keyService: keyService,
+		logger:     logger,
+	}
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key.
+// UserID is only honoured for admin callers.
+type CreateAPIKeyRequest struct {
+	UserID string   `json:"user_id"`
+	Name   string   `json:"name" binding:"max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=trade read admin"`
+}
+
+// APIKeyResponse represents API key information in API responses
+type APIKeyResponse struct {
+	ID         string   `json:"id"`
+	UserID     string   `json:"user_id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	Key        string   `json:"key,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+}
+
+// CreateAPIKey handles POST /api/keys
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	identity := middleware.GetAuthIdentity(c)
+	userID, ok := authorizeUser(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	scopes := make([]shared.APIKeyScope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = shared.APIKeyScope(scope)
+
+		// Callers cannot grant scopes they do not hold themselves
+		if identity != nil && !identity.HasScope(scopes[i]) {
+			c.JSON(http.StatusForbidden, APIResponse{
+				Success: false,
+				Error: &APIError{
+					Code:    shared.ErrCodeInsufficientScope,
+					Message: "Cannot grant a scope the caller does not hold",
+					Details: scope,
+				},
+			})
+			return
+		}
+	}
+
+	key, plainKey, err := h.keyService.CreateAPIKey(c.Request.Context(), userID, req.Name, scopes)
+	if err != nil {
+		writeError(c, h.logger, err, "API_KEY_CREATION_FAILED", "Failed to create API key", "user_id", userID)
+		return
+	}
+
+	response := toAPIKeyResponse(key)
+	response.Key = plainKey
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// ListAPIKeys handles GET /api/keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, ok := authorizeUser(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+
+	keys, err := h.keyService.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, h.logger, err, "API_KEY_RETRIEVAL_FAILED", "Failed to list API keys", "user_id", userID)
+		return
+	}
+
+	responses := make([]APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = toAPIKeyResponse(key)
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    responses,
+	})
+}
+
+// RevokeAPIKey handles DELETE /api/keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	keyID := c.Param("id")
+	identity := middleware.GetAuthIdentity(c)
+	if identity == nil {
+		c.JSON(http.StatusUnauthorized, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    shared.ErrCodeUnauthorized,
+				Message: "Authentication required",
+			},
+		})
+		return
+	}
+
+	if err := h.keyService.RevokeAPIKey(c.Request.Context(), identity, keyID); err != nil {
+		writeError(c, h.logger, err, "API_KEY_REVOCATION_FAILED", "Failed to revoke API key", "key_id", keyID)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"key_id":  keyID,
+			"message": "API key revoked successfully",
+		},
+	})
+}
+
+func toAPIKeyResponse(key *shared.APIKey) APIKeyResponse {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	response := APIKeyResponse{
+		ID:        key.ID,
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Scopes:    scopes,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+	if key.LastUsedAt != nil {
+		response.LastUsedAt = key.LastUsedAt.Format(time.RFC3339)
+	}
+	if key.RevokedAt != nil {
+		response.RevokedAt = key.RevokedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
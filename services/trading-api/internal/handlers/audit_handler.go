@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+)
+
+// AuditService defines the audit trail operations used by the handler
+type AuditService interface {
+	ExportCAT(ctx context.Context, tradeDate time.Time, w io.Writer) (string, error)
+}
+
+// AuditHandler handles audit trail export requests
+type AuditHandler struct {
+	auditService AuditService
+	logger       *slog.Logger
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService AuditService, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// ExportCAT handles GET /api/audit/cat/:date. The export is built in memory
+// so failures are still reported as an API error.
+func (h *AuditHandler) ExportCAT(c *gin.Context) {
+	tradeDate, ok := parseTradeDate(c, c.Param("date"))
+	if !ok {
+		return
+	}
+
+	var buffer bytes.Buffer
+	fileName, err := h.auditService.ExportCAT(c.Request.Context(), tradeDate, &buffer)
+	if err != nil {
+		status := http.StatusInternalServerError
+		apiError := &APIError{Code: "CAT_EXPORT_FAILED", Message: "Failed to export order events", Details: err.Error()}
+		if _, ok := err.(*shared.ValidationError); ok {
+			status = http.StatusBadRequest
+			apiError.Code = "VALIDATION_ERROR"
+		} else {
+			h.logger.Error("Failed to export order events", "error", err, "trade_date", c.Param("date"))
+		}
+
+		c.JSON(status, APIResponse{
+			Success: false,
+			Error:   apiError,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Data(http.StatusOK, "application/x-ndjson", buffer.Bytes())
+}
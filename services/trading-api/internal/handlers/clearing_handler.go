@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+)
+
+// ClearingService defines the clearing operations used by the handler
+type ClearingService interface {
+	GetReport(ctx context.Context, tradeDate time.Time) (*shared.SettlementReport, error)
+	RunClearing(ctx context.Context, tradeDate, asOf time.Time) (*shared.SettlementReport, error)
+	SetFailRate(rate float64) error
+}
+
+// ClearingHandler handles settlement report and clearing control requests
+type ClearingHandler struct {
+	clearingService ClearingService
+	logger          *slog.Logger
+}
+
+// NewClearingHandler creates a new clearing handler
+func NewClearingHandler(clearingService ClearingService, logger *slog.Logger) *ClearingHandler {
+	return &ClearingHandler{
+		clearingService: clearingService,
+		logger:          logger,
+	}
+}
+
+// RunClearingRequest represents a request to clear a trade date. AsOf
+// defaults to now; a later time fast-forwards settlement.
+type RunClearingRequest struct {
+	TradeDate string     `json:"trade_date" binding:"required"`
+	AsOf      *time.Time `json:"as_of,omitempty"`
+}
+
+// SetFailRateRequest represents a request to change the settlement fail rate
+type SetFailRateRequest struct {
+	Rate *float64 `json:"rate" binding:"required"`
+}
+
+// GetReport handles GET /api/clearing/reports/:date
+func (h *ClearingHandler) GetReport(c *gin.Context) {
+	tradeDate, ok := parseTradeDate(c, c.Param("date"))
+	if !ok {
+		return
+	}
+
+	report, err := h.clearingService.GetReport(c.Request.Context(), tradeDate)
+	if err != nil {
+		writeError(c, h.logger, err, "SETTLEMENT_REPORT_FAILED", "Failed to build settlement report", "trade_date", c.Param("date"))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// RunClearing handles POST /api/clearing/run
+func (h *ClearingHandler) RunClearing(c *gin.Context) {
+	var req RunClearingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	tradeDate, ok := parseTradeDate(c, req.TradeDate)
+	if !ok {
+		return
+	}
+
+	asOf := time.Now()
+	if req.AsOf != nil {
+		asOf = *req.AsOf
+	}
+
+	report, err := h.clearingService.RunClearing(c.Request.Context(), tradeDate, asOf)
+	if err != nil {
+		writeError(c, h.logger, err, "CLEARING_FAILED", "Failed to run clearing", "trade_date", req.TradeDate)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// SetFailRate handles PUT /api/clearing/failures
+func (h *ClearingHandler) SetFailRate(c *gin.Context) {
+	var req SetFailRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := h.clearingService.SetFailRate(*req.Rate); err != nil {
+		writeError(c, h.logger, err, "FAIL_RATE_UPDATE_FAILED", "Failed to update settlement fail rate")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    gin.H{"rate": *req.Rate},
+	})
+}
+
+// parseTradeDate parses a YYYY-MM-DD trade date. It writes the error
+// response and returns false if the value is invalid.
+func parseTradeDate(c *gin.Context, value string) (time.Time, bool) {
+	tradeDate, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "trade date must be formatted as YYYY-MM-DD",
+			},
+		})
+		return time.Time{}, false
+	}
+	return tradeDate, true
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+)
+
+// notFoundErrors are the not-found sentinels of the services, with the code
+// and message they are answered with
+var notFoundErrors = []struct {
+	err     error
+	code    string
+	message string
+}{
+	{shared.ErrOrderNotFound, shared.ErrCodeOrderNotFound, "Order not found"},
+	{shared.ErrTradeNotFound, "TRADE_NOT_FOUND", "Trade not found"},
+	{shared.ErrUserNotFound, shared.ErrCodeUserNotFound, "User not found"},
+	{shared.ErrAccountNotFound, "ACCOUNT_NOT_FOUND", "Account not found"},
+	{shared.ErrMarketMakerNotFound, "MARKET_MAKER_NOT_FOUND", "Market maker not found"},
+	{shared.ErrAlertNotFound, "ALERT_NOT_FOUND", "Surveillance alert not found"},
+	{shared.ErrAPIKeyNotFound, "API_KEY_NOT_FOUND", "API key not found"},
+}
+
+// writeError maps a service error to an HTTP response. Validation errors are
+// a 400, not-found sentinels a 404 and business errors a 403 when forbidden
+// and a 409 otherwise. Anything else is logged, with the error and args,
+// and answered with a 500 carrying the given code and message.
+func writeError(c *gin.Context, logger *slog.Logger, err error, code, message string, args ...any) {
+	status, apiError := serviceError(err, code, message)
+	if status == http.StatusInternalServerError {
+		logger.Error(message, append([]any{"error", err}, args...)...)
+	}
+
+	c.JSON(status, APIResponse{
+		Success: false,
+		Error:   apiError,
+	})
+}
+
+// serviceError returns the HTTP status and API error for a service error
+func serviceError(err error, code, message string) (int, *APIError) {
+	var validationErr *shared.ValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusBadRequest, &APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Validation failed",
+			Details: validationErr.Error(),
+		}
+	}
+
+	var businessErr *shared.BusinessError
+	if errors.As(err, &businessErr) {
+		status := http.StatusConflict
+		if businessErr.Code == shared.ErrCodeForbidden {
+			status = http.StatusForbidden
+		}
+		return status, &APIError{
+			Code:    businessErr.Code,
+			Message: businessErr.Message,
+			Details: businessErr.Details,
+		}
+	}
+
+	for _, notFound := range notFoundErrors {
+		if errors.Is(err, notFound.err) {
+			return http.StatusNotFound, &APIError{
+				Code:    notFound.code,
+				Message: notFound.message,
+				Details: err.Error(),
+			}
+		}
+	}
+
+	return http.StatusInternalServerError, &APIError{
+		Code:    code,
+		Message: message,
+		Details: err.Error(),
+	}
+}
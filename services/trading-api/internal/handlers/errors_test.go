@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"simulated_exchange/pkg/shared"
+)
+
+func TestServiceError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{
+			name:   "validation error",
+			err:    shared.NewValidationError("symbol", "is required"),
+			status: http.StatusBadRequest,
+			code:   "VALIDATION_ERROR",
+		},
+		{
+			name:   "business error",
+			err:    shared.NewBusinessError(shared.ErrCodeInsufficientBalance, "insufficient balance"),
+			status: http.StatusConflict,
+			code:   shared.ErrCodeInsufficientBalance,
+		},
+		{
+			name:   "forbidden",
+			err:    shared.NewBusinessError(shared.ErrCodeForbidden, "not your key"),
+			status: http.StatusForbidden,
+			code:   shared.ErrCodeForbidden,
+		},
+		{
+			name:   "wrapped not-found sentinel",
+			err:    fmt.Errorf("revoke key k1: %w", shared.ErrAPIKeyNotFound),
+			status: http.StatusNotFound,
+			code:   "API_KEY_NOT_FOUND",
+		},
+		{
+			name:   "alert not found",
+			err:    shared.ErrAlertNotFound,
+			status: http.StatusNotFound,
+			code:   "ALERT_NOT_FOUND",
+		},
+		{
+			name:   "unexpected error",
+			err:    errors.New("database unavailable"),
+			status: http.StatusInternalServerError,
+			code:   "LEDGER_RETRIEVAL_FAILED",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, apiError := serviceError(tt.err, "LEDGER_RETRIEVAL_FAILED", "Failed to retrieve journals")
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.code, apiError.Code)
+			assert.NotEmpty(t, apiError.Message)
+		})
+	}
+}
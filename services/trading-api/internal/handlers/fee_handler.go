@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/internal/reporting"
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/shared"
+)
+
+// defaultRevenueWindow is the revenue period when no range is given
+const defaultRevenueWindow = 24 * time.Hour
+
+// FeeService defines the fee operations used by the handler
+type FeeService interface {
+	GetSchedules() map[string][]config.FeeTier
+	GetUserTier(userID, symbol string) *shared.UserFeeTier
+	GetFeeRevenue(ctx context.Context, start, end time.Time) ([]*shared.FeeRevenue, error)
+	CalculateKPIs(ctx context.Context, data reporting.BusinessData) (*reporting.KPIMetrics, error)
+}
+
+// FeeHandler handles fee schedule and fee revenue requests
+type FeeHandler struct {
+	feeService FeeService
+	logger     *slog.Logger
+}
+
+// NewFeeHandler creates a new fee handler
+func NewFeeHandler(feeService FeeService, logger *slog.Logger) *FeeHandler {
+	return &FeeHandler{
+		feeService: feeService,
+		logger:     logger,
+	}
+}
+
+// FeeRevenueResponse represents fee revenue by symbol over a period
+type FeeRevenueResponse struct {
+	From      time.Time            `json:"from"`
+	To        time.Time            `json:"to"`
+	Volume    float64              `json:"volume"`
+	MakerFees float64              `json:"maker_fees"`
+	TakerFees float64              `json:"taker_fees"`
+	TotalFees float64              `json:"total_fees"`
+	Symbols   []*shared.FeeRevenue `json:"symbols"`
+}
+
+// GetSchedules handles GET /api/fees/schedule
+func (h *FeeHandler) GetSchedules(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    h.feeService.GetSchedules(),
+	})
+}
+
+// GetUserTier handles GET /api/fees/users/:user_id?symbol=
+func (h *FeeHandler) GetUserTier(c *gin.Context) {
+	userID, ok := authorizeUser(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+
+	symbol := strings.ToUpper(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "symbol is required",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    h.feeService.GetUserTier(userID, symbol),
+	})
+}
+
+// GetRevenue handles GET /api/fees/revenue?from=&to=
+func (h *FeeHandler) GetRevenue(c *gin.Context) {
+	start, ok := parseTimeQuery(c, "from")
+	if !ok {
+		return
+	}
+	end, ok := parseTimeQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-defaultRevenueWindow)
+	}
+
+	revenue, err := h.feeService.GetFeeRevenue(c.Request.Context(), start, end)
+	if err != nil {
+		h.logger.Error("Failed to get fee revenue", "error", err)
+		status := http.StatusInternalServerError
+		apiError := &APIError{Code: "FEE_REVENUE_FAILED", Message: "Failed to retrieve fee revenue", Details: err.Error()}
+		if _, ok := err.(*shared.ValidationError); ok {
+			status = http.StatusBadRequest
+			apiError.Code = "VALIDATION_ERROR"
+		}
+		c.JSON(status, APIResponse{
+			Success: false,
+			Error:   apiError,
+		})
+		return
+	}
+
+	response := FeeRevenueResponse{From: start, To: end, Symbols: revenue}
+	for _, symbol := range revenue {
+		response.Volume += symbol.Volume
+		response.MakerFees += symbol.MakerFees
+		response.TakerFees += symbol.TakerFees
+		response.TotalFees += symbol.TotalFees
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// CalculateKPIs handles POST /api/fees/kpis
+func (h *FeeHandler) CalculateKPIs(c *gin.Context) {
+	var data reporting.BusinessData
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	kpis, err := h.feeService.CalculateKPIs(c.Request.Context(), data)
+	if err != nil {
+		h.logger.Error("Failed to calculate KPIs", "error", err)
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "KPI_CALCULATION_FAILED",
+				Message: "Failed to calculate KPIs",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    kpis,
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/ledger"
+)
+
+// defaultLedgerEntryLimit is the number of entries returned when no limit is given
+const defaultLedgerEntryLimit = 100
+
+// LedgerAuditor defines the ledger checks used by the handler
+type LedgerAuditor interface {
+	CheckInvariants(ctx context.Context) (*ledger.InvariantReport, error)
+	Reconcile(ctx context.Context) (*ledger.ReconciliationReport, error)
+}
+
+// LedgerReader defines the ledger queries used by the handler
+type LedgerReader interface {
+	GetJournalsByReference(ctx context.Context, reference string) ([]*ledger.Journal, error)
+	GetAccountEntries(ctx context.Context, account string, limit int) ([]*ledger.Entry, error)
+}
+
+// LedgerHandler handles ledger audit and query requests
+type LedgerHandler struct {
+	auditor LedgerAuditor
+	reader  LedgerReader
+	logger  *slog.Logger
+}
+
+// NewLedgerHandler creates a new ledger handler
+func NewLedgerHandler(auditor LedgerAuditor, reader LedgerReader, logger *slog.Logger) *LedgerHandler {
+	return &LedgerHandler{
+		auditor: auditor,
+		reader:  reader,
+		logger:  logger,
+	}
+}
+
+// GetInvariants handles GET /api/ledger/invariants
+func (h *LedgerHandler) GetInvariants(c *gin.Context) {
+	report, err := h.auditor.CheckInvariants(c.Request.Context())
+	if err != nil {
+		writeError(c, h.logger, err, "LEDGER_INVARIANT_CHECK_FAILED", "Failed to check ledger invariants")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetReconciliation handles GET /api/ledger/reconciliation
+func (h *LedgerHandler) GetReconciliation(c *gin.Context) {
+	report, err := h.auditor.Reconcile(c.Request.Context())
+	if err != nil {
+		writeError(c, h.logger, err, "LEDGER_RECONCILIATION_FAILED", "Failed to reconcile ledger")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetJournals handles GET /api/ledger/journals?reference=
+func (h *LedgerHandler) GetJournals(c *gin.Context) {
+	reference := c.Query("reference")
+	if reference == "" {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "reference is required",
+			},
+		})
+		return
+	}
+
+	journals, err := h.reader.GetJournalsByReference(c.Request.Context(), reference)
+	if err != nil {
+		writeError(c, h.logger, err, "LEDGER_RETRIEVAL_FAILED", "Failed to retrieve journals")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    journals,
+	})
+}
+
+// GetUserEntries handles GET /api/ledger/accounts/:user_id/entries
+func (h *LedgerHandler) GetUserEntries(c *gin.Context) {
+	userID, ok := authorizeUser(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(c, defaultLedgerEntryLimit)
+	if !ok {
+		return
+	}
+
+	entries, err := h.reader.GetAccountEntries(c.Request.Context(), ledger.UserAccount(userID), limit)
+	if err != nil {
+		writeError(c, h.logger, err, "LEDGER_RETRIEVAL_FAILED", "Failed to retrieve ledger entries")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    entries,
+	})
+}
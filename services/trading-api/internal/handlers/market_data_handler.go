@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+)
+
+const (
+	// defaultTradeLimit is the page size for trade history
+	defaultTradeLimit = 100
+
+	// defaultCandleLimit is the number of candles returned when no range is given
+	defaultCandleLimit = 500
+
+	// maxMarketDataLimit caps the page size of trade and candle queries
+	maxMarketDataLimit = 1000
+)
+
+// MarketDataHandler handles trade history, candle and ticker HTTP requests
+type MarketDataHandler struct {
+	tradingService shared.TradingService
+	logger         *slog.Logger
+}
+
+// NewMarketDataHandler creates a new market data handler
+func NewMarketDataHandler(tradingService shared.TradingService, logger *slog.Logger) *MarketDataHandler {
+	return &MarketDataHandler{
+		tradingService: tradingService,
+		logger:         logger,
+	}
+}
+
+// GetTrades handles GET /api/trades/:symbol?cursor=...&limit=...
+func (h *MarketDataHandler) GetTrades(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	limit, ok := parseLimit(c, defaultTradeLimit)
+	if !ok {
+		return
+	}
+
+	page, err := h.tradingService.GetTradeHistory(c.Request.Context(), symbol, c.Query("cursor"), limit)
+	if err != nil {
+		writeError(c, h.logger, err, "TRADE_RETRIEVAL_FAILED", "Failed to retrieve trades", "symbol", symbol)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    page,
+	})
+}
+
+// GetCandles handles GET /api/candles/:symbol?interval=1m|5m|1h&from=...&to=...&limit=...
+func (h *MarketDataHandler) GetCandles(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	interval := shared.CandleInterval(c.DefaultQuery("interval", string(shared.CandleInterval1m)))
+
+	limit, ok := parseLimit(c, defaultCandleLimit)
+	if !ok {
+		return
+	}
+
+	from, ok := parseTimeQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseTimeQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	candles, err := h.tradingService.GetCandles(c.Request.Context(), symbol, interval, from, to, limit)
+	if err != nil {
+		writeError(c, h.logger, err, "CANDLE_RETRIEVAL_FAILED", "Failed to retrieve candles", "symbol", symbol, "interval", interval)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"symbol":   symbol,
+			"interval": interval,
+			"candles":  candles,
+		},
+	})
+}
+
+// GetTickers handles GET /api/ticker with optional ?symbol= filter
+func (h *MarketDataHandler) GetTickers(c *gin.Context) {
+	tickers, err := h.tradingService.GetTickers(c.Request.Context())
+	if err != nil {
+		writeError(c, h.logger, err, "TICKER_RETRIEVAL_FAILED", "Failed to retrieve tickers")
+		return
+	}
+
+	if symbol := strings.ToUpper(c.Query("symbol")); symbol != "" {
+		filtered := make([]*shared.Ticker, 0, 1)
+		for _, ticker := range tickers {
+			if ticker.Symbol == symbol {
+				filtered = append(filtered, ticker)
+			}
+		}
+		tickers = filtered
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    tickers,
+	})
+}
+
+// parseLimit reads the limit query parameter. It writes the error response
+// and returns false if the value is invalid.
+func parseLimit(c *gin.Context, defaultLimit int) (int, bool) {
+	limitParam := c.Query("limit")
+	if limitParam == "" {
+		return defaultLimit, true
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit <= 0 || limit > maxMarketDataLimit {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "limit must be between 1 and " + strconv.Itoa(maxMarketDataLimit),
+			},
+		})
+		return 0, false
+	}
+
+	return limit, true
+}
+
+// parseTimeQuery reads an optional RFC3339 or unix seconds time parameter.
+// It writes the error response and returns false if the value is invalid.
+func parseTimeQuery(c *gin.Context, name string) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, true
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+
+	c.JSON(http.StatusBadRequest, APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: name + " must be an RFC3339 timestamp or unix seconds",
+		},
+	})
+	return time.Time{}, false
+}
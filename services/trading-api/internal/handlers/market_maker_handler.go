@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+)
+
+// defaultComplianceWindow is the compliance report period when no range is given
+const defaultComplianceWindow = 7 * 24 * time.Hour
+
+// MarketMakerService defines the market maker program operations used by the handler
+type MarketMakerService interface {
+	NewRegistration(userID, symbol string) *shared.MarketMaker
+	Register(ctx context.Context, marketMaker *shared.MarketMaker) (*shared.MarketMaker, error)
+	Deregister(ctx context.Context, userID, symbol string) error
+	GetMarketMakers() []*shared.MarketMakerStatus
+	GetComplianceReport(ctx context.Context, userID string, start, end time.Time) (*shared.MarketMakerReport, error)
+}
+
+// MarketMakerHandler handles market maker registration and compliance requests
+type MarketMakerHandler struct {
+	marketMakerService MarketMakerService
+	logger             *slog.Logger
+}
+
+// NewMarketMakerHandler creates a new market maker handler
+func NewMarketMakerHandler(marketMakerService MarketMakerService, logger *slog.Logger) *MarketMakerHandler {
+	return &MarketMakerHandler{
+		marketMakerService: marketMakerService,
+		logger:             logger,
+	}
+}
+
+// RegisterMarketMakerRequest represents a request to register a designated
+// market maker. Unset obligations and rebate take the program defaults.
+type RegisterMarketMakerRequest struct {
+	UserID       string   `json:"user_id" binding:"required"`
+	Symbol       string   `json:"symbol" binding:"required,min=1,max=10"`
+	MaxSpreadBps *float64 `json:"max_spread_bps,omitempty"`
+	MinQuoteSize *float64 `json:"min_quote_size,omitempty"`
+	MinUptime    *float64 `json:"min_uptime,omitempty"`
+	RebateBps    *float64 `json:"rebate_bps,omitempty"`
+}
+
+// GetMarketMakers handles GET /api/market-makers
+func (h *MarketMakerHandler) GetMarketMakers(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    h.marketMakerService.GetMarketMakers(),
+	})
+}
+
+// Register handles POST /api/market-makers
+func (h *MarketMakerHandler) Register(c *gin.Context) {
+	var req RegisterMarketMakerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	marketMaker := h.marketMakerService.NewRegistration(req.UserID, req.Symbol)
+	if req.MaxSpreadBps != nil {
+		marketMaker.MaxSpreadBps = *req.MaxSpreadBps
+	}
+	if req.MinQuoteSize != nil {
+		marketMaker.MinQuoteSize = *req.MinQuoteSize
+	}
+	if req.MinUptime != nil {
+		marketMaker.MinUptime = *req.MinUptime
+	}
+	if req.RebateBps != nil {
+		marketMaker.RebateBps = *req.RebateBps
+	}
+
+	registered, err := h.marketMakerService.Register(c.Request.Context(), marketMaker)
+	if err != nil {
+		writeError(c, h.logger, err, "MARKET_MAKER_REGISTRATION_FAILED", "Failed to register market maker")
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Success: true,
+		Data:    registered,
+	})
+}
+
+// Deregister handles DELETE /api/market-makers/:user_id/:symbol
+func (h *MarketMakerHandler) Deregister(c *gin.Context) {
+	if err := h.marketMakerService.Deregister(c.Request.Context(), c.Param("user_id"), c.Param("symbol")); err != nil {
+		writeError(c, h.logger, err, "MARKET_MAKER_DEREGISTRATION_FAILED", "Failed to deregister market maker")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: gin.H{
+			"user_id": c.Param("user_id"),
+			"symbol":  c.Param("symbol"),
+		},
+	})
+}
+
+// GetComplianceReport handles GET /api/market-makers/:user_id/compliance?from=&to=
+func (h *MarketMakerHandler) GetComplianceReport(c *gin.Context) {
+	userID, ok := authorizeUser(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+
+	start, ok := parseTimeQuery(c, "from")
+	if !ok {
+		return
+	}
+	end, ok := parseTimeQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-defaultComplianceWindow)
+	}
+
+	report, err := h.marketMakerService.GetComplianceReport(c.Request.Context(), userID, start, end)
+	if err != nil {
+		writeError(c, h.logger, err, "COMPLIANCE_REPORT_FAILED", "Failed to build compliance report")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
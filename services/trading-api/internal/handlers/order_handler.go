@@ -4,11 +4,14 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"simulated_exchange/pkg/monitoring"
 	"simulated_exchange/pkg/shared"
+	"simulated_exchange/services/trading-api/internal/middleware"
 )
 
 // OrderHandler handles order-related HTTP requests
@@ -27,35 +30,80 @@ func NewOrderHandler(tradingService shared.TradingService, metricsCollector *mon
 	}
 }
 
-// PlaceOrderRequest represents the request body for placing an order
+// IdempotencyKeyHeader carries the client order ID when it is not in the body
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// PlaceOrderRequest represents the request body for placing an order.
+// UserID may be omitted when the request is authenticated. ClientOrderID
+// (or the Idempotency-Key header) makes resubmissions safe to retry.
 type PlaceOrderRequest struct {
-	UserID   string  `json:"user_id" binding:"required"`
-	Symbol   string  `json:"symbol" binding:"required,min=1,max=10"`
-	Side     string  `json:"side" binding:"required,oneof=BUY SELL"`
-	Type     string  `json:"type" binding:"required,oneof=MARKET LIMIT"`
+	UserID        string  `json:"user_id"`
+	ClientOrderID string  `json:"client_order_id" binding:"omitempty,max=64"`
+	Symbol        string  `json:"symbol" binding:"required,min=1,max=10"`
+	Side          string  `json:"side" binding:"required,oneof=BUY SELL"`
+	Type          string  `json:"type" binding:"required,oneof=MARKET LIMIT"`
+	Quantity      float64 `json:"quantity" binding:"required,gt=0"`
+	Price         float64 `json:"price" binding:"omitempty,gt=0"`
+}
+
+// AmendOrderRequest represents the request body for amending an order. The
+// new quantity must be below the order's open quantity.
+type AmendOrderRequest struct {
 	Quantity float64 `json:"quantity" binding:"required,gt=0"`
-	Price    float64 `json:"price" binding:"omitempty,gt=0"`
 }
 
 // PlaceOrderResponse represents the response after placing an order
 type PlaceOrderResponse struct {
-	OrderID string `json:"order_id"`
-	Status  string `json:"status"`
-	Message string `json:"message"`
+	OrderID       string `json:"order_id"`
+	ClientOrderID string `json:"client_order_id,omitempty"`
+	Status        string `json:"status"`
+	Message       string `json:"message"`
+}
+
+// PlaceOrderBatchRequest represents the request body for placing a batch of
+// up to 500 orders
+type PlaceOrderBatchRequest struct {
+	Orders []PlaceOrderRequest `json:"orders" binding:"required,min=1,max=500"`
+}
+
+// BatchOrderResult represents the outcome of one order in a batch
+type BatchOrderResult struct {
+	Index         int       `json:"index"`
+	Success       bool      `json:"success"`
+	OrderID       string    `json:"order_id,omitempty"`
+	ClientOrderID string    `json:"client_order_id,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	Replayed      bool      `json:"replayed,omitempty"`
+	Error         *APIError `json:"error,omitempty"`
+}
+
+// PlaceOrderBatchResponse represents the response after placing a batch of orders
+type PlaceOrderBatchResponse struct {
+	Accepted int                `json:"accepted"`
+	Rejected int                `json:"rejected"`
+	Results  []BatchOrderResult `json:"results"`
+}
+
+// CancelOrdersResponse represents the response after a mass cancel
+type CancelOrdersResponse struct {
+	Cancelled int             `json:"cancelled"`
+	Orders    []OrderResponse `json:"orders"`
 }
 
 // OrderResponse represents order information in API responses
 type OrderResponse struct {
-	ID        string  `json:"id"`
-	UserID    string  `json:"user_id"`
-	Symbol    string  `json:"symbol"`
-	Side      string  `json:"side"`
-	Type      string  `json:"type"`
-	Quantity  float64 `json:"quantity"`
-	Price     float64 `json:"price"`
-	Status    string  `json:"status"`
-	CreatedAt string  `json:"created_at"`
-	UpdatedAt string  `json:"updated_at"`
+	ID            string  `json:"id"`
+	ClientOrderID string  `json:"client_order_id,omitempty"`
+	UserID        string  `json:"user_id"`
+	Symbol        string  `json:"symbol"`
+	Side          string  `json:"side"`
+	Type          string  `json:"type"`
+	Quantity      float64 `json:"quantity"`
+	Price         float64 `json:"price"`
+	Status        string  `json:"status"`
+	RejectReason  string  `json:"reject_reason,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     string  `json:"updated_at"`
 }
 
 // OrderBookResponse represents order book information
@@ -118,52 +166,73 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 		return
 	}
 
+	// Accept the client order ID from the Idempotency-Key header as well
+	if key := c.GetHeader(IdempotencyKeyHeader); key != "" {
+		if req.ClientOrderID != "" && req.ClientOrderID != key {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Success: false,
+				Error: &APIError{
+					Code:    "VALIDATION_ERROR",
+					Message: "Idempotency-Key header does not match client_order_id",
+				},
+			})
+			return
+		}
+		req.ClientOrderID = key
+	}
+
+	userID, ok := authorizeUser(c, req.UserID)
+	if !ok {
+		return
+	}
+	req.UserID = userID
+
 	// Convert to domain model
 	order := &shared.Order{
-		UserID:   req.UserID,
-		Symbol:   req.Symbol,
-		Side:     shared.OrderSide(req.Side),
-		Type:     shared.OrderType(req.Type),
-		Quantity: req.Quantity,
-		Price:    req.Price,
+		UserID:        req.UserID,
+		ClientOrderID: req.ClientOrderID,
+		Symbol:        req.Symbol,
+		Side:          shared.OrderSide(req.Side),
+		Type:          shared.OrderType(req.Type),
+		Quantity:      req.Quantity,
+		Price:         req.Price,
 	}
 
 	// Call service layer
 	placedOrder, err := h.tradingService.PlaceOrder(c.Request.Context(), order)
 	processingTime := time.Since(startTime)
 
+	// Duplicate submissions replay the original order instead of failing
+	if duplicate, ok := err.(*shared.DuplicateOrderError); ok {
+		c.Header("Idempotent-Replayed", "true")
+		c.JSON(http.StatusOK, APIResponse{
+			Success: true,
+			Data: PlaceOrderResponse{
+				OrderID:       duplicate.Existing.ID,
+				ClientOrderID: duplicate.Existing.ClientOrderID,
+				Status:        string(duplicate.Existing.Status),
+				Message:       "Order already placed",
+			},
+		})
+		return
+	}
+
 	if err != nil {
-		h.logger.Error("Failed to place order", "error", err, "user_id", req.UserID)
+		outcome := "failed"
+		if _, rejected := err.(*shared.OrderRejectedError); rejected {
+			outcome = "rejected"
+			h.logger.Info("Order rejected", "error", err, "user_id", req.UserID)
+		} else {
+			h.logger.Error("Failed to place order", "error", err, "user_id", req.UserID)
+		}
 
 		// Record failed order metric
 		if h.metricsCollector != nil {
-			h.metricsCollector.RecordOrder("trading-api", req.Type, req.Side, "failed", processingTime)
-		}
-
-		// Handle different error types
-		var apiError *APIError
-		switch e := err.(type) {
-		case *shared.ValidationError:
-			apiError = &APIError{
-				Code:    "VALIDATION_ERROR",
-				Message: "Validation failed",
-				Details: e.Error(),
-			}
-		case *shared.BusinessError:
-			apiError = &APIError{
-				Code:    e.Code,
-				Message: e.Message,
-				Details: e.Details,
-			}
-		default:
-			apiError = &APIError{
-				Code:    "ORDER_PLACEMENT_FAILED",
-				Message: "Failed to place order",
-				Details: err.Error(),
-			}
+			h.metricsCollector.RecordOrder("trading-api", req.Type, req.Side, outcome, processingTime)
 		}
 
-		c.JSON(http.StatusInternalServerError, APIResponse{
+		status, apiError := placeOrderError(err)
+		c.JSON(status, APIResponse{
 			Success: false,
 			Error:   apiError,
 		})
@@ -184,9 +253,10 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	c.JSON(http.StatusCreated, APIResponse{
 		Success: true,
 		Data: PlaceOrderResponse{
-			OrderID: placedOrder.ID,
-			Status:  string(placedOrder.Status),
-			Message: "Order placed successfully",
+			OrderID:       placedOrder.ID,
+			ClientOrderID: placedOrder.ClientOrderID,
+			Status:        string(placedOrder.Status),
+			Message:       "Order placed successfully",
 		},
 	})
 
@@ -197,6 +267,135 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	)
 }
 
+// PlaceOrderBatch handles POST /api/orders/batch. Each order is validated and
+// placed independently; the response reports a result per order in request order.
+func (h *OrderHandler) PlaceOrderBatch(c *gin.Context) {
+	startTime := time.Now()
+	var req PlaceOrderBatchRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid place order batch request", "error", err)
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	results := make([]BatchOrderResult, len(req.Orders))
+	orders := make([]*shared.Order, 0, len(req.Orders))
+	indexes := make([]int, 0, len(req.Orders))
+
+	for i := range req.Orders {
+		order, apiError := h.batchOrderFromRequest(c, &req.Orders[i])
+		if apiError != nil {
+			results[i] = BatchOrderResult{Index: i, ClientOrderID: req.Orders[i].ClientOrderID, Error: apiError}
+			continue
+		}
+		orders = append(orders, order)
+		indexes = append(indexes, i)
+	}
+
+	placed := h.tradingService.PlaceOrders(c.Request.Context(), orders)
+	processingTime := time.Since(startTime)
+
+	for j, result := range placed {
+		i := indexes[j]
+		order := orders[j]
+		results[i] = BatchOrderResult{Index: i, ClientOrderID: order.ClientOrderID}
+
+		if duplicate, ok := result.Err.(*shared.DuplicateOrderError); ok {
+			results[i].Success = true
+			results[i].Replayed = true
+			results[i].OrderID = duplicate.Existing.ID
+			results[i].Status = string(duplicate.Existing.Status)
+			continue
+		}
+
+		if result.Err != nil {
+			_, results[i].Error = placeOrderError(result.Err)
+			if rejected, ok := result.Err.(*shared.OrderRejectedError); ok {
+				results[i].OrderID = rejected.Order.ID
+				results[i].Status = string(shared.OrderStatusRejected)
+			}
+			if h.metricsCollector != nil {
+				h.metricsCollector.RecordOrder("trading-api", string(order.Type), string(order.Side), "failed", processingTime)
+			}
+			continue
+		}
+
+		results[i].Success = true
+		results[i].OrderID = result.Order.ID
+		results[i].Status = string(result.Order.Status)
+		if h.metricsCollector != nil {
+			h.metricsCollector.RecordOrder("trading-api", string(order.Type), string(order.Side), string(result.Order.Status), processingTime)
+			if result.Order.Status == shared.OrderStatusFilled {
+				h.metricsCollector.RecordTrade("trading-api", order.Symbol)
+			}
+		}
+	}
+
+	response := PlaceOrderBatchResponse{Results: results}
+	for _, result := range results {
+		if result.Success {
+			response.Accepted++
+		} else {
+			response.Rejected++
+		}
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    response,
+	})
+
+	h.logger.Info("Order batch placed",
+		"orders", len(req.Orders),
+		"accepted", response.Accepted,
+		"rejected", response.Rejected,
+		"duration", processingTime,
+	)
+}
+
+// batchOrderFromRequest validates a single order of a batch and converts it to
+// the domain model. Errors are returned rather than written so that the rest
+// of the batch can proceed.
+func (h *OrderHandler) batchOrderFromRequest(c *gin.Context, req *PlaceOrderRequest) (*shared.Order, *APIError) {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return nil, &APIError{
+			Code:    "INVALID_REQUEST",
+			Message: "Invalid order format",
+			Details: err.Error(),
+		}
+	}
+
+	if req.Type == "LIMIT" && req.Price <= 0 {
+		return nil, &APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Price is required for limit orders",
+		}
+	}
+
+	userID, _, apiError := resolveUser(c, req.UserID)
+	if apiError != nil {
+		return nil, apiError
+	}
+
+	return &shared.Order{
+		UserID:        userID,
+		ClientOrderID: req.ClientOrderID,
+		Symbol:        req.Symbol,
+		Side:          shared.OrderSide(req.Side),
+		Type:          shared.OrderType(req.Type),
+		Quantity:      req.Quantity,
+		Price:         req.Price,
+	}, nil
+}
+
 // GetOrder handles GET /api/orders/:id
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	orderID := c.Param("id")
@@ -233,23 +432,100 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	// Convert to response model
-	orderResponse := OrderResponse{
-		ID:        order.ID,
-		UserID:    order.UserID,
-		Symbol:    order.Symbol,
-		Side:      string(order.Side),
-		Type:      string(order.Type),
-		Quantity:  order.Quantity,
-		Price:     order.Price,
-		Status:    string(order.Status),
-		CreatedAt: order.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt: order.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	if !authorizeOrderAccess(c, order) {
+		return
 	}
 
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
-		Data:    orderResponse,
+		Data:    toOrderResponse(order),
+	})
+}
+
+// GetOrderHistory handles GET /api/orders/:id/history
+func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
+	orderID := c.Param("id")
+
+	order, err := h.tradingService.GetOrder(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Warn("Failed to get order", "error", err, "order_id", orderID)
+
+		status := http.StatusInternalServerError
+		if err == shared.ErrOrderNotFound {
+			status = http.StatusNotFound
+		}
+
+		c.JSON(status, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "ORDER_NOT_FOUND",
+				Message: "Order not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if !authorizeOrderAccess(c, order) {
+		return
+	}
+
+	events, err := h.tradingService.GetOrderHistory(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to get order history", "error", err, "order_id", orderID)
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "ORDER_HISTORY_FETCH_FAILED",
+				Message: "Failed to get order history",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: gin.H{
+			"order":  toOrderResponse(order),
+			"events": events,
+		},
+	})
+}
+
+// GetOrderByClientID handles GET /api/orders/client/:client_order_id
+func (h *OrderHandler) GetOrderByClientID(c *gin.Context) {
+	clientOrderID := c.Param("client_order_id")
+
+	userID, ok := authorizeUser(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+
+	order, err := h.tradingService.GetOrderByClientID(c.Request.Context(), userID, clientOrderID)
+	if err != nil {
+		h.logger.Warn("Failed to get order by client order ID", "error", err,
+			"user_id", userID, "client_order_id", clientOrderID)
+
+		status := http.StatusInternalServerError
+		if err == shared.ErrOrderNotFound {
+			status = http.StatusNotFound
+		}
+
+		c.JSON(status, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "ORDER_NOT_FOUND",
+				Message: "Order not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    toOrderResponse(order),
 	})
 }
 
@@ -278,6 +554,17 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 		return
 	}
 
+	// Non-admin callers only see their own orders
+	if identity := middleware.GetAuthIdentity(c); identity != nil && !identity.IsAdmin() {
+		ownOrders := make([]*shared.Order, 0, len(orders))
+		for _, order := range orders {
+			if order.UserID == identity.UserID {
+				ownOrders = append(ownOrders, order)
+			}
+		}
+		orders = ownOrders
+	}
+
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
 		Data:    orders,
@@ -299,17 +586,37 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 		return
 	}
 
+	// Only the order owner (or an admin) may cancel an order
+	if middleware.GetAuthIdentity(c) != nil {
+		order, err := h.tradingService.GetOrder(c.Request.Context(), orderID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == shared.ErrOrderNotFound {
+				status = http.StatusNotFound
+			}
+
+			c.JSON(status, APIResponse{
+				Success: false,
+				Error: &APIError{
+					Code:    "ORDER_CANCELLATION_FAILED",
+					Message: "Failed to cancel order",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+
+		if !authorizeOrderAccess(c, order) {
+			return
+		}
+	}
+
 	// Call service layer
 	err := h.tradingService.CancelOrder(c.Request.Context(), orderID)
 	if err != nil {
 		h.logger.Warn("Failed to cancel order", "error", err, "order_id", orderID)
 
-		status := http.StatusInternalServerError
-		if err == shared.ErrOrderNotFound {
-			status = http.StatusNotFound
-		}
-
-		c.JSON(status, APIResponse{
+		c.JSON(cancelOrderStatus(err), APIResponse{
 			Success: false,
 			Error: &APIError{
 				Code:    "ORDER_CANCELLATION_FAILED",
@@ -332,6 +639,161 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	h.logger.Info("Order cancelled successfully", "order_id", orderID)
 }
 
+// AmendOrder handles PATCH /api/orders/:id and reduces an open limit
+// order's quantity
+func (h *OrderHandler) AmendOrder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var req AmendOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	// Only the order owner (or an admin) may amend an order
+	if middleware.GetAuthIdentity(c) != nil {
+		order, err := h.tradingService.GetOrder(c.Request.Context(), orderID)
+		if err != nil {
+			status, apiError := amendOrderError(err)
+			c.JSON(status, APIResponse{
+				Success: false,
+				Error:   apiError,
+			})
+			return
+		}
+
+		if !authorizeOrderAccess(c, order) {
+			return
+		}
+	}
+
+	order, err := h.tradingService.AmendOrder(c.Request.Context(), orderID, req.Quantity)
+	if err != nil {
+		h.logger.Warn("Failed to amend order", "error", err, "order_id", orderID)
+
+		status, apiError := amendOrderError(err)
+		c.JSON(status, APIResponse{
+			Success: false,
+			Error:   apiError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    toOrderResponse(order),
+	})
+
+	h.logger.Info("Order amended successfully", "order_id", orderID, "quantity", req.Quantity)
+}
+
+// CancelOrders handles DELETE /api/orders?user_id=...&symbol=...&side=...
+// and cancels every open order matching the filter. Non-admin callers can
+// only cancel their own orders.
+func (h *OrderHandler) CancelOrders(c *gin.Context) {
+	filter := shared.OrderFilter{
+		UserID: c.Query("user_id"),
+		Symbol: c.Query("symbol"),
+		Side:   shared.OrderSide(strings.ToUpper(c.Query("side"))),
+	}
+
+	if identity := middleware.GetAuthIdentity(c); identity != nil && !identity.IsAdmin() {
+		userID, ok := authorizeUser(c, filter.UserID)
+		if !ok {
+			return
+		}
+		filter.UserID = userID
+	}
+
+	orders, err := h.tradingService.CancelOrders(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Warn("Failed to cancel orders", "error", err,
+			"user_id", filter.UserID, "symbol", filter.Symbol, "side", filter.Side)
+
+		status := http.StatusInternalServerError
+		apiError := &APIError{
+			Code:    "ORDER_CANCELLATION_FAILED",
+			Message: "Failed to cancel orders",
+			Details: err.Error(),
+		}
+		if _, ok := err.(*shared.ValidationError); ok {
+			status = http.StatusBadRequest
+			apiError.Code = "VALIDATION_ERROR"
+		}
+
+		c.JSON(status, APIResponse{
+			Success: false,
+			Error:   apiError,
+		})
+		return
+	}
+
+	response := CancelOrdersResponse{
+		Cancelled: len(orders),
+		Orders:    make([]OrderResponse, len(orders)),
+	}
+	for i, order := range orders {
+		response.Orders[i] = toOrderResponse(order)
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    response,
+	})
+
+	h.logger.Info("Orders cancelled",
+		"cancelled", len(orders),
+		"user_id", filter.UserID,
+		"symbol", filter.Symbol,
+		"side", filter.Side,
+	)
+}
+
+// CancelOrderByClientID handles DELETE /api/orders/client/:client_order_id
+func (h *OrderHandler) CancelOrderByClientID(c *gin.Context) {
+	clientOrderID := c.Param("client_order_id")
+
+	userID, ok := authorizeUser(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+
+	order, err := h.tradingService.CancelOrderByClientID(c.Request.Context(), userID, clientOrderID)
+	if err != nil {
+		h.logger.Warn("Failed to cancel order by client order ID", "error", err,
+			"user_id", userID, "client_order_id", clientOrderID)
+
+		c.JSON(cancelOrderStatus(err), APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "ORDER_CANCELLATION_FAILED",
+				Message: "Failed to cancel order",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"order_id":        order.ID,
+			"client_order_id": order.ClientOrderID,
+			"status":          string(order.Status),
+			"message":         "Order cancelled successfully",
+		},
+	})
+
+	h.logger.Info("Order cancelled successfully", "order_id", order.ID, "client_order_id", clientOrderID)
+}
+
 // GetOrderBook handles GET /api/orderbook/:symbol
 func (h *OrderHandler) GetOrderBook(c *gin.Context) {
 	symbol := c.Param("symbol")
@@ -378,16 +840,8 @@ func (h *OrderHandler) GetOrderBook(c *gin.Context) {
 
 // GetUserOrders handles GET /api/users/:user_id/orders
 func (h *OrderHandler) GetUserOrders(c *gin.Context) {
-	userID := c.Param("user_id")
-
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error: &APIError{
-				Code:    "MISSING_USER_ID",
-				Message: "User ID is required",
-			},
-		})
+	userID, ok := authorizeUser(c, c.Param("user_id"))
+	if !ok {
 		return
 	}
 
@@ -409,18 +863,7 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 	// Convert to response model
 	var orderResponses []OrderResponse
 	for _, order := range orders {
-		orderResponses = append(orderResponses, OrderResponse{
-			ID:        order.ID,
-			UserID:    order.UserID,
-			Symbol:    order.Symbol,
-			Side:      string(order.Side),
-			Type:      string(order.Type),
-			Quantity:  order.Quantity,
-			Price:     order.Price,
-			Status:    string(order.Status),
-			CreatedAt: order.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt: order.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		})
+		orderResponses = append(orderResponses, toOrderResponse(order))
 	}
 
 	c.JSON(http.StatusOK, APIResponse{
@@ -452,4 +895,164 @@ func (h *OrderHandler) aggregateOrderBook(orders []shared.Order) []OrderBookEntr
 	}
 
 	return entries
+}
+
+// toOrderResponse converts a domain order to its API representation
+func toOrderResponse(order *shared.Order) OrderResponse {
+	return OrderResponse{
+		ID:            order.ID,
+		ClientOrderID: order.ClientOrderID,
+		UserID:        order.UserID,
+		Symbol:        order.Symbol,
+		Side:          string(order.Side),
+		Type:          string(order.Type),
+		Quantity:      order.Quantity,
+		Price:         order.Price,
+		Status:        string(order.Status),
+		RejectReason:  order.RejectReason,
+		CreatedAt:     order.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:     order.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// authorizeUser resolves the user an operation acts on behalf of. When the
+// request is authenticated the caller's identity is used by default and only
+// admins may act for other users. It writes the error response and returns
+// false if the request must not proceed.
+func authorizeUser(c *gin.Context, requestedUserID string) (string, bool) {
+	userID, status, apiError := resolveUser(c, requestedUserID)
+	if apiError != nil {
+		c.JSON(status, APIResponse{
+			Success: false,
+			Error:   apiError,
+		})
+		return "", false
+	}
+
+	return userID, true
+}
+
+// resolveUser implements authorizeUser without writing a response
+func resolveUser(c *gin.Context, requestedUserID string) (string, int, *APIError) {
+	identity := middleware.GetAuthIdentity(c)
+	if identity == nil {
+		if requestedUserID == "" {
+			return "", http.StatusBadRequest, &APIError{
+				Code:    "MISSING_USER_ID",
+				Message: "User ID is required",
+			}
+		}
+		return requestedUserID, http.StatusOK, nil
+	}
+
+	if requestedUserID == "" {
+		return identity.UserID, http.StatusOK, nil
+	}
+
+	if !identity.CanAccessUser(requestedUserID) {
+		return "", http.StatusForbidden, &APIError{
+			Code:    shared.ErrCodeForbidden,
+			Message: "Not permitted to act on behalf of another user",
+		}
+	}
+
+	return requestedUserID, http.StatusOK, nil
+}
+
+// cancelOrderStatus maps an order cancellation error to an HTTP status.
+// Orders whose status doesn't allow cancelling are a conflict.
+func cancelOrderStatus(err error) int {
+	if err == shared.ErrOrderNotFound {
+		return http.StatusNotFound
+	}
+	if _, ok := err.(*shared.BusinessError); ok {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// amendOrderError maps an order amendment error to an HTTP status and API
+// error. Orders whose status or fills don't allow the amendment are a
+// conflict.
+func amendOrderError(err error) (int, *APIError) {
+	switch e := err.(type) {
+	case *shared.ValidationError:
+		return http.StatusBadRequest, &APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Validation failed",
+			Details: e.Error(),
+		}
+	case *shared.BusinessError:
+		return http.StatusConflict, &APIError{
+			Code:    e.Code,
+			Message: e.Message,
+			Details: e.Details,
+		}
+	}
+
+	status := http.StatusInternalServerError
+	if err == shared.ErrOrderNotFound {
+		status = http.StatusNotFound
+	}
+	return status, &APIError{
+		Code:    "ORDER_AMENDMENT_FAILED",
+		Message: "Failed to amend order",
+		Details: err.Error(),
+	}
+}
+
+// placeOrderError maps an order placement error to an HTTP status and API error
+func placeOrderError(err error) (int, *APIError) {
+	var apiError *APIError
+	switch e := err.(type) {
+	case *shared.OrderRejectedError:
+		return http.StatusUnprocessableEntity, &APIError{
+			Code:    e.Code,
+			Message: e.Reason,
+			Details: "order " + e.Order.ID + " rejected",
+		}
+	case *shared.ValidationError:
+		apiError = &APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Validation failed",
+			Details: e.Error(),
+		}
+	case *shared.BusinessError:
+		apiError = &APIError{
+			Code:    e.Code,
+			Message: e.Message,
+			Details: e.Details,
+		}
+	default:
+		apiError = &APIError{
+			Code:    "ORDER_PLACEMENT_FAILED",
+			Message: "Failed to place order",
+			Details: err.Error(),
+		}
+	}
+
+	status := http.StatusInternalServerError
+	if apiError.Code == shared.ErrCodeIdempotencyConflict {
+		status = http.StatusConflict
+	}
+
+	return status, apiError
+}
+
+// authorizeOrderAccess ensures an authenticated caller owns the order.
+// It writes the error response and returns false if access is denied.
+func authorizeOrderAccess(c *gin.Context, order *shared.Order) bool {
+	identity := middleware.GetAuthIdentity(c)
+	if identity == nil || identity.CanAccessUser(order.UserID) {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    shared.ErrCodeForbidden,
+			Message: "Order belongs to another user",
+		},
+	})
+	return false
 }
\ No newline at end of file
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/shared"
+	"simulated_exchange/services/trading-api/internal/middleware"
+)
+
+// stubTradingService answers batch submissions and mass cancels from fixed
+// outcomes; other methods are not implemented
+type stubTradingService struct {
+	shared.TradingService
+
+	// placeErrors fails the orders with these client order IDs
+	placeErrors map[string]error
+	placed      []*shared.Order
+
+	// open holds the orders mass cancels select from
+	open         []*shared.Order
+	cancelFilter shared.OrderFilter
+}
+
+func (s *stubTradingService) PlaceOrders(ctx context.Context, orders []*shared.Order) []*shared.BatchOrderResult {
+	results := make([]*shared.BatchOrderResult, len(orders))
+	for i, order := range orders {
+		if err := s.placeErrors[order.ClientOrderID]; err != nil {
+			results[i] = &shared.BatchOrderResult{Index: i, Err: err}
+			continue
+		}
+		order.ID = "order-" + order.ClientOrderID
+		order.Status = shared.OrderStatusPending
+		s.placed = append(s.placed, order)
+		results[i] = &shared.BatchOrderResult{Index: i, Order: order}
+	}
+	return results
+}
+
+func (s *stubTradingService) CancelOrders(ctx context.Context, filter shared.OrderFilter) ([]*shared.Order, error) {
+	s.cancelFilter = filter
+	if filter.IsEmpty() {
+		return nil, shared.NewValidationError("filter", "at least one of user_id, symbol or side is required")
+	}
+
+	var cancelled []*shared.Order
+	for _, order := range s.open {
+		if (filter.UserID == "" || order.UserID == filter.UserID) &&
+			(filter.Symbol == "" || order.Symbol == filter.Symbol) &&
+			(filter.Side == "" || order.Side == filter.Side) {
+			order.Status = shared.OrderStatusCancelled
+			cancelled = append(cancelled, order)
+		}
+	}
+	return cancelled, nil
+}
+
+// fixedAuthenticator authenticates every API key as one identity
+type fixedAuthenticator struct {
+	identity *shared.AuthIdentity
+}
+
+func (a fixedAuthenticator) Authenticate(ctx context.Context, apiKey string) (*shared.AuthIdentity, error) {
+	return a.identity, nil
+}
+
+func (a fixedAuthenticator) AuthenticateSigned(ctx context.Context, keyID, timestamp, nonce, signature, method, path string, body []byte) (*shared.AuthIdentity, error) {
+	return a.identity, nil
+}
+
+// newOrderRouter routes order requests to a handler, authenticated as
+// identity unless it is nil
+func newOrderRouter(service shared.TradingService, identity *shared.AuthIdentity) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if identity != nil {
+		router.Use(func(c *gin.Context) {
+			c.Request.Header.Set(middleware.HeaderAPIKey, "ak_test.secret")
+		}, middleware.AuthMiddleware(fixedAuthenticator{identity: identity}, logger))
+	}
+
+	handler := NewOrderHandler(service, nil, logger)
+	router.POST("/api/orders/batch", handler.PlaceOrderBatch)
+	router.DELETE("/api/orders", handler.CancelOrders)
+	return router
+}
+
+func serve(router *gin.Engine, method, target string, body interface{}) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	request := httptest.NewRequest(method, target, reader)
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// decodeData decodes the data of a successful API response into v
+func decodeData(t *testing.T, recorder *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	var response struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.True(t, response.Success, recorder.Body.String())
+	require.NoError(t, json.Unmarshal(response.Data, v))
+}
+
+func TestPlaceOrderBatch_PartialFailure(t *testing.T) {
+	rejectedOrder := &shared.Order{ID: "order-risk"}
+	service := &stubTradingService{placeErrors: map[string]error{
+		"risk":     &shared.OrderRejectedError{Order: rejectedOrder, Code: "MAX_ORDER_QUANTITY", Reason: "quantity exceeds limit"},
+		"conflict": shared.NewBusinessErrorWithDetails(shared.ErrCodeIdempotencyConflict, "client order ID was already used for a different order", "order-1"),
+		"replay":   &shared.DuplicateOrderError{Existing: &shared.Order{ID: "order-original", Status: shared.OrderStatusFilled}},
+	}}
+	router := newOrderRouter(service, nil)
+
+	order := func(clientOrderID string) map[string]interface{} {
+		return map[string]interface{}{
+			"user_id": "user-1", "client_order_id": clientOrderID,
+			"symbol": "AAPL", "side": "BUY", "type": "LIMIT", "quantity": 10, "price": 100,
+		}
+	}
+	invalid := order("invalid")
+	invalid["side"] = "HOLD"
+	unpriced := order("unpriced")
+	delete(unpriced, "price")
+
+	recorder := serve(router, http.MethodPost, "/api/orders/batch", map[string]interface{}{
+		"orders": []interface{}{order("ok-1"), invalid, order("risk"), unpriced, order("conflict"), order("replay"), order("ok-2")},
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response PlaceOrderBatchResponse
+	decodeData(t, recorder, &response)
+	assert.Equal(t, 3, response.Accepted)
+	assert.Equal(t, 4, response.Rejected)
+	require.Len(t, response.Results, 7)
+
+	// Results are reported per order in request order
+	for i, result := range response.Results {
+		assert.Equal(t, i, result.Index)
+	}
+
+	assert.True(t, response.Results[0].Success)
+	assert.Equal(t, "order-ok-1", response.Results[0].OrderID)
+	assert.Equal(t, "PENDING", response.Results[0].Status)
+
+	assert.False(t, response.Results[1].Success)
+	assert.Equal(t, "INVALID_REQUEST", response.Results[1].Error.Code)
+
+	assert.False(t, response.Results[2].Success)
+	assert.Equal(t, "MAX_ORDER_QUANTITY", response.Results[2].Error.Code)
+	assert.Equal(t, "order-risk", response.Results[2].OrderID)
+	assert.Equal(t, "REJECTED", response.Results[2].Status)
+
+	assert.Equal(t, "VALIDATION_ERROR", response.Results[3].Error.Code)
+	assert.Equal(t, shared.ErrCodeIdempotencyConflict, response.Results[4].Error.Code)
+
+	assert.True(t, response.Results[5].Success)
+	assert.True(t, response.Results[5].Replayed)
+	assert.Equal(t, "order-original", response.Results[5].OrderID)
+	assert.Equal(t, "FILLED", response.Results[5].Status)
+
+	assert.True(t, response.Results[6].Success)
+	assert.Equal(t, "ok-2", response.Results[6].ClientOrderID)
+
+	// Orders failing validation never reach the service
+	assert.Len(t, service.placed, 2)
+}
+
+func TestPlaceOrderBatch_InvalidBatch(t *testing.T) {
+	router := newOrderRouter(&stubTradingService{}, nil)
+
+	recorder := serve(router, http.MethodPost, "/api/orders/batch", map[string]interface{}{"orders": []interface{}{}})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestCancelOrders_Filters(t *testing.T) {
+	openOrders := func() []*shared.Order {
+		return []*shared.Order{
+			{ID: "1", UserID: "user-1", Symbol: "AAPL", Side: shared.OrderSideBuy},
+			{ID: "2", UserID: "user-1", Symbol: "AAPL", Side: shared.OrderSideSell},
+			{ID: "3", UserID: "user-1", Symbol: "MSFT", Side: shared.OrderSideBuy},
+			{ID: "4", UserID: "user-2", Symbol: "AAPL", Side: shared.OrderSideBuy},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		identity *shared.AuthIdentity
+		filter   shared.OrderFilter
+		want     []string
+	}{
+		{
+			name:   "symbol",
+			query:  "?symbol=AAPL",
+			filter: shared.OrderFilter{Symbol: "AAPL"},
+			want:   []string{"1", "2", "4"},
+		},
+		{
+			name:   "side is case insensitive",
+			query:  "?side=buy",
+			filter: shared.OrderFilter{Side: shared.OrderSideBuy},
+			want:   []string{"1", "3", "4"},
+		},
+		{
+			name:   "symbol and side",
+			query:  "?symbol=AAPL&side=SELL",
+			filter: shared.OrderFilter{Symbol: "AAPL", Side: shared.OrderSideSell},
+			want:   []string{"2"},
+		},
+		{
+			name:     "authenticated users only cancel their own orders",
+			query:    "?symbol=AAPL&side=BUY",
+			identity: &shared.AuthIdentity{UserID: "user-2", Scopes: []shared.APIKeyScope{shared.APIKeyScopeTrade}},
+			filter:   shared.OrderFilter{UserID: "user-2", Symbol: "AAPL", Side: shared.OrderSideBuy},
+			want:     []string{"4"},
+		},
+		{
+			name:     "admins cancel across users",
+			query:    "?symbol=AAPL&side=BUY",
+			identity: &shared.AuthIdentity{UserID: "admin", Scopes: []shared.APIKeyScope{shared.APIKeyScopeAdmin}},
+			filter:   shared.OrderFilter{Symbol: "AAPL", Side: shared.OrderSideBuy},
+			want:     []string{"1", "4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubTradingService{open: openOrders()}
+			recorder := serve(newOrderRouter(service, tt.identity), http.MethodDelete, "/api/orders"+tt.query, nil)
+			require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+			assert.Equal(t, tt.filter, service.cancelFilter)
+
+			var response CancelOrdersResponse
+			decodeData(t, recorder, &response)
+			assert.Equal(t, len(tt.want), response.Cancelled)
+			var ids []string
+			for _, order := range response.Orders {
+				ids = append(ids, order.ID)
+				assert.Equal(t, "CANCELLED", order.Status)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestCancelOrders_Rejected(t *testing.T) {
+	// An empty filter would cancel every order
+	recorder := serve(newOrderRouter(&stubTradingService{}, nil), http.MethodDelete, "/api/orders", nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Users can't cancel other users' orders
+	identity := &shared.AuthIdentity{UserID: "user-1", Scopes: []shared.APIKeyScope{shared.APIKeyScopeTrade}}
+	recorder = serve(newOrderRouter(&stubTradingService{}, identity), http.MethodDelete, "/api/orders?user_id=user-2", nil)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
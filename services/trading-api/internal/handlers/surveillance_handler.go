@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/shared"
+)
+
+// defaultAlertLimit is the number of alerts returned when no limit is given
+const defaultAlertLimit = 100
+
+// SurveillanceService defines the surveillance operations used by the handler
+type SurveillanceService interface {
+	GetAlert(ctx context.Context, id string) (*shared.SurveillanceAlert, error)
+	GetAlerts(ctx context.Context, filter shared.AlertFilter) ([]*shared.SurveillanceAlert, error)
+	UpdateAlertStatus(ctx context.Context, id string, status shared.AlertStatus) (*shared.SurveillanceAlert, error)
+	SetBeneficialOwner(ctx context.Context, userID, ownerID string) error
+}
+
+// SurveillanceHandler handles market surveillance requests
+type SurveillanceHandler struct {
+	surveillanceService SurveillanceService
+	logger              *slog.Logger
+}
+
+// NewSurveillanceHandler creates a new surveillance handler
+func NewSurveillanceHandler(surveillanceService SurveillanceService, logger *slog.Logger) *SurveillanceHandler {
+	return &SurveillanceHandler{
+		surveillanceService: surveillanceService,
+		logger:              logger,
+	}
+}
+
+// UpdateAlertStatusRequest represents a request to record the review of an alert
+type UpdateAlertStatusRequest struct {
+	Status shared.AlertStatus `json:"status" binding:"required"`
+}
+
+// SetBeneficialOwnerRequest represents a request to set an account's beneficial owner
+type SetBeneficialOwnerRequest struct {
+	OwnerID string `json:"owner_id" binding:"required"`
+}
+
+// GetAlerts handles GET /api/surveillance/alerts?type=&status=&user_id=&symbol=&from=&to=&limit=
+func (h *SurveillanceHandler) GetAlerts(c *gin.Context) {
+	start, ok := parseTimeQuery(c, "from")
+	if !ok {
+		return
+	}
+	end, ok := parseTimeQuery(c, "to")
+	if !ok {
+		return
+	}
+	limit, ok := parseLimit(c, defaultAlertLimit)
+	if !ok {
+		return
+	}
+
+	alerts, err := h.surveillanceService.GetAlerts(c.Request.Context(), shared.AlertFilter{
+		Type:   shared.AlertType(c.Query("type")),
+		Status: shared.AlertStatus(c.Query("status")),
+		UserID: c.Query("user_id"),
+		Symbol: c.Query("symbol"),
+		Start:  start,
+		End:    end,
+		Limit:  limit,
+	})
+	if err != nil {
+		writeError(c, h.logger, err, "ALERTS_FETCH_FAILED", "Failed to get surveillance alerts")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    alerts,
+	})
+}
+
+// GetAlert handles GET /api/surveillance/alerts/:id
+func (h *SurveillanceHandler) GetAlert(c *gin.Context) {
+	alert, err := h.surveillanceService.GetAlert(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, h.logger, err, "ALERT_FETCH_FAILED", "Failed to get surveillance alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    alert,
+	})
+}
+
+// UpdateAlertStatus handles PUT /api/surveillance/alerts/:id/status
+func (h *SurveillanceHandler) UpdateAlertStatus(c *gin.Context) {
+	var req UpdateAlertStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	alert, err := h.surveillanceService.UpdateAlertStatus(c.Request.Context(), c.Param("id"), req.Status)
+	if err != nil {
+		writeError(c, h.logger, err, "ALERT_UPDATE_FAILED", "Failed to update surveillance alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data:    alert,
+	})
+}
+
+// SetBeneficialOwner handles PUT /api/surveillance/owners/:user_id
+func (h *SurveillanceHandler) SetBeneficialOwner(c *gin.Context) {
+	var req SetBeneficialOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Success: false,
+			Error: &APIError{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	userID := c.Param("user_id")
+	if err := h.surveillanceService.SetBeneficialOwner(c.Request.Context(), userID, req.OwnerID); err != nil {
+		writeError(c, h.logger, err, "BENEFICIAL_OWNER_UPDATE_FAILED", "Failed to set beneficial owner")
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Data: gin.H{
+			"user_id":  userID,
+			"owner_id": req.OwnerID,
+		},
+	})
+}
@@ -1,14 +1,35 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"simulated_exchange/pkg/shared"
 )
 
+const (
+	// authIdentityKey is the gin context key holding the authenticated identity
+	authIdentityKey = "auth_identity"
+
+	// Authentication headers
+	HeaderAPIKey       = "X-API-Key"
+	HeaderAPITimestamp = "X-API-Timestamp"
+	HeaderAPINonce     = "X-API-Nonce"
+	HeaderAPISignature = "X-API-Signature"
+)
+
+// Authenticator validates API credentials
+type Authenticator interface {
+	Authenticate(ctx context.Context, apiKey string) (*shared.AuthIdentity, error)
+	AuthenticateSigned(ctx context.Context, keyID, timestamp, nonce, signature, method, path string, body []byte) (*shared.AuthIdentity, error)
+}
+
 // LoggingMiddleware logs HTTP requests
 func LoggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -64,8 +85,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID, Idempotency-Key, X-API-Key, X-API-Timestamp, X-API-Nonce, X-API-Signature")
+		c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID, Idempotent-Replayed, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -91,15 +112,95 @@ func RequestIDMiddleware() gin.HandlerFunc {
 	}
 }
 
-// RateLimitMiddleware provides basic rate limiting
-func RateLimitMiddleware() gin.HandlerFunc {
-	// Simple in-memory rate limiter - in production use Redis
+// AuthMiddleware authenticates requests using an API key or an HMAC signature
+func AuthMiddleware(authenticator Authenticator, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader(HeaderAPIKey)
+		if apiKey == "" {
+			abortWithError(c, http.StatusUnauthorized, shared.ErrCodeUnauthorized, "API key is required")
+			return
+		}
+
+		var identity *shared.AuthIdentity
+		var err error
+
+		if signature := c.GetHeader(HeaderAPISignature); signature != "" {
+			var body []byte
+			if c.Request.Body != nil {
+				body, err = io.ReadAll(c.Request.Body)
+				if err != nil {
+					abortWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+					return
+				}
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			identity, err = authenticator.AuthenticateSigned(c.Request.Context(), apiKey,
+				c.GetHeader(HeaderAPITimestamp), c.GetHeader(HeaderAPINonce), signature,
+				c.Request.Method, c.Request.URL.RequestURI(), body)
+		} else {
+			identity, err = authenticator.Authenticate(c.Request.Context(), apiKey)
+		}
+
+		if err != nil {
+			logger.Warn("Authentication failed",
+				"error", err,
+				"path", c.Request.URL.Path,
+				"client_ip", c.ClientIP(),
+				"request_id", c.GetString("request_id"),
+			)
+
+			code := shared.ErrCodeInvalidAPIKey
+			if err == shared.ErrInvalidSignature || err == shared.ErrSignatureExpired || err == shared.ErrSignatureReplay {
+				code = shared.ErrCodeInvalidSignature
+			}
+			abortWithError(c, http.StatusUnauthorized, code, err.Error())
+			return
+		}
+
+		c.Set(authIdentityKey, identity)
+		c.Next()
+	}
+}
+
+// RequireScope rejects authenticated requests that lack the given scope.
+// It is a no-op when authentication is not enabled.
+func RequireScope(scope shared.APIKeyScope) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// For now, just continue - implement proper rate limiting in production
+		identity := GetAuthIdentity(c)
+		if identity != nil && !identity.HasScope(scope) {
+			abortWithError(c, http.StatusForbidden, shared.ErrCodeInsufficientScope,
+				"API key lacks the required scope: "+string(scope))
+			return
+		}
+
 		c.Next()
 	}
 }
 
+// GetAuthIdentity returns the authenticated identity, or nil when the
+// request was not authenticated
+func GetAuthIdentity(c *gin.Context) *shared.AuthIdentity {
+	value, exists := c.Get(authIdentityKey)
+	if !exists {
+		return nil
+	}
+
+	identity, _ := value.(*shared.AuthIdentity)
+	return identity
+}
+
+// abortWithError aborts the request with a standard error response
+func abortWithError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
 // ValidationMiddleware provides request validation
 func ValidationMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
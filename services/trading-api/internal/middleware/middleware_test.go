@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/shared"
+)
+
+// stubAuthenticator returns a fixed identity or error and records the
+// signed request it was asked to verify
+type stubAuthenticator struct {
+	identity *shared.AuthIdentity
+	err      error
+
+	nonce string
+	path  string
+	body  string
+}
+
+func (a *stubAuthenticator) Authenticate(ctx context.Context, apiKey string) (*shared.AuthIdentity, error) {
+	return a.identity, a.err
+}
+
+func (a *stubAuthenticator) AuthenticateSigned(ctx context.Context, keyID, timestamp, nonce, signature, method, path string, body []byte) (*shared.AuthIdentity, error) {
+	a.nonce, a.path, a.body = nonce, path, string(body)
+	return a.identity, a.err
+}
+
+func newAuthRouter(authenticator Authenticator, scope shared.APIKeyScope) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router.POST("/orders", AuthMiddleware(authenticator, logger), RequireScope(scope), func(c *gin.Context) {
+		// The body is still readable after the signature check
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	return router
+}
+
+func errorCode(t *testing.T, recorder *httptest.ResponseRecorder) string {
+	t.Helper()
+	var response struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response.Error.Code
+}
+
+func TestAuthMiddleware_Scopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []shared.APIKeyScope
+		status int
+	}{
+		{"matching scope", []shared.APIKeyScope{shared.APIKeyScopeTrade}, http.StatusOK},
+		{"admin has every scope", []shared.APIKeyScope{shared.APIKeyScopeAdmin}, http.StatusOK},
+		{"missing scope", []shared.APIKeyScope{shared.APIKeyScopeRead}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newAuthRouter(&stubAuthenticator{
+				identity: &shared.AuthIdentity{UserID: "user-1", Scopes: tt.scopes},
+			}, shared.APIKeyScopeTrade)
+
+			request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("{}"))
+			request.Header.Set(HeaderAPIKey, "ak_1.secret")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.status == http.StatusForbidden {
+				assert.Equal(t, shared.ErrCodeInsufficientScope, errorCode(t, recorder))
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_Signed(t *testing.T) {
+	authenticator := &stubAuthenticator{identity: &shared.AuthIdentity{
+		UserID: "user-1",
+		Scopes: []shared.APIKeyScope{shared.APIKeyScopeTrade},
+	}}
+	router := newAuthRouter(authenticator, shared.APIKeyScopeTrade)
+
+	request := httptest.NewRequest(http.MethodPost, "/orders?dry_run=true", strings.NewReader(`{"symbol":"BTCUSD"}`))
+	request.Header.Set(HeaderAPIKey, "ak_1")
+	request.Header.Set(HeaderAPITimestamp, "1700000000")
+	request.Header.Set(HeaderAPINonce, "n-1")
+	request.Header.Set(HeaderAPISignature, "abcd")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"symbol":"BTCUSD"}`, recorder.Body.String())
+	assert.Equal(t, "n-1", authenticator.nonce)
+	assert.Equal(t, "/orders?dry_run=true", authenticator.path)
+	assert.Equal(t, `{"symbol":"BTCUSD"}`, authenticator.body)
+}
+
+func TestAuthMiddleware_Failures(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		err    error
+		code   string
+	}{
+		{"missing key", "", nil, shared.ErrCodeUnauthorized},
+		{"invalid key", "ak_1", shared.ErrInvalidAPIKey, shared.ErrCodeInvalidAPIKey},
+		{"bad signature", "ak_1", shared.ErrInvalidSignature, shared.ErrCodeInvalidSignature},
+		{"expired signature", "ak_1", shared.ErrSignatureExpired, shared.ErrCodeInvalidSignature},
+		{"replayed nonce", "ak_1", shared.ErrSignatureReplay, shared.ErrCodeInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newAuthRouter(&stubAuthenticator{err: tt.err}, shared.APIKeyScopeTrade)
+
+			request := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			if tt.apiKey != "" {
+				request.Header.Set(HeaderAPIKey, tt.apiKey)
+				request.Header.Set(HeaderAPISignature, "abcd")
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			assert.Equal(t, tt.code, errorCode(t, recorder))
+		})
+	}
+}
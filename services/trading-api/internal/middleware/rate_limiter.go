@@ -0,0 +1,328 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"simulated_exchange/pkg/cache"
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/shared"
+)
+
+// RateLimit describes a token bucket: Rate tokens are added per second up
+// to a capacity of Burst tokens
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitResult describes the outcome of a rate limit check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// RateLimitBucket identifies a token bucket and its budget
+type RateLimitBucket struct {
+	Key   string
+	Limit RateLimit
+}
+
+// RateLimiter takes a token from each of a set of buckets
+type RateLimiter interface {
+	// Allow takes a token from every bucket if each of them has one, and from
+	// none otherwise, so that a request rejected by one budget doesn't use up
+	// the others. Results are in the order of buckets; a bucket's result is
+	// allowed if it had a token.
+	Allow(ctx context.Context, buckets []RateLimitBucket) ([]RateLimitResult, error)
+}
+
+// RateLimitRecorder records throttled requests
+type RateLimitRecorder interface {
+	RecordRateLimited(service, scope, endpoint string)
+}
+
+// RateLimitPolicy defines the budgets enforced by the rate limit middleware.
+// Endpoint budgets are keyed by "METHOD /route/pattern" and apply per client.
+// FailOpen allows requests when the limiter fails, e.g. during a cache
+// outage; otherwise they are rejected with 503.
+type RateLimitPolicy struct {
+	PerIP     RateLimit
+	PerKey    RateLimit
+	Endpoints map[string]RateLimit
+	FailOpen  bool
+}
+
+// newResult builds a RateLimitResult from the tokens left in a bucket
+func newResult(allowed bool, tokens float64, limit RateLimit) RateLimitResult {
+	result := RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Max(0, math.Floor(tokens))),
+		ResetAfter: time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+	return result
+}
+
+// tokenBucket is the in-memory state of a single bucket
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// MemoryRateLimiter implements RateLimiter with per-process token buckets.
+// It is suitable for single-replica deployments.
+type MemoryRateLimiter struct {
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	clock     clock.Clock
+	mutex     sync.Mutex
+}
+
+// NewMemoryRateLimiter creates a new in-memory rate limiter
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		clock:     clock.Real(),
+	}
+}
+
+// SetClock sets the clock buckets are refilled by
+func (l *MemoryRateLimiter) SetClock(clk clock.Clock) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.clock = clk
+	l.lastSweep = clk.Now()
+}
+
+// Allow takes a token from every bucket if each of them has one
+func (l *MemoryRateLimiter) Allow(ctx context.Context, buckets []RateLimitBucket) ([]RateLimitResult, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	states := make([]*tokenBucket, len(buckets))
+	allowed := true
+	for i, b := range buckets {
+		bucket, exists := l.buckets[b.Key]
+		if !exists {
+			bucket = &tokenBucket{tokens: float64(b.Limit.Burst), lastRefill: now}
+			l.buckets[b.Key] = bucket
+		}
+
+		elapsed := now.Sub(bucket.lastRefill).Seconds()
+		bucket.tokens = math.Min(float64(b.Limit.Burst), bucket.tokens+elapsed*b.Limit.Rate)
+		bucket.lastRefill = now
+
+		states[i] = bucket
+		allowed = allowed && bucket.tokens >= 1
+	}
+
+	results := make([]RateLimitResult, len(buckets))
+	for i, bucket := range states {
+		if allowed {
+			bucket.tokens--
+		}
+		results[i] = newResult(allowed || bucket.tokens >= 1, bucket.tokens, buckets[i].Limit)
+	}
+
+	return results, nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again.
+// Must be called with the mutex held.
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastRefill) > time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RedisRateLimiter implements RateLimiter with token buckets stored in Redis,
+// so that every replica shares the same budgets
+type RedisRateLimiter struct {
+	client    *cache.RedisClient
+	keyPrefix string
+}
+
+// NewRedisRateLimiter creates a new Redis-backed rate limiter
+func NewRedisRateLimiter(client *cache.RedisClient) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client:    client,
+		keyPrefix: "ratelimit:",
+	}
+}
+
+// Allow takes a token from every bucket if each of them has one
+func (l *RedisRateLimiter) Allow(ctx context.Context, buckets []RateLimitBucket) ([]RateLimitResult, error) {
+	tokenBuckets := make([]cache.TokenBucket, len(buckets))
+	for i, b := range buckets {
+		tokenBuckets[i] = cache.TokenBucket{Key: l.keyPrefix + b.Key, Rate: b.Limit.Rate, Burst: b.Limit.Burst}
+	}
+
+	allowed, tokens, err := l.client.TakeTokens(ctx, tokenBuckets, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RateLimitResult, len(buckets))
+	for i, b := range buckets {
+		results[i] = newResult(allowed || tokens[i] >= 1, tokens[i], b.Limit)
+	}
+	return results, nil
+}
+
+// rateLimitCheck is a single bucket evaluated for a request
+type rateLimitCheck struct {
+	scope string
+	RateLimitBucket
+}
+
+// RateLimitMiddleware enforces per-IP budgets and per-IP endpoint budgets
+func RateLimitMiddleware(limiter RateLimiter, policy RateLimitPolicy, recorder RateLimitRecorder, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientIP := c.ClientIP()
+		checks := []rateLimitCheck{{scope: "ip", RateLimitBucket: RateLimitBucket{Key: "ip:" + clientIP, Limit: policy.PerIP}}}
+
+		endpoint := c.Request.Method + " " + c.FullPath()
+		if limit, ok := policy.Endpoints[endpoint]; ok {
+			checks = append(checks, rateLimitCheck{scope: "endpoint", RateLimitBucket: RateLimitBucket{Key: "endpoint:" + endpoint + ":ip:" + clientIP, Limit: limit}})
+		}
+
+		enforceRateLimits(c, limiter, policy.FailOpen, checks, recorder, logger)
+	}
+}
+
+// APIKeyRateLimitMiddleware enforces per-key budgets and per-key endpoint
+// budgets. It must run after AuthMiddleware and is a no-op for
+// unauthenticated requests.
+func APIKeyRateLimitMiddleware(limiter RateLimiter, policy RateLimitPolicy, recorder RateLimitRecorder, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := GetAuthIdentity(c)
+		if identity == nil {
+			c.Next()
+			return
+		}
+
+		checks := []rateLimitCheck{{scope: "key", RateLimitBucket: RateLimitBucket{Key: "key:" + identity.KeyID, Limit: policy.PerKey}}}
+
+		endpoint := c.Request.Method + " " + c.FullPath()
+		if limit, ok := policy.Endpoints[endpoint]; ok {
+			checks = append(checks, rateLimitCheck{scope: "endpoint", RateLimitBucket: RateLimitBucket{Key: "endpoint:" + endpoint + ":key:" + identity.KeyID, Limit: limit}})
+		}
+
+		enforceRateLimits(c, limiter, policy.FailOpen, checks, recorder, logger)
+	}
+}
+
+// enforceRateLimits takes a token from every bucket of checks, sets the rate
+// limit headers from the most constrained bucket and rejects the request
+// with 429 if any bucket is empty, without taking tokens from the others.
+// When the limiter fails the request is allowed if failOpen is set, so a
+// cache outage doesn't take down the API, and rejected with 503 otherwise.
+func enforceRateLimits(c *gin.Context, limiter RateLimiter, failOpen bool, checks []rateLimitCheck, recorder RateLimitRecorder, logger *slog.Logger) {
+	buckets := make([]RateLimitBucket, len(checks))
+	for i := range checks {
+		buckets[i] = checks[i].RateLimitBucket
+	}
+
+	results, err := limiter.Allow(c.Request.Context(), buckets)
+	if err != nil {
+		if failOpen {
+			logger.Warn("Rate limiter unavailable, allowing request",
+				"error", err,
+				"request_id", c.GetString("request_id"),
+			)
+			c.Next()
+			return
+		}
+
+		logger.Error("Rate limiter unavailable, rejecting request",
+			"error", err,
+			"request_id", c.GetString("request_id"),
+		)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    shared.ErrCodeServiceUnavailable,
+				"message": "Rate limiter unavailable",
+			},
+		})
+		return
+	}
+
+	var tightest *RateLimitResult
+	var denied *rateLimitCheck
+	for i := range results {
+		if !results[i].Allowed {
+			if denied == nil {
+				tightest = &results[i]
+				denied = &checks[i]
+			}
+			continue
+		}
+		if denied == nil && (tightest == nil || results[i].Remaining < tightest.Remaining) {
+			tightest = &results[i]
+		}
+	}
+
+	if tightest != nil {
+		c.Header("X-RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(tightest.ResetAfter).Unix(), 10))
+	}
+
+	if denied == nil {
+		c.Next()
+		return
+	}
+
+	endpoint := c.FullPath()
+	if recorder != nil {
+		recorder.RecordRateLimited("trading-api", denied.scope, endpoint)
+	}
+
+	retryAfter := int(math.Ceil(tightest.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	logger.Debug("Request rate limited",
+		"scope", denied.scope,
+		"endpoint", endpoint,
+		"client_ip", c.ClientIP(),
+		"retry_after", retryAfter,
+	)
+
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    shared.ErrCodeRateLimited,
+			"message": "Rate limit exceeded",
+			"details": "retry after " + strconv.Itoa(retryAfter) + "s (" + denied.scope + " budget)",
+		},
+	})
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/clock"
+	"simulated_exchange/pkg/shared"
+)
+
+func newTestMemoryLimiter() (*MemoryRateLimiter, *clock.Virtual) {
+	limiter := NewMemoryRateLimiter()
+	clk := clock.NewVirtual(time.Unix(1_700_000_000, 0))
+	limiter.SetClock(clk)
+	return limiter, clk
+}
+
+func allowOne(t *testing.T, limiter RateLimiter, key string, limit RateLimit) RateLimitResult {
+	t.Helper()
+	results, err := limiter.Allow(context.Background(), []RateLimitBucket{{Key: key, Limit: limit}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	return results[0]
+}
+
+func TestMemoryRateLimiter_Refill(t *testing.T) {
+	limiter, clk := newTestMemoryLimiter()
+	limit := RateLimit{Rate: 2, Burst: 3}
+
+	// A new bucket starts full
+	for remaining := 2; remaining >= 0; remaining-- {
+		result := allowOne(t, limiter, "ip:1", limit)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result := allowOne(t, limiter, "ip:1", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.ResetAfter)
+
+	// Half a second refills one token at two tokens per second
+	clk.Advance(500 * time.Millisecond)
+	result = allowOne(t, limiter, "ip:1", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Refills stop at the burst
+	clk.Advance(time.Hour)
+	assert.Equal(t, 2, allowOne(t, limiter, "ip:1", limit).Remaining)
+
+	// Buckets are independent
+	assert.Equal(t, 2, allowOne(t, limiter, "ip:2", limit).Remaining)
+}
+
+func TestMemoryRateLimiter_AllOrNothing(t *testing.T) {
+	limiter, _ := newTestMemoryLimiter()
+	ctx := context.Background()
+	wide := RateLimitBucket{Key: "ip:1", Limit: RateLimit{Rate: 1, Burst: 10}}
+	narrow := RateLimitBucket{Key: "endpoint:POST /orders:ip:1", Limit: RateLimit{Rate: 1, Burst: 1}}
+
+	results, err := limiter.Allow(ctx, []RateLimitBucket{wide, narrow})
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+	assert.True(t, results[1].Allowed)
+	assert.Equal(t, 9, results[0].Remaining)
+
+	// The empty endpoint bucket rejects the request without taking a token
+	// from the IP bucket
+	for i := 0; i < 5; i++ {
+		results, err = limiter.Allow(ctx, []RateLimitBucket{wide, narrow})
+		require.NoError(t, err)
+		assert.True(t, results[0].Allowed)
+		assert.False(t, results[1].Allowed)
+		assert.Equal(t, 9, results[0].Remaining)
+	}
+}
+
+// failingLimiter is a RateLimiter whose backend is down
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, buckets []RateLimitBucket) ([]RateLimitResult, error) {
+	return nil, errors.New("connection refused")
+}
+
+// countingRecorder counts throttled requests by scope
+type countingRecorder map[string]int
+
+func (r countingRecorder) RecordRateLimited(service, scope, endpoint string) {
+	r[scope]++
+}
+
+func newRateLimitRouter(limiter RateLimiter, policy RateLimitPolicy, recorder RateLimitRecorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router.Use(RateLimitMiddleware(limiter, policy, recorder, logger))
+	router.POST("/orders", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func send(router *gin.Engine, method string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "/orders", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	limiter, _ := newTestMemoryLimiter()
+	recorder := countingRecorder{}
+	router := newRateLimitRouter(limiter, RateLimitPolicy{
+		PerIP:     RateLimit{Rate: 1, Burst: 5},
+		Endpoints: map[string]RateLimit{"POST /orders": {Rate: 0.5, Burst: 2}},
+	}, recorder)
+
+	// The endpoint budget is the most constrained
+	response := send(router, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Equal(t, "2", response.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", response.Header().Get("X-RateLimit-Remaining"))
+	reset, err := strconv.ParseInt(response.Header().Get("X-RateLimit-Reset"), 10, 64)
+	require.NoError(t, err)
+	assert.Greater(t, reset, int64(0))
+
+	assert.Equal(t, http.StatusCreated, send(router, http.MethodPost).Code)
+
+	response = send(router, http.MethodPost)
+	assert.Equal(t, http.StatusTooManyRequests, response.Code)
+	assert.Equal(t, "2", response.Header().Get("Retry-After"))
+	assert.Equal(t, "0", response.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, shared.ErrCodeRateLimited, errorCode(t, response))
+	assert.Equal(t, 1, recorder["endpoint"])
+
+	// Throttled POSTs left the IP budget to other routes
+	response = send(router, http.MethodGet)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "5", response.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "2", response.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestRateLimitMiddleware_LimiterFailure(t *testing.T) {
+	router := newRateLimitRouter(failingLimiter{}, RateLimitPolicy{
+		PerIP:    RateLimit{Rate: 1, Burst: 1},
+		FailOpen: true,
+	}, nil)
+	response := send(router, http.MethodGet)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, response.Header().Get("X-RateLimit-Limit"))
+
+	router = newRateLimitRouter(failingLimiter{}, RateLimitPolicy{
+		PerIP: RateLimit{Rate: 1, Burst: 1},
+	}, nil)
+	response = send(router, http.MethodGet)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Equal(t, shared.ErrCodeServiceUnavailable, errorCode(t, response))
+}
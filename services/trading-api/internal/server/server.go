@@ -10,45 +10,84 @@ import (
 	"github.com/gin-gonic/gin"
 	"simulated_exchange/pkg/config"
 	"simulated_exchange/pkg/monitoring"
+	"simulated_exchange/pkg/shared"
 	"simulated_exchange/services/trading-api/internal/handlers"
 	"simulated_exchange/services/trading-api/internal/middleware"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	config            *config.Config
-	orderHandler      *handlers.OrderHandler
-	healthHandler     *handlers.HealthHandler
-	metricsHandler    *handlers.MetricsHandler
-	metricsCollector  *monitoring.MetricsCollector
-	logger            *slog.Logger
-	router            *gin.Engine
-	httpServer        *http.Server
-	startTime         time.Time
+	config              *config.Config
+	orderHandler        *handlers.OrderHandler
+	healthHandler       *handlers.HealthHandler
+	metricsHandler      *handlers.MetricsHandler
+	apiKeyHandler       *handlers.APIKeyHandler
+	marketDataHandler   *handlers.MarketDataHandler
+	accountHandler      *handlers.AccountHandler
+	clearingHandler     *handlers.ClearingHandler
+	feeHandler          *handlers.FeeHandler
+	ledgerHandler       *handlers.LedgerHandler
+	mmHandler           *handlers.MarketMakerHandler
+	surveillanceHandler *handlers.SurveillanceHandler
+	auditHandler        *handlers.AuditHandler
+	authenticator       middleware.Authenticator
+	rateLimiter         middleware.RateLimiter
+	metricsCollector    *monitoring.MetricsCollector
+	logger              *slog.Logger
+	router              *gin.Engine
+	httpServer          *http.Server
+	startTime           time.Time
+}
+
+// Dependencies holds the handlers and middleware backends the server routes
+// to. A nil Authenticator disables authentication, a nil RateLimiter disables
+// rate limiting on the API routes and a nil account, clearing, fee, ledger,
+// market maker, surveillance or audit handler disables its routes.
+type Dependencies struct {
+	OrderHandler        *handlers.OrderHandler
+	HealthHandler       *handlers.HealthHandler
+	MetricsHandler      *handlers.MetricsHandler
+	APIKeyHandler       *handlers.APIKeyHandler
+	MarketDataHandler   *handlers.MarketDataHandler
+	AccountHandler      *handlers.AccountHandler
+	ClearingHandler     *handlers.ClearingHandler
+	FeeHandler          *handlers.FeeHandler
+	LedgerHandler       *handlers.LedgerHandler
+	MarketMakerHandler  *handlers.MarketMakerHandler
+	SurveillanceHandler *handlers.SurveillanceHandler
+	AuditHandler        *handlers.AuditHandler
+	Authenticator       middleware.Authenticator
+	RateLimiter         middleware.RateLimiter
+	MetricsCollector    *monitoring.MetricsCollector
+	Logger              *slog.Logger
 }
 
 // NewServer creates a new HTTP server
-func NewServer(
-	config *config.Config,
-	orderHandler *handlers.OrderHandler,
-	healthHandler *handlers.HealthHandler,
-	metricsHandler *handlers.MetricsHandler,
-	metricsCollector *monitoring.MetricsCollector,
-	logger *slog.Logger,
-) *Server {
+func NewServer(config *config.Config, deps Dependencies) *Server {
 	// Set Gin mode based on environment
 	if config.Service.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	server := &Server{
-		config:           config,
-		orderHandler:     orderHandler,
-		healthHandler:    healthHandler,
-		metricsHandler:   metricsHandler,
-		metricsCollector: metricsCollector,
-		logger:           logger,
-		startTime:        time.Now(),
+		config:              config,
+		orderHandler:        deps.OrderHandler,
+		healthHandler:       deps.HealthHandler,
+		metricsHandler:      deps.MetricsHandler,
+		apiKeyHandler:       deps.APIKeyHandler,
+		marketDataHandler:   deps.MarketDataHandler,
+		accountHandler:      deps.AccountHandler,
+		clearingHandler:     deps.ClearingHandler,
+		feeHandler:          deps.FeeHandler,
+		ledgerHandler:       deps.LedgerHandler,
+		mmHandler:           deps.MarketMakerHandler,
+		surveillanceHandler: deps.SurveillanceHandler,
+		auditHandler:        deps.AuditHandler,
+		authenticator:       deps.Authenticator,
+		rateLimiter:         deps.RateLimiter,
+		metricsCollector:    deps.MetricsCollector,
+		logger:              deps.Logger,
+		startTime:           time.Now(),
 	}
 
 	server.setupRouter()
@@ -94,7 +133,9 @@ func (s *Server) setupRouter() {
 	api := s.router.Group("/api")
 	{
 		// Add API middleware
-		api.Use(middleware.RateLimitMiddleware())
+		if s.rateLimiter != nil {
+			api.Use(middleware.RateLimitMiddleware(s.rateLimiter, s.rateLimitPolicy(), s.metricsCollector, s.logger))
+		}
 		api.Use(middleware.ValidationMiddleware())
 
 		// Health endpoints under /api
@@ -103,20 +144,113 @@ func (s *Server) setupRouter() {
 		// Metrics endpoint
 		api.GET("/metrics", s.metricsHandler.GetMetrics)
 
+		// Order book endpoints
+		api.GET("/orderbook/:symbol", s.orderHandler.GetOrderBook)
+
+		// Market data endpoints
+		api.GET("/trades/:symbol", s.marketDataHandler.GetTrades)
+		api.GET("/candles/:symbol", s.marketDataHandler.GetCandles)
+		api.GET("/ticker", s.marketDataHandler.GetTickers)
+
+		// Authenticated endpoints
+		protected := api.Group("")
+		if s.authenticator != nil {
+			protected.Use(middleware.AuthMiddleware(s.authenticator, s.logger))
+			if s.rateLimiter != nil {
+				protected.Use(middleware.APIKeyRateLimitMiddleware(s.rateLimiter, s.rateLimitPolicy(), s.metricsCollector, s.logger))
+			}
+		}
+
 		// Order endpoints
-		orders := api.Group("/orders")
+		orders := protected.Group("/orders")
 		{
-			orders.POST("", s.orderHandler.PlaceOrder)
-			orders.GET("", s.orderHandler.GetOrders) // Add GET all orders
-			orders.GET("/:id", s.orderHandler.GetOrder)
-			orders.DELETE("/:id", s.orderHandler.CancelOrder)
+			orders.POST("", middleware.RequireScope(shared.APIKeyScopeTrade), s.orderHandler.PlaceOrder)
+			orders.POST("/batch", middleware.RequireScope(shared.APIKeyScopeTrade), s.orderHandler.PlaceOrderBatch)
+			orders.DELETE("", middleware.RequireScope(shared.APIKeyScopeTrade), s.orderHandler.CancelOrders)
+			orders.GET("", middleware.RequireScope(shared.APIKeyScopeRead), s.orderHandler.GetOrders) // Add GET all orders
+			orders.GET("/:id", middleware.RequireScope(shared.APIKeyScopeRead), s.orderHandler.GetOrder)
+			orders.GET("/:id/history", middleware.RequireScope(shared.APIKeyScopeRead), s.orderHandler.GetOrderHistory)
+			orders.DELETE("/:id", middleware.RequireScope(shared.APIKeyScopeTrade), s.orderHandler.CancelOrder)
+			orders.PATCH("/:id", middleware.RequireScope(shared.APIKeyScopeTrade), s.orderHandler.AmendOrder)
+			orders.GET("/client/:client_order_id", middleware.RequireScope(shared.APIKeyScopeRead), s.orderHandler.GetOrderByClientID)
+			orders.DELETE("/client/:client_order_id", middleware.RequireScope(shared.APIKeyScopeTrade), s.orderHandler.CancelOrderByClientID)
 		}
 
-		// Order book endpoints
-		api.GET("/orderbook/:symbol", s.orderHandler.GetOrderBook)
-
 		// User order endpoints
-		api.GET("/users/:user_id/orders", s.orderHandler.GetUserOrders)
+		protected.GET("/users/:user_id/orders", middleware.RequireScope(shared.APIKeyScopeRead), s.orderHandler.GetUserOrders)
+
+		// Account endpoints
+		if s.accountHandler != nil {
+			protected.GET("/accounts/:user_id", middleware.RequireScope(shared.APIKeyScopeRead), s.accountHandler.GetAccount)
+			protected.POST("/accounts/:user_id/deposits", middleware.RequireScope(shared.APIKeyScopeAdmin), s.accountHandler.Deposit)
+			protected.POST("/accounts/:user_id/withdrawals", middleware.RequireScope(shared.APIKeyScopeAdmin), s.accountHandler.Withdraw)
+		}
+
+		// Clearing and settlement endpoints
+		if s.clearingHandler != nil {
+			clearing := protected.Group("/clearing")
+			{
+				clearing.GET("/reports/:date", middleware.RequireScope(shared.APIKeyScopeRead), s.clearingHandler.GetReport)
+				clearing.POST("/run", middleware.RequireScope(shared.APIKeyScopeAdmin), s.clearingHandler.RunClearing)
+				clearing.PUT("/failures", middleware.RequireScope(shared.APIKeyScopeAdmin), s.clearingHandler.SetFailRate)
+			}
+		}
+
+		// Ledger endpoints
+		if s.ledgerHandler != nil {
+			ledger := protected.Group("/ledger")
+			{
+				ledger.GET("/invariants", middleware.RequireScope(shared.APIKeyScopeAdmin), s.ledgerHandler.GetInvariants)
+				ledger.GET("/reconciliation", middleware.RequireScope(shared.APIKeyScopeAdmin), s.ledgerHandler.GetReconciliation)
+				ledger.GET("/journals", middleware.RequireScope(shared.APIKeyScopeAdmin), s.ledgerHandler.GetJournals)
+				ledger.GET("/accounts/:user_id/entries", middleware.RequireScope(shared.APIKeyScopeRead), s.ledgerHandler.GetUserEntries)
+			}
+		}
+
+		// Fee endpoints
+		if s.feeHandler != nil {
+			api.GET("/fees/schedule", s.feeHandler.GetSchedules)
+			protected.GET("/fees/users/:user_id", middleware.RequireScope(shared.APIKeyScopeRead), s.feeHandler.GetUserTier)
+			protected.GET("/fees/revenue", middleware.RequireScope(shared.APIKeyScopeAdmin), s.feeHandler.GetRevenue)
+			protected.POST("/fees/kpis", middleware.RequireScope(shared.APIKeyScopeAdmin), s.feeHandler.CalculateKPIs)
+		}
+
+		// Market maker program endpoints
+		if s.mmHandler != nil {
+			marketMakers := protected.Group("/market-makers")
+			{
+				marketMakers.GET("", middleware.RequireScope(shared.APIKeyScopeRead), s.mmHandler.GetMarketMakers)
+				marketMakers.POST("", middleware.RequireScope(shared.APIKeyScopeAdmin), s.mmHandler.Register)
+				marketMakers.DELETE("/:user_id/:symbol", middleware.RequireScope(shared.APIKeyScopeAdmin), s.mmHandler.Deregister)
+				marketMakers.GET("/:user_id/compliance", middleware.RequireScope(shared.APIKeyScopeRead), s.mmHandler.GetComplianceReport)
+			}
+		}
+
+		// Market surveillance endpoints
+		if s.surveillanceHandler != nil {
+			surveillance := protected.Group("/surveillance", middleware.RequireScope(shared.APIKeyScopeAdmin))
+			{
+				surveillance.GET("/alerts", s.surveillanceHandler.GetAlerts)
+				surveillance.GET("/alerts/:id", s.surveillanceHandler.GetAlert)
+				surveillance.PUT("/alerts/:id/status", s.surveillanceHandler.UpdateAlertStatus)
+				surveillance.PUT("/owners/:user_id", s.surveillanceHandler.SetBeneficialOwner)
+			}
+		}
+
+		// Audit trail export endpoints
+		if s.auditHandler != nil {
+			protected.GET("/audit/cat/:date", middleware.RequireScope(shared.APIKeyScopeAdmin), s.auditHandler.ExportCAT)
+		}
+
+		// API key management endpoints (only meaningful with authentication enabled)
+		if s.authenticator != nil && s.apiKeyHandler != nil {
+			keys := protected.Group("/keys")
+			{
+				keys.POST("", s.apiKeyHandler.CreateAPIKey)
+				keys.GET("", s.apiKeyHandler.ListAPIKeys)
+				keys.DELETE("/:id", s.apiKeyHandler.RevokeAPIKey)
+			}
+		}
 	}
 
 	// Service info endpoint
@@ -130,6 +264,23 @@ func (s *Server) setupRouter() {
 	})
 }
 
+// rateLimitPolicy builds the rate limit budgets from configuration
+func (s *Server) rateLimitPolicy() middleware.RateLimitPolicy {
+	cfg := s.config.RateLimit
+
+	endpoints := make(map[string]middleware.RateLimit, len(cfg.Endpoints))
+	for route, limit := range cfg.Endpoints {
+		endpoints[route] = middleware.RateLimit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+
+	return middleware.RateLimitPolicy{
+		PerIP:     middleware.RateLimit{Rate: cfg.IPRate, Burst: cfg.IPBurst},
+		PerKey:    middleware.RateLimit{Rate: cfg.KeyRate, Burst: cfg.KeyBurst},
+		Endpoints: endpoints,
+		FailOpen:  cfg.FailOpen,
+	}
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	addr := s.config.Server.Host + ":" + s.config.Server.Port
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simulated_exchange/pkg/config"
+	"simulated_exchange/pkg/monitoring"
+	"simulated_exchange/pkg/shared"
+	"simulated_exchange/services/trading-api/internal/handlers"
+	"simulated_exchange/services/trading-api/internal/middleware"
+)
+
+const testUserID = "ce77abc4-2496-40b2-a7ad-72c57368879a"
+
+// stubAuthenticator authenticates every key as a fixed identity
+type stubAuthenticator struct {
+	identity *shared.AuthIdentity
+}
+
+func (a *stubAuthenticator) Authenticate(ctx context.Context, apiKey string) (*shared.AuthIdentity, error) {
+	return a.identity, nil
+}
+
+func (a *stubAuthenticator) AuthenticateSigned(ctx context.Context, keyID, timestamp, nonce, signature, method, path string, body []byte) (*shared.AuthIdentity, error) {
+	return a.identity, nil
+}
+
+// stubAccountService records the cash movements it is asked to make
+type stubAccountService struct {
+	moved []string
+}
+
+func (s *stubAccountService) GetAccountSummary(ctx context.Context, userID string) (*shared.AccountSummary, error) {
+	return &shared.AccountSummary{Account: &shared.Account{UserID: userID}}, nil
+}
+
+func (s *stubAccountService) Deposit(ctx context.Context, userID string, amount float64) (*shared.AccountSummary, error) {
+	s.moved = append(s.moved, "deposit")
+	return s.GetAccountSummary(ctx, userID)
+}
+
+func (s *stubAccountService) Withdraw(ctx context.Context, userID string, amount float64) (*shared.AccountSummary, error) {
+	s.moved = append(s.moved, "withdrawal")
+	return s.GetAccountSummary(ctx, userID)
+}
+
+func newTestServer(identity *shared.AuthIdentity, accounts handlers.AccountService) *Server {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewServer(&config.Config{}, Dependencies{
+		AccountHandler:   handlers.NewAccountHandler(accounts, logger),
+		Authenticator:    &stubAuthenticator{identity: identity},
+		MetricsCollector: monitoring.NewMetricsCollector(logger),
+		Logger:           logger,
+	})
+}
+
+func TestServer_CashMovementsRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []shared.APIKeyScope
+		status int
+	}{
+		{"trade scope", []shared.APIKeyScope{shared.APIKeyScopeRead, shared.APIKeyScopeTrade}, http.StatusForbidden},
+		{"admin scope", []shared.APIKeyScope{shared.APIKeyScopeAdmin}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		for _, path := range []string{"deposits", "withdrawals"} {
+			t.Run(tt.name+"/"+path, func(t *testing.T) {
+				accounts := &stubAccountService{}
+				server := newTestServer(&shared.AuthIdentity{UserID: testUserID, KeyID: "key-1", Scopes: tt.scopes}, accounts)
+
+				request := httptest.NewRequest(http.MethodPost, "/api/accounts/"+testUserID+"/"+path, strings.NewReader(`{"amount": 100}`))
+				request.Header.Set("Content-Type", "application/json")
+				request.Header.Set(middleware.HeaderAPIKey, "key")
+				recorder := httptest.NewRecorder()
+				server.router.ServeHTTP(recorder, request)
+
+				require.Equal(t, tt.status, recorder.Code, recorder.Body.String())
+				if tt.status == http.StatusForbidden {
+					var response handlers.APIResponse
+					require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+					assert.Equal(t, shared.ErrCodeInsufficientScope, response.Error.Code)
+					assert.Empty(t, accounts.moved)
+					return
+				}
+				assert.Len(t, accounts.moved, 1)
+			})
+		}
+	}
+}